@@ -113,6 +113,12 @@ func (ctrl CampaignController) Reports(c httpInterfaces.RouterContextInterface)
 		ctrl.Error(c, constants.ErrCodeBadRequest, "请求参数错误: "+err.Error())
 		return
 	}
+	location, err := reportLocation(c)
+	if err != nil {
+		ctrl.Error(c, constants.ErrCodeBadRequest, err.Error())
+		return
+	}
+	reanchorDates(location, req.StartDate, req.EndDate)
 	response, err := service.NewCampaignService(helperPkg.GetHelper()).Reports(middleware.GetCurrentWorkspaceID(c), &req)
 	if err != nil {
 		ctrl.Error(c, constants.ErrCodeInternal, err.Error())
@@ -0,0 +1,125 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/constants"
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/dto"
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/middleware"
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/service"
+	helperPkg "cnb.cool/mliev/dwz/dwz-server/v2/pkg/helper"
+	httpInterfaces "cnb.cool/mliev/open/go-web/pkg/server/http_server/interfaces"
+)
+
+type ClickExportController struct {
+	BaseResponse
+}
+
+// Create 创建异步导出任务，筛选条件与点击统计列表相同
+func (ctrl ClickExportController) Create(c httpInterfaces.RouterContextInterface) {
+	var req dto.ClickStatisticListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		ctrl.Error(c, constants.ErrCodeBadRequest, "请求参数错误: "+err.Error())
+		return
+	}
+	if err := normalizeClickStatisticQueryDateRange(c, &req); err != nil {
+		ctrl.Error(c, constants.ErrCodeBadRequest, err.Error())
+		return
+	}
+	response, err := service.NewClickExportService(helperPkg.GetHelper()).Create(
+		middleware.GetCurrentWorkspaceID(c), middleware.GetCurrentUserID(c), c.Query("format"), &req)
+	if err != nil {
+		ctrl.writeClickExportError(c, err)
+		return
+	}
+	ctrl.Success(c, response)
+}
+
+func (ctrl ClickExportController) List(c httpInterfaces.RouterContextInterface) {
+	response, err := service.NewClickExportService(helperPkg.GetHelper()).List(middleware.GetCurrentWorkspaceID(c))
+	if err != nil {
+		ctrl.Error(c, constants.ErrCodeInternal, err.Error())
+		return
+	}
+	ctrl.Success(c, response)
+}
+
+// Get 查询导出任务状态和进度
+func (ctrl ClickExportController) Get(c httpInterfaces.RouterContextInterface) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		ctrl.Error(c, constants.ErrCodeBadRequest, "无效的ID")
+		return
+	}
+	response, err := service.NewClickExportService(helperPkg.GetHelper()).Get(id, middleware.GetCurrentWorkspaceID(c))
+	if err != nil {
+		ctrl.writeClickExportError(c, err)
+		return
+	}
+	ctrl.Success(c, response)
+}
+
+func (ctrl ClickExportController) Cancel(c httpInterfaces.RouterContextInterface) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		ctrl.Error(c, constants.ErrCodeBadRequest, "无效的ID")
+		return
+	}
+	response, err := service.NewClickExportService(helperPkg.GetHelper()).Cancel(id, middleware.GetCurrentWorkspaceID(c))
+	if err != nil {
+		ctrl.writeClickExportError(c, err)
+		return
+	}
+	ctrl.Success(c, response)
+}
+
+// Download 凭下载令牌下载导出文件，无需登录。文件按分段逐个从数据库读出并写入响应
+func (ctrl ClickExportController) Download(c httpInterfaces.RouterContextInterface) {
+	exportService := service.NewClickExportService(helperPkg.GetHelper())
+	job, err := exportService.Download(c.Param("token"))
+	if err != nil {
+		if errors.Is(err, service.ErrClickExportLinkExpired) {
+			c.Status(http.StatusNotFound)
+			return
+		}
+		ctrl.Error(c, constants.ErrCodeInternal, err.Error())
+		return
+	}
+	stream, ok := streamResponse(c)
+	if !ok {
+		ctrl.Error(c, constants.ErrCodeUnavailable, "当前 HTTP 服务不支持流式响应")
+		return
+	}
+	c.SetHeader("Content-Type", "application/gzip")
+	c.SetHeader("Content-Length", strconv.FormatInt(job.FileSize, 10))
+	c.SetHeader("Content-Disposition", `attachment; filename="`+job.FileName+`"`)
+	c.SetHeader("Cache-Control", "private, no-store")
+	seq := -1
+	stream(func(w io.Writer) bool {
+		seq, err = exportService.WriteChunk(w, job.ID, seq)
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				helperPkg.GetHelper().GetLogger().Warn(fmt.Sprintf("[click-export] 下载任务 %d 的分段失败: %s", job.ID, err.Error()))
+			}
+			return false
+		}
+		return true
+	})
+}
+
+func (ctrl ClickExportController) writeClickExportError(c httpInterfaces.RouterContextInterface, err error) {
+	switch {
+	case errors.Is(err, service.ErrClickExportNotFound):
+		ctrl.Error(c, constants.ErrCodeNotFound, err.Error())
+	case errors.Is(err, service.ErrClickExportFormat):
+		ctrl.Error(c, constants.ErrCodeBadRequest, err.Error())
+	case errors.Is(err, service.ErrClickExportTooManyActive), errors.Is(err, service.ErrClickExportNotCancellable):
+		ctrl.Error(c, constants.ErrCodeConflict, err.Error())
+	default:
+		ctrl.Error(c, constants.ErrCodeInternal, err.Error())
+	}
+}
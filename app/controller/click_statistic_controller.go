@@ -1,7 +1,9 @@
 package controller
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"strconv"
 	"strings"
@@ -83,6 +85,23 @@ func (ctrl ClickStatisticController) GetClickStatisticGeoAnalysis(c httpInterfac
 	ctrl.Success(c, response)
 }
 
+// GetReferrerChannels 按来源渠道统计点击
+func (ctrl ClickStatisticController) GetReferrerChannels(c httpInterfaces.RouterContextInterface) {
+	filterReq, days, err := buildClickStatisticAnalysisRequest(c)
+	if err != nil {
+		ctrl.Error(c, constants.ErrCodeBadRequest, err.Error())
+		return
+	}
+
+	response, err := service.NewClickStatisticService(helperPkg.GetHelper()).GetReferrerChannelBreakdownInWorkspace(middleware.GetCurrentWorkspaceID(c), filterReq, days)
+	if err != nil {
+		ctrl.Error(c, constants.ErrCodeInternal, err.Error())
+		return
+	}
+
+	ctrl.Success(c, response)
+}
+
 func (ctrl ClickStatisticController) ExportCSV(c httpInterfaces.RouterContextInterface) {
 	helper := helperPkg.GetHelper()
 	var req dto.ClickStatisticListRequest
@@ -104,6 +123,75 @@ func (ctrl ClickStatisticController) ExportCSV(c httpInterfaces.RouterContextInt
 	c.Data(http.StatusOK, "text/csv; charset=utf-8", data)
 }
 
+// RebuildRollups 提交后台任务，根据点击明细重建当前工作区的点击汇总
+func (ctrl ClickStatisticController) RebuildRollups(c httpInterfaces.RouterContextInterface) {
+	if !middleware.CanManageAdminResource(c) {
+		ctrl.Error(c, constants.ErrCodeForbidden, "无权限重建点击汇总")
+		return
+	}
+	var req dto.ClickRollupRebuildRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		ctrl.Error(c, constants.ErrCodeBadRequest, "请求参数错误: "+err.Error())
+		return
+	}
+	location := time.Now().Location()
+	var start, end time.Time
+	if req.StartDate != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", req.StartDate, location)
+		if err != nil {
+			ctrl.Error(c, constants.ErrCodeBadRequest, "开始日期格式错误")
+			return
+		}
+		start = parsed
+	}
+	if req.EndDate != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", req.EndDate, location)
+		if err != nil {
+			ctrl.Error(c, constants.ErrCodeBadRequest, "结束日期格式错误")
+			return
+		}
+		end = parsed.AddDate(0, 0, 1)
+	}
+	if !start.IsZero() && !end.IsZero() && !end.After(start) {
+		ctrl.Error(c, constants.ErrCodeBadRequest, "结束日期不能早于开始日期")
+		return
+	}
+
+	response, err := service.NewClickRollupService(helperPkg.GetHelper()).CreateRebuildJob(middleware.GetCurrentWorkspaceID(c), middleware.GetCurrentUserID(c), start, end)
+	if err != nil {
+		if errors.Is(err, service.ErrClickRollupRebuildActive) {
+			ctrl.Error(c, constants.ErrCodeConflict, err.Error())
+			return
+		}
+		ctrl.Error(c, constants.ErrCodeInternal, err.Error())
+		return
+	}
+	ctrl.Success(c, response)
+}
+
+// GetRollupRebuild 查询点击汇总重建任务的进度
+func (ctrl ClickStatisticController) GetRollupRebuild(c httpInterfaces.RouterContextInterface) {
+	if !middleware.CanManageAdminResource(c) {
+		ctrl.Error(c, constants.ErrCodeForbidden, "无权限查看点击汇总重建任务")
+		return
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		ctrl.Error(c, constants.ErrCodeBadRequest, "无效的ID")
+		return
+	}
+	response, err := service.NewClickRollupService(helperPkg.GetHelper()).GetRebuildJob(id, middleware.GetCurrentWorkspaceID(c))
+	if err != nil {
+		if errors.Is(err, service.ErrClickRollupRebuildNotFound) {
+			ctrl.Error(c, constants.ErrCodeNotFound, err.Error())
+			return
+		}
+		ctrl.Error(c, constants.ErrCodeInternal, err.Error())
+		return
+	}
+	ctrl.Success(c, response)
+}
+
 func parseUintQuery(value string) uint64 {
 	if value == "" {
 		return 0
@@ -145,23 +233,52 @@ func buildClickStatisticAnalysisRequest(c httpInterfaces.RouterContextInterface)
 			filterReq.IsBot = &isBot
 		}
 	}
-
-	if c.Query("start_date") != "" || c.Query("end_date") != "" {
-		if c.Query("start_date") == "" || c.Query("end_date") == "" {
-			return nil, 0, fmt.Errorf("开始日期和结束日期必须同时提供")
-		}
-		if err := normalizeClickStatisticQueryDateRange(c, filterReq); err != nil {
-			return nil, 0, err
+	for key, target := range map[string]**int{"min_bot_score": &filterReq.MinBotScore, "max_bot_score": &filterReq.MaxBotScore} {
+		if value := c.Query(key); value != "" {
+			score, err := strconv.Atoi(value)
+			if err != nil || score < 0 || score > 100 {
+				return nil, 0, fmt.Errorf("机器人评分必须为 0-100 的整数")
+			}
+			*target = &score
 		}
 	}
+	filterReq.BotReason = c.Query("bot_reason")
+	filterReq.WithDuplicates, _ = strconv.ParseBool(c.Query("include_duplicates"))
+
+	if (c.Query("start_date") == "") != (c.Query("end_date") == "") {
+		return nil, 0, fmt.Errorf("开始日期和结束日期必须同时提供")
+	}
+	if err := normalizeClickStatisticQueryDateRange(c, filterReq); err != nil {
+		return nil, 0, err
+	}
 
 	return filterReq, days, nil
 }
 
+// reportLocation 报表时区：请求参数 tz 优先，其次为当前工作区时区
+func reportLocation(c httpInterfaces.RouterContextInterface) (*time.Location, error) {
+	return service.ReportLocation(helperPkg.GetHelper(), middleware.GetCurrentWorkspaceID(c), c.Query("tz"))
+}
+
+// reanchorDates 将按服务器时区绑定的日期换算为报表时区中同一天的零点
+func reanchorDates(location *time.Location, dates ...*time.Time) {
+	for _, date := range dates {
+		if date != nil && !date.IsZero() {
+			*date = time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, location)
+		}
+	}
+}
+
+// normalizeClickStatisticQueryDateRange 解析报表时区，并按该时区将日期换算为时间范围
 func normalizeClickStatisticQueryDateRange(c httpInterfaces.RouterContextInterface, req *dto.ClickStatisticListRequest) error {
 	startDateStr := c.Query("start_date")
 	endDateStr := c.Query("end_date")
-	location := time.Now().Location()
+	location, err := reportLocation(c)
+	if err != nil {
+		return err
+	}
+	req.Location = location
+	req.Timezone = location.String()
 
 	if startDateStr != "" {
 		startDate, err := time.ParseInLocation("2006-01-02", startDateStr, location)
@@ -0,0 +1,113 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/constants"
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/dto"
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/middleware"
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/service"
+	helperPkg "cnb.cool/mliev/dwz/dwz-server/v2/pkg/helper"
+	httpInterfaces "cnb.cool/mliev/open/go-web/pkg/server/http_server/interfaces"
+)
+
+// clickStreamHeartbeat 心跳间隔，同时用于刷新按标签订阅的短网址
+const clickStreamHeartbeat = 15 * time.Second
+
+// streamingContext 支持流式响应的请求上下文，每一步写入后立即刷新到客户端
+type streamingContext interface {
+	Stream(step func(w io.Writer) bool) bool
+}
+
+// writerContext 暴露底层 ResponseWriter 的请求上下文
+type writerContext interface {
+	Writer() http.ResponseWriter
+}
+
+// streamResponse 返回逐步写入响应的函数：优先使用上下文自带的 Stream，
+// 否则直接写 ResponseWriter 并在每一步后 Flush；两者都不支持时返回 false
+func streamResponse(c httpInterfaces.RouterContextInterface) (func(step func(w io.Writer) bool), bool) {
+	if streamer, ok := c.(streamingContext); ok {
+		return func(step func(w io.Writer) bool) { streamer.Stream(step) }, true
+	}
+	if withWriter, ok := c.(writerContext); ok {
+		writer := withWriter.Writer()
+		if flusher, ok := writer.(http.Flusher); ok {
+			return func(step func(w io.Writer) bool) {
+				for step(writer) {
+					flusher.Flush()
+				}
+			}, true
+		}
+	}
+	return nil, false
+}
+
+type ClickStreamController struct {
+	BaseResponse
+}
+
+// Stream 以 Server-Sent Events 推送当前工作区的实时点击
+func (ctrl ClickStreamController) Stream(c httpInterfaces.RouterContextInterface) {
+	stream, ok := streamResponse(c)
+	if !ok {
+		ctrl.Error(c, constants.ErrCodeUnavailable, "当前 HTTP 服务不支持流式响应")
+		return
+	}
+	var req dto.ClickStreamRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		ctrl.Error(c, constants.ErrCodeBadRequest, bindErrorMessage(err))
+		return
+	}
+
+	subscription, err := service.NewClickStreamService(helperPkg.GetHelper()).Subscribe(middleware.GetCurrentWorkspaceID(c), &req)
+	if err != nil {
+		if errors.Is(err, service.ErrClickStreamTooManySubscribers) {
+			ctrl.Error(c, constants.ErrCodeConflict, err.Error())
+			return
+		}
+		ctrl.Error(c, constants.ErrCodeInternal, err.Error())
+		return
+	}
+	defer subscription.Close()
+
+	c.SetHeader("Content-Type", "text/event-stream; charset=utf-8")
+	c.SetHeader("Cache-Control", "no-cache")
+	c.SetHeader("Connection", "keep-alive")
+	c.SetHeader("X-Accel-Buffering", "no")
+
+	done := c.Request().Context().Done()
+	heartbeat := time.NewTicker(clickStreamHeartbeat)
+	defer heartbeat.Stop()
+	started := false
+	stream(func(w io.Writer) bool {
+		if !started {
+			started = true
+			_, err := fmt.Fprint(w, "retry: 3000\nevent: ready\ndata: {}\n\n")
+			return err == nil
+		}
+		select {
+		case <-done:
+			return false
+		case event, ok := <-subscription.Events():
+			if !ok {
+				return false
+			}
+			payload, err := json.Marshal(event)
+			if err != nil {
+				return true
+			}
+			_, err = fmt.Fprintf(w, "id: %d\nevent: click\ndata: %s\n\n", event.ID, payload)
+			return err == nil
+		case <-heartbeat.C:
+			_ = subscription.Refresh()
+			_, err := fmt.Fprintf(w, ": keep-alive dropped=%d\n\n", subscription.Dropped())
+			return err == nil
+		}
+	})
+}
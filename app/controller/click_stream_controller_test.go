@@ -0,0 +1,65 @@
+package controller
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	httpInterfaces "cnb.cool/mliev/open/go-web/pkg/server/http_server/interfaces"
+)
+
+type plainRouterContext struct {
+	httpInterfaces.RouterContextInterface
+}
+
+type writerRouterContext struct {
+	httpInterfaces.RouterContextInterface
+	writer http.ResponseWriter
+}
+
+func (c writerRouterContext) Writer() http.ResponseWriter { return c.writer }
+
+type streamingRouterContext struct {
+	httpInterfaces.RouterContextInterface
+	steps int
+}
+
+func (c *streamingRouterContext) Stream(step func(w io.Writer) bool) bool {
+	for step(io.Discard) {
+		c.steps++
+	}
+	return false
+}
+
+func TestStreamResponseFallsBackToFlushingWriter(t *testing.T) {
+	if _, ok := streamResponse(plainRouterContext{}); ok {
+		t.Fatal("context without Stream or Writer must not support streaming")
+	}
+
+	recorder := httptest.NewRecorder()
+	stream, ok := streamResponse(writerRouterContext{writer: recorder})
+	if !ok {
+		t.Fatal("context exposing a flushable writer must support streaming")
+	}
+	count := 0
+	stream(func(w io.Writer) bool {
+		count++
+		fmt.Fprintf(w, "data: %d\n\n", count)
+		return count < 3
+	})
+	if recorder.Body.String() != "data: 1\n\ndata: 2\n\ndata: 3\n\n" || !recorder.Flushed {
+		t.Fatalf("unexpected streamed body %q flushed=%v", recorder.Body.String(), recorder.Flushed)
+	}
+
+	streaming := &streamingRouterContext{}
+	stream, ok = streamResponse(streaming)
+	if !ok {
+		t.Fatal("context with Stream must support streaming")
+	}
+	stream(func(io.Writer) bool { return streaming.steps < 2 })
+	if streaming.steps != 2 {
+		t.Fatalf("Stream must drive the steps, got %d", streaming.steps)
+	}
+}
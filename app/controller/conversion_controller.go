@@ -0,0 +1,136 @@
+package controller
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/constants"
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/dto"
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/middleware"
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/service"
+	helperPkg "cnb.cool/mliev/dwz/dwz-server/v2/pkg/helper"
+	httpInterfaces "cnb.cool/mliev/open/go-web/pkg/server/http_server/interfaces"
+)
+
+const maxConversionPostbackBytes = 64 << 10
+
+// conversionPixelGIF 1x1 透明 GIF
+var conversionPixelGIF = []byte{
+	0x47, 0x49, 0x46, 0x38, 0x39, 0x61, 0x01, 0x00, 0x01, 0x00, 0x80, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0xff, 0xff, 0xff, 0x21, 0xf9, 0x04, 0x01, 0x00, 0x00, 0x00, 0x00, 0x2c, 0x00, 0x00, 0x00, 0x00,
+	0x01, 0x00, 0x01, 0x00, 0x00, 0x02, 0x02, 0x44, 0x01, 0x00, 0x3b,
+}
+
+type ConversionController struct {
+	BaseResponse
+}
+
+// Postback 公开服务端转化回传接口，使用工作区密钥签名
+func (ctrl ConversionController) Postback(c httpInterfaces.RouterContextInterface) {
+	body, err := io.ReadAll(io.LimitReader(c.Request().Body, maxConversionPostbackBytes+1))
+	if err != nil || len(body) > maxConversionPostbackBytes {
+		ctrl.Error(c, constants.ErrCodeBadRequest, service.ErrConversionBadRequest.Error())
+		return
+	}
+	response, err := service.NewConversionService(helperPkg.GetHelper()).RecordPostback(
+		body,
+		c.GetHeader(service.ConversionTimestampHeader),
+		c.GetHeader(service.ConversionSignatureHeader),
+		c.ClientIP(),
+		c.GetHeader("User-Agent"),
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrConversionBadRequest), errors.Is(err, service.ErrConversionClickNotFound), errors.Is(err, service.ErrConversionOutsideWindow):
+			ctrl.Error(c, constants.ErrCodeBadRequest, err.Error())
+		case errors.Is(err, service.ErrConversionInvalidSignature), errors.Is(err, service.ErrConversionExpiredSignature):
+			ctrl.Error(c, constants.ErrCodeUnauthorized, err.Error())
+		default:
+			ctrl.Error(c, constants.ErrCodeInternal, err.Error())
+		}
+		return
+	}
+	ctrl.Success(c, response)
+}
+
+// Pixel 公开像素转化回传接口，无论是否记录成功都返回透明像素
+func (ctrl ConversionController) Pixel(c httpInterfaces.RouterContextInterface) {
+	var req dto.ConversionRequest
+	if err := c.ShouldBindQuery(&req); err == nil {
+		// 像素接口无需认证，参数无效、点击ID无效等由请求方造成的失败不记录日志，避免被刷日志
+		_, err := service.NewConversionService(helperPkg.GetHelper()).RecordPixel(&req, c.ClientIP(), c.GetHeader("User-Agent"))
+		if err != nil && !errors.Is(err, service.ErrConversionBadRequest) && !errors.Is(err, service.ErrConversionClickNotFound) &&
+			!errors.Is(err, service.ErrConversionOutsideWindow) {
+			helperPkg.GetHelper().GetLogger().Warn("像素转化回传失败: " + err.Error())
+		}
+	}
+	c.SetHeader("Cache-Control", "no-store, no-cache, must-revalidate")
+	c.Data(http.StatusOK, "image/gif", conversionPixelGIF)
+}
+
+func (ctrl ConversionController) Report(c httpInterfaces.RouterContextInterface) {
+	var req dto.ConversionReportRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		ctrl.Error(c, constants.ErrCodeBadRequest, bindErrorMessage(err))
+		return
+	}
+	location, err := reportLocation(c)
+	if err != nil {
+		ctrl.Error(c, constants.ErrCodeBadRequest, err.Error())
+		return
+	}
+	if value := c.Query("start_date"); value != "" {
+		startDate, err := time.ParseInLocation("2006-01-02", value, location)
+		if err != nil {
+			ctrl.Error(c, constants.ErrCodeBadRequest, "开始日期格式错误")
+			return
+		}
+		req.StartDate = startDate
+	}
+	if value := c.Query("end_date"); value != "" {
+		endDate, err := time.ParseInLocation("2006-01-02", value, location)
+		if err != nil {
+			ctrl.Error(c, constants.ErrCodeBadRequest, "结束日期格式错误")
+			return
+		}
+		req.EndDate = endDate.AddDate(0, 0, 1)
+	}
+	if !req.StartDate.IsZero() && !req.EndDate.IsZero() && !req.EndDate.After(req.StartDate) {
+		ctrl.Error(c, constants.ErrCodeBadRequest, "结束日期不能早于开始日期")
+		return
+	}
+	response, err := service.NewConversionService(helperPkg.GetHelper()).Report(middleware.GetCurrentWorkspaceID(c), &req)
+	if err != nil {
+		ctrl.Error(c, constants.ErrCodeInternal, err.Error())
+		return
+	}
+	ctrl.Success(c, response)
+}
+
+func (ctrl ConversionController) GetPostbackSettings(c httpInterfaces.RouterContextInterface) {
+	if !middleware.CanManageAdminResource(c) {
+		ctrl.Error(c, constants.ErrCodeForbidden, "无权限查看转化回传设置")
+		return
+	}
+	response, err := service.NewConversionService(helperPkg.GetHelper()).GetPostbackSettings(middleware.GetCurrentWorkspaceID(c))
+	if err != nil {
+		ctrl.Error(c, constants.ErrCodeInternal, err.Error())
+		return
+	}
+	ctrl.Success(c, response)
+}
+
+func (ctrl ConversionController) RotatePostbackSecret(c httpInterfaces.RouterContextInterface) {
+	if !middleware.CanManageAdminResource(c) {
+		ctrl.Error(c, constants.ErrCodeForbidden, "无权限修改转化回传设置")
+		return
+	}
+	response, err := service.NewConversionService(helperPkg.GetHelper()).RotateSecret(middleware.GetCurrentWorkspaceID(c))
+	if err != nil {
+		ctrl.Error(c, constants.ErrCodeInternal, err.Error())
+		return
+	}
+	ctrl.Success(c, response)
+}
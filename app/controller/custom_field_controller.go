@@ -0,0 +1,116 @@
+package controller
+
+import (
+	"strconv"
+	"strings"
+
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/constants"
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/dto"
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/middleware"
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/service"
+	helperPkg "cnb.cool/mliev/dwz/dwz-server/v2/pkg/helper"
+	httpInterfaces "cnb.cool/mliev/open/go-web/pkg/server/http_server/interfaces"
+)
+
+type CustomFieldController struct {
+	BaseResponse
+}
+
+func (ctrl CustomFieldController) Create(c httpInterfaces.RouterContextInterface) {
+	if !middleware.CanManageAdminResource(c) {
+		ctrl.Error(c, constants.ErrCodeForbidden, "无权限创建自定义字段")
+		return
+	}
+	var req dto.CustomFieldRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ctrl.Error(c, constants.ErrCodeBadRequest, bindErrorMessage(err))
+		return
+	}
+	response, err := service.NewCustomFieldService(helperPkg.GetHelper()).Create(middleware.GetCurrentWorkspaceID(c), middleware.GetCurrentUserID(c), &req)
+	if err != nil {
+		ctrl.writeCustomFieldError(c, err)
+		return
+	}
+	ctrl.Success(c, response)
+}
+
+func (ctrl CustomFieldController) List(c httpInterfaces.RouterContextInterface) {
+	response, err := service.NewCustomFieldService(helperPkg.GetHelper()).List(middleware.GetCurrentWorkspaceID(c))
+	if err != nil {
+		ctrl.Error(c, constants.ErrCodeInternal, err.Error())
+		return
+	}
+	ctrl.Success(c, response)
+}
+
+func (ctrl CustomFieldController) Get(c httpInterfaces.RouterContextInterface) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		ctrl.Error(c, constants.ErrCodeBadRequest, "无效的ID")
+		return
+	}
+	response, err := service.NewCustomFieldService(helperPkg.GetHelper()).Get(id, middleware.GetCurrentWorkspaceID(c))
+	if err != nil {
+		ctrl.writeCustomFieldError(c, err)
+		return
+	}
+	ctrl.Success(c, response)
+}
+
+func (ctrl CustomFieldController) Update(c httpInterfaces.RouterContextInterface) {
+	if !middleware.CanManageAdminResource(c) {
+		ctrl.Error(c, constants.ErrCodeForbidden, "无权限更新自定义字段")
+		return
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		ctrl.Error(c, constants.ErrCodeBadRequest, "无效的ID")
+		return
+	}
+	var req dto.CustomFieldRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ctrl.Error(c, constants.ErrCodeBadRequest, bindErrorMessage(err))
+		return
+	}
+	response, err := service.NewCustomFieldService(helperPkg.GetHelper()).Update(id, middleware.GetCurrentWorkspaceID(c), middleware.GetCurrentUserID(c), &req)
+	if err != nil {
+		ctrl.writeCustomFieldError(c, err)
+		return
+	}
+	ctrl.Success(c, response)
+}
+
+func (ctrl CustomFieldController) Delete(c httpInterfaces.RouterContextInterface) {
+	if !middleware.CanManageAdminResource(c) {
+		ctrl.Error(c, constants.ErrCodeForbidden, "无权限删除自定义字段")
+		return
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		ctrl.Error(c, constants.ErrCodeBadRequest, "无效的ID")
+		return
+	}
+	if err := service.NewCustomFieldService(helperPkg.GetHelper()).Delete(id, middleware.GetCurrentWorkspaceID(c)); err != nil {
+		ctrl.writeCustomFieldError(c, err)
+		return
+	}
+	ctrl.SuccessWithMessage(c, "删除成功", nil)
+}
+
+func (ctrl CustomFieldController) writeCustomFieldError(c httpInterfaces.RouterContextInterface, err error) {
+	message := err.Error()
+	switch {
+	case message == "自定义字段不存在":
+		ctrl.Error(c, constants.ErrCodeNotFound, message)
+	case strings.Contains(message, "已存在"):
+		ctrl.Error(c, constants.ErrCodeConflict, message)
+	case strings.Contains(message, "不存在"),
+		strings.Contains(message, "不能"),
+		strings.Contains(message, "不支持"),
+		strings.Contains(message, "无效"),
+		strings.Contains(message, "至少需要"):
+		ctrl.Error(c, constants.ErrCodeBadRequest, message)
+	default:
+		ctrl.Error(c, constants.ErrCodeInternal, message)
+	}
+}
@@ -0,0 +1,123 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/constants"
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/dto"
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/middleware"
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/service"
+	helperPkg "cnb.cool/mliev/dwz/dwz-server/v2/pkg/helper"
+	httpInterfaces "cnb.cool/mliev/open/go-web/pkg/server/http_server/interfaces"
+)
+
+// DataSubjectController 按访客IP或访客标识查询、导出、删除个人数据
+type DataSubjectController struct {
+	BaseResponse
+}
+
+// Search 统计各数据表中匹配的记录数
+func (ctrl DataSubjectController) Search(c httpInterfaces.RouterContextInterface) {
+	if !middleware.CanManageAdminResource(c) {
+		ctrl.Error(c, constants.ErrCodeForbidden, "无权限查询个人数据")
+		return
+	}
+	var req dto.DataSubjectQuery
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ctrl.Error(c, constants.ErrCodeBadRequest, bindErrorMessage(err))
+		return
+	}
+	response, err := service.NewDataSubjectService(helperPkg.GetHelper()).Search(middleware.GetCurrentWorkspaceID(c), &req)
+	if err != nil {
+		ctrl.writeDataSubjectError(c, err)
+		return
+	}
+	ctrl.Success(c, response)
+}
+
+// Export 导出匹配的完整记录
+func (ctrl DataSubjectController) Export(c httpInterfaces.RouterContextInterface) {
+	if !middleware.CanManageAdminResource(c) {
+		ctrl.Error(c, constants.ErrCodeForbidden, "无权限导出个人数据")
+		return
+	}
+	var req dto.DataSubjectQuery
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ctrl.Error(c, constants.ErrCodeBadRequest, bindErrorMessage(err))
+		return
+	}
+	data, err := service.NewDataSubjectService(helperPkg.GetHelper()).Export(middleware.GetCurrentWorkspaceID(c), &req)
+	if err != nil {
+		ctrl.writeDataSubjectError(c, err)
+		return
+	}
+	filename := fmt.Sprintf("data-subject-%s.json", time.Now().Format("20060102150405"))
+	c.SetHeader("Content-Disposition", `attachment; filename="`+filename+`"`)
+	c.SetHeader("Cache-Control", "private, no-store")
+	c.Data(http.StatusOK, "application/json; charset=utf-8", data)
+}
+
+func (ctrl DataSubjectController) List(c httpInterfaces.RouterContextInterface) {
+	if !middleware.CanManageAdminResource(c) {
+		ctrl.Error(c, constants.ErrCodeForbidden, "无权限查看个人数据请求")
+		return
+	}
+	response, err := service.NewDataSubjectService(helperPkg.GetHelper()).List(middleware.GetCurrentWorkspaceID(c))
+	if err != nil {
+		ctrl.Error(c, constants.ErrCodeInternal, err.Error())
+		return
+	}
+	ctrl.Success(c, response)
+}
+
+// Create 提交删除或匿名化任务，由后台任务执行并在操作日志中记录回执
+func (ctrl DataSubjectController) Create(c httpInterfaces.RouterContextInterface) {
+	if !middleware.CanManageAdminResource(c) {
+		ctrl.Error(c, constants.ErrCodeForbidden, "无权限删除个人数据")
+		return
+	}
+	var req dto.DataSubjectEraseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ctrl.Error(c, constants.ErrCodeBadRequest, bindErrorMessage(err))
+		return
+	}
+	response, err := service.NewDataSubjectService(helperPkg.GetHelper()).CreateErasure(middleware.GetCurrentWorkspaceID(c), middleware.GetCurrentUserID(c), &req)
+	if err != nil {
+		ctrl.writeDataSubjectError(c, err)
+		return
+	}
+	ctrl.Success(c, response)
+}
+
+func (ctrl DataSubjectController) Get(c httpInterfaces.RouterContextInterface) {
+	if !middleware.CanManageAdminResource(c) {
+		ctrl.Error(c, constants.ErrCodeForbidden, "无权限查看个人数据请求")
+		return
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		ctrl.Error(c, constants.ErrCodeBadRequest, "无效的ID")
+		return
+	}
+	response, err := service.NewDataSubjectService(helperPkg.GetHelper()).Get(id, middleware.GetCurrentWorkspaceID(c))
+	if err != nil {
+		ctrl.writeDataSubjectError(c, err)
+		return
+	}
+	ctrl.Success(c, response)
+}
+
+func (ctrl DataSubjectController) writeDataSubjectError(c httpInterfaces.RouterContextInterface, err error) {
+	switch {
+	case errors.Is(err, service.ErrDataSubjectQueryEmpty):
+		ctrl.Error(c, constants.ErrCodeBadRequest, err.Error())
+	case errors.Is(err, service.ErrDataSubjectRequestNotFound):
+		ctrl.Error(c, constants.ErrCodeNotFound, err.Error())
+	default:
+		ctrl.Error(c, constants.ErrCodeInternal, err.Error())
+	}
+}
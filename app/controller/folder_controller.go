@@ -0,0 +1,147 @@
+package controller
+
+import (
+	"strconv"
+	"strings"
+
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/constants"
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/dto"
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/middleware"
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/service"
+	helperPkg "cnb.cool/mliev/dwz/dwz-server/v2/pkg/helper"
+	httpInterfaces "cnb.cool/mliev/open/go-web/pkg/server/http_server/interfaces"
+)
+
+type FolderController struct {
+	BaseResponse
+}
+
+func (ctrl FolderController) Create(c httpInterfaces.RouterContextInterface) {
+	if !middleware.CanManageBusinessResource(c) {
+		ctrl.Error(c, constants.ErrCodeForbidden, "无权限创建文件夹")
+		return
+	}
+	var req dto.FolderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ctrl.Error(c, constants.ErrCodeBadRequest, bindErrorMessage(err))
+		return
+	}
+	response, err := service.NewFolderService(helperPkg.GetHelper()).Create(middleware.GetCurrentWorkspaceID(c), middleware.GetCurrentUserID(c), &req)
+	if err != nil {
+		ctrl.writeFolderError(c, err)
+		return
+	}
+	ctrl.Success(c, response)
+}
+
+func (ctrl FolderController) List(c httpInterfaces.RouterContextInterface) {
+	var req dto.FolderListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		ctrl.Error(c, constants.ErrCodeBadRequest, bindErrorMessage(err))
+		return
+	}
+	response, err := service.NewFolderService(helperPkg.GetHelper()).List(middleware.GetCurrentWorkspaceID(c), &req)
+	if err != nil {
+		ctrl.Error(c, constants.ErrCodeInternal, err.Error())
+		return
+	}
+	ctrl.Success(c, response)
+}
+
+func (ctrl FolderController) Get(c httpInterfaces.RouterContextInterface) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		ctrl.Error(c, constants.ErrCodeBadRequest, "无效的ID")
+		return
+	}
+	response, err := service.NewFolderService(helperPkg.GetHelper()).Get(id, middleware.GetCurrentWorkspaceID(c))
+	if err != nil {
+		ctrl.writeFolderError(c, err)
+		return
+	}
+	ctrl.Success(c, response)
+}
+
+func (ctrl FolderController) Update(c httpInterfaces.RouterContextInterface) {
+	if !middleware.CanManageBusinessResource(c) {
+		ctrl.Error(c, constants.ErrCodeForbidden, "无权限更新文件夹")
+		return
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		ctrl.Error(c, constants.ErrCodeBadRequest, "无效的ID")
+		return
+	}
+	var req dto.FolderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ctrl.Error(c, constants.ErrCodeBadRequest, bindErrorMessage(err))
+		return
+	}
+	response, err := service.NewFolderService(helperPkg.GetHelper()).Update(id, middleware.GetCurrentWorkspaceID(c), &req)
+	if err != nil {
+		ctrl.writeFolderError(c, err)
+		return
+	}
+	ctrl.Success(c, response)
+}
+
+func (ctrl FolderController) Delete(c httpInterfaces.RouterContextInterface) {
+	if !middleware.CanManageBusinessResource(c) {
+		ctrl.Error(c, constants.ErrCodeForbidden, "无权限删除文件夹")
+		return
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		ctrl.Error(c, constants.ErrCodeBadRequest, "无效的ID")
+		return
+	}
+	if err := service.NewFolderService(helperPkg.GetHelper()).Delete(id, middleware.GetCurrentWorkspaceID(c)); err != nil {
+		ctrl.writeFolderError(c, err)
+		return
+	}
+	ctrl.SuccessWithMessage(c, "删除成功", nil)
+}
+
+// Statistics 文件夹聚合统计
+func (ctrl FolderController) Statistics(c httpInterfaces.RouterContextInterface) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		ctrl.Error(c, constants.ErrCodeBadRequest, "无效的ID")
+		return
+	}
+	var req dto.FolderStatisticsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		ctrl.Error(c, constants.ErrCodeBadRequest, bindErrorMessage(err))
+		return
+	}
+	location, err := reportLocation(c)
+	if err != nil {
+		ctrl.Error(c, constants.ErrCodeBadRequest, err.Error())
+		return
+	}
+	reanchorDates(location, &req.StartDate, &req.EndDate)
+	req.Location = location
+	response, err := service.NewFolderService(helperPkg.GetHelper()).Statistics(id, middleware.GetCurrentWorkspaceID(c), &req)
+	if err != nil {
+		ctrl.writeFolderError(c, err)
+		return
+	}
+	ctrl.Success(c, response)
+}
+
+func (ctrl FolderController) writeFolderError(c httpInterfaces.RouterContextInterface, err error) {
+	message := err.Error()
+	switch {
+	case message == "文件夹不存在":
+		ctrl.Error(c, constants.ErrCodeNotFound, message)
+	case strings.Contains(message, "已存在"):
+		ctrl.Error(c, constants.ErrCodeConflict, message)
+	case strings.Contains(message, "不存在"),
+		strings.Contains(message, "不能"),
+		strings.Contains(message, "不支持"),
+		strings.Contains(message, "不为空"):
+		ctrl.Error(c, constants.ErrCodeBadRequest, message)
+	default:
+		ctrl.Error(c, constants.ErrCodeInternal, message)
+	}
+}
@@ -0,0 +1,62 @@
+package controller
+
+import (
+	"errors"
+	"strconv"
+
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/constants"
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/middleware"
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/service"
+	helperPkg "cnb.cool/mliev/dwz/dwz-server/v2/pkg/helper"
+	httpInterfaces "cnb.cool/mliev/open/go-web/pkg/server/http_server/interfaces"
+)
+
+type LinkHealthController struct {
+	BaseResponse
+}
+
+// Get 获取短网址目标地址最近一次健康检查结果
+func (ctrl LinkHealthController) Get(c httpInterfaces.RouterContextInterface) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		ctrl.Error(c, constants.ErrCodeBadRequest, "无效的ID格式")
+		return
+	}
+	response, err := service.NewLinkHealthService(helperPkg.GetHelper()).GetShortLinkHealthInWorkspace(id, middleware.GetCurrentWorkspaceID(c))
+	if err != nil {
+		ctrl.writeLinkHealthError(c, err)
+		return
+	}
+	ctrl.Success(c, response)
+}
+
+// Check 立即检查短网址的全部目标地址
+func (ctrl LinkHealthController) Check(c httpInterfaces.RouterContextInterface) {
+	if !middleware.CanManageBusinessResource(c) {
+		ctrl.Error(c, constants.ErrCodeForbidden, "无权限检查短网址")
+		return
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		ctrl.Error(c, constants.ErrCodeBadRequest, "无效的ID格式")
+		return
+	}
+	response, err := service.NewLinkHealthService(helperPkg.GetHelper()).CheckShortLinkInWorkspace(id, middleware.GetCurrentWorkspaceID(c))
+	if err != nil {
+		ctrl.writeLinkHealthError(c, err)
+		return
+	}
+	ctrl.Success(c, response)
+}
+
+func (ctrl LinkHealthController) writeLinkHealthError(c httpInterfaces.RouterContextInterface, err error) {
+	if err.Error() == "短网址不存在" {
+		ctrl.Error(c, constants.ErrCodeNotFound, err.Error())
+		return
+	}
+	if errors.Is(err, service.ErrLinkHealthCheckTooFrequent) {
+		ctrl.Error(c, constants.ErrCodeConflict, err.Error())
+		return
+	}
+	ctrl.Error(c, constants.ErrCodeInternal, err.Error())
+}
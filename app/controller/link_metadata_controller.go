@@ -0,0 +1,58 @@
+package controller
+
+import (
+	"strconv"
+
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/constants"
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/middleware"
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/service"
+	helperPkg "cnb.cool/mliev/dwz/dwz-server/v2/pkg/helper"
+	httpInterfaces "cnb.cool/mliev/open/go-web/pkg/server/http_server/interfaces"
+)
+
+type LinkMetadataController struct {
+	BaseResponse
+}
+
+// Get 获取最近一次抓取的目标页面元数据（建议值）
+func (ctrl LinkMetadataController) Get(c httpInterfaces.RouterContextInterface) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		ctrl.Error(c, constants.ErrCodeBadRequest, "无效的ID格式")
+		return
+	}
+	response, err := service.NewLinkMetadataService(helperPkg.GetHelper()).GetShortLinkMetadataInWorkspace(id, middleware.GetCurrentWorkspaceID(c))
+	if err != nil {
+		ctrl.writeLinkMetadataError(c, err)
+		return
+	}
+	ctrl.Success(c, response)
+}
+
+// Fetch 立即重新抓取目标页面元数据
+func (ctrl LinkMetadataController) Fetch(c httpInterfaces.RouterContextInterface) {
+	if !middleware.CanManageBusinessResource(c) {
+		ctrl.Error(c, constants.ErrCodeForbidden, "无权限抓取元数据")
+		return
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		ctrl.Error(c, constants.ErrCodeBadRequest, "无效的ID格式")
+		return
+	}
+	response, err := service.NewLinkMetadataService(helperPkg.GetHelper()).FetchShortLinkMetadataInWorkspace(id, middleware.GetCurrentWorkspaceID(c))
+	if err != nil {
+		ctrl.writeLinkMetadataError(c, err)
+		return
+	}
+	ctrl.Success(c, response)
+}
+
+func (ctrl LinkMetadataController) writeLinkMetadataError(c httpInterfaces.RouterContextInterface, err error) {
+	switch err.Error() {
+	case "短网址不存在", "尚未抓取元数据":
+		ctrl.Error(c, constants.ErrCodeNotFound, err.Error())
+		return
+	}
+	ctrl.Error(c, constants.ErrCodeInternal, err.Error())
+}
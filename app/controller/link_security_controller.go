@@ -150,6 +150,12 @@ func (ctrl LinkSecurityController) ListEvents(c httpInterfaces.RouterContextInte
 		ctrl.Error(c, constants.ErrCodeBadRequest, "请求参数错误: "+err.Error())
 		return
 	}
+	location, err := reportLocation(c)
+	if err != nil {
+		ctrl.Error(c, constants.ErrCodeBadRequest, err.Error())
+		return
+	}
+	reanchorDates(location, req.StartDate, req.EndDate)
 	resp, err := service.NewLinkSecurityService(helperPkg.GetHelper()).ListEvents(middleware.GetCurrentWorkspaceID(c), &req)
 	if err != nil {
 		ctrl.Error(c, constants.ErrCodeInternal, err.Error())
@@ -0,0 +1,117 @@
+package controller
+
+import (
+	"strconv"
+	"strings"
+
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/constants"
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/dto"
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/middleware"
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/service"
+	helperPkg "cnb.cool/mliev/dwz/dwz-server/v2/pkg/helper"
+	httpInterfaces "cnb.cool/mliev/open/go-web/pkg/server/http_server/interfaces"
+)
+
+type LinkTemplateController struct {
+	BaseResponse
+}
+
+func (ctrl LinkTemplateController) Create(c httpInterfaces.RouterContextInterface) {
+	if !middleware.CanManageAdminResource(c) {
+		ctrl.Error(c, constants.ErrCodeForbidden, "无权限创建链接模板")
+		return
+	}
+	var req dto.LinkTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ctrl.Error(c, constants.ErrCodeBadRequest, bindErrorMessage(err))
+		return
+	}
+	response, err := service.NewLinkTemplateService(helperPkg.GetHelper()).Create(middleware.GetCurrentWorkspaceID(c), middleware.GetCurrentUserID(c), &req)
+	if err != nil {
+		ctrl.writeLinkTemplateError(c, err)
+		return
+	}
+	ctrl.Success(c, response)
+}
+
+func (ctrl LinkTemplateController) List(c httpInterfaces.RouterContextInterface) {
+	response, err := service.NewLinkTemplateService(helperPkg.GetHelper()).List(middleware.GetCurrentWorkspaceID(c))
+	if err != nil {
+		ctrl.Error(c, constants.ErrCodeInternal, err.Error())
+		return
+	}
+	ctrl.Success(c, response)
+}
+
+func (ctrl LinkTemplateController) Get(c httpInterfaces.RouterContextInterface) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		ctrl.Error(c, constants.ErrCodeBadRequest, "无效的ID")
+		return
+	}
+	response, err := service.NewLinkTemplateService(helperPkg.GetHelper()).Get(id, middleware.GetCurrentWorkspaceID(c))
+	if err != nil {
+		ctrl.writeLinkTemplateError(c, err)
+		return
+	}
+	ctrl.Success(c, response)
+}
+
+func (ctrl LinkTemplateController) Update(c httpInterfaces.RouterContextInterface) {
+	if !middleware.CanManageAdminResource(c) {
+		ctrl.Error(c, constants.ErrCodeForbidden, "无权限更新链接模板")
+		return
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		ctrl.Error(c, constants.ErrCodeBadRequest, "无效的ID")
+		return
+	}
+	var req dto.LinkTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ctrl.Error(c, constants.ErrCodeBadRequest, bindErrorMessage(err))
+		return
+	}
+	response, err := service.NewLinkTemplateService(helperPkg.GetHelper()).Update(id, middleware.GetCurrentWorkspaceID(c), middleware.GetCurrentUserID(c), &req)
+	if err != nil {
+		ctrl.writeLinkTemplateError(c, err)
+		return
+	}
+	ctrl.Success(c, response)
+}
+
+func (ctrl LinkTemplateController) Delete(c httpInterfaces.RouterContextInterface) {
+	if !middleware.CanManageAdminResource(c) {
+		ctrl.Error(c, constants.ErrCodeForbidden, "无权限删除链接模板")
+		return
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		ctrl.Error(c, constants.ErrCodeBadRequest, "无效的ID")
+		return
+	}
+	if err := service.NewLinkTemplateService(helperPkg.GetHelper()).Delete(id, middleware.GetCurrentWorkspaceID(c)); err != nil {
+		ctrl.writeLinkTemplateError(c, err)
+		return
+	}
+	ctrl.SuccessWithMessage(c, "删除成功", nil)
+}
+
+func (ctrl LinkTemplateController) writeLinkTemplateError(c httpInterfaces.RouterContextInterface, err error) {
+	message := err.Error()
+	switch {
+	case message == "链接模板不存在":
+		ctrl.Error(c, constants.ErrCodeNotFound, message)
+	case strings.Contains(message, "已存在"):
+		ctrl.Error(c, constants.ErrCodeConflict, message)
+	case strings.Contains(message, "不存在"),
+		strings.Contains(message, "不能"),
+		strings.Contains(message, "不支持"),
+		strings.Contains(message, "无效"),
+		strings.Contains(message, "命中安全规则"),
+		strings.Contains(message, "至少需要"):
+		ctrl.Error(c, constants.ErrCodeBadRequest, message)
+	default:
+		ctrl.Error(c, constants.ErrCodeInternal, message)
+	}
+}
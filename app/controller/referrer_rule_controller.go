@@ -0,0 +1,97 @@
+package controller
+
+import (
+	"errors"
+	"strconv"
+
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/constants"
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/dto"
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/middleware"
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/service"
+	helperPkg "cnb.cool/mliev/dwz/dwz-server/v2/pkg/helper"
+	httpInterfaces "cnb.cool/mliev/open/go-web/pkg/server/http_server/interfaces"
+)
+
+// ReferrerRuleController 工作区来源渠道规则
+type ReferrerRuleController struct {
+	BaseResponse
+}
+
+// List 返回工作区规则和内置规则
+func (ctrl ReferrerRuleController) List(c httpInterfaces.RouterContextInterface) {
+	response, err := service.NewReferrerService(helperPkg.GetHelper()).ListRules(middleware.GetCurrentWorkspaceID(c))
+	if err != nil {
+		ctrl.Error(c, constants.ErrCodeInternal, err.Error())
+		return
+	}
+	ctrl.Success(c, response)
+}
+
+func (ctrl ReferrerRuleController) Create(c httpInterfaces.RouterContextInterface) {
+	if !middleware.CanManageAdminResource(c) {
+		ctrl.Error(c, constants.ErrCodeForbidden, "无权限创建来源渠道规则")
+		return
+	}
+	var req dto.ReferrerRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ctrl.Error(c, constants.ErrCodeBadRequest, bindErrorMessage(err))
+		return
+	}
+	response, err := service.NewReferrerService(helperPkg.GetHelper()).CreateRule(middleware.GetCurrentWorkspaceID(c), middleware.GetCurrentUserID(c), &req)
+	if err != nil {
+		ctrl.writeReferrerRuleError(c, err)
+		return
+	}
+	ctrl.Success(c, response)
+}
+
+func (ctrl ReferrerRuleController) Update(c httpInterfaces.RouterContextInterface) {
+	if !middleware.CanManageAdminResource(c) {
+		ctrl.Error(c, constants.ErrCodeForbidden, "无权限更新来源渠道规则")
+		return
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		ctrl.Error(c, constants.ErrCodeBadRequest, "无效的ID")
+		return
+	}
+	var req dto.ReferrerRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ctrl.Error(c, constants.ErrCodeBadRequest, bindErrorMessage(err))
+		return
+	}
+	response, err := service.NewReferrerService(helperPkg.GetHelper()).UpdateRule(id, middleware.GetCurrentWorkspaceID(c), &req)
+	if err != nil {
+		ctrl.writeReferrerRuleError(c, err)
+		return
+	}
+	ctrl.Success(c, response)
+}
+
+func (ctrl ReferrerRuleController) Delete(c httpInterfaces.RouterContextInterface) {
+	if !middleware.CanManageAdminResource(c) {
+		ctrl.Error(c, constants.ErrCodeForbidden, "无权限删除来源渠道规则")
+		return
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		ctrl.Error(c, constants.ErrCodeBadRequest, "无效的ID")
+		return
+	}
+	if err := service.NewReferrerService(helperPkg.GetHelper()).DeleteRule(id, middleware.GetCurrentWorkspaceID(c)); err != nil {
+		ctrl.writeReferrerRuleError(c, err)
+		return
+	}
+	ctrl.SuccessWithMessage(c, "删除成功", nil)
+}
+
+func (ctrl ReferrerRuleController) writeReferrerRuleError(c httpInterfaces.RouterContextInterface, err error) {
+	switch {
+	case errors.Is(err, service.ErrReferrerRuleNotFound):
+		ctrl.Error(c, constants.ErrCodeNotFound, err.Error())
+	case errors.Is(err, service.ErrReferrerRuleInvalid):
+		ctrl.Error(c, constants.ErrCodeBadRequest, err.Error())
+	default:
+		ctrl.Error(c, constants.ErrCodeInternal, err.Error())
+	}
+}
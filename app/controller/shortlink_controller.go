@@ -1,7 +1,9 @@
 package controller
 
 import (
+	"encoding/json"
 	"errors"
+	"io"
 
 	"cnb.cool/mliev/dwz/dwz-server/v2/app/constants"
 	"cnb.cool/mliev/dwz/dwz-server/v2/app/dto"
@@ -37,9 +39,92 @@ func (ctrl ShortLinkController) CreateShortLink(c httpInterfaces.RouterContextIn
 	// 获取客户端IP
 	clientIP := c.ClientIP()
 
+	idempotencyService := service.NewIdempotencyService(helper)
+	record, handled := ctrl.beginIdempotentRequest(c, idempotencyService, service.IdempotencyScopeCreateShortLink, &req)
+	if handled {
+		return
+	}
+
 	shortLinkService := service.NewShortLinkService(helper, c.Request().Context())
 	response, err := shortLinkService.CreateShortLinkInWorkspace(&req, clientIP, middleware.GetCurrentWorkspaceID(c), middleware.GetCurrentUserID(c))
 	if err != nil {
+		_ = idempotencyService.Release(record)
+		ctrl.writeShortLinkError(c, err)
+		return
+	}
+	ctrl.completeIdempotentRequest(idempotencyService, record, response)
+
+	ctrl.Success(c, response)
+}
+
+// GetShortLinkByExternalID 根据外部标识获取短网址
+func (ctrl ShortLinkController) GetShortLinkByExternalID(c httpInterfaces.RouterContextInterface) {
+	helper := helperPkg.GetHelper()
+	_ = helper
+	shortLinkService := service.NewShortLinkService(helper, c.Request().Context())
+	response, err := shortLinkService.GetShortLinkByExternalIDInWorkspace(c.Param("external_id"), middleware.GetCurrentWorkspaceID(c))
+	if err != nil {
+		ctrl.writeShortLinkError(c, err)
+		return
+	}
+
+	ctrl.Success(c, response)
+}
+
+// UpsertShortLinkByExternalID 根据外部标识创建或更新短网址
+func (ctrl ShortLinkController) UpsertShortLinkByExternalID(c httpInterfaces.RouterContextInterface) {
+	if !middleware.CanManageBusinessResource(c) {
+		ctrl.Error(c, constants.ErrCodeForbidden, "无权限创建短网址")
+		return
+	}
+	helper := helperPkg.GetHelper()
+	_ = helper
+	var req dto.CreateShortLinkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ctrl.Error(c, constants.ErrCodeBadRequest, bindErrorMessage(err))
+		return
+	}
+
+	shortLinkService := service.NewShortLinkService(helper, c.Request().Context())
+	response, created, err := shortLinkService.UpsertShortLinkByExternalIDInWorkspace(c.Param("external_id"), &req, c.ClientIP(), middleware.GetCurrentWorkspaceID(c), middleware.GetCurrentUserID(c))
+	if err != nil {
+		ctrl.writeShortLinkError(c, err)
+		return
+	}
+
+	if created {
+		ctrl.SuccessWithMessage(c, "创建成功", response)
+		return
+	}
+	ctrl.SuccessWithMessage(c, "更新成功", response)
+}
+
+// CloneShortLink 克隆短网址
+func (ctrl ShortLinkController) CloneShortLink(c httpInterfaces.RouterContextInterface) {
+	if !middleware.CanManageBusinessResource(c) {
+		ctrl.Error(c, constants.ErrCodeForbidden, "无权限创建短网址")
+		return
+	}
+	helper := helperPkg.GetHelper()
+	_ = helper
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		ctrl.Error(c, constants.ErrCodeBadRequest, "无效的ID格式")
+		return
+	}
+	var req dto.CloneShortLinkRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		ctrl.Error(c, constants.ErrCodeBadRequest, bindErrorMessage(err))
+		return
+	}
+
+	shortLinkService := service.NewShortLinkService(helper, c.Request().Context())
+	response, err := shortLinkService.CloneShortLinkInWorkspace(id, &req, c.ClientIP(), middleware.GetCurrentWorkspaceID(c), middleware.GetCurrentUserID(c))
+	if err != nil {
+		if strings.Contains(err.Error(), "无权限") {
+			ctrl.Error(c, constants.ErrCodeForbidden, err.Error())
+			return
+		}
 		ctrl.writeShortLinkError(c, err)
 		return
 	}
@@ -217,6 +302,325 @@ func (ctrl ShortLinkController) BatchDeleteShortLinks(c httpInterfaces.RouterCon
 	ctrl.Success(c, response)
 }
 
+// MoveShortLink 移动短网址到文件夹
+func (ctrl ShortLinkController) MoveShortLink(c httpInterfaces.RouterContextInterface) {
+	if !middleware.CanManageBusinessResource(c) {
+		ctrl.Error(c, constants.ErrCodeForbidden, "无权限更新短网址")
+		return
+	}
+	helper := helperPkg.GetHelper()
+	_ = helper
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		ctrl.Error(c, constants.ErrCodeBadRequest, "无效的ID格式")
+		return
+	}
+	var req dto.MoveShortLinkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ctrl.Error(c, constants.ErrCodeBadRequest, bindErrorMessage(err))
+		return
+	}
+
+	shortLinkService := service.NewShortLinkService(helper, c.Request().Context())
+	response, err := shortLinkService.MoveShortLinkInWorkspace(id, req.FolderID, middleware.GetCurrentWorkspaceID(c), middleware.GetCurrentUserID(c))
+	if err != nil {
+		ctrl.writeShortLinkError(c, err)
+		return
+	}
+
+	ctrl.Success(c, response)
+}
+
+// BatchMoveShortLinks 批量移动短网址到文件夹
+func (ctrl ShortLinkController) BatchMoveShortLinks(c httpInterfaces.RouterContextInterface) {
+	if !middleware.CanManageBusinessResource(c) {
+		ctrl.Error(c, constants.ErrCodeForbidden, "无权限更新短网址")
+		return
+	}
+	helper := helperPkg.GetHelper()
+	_ = helper
+
+	var req dto.BatchMoveShortLinkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ctrl.Error(c, constants.ErrCodeBadRequest, bindErrorMessage(err))
+		return
+	}
+
+	shortLinkService := service.NewShortLinkService(helper, c.Request().Context())
+	response, err := shortLinkService.BatchMoveShortLinksInWorkspace(req.IDs, req.FolderID, middleware.GetCurrentWorkspaceID(c), middleware.GetCurrentUserID(c))
+	if err != nil {
+		ctrl.writeShortLinkError(c, err)
+		return
+	}
+
+	ctrl.Success(c, response)
+}
+
+// RekeyShortLink 重新分配域名或短码，旧短码保留为别名
+func (ctrl ShortLinkController) RekeyShortLink(c httpInterfaces.RouterContextInterface) {
+	if !middleware.CanManageBusinessResource(c) {
+		ctrl.Error(c, constants.ErrCodeForbidden, "无权限更新短网址")
+		return
+	}
+	helper := helperPkg.GetHelper()
+	_ = helper
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		ctrl.Error(c, constants.ErrCodeBadRequest, "无效的ID格式")
+		return
+	}
+	var req dto.RekeyShortLinkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ctrl.Error(c, constants.ErrCodeBadRequest, bindErrorMessage(err))
+		return
+	}
+
+	shortLinkService := service.NewShortLinkService(helper, c.Request().Context())
+	response, err := shortLinkService.RekeyShortLinkInWorkspace(id, &req, middleware.GetCurrentWorkspaceID(c), middleware.GetCurrentUserID(c))
+	if err != nil {
+		if strings.Contains(err.Error(), "短代码已存在") {
+			ctrl.Error(c, constants.ErrCodeConflict, err.Error())
+			return
+		}
+		ctrl.writeShortLinkError(c, err)
+		return
+	}
+
+	ctrl.Success(c, response)
+}
+
+// GetShortLinkReviewQueue 获取待审核短网址队列
+func (ctrl ShortLinkController) GetShortLinkReviewQueue(c httpInterfaces.RouterContextInterface) {
+	if !middleware.CanManageAdminResource(c) {
+		ctrl.Error(c, constants.ErrCodeForbidden, "无权限审核短网址")
+		return
+	}
+	helper := helperPkg.GetHelper()
+	_ = helper
+	var req dto.ShortLinkListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		ctrl.Error(c, constants.ErrCodeBadRequest, bindErrorMessage(err))
+		return
+	}
+	location, err := reportLocation(c)
+	if err != nil {
+		ctrl.Error(c, constants.ErrCodeBadRequest, err.Error())
+		return
+	}
+	req.Location = location
+	shortLinkService := service.NewShortLinkService(helper, c.Request().Context())
+	response, err := shortLinkService.GetReviewQueueInWorkspace(&req, middleware.GetCurrentWorkspaceID(c))
+	if err != nil {
+		ctrl.writeShortLinkError(c, err)
+		return
+	}
+
+	ctrl.Success(c, response)
+}
+
+// ApproveShortLink 审核通过短网址
+func (ctrl ShortLinkController) ApproveShortLink(c httpInterfaces.RouterContextInterface) {
+	ctrl.reviewShortLink(c, true)
+}
+
+// RejectShortLink 驳回短网址
+func (ctrl ShortLinkController) RejectShortLink(c httpInterfaces.RouterContextInterface) {
+	ctrl.reviewShortLink(c, false)
+}
+
+func (ctrl ShortLinkController) reviewShortLink(c httpInterfaces.RouterContextInterface, approve bool) {
+	if !middleware.CanManageAdminResource(c) {
+		ctrl.Error(c, constants.ErrCodeForbidden, "无权限审核短网址")
+		return
+	}
+	helper := helperPkg.GetHelper()
+	_ = helper
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		ctrl.Error(c, constants.ErrCodeBadRequest, "无效的ID格式")
+		return
+	}
+	var req dto.ReviewShortLinkRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		ctrl.Error(c, constants.ErrCodeBadRequest, bindErrorMessage(err))
+		return
+	}
+
+	shortLinkService := service.NewShortLinkService(helper, c.Request().Context())
+	workspaceID, userID := middleware.GetCurrentWorkspaceID(c), middleware.GetCurrentUserID(c)
+	var response *dto.ShortLinkResponse
+	if approve {
+		response, err = shortLinkService.ApproveShortLinkInWorkspace(id, &req, workspaceID, userID)
+	} else {
+		response, err = shortLinkService.RejectShortLinkInWorkspace(id, &req, workspaceID, userID)
+	}
+	if err != nil {
+		ctrl.writeShortLinkError(c, err)
+		return
+	}
+
+	ctrl.Success(c, response)
+}
+
+// GetShortLinkAliases 获取短网址别名列表
+func (ctrl ShortLinkController) GetShortLinkAliases(c httpInterfaces.RouterContextInterface) {
+	helper := helperPkg.GetHelper()
+	_ = helper
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		ctrl.Error(c, constants.ErrCodeBadRequest, "无效的ID格式")
+		return
+	}
+
+	shortLinkService := service.NewShortLinkService(helper, c.Request().Context())
+	response, err := shortLinkService.ListShortLinkAliasesInWorkspace(id, middleware.GetCurrentWorkspaceID(c))
+	if err != nil {
+		ctrl.writeShortLinkError(c, err)
+		return
+	}
+
+	ctrl.Success(c, response)
+}
+
+// CreateShortLinkAlias 为短网址添加别名
+func (ctrl ShortLinkController) CreateShortLinkAlias(c httpInterfaces.RouterContextInterface) {
+	if !middleware.CanManageBusinessResource(c) {
+		ctrl.Error(c, constants.ErrCodeForbidden, "无权限更新短网址")
+		return
+	}
+	helper := helperPkg.GetHelper()
+	_ = helper
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		ctrl.Error(c, constants.ErrCodeBadRequest, "无效的ID格式")
+		return
+	}
+	var req dto.CreateShortLinkAliasRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ctrl.Error(c, constants.ErrCodeBadRequest, bindErrorMessage(err))
+		return
+	}
+
+	shortLinkService := service.NewShortLinkService(helper, c.Request().Context())
+	response, err := shortLinkService.CreateShortLinkAliasInWorkspace(id, &req, middleware.GetCurrentWorkspaceID(c), middleware.GetCurrentUserID(c))
+	if err != nil {
+		if strings.Contains(err.Error(), "短代码已存在") {
+			ctrl.Error(c, constants.ErrCodeConflict, err.Error())
+			return
+		}
+		ctrl.writeShortLinkError(c, err)
+		return
+	}
+
+	ctrl.Success(c, response)
+}
+
+// DeleteShortLinkAlias 删除短网址别名
+func (ctrl ShortLinkController) DeleteShortLinkAlias(c httpInterfaces.RouterContextInterface) {
+	if !middleware.CanManageBusinessResource(c) {
+		ctrl.Error(c, constants.ErrCodeForbidden, "无权限更新短网址")
+		return
+	}
+	helper := helperPkg.GetHelper()
+	_ = helper
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		ctrl.Error(c, constants.ErrCodeBadRequest, "无效的ID格式")
+		return
+	}
+	aliasID, err := strconv.ParseUint(c.Param("alias_id"), 10, 64)
+	if err != nil {
+		ctrl.Error(c, constants.ErrCodeBadRequest, "无效的别名ID格式")
+		return
+	}
+
+	shortLinkService := service.NewShortLinkService(helper, c.Request().Context())
+	if err := shortLinkService.DeleteShortLinkAliasInWorkspace(id, aliasID, middleware.GetCurrentWorkspaceID(c)); err != nil {
+		if err.Error() == "别名不存在" {
+			ctrl.Error(c, constants.ErrCodeNotFound, err.Error())
+			return
+		}
+		ctrl.writeShortLinkError(c, err)
+		return
+	}
+
+	ctrl.SuccessWithMessage(c, "删除成功", nil)
+}
+
+// GetShortLinkTrash 获取回收站列表
+func (ctrl ShortLinkController) GetShortLinkTrash(c httpInterfaces.RouterContextInterface) {
+	helper := helperPkg.GetHelper()
+	_ = helper
+	var req dto.ShortLinkTrashListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		ctrl.Error(c, constants.ErrCodeBadRequest, bindErrorMessage(err))
+		return
+	}
+	shortLinkService := service.NewShortLinkService(helper, c.Request().Context())
+	response, err := shortLinkService.GetTrashListInWorkspace(&req, middleware.GetCurrentWorkspaceID(c))
+	if err != nil {
+		ctrl.Error(c, constants.ErrCodeInternal, err.Error())
+		return
+	}
+
+	ctrl.Success(c, response)
+}
+
+// RestoreShortLink 从回收站恢复短网址
+func (ctrl ShortLinkController) RestoreShortLink(c httpInterfaces.RouterContextInterface) {
+	if !middleware.CanManageBusinessResource(c) {
+		ctrl.Error(c, constants.ErrCodeForbidden, "无权限恢复短网址")
+		return
+	}
+	helper := helperPkg.GetHelper()
+	_ = helper
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		ctrl.Error(c, constants.ErrCodeBadRequest, "无效的ID格式")
+		return
+	}
+	shortLinkService := service.NewShortLinkService(helper, c.Request().Context())
+	response, err := shortLinkService.RestoreShortLinkInWorkspace(id, middleware.GetCurrentWorkspaceID(c))
+	if err != nil {
+		if strings.Contains(err.Error(), "不存在") {
+			ctrl.Error(c, constants.ErrCodeNotFound, err.Error())
+		} else if strings.Contains(err.Error(), "已被其他短网址占用") {
+			ctrl.Error(c, constants.ErrCodeConflict, err.Error())
+		} else {
+			ctrl.Error(c, constants.ErrCodeInternal, err.Error())
+		}
+		return
+	}
+
+	ctrl.SuccessWithMessage(c, "恢复成功", response)
+}
+
+// PurgeShortLink 彻底删除回收站中的短网址
+func (ctrl ShortLinkController) PurgeShortLink(c httpInterfaces.RouterContextInterface) {
+	if !middleware.CanManageBusinessResource(c) {
+		ctrl.Error(c, constants.ErrCodeForbidden, "无权限删除短网址")
+		return
+	}
+	helper := helperPkg.GetHelper()
+	_ = helper
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		ctrl.Error(c, constants.ErrCodeBadRequest, "无效的ID格式")
+		return
+	}
+	shortLinkService := service.NewShortLinkService(helper, c.Request().Context())
+	if err := shortLinkService.PurgeShortLinkInWorkspace(id, middleware.GetCurrentWorkspaceID(c)); err != nil {
+		if strings.Contains(err.Error(), "不存在") {
+			ctrl.Error(c, constants.ErrCodeNotFound, err.Error())
+		} else {
+			ctrl.Error(c, constants.ErrCodeInternal, err.Error())
+		}
+		return
+	}
+
+	ctrl.SuccessWithMessage(c, "已彻底删除", nil)
+}
+
 // GetShortLinkList 获取短网址列表
 func (ctrl ShortLinkController) GetShortLinkList(c httpInterfaces.RouterContextInterface) {
 	helper := helperPkg.GetHelper()
@@ -226,10 +630,16 @@ func (ctrl ShortLinkController) GetShortLinkList(c httpInterfaces.RouterContextI
 		ctrl.Error(c, constants.ErrCodeBadRequest, bindErrorMessage(err))
 		return
 	}
+	location, err := reportLocation(c)
+	if err != nil {
+		ctrl.Error(c, constants.ErrCodeBadRequest, err.Error())
+		return
+	}
+	req.Location = location
 	shortLinkService := service.NewShortLinkService(helper, c.Request().Context())
 	response, err := shortLinkService.GetShortLinkListInWorkspace(&req, middleware.GetCurrentWorkspaceID(c))
 	if err != nil {
-		ctrl.Error(c, constants.ErrCodeInternal, err.Error())
+		ctrl.writeShortLinkError(c, err)
 		return
 	}
 
@@ -253,7 +663,13 @@ func (ctrl ShortLinkController) GetShortLinkStatistics(c httpInterfaces.RouterCo
 		days = 7
 	}
 	shortLinkService := service.NewShortLinkService(helper, c.Request().Context())
-	response, err := shortLinkService.GetShortLinkStatisticsInWorkspace(id, days, middleware.GetCurrentWorkspaceID(c))
+	location, err := reportLocation(c)
+	if err != nil {
+		ctrl.Error(c, constants.ErrCodeBadRequest, err.Error())
+		return
+	}
+	includeDuplicates, _ := strconv.ParseBool(c.Query("include_duplicates"))
+	response, err := shortLinkService.GetShortLinkStatisticsInWorkspace(id, days, middleware.GetCurrentWorkspaceID(c), location, includeDuplicates)
 	if err != nil {
 		if strings.Contains(err.Error(), "不存在") {
 			ctrl.Error(c, constants.ErrCodeNotFound, err.Error())
@@ -282,16 +698,54 @@ func (ctrl ShortLinkController) BatchCreateShortLinks(c httpInterfaces.RouterCon
 
 	// 获取客户端IP
 	clientIP := c.ClientIP()
+
+	idempotencyService := service.NewIdempotencyService(helper)
+	record, handled := ctrl.beginIdempotentRequest(c, idempotencyService, service.IdempotencyScopeBatchCreateShortLinks, &req)
+	if handled {
+		return
+	}
+
 	shortLinkService := service.NewShortLinkService(helper, c.Request().Context())
 	response, err := shortLinkService.BatchCreateShortLinksInWorkspace(&req, clientIP, helper, middleware.GetCurrentWorkspaceID(c), middleware.GetCurrentUserID(c))
 	if err != nil {
-		ctrl.Error(c, constants.ErrCodeInternal, err.Error())
+		_ = idempotencyService.Release(record)
+		ctrl.writeShortLinkError(c, err)
 		return
 	}
+	ctrl.completeIdempotentRequest(idempotencyService, record, response)
 
 	ctrl.Success(c, response)
 }
 
+// beginIdempotentRequest 处理 Idempotency-Key 请求头。handled 为 true 时已重放原始响应或返回错误，调用方应直接结束。
+func (ctrl ShortLinkController) beginIdempotentRequest(c httpInterfaces.RouterContextInterface, idempotencyService *service.IdempotencyService, scope string, payload any) (*model.IdempotencyKey, bool) {
+	record, replay, err := idempotencyService.Begin(middleware.GetCurrentWorkspaceID(c), scope, c.GetHeader("Idempotency-Key"), payload)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrIdempotencyKeyTooLong):
+			ctrl.Error(c, constants.ErrCodeBadRequest, err.Error())
+		case errors.Is(err, service.ErrIdempotencyKeyMismatch), errors.Is(err, service.ErrIdempotencyKeyInProgress):
+			ctrl.Error(c, constants.ErrCodeConflict, err.Error())
+		default:
+			ctrl.Error(c, constants.ErrCodeInternal, err.Error())
+		}
+		return nil, true
+	}
+	if replay {
+		c.SetHeader("Idempotent-Replayed", "true")
+		ctrl.Success(c, json.RawMessage(record.ResponseBody))
+		return nil, true
+	}
+	return record, false
+}
+
+// completeIdempotentRequest 保存响应供重试重放，保存失败时释放幂等键以免重试被长期阻塞
+func (ctrl ShortLinkController) completeIdempotentRequest(idempotencyService *service.IdempotencyService, record *model.IdempotencyKey, response any) {
+	if err := idempotencyService.Complete(record, response); err != nil {
+		_ = idempotencyService.Release(record)
+	}
+}
+
 // ErrorPageData 错误页面模板数据结构
 type ErrorPageData struct {
 	SiteName     string
@@ -361,7 +815,6 @@ func (ctrl ShortLinkController) RedirectShortLink(c httpInterfaces.RouterContext
 	clientIP := c.ClientIP()
 	userAgent := c.GetHeader("User-Agent")
 	referer := c.GetHeader("Referer")
-	acceptLanguage := c.GetHeader("Accept-Language")
 
 	// 获取查询参数字符串
 	queryString := c.Request().URL.RawQuery
@@ -371,7 +824,7 @@ func (ctrl ShortLinkController) RedirectShortLink(c httpInterfaces.RouterContext
 		accessToken = cookie
 	}
 	shortLinkService := service.NewShortLinkService(helper, c.Request().Context())
-	decision, err := shortLinkService.ResolveRedirectWithSecurityAndLanguage(domain, shortCode, clientIP, userAgent, referer, queryString, accessToken, acceptLanguage)
+	decision, err := shortLinkService.ResolveRedirectWithHeaders(domain, shortCode, clientIP, referer, queryString, accessToken, c.Request().Header)
 	if err != nil {
 		if errors.Is(err, service.ErrSecurityPasswordRequired) {
 			ctrl.renderPasswordPage(c, domain, shortCode, "")
@@ -621,6 +1074,12 @@ func isShortLinkBadRequestError(message string) bool {
 		"命中安全规则",
 		"仅支持",
 		"已存在",
+		"复用已有短网址时",
+		"有效访问结束时间不能早于",
+		"新短代码不能与当前短代码相同",
+		"别名数量不能超过",
+		"自定义字段",
+		"不是待审核状态",
 	}
 	for _, phrase := range badRequestPhrases {
 		if strings.Contains(message, phrase) {
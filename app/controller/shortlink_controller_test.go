@@ -0,0 +1,28 @@
+package controller
+
+import "testing"
+
+func TestIsShortLinkBadRequestError(t *testing.T) {
+	for _, message := range []string{
+		"目标 URL 不能为空",
+		"外部标识不能为空",
+		"有效访问结束时间不能早于开始时间",
+		"新短代码不能与当前短代码相同",
+		"别名数量不能超过 50 个",
+		"自定义字段 budget 不能大于 100",
+		"短网址不是待审核状态，不能审核",
+		"复用已有短网址时无法设置自定义字段，请将 reuse 设为 false",
+	} {
+		if !isShortLinkBadRequestError(message) {
+			t.Fatalf("%q should be a bad request", message)
+		}
+	}
+	for _, message := range []string{
+		"database is locked",
+		"不能从状态 running 转换到 draft",
+	} {
+		if isShortLinkBadRequestError(message) {
+			t.Fatalf("%q should not be a bad request", message)
+		}
+	}
+}
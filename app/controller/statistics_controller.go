@@ -67,8 +67,13 @@ func (s StatisticsController) GetSystem(c httpInterfaces.RouterContextInterface)
 	userDao := dao.NewUserDAO(helper)
 
 	// 获取当前时间以及相关时间范围
-	now := time.Now()
-	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	location, err := reportLocation(c)
+	if err != nil {
+		s.Error(c, constants.ErrCodeBadRequest, err.Error())
+		return
+	}
+	now := time.Now().In(location)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, location)
 	tomorrow := today.AddDate(0, 0, 1)
 
 	// 计算本周开始时间（从周一开始）
@@ -146,8 +151,13 @@ func (s StatisticsController) GetDashboard(c httpInterfaces.RouterContextInterfa
 	userDao := dao.NewUserDAO(helper)
 
 	// 获取当天日期范围
-	now := time.Now()
-	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	location, err := reportLocation(c)
+	if err != nil {
+		s.Error(c, constants.ErrCodeBadRequest, err.Error())
+		return
+	}
+	now := time.Now().In(location)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, location)
 	tomorrow := today.AddDate(0, 0, 1)
 	rangeStart, rangeEnd, hasRange, err := parseStatisticsDateRange(c, today, tomorrow)
 	if err != nil {
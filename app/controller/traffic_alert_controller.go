@@ -0,0 +1,178 @@
+package controller
+
+import (
+	"errors"
+	"strconv"
+
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/constants"
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/dto"
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/middleware"
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/service"
+	helperPkg "cnb.cool/mliev/dwz/dwz-server/v2/pkg/helper"
+	httpInterfaces "cnb.cool/mliev/open/go-web/pkg/server/http_server/interfaces"
+)
+
+// TrafficAlertController 流量异常告警、检测阈值和告警渠道
+type TrafficAlertController struct {
+	BaseResponse
+}
+
+func (ctrl TrafficAlertController) List(c httpInterfaces.RouterContextInterface) {
+	var req dto.TrafficAlertListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		ctrl.Error(c, constants.ErrCodeBadRequest, "请求参数错误: "+err.Error())
+		return
+	}
+	location, err := reportLocation(c)
+	if err != nil {
+		ctrl.Error(c, constants.ErrCodeBadRequest, err.Error())
+		return
+	}
+	reanchorDates(location, req.StartDate, req.EndDate)
+	response, err := service.NewTrafficAlertService(helperPkg.GetHelper()).ListAlerts(middleware.GetCurrentWorkspaceID(c), &req)
+	if err != nil {
+		ctrl.Error(c, constants.ErrCodeInternal, err.Error())
+		return
+	}
+	ctrl.Success(c, response)
+}
+
+// Acknowledge 标记告警已处理
+func (ctrl TrafficAlertController) Acknowledge(c httpInterfaces.RouterContextInterface) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		ctrl.Error(c, constants.ErrCodeBadRequest, "无效的ID")
+		return
+	}
+	response, err := service.NewTrafficAlertService(helperPkg.GetHelper()).Acknowledge(id, middleware.GetCurrentWorkspaceID(c), middleware.GetCurrentUserID(c))
+	if err != nil {
+		ctrl.writeTrafficAlertError(c, err)
+		return
+	}
+	ctrl.Success(c, response)
+}
+
+func (ctrl TrafficAlertController) GetSettings(c httpInterfaces.RouterContextInterface) {
+	response, err := service.NewTrafficAlertService(helperPkg.GetHelper()).GetSetting(middleware.GetCurrentWorkspaceID(c))
+	if err != nil {
+		ctrl.Error(c, constants.ErrCodeInternal, err.Error())
+		return
+	}
+	ctrl.Success(c, response)
+}
+
+func (ctrl TrafficAlertController) UpdateSettings(c httpInterfaces.RouterContextInterface) {
+	if !middleware.CanManageAdminResource(c) {
+		ctrl.Error(c, constants.ErrCodeForbidden, "无权限修改告警设置")
+		return
+	}
+	var req dto.TrafficAlertSettingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ctrl.Error(c, constants.ErrCodeBadRequest, bindErrorMessage(err))
+		return
+	}
+	response, err := service.NewTrafficAlertService(helperPkg.GetHelper()).UpdateSetting(middleware.GetCurrentWorkspaceID(c), &req)
+	if err != nil {
+		ctrl.Error(c, constants.ErrCodeInternal, err.Error())
+		return
+	}
+	ctrl.Success(c, response)
+}
+
+func (ctrl TrafficAlertController) ListChannels(c httpInterfaces.RouterContextInterface) {
+	response, err := service.NewTrafficAlertService(helperPkg.GetHelper()).ListChannels(middleware.GetCurrentWorkspaceID(c))
+	if err != nil {
+		ctrl.Error(c, constants.ErrCodeInternal, err.Error())
+		return
+	}
+	ctrl.Success(c, response)
+}
+
+func (ctrl TrafficAlertController) CreateChannel(c httpInterfaces.RouterContextInterface) {
+	if !middleware.CanManageAdminResource(c) {
+		ctrl.Error(c, constants.ErrCodeForbidden, "无权限创建告警渠道")
+		return
+	}
+	var req dto.AlertChannelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ctrl.Error(c, constants.ErrCodeBadRequest, bindErrorMessage(err))
+		return
+	}
+	response, err := service.NewTrafficAlertService(helperPkg.GetHelper()).CreateChannel(middleware.GetCurrentWorkspaceID(c), middleware.GetCurrentUserID(c), &req)
+	if err != nil {
+		ctrl.writeTrafficAlertError(c, err)
+		return
+	}
+	ctrl.Success(c, response)
+}
+
+func (ctrl TrafficAlertController) UpdateChannel(c httpInterfaces.RouterContextInterface) {
+	if !middleware.CanManageAdminResource(c) {
+		ctrl.Error(c, constants.ErrCodeForbidden, "无权限更新告警渠道")
+		return
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		ctrl.Error(c, constants.ErrCodeBadRequest, "无效的ID")
+		return
+	}
+	var req dto.AlertChannelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ctrl.Error(c, constants.ErrCodeBadRequest, bindErrorMessage(err))
+		return
+	}
+	response, err := service.NewTrafficAlertService(helperPkg.GetHelper()).UpdateChannel(id, middleware.GetCurrentWorkspaceID(c), &req)
+	if err != nil {
+		ctrl.writeTrafficAlertError(c, err)
+		return
+	}
+	ctrl.Success(c, response)
+}
+
+func (ctrl TrafficAlertController) DeleteChannel(c httpInterfaces.RouterContextInterface) {
+	if !middleware.CanManageAdminResource(c) {
+		ctrl.Error(c, constants.ErrCodeForbidden, "无权限删除告警渠道")
+		return
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		ctrl.Error(c, constants.ErrCodeBadRequest, "无效的ID")
+		return
+	}
+	if err := service.NewTrafficAlertService(helperPkg.GetHelper()).DeleteChannel(id, middleware.GetCurrentWorkspaceID(c)); err != nil {
+		ctrl.writeTrafficAlertError(c, err)
+		return
+	}
+	ctrl.SuccessWithMessage(c, "删除成功", nil)
+}
+
+// TestChannel 向告警渠道发送测试消息
+func (ctrl TrafficAlertController) TestChannel(c httpInterfaces.RouterContextInterface) {
+	if !middleware.CanManageAdminResource(c) {
+		ctrl.Error(c, constants.ErrCodeForbidden, "无权限测试告警渠道")
+		return
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		ctrl.Error(c, constants.ErrCodeBadRequest, "无效的ID")
+		return
+	}
+	if err := service.NewTrafficAlertService(helperPkg.GetHelper()).TestChannel(id, middleware.GetCurrentWorkspaceID(c)); err != nil {
+		if errors.Is(err, service.ErrAlertChannelNotFound) {
+			ctrl.writeTrafficAlertError(c, err)
+			return
+		}
+		ctrl.Error(c, constants.ErrCodeBadRequest, "发送失败: "+err.Error())
+		return
+	}
+	ctrl.SuccessWithMessage(c, "发送成功", nil)
+}
+
+func (ctrl TrafficAlertController) writeTrafficAlertError(c httpInterfaces.RouterContextInterface, err error) {
+	switch {
+	case errors.Is(err, service.ErrTrafficAlertNotFound), errors.Is(err, service.ErrAlertChannelNotFound):
+		ctrl.Error(c, constants.ErrCodeNotFound, err.Error())
+	default:
+		ctrl.Error(c, constants.ErrCodeInternal, err.Error())
+	}
+}
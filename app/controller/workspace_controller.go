@@ -1,6 +1,7 @@
 package controller
 
 import (
+	"errors"
 	"strconv"
 	"strings"
 
@@ -64,6 +65,10 @@ func (ctrl WorkspaceController) UpdateWorkspace(c httpInterfaces.RouterContextIn
 		return
 	}
 	response, err := service.NewWorkspaceService(helperPkg.GetHelper()).UpdateWorkspace(middleware.GetCurrentWorkspaceID(c), &req)
+	if errors.Is(err, service.ErrInvalidTimezone) {
+		ctrl.Error(c, constants.ErrCodeBadRequest, err.Error())
+		return
+	}
 	if err != nil {
 		ctrl.Error(c, constants.ErrCodeInternal, err.Error())
 		return
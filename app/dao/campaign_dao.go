@@ -62,7 +62,7 @@ func (d *CampaignDao) Reports(workspaceID uint64, campaignID uint64, startDate,
 	query := db.Table("campaigns c").
 		Select("c.id AS campaign_id, c.name AS campaign_name, COUNT(DISTINCT sl.id) AS short_link_count, COUNT(cs.id) AS click_count, COUNT(DISTINCT cs.ip) AS unique_ips").
 		Joins("LEFT JOIN short_links sl ON sl.campaign_id = c.id AND sl.deleted_at IS NULL").
-		Joins("LEFT JOIN click_statistics cs ON cs.campaign_id = c.id").
+		Joins("LEFT JOIN click_statistics cs ON cs.campaign_id = c.id AND cs.is_duplicate = ?", false).
 		Where("c.workspace_id = ? AND c.deleted_at IS NULL", workspaceID).
 		Group("c.id, c.name").
 		Order("click_count DESC")
@@ -0,0 +1,176 @@
+package dao
+
+import (
+	"time"
+
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/model"
+	"cnb.cool/mliev/dwz/dwz-server/v2/pkg/interfaces"
+	"gorm.io/gorm"
+)
+
+// ClickExportDao 点击明细异步导出任务DAO
+type ClickExportDao struct {
+	helper interfaces.HelperInterface
+}
+
+func NewClickExportDao(helper interfaces.HelperInterface) *ClickExportDao {
+	return &ClickExportDao{helper: helper}
+}
+
+func (d *ClickExportDao) Create(job *model.ClickExportJob) error {
+	return d.helper.GetDatabase().Create(job).Error
+}
+
+func (d *ClickExportDao) FindByID(id, workspaceID uint64) (*model.ClickExportJob, error) {
+	var job model.ClickExportJob
+	err := d.helper.GetDatabase().Where("id = ? AND workspace_id = ?", id, workspaceID).First(&job).Error
+	return &job, err
+}
+
+// FindByToken 按下载令牌查找已完成的导出任务
+func (d *ClickExportDao) FindByToken(token string) (*model.ClickExportJob, error) {
+	var job model.ClickExportJob
+	err := d.helper.GetDatabase().
+		Where("download_token = ? AND status = ?", token, model.ClickExportStatusCompleted).
+		First(&job).Error
+	return &job, err
+}
+
+func (d *ClickExportDao) ListInWorkspace(workspaceID uint64, limit int) ([]model.ClickExportJob, error) {
+	var jobs []model.ClickExportJob
+	err := d.helper.GetDatabase().
+		Where("workspace_id = ?", workspaceID).
+		Order("id DESC").
+		Limit(limit).
+		Find(&jobs).Error
+	return jobs, err
+}
+
+// CountActiveInWorkspace 待执行和执行中的任务数
+func (d *ClickExportDao) CountActiveInWorkspace(workspaceID uint64) (int64, error) {
+	var count int64
+	err := d.helper.GetDatabase().Model(&model.ClickExportJob{}).
+		Where("workspace_id = ? AND status IN ?", workspaceID,
+			[]string{model.ClickExportStatusPending, model.ClickExportStatusRunning}).
+		Count(&count).Error
+	return count, err
+}
+
+// Claim 领取一个待执行或心跳超时的任务，多个实例并发领取时只有一个成功
+func (d *ClickExportDao) Claim(staleBefore time.Time) (*model.ClickExportJob, error) {
+	db := d.helper.GetDatabase()
+	var candidates []model.ClickExportJob
+	if err := db.Where("status = ? OR (status = ? AND (heartbeat_at IS NULL OR heartbeat_at < ?))",
+		model.ClickExportStatusPending, model.ClickExportStatusRunning, staleBefore).
+		Order("id").
+		Limit(5).
+		Find(&candidates).Error; err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	for _, candidate := range candidates {
+		updates := map[string]any{
+			"status":        model.ClickExportStatusRunning,
+			"claim_version": gorm.Expr("claim_version + 1"),
+			"heartbeat_at":  now,
+		}
+		if candidate.StartedAt == nil {
+			updates["started_at"] = now
+		}
+		result := db.Model(&model.ClickExportJob{}).
+			Where("id = ? AND status = ? AND claim_version = ?", candidate.ID, candidate.Status, candidate.ClaimVersion).
+			Updates(updates)
+		if result.Error != nil {
+			return nil, result.Error
+		}
+		if result.RowsAffected == 0 {
+			continue
+		}
+		var job model.ClickExportJob
+		if err := db.First(&job, candidate.ID).Error; err != nil {
+			return nil, err
+		}
+		return &job, nil
+	}
+	return nil, nil
+}
+
+// UpdateClaimed 更新本实例领取的执行中任务，任务已被取消或被其他实例重新领取时返回 false
+func (d *ClickExportDao) UpdateClaimed(job *model.ClickExportJob, updates map[string]any) (bool, error) {
+	result := d.helper.GetDatabase().Model(&model.ClickExportJob{}).
+		Where("id = ? AND status = ? AND claim_version = ?", job.ID, model.ClickExportStatusRunning, job.ClaimVersion).
+		Updates(updates)
+	return result.RowsAffected > 0, result.Error
+}
+
+// AppendChunk 写入一个导出分段并更新任务进度，两者在同一事务中提交；
+// 任务已被取消或被其他实例重新领取时不写入并返回 false
+func (d *ClickExportDao) AppendChunk(job *model.ClickExportJob, chunk *model.ClickExportChunk, updates map[string]any) (bool, error) {
+	owned := false
+	err := d.helper.GetDatabase().Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&model.ClickExportJob{}).
+			Where("id = ? AND status = ? AND claim_version = ?", job.ID, model.ClickExportStatusRunning, job.ClaimVersion).
+			Updates(updates)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		owned = true
+		return tx.Create(chunk).Error
+	})
+	return owned && err == nil, err
+}
+
+// NextChunkSeq 下一个分段序号
+func (d *ClickExportDao) NextChunkSeq(jobID uint64) (int, error) {
+	var seq int
+	err := d.helper.GetDatabase().Model(&model.ClickExportChunk{}).
+		Where("job_id = ?", jobID).
+		Select("COALESCE(MAX(seq) + 1, 0)").
+		Scan(&seq).Error
+	return seq, err
+}
+
+// NextChunk 序号大于 afterSeq 的下一个分段，没有更多分段时返回 gorm.ErrRecordNotFound
+func (d *ClickExportDao) NextChunk(jobID uint64, afterSeq int) (*model.ClickExportChunk, error) {
+	var chunk model.ClickExportChunk
+	err := d.helper.GetDatabase().Where("job_id = ? AND seq > ?", jobID, afterSeq).Order("seq").First(&chunk).Error
+	return &chunk, err
+}
+
+func (d *ClickExportDao) DeleteChunks(jobID uint64) error {
+	return d.helper.GetDatabase().Where("job_id = ?", jobID).Delete(&model.ClickExportChunk{}).Error
+}
+
+// Cancel 取消待执行或执行中的任务
+func (d *ClickExportDao) Cancel(id, workspaceID uint64) (bool, error) {
+	now := time.Now()
+	result := d.helper.GetDatabase().Model(&model.ClickExportJob{}).
+		Where("id = ? AND workspace_id = ? AND status IN ?", id, workspaceID,
+			[]string{model.ClickExportStatusPending, model.ClickExportStatusRunning}).
+		Updates(map[string]any{"status": model.ClickExportStatusCancelled, "finished_at": now})
+	return result.RowsAffected > 0, result.Error
+}
+
+// ListFinishedWithFiles 列出已结束但仍留有文件的任务：已取消、失败或下载链接已过期
+func (d *ClickExportDao) ListFinishedWithFiles(now time.Time, limit int) ([]model.ClickExportJob, error) {
+	var jobs []model.ClickExportJob
+	err := d.helper.GetDatabase().
+		Where("file_name <> '' AND (status IN ? OR (status = ? AND expires_at < ?))",
+			[]string{model.ClickExportStatusCancelled, model.ClickExportStatusFailed},
+			model.ClickExportStatusCompleted, now).
+		Order("id").
+		Limit(limit).
+		Find(&jobs).Error
+	return jobs, err
+}
+
+// MarkFileRemoved 文件删除后清空文件信息，已完成的任务标记为已过期
+func (d *ClickExportDao) MarkFileRemoved(job *model.ClickExportJob) error {
+	updates := map[string]any{"file_name": "", "file_size": 0, "download_token": ""}
+	if job.Status == model.ClickExportStatusCompleted {
+		updates["status"] = model.ClickExportStatusExpired
+	}
+	return d.helper.GetDatabase().Model(&model.ClickExportJob{}).
+		Where("id = ? AND status = ?", job.ID, job.Status).
+		Updates(updates).Error
+}
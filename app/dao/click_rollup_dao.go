@@ -0,0 +1,317 @@
+package dao
+
+import (
+	"errors"
+	"time"
+
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/dto"
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/model"
+	"cnb.cool/mliev/dwz/dwz-server/v2/pkg/interfaces"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ClickRollupAggregate 汇总表按维度取值合计的点击数
+type ClickRollupAggregate struct {
+	Dimension      string
+	Country        string
+	Province       string
+	DimensionValue string
+	Label          string
+	IsBot          bool
+	Clicks         int64
+}
+
+// ClickRollupBucket 小时桶的点击数
+type ClickRollupBucket struct {
+	BucketStart time.Time
+	Clicks      int64
+}
+
+// ClickRollupDao 点击汇总DAO
+type ClickRollupDao struct {
+	helper interfaces.HelperInterface
+}
+
+func NewClickRollupDao(helper interfaces.HelperInterface) *ClickRollupDao {
+	return &ClickRollupDao{helper: helper}
+}
+
+// Cursor 获取增量汇总游标，不存在时创建
+func (d *ClickRollupDao) Cursor() (*model.ClickRollupCursor, error) {
+	cursor := model.ClickRollupCursor{Name: model.ClickRollupCursorName}
+	err := d.helper.GetDatabase().Where("name = ?", cursor.Name).FirstOrCreate(&cursor).Error
+	return &cursor, err
+}
+
+// ListClicksAfter 按ID顺序读取尚未汇总、且写入时间早于 settledBefore 的点击记录。
+// 点击并发写入，较小的ID可能晚于较大的ID提交，遇到仍在等待期内的点击即停止，游标不会越过尚未提交的点击。
+// settledBefore 为零值时不等待。
+func (d *ClickRollupDao) ListClicksAfter(afterID uint64, settledBefore time.Time, limit int) ([]model.ClickStatistic, error) {
+	db := d.helper.GetDatabase()
+	query := db.Where("id > ?", afterID)
+	if !settledBefore.IsZero() {
+		var unsettledID uint64
+		if err := db.Model(&model.ClickStatistic{}).
+			Where("id > ? AND created_at >= ?", afterID, settledBefore).
+			Select("COALESCE(MIN(id), 0)").
+			Scan(&unsettledID).Error; err != nil {
+			return nil, err
+		}
+		query = query.Where("created_at < ?", settledBefore)
+		if unsettledID > 0 {
+			query = query.Where("id < ?", unsettledID)
+		}
+	}
+	var statistics []model.ClickStatistic
+	err := query.Order("id").Limit(limit).Find(&statistics).Error
+	return statistics, err
+}
+
+// ListWorkspaceClicks 重建时按ID顺序读取工作区在时间范围内、不超过 maxID 的点击记录
+func (d *ClickRollupDao) ListWorkspaceClicks(workspaceID uint64, start, end time.Time, afterID, maxID uint64, limit int) ([]model.ClickStatistic, error) {
+	var statistics []model.ClickStatistic
+	query := d.helper.GetDatabase().Where("workspace_id = ? AND id > ? AND id <= ?", workspaceID, afterID, maxID)
+	if !start.IsZero() {
+		query = query.Where("click_date >= ?", start)
+	}
+	if !end.IsZero() {
+		query = query.Where("click_date < ?", end)
+	}
+	err := query.Order("id").Limit(limit).Find(&statistics).Error
+	return statistics, err
+}
+
+// ApplyIncrement 写入一批增量和访客草图并推进游标；游标已被其他实例推进时放弃本批，返回 false
+func (d *ClickRollupDao) ApplyIncrement(fromID, toID uint64, rollups []model.ClickRollup, sketches []model.VisitorSketch) (bool, error) {
+	applied := false
+	err := d.helper.GetDatabase().Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&model.ClickRollupCursor{}).
+			Where("name = ? AND last_click_id = ?", model.ClickRollupCursorName, fromID).
+			Updates(map[string]any{"last_click_id": toID, "updated_at": time.Now()})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		applied = true
+		if err := d.upsert(tx, rollups); err != nil {
+			return err
+		}
+		return NewVisitorDao(d.helper).MergeSketches(tx, sketches)
+	})
+	return applied && err == nil, err
+}
+
+// Upsert 累加汇总行
+func (d *ClickRollupDao) Upsert(rollups []model.ClickRollup) error {
+	return d.upsert(d.helper.GetDatabase(), rollups)
+}
+
+// ReplaceRange 用重建结果替换工作区在时间范围内的汇总，删除与写入在同一事务内，读取方不会看到半成品。
+// 先更新游标行取得行锁，与增量汇总互斥；重建读取明细（不超过 builtUpTo）之后游标又推进时，
+// 这部分点击已计入旧汇总，由 build 连同重建结果一起写入新汇总，游标之后的点击仍由增量汇总计入。
+func (d *ClickRollupDao) ReplaceRange(workspaceID uint64, start, end time.Time, builtUpTo uint64, build func(tail []model.ClickStatistic) []model.ClickRollup) error {
+	if _, err := d.Cursor(); err != nil {
+		return err
+	}
+	return d.helper.GetDatabase().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.ClickRollupCursor{}).
+			Where("name = ?", model.ClickRollupCursorName).
+			Update("updated_at", time.Now()).Error; err != nil {
+			return err
+		}
+		var cursor model.ClickRollupCursor
+		if err := tx.Where("name = ?", model.ClickRollupCursorName).First(&cursor).Error; err != nil {
+			return err
+		}
+		var tail []model.ClickStatistic
+		if cursor.LastClickID > builtUpTo {
+			if err := tx.Where("workspace_id = ? AND id > ? AND id <= ? AND click_date >= ? AND click_date < ?", workspaceID, builtUpTo, cursor.LastClickID, start, end).
+				Order("id").
+				Find(&tail).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Where("workspace_id = ? AND bucket_start >= ? AND bucket_start < ?", workspaceID, start, end).
+			Delete(&model.ClickRollup{}).Error; err != nil {
+			return err
+		}
+		return d.upsert(tx, build(tail))
+	})
+}
+
+// Span 工作区点击明细与汇总覆盖的最早、最晚时间，均不存在时返回零值
+func (d *ClickRollupDao) Span(workspaceID uint64) (time.Time, time.Time, error) {
+	db := d.helper.GetDatabase()
+	var first, last time.Time
+	merge := func(at time.Time) {
+		if first.IsZero() || at.Before(first) {
+			first = at
+		}
+		if last.IsZero() || at.After(last) {
+			last = at
+		}
+	}
+	for _, order := range []string{"ASC", "DESC"} {
+		var statistic model.ClickStatistic
+		err := db.Select("id, click_date").Where("workspace_id = ?", workspaceID).Order("click_date " + order).Take(&statistic).Error
+		if err == nil {
+			merge(statistic.ClickDate)
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return first, last, err
+		}
+		var rollup model.ClickRollup
+		err = db.Select("id, bucket_start").Where("workspace_id = ?", workspaceID).Order("bucket_start " + order).Take(&rollup).Error
+		if err == nil {
+			merge(rollup.BucketStart)
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return first, last, err
+		}
+	}
+	return first, last, nil
+}
+
+// Breakdown 按维度取值合计点击数，dimensions 为空时返回全部维度
+func (d *ClickRollupDao) Breakdown(workspaceID uint64, req *dto.ClickStatisticListRequest, dimensions []string) ([]ClickRollupAggregate, error) {
+	var rows []ClickRollupAggregate
+	query := d.applyFilters(d.helper.GetDatabase().Model(&model.ClickRollup{}), workspaceID, req)
+	query = applyRollupRange(query, req.StartDate, req.EndDate)
+	if len(dimensions) > 0 {
+		query = query.Where("click_rollups.dimension IN ?", dimensions)
+	}
+	err := query.
+		Select("click_rollups.dimension, click_rollups.country, click_rollups.province, click_rollups.dimension_value, MAX(click_rollups.label) AS label, click_rollups.is_bot, SUM(click_rollups.clicks) AS clicks").
+		Group("click_rollups.dimension, click_rollups.country, click_rollups.province, click_rollups.dimension_value, click_rollups.is_bot").
+		Scan(&rows).Error
+	return rows, err
+}
+
+// Timeline 按小时桶合计点击数
+func (d *ClickRollupDao) Timeline(workspaceID uint64, req *dto.ClickStatisticListRequest) ([]ClickRollupBucket, error) {
+	var rows []ClickRollupBucket
+	query := d.applyFilters(d.helper.GetDatabase().Model(&model.ClickRollup{}), workspaceID, req).
+		Where("click_rollups.granularity = ? AND click_rollups.dimension = ?", model.ClickRollupGranularityHour, model.ClickRollupDimensionTotal)
+	if !req.StartDate.IsZero() {
+		query = query.Where("click_rollups.bucket_start >= ?", req.StartDate)
+	}
+	if !req.EndDate.IsZero() {
+		query = query.Where("click_rollups.bucket_start < ?", req.EndDate)
+	}
+	err := query.
+		Select("click_rollups.bucket_start, SUM(click_rollups.clicks) AS clicks").
+		Group("click_rollups.bucket_start").
+		Order("click_rollups.bucket_start").
+		Scan(&rows).Error
+	return rows, err
+}
+
+// TrafficMixInWorkspace 从小时汇总统计各短网址的点击构成，每次点击在地区维度恰好计一次
+func (d *ClickRollupDao) TrafficMixInWorkspace(workspaceID uint64, start, end time.Time) ([]TrafficMixCount, error) {
+	var counts []TrafficMixCount
+	err := d.helper.GetDatabase().Model(&model.ClickRollup{}).
+		Where("workspace_id = ? AND granularity = ? AND dimension = ?", workspaceID, model.ClickRollupGranularityHour, model.ClickRollupDimensionRegion).
+		Where("bucket_start >= ? AND bucket_start < ?", start, end).
+		Select("short_link_id, is_bot, country, SUM(clicks) AS clicks").
+		Group("short_link_id, is_bot, country").
+		Scan(&counts).Error
+	return counts, err
+}
+
+func (d *ClickRollupDao) applyFilters(query *gorm.DB, workspaceID uint64, req *dto.ClickStatisticListRequest) *gorm.DB {
+	query = query.Where("click_rollups.workspace_id = ?", workspaceID)
+	if req.ShortLinkID > 0 {
+		query = query.Where("click_rollups.short_link_id = ?", req.ShortLinkID)
+	}
+	if req.CampaignID > 0 {
+		query = query.Where("click_rollups.campaign_id = ?", req.CampaignID)
+	}
+	if req.TagID > 0 {
+		query = query.Where("click_rollups.short_link_id IN (SELECT short_link_id FROM short_link_tags WHERE tag_id = ?)", req.TagID)
+	}
+	if len(req.FolderIDs) > 0 {
+		query = query.Where("click_rollups.short_link_id IN (SELECT id FROM short_links WHERE workspace_id = ? AND folder_id IN ?)", workspaceID, req.FolderIDs)
+	} else if req.FolderID > 0 {
+		query = query.Where("click_rollups.short_link_id IN (SELECT id FROM short_links WHERE workspace_id = ? AND folder_id = ?)", workspaceID, req.FolderID)
+	}
+	if req.IsBot != nil {
+		query = query.Where("click_rollups.is_bot = ?", *req.IsBot)
+	}
+	if req.Country != "" {
+		query = query.Where("click_rollups.country = ?", req.Country)
+	}
+	if req.Province != "" {
+		query = query.Where("click_rollups.province = ?", req.Province)
+	}
+	return query
+}
+
+func (d *ClickRollupDao) upsert(db *gorm.DB, rollups []model.ClickRollup) error {
+	if len(rollups) == 0 {
+		return nil
+	}
+	// 冲突时累加点击数，MySQL 使用 VALUES() 引用待插入的值
+	mysql := d.helper.GetConfig().GetString("database.driver", "mysql") == "mysql"
+	value := func(column string) string {
+		if mysql {
+			return "VALUES(" + column + ")"
+		}
+		return "excluded." + column
+	}
+	return db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{
+			{Name: "workspace_id"}, {Name: "short_link_id"}, {Name: "campaign_id"}, {Name: "is_bot"}, {Name: "granularity"},
+			{Name: "bucket_start"}, {Name: "dimension"}, {Name: "country"}, {Name: "province"}, {Name: "dimension_value"},
+		},
+		DoUpdates: clause.Assignments(map[string]any{
+			"clicks":     gorm.Expr("click_rollups.clicks + " + value("clicks")),
+			"label":      gorm.Expr(value("label")),
+			"updated_at": gorm.Expr(value("updated_at")),
+		}),
+	}).CreateInBatches(rollups, 500).Error
+}
+
+// applyRollupRange 整天的部分读取天汇总，首尾不足一天的部分读取小时汇总
+func applyRollupRange(query *gorm.DB, start, end time.Time) *gorm.DB {
+	dayStart, dayEnd := start, end
+	if !start.IsZero() {
+		dayStart = ceilRollupDay(start)
+	}
+	if !end.IsZero() {
+		dayEnd = floorRollupDay(end)
+	}
+	if !start.IsZero() && !end.IsZero() && !dayStart.Before(dayEnd) {
+		return query.Where("click_rollups.granularity = ? AND click_rollups.bucket_start >= ? AND click_rollups.bucket_start < ?", model.ClickRollupGranularityHour, start, end)
+	}
+
+	daySQL := "click_rollups.granularity = ?"
+	args := []any{model.ClickRollupGranularityDay}
+	if !dayStart.IsZero() {
+		daySQL += " AND click_rollups.bucket_start >= ?"
+		args = append(args, dayStart)
+	}
+	if !dayEnd.IsZero() {
+		daySQL += " AND click_rollups.bucket_start < ?"
+		args = append(args, dayEnd)
+	}
+	conditionSQL := "(" + daySQL + ")"
+	if !start.IsZero() && start.Before(dayStart) {
+		conditionSQL += " OR (click_rollups.granularity = ? AND click_rollups.bucket_start >= ? AND click_rollups.bucket_start < ?)"
+		args = append(args, model.ClickRollupGranularityHour, start, dayStart)
+	}
+	if !end.IsZero() && dayEnd.Before(end) {
+		conditionSQL += " OR (click_rollups.granularity = ? AND click_rollups.bucket_start >= ? AND click_rollups.bucket_start < ?)"
+		args = append(args, model.ClickRollupGranularityHour, dayEnd, end)
+	}
+	return query.Where(conditionSQL, args...)
+}
+
+func floorRollupDay(t time.Time) time.Time {
+	return model.ClickRollupBucketStart(t, model.ClickRollupGranularityDay)
+}
+
+func ceilRollupDay(t time.Time) time.Time {
+	day := floorRollupDay(t)
+	if day.Equal(t) {
+		return day
+	}
+	return day.AddDate(0, 0, 1)
+}
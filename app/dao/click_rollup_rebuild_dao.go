@@ -0,0 +1,84 @@
+package dao
+
+import (
+	"time"
+
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/model"
+	"cnb.cool/mliev/dwz/dwz-server/v2/pkg/interfaces"
+	"gorm.io/gorm"
+)
+
+// ClickRollupRebuildDao 点击汇总重建任务DAO
+type ClickRollupRebuildDao struct {
+	helper interfaces.HelperInterface
+}
+
+func NewClickRollupRebuildDao(helper interfaces.HelperInterface) *ClickRollupRebuildDao {
+	return &ClickRollupRebuildDao{helper: helper}
+}
+
+func (d *ClickRollupRebuildDao) Create(job *model.ClickRollupRebuildJob) error {
+	return d.helper.GetDatabase().Create(job).Error
+}
+
+func (d *ClickRollupRebuildDao) FindByID(id, workspaceID uint64) (*model.ClickRollupRebuildJob, error) {
+	var job model.ClickRollupRebuildJob
+	err := d.helper.GetDatabase().Where("id = ? AND workspace_id = ?", id, workspaceID).First(&job).Error
+	return &job, err
+}
+
+// CountActiveInWorkspace 工作区待执行和执行中的重建任务数
+func (d *ClickRollupRebuildDao) CountActiveInWorkspace(workspaceID uint64) (int64, error) {
+	var count int64
+	err := d.helper.GetDatabase().Model(&model.ClickRollupRebuildJob{}).
+		Where("workspace_id = ? AND status IN ?", workspaceID, []string{model.ClickRollupRebuildStatusPending, model.ClickRollupRebuildStatusRunning}).
+		Count(&count).Error
+	return count, err
+}
+
+// Claim 领取一个待执行或心跳超时的任务，多个实例并发领取时只有一个成功
+func (d *ClickRollupRebuildDao) Claim(staleBefore time.Time) (*model.ClickRollupRebuildJob, error) {
+	db := d.helper.GetDatabase()
+	var candidates []model.ClickRollupRebuildJob
+	if err := db.Where("status = ? OR (status = ? AND (heartbeat_at IS NULL OR heartbeat_at < ?))",
+		model.ClickRollupRebuildStatusPending, model.ClickRollupRebuildStatusRunning, staleBefore).
+		Order("id").
+		Limit(5).
+		Find(&candidates).Error; err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	for _, candidate := range candidates {
+		updates := map[string]any{
+			"status":        model.ClickRollupRebuildStatusRunning,
+			"claim_version": gorm.Expr("claim_version + 1"),
+			"heartbeat_at":  now,
+		}
+		if candidate.StartedAt == nil {
+			updates["started_at"] = now
+		}
+		result := db.Model(&model.ClickRollupRebuildJob{}).
+			Where("id = ? AND status = ? AND claim_version = ?", candidate.ID, candidate.Status, candidate.ClaimVersion).
+			Updates(updates)
+		if result.Error != nil {
+			return nil, result.Error
+		}
+		if result.RowsAffected == 0 {
+			continue
+		}
+		var job model.ClickRollupRebuildJob
+		if err := db.First(&job, candidate.ID).Error; err != nil {
+			return nil, err
+		}
+		return &job, nil
+	}
+	return nil, nil
+}
+
+// UpdateClaimed 更新本实例领取的执行中任务，任务已被其他实例重新领取时返回 false
+func (d *ClickRollupRebuildDao) UpdateClaimed(job *model.ClickRollupRebuildJob, updates map[string]any) (bool, error) {
+	result := d.helper.GetDatabase().Model(&model.ClickRollupRebuildJob{}).
+		Where("id = ? AND status = ? AND claim_version = ?", job.ID, model.ClickRollupRebuildStatusRunning, job.ClaimVersion).
+		Updates(updates)
+	return result.RowsAffected > 0, result.Error
+}
@@ -23,6 +23,16 @@ func (d *ClickStatisticDao) Create(statistic *model.ClickStatistic) error {
 	return d.helper.GetDatabase().Create(statistic).Error
 }
 
+// ExistsRecentVisitorClick 同一访客在 since 之后是否已有该短网址的非重复点击
+func (d *ClickStatisticDao) ExistsRecentVisitorClick(shortLinkID uint64, visitorKey string, since time.Time) (bool, error) {
+	var count int64
+	err := d.helper.GetDatabase().Model(&model.ClickStatistic{}).
+		Where("short_link_id = ? AND visitor_key = ? AND click_date >= ? AND is_duplicate = ?", shortLinkID, visitorKey, since, false).
+		Limit(1).
+		Count(&count).Error
+	return count > 0, err
+}
+
 // List 获取点击统计列表
 func (d *ClickStatisticDao) List(req *dto.ClickStatisticListRequest) ([]model.ClickStatistic, int64, error) {
 	var statistics []model.ClickStatistic
@@ -94,6 +104,140 @@ func (d *ClickStatisticDao) ExportInWorkspace(workspaceID uint64, req *dto.Click
 	return statistics, err
 }
 
+// ListAfterIDInWorkspace 读取ID大于 afterID 且符合筛选条件的点击记录，用于补足尚未汇总的部分
+func (d *ClickStatisticDao) ListAfterIDInWorkspace(workspaceID uint64, req *dto.ClickStatisticListRequest, afterID uint64, limit int) ([]model.ClickStatistic, error) {
+	var statistics []model.ClickStatistic
+	err := d.applyFilters(d.helper.GetDatabase().Model(&model.ClickStatistic{}), workspaceID, req).
+		Where("click_statistics.id > ?", afterID).
+		Order("click_statistics.id").
+		Limit(limit).
+		Find(&statistics).Error
+	return statistics, err
+}
+
+// ListExportChunkInWorkspace 按ID升序读取 (afterID, maxID] 区间内符合筛选条件的点击记录，用于异步导出分批读取
+func (d *ClickStatisticDao) ListExportChunkInWorkspace(workspaceID uint64, req *dto.ClickStatisticListRequest, afterID, maxID uint64, limit int) ([]model.ClickStatistic, error) {
+	var statistics []model.ClickStatistic
+	err := d.applyFilters(d.helper.GetDatabase().Model(&model.ClickStatistic{}), workspaceID, req).
+		Where("click_statistics.id > ? AND click_statistics.id <= ?", afterID, maxID).
+		Order("click_statistics.id").
+		Limit(limit).
+		Find(&statistics).Error
+	return statistics, err
+}
+
+// GetMaxID 获取当前最大点击ID
+func (d *ClickStatisticDao) GetMaxID() (uint64, error) {
+	var maxID uint64
+	err := d.helper.GetDatabase().Model(&model.ClickStatistic{}).
+		Select("COALESCE(MAX(id), 0)").
+		Row().Scan(&maxID)
+	return maxID, err
+}
+
+// ListUnclassifiedReferrers 读取尚未分类来源渠道的点击记录
+func (d *ClickStatisticDao) ListUnclassifiedReferrers(limit int) ([]model.ClickStatistic, error) {
+	var statistics []model.ClickStatistic
+	err := d.helper.GetDatabase().
+		Where("referer_channel IS NULL OR referer_channel = ''").
+		Order("id").
+		Limit(limit).
+		Find(&statistics).Error
+	return statistics, err
+}
+
+// UpdateReferrer 更新点击的来源主机名和渠道
+func (d *ClickStatisticDao) UpdateReferrer(id uint64, host, channel string) error {
+	return d.helper.GetDatabase().Model(&model.ClickStatistic{}).
+		Where("id = ?", id).
+		Updates(map[string]any{"referer_host": host, "referer_channel": channel}).Error
+}
+
+// ReferrerChannelHostCount 按来源渠道和主机名分组的点击数
+type ReferrerChannelHostCount struct {
+	Channel string
+	Host    string
+	Clicks  int64
+}
+
+// CountByReferrerChannelInWorkspace 按来源渠道和主机名分组统计点击数
+func (d *ClickStatisticDao) CountByReferrerChannelInWorkspace(workspaceID uint64, req *dto.ClickStatisticListRequest) ([]ReferrerChannelHostCount, error) {
+	var counts []ReferrerChannelHostCount
+	err := d.applyFilters(d.helper.GetDatabase().Model(&model.ClickStatistic{}), workspaceID, req).
+		Select("COALESCE(click_statistics.referer_channel, '') AS channel, COALESCE(click_statistics.referer_host, '') AS host, COUNT(*) AS clicks").
+		Group("click_statistics.referer_channel, click_statistics.referer_host").
+		Scan(&counts).Error
+	return counts, err
+}
+
+// TrafficMixCount 按短网址、是否机器人和国家分组的点击数，用于流量异常检测
+type TrafficMixCount struct {
+	ShortLinkID uint64
+	IsBot       bool
+	Country     string
+	Clicks      int64
+}
+
+// CountTrafficMixInWorkspace 统计时间范围内各短网址的点击构成
+func (d *ClickStatisticDao) CountTrafficMixInWorkspace(workspaceID uint64, start, end time.Time) ([]TrafficMixCount, error) {
+	var counts []TrafficMixCount
+	err := d.helper.GetDatabase().Model(&model.ClickStatistic{}).
+		Where("workspace_id = ? AND click_date >= ? AND click_date < ? AND is_duplicate = ?", workspaceID, start, end, false).
+		Select("short_link_id, is_bot, COALESCE(country, '') AS country, COUNT(*) AS clicks").
+		Group("short_link_id, is_bot, country").
+		Scan(&counts).Error
+	return counts, err
+}
+
+// CountUniqueIPsInWorkspace 独立IP数
+func (d *ClickStatisticDao) CountUniqueIPsInWorkspace(workspaceID uint64, req *dto.ClickStatisticListRequest) (int64, error) {
+	var count int64
+	err := d.applyFilters(d.helper.GetDatabase().Model(&model.ClickStatistic{}), workspaceID, req).
+		Distinct("ip").
+		Count(&count).Error
+	return count, err
+}
+
+// CountUniqueVisitorsInWorkspace 按访客标识精确去重的独立访客数，不含机器人
+func (d *ClickStatisticDao) CountUniqueVisitorsInWorkspace(workspaceID uint64, req *dto.ClickStatisticListRequest) (int64, error) {
+	var count int64
+	err := d.applyFilters(d.helper.GetDatabase().Model(&model.ClickStatistic{}), workspaceID, req).
+		Where("click_statistics.is_bot = ? AND click_statistics.visitor_key != ''", false).
+		Distinct("click_statistics.visitor_key").
+		Count(&count).Error
+	return count, err
+}
+
+// ListVisitorKeysAfterInWorkspace 读取ID大于 afterID 的点击中的访客标识，不含机器人
+func (d *ClickStatisticDao) ListVisitorKeysAfterInWorkspace(workspaceID uint64, req *dto.ClickStatisticListRequest, afterID uint64, limit int) ([]string, error) {
+	var keys []string
+	err := d.applyFilters(d.helper.GetDatabase().Model(&model.ClickStatistic{}), workspaceID, req).
+		Where("click_statistics.id > ? AND click_statistics.is_bot = ? AND click_statistics.visitor_key != ''", afterID, false).
+		Distinct("click_statistics.visitor_key").
+		Limit(limit).
+		Pluck("click_statistics.visitor_key", &keys).Error
+	return keys, err
+}
+
+// FilterShortLinkIDsInWorkspace 按短网址、标签、文件夹筛选条件列出短网址ID，包括回收站中的短网址
+func (d *ClickStatisticDao) FilterShortLinkIDsInWorkspace(workspaceID uint64, req *dto.ClickStatisticListRequest) ([]uint64, error) {
+	var ids []uint64
+	query := d.helper.GetDatabase().Table("short_links").Where("short_links.workspace_id = ?", workspaceID)
+	if req.ShortLinkID > 0 {
+		query = query.Where("short_links.id = ?", req.ShortLinkID)
+	}
+	if req.TagID > 0 {
+		query = query.Where("short_links.id IN (SELECT short_link_id FROM short_link_tags WHERE tag_id = ?)", req.TagID)
+	}
+	if len(req.FolderIDs) > 0 {
+		query = query.Where("short_links.folder_id IN ?", req.FolderIDs)
+	} else if req.FolderID > 0 {
+		query = query.Where("short_links.folder_id = ?", req.FolderID)
+	}
+	err := query.Pluck("short_links.id", &ids).Error
+	return ids, err
+}
+
 func (d *ClickStatisticDao) applyFilters(query *gorm.DB, workspaceID uint64, req *dto.ClickStatisticListRequest) *gorm.DB {
 	query = query.Where("click_statistics.workspace_id = ?", workspaceID)
 	if req.ShortLinkID > 0 {
@@ -105,15 +249,35 @@ func (d *ClickStatisticDao) applyFilters(query *gorm.DB, workspaceID uint64, req
 	if req.RouteID > 0 {
 		query = query.Where("click_statistics.route_id = ?", req.RouteID)
 	}
+	if req.AliasID > 0 {
+		query = query.Where("click_statistics.alias_id = ?", req.AliasID)
+	}
 	if req.TagID > 0 {
 		query = query.Joins("JOIN short_link_tags slt ON slt.short_link_id = click_statistics.short_link_id AND slt.tag_id = ?", req.TagID)
 	}
+	if len(req.FolderIDs) > 0 {
+		query = query.Where("click_statistics.short_link_id IN (SELECT id FROM short_links WHERE workspace_id = ? AND folder_id IN ?)", workspaceID, req.FolderIDs)
+	} else if req.FolderID > 0 {
+		query = query.Where("click_statistics.short_link_id IN (SELECT id FROM short_links WHERE workspace_id = ? AND folder_id = ?)", workspaceID, req.FolderID)
+	}
 	if req.DeviceType != "" {
 		query = query.Where("click_statistics.device_type = ?", req.DeviceType)
 	}
 	if req.IsBot != nil {
 		query = query.Where("click_statistics.is_bot = ?", *req.IsBot)
 	}
+	if req.MinBotScore != nil {
+		query = query.Where("click_statistics.bot_score >= ?", *req.MinBotScore)
+	}
+	if req.MaxBotScore != nil {
+		query = query.Where("click_statistics.bot_score <= ?", *req.MaxBotScore)
+	}
+	if req.BotReason != "" {
+		query = query.Where("click_statistics.bot_reason LIKE ?", "%"+req.BotReason+"%")
+	}
+	if !req.WithDuplicates {
+		query = query.Where("click_statistics.is_duplicate = ?", false)
+	}
 	if req.IP != "" {
 		query = query.Where("click_statistics.ip = ?", req.IP)
 	}
@@ -129,6 +293,9 @@ func (d *ClickStatisticDao) applyFilters(query *gorm.DB, workspaceID uint64, req
 	if req.ISP != "" {
 		query = query.Where("click_statistics.isp = ?", req.ISP)
 	}
+	if req.RefererChannel != "" {
+		query = query.Where("click_statistics.referer_channel = ?", req.RefererChannel)
+	}
 	if !req.StartDate.IsZero() {
 		query = query.Where("click_statistics.click_date >= ?", req.StartDate)
 	}
@@ -287,12 +454,14 @@ func (d *ClickStatisticDao) GetAnalysisInWorkspace(workspaceID uint64, req *dto.
 	group("city, COUNT(*) as count", "city != ''", "city", "count DESC", &analysis.TopCities)
 	group("isp, COUNT(*) as count", "isp != ''", "isp", "count DESC", &analysis.TopISPs)
 	group("referer, COUNT(*) as count", "referer != ''", "referer", "count DESC", &analysis.TopReferers)
+	analysis.TopRefererHosts = d.topRefererHosts(workspaceID, req)
 	group("device_type, COUNT(*) as count", "device_type != ''", "device_type", "count DESC", &analysis.TopDevices)
 	group("browser, COUNT(*) as count", "browser != ''", "browser", "count DESC", &analysis.TopBrowsers)
 	group("os, COUNT(*) as count", "os != ''", "os", "count DESC", &analysis.TopOS)
 	group("utm_source AS value, COUNT(*) as count", "utm_source != ''", "utm_source", "count DESC", &analysis.TopUTMSources)
 	group("utm_campaign AS value, COUNT(*) as count", "utm_campaign != ''", "utm_campaign", "count DESC", &analysis.TopUTMCampaigns)
 	group("route_id, route_name, COUNT(*) as count", "route_id IS NOT NULL", "route_id, route_name", "count DESC", &analysis.TopRoutes)
+	group("alias_id, alias_code, COUNT(*) as count", "alias_id IS NOT NULL", "alias_id, alias_code", "count DESC", &analysis.TopAliases)
 
 	type botRow struct {
 		IsBot bool
@@ -311,18 +480,13 @@ func (d *ClickStatisticDao) GetAnalysisInWorkspace(workspaceID uint64, req *dto.
 		}
 	}
 
-	hourSQL := d.getHourSQL("click_date")
-	dateSQL := d.getDateSQL("click_date")
+	var slots []reportSlotCount
+	slotSQL := reportSlotSQL(d.getDBDriver(), "click_date")
 	d.applyFilters(d.helper.GetDatabase().Model(&model.ClickStatistic{}), workspaceID, req).
-		Select(hourSQL + " as hour, COUNT(*) as count").
-		Group(hourSQL).
-		Order("hour").
-		Find(&analysis.HourlyStats)
-	d.applyFilters(d.helper.GetDatabase().Model(&model.ClickStatistic{}), workspaceID, req).
-		Select(dateSQL + " as date, COUNT(*) as count").
-		Group(dateSQL).
-		Order("date").
-		Find(&analysis.DailyStats)
+		Select(slotSQL + " as slot, COUNT(*) as count").
+		Group(slotSQL).
+		Find(&slots)
+	analysis.HourlyStats, analysis.DailyStats = bucketReportSlots(d.getDBDriver(), slots, ReportLocation(req))
 
 	return analysis, nil
 }
@@ -367,44 +531,35 @@ func (d *ClickStatisticDao) GetGeoAnalysisInWorkspace(workspaceID uint64, req *d
 	return analysis, nil
 }
 
-func (d *ClickStatisticDao) getDBDriver() string {
-	return d.helper.GetConfig().GetString("database.driver", "mysql")
-}
-
-func (d *ClickStatisticDao) getHourSQL(column string) string {
-	switch d.getDBDriver() {
-	case "sqlite":
-		return "CAST(strftime('%H', " + column + ") AS INTEGER)"
-	case "postgres", "postgresql":
-		return "EXTRACT(HOUR FROM " + column + ")::INT"
-	default:
-		return "HOUR(" + column + ")"
-	}
+// topRefererHosts 按写入时解析的来源域名统计的前10名
+func (d *ClickStatisticDao) topRefererHosts(workspaceID uint64, req *dto.ClickStatisticListRequest) []dto.RefererStatistic {
+	var hosts []dto.RefererStatistic
+	d.applyFilters(d.helper.GetDatabase().Model(&model.ClickStatistic{}), workspaceID, req).
+		Select("click_statistics.referer_host AS referer, COUNT(*) as count").
+		Where("click_statistics.referer_host != ''").
+		Group("click_statistics.referer_host").
+		Order("count DESC").
+		Limit(10).
+		Find(&hosts)
+	return hosts
 }
 
-func (d *ClickStatisticDao) getDateSQL(column string) string {
-	switch d.getDBDriver() {
-	case "sqlite":
-		return "date(" + column + ")"
-	case "postgres", "postgresql":
-		return "TO_CHAR(" + column + ", 'YYYY-MM-DD')"
-	default:
-		return "DATE(" + column + ")"
-	}
+func (d *ClickStatisticDao) getDBDriver() string {
+	return d.helper.GetConfig().GetString("database.driver", "mysql")
 }
 
-// CountAll 获取所有点击统计数量
+// CountAll 获取所有点击统计数量，不含重复点击
 func (d *ClickStatisticDao) CountAll() (int64, error) {
 	var count int64
-	err := d.helper.GetDatabase().Model(&model.ClickStatistic{}).Count(&count).Error
+	err := d.helper.GetDatabase().Model(&model.ClickStatistic{}).Where("is_duplicate = ?", false).Count(&count).Error
 	return count, err
 }
 
-// CountByDateRange 获取指定时间范围内的点击统计数量
+// CountByDateRange 获取指定时间范围内的点击统计数量，不含重复点击
 func (d *ClickStatisticDao) CountByDateRange(startDate, endDate time.Time) (int64, error) {
 	var count int64
 	err := d.helper.GetDatabase().Model(&model.ClickStatistic{}).
-		Where("click_date >= ? AND click_date < ?", startDate, endDate).
+		Where("click_date >= ? AND click_date < ? AND is_duplicate = ?", startDate, endDate, false).
 		Count(&count).Error
 	return count, err
 }
@@ -0,0 +1,138 @@
+package dao
+
+import (
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/dto"
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/model"
+	"cnb.cool/mliev/dwz/dwz-server/v2/pkg/interfaces"
+	"gorm.io/gorm"
+)
+
+// ConversionGroupClicks 报表分组的点击数
+type ConversionGroupClicks struct {
+	GroupID uint64
+	Clicks  int64
+}
+
+// ConversionGroupTotals 报表分组按币种汇总的转化
+type ConversionGroupTotals struct {
+	GroupID     uint64
+	Currency    string
+	Conversions int64
+	Revenue     float64
+}
+
+type ConversionDao struct {
+	helper interfaces.HelperInterface
+}
+
+func NewConversionDao(helper interfaces.HelperInterface) *ConversionDao {
+	return &ConversionDao{helper: helper}
+}
+
+func (d *ConversionDao) Create(conversion *model.Conversion) error {
+	return d.helper.GetDatabase().Create(conversion).Error
+}
+
+func (d *ConversionDao) FindByEventID(workspaceID uint64, eventID string) (*model.Conversion, error) {
+	var conversion model.Conversion
+	err := d.helper.GetDatabase().
+		Where("workspace_id = ? AND event_id = ?", workspaceID, eventID).
+		First(&conversion).Error
+	return &conversion, err
+}
+
+// FindClick 根据点击ID查找点击记录
+func (d *ConversionDao) FindClick(clickID string) (*model.ClickStatistic, error) {
+	var click model.ClickStatistic
+	err := d.helper.GetDatabase().Where("click_id = ?", clickID).First(&click).Error
+	return &click, err
+}
+
+// GroupClicks 按报表分组统计点击数
+func (d *ConversionDao) GroupClicks(workspaceID uint64, req *dto.ConversionReportRequest) ([]ConversionGroupClicks, error) {
+	var rows []ConversionGroupClicks
+	query, group := conversionReportScope(d.helper.GetDatabase().Model(&model.ClickStatistic{}), "click_statistics", "click_date", workspaceID, req)
+	err := query.Where("click_statistics.is_duplicate = ?", false).
+		Select("COALESCE(" + group + ", 0) AS group_id, COUNT(*) AS clicks").
+		Group(group).
+		Scan(&rows).Error
+	return rows, err
+}
+
+// GroupConversions 按报表分组和币种统计转化次数与金额
+func (d *ConversionDao) GroupConversions(workspaceID uint64, req *dto.ConversionReportRequest) ([]ConversionGroupTotals, error) {
+	var rows []ConversionGroupTotals
+	query, group := conversionReportScope(d.helper.GetDatabase().Model(&model.Conversion{}), "conversions", "occurred_at", workspaceID, req)
+	err := query.Select("COALESCE(" + group + ", 0) AS group_id, conversions.currency AS currency, COUNT(*) AS conversions, COALESCE(SUM(conversions.value), 0) AS revenue").
+		Group(group + ", conversions.currency").
+		Scan(&rows).Error
+	return rows, err
+}
+
+// GroupNames 分组对象名称：短网址为 域名/短码，其余为名称
+func (d *ConversionDao) GroupNames(groupBy string, ids []uint64) (map[uint64]string, error) {
+	names := make(map[uint64]string, len(ids))
+	if len(ids) == 0 {
+		return names, nil
+	}
+	var rows []struct {
+		ID   uint64
+		Name string
+	}
+	db := d.helper.GetDatabase()
+	var err error
+	switch groupBy {
+	case "campaign":
+		err = db.Model(&model.Campaign{}).Select("id, name").Where("id IN ?", ids).Scan(&rows).Error
+	case "tag":
+		err = db.Model(&model.Tag{}).Select("id, name").Where("id IN ?", ids).Scan(&rows).Error
+	case "route":
+		err = db.Model(&model.LinkRoute{}).Unscoped().Select("id, name").Where("id IN ?", ids).Scan(&rows).Error
+	default:
+		var links []model.ShortLink
+		err = db.Unscoped().Select("id, domain, short_code").Where("id IN ?", ids).Find(&links).Error
+		for _, link := range links {
+			rows = append(rows, struct {
+				ID   uint64
+				Name string
+			}{ID: link.ID, Name: link.Domain + "/" + link.GetShortCode()})
+		}
+	}
+	for _, row := range rows {
+		names[row.ID] = row.Name
+	}
+	return names, err
+}
+
+// conversionReportScope 点击与转化共用的筛选条件，返回分组列；tag 分组通过 short_link_tags 关联当前标签
+func conversionReportScope(query *gorm.DB, table, dateColumn string, workspaceID uint64, req *dto.ConversionReportRequest) (*gorm.DB, string) {
+	query = query.Where(table+".workspace_id = ?", workspaceID)
+	if req.ShortLinkID > 0 {
+		query = query.Where(table+".short_link_id = ?", req.ShortLinkID)
+	}
+	if req.CampaignID > 0 {
+		query = query.Where(table+".campaign_id = ?", req.CampaignID)
+	}
+	if req.RouteID > 0 {
+		query = query.Where(table+".route_id = ?", req.RouteID)
+	}
+	if req.TagID > 0 {
+		query = query.Joins("JOIN short_link_tags fslt ON fslt.short_link_id = "+table+".short_link_id AND fslt.tag_id = ?", req.TagID)
+	}
+	if !req.StartDate.IsZero() {
+		query = query.Where(table+"."+dateColumn+" >= ?", req.StartDate)
+	}
+	if !req.EndDate.IsZero() {
+		query = query.Where(table+"."+dateColumn+" < ?", req.EndDate)
+	}
+	switch req.GroupBy {
+	case "campaign":
+		return query, table + ".campaign_id"
+	case "route":
+		return query, table + ".route_id"
+	case "tag":
+		return query.Joins("JOIN short_link_tags gslt ON gslt.short_link_id = " + table + ".short_link_id"), "gslt.tag_id"
+	default:
+		return query, table + ".short_link_id"
+	}
+}
@@ -0,0 +1,119 @@
+package dao
+
+import (
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/model"
+	"cnb.cool/mliev/dwz/dwz-server/v2/pkg/interfaces"
+	"gorm.io/gorm"
+)
+
+type CustomFieldDao struct {
+	helper interfaces.HelperInterface
+}
+
+func NewCustomFieldDao(helper interfaces.HelperInterface) *CustomFieldDao {
+	return &CustomFieldDao{helper: helper}
+}
+
+func (d *CustomFieldDao) Save(field *model.CustomField) error {
+	return d.helper.GetDatabase().Save(field).Error
+}
+
+// Delete 删除字段定义并清除所有短网址上该字段的取值
+func (d *CustomFieldDao) Delete(id, workspaceID uint64) error {
+	return d.helper.GetDatabase().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("field_id = ? AND workspace_id = ?", id, workspaceID).Delete(&model.ShortLinkCustomValue{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("id = ? AND workspace_id = ?", id, workspaceID).Delete(&model.CustomField{}).Error
+	})
+}
+
+func (d *CustomFieldDao) FindByID(id, workspaceID uint64) (*model.CustomField, error) {
+	var field model.CustomField
+	err := d.helper.GetDatabase().
+		Where("id = ? AND workspace_id = ? AND deleted_at IS NULL", id, workspaceID).
+		First(&field).Error
+	return &field, err
+}
+
+func (d *CustomFieldDao) ListInWorkspace(workspaceID uint64) ([]model.CustomField, error) {
+	var fields []model.CustomField
+	err := d.helper.GetDatabase().
+		Where("workspace_id = ? AND deleted_at IS NULL", workspaceID).
+		Order("position ASC, id ASC").
+		Find(&fields).Error
+	return fields, err
+}
+
+// ExistsKey 工作区内是否已有相同标识的字段
+func (d *CustomFieldDao) ExistsKey(workspaceID uint64, key string, excludeID uint64) (bool, error) {
+	var count int64
+	query := d.helper.GetDatabase().Model(&model.CustomField{}).
+		Where("workspace_id = ? AND field_key = ? AND deleted_at IS NULL", workspaceID, key)
+	if excludeID > 0 {
+		query = query.Where("id <> ?", excludeID)
+	}
+	err := query.Count(&count).Error
+	return count > 0, err
+}
+
+func (d *CustomFieldDao) ValuesByShortLinkID(shortLinkID uint64) ([]model.ShortLinkCustomValue, error) {
+	var values []model.ShortLinkCustomValue
+	err := d.helper.GetDatabase().
+		Where("short_link_id = ?", shortLinkID).
+		Order("field_id ASC").
+		Find(&values).Error
+	return values, err
+}
+
+// ValuesByShortLinkIDs 批量读取取值，按批查询避免超出数据库参数数量限制
+func (d *CustomFieldDao) ValuesByShortLinkIDs(shortLinkIDs []uint64) ([]model.ShortLinkCustomValue, error) {
+	const batchSize = 500
+	var values []model.ShortLinkCustomValue
+	for start := 0; start < len(shortLinkIDs); start += batchSize {
+		end := min(start+batchSize, len(shortLinkIDs))
+		var batch []model.ShortLinkCustomValue
+		if err := d.helper.GetDatabase().Where("short_link_id IN ?", shortLinkIDs[start:end]).Find(&batch).Error; err != nil {
+			return nil, err
+		}
+		values = append(values, batch...)
+	}
+	return values, nil
+}
+
+// ReplaceValues 在调用方的事务中写入短网址的取值，removeFieldIDs 中的字段取值被清除
+func (d *CustomFieldDao) ReplaceValues(tx *gorm.DB, shortLinkID uint64, values []model.ShortLinkCustomValue, removeFieldIDs []uint64) error {
+	fieldIDs := append([]uint64{}, removeFieldIDs...)
+	for _, value := range values {
+		fieldIDs = append(fieldIDs, value.FieldID)
+	}
+	if len(fieldIDs) > 0 {
+		if err := tx.Where("short_link_id = ? AND field_id IN ?", shortLinkID, fieldIDs).Delete(&model.ShortLinkCustomValue{}).Error; err != nil {
+			return err
+		}
+	}
+	for _, value := range values {
+		value.ShortLinkID = shortLinkID
+		if err := tx.Create(&value).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CopyValues 克隆短网址时复制自定义字段取值
+func (d *CustomFieldDao) CopyValues(tx *gorm.DB, sourceID, targetID uint64) error {
+	var values []model.ShortLinkCustomValue
+	if err := tx.Where("short_link_id = ?", sourceID).Find(&values).Error; err != nil {
+		return err
+	}
+	for _, value := range values {
+		copied := value
+		copied.ID = 0
+		copied.ShortLinkID = targetID
+		if err := tx.Create(&copied).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
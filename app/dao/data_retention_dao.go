@@ -0,0 +1,32 @@
+package dao
+
+import (
+	"time"
+
+	"cnb.cool/mliev/dwz/dwz-server/v2/pkg/interfaces"
+)
+
+// DataRetentionDao 按保留期清理点击、安全事件和操作日志
+type DataRetentionDao struct {
+	helper interfaces.HelperInterface
+}
+
+func NewDataRetentionDao(helper interfaces.HelperInterface) *DataRetentionDao {
+	return &DataRetentionDao{helper: helper}
+}
+
+// PurgeBefore 删除工作区内 column 早于 cutoff 的一批记录，maxID 不为空时只删除ID不超过它的记录，返回删除数量。
+// 先按ID查出一批再删除，避免一次删除锁住大量行。
+func (d *DataRetentionDao) PurgeBefore(value any, workspaceID uint64, column string, cutoff time.Time, maxID *uint64, limit int) (int64, error) {
+	db := d.helper.GetDatabase()
+	query := db.Model(value).Where("workspace_id = ? AND "+column+" < ?", workspaceID, cutoff)
+	if maxID != nil {
+		query = query.Where("id <= ?", *maxID)
+	}
+	var ids []uint64
+	if err := query.Order("id").Limit(limit).Pluck("id", &ids).Error; err != nil || len(ids) == 0 {
+		return 0, err
+	}
+	result := db.Unscoped().Where("id IN ?", ids).Delete(value)
+	return result.RowsAffected, result.Error
+}
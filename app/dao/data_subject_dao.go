@@ -0,0 +1,165 @@
+package dao
+
+import (
+	"strings"
+	"time"
+
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/model"
+	"cnb.cool/mliev/dwz/dwz-server/v2/pkg/interfaces"
+	"gorm.io/gorm"
+)
+
+// DataSubjectTable 含访客个人数据的数据表，VisitorKeyColumn 为空表示不记录访客标识。
+// Clicks 非空时，ClickColumn 关联的点击记录匹配也算匹配。
+type DataSubjectTable struct {
+	Name             string
+	IPColumn         string
+	VisitorKeyColumn string
+	ClickColumn      string
+	Clicks           *DataSubjectTable
+}
+
+// DataSubjectRow 匿名化时读取的记录ID和IP
+type DataSubjectRow struct {
+	ID uint64
+	IP string
+}
+
+// DataSubjectDao 数据主体请求任务，以及按访客IP、访客标识查找和删除个人数据
+type DataSubjectDao struct {
+	helper interfaces.HelperInterface
+}
+
+func NewDataSubjectDao(helper interfaces.HelperInterface) *DataSubjectDao {
+	return &DataSubjectDao{helper: helper}
+}
+
+func (d *DataSubjectDao) Create(request *model.DataSubjectRequest) error {
+	return d.helper.GetDatabase().Create(request).Error
+}
+
+func (d *DataSubjectDao) FindByID(id, workspaceID uint64) (*model.DataSubjectRequest, error) {
+	var request model.DataSubjectRequest
+	err := d.helper.GetDatabase().Where("id = ? AND workspace_id = ?", id, workspaceID).First(&request).Error
+	return &request, err
+}
+
+func (d *DataSubjectDao) ListInWorkspace(workspaceID uint64, limit int) ([]model.DataSubjectRequest, error) {
+	var requests []model.DataSubjectRequest
+	err := d.helper.GetDatabase().Where("workspace_id = ?", workspaceID).Order("id DESC").Limit(limit).Find(&requests).Error
+	return requests, err
+}
+
+// Claim 领取一个待执行或心跳超时的任务，多个实例并发领取时只有一个成功
+func (d *DataSubjectDao) Claim(staleBefore time.Time) (*model.DataSubjectRequest, error) {
+	db := d.helper.GetDatabase()
+	var candidates []model.DataSubjectRequest
+	if err := db.Where("status = ? OR (status = ? AND (heartbeat_at IS NULL OR heartbeat_at < ?))",
+		model.DataSubjectStatusPending, model.DataSubjectStatusRunning, staleBefore).
+		Order("id").
+		Limit(5).
+		Find(&candidates).Error; err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	for _, candidate := range candidates {
+		updates := map[string]any{
+			"status":        model.DataSubjectStatusRunning,
+			"claim_version": gorm.Expr("claim_version + 1"),
+			"heartbeat_at":  now,
+		}
+		if candidate.StartedAt == nil {
+			updates["started_at"] = now
+		}
+		result := db.Model(&model.DataSubjectRequest{}).
+			Where("id = ? AND status = ? AND claim_version = ?", candidate.ID, candidate.Status, candidate.ClaimVersion).
+			Updates(updates)
+		if result.Error != nil {
+			return nil, result.Error
+		}
+		if result.RowsAffected == 0 {
+			continue
+		}
+		var request model.DataSubjectRequest
+		if err := db.First(&request, candidate.ID).Error; err != nil {
+			return nil, err
+		}
+		return &request, nil
+	}
+	return nil, nil
+}
+
+// UpdateClaimed 更新本实例领取的执行中任务，任务已被其他实例重新领取时返回 false
+func (d *DataSubjectDao) UpdateClaimed(request *model.DataSubjectRequest, updates map[string]any) (bool, error) {
+	result := d.helper.GetDatabase().Model(&model.DataSubjectRequest{}).
+		Where("id = ? AND status = ? AND claim_version = ?", request.ID, model.DataSubjectStatusRunning, request.ClaimVersion).
+		Updates(updates)
+	return result.RowsAffected > 0, result.Error
+}
+
+// subjectQuery 工作区内IP或访客标识匹配的记录，ips 只包含完整IP
+func (d *DataSubjectDao) subjectQuery(table DataSubjectTable, workspaceID uint64, ips []string, visitorKey string, afterID uint64) *gorm.DB {
+	query := d.helper.GetDatabase().Table(table.Name).Where("workspace_id = ? AND id > ?", workspaceID, afterID)
+	var conditions []string
+	var args []any
+	if len(ips) > 0 {
+		conditions = append(conditions, table.IPColumn+" IN ?")
+		args = append(args, ips)
+	}
+	if visitorKey != "" && table.VisitorKeyColumn != "" {
+		conditions = append(conditions, table.VisitorKeyColumn+" = ?")
+		args = append(args, visitorKey)
+	}
+	if table.Clicks != nil && (len(ips) > 0 || visitorKey != "") {
+		conditions = append(conditions, table.ClickColumn+" IN (?)")
+		args = append(args, d.subjectQuery(*table.Clicks, workspaceID, ips, visitorKey, 0).Select("id"))
+	}
+	if len(conditions) == 0 {
+		return query.Where("1 = 0")
+	}
+	return query.Where(strings.Join(conditions, " OR "), args...)
+}
+
+func (d *DataSubjectDao) CountRecords(table DataSubjectTable, workspaceID uint64, ips []string, visitorKey string) (int64, error) {
+	var count int64
+	err := d.subjectQuery(table, workspaceID, ips, visitorKey, 0).Count(&count).Error
+	return count, err
+}
+
+// CountNetworkRecords 统计IP为 network 网段地址、且未按IP或访客标识匹配的记录数。
+// 这些记录在写入时已匿名化，无法判断是否属于该访客
+func (d *DataSubjectDao) CountNetworkRecords(table DataSubjectTable, workspaceID uint64, network string, ips []string, visitorKey string) (int64, error) {
+	var count int64
+	err := d.helper.GetDatabase().Table(table.Name).
+		Where("workspace_id = ? AND "+table.IPColumn+" = ?", workspaceID, network).
+		Where("id NOT IN (?)", d.subjectQuery(table, workspaceID, ips, visitorKey, 0).Select("id")).
+		Count(&count).Error
+	return count, err
+}
+
+// ListRecords 导出用，返回完整记录
+func (d *DataSubjectDao) ListRecords(table DataSubjectTable, workspaceID uint64, ips []string, visitorKey string, limit int) ([]map[string]any, error) {
+	var records []map[string]any
+	err := d.subjectQuery(table, workspaceID, ips, visitorKey, 0).Order("id").Limit(limit).Find(&records).Error
+	return records, err
+}
+
+// ListRows 返回 afterID 之后的一批匹配记录的ID和IP
+func (d *DataSubjectDao) ListRows(table DataSubjectTable, workspaceID uint64, ips []string, visitorKey string, afterID uint64, limit int) ([]DataSubjectRow, error) {
+	var rows []DataSubjectRow
+	err := d.subjectQuery(table, workspaceID, ips, visitorKey, afterID).
+		Select("id, " + table.IPColumn + " AS ip").
+		Order("id").
+		Limit(limit).
+		Scan(&rows).Error
+	return rows, err
+}
+
+func (d *DataSubjectDao) DeleteRows(table DataSubjectTable, ids []uint64) (int64, error) {
+	result := d.helper.GetDatabase().Table(table.Name).Where("id IN ?", ids).Delete(map[string]any{})
+	return result.RowsAffected, result.Error
+}
+
+func (d *DataSubjectDao) UpdateRows(table DataSubjectTable, ids []uint64, updates map[string]any) error {
+	return d.helper.GetDatabase().Table(table.Name).Where("id IN ?", ids).Updates(updates).Error
+}
@@ -0,0 +1,91 @@
+package dao
+
+import (
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/model"
+	"cnb.cool/mliev/dwz/dwz-server/v2/pkg/interfaces"
+)
+
+type FolderDao struct {
+	helper interfaces.HelperInterface
+}
+
+func NewFolderDao(helper interfaces.HelperInterface) *FolderDao {
+	return &FolderDao{helper: helper}
+}
+
+func (d *FolderDao) Create(folder *model.Folder) error {
+	return d.helper.GetDatabase().Create(folder).Error
+}
+
+func (d *FolderDao) Update(folder *model.Folder) error {
+	return d.helper.GetDatabase().Save(folder).Error
+}
+
+func (d *FolderDao) Delete(id, workspaceID uint64) error {
+	return d.helper.GetDatabase().Where("id = ? AND workspace_id = ?", id, workspaceID).Delete(&model.Folder{}).Error
+}
+
+func (d *FolderDao) FindByID(id, workspaceID uint64) (*model.Folder, error) {
+	var folder model.Folder
+	err := d.helper.GetDatabase().
+		Where("id = ? AND workspace_id = ? AND deleted_at IS NULL", id, workspaceID).
+		First(&folder).Error
+	return &folder, err
+}
+
+// ListInWorkspace 获取工作区内全部文件夹，用于构建目录树
+func (d *FolderDao) ListInWorkspace(workspaceID uint64) ([]model.Folder, error) {
+	var folders []model.Folder
+	err := d.helper.GetDatabase().
+		Where("workspace_id = ? AND deleted_at IS NULL", workspaceID).
+		Order("name ASC, id ASC").
+		Find(&folders).Error
+	return folders, err
+}
+
+// ExistsSiblingName 同级目录下是否已有同名文件夹
+func (d *FolderDao) ExistsSiblingName(workspaceID uint64, parentID *uint64, name string, excludeID uint64) (bool, error) {
+	var count int64
+	query := d.helper.GetDatabase().Model(&model.Folder{}).
+		Where("workspace_id = ? AND name = ? AND deleted_at IS NULL", workspaceID, name)
+	if parentID == nil {
+		query = query.Where("parent_id IS NULL")
+	} else {
+		query = query.Where("parent_id = ?", *parentID)
+	}
+	if excludeID > 0 {
+		query = query.Where("id <> ?", excludeID)
+	}
+	err := query.Count(&count).Error
+	return count > 0, err
+}
+
+// CountChildren 统计直接子文件夹数量
+func (d *FolderDao) CountChildren(id, workspaceID uint64) (int64, error) {
+	var count int64
+	err := d.helper.GetDatabase().Model(&model.Folder{}).
+		Where("workspace_id = ? AND parent_id = ? AND deleted_at IS NULL", workspaceID, id).
+		Count(&count).Error
+	return count, err
+}
+
+// CountShortLinksByFolder 按文件夹统计未删除的短网址数量
+func (d *FolderDao) CountShortLinksByFolder(workspaceID uint64) (map[uint64]int64, error) {
+	var rows []struct {
+		FolderID uint64
+		Count    int64
+	}
+	err := d.helper.GetDatabase().Model(&model.ShortLink{}).
+		Select("folder_id, COUNT(*) AS count").
+		Where("workspace_id = ? AND folder_id IS NOT NULL AND deleted_at IS NULL", workspaceID).
+		Group("folder_id").
+		Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[uint64]int64, len(rows))
+	for _, row := range rows {
+		counts[row.FolderID] = row.Count
+	}
+	return counts, nil
+}
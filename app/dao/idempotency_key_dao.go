@@ -0,0 +1,49 @@
+package dao
+
+import (
+	"time"
+
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/model"
+	"cnb.cool/mliev/dwz/dwz-server/v2/pkg/interfaces"
+)
+
+type IdempotencyKeyDao struct {
+	helper interfaces.HelperInterface
+}
+
+func NewIdempotencyKeyDao(helper interfaces.HelperInterface) *IdempotencyKeyDao {
+	return &IdempotencyKeyDao{helper: helper}
+}
+
+// Create 插入幂等键占位记录，唯一索引冲突时返回错误
+func (d *IdempotencyKeyDao) Create(record *model.IdempotencyKey) error {
+	return d.helper.GetDatabase().Create(record).Error
+}
+
+func (d *IdempotencyKeyDao) Find(workspaceID uint64, scope, key string) (*model.IdempotencyKey, error) {
+	var record model.IdempotencyKey
+	err := d.helper.GetDatabase().
+		Where("workspace_id = ? AND scope = ? AND idempotency_key = ?", workspaceID, scope, key).
+		First(&record).Error
+	return &record, err
+}
+
+// Complete 保存响应并标记请求完成
+func (d *IdempotencyKeyDao) Complete(id uint64, responseBody string, completedAt time.Time) error {
+	return d.helper.GetDatabase().Model(&model.IdempotencyKey{}).
+		Where("id = ?", id).
+		Updates(map[string]any{
+			"response_body": responseBody,
+			"completed_at":  completedAt,
+		}).Error
+}
+
+func (d *IdempotencyKeyDao) Delete(id uint64) error {
+	return d.helper.GetDatabase().Where("id = ?", id).Delete(&model.IdempotencyKey{}).Error
+}
+
+// DeleteExpired 删除已过期的幂等键，返回删除数量
+func (d *IdempotencyKeyDao) DeleteExpired(now time.Time) (int64, error) {
+	result := d.helper.GetDatabase().Where("expires_at <= ?", now).Delete(&model.IdempotencyKey{})
+	return result.RowsAffected, result.Error
+}
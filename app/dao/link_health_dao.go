@@ -0,0 +1,92 @@
+package dao
+
+import (
+	"time"
+
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/model"
+	"cnb.cool/mliev/dwz/dwz-server/v2/pkg/interfaces"
+	"gorm.io/gorm"
+)
+
+type LinkHealthDao struct {
+	helper interfaces.HelperInterface
+}
+
+func NewLinkHealthDao(helper interfaces.HelperInterface) *LinkHealthDao {
+	return &LinkHealthDao{helper: helper}
+}
+
+// ListDueShortLinks 获取需要检查的短网址：已激活、未过期，且从未检查或上次检查早于 checkedBefore
+func (d *LinkHealthDao) ListDueShortLinks(checkedBefore time.Time, limit int) ([]model.ShortLink, error) {
+	var shortLinks []model.ShortLink
+	now := time.Now()
+	err := d.helper.GetDatabase().
+		Where("is_active = ? AND deleted_at IS NULL", true).
+		Where("expire_at IS NULL OR expire_at > ?", now).
+		Where("health_checked_at IS NULL OR health_checked_at < ?", checkedBefore).
+		Order("id ASC").
+		Limit(limit).
+		Find(&shortLinks).Error
+	return shortLinks, err
+}
+
+// ClaimShortLink 将到期的短网址标记为本实例检查中，检查时间更新为 claimedAt。
+// 多个实例同时领取同一短网址时只有一个成功，其余实例跳过
+func (d *LinkHealthDao) ClaimShortLink(id uint64, checkedBefore, claimedAt time.Time) (bool, error) {
+	result := d.helper.GetDatabase().Model(&model.ShortLink{}).
+		Where("id = ? AND (health_checked_at IS NULL OR health_checked_at < ?)", id, checkedBefore).
+		UpdateColumn("health_checked_at", claimedAt)
+	return result.RowsAffected > 0, result.Error
+}
+
+// ListActiveRoutes 获取短网址已启用的高级路由
+func (d *LinkHealthDao) ListActiveRoutes(shortLinkID uint64) ([]model.LinkRoute, error) {
+	var routes []model.LinkRoute
+	err := d.helper.GetDatabase().
+		Where("short_link_id = ? AND is_active = ? AND deleted_at IS NULL", shortLinkID, true).
+		Order("priority ASC, id ASC").
+		Find(&routes).Error
+	return routes, err
+}
+
+// ListRunningVariants 获取短网址运行中 A/B 测试的已启用版本
+func (d *LinkHealthDao) ListRunningVariants(shortLinkID uint64) ([]model.ABTestVariant, error) {
+	var variants []model.ABTestVariant
+	err := d.helper.GetDatabase().
+		Joins("JOIN ab_tests ON ab_tests.id = ab_test_variants.ab_test_id").
+		Where("ab_tests.short_link_id = ? AND ab_tests.is_active = ? AND ab_tests.status = ? AND ab_tests.deleted_at IS NULL", shortLinkID, true, "running").
+		Where("ab_test_variants.is_active = ? AND ab_test_variants.deleted_at IS NULL", true).
+		Order("ab_test_variants.id ASC").
+		Find(&variants).Error
+	return variants, err
+}
+
+// ListByShortLinkID 获取短网址各目标地址的最近检查结果
+func (d *LinkHealthDao) ListByShortLinkID(shortLinkID, workspaceID uint64) ([]model.LinkHealthCheck, error) {
+	var checks []model.LinkHealthCheck
+	err := d.helper.GetDatabase().
+		Where("short_link_id = ? AND workspace_id = ?", shortLinkID, workspaceID).
+		Order("id ASC").
+		Find(&checks).Error
+	return checks, err
+}
+
+// Replace 用本轮检查结果替换短网址的全部检查记录，并更新短网址的健康状态。
+// 已移除的路由或版本对应的旧记录会一并删除。
+func (d *LinkHealthDao) Replace(shortLinkID uint64, checks []model.LinkHealthCheck, status string, checkedAt time.Time) error {
+	return d.helper.GetDatabase().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("short_link_id = ?", shortLinkID).Delete(&model.LinkHealthCheck{}).Error; err != nil {
+			return err
+		}
+		if len(checks) > 0 {
+			if err := tx.Create(&checks).Error; err != nil {
+				return err
+			}
+		}
+		// 使用 UpdateColumns 避免刷新 updated_at
+		return tx.Model(&model.ShortLink{}).Where("id = ?", shortLinkID).UpdateColumns(map[string]any{
+			"health_status":     status,
+			"health_checked_at": checkedAt,
+		}).Error
+	})
+}
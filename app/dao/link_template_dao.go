@@ -0,0 +1,75 @@
+package dao
+
+import (
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/model"
+	"cnb.cool/mliev/dwz/dwz-server/v2/pkg/interfaces"
+	"gorm.io/gorm"
+)
+
+type LinkTemplateDao struct {
+	helper interfaces.HelperInterface
+}
+
+func NewLinkTemplateDao(helper interfaces.HelperInterface) *LinkTemplateDao {
+	return &LinkTemplateDao{helper: helper}
+}
+
+// Save 创建或更新模板；模板设为默认时在同一事务内取消工作区其他默认模板
+func (d *LinkTemplateDao) Save(template *model.LinkTemplate) error {
+	return d.helper.GetDatabase().Transaction(func(tx *gorm.DB) error {
+		if template.IsDefault {
+			query := tx.Model(&model.LinkTemplate{}).
+				Where("workspace_id = ? AND is_default = ? AND deleted_at IS NULL", template.WorkspaceID, true)
+			if template.ID > 0 {
+				query = query.Where("id <> ?", template.ID)
+			}
+			if err := query.Update("is_default", false).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Save(template).Error
+	})
+}
+
+func (d *LinkTemplateDao) Delete(id, workspaceID uint64) error {
+	return d.helper.GetDatabase().Where("id = ? AND workspace_id = ?", id, workspaceID).Delete(&model.LinkTemplate{}).Error
+}
+
+func (d *LinkTemplateDao) FindByID(id, workspaceID uint64) (*model.LinkTemplate, error) {
+	var template model.LinkTemplate
+	err := d.helper.GetDatabase().
+		Where("id = ? AND workspace_id = ? AND deleted_at IS NULL", id, workspaceID).
+		First(&template).Error
+	return &template, err
+}
+
+// FindDefault 获取工作区默认模板
+func (d *LinkTemplateDao) FindDefault(workspaceID uint64) (*model.LinkTemplate, error) {
+	var template model.LinkTemplate
+	err := d.helper.GetDatabase().
+		Where("workspace_id = ? AND is_default = ? AND deleted_at IS NULL", workspaceID, true).
+		Order("id ASC").
+		First(&template).Error
+	return &template, err
+}
+
+func (d *LinkTemplateDao) ListInWorkspace(workspaceID uint64) ([]model.LinkTemplate, error) {
+	var templates []model.LinkTemplate
+	err := d.helper.GetDatabase().
+		Where("workspace_id = ? AND deleted_at IS NULL", workspaceID).
+		Order("is_default DESC, name ASC, id ASC").
+		Find(&templates).Error
+	return templates, err
+}
+
+// ExistsName 工作区内是否已有同名模板
+func (d *LinkTemplateDao) ExistsName(workspaceID uint64, name string, excludeID uint64) (bool, error) {
+	var count int64
+	query := d.helper.GetDatabase().Model(&model.LinkTemplate{}).
+		Where("workspace_id = ? AND name = ? AND deleted_at IS NULL", workspaceID, name)
+	if excludeID > 0 {
+		query = query.Where("id <> ?", excludeID)
+	}
+	err := query.Count(&count).Error
+	return count > 0, err
+}
@@ -111,9 +111,3 @@ func (dao *OperationLogDAO) GetListByWorkspace(workspaceID uint64, offset, limit
 	err := query.Offset(offset).Limit(limit).Order("created_at DESC").Find(&logs).Error
 	return logs, total, err
 }
-
-// DeleteOldLogs 删除过期日志（物理删除）
-func (dao *OperationLogDAO) DeleteOldLogs(days int) error {
-	cutoffTime := time.Now().AddDate(0, 0, -days)
-	return dao.helper.GetDatabase().Unscoped().Where("created_at < ?", cutoffTime).Delete(&model.OperationLog{}).Error
-}
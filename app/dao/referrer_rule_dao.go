@@ -0,0 +1,45 @@
+package dao
+
+import (
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/model"
+	"cnb.cool/mliev/dwz/dwz-server/v2/pkg/interfaces"
+)
+
+// ReferrerRuleDao 工作区来源渠道规则DAO
+type ReferrerRuleDao struct {
+	helper interfaces.HelperInterface
+}
+
+func NewReferrerRuleDao(helper interfaces.HelperInterface) *ReferrerRuleDao {
+	return &ReferrerRuleDao{helper: helper}
+}
+
+func (d *ReferrerRuleDao) Save(rule *model.ReferrerRule) error {
+	db := d.helper.GetDatabase()
+	if rule.ID != 0 {
+		return db.Save(rule).Error
+	}
+	return db.Create(rule).Error
+}
+
+func (d *ReferrerRuleDao) Delete(id, workspaceID uint64) (int64, error) {
+	result := d.helper.GetDatabase().Where("id = ? AND workspace_id = ?", id, workspaceID).Delete(&model.ReferrerRule{})
+	return result.RowsAffected, result.Error
+}
+
+func (d *ReferrerRuleDao) FindByID(id, workspaceID uint64) (*model.ReferrerRule, error) {
+	var rule model.ReferrerRule
+	err := d.helper.GetDatabase().Where("id = ? AND workspace_id = ?", id, workspaceID).First(&rule).Error
+	return &rule, err
+}
+
+// ListInWorkspace 按匹配顺序列出工作区规则，enabledOnly 为 true 时只返回启用的规则
+func (d *ReferrerRuleDao) ListInWorkspace(workspaceID uint64, enabledOnly bool) ([]model.ReferrerRule, error) {
+	var rules []model.ReferrerRule
+	query := d.helper.GetDatabase().Where("workspace_id = ?", workspaceID)
+	if enabledOnly {
+		query = query.Where("enabled = ?", true)
+	}
+	err := query.Order("priority DESC, id ASC").Find(&rules).Error
+	return rules, err
+}
@@ -0,0 +1,68 @@
+package dao
+
+import (
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/model"
+	"cnb.cool/mliev/dwz/dwz-server/v2/pkg/interfaces"
+)
+
+type ShortLinkAliasDao struct {
+	helper interfaces.HelperInterface
+}
+
+func NewShortLinkAliasDao(helper interfaces.HelperInterface) *ShortLinkAliasDao {
+	return &ShortLinkAliasDao{helper: helper}
+}
+
+func (d *ShortLinkAliasDao) Create(alias *model.ShortLinkAlias) error {
+	return d.helper.GetDatabase().Create(alias).Error
+}
+
+// FindByDomainAndCode 根据域名和短码查找别名
+func (d *ShortLinkAliasDao) FindByDomainAndCode(domain, shortCode string) (*model.ShortLinkAlias, error) {
+	var alias model.ShortLinkAlias
+	err := d.helper.GetDatabase().
+		Where("domain = ? AND short_code = ?", domain, shortCode).
+		First(&alias).Error
+	return &alias, err
+}
+
+// ExistsByDomainAndCode 检查域名和短码是否已被别名占用
+func (d *ShortLinkAliasDao) ExistsByDomainAndCode(domain, shortCode string) (bool, error) {
+	var count int64
+	err := d.helper.GetDatabase().Model(&model.ShortLinkAlias{}).
+		Where("domain = ? AND short_code = ?", domain, shortCode).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// ListByShortLinkID 获取短网址的全部别名
+func (d *ShortLinkAliasDao) ListByShortLinkID(shortLinkID, workspaceID uint64) ([]model.ShortLinkAlias, error) {
+	var aliases []model.ShortLinkAlias
+	err := d.helper.GetDatabase().
+		Where("short_link_id = ? AND workspace_id = ?", shortLinkID, workspaceID).
+		Order("created_at DESC, id DESC").
+		Find(&aliases).Error
+	return aliases, err
+}
+
+// CountByShortLinkID 统计短网址指定来源的别名数量
+func (d *ShortLinkAliasDao) CountByShortLinkID(shortLinkID uint64, source string) (int64, error) {
+	var count int64
+	err := d.helper.GetDatabase().Model(&model.ShortLinkAlias{}).
+		Where("short_link_id = ? AND source = ?", shortLinkID, source).
+		Count(&count).Error
+	return count, err
+}
+
+// FindByIDForShortLink 查找属于指定短网址的别名
+func (d *ShortLinkAliasDao) FindByIDForShortLink(id, shortLinkID, workspaceID uint64) (*model.ShortLinkAlias, error) {
+	var alias model.ShortLinkAlias
+	err := d.helper.GetDatabase().
+		Where("id = ? AND short_link_id = ? AND workspace_id = ?", id, shortLinkID, workspaceID).
+		First(&alias).Error
+	return &alias, err
+}
+
+func (d *ShortLinkAliasDao) Delete(id uint64) error {
+	return d.helper.GetDatabase().Delete(&model.ShortLinkAlias{}, id).Error
+}
@@ -54,14 +54,190 @@ func (d *ShortLinkDao) FindByIDInWorkspace(id, workspaceID uint64) (*model.Short
 	return &shortLink, nil
 }
 
+// ListByIDsInWorkspace 按ID批量获取工作区内未删除的短网址
+func (d *ShortLinkDao) ListByIDsInWorkspace(workspaceID uint64, ids []uint64) ([]model.ShortLink, error) {
+	var shortLinks []model.ShortLink
+	if len(ids) == 0 {
+		return shortLinks, nil
+	}
+	err := d.helper.GetDatabase().
+		Where("id IN ? AND workspace_id = ? AND deleted_at IS NULL", ids, workspaceID).
+		Find(&shortLinks).Error
+	return shortLinks, err
+}
+
 // Update 更新短网址
 func (d *ShortLinkDao) Update(shortLink *model.ShortLink) error {
 	return d.helper.GetDatabase().Save(shortLink).Error
 }
 
-// Delete 删除短网址（硬删除）
+// shortLinkTrashRelations 随短网址一起移入回收站、一起恢复的关联记录
+var shortLinkTrashRelations = []any{
+	&model.LinkRoute{},
+	&model.LinkSecuritySetting{},
+	&model.LinkSecurityIPRule{},
+	&model.ABTest{},
+}
+
+// Delete 删除短网址（移入回收站）
+// 关联的路由、安全设置与AB测试写入同一个 deleted_at，恢复时据此一并还原。
+// 各表 deleted_at 精度不一致（DATETIME / DATETIME(3)），因此截断到秒。
 func (d *ShortLinkDao) Delete(id uint64) error {
-	return d.helper.GetDatabase().Unscoped().Delete(&model.ShortLink{}, id).Error
+	deletedAt := time.Now().Truncate(time.Second)
+	return d.helper.GetDatabase().Transaction(func(tx *gorm.DB) error {
+		for _, relation := range shortLinkTrashRelations {
+			if err := tx.Model(relation).Where("short_link_id = ?", id).Update("deleted_at", deletedAt).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Model(&model.ShortLink{}).Where("id = ?", id).Update("deleted_at", deletedAt).Error
+	})
+}
+
+// ListTrashInWorkspace 获取回收站中的短网址
+func (d *ShortLinkDao) ListTrashInWorkspace(workspaceID uint64, offset, limit int, req *dto.ShortLinkTrashListRequest) ([]model.ShortLink, int64, error) {
+	var shortLinks []model.ShortLink
+	var total int64
+
+	query := d.helper.GetDatabase().Unscoped().Model(&model.ShortLink{}).
+		Where("workspace_id = ? AND deleted_at IS NOT NULL", workspaceID)
+	if req.Domain != "" {
+		query = query.Where("domain = ?", req.Domain)
+	}
+	if req.Keyword != "" {
+		keyword := "%" + req.Keyword + "%"
+		query = query.Where("original_url LIKE ? OR title LIKE ? OR short_code LIKE ?", keyword, keyword, keyword)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	err := query.Order("deleted_at DESC").Offset(offset).Limit(limit).Find(&shortLinks).Error
+	return shortLinks, total, err
+}
+
+// FindTrashedByIDInWorkspace 查找回收站中的短网址
+func (d *ShortLinkDao) FindTrashedByIDInWorkspace(id, workspaceID uint64) (*model.ShortLink, error) {
+	var shortLink model.ShortLink
+	err := d.helper.GetDatabase().Unscoped().
+		Where("id = ? AND workspace_id = ? AND deleted_at IS NOT NULL", id, workspaceID).
+		First(&shortLink).Error
+	if err != nil {
+		return nil, err
+	}
+	return &shortLink, nil
+}
+
+// FindTrashedBefore 查找删除时间早于 cutoff 的短网址，用于回收站自动清理
+func (d *ShortLinkDao) FindTrashedBefore(cutoff time.Time, limit int) ([]model.ShortLink, error) {
+	var shortLinks []model.ShortLink
+	err := d.helper.GetDatabase().Unscoped().
+		Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff).
+		Order("deleted_at ASC").
+		Limit(limit).
+		Find(&shortLinks).Error
+	return shortLinks, err
+}
+
+// Restore 从回收站恢复短网址及与其同时删除的关联记录
+func (d *ShortLinkDao) Restore(id uint64) error {
+	return d.helper.GetDatabase().Transaction(func(tx *gorm.DB) error {
+		var shortLink model.ShortLink
+		if err := tx.Unscoped().Where("id = ? AND deleted_at IS NOT NULL", id).First(&shortLink).Error; err != nil {
+			return err
+		}
+		for _, relation := range shortLinkTrashRelations {
+			if err := tx.Unscoped().Model(relation).
+				Where("short_link_id = ? AND deleted_at = ?", id, shortLink.DeletedAt.Time).
+				Update("deleted_at", nil).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Unscoped().Model(&model.ShortLink{}).Where("id = ?", id).Update("deleted_at", nil).Error
+	})
+}
+
+// Purge 彻底删除短网址及其路由、安全设置、AB测试、标签关联、自定义字段取值、
+// 转化、点击汇总、安全事件和流量告警；点击明细保留
+func (d *ShortLinkDao) Purge(id uint64) error {
+	return d.helper.GetDatabase().Transaction(func(tx *gorm.DB) error {
+		routeIDs := tx.Unscoped().Model(&model.LinkRoute{}).Select("id").Where("short_link_id = ?", id)
+		groupIDs := tx.Model(&model.LinkRouteConditionGroup{}).Select("id").Where("route_id IN (?)", routeIDs)
+		if err := tx.Where("group_id IN (?)", groupIDs).Delete(&model.LinkRouteCondition{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("route_id IN (?)", routeIDs).Delete(&model.LinkRouteConditionGroup{}).Error; err != nil {
+			return err
+		}
+		abTestIDs := tx.Unscoped().Model(&model.ABTest{}).Select("id").Where("short_link_id = ?", id)
+		if err := tx.Unscoped().Where("ab_test_id IN (?)", abTestIDs).Delete(&model.ABTestVariant{}).Error; err != nil {
+			return err
+		}
+		for _, relation := range shortLinkTrashRelations {
+			if err := tx.Unscoped().Where("short_link_id = ?", id).Delete(relation).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Where("short_link_id = ?", id).Delete(&model.ShortLinkTag{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("short_link_id = ?", id).Delete(&model.ShortLinkAlias{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("short_link_id = ?", id).Delete(&model.LinkHealthCheck{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("short_link_id = ?", id).Delete(&model.ShortLinkMetadata{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("short_link_id = ?", id).Delete(&model.ShortLinkCustomValue{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("short_link_id = ?", id).Delete(&model.VisitorSketch{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("short_link_id = ?", id).Delete(&model.Conversion{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("short_link_id = ?", id).Delete(&model.ClickRollup{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("short_link_id = ?", id).Delete(&model.LinkSecurityEvent{}).Error; err != nil {
+			return err
+		}
+		alertIDs := tx.Model(&model.TrafficAlert{}).Select("id").Where("short_link_id = ?", id)
+		if err := tx.Where("alert_id IN (?)", alertIDs).Delete(&model.TrafficAlertDelivery{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("short_link_id = ?", id).Delete(&model.TrafficAlert{}).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Delete(&model.ShortLink{}, id).Error
+	})
+}
+
+// Rekey 更换短网址的域名和短码，并将旧的域名+短码保存为别名。
+// promotedAliasID 非零时表示新短码原本是该短网址的别名，需要先删除该别名。
+func (d *ShortLinkDao) Rekey(shortLink *model.ShortLink, previous *model.ShortLinkAlias, promotedAliasID uint64) error {
+	return d.helper.GetDatabase().Transaction(func(tx *gorm.DB) error {
+		if promotedAliasID > 0 {
+			if err := tx.Delete(&model.ShortLinkAlias{}, promotedAliasID).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Create(previous).Error; err != nil {
+			return err
+		}
+		return tx.Model(&model.ShortLink{}).Where("id = ?", shortLink.ID).Updates(map[string]any{
+			"domain_id":      shortLink.DomainID,
+			"domain":         shortLink.Domain,
+			"protocol":       shortLink.Protocol,
+			"short_code":     shortLink.ShortCode,
+			"is_custom_code": shortLink.IsCustomCode,
+			"issuer_number":  shortLink.IssuerNumber,
+			"updated_by":     shortLink.UpdatedBy,
+		}).Error
+	})
 }
 
 // List 获取短网址列表
@@ -114,43 +290,69 @@ func (d *ShortLinkDao) ListInWorkspace(workspaceID uint64, offset, limit int, re
 	if req.TagID > 0 {
 		query = query.Joins("JOIN short_link_tags slt ON slt.short_link_id = short_links.id AND slt.tag_id = ?", req.TagID)
 	}
-	switch req.SecurityStatus {
-	case "none":
-		query = query.Joins("LEFT JOIN link_security_settings lss ON lss.short_link_id = short_links.id AND lss.deleted_at IS NULL").
-			Where("lss.id IS NULL OR (lss.password_enabled = ? AND lss.access_window_start IS NULL AND lss.access_window_end IS NULL AND lss.max_clicks IS NULL AND lss.ip_policy = ? AND lss.bot_policy <> ? AND lss.report_enabled = ? AND lss.url_blocked = ?)",
-				false, model.LinkIPPolicyOff, model.LinkBotPolicyBlockKnownBots, false, false)
-	case "enabled":
-		query = query.Joins("JOIN link_security_settings lss ON lss.short_link_id = short_links.id AND lss.deleted_at IS NULL").
-			Where("lss.password_enabled = ? OR lss.access_window_start IS NOT NULL OR lss.access_window_end IS NOT NULL OR lss.max_clicks IS NOT NULL OR lss.ip_policy <> ? OR lss.bot_policy = ? OR lss.report_enabled = ? OR lss.url_blocked = ?",
-				true, model.LinkIPPolicyOff, model.LinkBotPolicyBlockKnownBots, true, true)
-	case "password":
-		query = query.Joins("JOIN link_security_settings lss ON lss.short_link_id = short_links.id AND lss.deleted_at IS NULL").
-			Where("lss.password_enabled = ?", true)
-	case "restricted":
-		query = query.Joins("JOIN link_security_settings lss ON lss.short_link_id = short_links.id AND lss.deleted_at IS NULL").
-			Where("lss.access_window_start IS NOT NULL OR lss.access_window_end IS NOT NULL OR lss.max_clicks IS NOT NULL OR lss.ip_policy <> ? OR lss.bot_policy = ?",
-				model.LinkIPPolicyOff, model.LinkBotPolicyBlockKnownBots)
-	case "url_blocked":
-		query = query.Joins("JOIN link_security_settings lss ON lss.short_link_id = short_links.id AND lss.deleted_at IS NULL").
-			Where("lss.url_blocked = ?", true)
-	case "reported":
-		query = query.Where("EXISTS (SELECT 1 FROM abuse_reports ar WHERE ar.short_link_id = short_links.id AND ar.workspace_id = short_links.workspace_id)")
-	}
-	switch req.RoutingStatus {
-	case "none":
-		query = query.Where("NOT EXISTS (SELECT 1 FROM link_routes lr WHERE lr.short_link_id = short_links.id AND lr.workspace_id = short_links.workspace_id AND lr.deleted_at IS NULL)")
-	case "enabled":
-		query = query.Where("EXISTS (SELECT 1 FROM link_routes lr WHERE lr.short_link_id = short_links.id AND lr.workspace_id = short_links.workspace_id AND lr.is_active = ? AND lr.deleted_at IS NULL)", true)
-	case "fallback":
-		query = query.Where("short_links.fallback_url <> '' AND EXISTS (SELECT 1 FROM link_routes lr WHERE lr.short_link_id = short_links.id AND lr.workspace_id = short_links.workspace_id AND lr.is_active = ? AND lr.deleted_at IS NULL)", true)
-	case "disabled":
-		query = query.Where("EXISTS (SELECT 1 FROM link_routes lr WHERE lr.short_link_id = short_links.id AND lr.workspace_id = short_links.workspace_id AND lr.deleted_at IS NULL) AND NOT EXISTS (SELECT 1 FROM link_routes lr2 WHERE lr2.short_link_id = short_links.id AND lr2.workspace_id = short_links.workspace_id AND lr2.is_active = ? AND lr2.deleted_at IS NULL)", true)
+	if len(req.FolderIDs) > 0 {
+		query = query.Where("short_links.folder_id IN ?", req.FolderIDs)
+	} else if req.FolderID != nil {
+		if *req.FolderID == 0 {
+			query = query.Where("short_links.folder_id IS NULL")
+		} else {
+			query = query.Where("short_links.folder_id = ?", *req.FolderID)
+		}
+	}
+	if req.SecurityStatus != "" {
+		if condition, args, ok := securityStatusCondition(req.SecurityStatus); ok {
+			query = query.Where(condition, args...)
+		}
+	}
+	if req.RoutingStatus != "" {
+		if condition, args, ok := routingStatusCondition(req.RoutingStatus); ok {
+			query = query.Where(condition, args...)
+		}
+	}
+	if req.HealthStatus != "" {
+		if condition, args, ok := healthStatusCondition(req.HealthStatus); ok {
+			query = query.Where(condition, args...)
+		}
+	}
+	if req.ReviewStatus != "" {
+		if condition, args, ok := reviewStatusCondition(req.ReviewStatus); ok {
+			query = query.Where(condition, args...)
+		}
+	}
+	if req.Q != "" {
+		parsed, err := ParseShortLinkQuery(req.Q)
+		if err != nil {
+			return nil, 0, err
+		}
+		if parsed != nil {
+			location := req.Location
+			if location == nil {
+				location = time.Local
+			}
+			condition, args, err := parsed.Build(time.Now().In(location))
+			if err != nil {
+				return nil, 0, err
+			}
+			query = query.Where(condition, args...)
+		}
+	}
+	sort, err := ParseShortLinkSort(req.Sort)
+	if err != nil {
+		return nil, 0, err
 	}
 
 	if err := query.Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
-	err := query.Preload("Campaign").Order("short_links.created_at DESC").Offset(offset).Limit(limit).Find(&shortLinks).Error
+	if req.Cursor != "" {
+		condition, args, err := sort.cursorCondition(req.Cursor)
+		if err != nil {
+			return nil, 0, err
+		}
+		query = query.Where(condition, args...)
+		offset = 0
+	}
+	err = query.Preload("Campaign").Order(sort.OrderClause()).Offset(offset).Limit(limit).Find(&shortLinks).Error
 	return shortLinks, total, err
 }
 
@@ -159,6 +361,11 @@ func (d *ShortLinkDao) IncrementClickCount(id uint64) error {
 	return d.helper.GetDatabase().Model(&model.ShortLink{}).Where("id = ?", id).UpdateColumn("click_count", gorm.Expr("click_count + ?", 1)).Error
 }
 
+// IncrementDuplicateClickCount 增加重复点击次数
+func (d *ShortLinkDao) IncrementDuplicateClickCount(id uint64) error {
+	return d.helper.GetDatabase().Model(&model.ShortLink{}).Where("id = ?", id).UpdateColumn("duplicate_click_count", gorm.Expr("duplicate_click_count + ?", 1)).Error
+}
+
 // GetClickStatistics 获取点击统计
 func (d *ShortLinkDao) GetClickStatistics(shortLinkID uint64, startDate, endDate time.Time) ([]model.ClickStatistic, error) {
 	var statistics []model.ClickStatistic
@@ -167,23 +374,25 @@ func (d *ShortLinkDao) GetClickStatistics(shortLinkID uint64, startDate, endDate
 	return statistics, err
 }
 
-// GetDailyClickCount 获取每日点击统计
-func (d *ShortLinkDao) GetDailyClickCount(shortLinkID uint64, days int) (map[string]int64, error) {
-	startDate := time.Now().AddDate(0, 0, -days).Format("2006-01-02")
-	endDate := time.Now().Format("2006-01-02")
-
-	var results []struct {
-		Date  string `json:"date"`
-		Count int64  `json:"count"`
+// GetDailyClickCount 获取每日点击统计，按 location 划分日期，includeDuplicates 为 false 时不含重复点击
+func (d *ShortLinkDao) GetDailyClickCount(shortLinkID uint64, days int, location *time.Location, includeDuplicates bool) (map[string]int64, error) {
+	now := time.Now().In(location)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, location)
+	startTime := today.AddDate(0, 0, -days)
+	driver := d.helper.GetConfig().GetString("database.driver", "mysql")
+	slotSQL := reportSlotSQL(driver, "click_date")
+
+	var slots []reportSlotCount
+	query := d.helper.GetDatabase().Model(&model.ClickStatistic{})
+	if !includeDuplicates {
+		query = query.Where("is_duplicate = ?", false)
 	}
-
-	err := d.helper.GetDatabase().Model(&model.ClickStatistic{}).
-		Select("DATE(click_date) as date, COUNT(*) as count").
-		Where("short_link_id = ? AND DATE(click_date) >= ? AND DATE(click_date) <= ?",
-			shortLinkID, startDate, endDate).
-		Group("DATE(click_date)").
-		Order("date").
-		Find(&results).Error
+	err := query.
+		Select(slotSQL+" as slot, COUNT(*) as count").
+		Where("short_link_id = ? AND click_date >= ? AND click_date < ?",
+			shortLinkID, startTime, today.AddDate(0, 0, 1)).
+		Group(slotSQL).
+		Find(&slots).Error
 
 	if err != nil {
 		return nil, err
@@ -193,31 +402,26 @@ func (d *ShortLinkDao) GetDailyClickCount(shortLinkID uint64, days int) (map[str
 	countMap := make(map[string]int64)
 
 	// 先初始化所有日期的点击数为0
-	startTime, _ := time.Parse("2006-01-02", startDate)
-	endTime, _ := time.Parse("2006-01-02", endDate)
-	for d := startTime; !d.After(endTime); d = d.AddDate(0, 0, 1) {
-		dateStr := d.Format("2006-01-02")
-		countMap[dateStr] = 0
+	for d := startTime; !d.After(today); d = d.AddDate(0, 0, 1) {
+		countMap[d.Format("2006-01-02")] = 0
 	}
 
-	// 用实际查询结果更新点击数
-	for _, result := range results {
-		tempTime, err := time.Parse(time.RFC3339, result.Date)
-		if err != nil {
-			d.helper.GetLogger().Error(err.Error())
-			continue
-		}
-		dateStr := tempTime.Format("2006-01-02")
-		countMap[dateStr] = result.Count
+	_, daily := bucketReportSlots(driver, slots, location)
+	for _, day := range daily {
+		countMap[day.Date] = day.Count
 	}
 
 	return countMap, nil
 }
 
-// GetClickCountByDateRange 获取指定时间范围内的点击数
-func (d *ShortLinkDao) GetClickCountByDateRange(shortLinkID uint64, startDate, endDate time.Time) (int64, error) {
+// GetClickCountByDateRange 获取指定时间范围内的点击数，includeDuplicates 为 false 时不含重复点击
+func (d *ShortLinkDao) GetClickCountByDateRange(shortLinkID uint64, startDate, endDate time.Time, includeDuplicates bool) (int64, error) {
 	var count int64
-	err := d.helper.GetDatabase().Model(&model.ClickStatistic{}).
+	query := d.helper.GetDatabase().Model(&model.ClickStatistic{})
+	if !includeDuplicates {
+		query = query.Where("is_duplicate = ?", false)
+	}
+	err := query.
 		Where("short_link_id = ? AND click_date >= ? AND click_date < ?",
 			shortLinkID, startDate, endDate).Count(&count).Error
 	return count, err
@@ -232,6 +436,66 @@ func (d *ShortLinkDao) ExistsByDomainAndCode(domain, shortCode string) (bool, er
 	return count > 0, err
 }
 
+// FindByExternalIDInWorkspace 根据外部标识在工作区内查找短网址
+func (d *ShortLinkDao) FindByExternalIDInWorkspace(externalID string, workspaceID uint64) (*model.ShortLink, error) {
+	var shortLink model.ShortLink
+	err := d.helper.GetDatabase().
+		Preload("Campaign").
+		Where("external_id = ? AND workspace_id = ? AND deleted_at IS NULL", externalID, workspaceID).
+		First(&shortLink).Error
+	if err != nil {
+		return nil, err
+	}
+	return &shortLink, nil
+}
+
+// ExistsByExternalID 检查外部标识在工作区内是否已被占用
+func (d *ShortLinkDao) ExistsByExternalID(externalID string, workspaceID uint64) (bool, error) {
+	var count int64
+	err := d.helper.GetDatabase().Model(&model.ShortLink{}).
+		Where("external_id = ? AND workspace_id = ? AND deleted_at IS NULL", externalID, workspaceID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// FindReusableInWorkspace 查找目标地址指纹、域名与 UTM 参数均相同且仍可访问的短网址，待审核和已驳回的不复用
+func (d *ShortLinkDao) FindReusableInWorkspace(candidate *model.ShortLink, now time.Time) (*model.ShortLink, error) {
+	var shortLink model.ShortLink
+	err := d.helper.GetDatabase().
+		Preload("Campaign").
+		Where("workspace_id = ? AND domain = ? AND original_url_hash = ? AND deleted_at IS NULL", candidate.WorkspaceID, candidate.Domain, candidate.URLHash).
+		Where("utm_source = ? AND utm_medium = ? AND utm_campaign = ? AND utm_term = ? AND utm_content = ?",
+			candidate.UTMSource, candidate.UTMMedium, candidate.UTMCampaign, candidate.UTMTerm, candidate.UTMContent).
+		Where("is_active = ? AND (expire_at IS NULL OR expire_at > ?)", true, now).
+		Where("(review_status IS NULL OR review_status NOT IN ?)", []string{model.ShortLinkReviewPending, model.ShortLinkReviewRejected}).
+		Order("id ASC").
+		First(&shortLink).Error
+	if err != nil {
+		return nil, err
+	}
+	return &shortLink, nil
+}
+
+// UpdateFolder 移动短网址到文件夹，folderID 为空表示移回根目录
+func (d *ShortLinkDao) UpdateFolder(id uint64, folderID *uint64, updatedBy *uint64) error {
+	var value any
+	if folderID != nil {
+		value = *folderID
+	}
+	return d.helper.GetDatabase().Model(&model.ShortLink{}).
+		Where("id = ? AND deleted_at IS NULL", id).
+		Updates(map[string]any{"folder_id": value, "updated_by": updatedBy}).Error
+}
+
+// CountInFolders 统计位于指定文件夹中的短网址数量
+func (d *ShortLinkDao) CountInFolders(workspaceID uint64, folderIDs []uint64) (int64, error) {
+	var count int64
+	err := d.helper.GetDatabase().Model(&model.ShortLink{}).
+		Where("workspace_id = ? AND folder_id IN ? AND deleted_at IS NULL", workspaceID, folderIDs).
+		Count(&count).Error
+	return count, err
+}
+
 // ExistsByID 检查ID是否已存在
 func (d *ShortLinkDao) ExistsByID(id uint64) (bool, error) {
 	var count int64
@@ -289,7 +553,7 @@ func (d *ShortLinkDao) GetTopClickedByDateRange(startDate, endDate time.Time, li
 	err := d.helper.GetDatabase().Model(&model.ShortLink{}).
 		Select("short_links.id, short_links.protocol, short_links.domain, short_links.issuer_number, short_links.short_code, short_links.original_url, short_links.title, COUNT(click_statistics.id) AS click_count").
 		Joins("JOIN click_statistics ON click_statistics.short_link_id = short_links.id").
-		Where("short_links.deleted_at IS NULL AND click_statistics.click_date >= ? AND click_statistics.click_date < ?", startDate, endDate).
+		Where("short_links.deleted_at IS NULL AND click_statistics.click_date >= ? AND click_statistics.click_date < ? AND click_statistics.is_duplicate = ?", startDate, endDate, false).
 		Group("short_links.id, short_links.protocol, short_links.domain, short_links.issuer_number, short_links.short_code, short_links.original_url, short_links.title").
 		Order("click_count DESC").
 		Limit(limit).
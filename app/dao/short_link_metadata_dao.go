@@ -0,0 +1,61 @@
+package dao
+
+import (
+	"errors"
+
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/model"
+	"cnb.cool/mliev/dwz/dwz-server/v2/pkg/interfaces"
+	"gorm.io/gorm"
+)
+
+type ShortLinkMetadataDao struct {
+	helper interfaces.HelperInterface
+}
+
+func NewShortLinkMetadataDao(helper interfaces.HelperInterface) *ShortLinkMetadataDao {
+	return &ShortLinkMetadataDao{helper: helper}
+}
+
+func (d *ShortLinkMetadataDao) FindByShortLinkID(shortLinkID, workspaceID uint64) (*model.ShortLinkMetadata, error) {
+	var metadata model.ShortLinkMetadata
+	err := d.helper.GetDatabase().
+		Where("short_link_id = ? AND workspace_id = ?", shortLinkID, workspaceID).
+		First(&metadata).Error
+	return &metadata, err
+}
+
+// Save 保存短网址的抓取结果，已有记录时覆盖
+func (d *ShortLinkMetadataDao) Save(metadata *model.ShortLinkMetadata) error {
+	return d.helper.GetDatabase().Transaction(func(tx *gorm.DB) error {
+		var existing model.ShortLinkMetadata
+		err := tx.Where("short_link_id = ?", metadata.ShortLinkID).First(&existing).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		if err == nil {
+			metadata.ID = existing.ID
+			metadata.CreatedAt = existing.CreatedAt
+		}
+		return tx.Save(metadata).Error
+	})
+}
+
+// FillEmptyTitleAndDescription 仅在标题或描述为空时写入建议值，避免覆盖用户填写的内容
+func (d *ShortLinkMetadataDao) FillEmptyTitleAndDescription(shortLinkID uint64, title, description string) error {
+	db := d.helper.GetDatabase()
+	if title != "" {
+		if err := db.Model(&model.ShortLink{}).
+			Where("id = ? AND (title IS NULL OR title = '')", shortLinkID).
+			UpdateColumn("title", title).Error; err != nil {
+			return err
+		}
+	}
+	if description != "" {
+		if err := db.Model(&model.ShortLink{}).
+			Where("id = ? AND (description IS NULL OR description = '')", shortLinkID).
+			UpdateColumn("description", description).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
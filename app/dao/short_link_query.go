@@ -0,0 +1,723 @@
+package dao
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/model"
+)
+
+// 短网址高级搜索语法：
+//
+//	tag:promo AND clicks>100 AND created:>2026-01-01 AND target:*example.com* AND cf.cost_center:RD01
+//
+// 条件之间默认 AND，可使用 OR、NOT（或前缀 -）与括号；值中的 * 为通配符，含空格的值用双引号包裹。
+// 不带字段名的词按关键词匹配原始 URL、标题与描述。所有值均以参数绑定，字段名只能取自白名单。
+
+const (
+	maxShortLinkQueryLength = 1000
+	maxShortLinkQueryTerms  = 50
+	maxShortLinkQueryDepth  = 10
+)
+
+type shortLinkQueryFieldKind int
+
+const (
+	queryFieldText shortLinkQueryFieldKind = iota
+	queryFieldNumber
+	queryFieldDate
+	queryFieldTag
+	queryFieldCampaign
+	queryFieldSecurity
+	queryFieldRouting
+	queryFieldHealth
+	queryFieldReview
+	queryFieldState
+)
+
+type shortLinkQueryField struct {
+	kind   shortLinkQueryFieldKind
+	column string
+}
+
+// shortLinkQueryFields 查询语法可用字段，column 只能来自此处
+var shortLinkQueryFields = map[string]shortLinkQueryField{
+	"tag":          {kind: queryFieldTag},
+	"campaign":     {kind: queryFieldCampaign},
+	"security":     {kind: queryFieldSecurity},
+	"routing":      {kind: queryFieldRouting},
+	"health":       {kind: queryFieldHealth},
+	"review":       {kind: queryFieldReview},
+	"is":           {kind: queryFieldState},
+	"id":           {kind: queryFieldNumber, column: "short_links.id"},
+	"clicks":       {kind: queryFieldNumber, column: "short_links.click_count"},
+	"creator":      {kind: queryFieldNumber, column: "short_links.created_by"},
+	"created":      {kind: queryFieldDate, column: "short_links.created_at"},
+	"updated":      {kind: queryFieldDate, column: "short_links.updated_at"},
+	"expires":      {kind: queryFieldDate, column: "short_links.expire_at"},
+	"target":       {kind: queryFieldText, column: "short_links.original_url"},
+	"fallback":     {kind: queryFieldText, column: "short_links.fallback_url"},
+	"domain":       {kind: queryFieldText, column: "short_links.domain"},
+	"code":         {kind: queryFieldText, column: "short_links.short_code"},
+	"title":        {kind: queryFieldText, column: "short_links.title"},
+	"description":  {kind: queryFieldText, column: "short_links.description"},
+	"notes":        {kind: queryFieldText, column: "short_links.notes"},
+	"external":     {kind: queryFieldText, column: "short_links.external_id"},
+	"utm_source":   {kind: queryFieldText, column: "short_links.utm_source"},
+	"utm_medium":   {kind: queryFieldText, column: "short_links.utm_medium"},
+	"utm_campaign": {kind: queryFieldText, column: "short_links.utm_campaign"},
+	"utm_term":     {kind: queryFieldText, column: "short_links.utm_term"},
+	"utm_content":  {kind: queryFieldText, column: "short_links.utm_content"},
+}
+
+// ShortLinkQuery 解析后的搜索条件
+type ShortLinkQuery struct {
+	root shortLinkQueryNode
+}
+
+type shortLinkQueryNode struct {
+	op       string // and / or / not / term
+	children []*shortLinkQueryNode
+	field    string
+	operator string
+	value    string
+}
+
+// ParseShortLinkQuery 解析搜索语句，空语句返回 nil
+func ParseShortLinkQuery(input string) (*ShortLinkQuery, error) {
+	input = strings.TrimSpace(input)
+	if input == "" {
+		return nil, nil
+	}
+	if len(input) > maxShortLinkQueryLength {
+		return nil, fmt.Errorf("无效的查询条件: 长度不能超过%d个字符", maxShortLinkQueryLength)
+	}
+	tokens, err := lexShortLinkQuery(input)
+	if err != nil {
+		return nil, err
+	}
+	terms := 0
+	for _, token := range tokens {
+		if token.kind == queryTokenTerm {
+			terms++
+		}
+	}
+	if terms > maxShortLinkQueryTerms {
+		return nil, fmt.Errorf("无效的查询条件: 条件数量不能超过%d个", maxShortLinkQueryTerms)
+	}
+	parser := &shortLinkQueryParser{tokens: tokens}
+	root, err := parser.parseOr(0)
+	if err != nil {
+		return nil, err
+	}
+	if parser.pos < len(parser.tokens) {
+		return nil, fmt.Errorf("无效的查询条件: 多余的 %q", parser.tokens[parser.pos].text)
+	}
+	query := &ShortLinkQuery{root: *root}
+	// 编译一次以便尽早发现字段与取值错误
+	if _, _, err := query.Build(time.Now()); err != nil {
+		return nil, err
+	}
+	return query, nil
+}
+
+// Build 生成参数化的 WHERE 片段，仅日期的取值按 now 所在时区划分整天
+func (q *ShortLinkQuery) Build(now time.Time) (string, []any, error) {
+	return compileShortLinkQueryNode(&q.root, now)
+}
+
+type queryTokenKind int
+
+const (
+	queryTokenTerm queryTokenKind = iota
+	queryTokenAnd
+	queryTokenOr
+	queryTokenNot
+	queryTokenLParen
+	queryTokenRParen
+)
+
+type queryToken struct {
+	kind    queryTokenKind
+	text    string
+	literal bool // 以引号开头的词只作为关键词，不解析字段与运算符
+}
+
+func lexShortLinkQuery(input string) ([]queryToken, error) {
+	var tokens []queryToken
+	runes := []rune(input)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case r == ' ' || r == '\t' || r == '\n' || r == '\r':
+			i++
+			continue
+		case r == '(':
+			tokens = append(tokens, queryToken{kind: queryTokenLParen, text: "("})
+			i++
+			continue
+		case r == ')':
+			tokens = append(tokens, queryToken{kind: queryTokenRParen, text: ")"})
+			i++
+			continue
+		}
+
+		var word strings.Builder
+		literal := r == '"'
+		for i < len(runes) {
+			r = runes[i]
+			if r == ' ' || r == '\t' || r == '\n' || r == '\r' || r == '(' || r == ')' {
+				break
+			}
+			if r == '"' {
+				i++
+				closed := false
+				for i < len(runes) {
+					if runes[i] == '\\' && i+1 < len(runes) {
+						word.WriteRune(runes[i+1])
+						i += 2
+						continue
+					}
+					if runes[i] == '"' {
+						closed = true
+						i++
+						break
+					}
+					word.WriteRune(runes[i])
+					i++
+				}
+				if !closed {
+					return nil, errors.New("无效的查询条件: 引号未闭合")
+				}
+				continue
+			}
+			word.WriteRune(r)
+			i++
+		}
+
+		text := word.String()
+		if literal {
+			tokens = append(tokens, queryToken{kind: queryTokenTerm, text: text, literal: true})
+			continue
+		}
+		switch strings.ToUpper(text) {
+		case "AND", "&&":
+			tokens = append(tokens, queryToken{kind: queryTokenAnd, text: text})
+		case "OR", "||":
+			tokens = append(tokens, queryToken{kind: queryTokenOr, text: text})
+		case "NOT":
+			tokens = append(tokens, queryToken{kind: queryTokenNot, text: text})
+		default:
+			tokens = append(tokens, queryToken{kind: queryTokenTerm, text: text})
+		}
+	}
+	return tokens, nil
+}
+
+type shortLinkQueryParser struct {
+	tokens []queryToken
+	pos    int
+}
+
+func (p *shortLinkQueryParser) peek() *queryToken {
+	if p.pos >= len(p.tokens) {
+		return nil
+	}
+	return &p.tokens[p.pos]
+}
+
+func (p *shortLinkQueryParser) parseOr(depth int) (*shortLinkQueryNode, error) {
+	left, err := p.parseAnd(depth)
+	if err != nil {
+		return nil, err
+	}
+	node := left
+	for token := p.peek(); token != nil && token.kind == queryTokenOr; token = p.peek() {
+		p.pos++
+		right, err := p.parseAnd(depth)
+		if err != nil {
+			return nil, err
+		}
+		if node.op != "or" {
+			node = &shortLinkQueryNode{op: "or", children: []*shortLinkQueryNode{node}}
+		}
+		node.children = append(node.children, right)
+	}
+	return node, nil
+}
+
+func (p *shortLinkQueryParser) parseAnd(depth int) (*shortLinkQueryNode, error) {
+	left, err := p.parseUnary(depth)
+	if err != nil {
+		return nil, err
+	}
+	node := left
+	for token := p.peek(); token != nil && token.kind != queryTokenOr && token.kind != queryTokenRParen; token = p.peek() {
+		if token.kind == queryTokenAnd {
+			p.pos++
+		}
+		right, err := p.parseUnary(depth)
+		if err != nil {
+			return nil, err
+		}
+		if node.op != "and" {
+			node = &shortLinkQueryNode{op: "and", children: []*shortLinkQueryNode{node}}
+		}
+		node.children = append(node.children, right)
+	}
+	return node, nil
+}
+
+func (p *shortLinkQueryParser) parseUnary(depth int) (*shortLinkQueryNode, error) {
+	if depth > maxShortLinkQueryDepth {
+		return nil, errors.New("无效的查询条件: 嵌套层级过深")
+	}
+	token := p.peek()
+	if token == nil {
+		return nil, errors.New("无效的查询条件: 语句不完整")
+	}
+	switch token.kind {
+	case queryTokenNot:
+		p.pos++
+		child, err := p.parseUnary(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		return &shortLinkQueryNode{op: "not", children: []*shortLinkQueryNode{child}}, nil
+	case queryTokenLParen:
+		p.pos++
+		node, err := p.parseOr(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		if next := p.peek(); next == nil || next.kind != queryTokenRParen {
+			return nil, errors.New("无效的查询条件: 括号未闭合")
+		}
+		p.pos++
+		return node, nil
+	case queryTokenTerm:
+		p.pos++
+		text := token.text
+		if token.literal {
+			return &shortLinkQueryNode{op: "term", operator: "=", value: text}, nil
+		}
+		if strings.HasPrefix(text, "-") && len(text) > 1 {
+			term := parseShortLinkQueryTerm(text[1:])
+			return &shortLinkQueryNode{op: "not", children: []*shortLinkQueryNode{term}}, nil
+		}
+		return parseShortLinkQueryTerm(text), nil
+	default:
+		return nil, fmt.Errorf("无效的查询条件: 意外的 %q", token.text)
+	}
+}
+
+// shortLinkQueryOperators 按长度优先匹配，field:>value 与 field>value 等价
+var shortLinkQueryOperators = []string{":>=", ":<=", ":!=", ":>", ":<", ":", ">=", "<=", "!=", ">", "<", "="}
+
+// parseShortLinkQueryTerm 拆分 field + 运算符 + 值；无字段时作为关键词
+func parseShortLinkQueryTerm(text string) *shortLinkQueryNode {
+	nameEnd := 0
+	for nameEnd < len(text) && (text[nameEnd] == '_' || (text[nameEnd] >= 'a' && text[nameEnd] <= 'z') || (text[nameEnd] >= 'A' && text[nameEnd] <= 'Z')) {
+		nameEnd++
+	}
+	// 自定义字段写作 cf.<字段标识>
+	if strings.EqualFold(text[:nameEnd], "cf") && nameEnd < len(text) && text[nameEnd] == '.' {
+		nameEnd++
+		for nameEnd < len(text) && (text[nameEnd] == '_' || (text[nameEnd] >= 'a' && text[nameEnd] <= 'z') || (text[nameEnd] >= '0' && text[nameEnd] <= '9')) {
+			nameEnd++
+		}
+	}
+	if nameEnd > 0 && nameEnd < len(text) {
+		rest := text[nameEnd:]
+		for _, prefix := range shortLinkQueryOperators {
+			if !strings.HasPrefix(rest, prefix) {
+				continue
+			}
+			operator := strings.TrimPrefix(prefix, ":")
+			if operator == "" {
+				operator = "="
+			}
+			return &shortLinkQueryNode{
+				op:       "term",
+				field:    strings.ToLower(text[:nameEnd]),
+				operator: operator,
+				value:    rest[len(prefix):],
+			}
+		}
+	}
+	return &shortLinkQueryNode{op: "term", operator: "=", value: text}
+}
+
+func compileShortLinkQueryNode(node *shortLinkQueryNode, now time.Time) (string, []any, error) {
+	switch node.op {
+	case "and", "or":
+		parts := make([]string, 0, len(node.children))
+		var args []any
+		for _, child := range node.children {
+			sql, childArgs, err := compileShortLinkQueryNode(child, now)
+			if err != nil {
+				return "", nil, err
+			}
+			parts = append(parts, "("+sql+")")
+			args = append(args, childArgs...)
+		}
+		return strings.Join(parts, " "+strings.ToUpper(node.op)+" "), args, nil
+	case "not":
+		sql, args, err := compileShortLinkQueryNode(node.children[0], now)
+		if err != nil {
+			return "", nil, err
+		}
+		return "NOT (" + sql + ")", args, nil
+	default:
+		return compileShortLinkQueryTerm(node, now)
+	}
+}
+
+func compileShortLinkQueryTerm(node *shortLinkQueryNode, now time.Time) (string, []any, error) {
+	if node.value == "" {
+		return "", nil, fmt.Errorf("无效的查询条件: %s 缺少取值", node.field)
+	}
+	if node.field == "" {
+		pattern := "%" + escapeQueryLike(strings.ToLower(node.value)) + "%"
+		return "LOWER(short_links.original_url) LIKE ? ESCAPE '!' OR LOWER(COALESCE(short_links.title, '')) LIKE ? ESCAPE '!' OR LOWER(COALESCE(short_links.description, '')) LIKE ? ESCAPE '!'",
+			[]any{pattern, pattern, pattern}, nil
+	}
+	if key, ok := strings.CutPrefix(node.field, "cf."); ok {
+		return compileQueryCustomFieldCondition(key, node)
+	}
+	field, ok := shortLinkQueryFields[node.field]
+	if !ok {
+		return "", nil, fmt.Errorf("无效的查询条件: 未知字段 %s", node.field)
+	}
+
+	switch field.kind {
+	case queryFieldText:
+		return compileQueryTextCondition("COALESCE("+field.column+", '')", node)
+	case queryFieldNumber:
+		number, err := strconv.ParseInt(node.value, 10, 64)
+		if err != nil {
+			return "", nil, fmt.Errorf("无效的查询条件: %s 需要整数", node.field)
+		}
+		return field.column + " " + node.operator + " ?", []any{number}, nil
+	case queryFieldDate:
+		return compileQueryDateCondition(field.column, node, now.Location())
+	case queryFieldTag:
+		condition, args, err := compileQueryTextCondition("t.name", node)
+		if err != nil {
+			return "", nil, err
+		}
+		return "EXISTS (SELECT 1 FROM short_link_tags qslt JOIN tags t ON t.id = qslt.tag_id WHERE qslt.short_link_id = short_links.id AND t.deleted_at IS NULL AND " + condition + ")", args, nil
+	case queryFieldCampaign:
+		condition, args, err := compileQueryTextCondition("c.name", node)
+		if err != nil {
+			return "", nil, err
+		}
+		if id, err := strconv.ParseUint(node.value, 10, 64); err == nil && node.operator == "=" {
+			condition = "(" + condition + " OR c.id = ?)"
+			args = append(args, id)
+		}
+		return "EXISTS (SELECT 1 FROM campaigns c WHERE c.id = short_links.campaign_id AND c.deleted_at IS NULL AND " + condition + ")", args, nil
+	case queryFieldSecurity:
+		if node.operator != "=" && node.operator != "!=" {
+			return "", nil, errors.New("无效的查询条件: security 仅支持 : 或 !=")
+		}
+		sql, args, ok := securityStatusCondition(strings.ToLower(node.value))
+		if !ok {
+			return "", nil, fmt.Errorf("无效的查询条件: 未知的安全状态 %s", node.value)
+		}
+		return negateIf(node.operator == "!=", sql), args, nil
+	case queryFieldRouting:
+		if node.operator != "=" && node.operator != "!=" {
+			return "", nil, errors.New("无效的查询条件: routing 仅支持 : 或 !=")
+		}
+		sql, args, ok := routingStatusCondition(strings.ToLower(node.value))
+		if !ok {
+			return "", nil, fmt.Errorf("无效的查询条件: 未知的路由状态 %s", node.value)
+		}
+		return negateIf(node.operator == "!=", sql), args, nil
+	case queryFieldHealth:
+		if node.operator != "=" && node.operator != "!=" {
+			return "", nil, errors.New("无效的查询条件: health 仅支持 : 或 !=")
+		}
+		sql, args, ok := healthStatusCondition(strings.ToLower(node.value))
+		if !ok {
+			return "", nil, fmt.Errorf("无效的查询条件: 未知的健康状态 %s", node.value)
+		}
+		return negateIf(node.operator == "!=", sql), args, nil
+	case queryFieldReview:
+		if node.operator != "=" && node.operator != "!=" {
+			return "", nil, errors.New("无效的查询条件: review 仅支持 : 或 !=")
+		}
+		sql, args, ok := reviewStatusCondition(strings.ToLower(node.value))
+		if !ok {
+			return "", nil, fmt.Errorf("无效的查询条件: 未知的审核状态 %s", node.value)
+		}
+		return negateIf(node.operator == "!=", sql), args, nil
+	case queryFieldState:
+		if node.operator != "=" && node.operator != "!=" {
+			return "", nil, errors.New("无效的查询条件: is 仅支持 : 或 !=")
+		}
+		var sql string
+		var args []any
+		switch strings.ToLower(node.value) {
+		case "active":
+			sql, args = "short_links.is_active = ?", []any{true}
+		case "inactive":
+			sql, args = "short_links.is_active = ?", []any{false}
+		case "expired":
+			sql, args = "short_links.expire_at IS NOT NULL AND short_links.expire_at <= ?", []any{now}
+		case "custom":
+			sql, args = "short_links.is_custom_code = ?", []any{true}
+		default:
+			return "", nil, fmt.Errorf("无效的查询条件: 未知的状态 %s", node.value)
+		}
+		return negateIf(node.operator == "!=", sql), args, nil
+	}
+	return "", nil, fmt.Errorf("无效的查询条件: 未知字段 %s", node.field)
+}
+
+// compileQueryTextCondition 文本字段支持等于、不等于与 * 通配符，通配匹配不区分大小写
+func compileQueryTextCondition(column string, node *shortLinkQueryNode) (string, []any, error) {
+	if node.operator != "=" && node.operator != "!=" {
+		return "", nil, fmt.Errorf("无效的查询条件: %s 仅支持 : 或 !=", node.field)
+	}
+	var sql string
+	var args []any
+	if strings.Contains(node.value, "*") {
+		pattern := strings.ReplaceAll(escapeQueryLike(strings.ToLower(node.value)), "*", "%")
+		sql, args = "LOWER("+column+") LIKE ? ESCAPE '!'", []any{pattern}
+	} else {
+		sql, args = column+" = ?", []any{node.value}
+	}
+	return negateIf(node.operator == "!=", sql), args, nil
+}
+
+// compileQueryDateCondition 仅日期的取值按 location 中的整天处理：created:2026-01-01 匹配当天，created:>2026-01-01 从次日开始
+func compileQueryDateCondition(column string, node *shortLinkQueryNode, location *time.Location) (string, []any, error) {
+	if node.operator == "!=" {
+		return "", nil, fmt.Errorf("无效的查询条件: %s 不支持 !=", node.field)
+	}
+	if value, err := time.Parse(time.RFC3339, node.value); err == nil {
+		return column + " " + node.operator + " ?", []any{value}, nil
+	}
+	day, err := time.ParseInLocation("2006-01-02", node.value, location)
+	if err != nil {
+		return "", nil, fmt.Errorf("无效的查询条件: %s 需要 YYYY-MM-DD 或 RFC3339 时间", node.field)
+	}
+	next := day.AddDate(0, 0, 1)
+	switch node.operator {
+	case ">":
+		return column + " >= ?", []any{next}, nil
+	case ">=":
+		return column + " >= ?", []any{day}, nil
+	case "<":
+		return column + " < ?", []any{day}, nil
+	case "<=":
+		return column + " < ?", []any{next}, nil
+	default:
+		return column + " >= ? AND " + column + " < ?", []any{day, next}, nil
+	}
+}
+
+// compileQueryCustomFieldCondition cf.<key> 按自定义字段取值筛选；数字取值的大小比较使用 number_value，
+// 其余按文本比较（日期取值为 YYYY-MM-DD，可直接比较）
+func compileQueryCustomFieldCondition(key string, node *shortLinkQueryNode) (string, []any, error) {
+	if key == "" {
+		return "", nil, errors.New("无效的查询条件: cf. 缺少字段标识")
+	}
+	const valueExists = "EXISTS (SELECT 1 FROM short_link_custom_values qcv WHERE qcv.short_link_id = short_links.id AND qcv.field_key = ? AND "
+	if node.operator == "=" || node.operator == "!=" {
+		match := *node
+		match.operator = "="
+		condition, args, err := compileQueryTextCondition("qcv.value", &match)
+		if err != nil {
+			return "", nil, err
+		}
+		return negateIf(node.operator == "!=", valueExists+condition+")"), append([]any{key}, args...), nil
+	}
+	if number, err := strconv.ParseFloat(node.value, 64); err == nil {
+		return valueExists + "qcv.number_value " + node.operator + " ?)", []any{key, number}, nil
+	}
+	return valueExists + "qcv.value " + node.operator + " ?)", []any{key, node.value}, nil
+}
+
+func escapeQueryLike(value string) string {
+	return strings.NewReplacer("!", "!!", "%", "!%", "_", "!_").Replace(value)
+}
+
+func negateIf(negate bool, sql string) string {
+	if negate {
+		return "NOT (" + sql + ")"
+	}
+	return sql
+}
+
+// securityStatusCondition 安全状态筛选条件，列表筛选与搜索语法共用
+func securityStatusCondition(status string) (string, []any, bool) {
+	const settingExists = "EXISTS (SELECT 1 FROM link_security_settings lss WHERE lss.short_link_id = short_links.id AND lss.deleted_at IS NULL AND "
+	enabled := "(lss.password_enabled = ? OR lss.access_window_start IS NOT NULL OR lss.access_window_end IS NOT NULL OR lss.max_clicks IS NOT NULL OR lss.ip_policy <> ? OR lss.bot_policy = ? OR lss.report_enabled = ? OR lss.url_blocked = ?)"
+	enabledArgs := []any{true, model.LinkIPPolicyOff, model.LinkBotPolicyBlockKnownBots, true, true}
+	switch status {
+	case "none":
+		return "NOT " + settingExists + enabled + ")", enabledArgs, true
+	case "enabled":
+		return settingExists + enabled + ")", enabledArgs, true
+	case "password":
+		return settingExists + "lss.password_enabled = ?)", []any{true}, true
+	case "restricted":
+		return settingExists + "(lss.access_window_start IS NOT NULL OR lss.access_window_end IS NOT NULL OR lss.max_clicks IS NOT NULL OR lss.ip_policy <> ? OR lss.bot_policy = ?))",
+			[]any{model.LinkIPPolicyOff, model.LinkBotPolicyBlockKnownBots}, true
+	case "url_blocked":
+		return settingExists + "lss.url_blocked = ?)", []any{true}, true
+	case "reported":
+		return "EXISTS (SELECT 1 FROM abuse_reports ar WHERE ar.short_link_id = short_links.id AND ar.workspace_id = short_links.workspace_id)", nil, true
+	}
+	return "", nil, false
+}
+
+// routingStatusCondition 高级路由状态筛选条件，列表筛选与搜索语法共用
+func routingStatusCondition(status string) (string, []any, bool) {
+	switch status {
+	case "none":
+		return "NOT EXISTS (SELECT 1 FROM link_routes lr WHERE lr.short_link_id = short_links.id AND lr.workspace_id = short_links.workspace_id AND lr.deleted_at IS NULL)", nil, true
+	case "enabled":
+		return "EXISTS (SELECT 1 FROM link_routes lr WHERE lr.short_link_id = short_links.id AND lr.workspace_id = short_links.workspace_id AND lr.is_active = ? AND lr.deleted_at IS NULL)", []any{true}, true
+	case "fallback":
+		return "short_links.fallback_url <> '' AND EXISTS (SELECT 1 FROM link_routes lr WHERE lr.short_link_id = short_links.id AND lr.workspace_id = short_links.workspace_id AND lr.is_active = ? AND lr.deleted_at IS NULL)", []any{true}, true
+	case "disabled":
+		return "EXISTS (SELECT 1 FROM link_routes lr WHERE lr.short_link_id = short_links.id AND lr.workspace_id = short_links.workspace_id AND lr.deleted_at IS NULL) AND NOT EXISTS (SELECT 1 FROM link_routes lr2 WHERE lr2.short_link_id = short_links.id AND lr2.workspace_id = short_links.workspace_id AND lr2.is_active = ? AND lr2.deleted_at IS NULL)", []any{true}, true
+	}
+	return "", nil, false
+}
+
+// healthStatusCondition 目标地址健康状态筛选条件，列表筛选与搜索语法共用
+func healthStatusCondition(status string) (string, []any, bool) {
+	switch status {
+	case model.LinkHealthStatusHealthy, model.LinkHealthStatusBroken:
+		return "short_links.health_status = ?", []any{status}, true
+	case model.LinkHealthStatusUnchecked:
+		return "COALESCE(short_links.health_status, '') = ''", nil, true
+	}
+	return "", nil, false
+}
+
+// reviewStatusCondition 审核状态筛选条件，列表筛选与搜索语法共用
+func reviewStatusCondition(status string) (string, []any, bool) {
+	switch status {
+	case model.ShortLinkReviewPending, model.ShortLinkReviewApproved, model.ShortLinkReviewRejected:
+		return "COALESCE(short_links.review_status, '') = ?", []any{status}, true
+	}
+	return "", nil, false
+}
+
+// ShortLinkSort 列表排序，只允许按有索引的列排序
+type ShortLinkSort struct {
+	Key    string
+	Column string
+	Desc   bool
+}
+
+// shortLinkSortColumns 可排序字段
+var shortLinkSortColumns = map[string]string{
+	"id":      "short_links.id",
+	"created": "short_links.created_at",
+	"updated": "short_links.updated_at",
+	"clicks":  "short_links.click_count",
+	"domain":  "short_links.domain",
+	"code":    "short_links.short_code",
+}
+
+// ParseShortLinkSort 解析排序参数，如 "-clicks" 表示按点击数倒序，默认按创建时间倒序
+func ParseShortLinkSort(value string) (ShortLinkSort, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		value = "-created"
+	}
+	desc := strings.HasPrefix(value, "-")
+	key := strings.TrimPrefix(strings.TrimPrefix(value, "-"), "+")
+	column, ok := shortLinkSortColumns[key]
+	if !ok {
+		return ShortLinkSort{}, fmt.Errorf("无效的排序字段: %s", key)
+	}
+	return ShortLinkSort{Key: key, Column: column, Desc: desc}, nil
+}
+
+// OrderClause 排序语句，以 id 作为次序键保证游标分页稳定
+func (s ShortLinkSort) OrderClause() string {
+	direction := "ASC"
+	if s.Desc {
+		direction = "DESC"
+	}
+	if s.Column == "short_links.id" {
+		return s.Column + " " + direction
+	}
+	return s.Column + " " + direction + ", short_links.id " + direction
+}
+
+// shortLinkCursor 游标内容：排序字段与方向，以及上一页最后一条记录的排序值与 ID
+type shortLinkCursor struct {
+	Sort  string `json:"s"`
+	Desc  bool   `json:"d"`
+	Value string `json:"v,omitempty"`
+	At    int64  `json:"t,omitempty"` // 按时间排序时的 Unix 纳秒时间戳
+	ID    uint64 `json:"id"`
+}
+
+// EncodeShortLinkCursor 根据当前页最后一条记录生成下一页游标
+func EncodeShortLinkCursor(sort ShortLinkSort, shortLink *model.ShortLink) string {
+	cursor := shortLinkCursor{Sort: sort.Key, Desc: sort.Desc, ID: shortLink.ID}
+	switch sort.Key {
+	case "created":
+		cursor.At = shortLink.CreatedAt.UnixNano()
+	case "updated":
+		cursor.At = shortLink.UpdatedAt.UnixNano()
+	case "clicks":
+		cursor.Value = strconv.FormatInt(shortLink.ClickCount, 10)
+	case "domain":
+		cursor.Value = shortLink.Domain
+	case "code":
+		cursor.Value = shortLink.ShortCode
+	}
+	body, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(body)
+}
+
+// cursorCondition 生成游标之后的记录条件
+func (s ShortLinkSort) cursorCondition(encoded string) (string, []any, error) {
+	body, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", nil, errors.New("无效的分页游标")
+	}
+	var cursor shortLinkCursor
+	// 游标只能用于生成它的排序字段与方向
+	if err := json.Unmarshal(body, &cursor); err != nil || cursor.Sort != s.Key || cursor.Desc != s.Desc {
+		return "", nil, errors.New("无效的分页游标")
+	}
+	comparator := ">"
+	if s.Desc {
+		comparator = "<"
+	}
+	if s.Key == "id" {
+		return "short_links.id " + comparator + " ?", []any{cursor.ID}, nil
+	}
+
+	var value any = cursor.Value
+	switch s.Key {
+	case "created", "updated":
+		// 按本地时区还原时间，与写入时的时间值保持一致，避免按字符串比较
+		value = time.Unix(0, cursor.At)
+	case "clicks":
+		parsed, err := strconv.ParseInt(cursor.Value, 10, 64)
+		if err != nil {
+			return "", nil, errors.New("无效的分页游标")
+		}
+		value = parsed
+	}
+	return s.Column + " " + comparator + " ? OR (" + s.Column + " = ? AND short_links.id " + comparator + " ?)",
+		[]any{value, value, cursor.ID}, nil
+}
@@ -60,22 +60,20 @@ func (d *TagDao) FindMany(ids []uint64, workspaceID uint64) ([]model.Tag, error)
 	return tags, err
 }
 
-func (d *TagDao) ReplaceShortLinkTags(shortLinkID uint64, tagIDs []uint64) error {
-	db := d.helper.GetDatabase()
-	return db.Transaction(func(tx *gorm.DB) error {
-		if err := tx.Where("short_link_id = ?", shortLinkID).Delete(&model.ShortLinkTag{}).Error; err != nil {
+// ReplaceShortLinkTags 在调用方的事务中替换短网址的标签
+func (d *TagDao) ReplaceShortLinkTags(tx *gorm.DB, shortLinkID uint64, tagIDs []uint64) error {
+	if err := tx.Where("short_link_id = ?", shortLinkID).Delete(&model.ShortLinkTag{}).Error; err != nil {
+		return err
+	}
+	for _, tagID := range tagIDs {
+		if err := tx.Create(&model.ShortLinkTag{
+			ShortLinkID: shortLinkID,
+			TagID:       tagID,
+		}).Error; err != nil {
 			return err
 		}
-		for _, tagID := range tagIDs {
-			if err := tx.Create(&model.ShortLinkTag{
-				ShortLinkID: shortLinkID,
-				TagID:       tagID,
-			}).Error; err != nil {
-				return err
-			}
-		}
-		return nil
-	})
+	}
+	return nil
 }
 
 func (d *TagDao) GetTagsByShortLinkID(shortLinkID uint64) ([]model.Tag, error) {
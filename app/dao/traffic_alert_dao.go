@@ -0,0 +1,188 @@
+package dao
+
+import (
+	"errors"
+	"time"
+
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/dto"
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/model"
+	"cnb.cool/mliev/dwz/dwz-server/v2/pkg/interfaces"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// TrafficAlertDao 流量异常告警、阈值设置和投递渠道DAO
+type TrafficAlertDao struct {
+	helper interfaces.HelperInterface
+}
+
+func NewTrafficAlertDao(helper interfaces.HelperInterface) *TrafficAlertDao {
+	return &TrafficAlertDao{helper: helper}
+}
+
+func (d *TrafficAlertDao) FindSetting(workspaceID uint64) (*model.TrafficAlertSetting, error) {
+	var setting model.TrafficAlertSetting
+	err := d.helper.GetDatabase().Where("workspace_id = ?", workspaceID).First(&setting).Error
+	return &setting, err
+}
+
+func (d *TrafficAlertDao) SaveSetting(setting *model.TrafficAlertSetting) error {
+	db := d.helper.GetDatabase()
+	if setting.ID != 0 {
+		return db.Save(setting).Error
+	}
+	return db.Create(setting).Error
+}
+
+func (d *TrafficAlertDao) ListChannels(workspaceID uint64, enabledOnly bool) ([]model.AlertChannel, error) {
+	var channels []model.AlertChannel
+	query := d.helper.GetDatabase().Where("workspace_id = ?", workspaceID)
+	if enabledOnly {
+		query = query.Where("enabled = ?", true)
+	}
+	err := query.Order("id ASC").Find(&channels).Error
+	return channels, err
+}
+
+func (d *TrafficAlertDao) FindChannel(id, workspaceID uint64) (*model.AlertChannel, error) {
+	var channel model.AlertChannel
+	err := d.helper.GetDatabase().Where("id = ? AND workspace_id = ?", id, workspaceID).First(&channel).Error
+	return &channel, err
+}
+
+func (d *TrafficAlertDao) SaveChannel(channel *model.AlertChannel) error {
+	db := d.helper.GetDatabase()
+	if channel.ID != 0 {
+		return db.Save(channel).Error
+	}
+	return db.Create(channel).Error
+}
+
+func (d *TrafficAlertDao) DeleteChannel(id, workspaceID uint64) (int64, error) {
+	result := d.helper.GetDatabase().Where("id = ? AND workspace_id = ?", id, workspaceID).Delete(&model.AlertChannel{})
+	return result.RowsAffected, result.Error
+}
+
+// ListRecentAlerts 返回 since 之后的告警对象、类型和时间，用于冷却判断
+func (d *TrafficAlertDao) ListRecentAlerts(workspaceID uint64, since time.Time) ([]model.TrafficAlert, error) {
+	var alerts []model.TrafficAlert
+	err := d.helper.GetDatabase().
+		Select("id, short_link_id, alert_type, created_at").
+		Where("workspace_id = ? AND created_at >= ?", workspaceID, since).
+		Find(&alerts).Error
+	return alerts, err
+}
+
+// CreateAlert 写入告警和待投递记录，同一时间段内已有同类告警时不写入并返回 false
+func (d *TrafficAlertDao) CreateAlert(alert *model.TrafficAlert, deliveries []model.TrafficAlertDelivery) (bool, error) {
+	created := false
+	err := d.helper.GetDatabase().Transaction(func(tx *gorm.DB) error {
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(alert)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		created = true
+		if len(deliveries) == 0 {
+			return nil
+		}
+		for i := range deliveries {
+			deliveries[i].AlertID = alert.ID
+		}
+		return tx.Create(&deliveries).Error
+	})
+	return created, err
+}
+
+func (d *TrafficAlertDao) FindAlert(id, workspaceID uint64) (*model.TrafficAlert, error) {
+	var alert model.TrafficAlert
+	err := d.helper.GetDatabase().Where("id = ? AND workspace_id = ?", id, workspaceID).First(&alert).Error
+	return &alert, err
+}
+
+func (d *TrafficAlertDao) ListAlerts(workspaceID uint64, req *dto.TrafficAlertListRequest) ([]model.TrafficAlert, int64, error) {
+	query := d.helper.GetDatabase().Model(&model.TrafficAlert{}).Where("workspace_id = ?", workspaceID)
+	if req.ShortLinkID != nil {
+		query = query.Where("short_link_id = ?", *req.ShortLinkID)
+	}
+	if req.AlertType != "" {
+		query = query.Where("alert_type = ?", req.AlertType)
+	}
+	if req.Acknowledged != nil {
+		if *req.Acknowledged {
+			query = query.Where("acknowledged_at IS NOT NULL")
+		} else {
+			query = query.Where("acknowledged_at IS NULL")
+		}
+	}
+	if req.StartDate != nil {
+		query = query.Where("created_at >= ?", *req.StartDate)
+	}
+	if req.EndDate != nil {
+		query = query.Where("created_at < ?", req.EndDate.AddDate(0, 0, 1))
+	}
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var alerts []model.TrafficAlert
+	err := query.Order("created_at DESC, id DESC").Offset((req.Page - 1) * req.PageSize).Limit(req.PageSize).Find(&alerts).Error
+	return alerts, total, err
+}
+
+// Acknowledge 标记告警已处理，已处理的告警保持原处理人
+func (d *TrafficAlertDao) Acknowledge(id, workspaceID, userID uint64, now time.Time) error {
+	return d.helper.GetDatabase().Model(&model.TrafficAlert{}).
+		Where("id = ? AND workspace_id = ? AND acknowledged_at IS NULL", id, workspaceID).
+		Updates(map[string]any{"acknowledged_by": userID, "acknowledged_at": now}).Error
+}
+
+func (d *TrafficAlertDao) ListDeliveries(alertIDs []uint64) ([]model.TrafficAlertDelivery, error) {
+	var deliveries []model.TrafficAlertDelivery
+	if len(alertIDs) == 0 {
+		return deliveries, nil
+	}
+	err := d.helper.GetDatabase().Where("alert_id IN ?", alertIDs).Order("id ASC").Find(&deliveries).Error
+	return deliveries, err
+}
+
+// ListDueDeliveries 到达重试时间的待投递记录
+func (d *TrafficAlertDao) ListDueDeliveries(now time.Time, limit int) ([]model.TrafficAlertDelivery, error) {
+	var deliveries []model.TrafficAlertDelivery
+	err := d.helper.GetDatabase().
+		Where("status = ? AND next_attempt_at <= ?", model.AlertDeliveryStatusPending, now).
+		Order("next_attempt_at ASC, id ASC").
+		Limit(limit).
+		Find(&deliveries).Error
+	return deliveries, err
+}
+
+// ClaimDelivery 以尝试次数做乐观锁领取一次投递，并预先推迟下次重试时间，多个实例不会同时投递
+func (d *TrafficAlertDao) ClaimDelivery(delivery *model.TrafficAlertDelivery, nextAttemptAt time.Time) (bool, error) {
+	result := d.helper.GetDatabase().Model(&model.TrafficAlertDelivery{}).
+		Where("id = ? AND status = ? AND attempts = ?", delivery.ID, model.AlertDeliveryStatusPending, delivery.Attempts).
+		Updates(map[string]any{"attempts": gorm.Expr("attempts + 1"), "next_attempt_at": nextAttemptAt})
+	if result.Error != nil || result.RowsAffected == 0 {
+		return false, result.Error
+	}
+	delivery.Attempts++
+	delivery.NextAttemptAt = nextAttemptAt
+	return true, nil
+}
+
+func (d *TrafficAlertDao) UpdateDelivery(id uint64, updates map[string]any) error {
+	return d.helper.GetDatabase().Model(&model.TrafficAlertDelivery{}).Where("id = ?", id).Updates(updates).Error
+}
+
+// FindAlertAndChannel 投递所需的告警和渠道，渠道已删除时返回 nil
+func (d *TrafficAlertDao) FindAlertAndChannel(alertID, channelID uint64) (*model.TrafficAlert, *model.AlertChannel, error) {
+	var alert model.TrafficAlert
+	if err := d.helper.GetDatabase().First(&alert, alertID).Error; err != nil {
+		return nil, nil, err
+	}
+	var channel model.AlertChannel
+	err := d.helper.GetDatabase().Where("id = ? AND workspace_id = ?", channelID, alert.WorkspaceID).First(&channel).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &alert, nil, nil
+	}
+	return &alert, &channel, err
+}
@@ -0,0 +1,86 @@
+package dao
+
+import (
+	"time"
+
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/model"
+	"cnb.cool/mliev/dwz/dwz-server/v2/pkg/interfaces"
+	"cnb.cool/mliev/dwz/dwz-server/v2/pkg/service/hyperloglog"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// VisitorDao 访客盐值与独立访客草图DAO
+type VisitorDao struct {
+	helper interfaces.HelperInterface
+}
+
+func NewVisitorDao(helper interfaces.HelperInterface) *VisitorDao {
+	return &VisitorDao{helper: helper}
+}
+
+// FindOrCreateSalt 获取某天的盐值，不存在时写入候选值；多个实例并发写入时以先写入的为准
+func (d *VisitorDao) FindOrCreateSalt(day, candidate string) (string, error) {
+	db := d.helper.GetDatabase()
+	if err := db.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&model.VisitorSalt{Day: day, Salt: candidate}).Error; err != nil {
+		return "", err
+	}
+	var salt model.VisitorSalt
+	if err := db.Where("day = ?", day).First(&salt).Error; err != nil {
+		return "", err
+	}
+	return salt.Salt, nil
+}
+
+// PurgeSaltsBefore 删除早于指定日期的盐值
+func (d *VisitorDao) PurgeSaltsBefore(day string) (int64, error) {
+	result := d.helper.GetDatabase().Where("day < ?", day).Delete(&model.VisitorSalt{})
+	return result.RowsAffected, result.Error
+}
+
+// ListSketches 读取时间范围内的草图，shortLinkIDs 为空时读取工作区合计草图
+func (d *VisitorDao) ListSketches(workspaceID uint64, shortLinkIDs []uint64, start, end time.Time) ([]model.VisitorSketch, error) {
+	var sketches []model.VisitorSketch
+	query := d.helper.GetDatabase().Where("workspace_id = ? AND day >= ? AND day < ?", workspaceID, start, end)
+	if len(shortLinkIDs) > 0 {
+		query = query.Where("short_link_id IN ?", shortLinkIDs)
+	} else {
+		query = query.Where("short_link_id = 0")
+	}
+	err := query.Find(&sketches).Error
+	return sketches, err
+}
+
+// MergeSketches 将草图合并到已有记录，需在持有汇总游标锁的事务中调用，避免并发覆盖
+func (d *VisitorDao) MergeSketches(tx *gorm.DB, sketches []model.VisitorSketch) error {
+	for _, sketch := range sketches {
+		var existing []model.VisitorSketch
+		if err := tx.Where("workspace_id = ? AND short_link_id = ? AND day = ?", sketch.WorkspaceID, sketch.ShortLinkID, sketch.Day).
+			Limit(1).Find(&existing).Error; err != nil {
+			return err
+		}
+		if len(existing) == 0 {
+			if err := tx.Create(&sketch).Error; err != nil {
+				return err
+			}
+			continue
+		}
+		merged, err := hyperloglog.FromBytes(existing[0].Registers)
+		if err != nil {
+			merged = hyperloglog.New()
+		}
+		incoming, err := hyperloglog.FromBytes(sketch.Registers)
+		if err != nil {
+			return err
+		}
+		merged.Merge(incoming)
+		if err := tx.Model(&existing[0]).Updates(map[string]any{
+			"registers":  merged.Bytes(),
+			"updated_at": time.Now(),
+		}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -27,6 +27,27 @@ func (d *WorkspaceDao) FindByID(id uint64) (*model.Workspace, error) {
 	return &workspace, err
 }
 
+// ListActive 所有启用的工作区
+func (d *WorkspaceDao) ListActive() ([]model.Workspace, error) {
+	var workspaces []model.Workspace
+	err := d.helper.GetDatabase().Where("status = ? AND deleted_at IS NULL", 1).Order("id ASC").Find(&workspaces).Error
+	return workspaces, err
+}
+
+// ListAll 所有未删除的工作区，包括已停用的
+func (d *WorkspaceDao) ListAll() ([]model.Workspace, error) {
+	var workspaces []model.Workspace
+	err := d.helper.GetDatabase().Where("deleted_at IS NULL").Order("id ASC").Find(&workspaces).Error
+	return workspaces, err
+}
+
+// ListAllWithDeleted 全部工作区，包括已删除的
+func (d *WorkspaceDao) ListAllWithDeleted() ([]model.Workspace, error) {
+	var workspaces []model.Workspace
+	err := d.helper.GetDatabase().Unscoped().Order("id ASC").Find(&workspaces).Error
+	return workspaces, err
+}
+
 func (d *WorkspaceDao) FindBySlug(slug string) (*model.Workspace, error) {
 	var workspace model.Workspace
 	err := d.helper.GetDatabase().Where("slug = ? AND deleted_at IS NULL", slug).First(&workspace).Error
@@ -0,0 +1,28 @@
+package dto
+
+import "time"
+
+// ClickExportResponse 点击明细异步导出任务
+type ClickExportResponse struct {
+	ID          uint64     `json:"id"`
+	Format      string     `json:"format"` // csv 或 ndjson，文件均为 gzip 压缩
+	Status      string     `json:"status"` // pending、running、completed、failed、cancelled、expired
+	RowCount    int64      `json:"row_count"`
+	FileSize    int64      `json:"file_size"`
+	Error       string     `json:"error,omitempty"`
+	DownloadURL string     `json:"download_url,omitempty"` // 仅已完成的任务返回，无需登录即可下载，过期后失效
+	ExpiresAt   *time.Time `json:"expires_at"`
+	StartedAt   *time.Time `json:"started_at"`
+	FinishedAt  *time.Time `json:"finished_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+type ClickExportListResponse struct {
+	List []ClickExportResponse `json:"list"`
+}
+
+// ClickStatisticExportRecord NDJSON 导出的一行，自定义字段按字段标识输出
+type ClickStatisticExportRecord struct {
+	ClickStatisticDetailResponse
+	CustomFields map[string]string `json:"custom_fields,omitempty"`
+}
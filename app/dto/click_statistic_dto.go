@@ -4,54 +4,72 @@ import "time"
 
 // ClickStatisticListRequest 点击统计列表请求
 type ClickStatisticListRequest struct {
-	Page        int       `form:"page" binding:"min=1" example:"1"`
-	PageSize    int       `form:"page_size" binding:"min=1,max=100" example:"10"`
-	ShortLinkID uint64    `form:"short_link_id" example:"1"` // 短链接ID筛选
-	CampaignID  uint64    `form:"campaign_id"`
-	RouteID     uint64    `form:"route_id"`
-	TagID       uint64    `form:"tag_id"`
-	DeviceType  string    `form:"device_type"`
-	IsBot       *bool     `form:"is_bot"`
-	IP          string    `form:"ip" example:"192.168.1.1"`                                 // IP地址筛选
-	Country     string    `form:"country" example:"中国"`                                     // 国家筛选
-	Province    string    `form:"province" example:"广东省"`                                   // 省份筛选
-	City        string    `form:"city" example:"北京"`                                        // 城市筛选
-	ISP         string    `form:"isp" example:"电信"`                                         // 运营商筛选
-	StartDate   time.Time `form:"start_date" time_format:"2006-01-02" example:"2023-01-01"` // 开始日期
-	EndDate     time.Time `form:"end_date" time_format:"2006-01-02" example:"2023-12-31"`   // 结束日期
+	Page           int       `form:"page" binding:"min=1" example:"1"`
+	PageSize       int       `form:"page_size" binding:"min=1,max=100" example:"10"`
+	ShortLinkID    uint64    `form:"short_link_id" example:"1"` // 短链接ID筛选
+	CampaignID     uint64    `form:"campaign_id"`
+	RouteID        uint64    `form:"route_id"`
+	AliasID        uint64    `form:"alias_id"` // 别名筛选
+	TagID          uint64    `form:"tag_id"`
+	FolderID       uint64    `form:"folder_id"` // 文件夹筛选，包含子文件夹
+	FolderIDs      []uint64  `form:"-"`         // 由服务层展开后的文件夹ID
+	DeviceType     string    `form:"device_type"`
+	IsBot          *bool     `form:"is_bot"`
+	MinBotScore    *int      `form:"min_bot_score" binding:"omitempty,min=0,max=100"`          // 机器人评分下限（含）
+	MaxBotScore    *int      `form:"max_bot_score" binding:"omitempty,min=0,max=100"`          // 机器人评分上限（含）
+	BotReason      string    `form:"bot_reason"`                                               // 命中的识别规则，如 datacenter、email_scanner
+	WithDuplicates bool      `form:"include_duplicates"`                                       // 是否包含去重窗口内的重复点击，默认不包含
+	IP             string    `form:"ip" example:"192.168.1.1"`                                 // IP地址筛选
+	Country        string    `form:"country" example:"中国"`                                     // 国家筛选
+	Province       string    `form:"province" example:"广东省"`                                   // 省份筛选
+	City           string    `form:"city" example:"北京"`                                        // 城市筛选
+	ISP            string    `form:"isp" example:"电信"`                                         // 运营商筛选
+	RefererChannel string    `form:"referer_channel"`                                          // 来源渠道筛选
+	StartDate      time.Time `form:"start_date" time_format:"2006-01-02" example:"2023-01-01"` // 开始日期
+	EndDate        time.Time `form:"end_date" time_format:"2006-01-02" example:"2023-12-31"`   // 结束日期
+	Timezone       string    `form:"tz" example:"Asia/Shanghai"`                               // 按天、按小时划分使用的时区，为空时使用工作区时区
+
+	Location *time.Location `form:"-" json:"-"` // 由控制器解析后的报表时区
 }
 
 // ClickStatisticDetailResponse 点击统计详细响应
 type ClickStatisticDetailResponse struct {
-	ID          uint64    `json:"id"`
-	WorkspaceID uint64    `json:"workspace_id"`
-	CampaignID  *uint64   `json:"campaign_id"`
-	RouteID     *uint64   `json:"route_id"`
-	RouteName   string    `json:"route_name"`
-	ShortLinkID uint64    `json:"short_link_id"`
-	ShortCode   string    `json:"short_code,omitempty"`   // 短链代码
-	Domain      string    `json:"domain,omitempty"`       // 域名
-	OriginalURL string    `json:"original_url,omitempty"` // 原始URL
-	IP          string    `json:"ip"`
-	UserAgent   string    `json:"user_agent"`
-	Referer     string    `json:"referer"`
-	QueryParams string    `json:"query_params"`
-	UTMSource   string    `json:"utm_source"`
-	UTMMedium   string    `json:"utm_medium"`
-	UTMCampaign string    `json:"utm_campaign"`
-	UTMTerm     string    `json:"utm_term"`
-	UTMContent  string    `json:"utm_content"`
-	DeviceType  string    `json:"device_type"`
-	Browser     string    `json:"browser"`
-	OS          string    `json:"os"`
-	IsBot       bool      `json:"is_bot"`
-	BotName     string    `json:"bot_name"`
-	Country     string    `json:"country"`
-	Province    string    `json:"province"`
-	City        string    `json:"city"`
-	ISP         string    `json:"isp"`
-	ClickDate   time.Time `json:"click_date"`
-	CreatedAt   time.Time `json:"created_at"`
+	ID             uint64    `json:"id"`
+	WorkspaceID    uint64    `json:"workspace_id"`
+	CampaignID     *uint64   `json:"campaign_id"`
+	RouteID        *uint64   `json:"route_id"`
+	RouteName      string    `json:"route_name"`
+	AliasID        *uint64   `json:"alias_id"`   // 通过别名访问时的别名ID
+	AliasCode      string    `json:"alias_code"` // 通过别名访问时的别名短码
+	ShortLinkID    uint64    `json:"short_link_id"`
+	ShortCode      string    `json:"short_code,omitempty"`   // 短链代码
+	Domain         string    `json:"domain,omitempty"`       // 域名
+	OriginalURL    string    `json:"original_url,omitempty"` // 原始URL
+	IP             string    `json:"ip"`
+	UserAgent      string    `json:"user_agent"`
+	Referer        string    `json:"referer"`
+	RefererHost    string    `json:"referer_host"`
+	RefererChannel string    `json:"referer_channel"`
+	QueryParams    string    `json:"query_params"`
+	UTMSource      string    `json:"utm_source"`
+	UTMMedium      string    `json:"utm_medium"`
+	UTMCampaign    string    `json:"utm_campaign"`
+	UTMTerm        string    `json:"utm_term"`
+	UTMContent     string    `json:"utm_content"`
+	DeviceType     string    `json:"device_type"`
+	Browser        string    `json:"browser"`
+	OS             string    `json:"os"`
+	IsBot          bool      `json:"is_bot"`
+	BotName        string    `json:"bot_name"`
+	BotScore       int       `json:"bot_score"`
+	BotReason      string    `json:"bot_reason"`
+	IsDuplicate    bool      `json:"is_duplicate"`
+	Country        string    `json:"country"`
+	Province       string    `json:"province"`
+	City           string    `json:"city"`
+	ISP            string    `json:"isp"`
+	ClickDate      time.Time `json:"click_date"`
+	CreatedAt      time.Time `json:"created_at"`
 }
 
 // ClickStatisticListResponse 点击统计列表响应
@@ -64,13 +82,15 @@ type ClickStatisticListResponse struct {
 
 // ClickStatisticAnalysisResponse 点击统计分析响应
 type ClickStatisticAnalysisResponse struct {
-	TotalClicks     int64               `json:"total_clicks"`  // 总点击数
-	UniqueIPs       int64               `json:"unique_ips"`    // 独立IP数
-	TopCountries    []CountryStatistic  `json:"top_countries"` // 热门国家
-	TopProvinces    []ProvinceStatistic `json:"top_provinces"` // 热门省份
-	TopCities       []CityStatistic     `json:"top_cities"`    // 热门城市
-	TopISPs         []ISPStatistic      `json:"top_isps"`      // 热门运营商
-	TopReferers     []RefererStatistic  `json:"top_referers"`  // 热门来源
+	TotalClicks     int64               `json:"total_clicks"`      // 总点击数
+	UniqueIPs       int64               `json:"unique_ips"`        // 独立IP数
+	UniqueVisitors  int64               `json:"unique_visitors"`   // 独立访客数，不含机器人
+	TopCountries    []CountryStatistic  `json:"top_countries"`     // 热门国家
+	TopProvinces    []ProvinceStatistic `json:"top_provinces"`     // 热门省份
+	TopCities       []CityStatistic     `json:"top_cities"`        // 热门城市
+	TopISPs         []ISPStatistic      `json:"top_isps"`          // 热门运营商
+	TopReferers     []RefererStatistic  `json:"top_referers"`      // 热门来源
+	TopRefererHosts []RefererStatistic  `json:"top_referer_hosts"` // 热门来源域名
 	TopDevices      []DeviceStatistic   `json:"top_devices"`
 	TopBrowsers     []BrowserStatistic  `json:"top_browsers"`
 	TopOS           []OSStatistic       `json:"top_os"`
@@ -78,6 +98,7 @@ type ClickStatisticAnalysisResponse struct {
 	TopUTMSources   []UTMStatistic      `json:"top_utm_sources"`
 	TopUTMCampaigns []UTMStatistic      `json:"top_utm_campaigns"`
 	TopRoutes       []RouteStatistic    `json:"top_routes"`
+	TopAliases      []AliasStatistic    `json:"top_aliases"`
 	HourlyStats     []HourlyStatistic   `json:"hourly_stats"` // 小时统计
 	DailyStats      []DailyStatistic    `json:"daily_stats"`  // 日统计
 }
@@ -171,3 +192,29 @@ type RouteStatistic struct {
 	RouteName string `json:"route_name"`
 	Count     int64  `json:"count"`
 }
+
+type AliasStatistic struct {
+	AliasID   uint64 `json:"alias_id"`
+	AliasCode string `json:"alias_code"`
+	Count     int64  `json:"count"`
+}
+
+// ClickRollupRebuildRequest 重建点击汇总请求，日期为 YYYY-MM-DD，包含结束日期当天，均为空表示全部
+type ClickRollupRebuildRequest struct {
+	StartDate string `json:"start_date"`
+	EndDate   string `json:"end_date"`
+}
+
+// ClickRollupRebuildJobResponse 点击汇总重建任务
+type ClickRollupRebuildJobResponse struct {
+	ID         uint64     `json:"id"`
+	Status     string     `json:"status"`
+	StartDate  *time.Time `json:"start_date"`
+	EndDate    *time.Time `json:"end_date"`  // 不含当天
+	NextDate   *time.Time `json:"next_date"` // 下一个待重建的日期
+	Clicks     int64      `json:"clicks"`    // 已重新汇总的点击记录数
+	Error      string     `json:"error"`
+	StartedAt  *time.Time `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
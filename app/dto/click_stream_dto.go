@@ -0,0 +1,35 @@
+package dto
+
+import "time"
+
+// ClickStreamRequest 实时点击流订阅条件，均为空时订阅整个工作区
+type ClickStreamRequest struct {
+	ShortLinkID uint64 `form:"short_link_id"`
+	CampaignID  uint64 `form:"campaign_id"`
+	TagID       uint64 `form:"tag_id"`
+}
+
+// ClickStreamEvent 推送给实时点击流的点击事件，不包含 IP、UA、完整来源地址和点击ID
+type ClickStreamEvent struct {
+	ID             uint64    `json:"id"`
+	ShortLinkID    uint64    `json:"short_link_id"`
+	Domain         string    `json:"domain"`
+	ShortCode      string    `json:"short_code"` // 访问时使用的短码，通过别名访问时为别名
+	CampaignID     *uint64   `json:"campaign_id"`
+	RouteID        *uint64   `json:"route_id"`
+	RouteName      string    `json:"route_name"`
+	Country        string    `json:"country"`
+	Province       string    `json:"province"`
+	City           string    `json:"city"`
+	DeviceType     string    `json:"device_type"`
+	Browser        string    `json:"browser"`
+	OS             string    `json:"os"`
+	IsBot          bool      `json:"is_bot"`
+	BotScore       int       `json:"bot_score"`
+	IsDuplicate    bool      `json:"is_duplicate"`
+	RefererHost    string    `json:"referer_host"`
+	RefererChannel string    `json:"referer_channel"`
+	UTMSource      string    `json:"utm_source"`
+	UTMCampaign    string    `json:"utm_campaign"`
+	ClickedAt      time.Time `json:"clicked_at"`
+}
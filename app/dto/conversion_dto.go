@@ -0,0 +1,67 @@
+package dto
+
+import "time"
+
+// ConversionRequest 转化回传请求，服务端回传使用 JSON 请求体，像素使用查询参数
+type ConversionRequest struct {
+	ClickID    string         `json:"click_id" form:"click_id" binding:"required,max=64"`
+	Event      string         `json:"event" form:"event" binding:"max=64"`        // 事件名，默认 conversion
+	EventID    string         `json:"event_id" form:"event_id" binding:"max=128"` // 去重标识，默认 事件名:点击ID
+	Value      *float64       `json:"value" form:"value"`
+	Currency   string         `json:"currency" form:"currency" binding:"max=16"`
+	Metadata   map[string]any `json:"metadata" form:"-"`
+	OccurredAt *time.Time     `json:"occurred_at" form:"-"`
+}
+
+type ConversionResponse struct {
+	ID          uint64    `json:"id"`
+	ClickID     string    `json:"click_id"`
+	ShortLinkID uint64    `json:"short_link_id"`
+	CampaignID  *uint64   `json:"campaign_id"`
+	RouteID     *uint64   `json:"route_id"`
+	Event       string    `json:"event"`
+	EventID     string    `json:"event_id"`
+	Value       *float64  `json:"value"`
+	Currency    string    `json:"currency"`
+	Source      string    `json:"source"`
+	Duplicate   bool      `json:"duplicate"` // 相同 event_id 已记录过，本次未重复计入
+	OccurredAt  time.Time `json:"occurred_at"`
+}
+
+// ConversionPostbackSettingsResponse 转化回传设置
+type ConversionPostbackSettingsResponse struct {
+	Secret       string `json:"secret"`
+	ClickIDParam string `json:"click_id_param"` // 跳转时追加到目标地址的点击ID参数名
+	PostbackPath string `json:"postback_path"`
+	PixelPath    string `json:"pixel_path"`
+
+	PixelConversionValue bool `json:"pixel_conversion_value"` // 像素回传是否记录 value、currency，在工作区设置中修改
+}
+
+// ConversionReportRequest 转化报表请求，日期为 YYYY-MM-DD，包含结束日期当天
+type ConversionReportRequest struct {
+	GroupBy     string    `form:"group_by" binding:"omitempty,oneof=link campaign tag route"` // 默认 link
+	ShortLinkID uint64    `form:"short_link_id"`
+	CampaignID  uint64    `form:"campaign_id"`
+	RouteID     uint64    `form:"route_id"`
+	TagID       uint64    `form:"tag_id"`
+	StartDate   time.Time `form:"-"`
+	EndDate     time.Time `form:"-"`
+}
+
+type ConversionReportItem struct {
+	ID             uint64             `json:"id"` // 分组对象ID，0 表示未关联活动或路由
+	Name           string             `json:"name"`
+	Clicks         int64              `json:"clicks"`
+	Conversions    int64              `json:"conversions"`
+	ConversionRate float64            `json:"conversion_rate"` // 转化次数 / 点击次数
+	Revenue        map[string]float64 `json:"revenue"`         // 按币种汇总的转化金额，未填币种的记为空字符串
+}
+
+type ConversionReportResponse struct {
+	GroupBy          string                 `json:"group_by"`
+	TotalClicks      int64                  `json:"total_clicks"`
+	TotalConversions int64                  `json:"total_conversions"`
+	TotalRevenue     map[string]float64     `json:"total_revenue"`
+	Items            []ConversionReportItem `json:"items"`
+}
@@ -0,0 +1,39 @@
+package dto
+
+import "time"
+
+type CustomFieldRequest struct {
+	Key         string   `json:"key" binding:"required,max=50"` // 小写字母开头，仅含小写字母、数字和下划线；创建后不可修改
+	Name        string   `json:"name" binding:"required,max=100"`
+	Description string   `json:"description" binding:"max=500"`
+	FieldType   string   `json:"field_type" binding:"required,oneof=string number enum date"` // 创建后不可修改
+	Options     []string `json:"options" binding:"omitempty,max=100,dive,max=100"`            // enum 可选值
+	Required    bool     `json:"required"`
+	MaxLength   int      `json:"max_length" binding:"min=0,max=500"` // string 最大长度，0 表示不限制
+	MinValue    *float64 `json:"min_value"`
+	MaxValue    *float64 `json:"max_value"`
+	Position    int      `json:"position"`
+}
+
+type CustomFieldResponse struct {
+	ID          uint64    `json:"id"`
+	WorkspaceID uint64    `json:"workspace_id"`
+	Key         string    `json:"key"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	FieldType   string    `json:"field_type"`
+	Options     []string  `json:"options"`
+	Required    bool      `json:"required"`
+	MaxLength   int       `json:"max_length"`
+	MinValue    *float64  `json:"min_value"`
+	MaxValue    *float64  `json:"max_value"`
+	Position    int       `json:"position"`
+	CreatedBy   *uint64   `json:"created_by"`
+	UpdatedBy   *uint64   `json:"updated_by"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type CustomFieldListResponse struct {
+	List []CustomFieldResponse `json:"list"`
+}
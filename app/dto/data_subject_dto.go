@@ -0,0 +1,54 @@
+package dto
+
+import "time"
+
+// DataSubjectQuery 按访客IP或访客标识查找个人数据，至少提供一项，两项都提供时匹配任一项
+type DataSubjectQuery struct {
+	IP         string `json:"ip" binding:"omitempty,ip"`
+	VisitorKey string `json:"visitor_key" binding:"omitempty,max=64"`
+}
+
+type DataSubjectEraseRequest struct {
+	DataSubjectQuery
+	Action string `json:"action" binding:"required,oneof=delete anonymize"`
+}
+
+// DataSubjectSearchResponse NetworkCounts 为IP已在写入时截断为同一网段、无法确认归属的记录数，不计入 Total，也不会被导出或删除
+type DataSubjectSearchResponse struct {
+	Subject       string           `json:"subject"`
+	Counts        map[string]int64 `json:"counts"` // 键为数据表名
+	Total         int64            `json:"total"`
+	NetworkCounts map[string]int64 `json:"network_counts"`
+	NetworkTotal  int64            `json:"network_total"`
+}
+
+// DataSubjectExport 导出文件内容，每个数据表最多导出 Limit 条
+type DataSubjectExport struct {
+	IP          string                      `json:"ip,omitempty"`
+	VisitorKey  string                      `json:"visitor_key,omitempty"`
+	WorkspaceID uint64                      `json:"workspace_id"`
+	GeneratedAt time.Time                   `json:"generated_at"`
+	Limit       int                         `json:"limit"`
+	Truncated   map[string]bool             `json:"truncated"`
+	Records     map[string][]map[string]any `json:"records"`
+	// NetworkCounts 同一网段内已匿名化、无法确认归属的记录数，记录本身不导出
+	NetworkCounts map[string]int64 `json:"network_counts,omitempty"`
+}
+
+type DataSubjectRequestResponse struct {
+	ID          uint64           `json:"id"`
+	WorkspaceID uint64           `json:"workspace_id"`
+	Action      string           `json:"action"`
+	Subject     string           `json:"subject"`
+	Status      string           `json:"status"`
+	Counts      map[string]int64 `json:"counts"`
+	Error       string           `json:"error"`
+	RequestedBy *uint64          `json:"requested_by"`
+	StartedAt   *time.Time       `json:"started_at"`
+	FinishedAt  *time.Time       `json:"finished_at"`
+	CreatedAt   time.Time        `json:"created_at"`
+}
+
+type DataSubjectRequestListResponse struct {
+	List []DataSubjectRequestResponse `json:"list"`
+}
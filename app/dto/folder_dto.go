@@ -0,0 +1,78 @@
+package dto
+
+import "time"
+
+// FolderDefaults 文件夹默认设置，新建短网址时填充请求中未填写的字段
+type FolderDefaults struct {
+	Domain      string               `json:"domain" binding:"max=100"`
+	UTMSource   string               `json:"utm_source" binding:"max=255"`
+	UTMMedium   string               `json:"utm_medium" binding:"max=255"`
+	UTMCampaign string               `json:"utm_campaign" binding:"max=255"`
+	UTMTerm     string               `json:"utm_term" binding:"max=255"`
+	UTMContent  string               `json:"utm_content" binding:"max=255"`
+	Security    *LinkSecurityRequest `json:"security"`
+}
+
+type FolderRequest struct {
+	Name        string          `json:"name" binding:"required,max=100"`
+	Description string          `json:"description" binding:"max=500"`
+	ParentID    *uint64         `json:"parent_id"` // 为空表示根目录
+	Defaults    *FolderDefaults `json:"defaults"`
+}
+
+type FolderResponse struct {
+	ID             uint64         `json:"id"`
+	WorkspaceID    uint64         `json:"workspace_id"`
+	ParentID       *uint64        `json:"parent_id"`
+	Name           string         `json:"name"`
+	Description    string         `json:"description"`
+	Path           []string       `json:"path"` // 从根目录到当前文件夹的名称
+	Defaults       FolderDefaults `json:"defaults"`
+	ShortLinkCount int64          `json:"short_link_count"` // 直接位于该文件夹的短网址数
+	CreatedBy      *uint64        `json:"created_by"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+}
+
+type FolderListRequest struct {
+	ParentID *uint64 `form:"parent_id"` // 仅列出指定文件夹的直接子文件夹，为空时返回全部
+	Keyword  string  `form:"keyword"`
+}
+
+type FolderListResponse struct {
+	List []FolderResponse `json:"list"`
+}
+
+// FolderStatisticsRequest 文件夹聚合统计请求，统计包含所有子文件夹中的短网址
+type FolderStatisticsRequest struct {
+	Days      int       `form:"days"`
+	StartDate time.Time `form:"start_date" time_format:"2006-01-02"`
+	EndDate   time.Time `form:"end_date" time_format:"2006-01-02"`
+	IsBot     *bool     `form:"is_bot"`
+
+	Location *time.Location `form:"-"` // 由控制器解析后的报表时区
+}
+
+type FolderStatisticsResponse struct {
+	Folder         FolderResponse                  `json:"folder"`
+	FolderCount    int                             `json:"folder_count"`     // 含自身在内的文件夹数
+	ShortLinkCount int64                           `json:"short_link_count"` // 含子文件夹在内的短网址数
+	Analysis       *ClickStatisticAnalysisResponse `json:"analysis"`
+}
+
+// MoveShortLinkRequest 移动短网址到文件夹，folder_id 为空表示移回根目录
+type MoveShortLinkRequest struct {
+	FolderID *uint64 `json:"folder_id"`
+}
+
+// BatchMoveShortLinkRequest 批量移动短网址请求
+type BatchMoveShortLinkRequest struct {
+	IDs      []uint64 `json:"ids" binding:"required,min=1,max=100,dive,gt=0"`
+	FolderID *uint64  `json:"folder_id"`
+}
+
+// BatchMoveShortLinkResponse 批量移动短网址响应
+type BatchMoveShortLinkResponse struct {
+	Success []uint64                            `json:"success"`
+	Failed  []BatchShortLinkOperationFailedItem `json:"failed"`
+}
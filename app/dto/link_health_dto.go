@@ -0,0 +1,26 @@
+package dto
+
+import "time"
+
+// LinkHealthTargetResponse 目标地址最近一次检查结果
+type LinkHealthTargetResponse struct {
+	TargetType          string     `json:"target_type"` // original、fallback、route 或 ab_variant
+	TargetID            uint64     `json:"target_id"`   // 路由ID或A/B变体ID
+	TargetURL           string     `json:"target_url"`
+	Healthy             bool       `json:"healthy"`
+	StatusCode          int        `json:"status_code"`
+	Error               string     `json:"error"`
+	RedirectChain       []string   `json:"redirect_chain"`
+	LatencyMs           int64      `json:"latency_ms"`
+	TLSExpiresAt        *time.Time `json:"tls_expires_at"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	CheckedAt           time.Time  `json:"checked_at"`
+}
+
+// LinkHealthResponse 短网址目标地址健康状态
+type LinkHealthResponse struct {
+	ShortLinkID     uint64                     `json:"short_link_id"`
+	HealthStatus    string                     `json:"health_status"` // healthy 或 broken，空表示未检查
+	HealthCheckedAt *time.Time                 `json:"health_checked_at"`
+	Targets         []LinkHealthTargetResponse `json:"targets"`
+}
@@ -0,0 +1,16 @@
+package dto
+
+import "time"
+
+// ShortLinkMetadataResponse 从目标页面抓取的建议元数据
+type ShortLinkMetadataResponse struct {
+	ShortLinkID uint64    `json:"short_link_id"`
+	SourceURL   string    `json:"source_url"`
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	ImageURL    string    `json:"image_url"`
+	FaviconURL  string    `json:"favicon_url"`
+	Status      string    `json:"status"` // fetched 或 failed
+	Error       string    `json:"error"`
+	FetchedAt   time.Time `json:"fetched_at"`
+}
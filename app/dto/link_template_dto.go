@@ -0,0 +1,45 @@
+package dto
+
+import "time"
+
+// LinkTemplateSettings 模板预设，创建短网址时只填充请求中未填写的字段
+type LinkTemplateSettings struct {
+	Domain       string               `json:"domain" binding:"max=100"`
+	RedirectCode int                  `json:"redirect_code" binding:"omitempty,oneof=301 302 307 308"`
+	FallbackURL  string               `json:"fallback_url" binding:"omitempty,url"`
+	ExpireInDays int                  `json:"expire_in_days" binding:"min=0,max=3650"` // 创建后多少天过期，0 表示不过期
+	CampaignID   *uint64              `json:"campaign_id"`
+	FolderID     *uint64              `json:"folder_id"`
+	TagIDs       []uint64             `json:"tag_ids"`
+	UTMSource    string               `json:"utm_source" binding:"max=255"`
+	UTMMedium    string               `json:"utm_medium" binding:"max=255"`
+	UTMCampaign  string               `json:"utm_campaign" binding:"max=255"`
+	UTMTerm      string               `json:"utm_term" binding:"max=255"`
+	UTMContent   string               `json:"utm_content" binding:"max=255"`
+	Security     *LinkSecurityRequest `json:"security"`
+	Routes       []LinkRouteRequest   `json:"routes" binding:"omitempty,max=20,dive"`
+}
+
+type LinkTemplateRequest struct {
+	Name        string               `json:"name" binding:"required,max=100"`
+	Description string               `json:"description" binding:"max=500"`
+	IsDefault   bool                 `json:"is_default"` // 设为默认后工作区原有默认模板自动取消
+	Settings    LinkTemplateSettings `json:"settings"`
+}
+
+type LinkTemplateResponse struct {
+	ID          uint64               `json:"id"`
+	WorkspaceID uint64               `json:"workspace_id"`
+	Name        string               `json:"name"`
+	Description string               `json:"description"`
+	IsDefault   bool                 `json:"is_default"`
+	Settings    LinkTemplateSettings `json:"settings"`
+	CreatedBy   *uint64              `json:"created_by"`
+	UpdatedBy   *uint64              `json:"updated_by"`
+	CreatedAt   time.Time            `json:"created_at"`
+	UpdatedAt   time.Time            `json:"updated_at"`
+}
+
+type LinkTemplateListResponse struct {
+	List []LinkTemplateResponse `json:"list"`
+}
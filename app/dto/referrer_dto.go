@@ -0,0 +1,44 @@
+package dto
+
+import "time"
+
+type ReferrerRuleRequest struct {
+	MatchType string `json:"match_type" binding:"required,oneof=host query_param utm_source utm_medium"`
+	Pattern   string `json:"pattern" binding:"required,max=255"`
+	Channel   string `json:"channel" binding:"required,oneof=search social email messaging paid direct internal referral"`
+	Priority  int    `json:"priority" binding:"min=-1000,max=1000"`
+	Enabled   *bool  `json:"enabled"`
+}
+
+type ReferrerRuleResponse struct {
+	ID          uint64    `json:"id"`
+	WorkspaceID uint64    `json:"workspace_id"`
+	MatchType   string    `json:"match_type"`
+	Pattern     string    `json:"pattern"`
+	Channel     string    `json:"channel"`
+	Priority    int       `json:"priority"`
+	Enabled     bool      `json:"enabled"`
+	Builtin     bool      `json:"builtin"` // 内置规则，只读
+	CreatedBy   *uint64   `json:"created_by"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// ReferrerRuleListResponse 工作区规则按匹配顺序排列，内置规则在工作区规则之后匹配
+type ReferrerRuleListResponse struct {
+	List    []ReferrerRuleResponse `json:"list"`
+	Builtin []ReferrerRuleResponse `json:"builtin"`
+}
+
+// ReferrerChannelBreakdownResponse 来源渠道分析
+type ReferrerChannelBreakdownResponse struct {
+	TotalClicks int64                      `json:"total_clicks"`
+	Channels    []ReferrerChannelStatistic `json:"channels"`
+}
+
+type ReferrerChannelStatistic struct {
+	Channel    string             `json:"channel"`
+	Clicks     int64              `json:"clicks"`
+	Percentage float64            `json:"percentage"` // 占总点击的百分比，保留两位小数
+	TopHosts   []RefererStatistic `json:"top_hosts"`  // 该渠道点击数最多的来源域名
+}
@@ -4,43 +4,53 @@ import "time"
 
 // CreateShortLinkRequest 创建短网址请求
 type CreateShortLinkRequest struct {
-	OriginalURL  string               `json:"original_url" binding:"required,url" example:"https://www.example.com"`
-	Domain       string               `json:"domain" example:"dwz.do"`
-	CustomCode   string               `json:"custom_code" example:"abc123"`
-	Title        string               `json:"title" example:"示例网站"`
-	Description  string               `json:"description" example:"这是一个示例网站"`
-	FallbackURL  string               `json:"fallback_url" binding:"omitempty,url"`
-	RedirectCode int                  `json:"redirect_code" binding:"omitempty,oneof=301 302 307 308"`
-	ExpireAt     *time.Time           `json:"expire_at" example:"2024-12-31T23:59:59Z"`
-	CampaignID   *uint64              `json:"campaign_id"`
-	TagIDs       []uint64             `json:"tag_ids"`
-	UTMSource    string               `json:"utm_source"`
-	UTMMedium    string               `json:"utm_medium"`
-	UTMCampaign  string               `json:"utm_campaign"`
-	UTMTerm      string               `json:"utm_term"`
-	UTMContent   string               `json:"utm_content"`
-	Notes        string               `json:"notes"`
-	Security     *LinkSecurityRequest `json:"security"`
+	OriginalURL   string               `json:"original_url" binding:"required,url" example:"https://www.example.com"`
+	Domain        string               `json:"domain" example:"dwz.do"`
+	CustomCode    string               `json:"custom_code" example:"abc123"`
+	Title         string               `json:"title" example:"示例网站"`
+	Description   string               `json:"description" example:"这是一个示例网站"`
+	FallbackURL   string               `json:"fallback_url" binding:"omitempty,url"`
+	RedirectCode  int                  `json:"redirect_code" binding:"omitempty,oneof=301 302 307 308"`
+	ExpireAt      *time.Time           `json:"expire_at" example:"2024-12-31T23:59:59Z"`
+	CampaignID    *uint64              `json:"campaign_id"`
+	FolderID      *uint64              `json:"folder_id"` // 所属文件夹，未填写的域名、UTM 和安全设置使用文件夹默认值
+	TagIDs        []uint64             `json:"tag_ids"`
+	UTMSource     string               `json:"utm_source"`
+	UTMMedium     string               `json:"utm_medium"`
+	UTMCampaign   string               `json:"utm_campaign"`
+	UTMTerm       string               `json:"utm_term"`
+	UTMContent    string               `json:"utm_content"`
+	Notes         string               `json:"notes"`
+	ExternalID    string               `json:"external_id" binding:"omitempty,max=128"`
+	Reuse         *bool                `json:"reuse"` // 是否复用相同目标的已有短网址，为空时使用工作区策略
+	Security      *LinkSecurityRequest `json:"security"`
+	Routes        []LinkRouteRequest   `json:"routes" binding:"omitempty,max=20,dive"` // 创建后一并添加的高级路由
+	TemplateID    *uint64              `json:"template_id"`                            // 链接模板，为空时使用工作区默认模板，0 表示不使用模板
+	CustomFields  map[string]any       `json:"custom_fields"`                          // 自定义字段取值，键为字段标识
+	AppendClickID bool                 `json:"append_click_id"`                        // 跳转时在目标地址追加点击ID，用于转化回传
 }
 
 // UpdateShortLinkRequest 更新短网址请求
 type UpdateShortLinkRequest struct {
-	OriginalURL  string               `json:"original_url" binding:"omitempty,url"`
-	Title        string               `json:"title"`
-	Description  string               `json:"description"`
-	FallbackURL  *string              `json:"fallback_url" binding:"omitempty"`
-	RedirectCode *int                 `json:"redirect_code" binding:"omitempty,oneof=301 302 307 308"`
-	ExpireAt     *time.Time           `json:"expire_at"`
-	IsActive     *bool                `json:"is_active"`
-	CampaignID   *uint64              `json:"campaign_id"`
-	TagIDs       []uint64             `json:"tag_ids"`
-	UTMSource    string               `json:"utm_source"`
-	UTMMedium    string               `json:"utm_medium"`
-	UTMCampaign  string               `json:"utm_campaign"`
-	UTMTerm      string               `json:"utm_term"`
-	UTMContent   string               `json:"utm_content"`
-	Notes        string               `json:"notes"`
-	Security     *LinkSecurityRequest `json:"security"`
+	OriginalURL   string               `json:"original_url" binding:"omitempty,url"`
+	Title         string               `json:"title"`
+	Description   string               `json:"description"`
+	FallbackURL   *string              `json:"fallback_url" binding:"omitempty"`
+	RedirectCode  *int                 `json:"redirect_code" binding:"omitempty,oneof=301 302 307 308"`
+	ExpireAt      *time.Time           `json:"expire_at"`
+	IsActive      *bool                `json:"is_active"`
+	CampaignID    *uint64              `json:"campaign_id"`
+	TagIDs        []uint64             `json:"tag_ids"`
+	UTMSource     string               `json:"utm_source"`
+	UTMMedium     string               `json:"utm_medium"`
+	UTMCampaign   string               `json:"utm_campaign"`
+	UTMTerm       string               `json:"utm_term"`
+	UTMContent    string               `json:"utm_content"`
+	Notes         string               `json:"notes"`
+	ExternalID    *string              `json:"external_id" binding:"omitempty,max=128"`
+	Security      *LinkSecurityRequest `json:"security"`
+	CustomFields  map[string]any       `json:"custom_fields"` // 只更新传入的字段，取值为 null 或空字符串时清除
+	AppendClickID *bool                `json:"append_click_id"`
 }
 
 // UpdateShortLinkStatusRequest 更新短网址状态请求
@@ -77,39 +87,63 @@ type BatchDeleteShortLinkResponse struct {
 	Failed  []BatchShortLinkOperationFailedItem `json:"failed"`
 }
 
+// CloneShortLinkRequest 克隆短网址请求
+// Include 可选 tags、campaign、utm、routes、security、ab_test，为空时全部复制；
+// 跨工作区克隆时标签和活动不会被复制。
+type CloneShortLinkRequest struct {
+	Domain            string   `json:"domain" example:"dwz.do"`
+	CustomCode        string   `json:"custom_code" example:"abc123"`
+	Title             string   `json:"title" example:"示例网站"`
+	TargetWorkspaceID uint64   `json:"target_workspace_id" example:"2"`
+	Include           []string `json:"include" binding:"omitempty,dive,oneof=tags campaign utm routes security ab_test" example:"routes,security"`
+}
+
 // ShortLinkResponse 短网址响应
 type ShortLinkResponse struct {
-	ID              uint64        `json:"id"`
-	WorkspaceID     uint64        `json:"workspace_id"`
-	CampaignID      *uint64       `json:"campaign_id"`
-	CampaignName    string        `json:"campaign_name,omitempty"`
-	Tags            []TagResponse `json:"tags,omitempty"`
-	ShortCode       string        `json:"short_code"`
-	Domain          string        `json:"domain"`
-	ShortURL        string        `json:"short_url"`
-	OriginalURL     string        `json:"original_url"`
-	FallbackURL     string        `json:"fallback_url"`
-	RedirectCode    int           `json:"redirect_code"`
-	Title           string        `json:"title"`
-	Description     string        `json:"description"`
-	UTMSource       string        `json:"utm_source"`
-	UTMMedium       string        `json:"utm_medium"`
-	UTMCampaign     string        `json:"utm_campaign"`
-	UTMTerm         string        `json:"utm_term"`
-	UTMContent      string        `json:"utm_content"`
-	Notes           string        `json:"notes"`
-	ExpireAt        *time.Time    `json:"expire_at"`
-	IsActive        bool          `json:"is_active"`
-	ClickCount      int64         `json:"click_count"`
-	CreatedBy       *uint64       `json:"created_by"`
-	UpdatedBy       *uint64       `json:"updated_by"`
-	SecurityEnabled bool          `json:"security_enabled"`
-	SecuritySummary string        `json:"security_summary"`
-	ReportEnabled   bool          `json:"report_enabled"`
-	RoutingEnabled  bool          `json:"routing_enabled"`
-	RoutingSummary  string        `json:"routing_summary"`
-	CreatedAt       time.Time     `json:"created_at"`
-	UpdatedAt       time.Time     `json:"updated_at"`
+	ID              uint64            `json:"id"`
+	WorkspaceID     uint64            `json:"workspace_id"`
+	CampaignID      *uint64           `json:"campaign_id"`
+	CampaignName    string            `json:"campaign_name,omitempty"`
+	FolderID        *uint64           `json:"folder_id"`
+	Tags            []TagResponse     `json:"tags,omitempty"`
+	ShortCode       string            `json:"short_code"`
+	Domain          string            `json:"domain"`
+	ShortURL        string            `json:"short_url"`
+	OriginalURL     string            `json:"original_url"`
+	FallbackURL     string            `json:"fallback_url"`
+	RedirectCode    int               `json:"redirect_code"`
+	Title           string            `json:"title"`
+	Description     string            `json:"description"`
+	UTMSource       string            `json:"utm_source"`
+	UTMMedium       string            `json:"utm_medium"`
+	UTMCampaign     string            `json:"utm_campaign"`
+	UTMTerm         string            `json:"utm_term"`
+	UTMContent      string            `json:"utm_content"`
+	Notes           string            `json:"notes"`
+	ExternalID      *string           `json:"external_id"`
+	Reused          bool              `json:"reused,omitempty"`          // 是否复用了已有短网址
+	IgnoredOptions  []string          `json:"ignored_options,omitempty"` // 复用已有短网址时未生效的请求选项
+	ExpireAt        *time.Time        `json:"expire_at"`
+	IsActive        bool              `json:"is_active"`
+	ClickCount      int64             `json:"click_count"`           // 点击次数，不含重复点击
+	DuplicateClicks int64             `json:"duplicate_click_count"` // 去重窗口内的重复点击次数
+	CreatedBy       *uint64           `json:"created_by"`
+	UpdatedBy       *uint64           `json:"updated_by"`
+	SecurityEnabled bool              `json:"security_enabled"`
+	SecuritySummary string            `json:"security_summary"`
+	ReportEnabled   bool              `json:"report_enabled"`
+	RoutingEnabled  bool              `json:"routing_enabled"`
+	RoutingSummary  string            `json:"routing_summary"`
+	HealthStatus    string            `json:"health_status"` // healthy 或 broken，空表示未检查
+	HealthCheckedAt *time.Time        `json:"health_checked_at"`
+	ReviewStatus    string            `json:"review_status"` // pending_review、approved、rejected，空表示无需审核
+	ReviewComment   string            `json:"review_comment"`
+	ReviewedBy      *uint64           `json:"reviewed_by"`
+	ReviewedAt      *time.Time        `json:"reviewed_at"`
+	CustomFields    map[string]string `json:"custom_fields"` // 自定义字段取值，键为字段标识
+	AppendClickID   bool              `json:"append_click_id"`
+	CreatedAt       time.Time         `json:"created_at"`
+	UpdatedAt       time.Time         `json:"updated_at"`
 }
 
 // ShortLinkListRequest 短网址列表请求
@@ -123,20 +157,101 @@ type ShortLinkListRequest struct {
 	CreatedBy      uint64 `form:"created_by"`
 	SecurityStatus string `form:"security_status" binding:"omitempty,oneof=none enabled password restricted url_blocked reported"`
 	RoutingStatus  string `form:"routing_status" binding:"omitempty,oneof=none enabled fallback disabled"`
+	HealthStatus   string `form:"health_status" binding:"omitempty,oneof=healthy broken unchecked"`
+	ReviewStatus   string `form:"review_status" binding:"omitempty,oneof=pending_review approved rejected"`
+	Q              string `form:"q" example:"tag:promo AND clicks>100"` // 高级搜索语句
+	Sort           string `form:"sort" example:"-clicks"`               // 排序字段，前缀 - 表示倒序
+	Cursor         string `form:"cursor"`                               // 游标分页，传入上一页返回的 next_cursor
+
+	Location *time.Location `form:"-" json:"-"` // 高级搜索中仅日期取值按该时区划分整天，为空时由服务层取工作区时区
+
+	// 文件夹筛选，folder_id=0 表示根目录；subfolders=true 时包含子文件夹
+	FolderID   *uint64  `form:"folder_id"`
+	Subfolders bool     `form:"subfolders"`
+	FolderIDs  []uint64 `form:"-"` // 由服务层展开后的文件夹ID
 }
 
 // ShortLinkListResponse 短网址列表响应
 type ShortLinkListResponse struct {
-	List  []ShortLinkResponse `json:"list"`
-	Total int64               `json:"total"`
-	Page  int                 `json:"page"`
-	Size  int                 `json:"size"`
+	List       []ShortLinkResponse `json:"list"`
+	Total      int64               `json:"total"`
+	Page       int                 `json:"page"`
+	Size       int                 `json:"size"`
+	NextCursor string              `json:"next_cursor,omitempty"`
+}
+
+// RekeyShortLinkRequest 重新分配域名或短码请求，旧的域名+短码保留为别名
+type RekeyShortLinkRequest struct {
+	Domain    string `json:"domain" example:"dwz.do"`                                  // 为空时保持当前域名
+	ShortCode string `json:"short_code" binding:"omitempty,max=20" example:"new-code"` // 为空时：更换域名则沿用当前短码，否则由发号器生成
+}
+
+// ReviewShortLinkRequest 审核短网址请求
+type ReviewShortLinkRequest struct {
+	Comment string `json:"comment" binding:"max=500" example:"落地页已确认"`
+}
+
+// CreateShortLinkAliasRequest 添加短网址别名请求
+type CreateShortLinkAliasRequest struct {
+	Domain    string `json:"domain" example:"dwz.do"` // 为空时使用短网址当前域名
+	ShortCode string `json:"short_code" binding:"required,max=20" example:"spring26"`
+}
+
+// ShortLinkAliasResponse 短网址别名响应
+type ShortLinkAliasResponse struct {
+	ID          uint64    `json:"id"`
+	ShortLinkID uint64    `json:"short_link_id"`
+	Domain      string    `json:"domain"`
+	ShortCode   string    `json:"short_code"`
+	ShortURL    string    `json:"short_url"`
+	Source      string    `json:"source"`
+	CreatedBy   *uint64   `json:"created_by"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// ShortLinkAliasListResponse 短网址别名列表响应
+type ShortLinkAliasListResponse struct {
+	List []ShortLinkAliasResponse `json:"list"`
+}
+
+// ShortLinkTrashListRequest 回收站列表请求
+type ShortLinkTrashListRequest struct {
+	Page     int    `form:"page" binding:"min=1" example:"1"`
+	PageSize int    `form:"page_size" binding:"min=1,max=100" example:"10"`
+	Domain   string `form:"domain" example:"dwz.do"`
+	Keyword  string `form:"keyword" example:"example"`
+}
+
+// ShortLinkTrashResponse 回收站中的短网址
+type ShortLinkTrashResponse struct {
+	ID          uint64     `json:"id"`
+	WorkspaceID uint64     `json:"workspace_id"`
+	ShortCode   string     `json:"short_code"`
+	Domain      string     `json:"domain"`
+	ShortURL    string     `json:"short_url"`
+	OriginalURL string     `json:"original_url"`
+	Title       string     `json:"title"`
+	ClickCount  int64      `json:"click_count"`
+	CreatedBy   *uint64    `json:"created_by,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	DeletedAt   time.Time  `json:"deleted_at"`
+	PurgeAt     *time.Time `json:"purge_at,omitempty"` // 自动清理时间，未开启自动清理时为空
+}
+
+// ShortLinkTrashListResponse 回收站列表响应
+type ShortLinkTrashListResponse struct {
+	List          []ShortLinkTrashResponse `json:"list"`
+	Total         int64                    `json:"total"`
+	Page          int                      `json:"page"`
+	Size          int                      `json:"size"`
+	RetentionDays int                      `json:"retention_days"`
 }
 
 // ClickStatisticResponse 点击统计响应
 type ClickStatisticResponse struct {
-	Date       string `json:"date"`
-	ClickCount int64  `json:"click_count"`
+	Date           string `json:"date"`
+	ClickCount     int64  `json:"click_count"`
+	UniqueVisitors int64  `json:"unique_visitors"`
 }
 
 // ShortLinkStatisticResponse 短网址统计响应
@@ -145,13 +260,20 @@ type ShortLinkStatisticResponse struct {
 	TodayClicks     int64                    `json:"today_clicks"`
 	WeekClicks      int64                    `json:"week_clicks"`
 	MonthClicks     int64                    `json:"month_clicks"`
+	TodayVisitors   int64                    `json:"today_visitors"` // 独立访客数，不含机器人，跨天的同一访客分别计数
+	WeekVisitors    int64                    `json:"week_visitors"`
+	MonthVisitors   int64                    `json:"month_visitors"`
+	DuplicateClicks int64                    `json:"duplicate_clicks"` // 去重窗口内的重复点击总数
 	DailyStatistics []ClickStatisticResponse `json:"daily_statistics"`
 }
 
 // BatchCreateShortLinkRequest 批量创建短网址请求
 type BatchCreateShortLinkRequest struct {
-	URLs   []string `json:"urls" binding:"required,min=1,max=100"`
-	Domain string   `json:"domain"`
+	URLs          []string       `json:"urls" binding:"required,min=1,max=100"`
+	Domain        string         `json:"domain"`
+	TemplateID    *uint64        `json:"template_id"`   // 链接模板，为空时使用工作区默认模板，0 表示不使用模板
+	CustomFields  map[string]any `json:"custom_fields"` // 每条短网址使用相同的自定义字段取值
+	AppendClickID bool           `json:"append_click_id"`
 }
 
 // BatchCreateShortLinkResponse 批量创建短网址响应
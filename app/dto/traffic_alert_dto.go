@@ -0,0 +1,103 @@
+package dto
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// TrafficAlertSettingRequest 流量异常检测阈值，未传的字段保持原值
+type TrafficAlertSettingRequest struct {
+	Enabled               *bool    `json:"enabled"`
+	WindowMinutes         *int     `json:"window_minutes" binding:"omitempty,min=15,max=1440"`
+	BaselineDays          *int     `json:"baseline_days" binding:"omitempty,min=1,max=30"`
+	SpikeFactor           *float64 `json:"spike_factor" binding:"omitempty,gt=1,max=100"`
+	SpikeMinClicks        *int64   `json:"spike_min_clicks" binding:"omitempty,min=1"`
+	DropMinHourlyClicks   *float64 `json:"drop_min_hourly_clicks" binding:"omitempty,gt=0"`
+	BotRatioDelta         *float64 `json:"bot_ratio_delta" binding:"omitempty,gt=0,max=1"`
+	CountryShiftThreshold *float64 `json:"country_shift_threshold" binding:"omitempty,gt=0,max=1"`
+	MixMinClicks          *int64   `json:"mix_min_clicks" binding:"omitempty,min=1"`
+	CooldownMinutes       *int     `json:"cooldown_minutes" binding:"omitempty,min=5,max=10080"`
+}
+
+type TrafficAlertSettingResponse struct {
+	WorkspaceID           uint64  `json:"workspace_id"`
+	Enabled               bool    `json:"enabled"`
+	WindowMinutes         int     `json:"window_minutes"`
+	BaselineDays          int     `json:"baseline_days"`
+	SpikeFactor           float64 `json:"spike_factor"`
+	SpikeMinClicks        int64   `json:"spike_min_clicks"`
+	DropMinHourlyClicks   float64 `json:"drop_min_hourly_clicks"`
+	BotRatioDelta         float64 `json:"bot_ratio_delta"`
+	CountryShiftThreshold float64 `json:"country_shift_threshold"`
+	MixMinClicks          int64   `json:"mix_min_clicks"`
+	CooldownMinutes       int     `json:"cooldown_minutes"`
+}
+
+type AlertChannelRequest struct {
+	Name       string   `json:"name" binding:"required,max=100"`
+	Type       string   `json:"type" binding:"required,oneof=webhook slack dingtalk wecom feishu"`
+	URL        string   `json:"url" binding:"required,url,max=1000"`
+	Secret     *string  `json:"secret" binding:"omitempty,max=255"` // 不传时保持原值
+	AlertTypes []string `json:"alert_types" binding:"omitempty,dive,oneof=spike drop bot_ratio country_shift"`
+	Enabled    *bool    `json:"enabled"`
+}
+
+type AlertChannelResponse struct {
+	ID          uint64    `json:"id"`
+	WorkspaceID uint64    `json:"workspace_id"`
+	Name        string    `json:"name"`
+	Type        string    `json:"type"`
+	URL         string    `json:"url"`
+	HasSecret   bool      `json:"has_secret"`
+	AlertTypes  []string  `json:"alert_types"`
+	Enabled     bool      `json:"enabled"`
+	CreatedBy   *uint64   `json:"created_by"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type AlertChannelListResponse struct {
+	List []AlertChannelResponse `json:"list"`
+}
+
+type TrafficAlertListRequest struct {
+	Page         int        `form:"page" binding:"min=1"`
+	PageSize     int        `form:"page_size" binding:"min=1,max=100"`
+	ShortLinkID  *uint64    `form:"short_link_id"` // 0 表示工作区级告警
+	AlertType    string     `form:"alert_type"`
+	Acknowledged *bool      `form:"acknowledged"`
+	StartDate    *time.Time `form:"start_date" time_format:"2006-01-02"`
+	EndDate      *time.Time `form:"end_date" time_format:"2006-01-02"`
+}
+
+type TrafficAlertDeliveryResponse struct {
+	ChannelID   uint64     `json:"channel_id"`
+	Status      string     `json:"status"`
+	Attempts    int        `json:"attempts"`
+	LastError   string     `json:"last_error"`
+	DeliveredAt *time.Time `json:"delivered_at"`
+}
+
+type TrafficAlertResponse struct {
+	ID             uint64                         `json:"id"`
+	WorkspaceID    uint64                         `json:"workspace_id"`
+	ShortLinkID    uint64                         `json:"short_link_id"`
+	AlertType      string                         `json:"alert_type"`
+	Message        string                         `json:"message"`
+	Observed       float64                        `json:"observed"`
+	Baseline       float64                        `json:"baseline"`
+	Details        json.RawMessage                `json:"details"`
+	WindowStart    time.Time                      `json:"window_start"`
+	WindowEnd      time.Time                      `json:"window_end"`
+	AcknowledgedBy *uint64                        `json:"acknowledged_by"`
+	AcknowledgedAt *time.Time                     `json:"acknowledged_at"`
+	Deliveries     []TrafficAlertDeliveryResponse `json:"deliveries"`
+	CreatedAt      time.Time                      `json:"created_at"`
+}
+
+type TrafficAlertListResponse struct {
+	List  []TrafficAlertResponse `json:"list"`
+	Total int64                  `json:"total"`
+	Page  int                    `json:"page"`
+	Size  int                    `json:"size"`
+}
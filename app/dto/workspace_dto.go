@@ -12,6 +12,20 @@ type WorkspaceResponse struct {
 	Role        string    `json:"role,omitempty"`
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
+
+	ReuseExistingLinks  bool `json:"reuse_existing_links"`
+	RequireLinkApproval bool `json:"require_link_approval"`
+
+	AnonymizeIP                bool `json:"anonymize_ip"`
+	ClickRetentionDays         int  `json:"click_retention_days"`
+	ABTestClickRetentionDays   int  `json:"ab_test_click_retention_days"`
+	SecurityEventRetentionDays int  `json:"security_event_retention_days"`
+	OperationLogRetentionDays  int  `json:"operation_log_retention_days"`
+
+	ClickDedupSeconds int    `json:"click_dedup_seconds"`
+	Timezone          string `json:"timezone"`
+
+	PixelConversionValue bool `json:"pixel_conversion_value"`
 }
 
 type CreateWorkspaceRequest struct {
@@ -23,6 +37,20 @@ type CreateWorkspaceRequest struct {
 type UpdateWorkspaceRequest struct {
 	Name        string `json:"name" binding:"required,max=100"`
 	Description string `json:"description" binding:"max=500"`
+
+	ReuseExistingLinks  *bool `json:"reuse_existing_links"`
+	RequireLinkApproval *bool `json:"require_link_approval"` // 开启后 member 角色创建或修改的短网址需审核
+
+	AnonymizeIP                *bool `json:"anonymize_ip"`
+	ClickRetentionDays         *int  `json:"click_retention_days" binding:"omitempty,min=0,max=3650"` // 0 表示使用服务端默认值
+	ABTestClickRetentionDays   *int  `json:"ab_test_click_retention_days" binding:"omitempty,min=0,max=3650"`
+	SecurityEventRetentionDays *int  `json:"security_event_retention_days" binding:"omitempty,min=0,max=3650"`
+	OperationLogRetentionDays  *int  `json:"operation_log_retention_days" binding:"omitempty,min=0,max=3650"`
+
+	ClickDedupSeconds *int    `json:"click_dedup_seconds" binding:"omitempty,min=0,max=86400"` // 重复点击去重窗口（秒），0 表示使用服务端默认值
+	Timezone          *string `json:"timezone" binding:"omitempty,max=64"`                     // IANA 时区名称，空字符串表示使用服务端默认值
+
+	PixelConversionValue *bool `json:"pixel_conversion_value"` // 像素转化回传是否记录 value、currency
 }
 
 type WorkspaceListResponse struct {
@@ -32,7 +32,7 @@ var DefaultOperationLogConfig = OperationLogConfig{
 	Enable:          true,
 	MaxRequestSize:  1024 * 1024,
 	SkipPaths:       []string{},
-	SensitiveFields: []string{"password", "token", "secret", "key", "passwd"},
+	SensitiveFields: []string{"password", "token", "secret", "key", "passwd", "ip", "visitor_key"},
 	LogRequestBody:  true,
 	AsyncLogging:    true,
 	LogHealthCheck:  false,
@@ -236,17 +236,70 @@ var defaultOperationMappings = []OperationMapping{
 	{"POST", "/api/v1/short_links/batch", "批量创建", "短网址"},
 	{"POST", "/api/v1/short_links/batch/status", "批量更新状态", "短网址"},
 	{"POST", "/api/v1/short_links/batch/delete", "批量删除", "短网址"},
+	{"POST", "/api/v1/short_links/batch/move", "批量移动", "短网址"},
+	{"GET", "/api/v1/short_links/reviews", "查看待审核", "短网址"},
+	{"GET", "/api/v1/short_links/trash", "查看回收站", "短网址"},
+	{"POST", "/api/v1/short_links/trash/[^/]+/restore", "恢复", "短网址"},
+	{"DELETE", "/api/v1/short_links/trash/[^/]+", "彻底删除", "短网址"},
+	{"GET", "/api/v1/short_links/external/[^/]+", "按外部标识查看", "短网址"},
+	{"PUT", "/api/v1/short_links/external/[^/]+", "按外部标识创建或更新", "短网址"},
 	{"GET", "/api/v1/short_links", "查看列表", "短网址"},
 	{"GET", "/api/v1/short_links/[^/]+", "查看详情", "短网址"},
 	{"PUT", "/api/v1/short_links/[^/]+", "更新", "短网址"},
 	{"DELETE", "/api/v1/short_links/[^/]+", "删除", "短网址"},
 	{"GET", "/api/v1/short_links/[^/]+/statistics", "查看统计", "短网址"},
+	{"POST", "/api/v1/short_links/[^/]+/clone", "克隆", "短网址"},
+	{"POST", "/api/v1/short_links/[^/]+/move", "移动", "短网址"},
+	{"POST", "/api/v1/short_links/[^/]+/rekey", "更换短码", "短网址"},
+	{"POST", "/api/v1/short_links/[^/]+/review/approve", "审核通过", "短网址"},
+	{"POST", "/api/v1/short_links/[^/]+/review/reject", "审核驳回", "短网址"},
+	{"GET", "/api/v1/short_links/[^/]+/aliases", "查看别名", "短网址"},
+	{"POST", "/api/v1/short_links/[^/]+/aliases", "添加别名", "短网址"},
+	{"GET", "/api/v1/short_links/[^/]+/health", "查看健康状态", "短网址"},
+	{"POST", "/api/v1/short_links/[^/]+/health/check", "检查目标地址", "短网址"},
+	{"GET", "/api/v1/short_links/[^/]+/metadata", "查看元数据", "短网址"},
+	{"POST", "/api/v1/short_links/[^/]+/metadata/fetch", "抓取元数据", "短网址"},
+	{"DELETE", "/api/v1/short_links/[^/]+/aliases/[^/]+", "删除别名", "短网址"},
 	{"GET", "/api/v1/short_links/[^/]+/routes", "查看", "高级路由"},
 	{"POST", "/api/v1/short_links/[^/]+/routes", "创建", "高级路由"},
 	{"PUT", "/api/v1/short_links/[^/]+/routes/[^/]+", "更新", "高级路由"},
 	{"DELETE", "/api/v1/short_links/[^/]+/routes/[^/]+", "删除", "高级路由"},
 	{"POST", "/api/v1/short_links/[^/]+/routes/reorder", "排序", "高级路由"},
 	{"POST", "/api/v1/short_links/[^/]+/routes/test", "测试", "高级路由"},
+	{"POST", "/api/v1/folders", "创建", "文件夹"},
+	{"GET", "/api/v1/folders", "查看列表", "文件夹"},
+	{"GET", "/api/v1/folders/[^/]+", "查看详情", "文件夹"},
+	{"PUT", "/api/v1/folders/[^/]+", "更新", "文件夹"},
+	{"DELETE", "/api/v1/folders/[^/]+", "删除", "文件夹"},
+	{"GET", "/api/v1/folders/[^/]+/statistics", "查看统计", "文件夹"},
+	{"POST", "/api/v1/link_templates", "创建", "链接模板"},
+	{"GET", "/api/v1/link_templates", "查看列表", "链接模板"},
+	{"GET", "/api/v1/link_templates/[^/]+", "查看详情", "链接模板"},
+	{"PUT", "/api/v1/link_templates/[^/]+", "更新", "链接模板"},
+	{"DELETE", "/api/v1/link_templates/[^/]+", "删除", "链接模板"},
+	{"POST", "/api/v1/custom_fields", "创建", "自定义字段"},
+	{"GET", "/api/v1/custom_fields", "查看列表", "自定义字段"},
+	{"GET", "/api/v1/custom_fields/[^/]+", "查看详情", "自定义字段"},
+	{"PUT", "/api/v1/custom_fields/[^/]+", "更新", "自定义字段"},
+	{"DELETE", "/api/v1/custom_fields/[^/]+", "删除", "自定义字段"},
+	{"GET", "/api/v1/conversions/report", "查看报表", "转化追踪"},
+	{"GET", "/api/v1/conversions/postback", "查看回传设置", "转化追踪"},
+	{"POST", "/api/v1/conversions/postback/rotate", "重置回传密钥", "转化追踪"},
+	{"POST", "/api/v1/click_statistics/rollups/rebuild", "重建汇总", "点击统计"},
+	{"POST", "/api/v1/click_statistics/exports", "创建导出任务", "点击统计"},
+	{"POST", "/api/v1/click_statistics/exports/[^/]+/cancel", "取消导出任务", "点击统计"},
+	{"POST", "/api/v1/referrer_rules", "创建", "来源渠道规则"},
+	{"PUT", "/api/v1/referrer_rules/[^/]+", "更新", "来源渠道规则"},
+	{"DELETE", "/api/v1/referrer_rules/[^/]+", "删除", "来源渠道规则"},
+	{"POST", "/api/v1/traffic_alerts/[^/]+/acknowledge", "标记已处理", "流量告警"},
+	{"PUT", "/api/v1/traffic_alerts/settings", "更新设置", "流量告警"},
+	{"POST", "/api/v1/traffic_alerts/channels", "创建渠道", "流量告警"},
+	{"PUT", "/api/v1/traffic_alerts/channels/[^/]+", "更新渠道", "流量告警"},
+	{"DELETE", "/api/v1/traffic_alerts/channels/[^/]+", "删除渠道", "流量告警"},
+	{"POST", "/api/v1/traffic_alerts/channels/[^/]+/test", "测试渠道", "流量告警"},
+	{"POST", "/api/v1/data_subject_requests/search", "查询个人数据", "数据主体请求"},
+	{"POST", "/api/v1/data_subject_requests/export", "导出个人数据", "数据主体请求"},
+	{"POST", "/api/v1/data_subject_requests", "提交个人数据删除", "数据主体请求"},
 	{"POST", "/api/v1/domains", "创建", "域名"},
 	{"GET", "/api/v1/domains", "查看列表", "域名"},
 	{"GET", "/api/v1/domains/[^/]+", "查看详情", "域名"},
@@ -0,0 +1,58 @@
+package model
+
+import "time"
+
+const (
+	ClickExportFormatCSV    = "csv"    // gzip 压缩的 CSV
+	ClickExportFormatNDJSON = "ndjson" // gzip 压缩的 NDJSON，每行一条点击
+
+	ClickExportStatusPending   = "pending"
+	ClickExportStatusRunning   = "running"
+	ClickExportStatusCompleted = "completed"
+	ClickExportStatusFailed    = "failed"
+	ClickExportStatusCancelled = "cancelled"
+	ClickExportStatusExpired   = "expired" // 下载链接过期，文件已删除
+)
+
+// ClickExportJob 点击明细异步导出任务
+// 导出按点击ID升序分批写入 ClickExportChunk，分段与 LastClickID、FileSize 在同一事务中写入，进程重启后从该位置继续导出
+type ClickExportJob struct {
+	ID            uint64     `gorm:"primaryKey" json:"id"`
+	WorkspaceID   uint64     `gorm:"not null;index" json:"workspace_id"`
+	UserID        uint64     `gorm:"not null;default:0" json:"user_id"`
+	Format        string     `gorm:"size:20;not null" json:"format"`
+	Filters       string     `gorm:"type:text" json:"-"` // ClickStatisticListRequest 的 JSON
+	Status        string     `gorm:"size:20;not null;index" json:"status"`
+	MaxClickID    uint64     `gorm:"not null;default:0" json:"max_click_id"` // 创建任务时的最大点击ID，之后的点击不导出
+	LastClickID   uint64     `gorm:"not null;default:0" json:"last_click_id"`
+	RowCount      int64      `gorm:"not null;default:0" json:"row_count"`
+	FileName      string     `gorm:"size:255" json:"file_name"`
+	FileSize      int64      `gorm:"not null;default:0" json:"file_size"`
+	Error         string     `gorm:"size:500" json:"error"`
+	DownloadToken string     `gorm:"size:64;index" json:"-"`
+	ClaimVersion  int64      `gorm:"not null;default:0" json:"-"` // 每次被执行实例领取时加一，旧实例据此停止写入
+	HeartbeatAt   *time.Time `json:"heartbeat_at"`
+	ExpiresAt     *time.Time `json:"expires_at"`
+	StartedAt     *time.Time `json:"started_at"`
+	FinishedAt    *time.Time `json:"finished_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+func (ClickExportJob) TableName() string {
+	return "click_export_jobs"
+}
+
+// ClickExportChunk 导出文件分段，每段是一个独立的 gzip 分段，按 Seq 拼接后即为完整文件。
+// 分段存放在数据库中，多实例部署时任一实例都能提供下载
+type ClickExportChunk struct {
+	ID        uint64    `gorm:"primaryKey" json:"id"`
+	JobID     uint64    `gorm:"not null;uniqueIndex:idx_click_export_chunks_job_seq" json:"job_id"`
+	Seq       int       `gorm:"not null;uniqueIndex:idx_click_export_chunks_job_seq" json:"seq"`
+	Data      []byte    `gorm:"not null" json:"-"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (ClickExportChunk) TableName() string {
+	return "click_export_chunks"
+}
@@ -0,0 +1,96 @@
+package model
+
+import "time"
+
+const (
+	ClickRollupGranularityHour = "hour"
+	ClickRollupGranularityDay  = "day"
+
+	ClickRollupDimensionTotal          = "total"
+	ClickRollupDimensionRegion         = "region" // country/province 列 + city 取值
+	ClickRollupDimensionISP            = "isp"
+	ClickRollupDimensionRefererHost    = "referer_host"
+	ClickRollupDimensionRefererChannel = "referer_channel" // 取值为 渠道|来源主机名
+	ClickRollupDimensionDevice         = "device_type"
+	ClickRollupDimensionBrowser        = "browser"
+	ClickRollupDimensionOS             = "os"
+	ClickRollupDimensionUTMSource      = "utm_source"
+	ClickRollupDimensionUTMCampaign    = "utm_campaign"
+	ClickRollupDimensionRoute          = "route" // 取值为路由ID，label 为路由名称
+	ClickRollupDimensionAlias          = "alias" // 取值为别名ID，label 为别名短码
+
+	// ClickRollupCursorName 增量汇总进度的游标名
+	ClickRollupCursorName = "click_statistics"
+)
+
+// ClickRollup 按小时、按天预聚合的点击数，每个维度取值一行
+type ClickRollup struct {
+	ID             uint64    `gorm:"primaryKey" json:"id"`
+	WorkspaceID    uint64    `gorm:"not null;uniqueIndex:uk_click_rollups_key,priority:1" json:"workspace_id"`
+	ShortLinkID    uint64    `gorm:"not null;uniqueIndex:uk_click_rollups_key,priority:2;index" json:"short_link_id"`
+	CampaignID     uint64    `gorm:"not null;default:0;uniqueIndex:uk_click_rollups_key,priority:3" json:"campaign_id"` // 点击时所属活动，0 表示无
+	IsBot          bool      `gorm:"not null;default:false;uniqueIndex:uk_click_rollups_key,priority:4" json:"is_bot"`
+	Granularity    string    `gorm:"size:8;not null;uniqueIndex:uk_click_rollups_key,priority:5" json:"granularity"`
+	BucketStart    time.Time `gorm:"not null;uniqueIndex:uk_click_rollups_key,priority:6;index" json:"bucket_start"`
+	Dimension      string    `gorm:"size:32;not null;uniqueIndex:uk_click_rollups_key,priority:7" json:"dimension"`
+	Country        string    `gorm:"size:100;not null;default:'';uniqueIndex:uk_click_rollups_key,priority:8" json:"country"`
+	Province       string    `gorm:"size:100;not null;default:'';uniqueIndex:uk_click_rollups_key,priority:9" json:"province"`
+	DimensionValue string    `gorm:"size:255;not null;default:'';uniqueIndex:uk_click_rollups_key,priority:10" json:"dimension_value"`
+	Label          string    `gorm:"size:255" json:"label"`
+	Clicks         int64     `gorm:"not null;default:0" json:"clicks"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+func (ClickRollup) TableName() string {
+	return "click_rollups"
+}
+
+// ClickRollupBucketStart 点击时间所在的小时或天的起点，按服务器时区划分
+func ClickRollupBucketStart(t time.Time, granularity string) time.Time {
+	t = t.In(time.Local)
+	if granularity == ClickRollupGranularityHour {
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, time.Local)
+	}
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local)
+}
+
+// ClickRollupCursor 记录已汇总到的最大点击记录ID
+type ClickRollupCursor struct {
+	Name        string    `gorm:"primaryKey;size:50" json:"name"`
+	LastClickID uint64    `gorm:"not null;default:0" json:"last_click_id"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+func (ClickRollupCursor) TableName() string {
+	return "click_rollup_cursors"
+}
+
+const (
+	ClickRollupRebuildStatusPending   = "pending"
+	ClickRollupRebuildStatusRunning   = "running"
+	ClickRollupRebuildStatusCompleted = "completed"
+	ClickRollupRebuildStatusFailed    = "failed"
+)
+
+// ClickRollupRebuildJob 后台重建工作区点击汇总的任务，按天逐日重建，NextDate 记录下一个待重建的日期
+type ClickRollupRebuildJob struct {
+	ID           uint64     `gorm:"primaryKey" json:"id"`
+	WorkspaceID  uint64     `gorm:"not null;index" json:"workspace_id"`
+	StartDate    *time.Time `json:"start_date"` // 为空表示从最早的点击开始
+	EndDate      *time.Time `json:"end_date"`   // 不含当天，为空表示到最近的点击为止
+	NextDate     *time.Time `json:"next_date"`
+	Status       string     `gorm:"size:20;not null;index" json:"status"`
+	Clicks       int64      `gorm:"not null;default:0" json:"clicks"`
+	Error        string     `gorm:"size:500" json:"error"`
+	RequestedBy  *uint64    `gorm:"index" json:"requested_by"`
+	ClaimVersion int64      `gorm:"not null;default:0" json:"-"` // 每次被执行实例领取时加一
+	HeartbeatAt  *time.Time `json:"heartbeat_at"`
+	StartedAt    *time.Time `json:"started_at"`
+	FinishedAt   *time.Time `json:"finished_at"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+func (ClickRollupRebuildJob) TableName() string {
+	return "click_rollup_rebuild_jobs"
+}
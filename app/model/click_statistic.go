@@ -1,37 +1,62 @@
 package model
 
-import "time"
+import (
+	"net/url"
+	"strings"
+	"time"
+)
 
 // ClickStatistic 点击统计模型
 type ClickStatistic struct {
-	ID          uint64    `gorm:"primaryKey" json:"id"`
-	WorkspaceID uint64    `gorm:"not null;default:1;index" json:"workspace_id"`
-	CampaignID  *uint64   `gorm:"index" json:"campaign_id"`
-	RouteID     *uint64   `gorm:"index" json:"route_id"`
-	RouteName   string    `gorm:"size:100" json:"route_name"`
-	ShortLinkID uint64    `gorm:"index:idx_short_link_date;not null" json:"short_link_id"`
-	IP          string    `gorm:"size:45" json:"ip"`
-	UserAgent   string    `gorm:"size:1024" json:"user_agent"`
-	Referer     string    `gorm:"size:2048" json:"referer"`
-	QueryParams string    `gorm:"size:2048" json:"query_params"`
-	UTMSource   string    `gorm:"size:255" json:"utm_source"`
-	UTMMedium   string    `gorm:"size:255" json:"utm_medium"`
-	UTMCampaign string    `gorm:"size:255" json:"utm_campaign"`
-	UTMTerm     string    `gorm:"size:255" json:"utm_term"`
-	UTMContent  string    `gorm:"size:255" json:"utm_content"`
-	DeviceType  string    `gorm:"size:50" json:"device_type"`
-	Browser     string    `gorm:"size:100" json:"browser"`
-	OS          string    `gorm:"size:100" json:"os"`
-	IsBot       bool      `gorm:"default:false;index" json:"is_bot"`
-	BotName     string    `gorm:"size:100" json:"bot_name"`
-	Country     string    `gorm:"size:100" json:"country"`
-	Province    string    `gorm:"size:100" json:"province"`
-	City        string    `gorm:"size:100" json:"city"`
-	ISP         string    `gorm:"size:100" json:"isp"`
-	ClickDate   time.Time `gorm:"index:idx_short_link_date" json:"click_date"`
-	CreatedAt   time.Time `json:"created_at"`
+	ID             uint64    `gorm:"primaryKey" json:"id"`
+	WorkspaceID    uint64    `gorm:"not null;default:1;index;index:idx_click_statistics_workspace_date,priority:1" json:"workspace_id"`
+	CampaignID     *uint64   `gorm:"index" json:"campaign_id"`
+	RouteID        *uint64   `gorm:"index" json:"route_id"`
+	RouteName      string    `gorm:"size:100" json:"route_name"`
+	AliasID        *uint64   `gorm:"index" json:"alias_id"`
+	AliasCode      string    `gorm:"size:20" json:"alias_code"`
+	ClickID        string    `gorm:"size:64;index" json:"click_id"`    // 点击唯一标识，转化回传时用于归因
+	VisitorKey     string    `gorm:"size:64;index" json:"visitor_key"` // 访客标识，IP 与 UA 加当日盐值的哈希，每天轮换
+	ShortLinkID    uint64    `gorm:"index:idx_short_link_date;not null" json:"short_link_id"`
+	IP             string    `gorm:"size:45" json:"ip"`
+	UserAgent      string    `gorm:"size:1024" json:"user_agent"`
+	Referer        string    `gorm:"size:2048" json:"referer"`
+	RefererHost    string    `gorm:"size:255;index" json:"referer_host"`   // 来源主机名，去掉 www. 前缀
+	RefererChannel string    `gorm:"size:20;index" json:"referer_channel"` // 来源渠道，见 ReferrerChannel* 常量
+	QueryParams    string    `gorm:"size:2048" json:"query_params"`
+	UTMSource      string    `gorm:"size:255" json:"utm_source"`
+	UTMMedium      string    `gorm:"size:255" json:"utm_medium"`
+	UTMCampaign    string    `gorm:"size:255" json:"utm_campaign"`
+	UTMTerm        string    `gorm:"size:255" json:"utm_term"`
+	UTMContent     string    `gorm:"size:255" json:"utm_content"`
+	DeviceType     string    `gorm:"size:50" json:"device_type"`
+	Browser        string    `gorm:"size:100" json:"browser"`
+	OS             string    `gorm:"size:100" json:"os"`
+	IsBot          bool      `gorm:"default:false;index" json:"is_bot"`
+	BotName        string    `gorm:"size:100" json:"bot_name"`
+	BotScore       int       `gorm:"not null;default:0" json:"bot_score"`              // 机器人评分 0-100，达到阈值时 IsBot 为 true
+	BotReason      string    `gorm:"size:255" json:"bot_reason"`                       // 命中的识别规则，逗号分隔
+	IsDuplicate    bool      `gorm:"not null;default:false;index" json:"is_duplicate"` // 去重窗口内同一访客对同一短网址的重复点击，统计默认不计入
+	Country        string    `gorm:"size:100" json:"country"`
+	Province       string    `gorm:"size:100" json:"province"`
+	City           string    `gorm:"size:100" json:"city"`
+	ISP            string    `gorm:"size:100" json:"isp"`
+	ClickDate      time.Time `gorm:"index:idx_short_link_date;index:idx_click_statistics_workspace_date,priority:2" json:"click_date"`
+	CreatedAt      time.Time `json:"created_at"`
 }
 
 func (ClickStatistic) TableName() string {
 	return "click_statistics"
 }
+
+// RefererHost 来源地址的主机名（去掉 www. 前缀），无法解析时返回空
+func RefererHost(referer string) string {
+	if referer == "" {
+		return ""
+	}
+	parsed, err := url.Parse(strings.TrimSpace(referer))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimPrefix(strings.ToLower(parsed.Hostname()), "www.")
+}
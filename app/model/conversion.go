@@ -0,0 +1,34 @@
+package model
+
+import "time"
+
+const (
+	ConversionSourcePostback = "postback"
+	ConversionSourcePixel    = "pixel"
+)
+
+// Conversion 归因到某次点击的转化事件，短网址、活动和路由取自点击记录
+type Conversion struct {
+	ID               uint64    `gorm:"primaryKey" json:"id"`
+	WorkspaceID      uint64    `gorm:"not null;uniqueIndex:uk_conversions_event" json:"workspace_id"`
+	ShortLinkID      uint64    `gorm:"not null;index" json:"short_link_id"`
+	CampaignID       *uint64   `gorm:"index" json:"campaign_id"`
+	RouteID          *uint64   `gorm:"index" json:"route_id"`
+	ClickStatisticID uint64    `gorm:"not null;index" json:"click_statistic_id"`
+	ClickID          string    `gorm:"size:64;not null;index" json:"click_id"`
+	Event            string    `gorm:"size:64;not null" json:"event"`
+	EventID          string    `gorm:"size:128;not null;uniqueIndex:uk_conversions_event" json:"event_id"` // 工作区内去重，默认 事件名:点击ID
+	Value            *float64  `gorm:"type:decimal(18,4)" json:"value"`
+	Currency         string    `gorm:"size:16" json:"currency"`
+	Source           string    `gorm:"size:20;not null" json:"source"` // postback 或 pixel
+	Metadata         string    `gorm:"type:text" json:"metadata"`
+	IP               string    `gorm:"size:45" json:"ip"`
+	UserAgent        string    `gorm:"size:1024" json:"user_agent"`
+	ClickedAt        time.Time `json:"clicked_at"`
+	OccurredAt       time.Time `gorm:"not null;index" json:"occurred_at"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+func (Conversion) TableName() string {
+	return "conversions"
+}
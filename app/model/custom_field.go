@@ -0,0 +1,56 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	CustomFieldTypeString = "string"
+	CustomFieldTypeNumber = "number"
+	CustomFieldTypeEnum   = "enum"
+	CustomFieldTypeDate   = "date"
+)
+
+// CustomField 工作区自定义字段定义，字段标识与类型创建后不可修改
+type CustomField struct {
+	ID          uint64         `gorm:"primaryKey" json:"id"`
+	WorkspaceID uint64         `gorm:"not null;index" json:"workspace_id"`
+	FieldKey    string         `gorm:"size:50;not null;index" json:"key"`
+	Name        string         `gorm:"size:100;not null" json:"name"`
+	Description string         `gorm:"size:500" json:"description"`
+	FieldType   string         `gorm:"size:20;not null" json:"field_type"` // string、number、enum、date
+	Options     string         `gorm:"type:text" json:"options"`           // enum 可选值，JSON 数组
+	Required    bool           `gorm:"not null;default:false" json:"required"`
+	MaxLength   int            `gorm:"not null;default:0" json:"max_length"` // string 最大长度，0 表示不限制
+	MinValue    *float64       `json:"min_value"`                            // number 取值范围
+	MaxValue    *float64       `json:"max_value"`
+	Position    int            `gorm:"not null;default:0" json:"position"`
+	CreatedBy   *uint64        `gorm:"index" json:"created_by"`
+	UpdatedBy   *uint64        `json:"updated_by"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+func (CustomField) TableName() string {
+	return "custom_fields"
+}
+
+// ShortLinkCustomValue 短网址的自定义字段取值，冗余字段标识便于搜索；number 类型同时写入 NumberValue 用于比较
+type ShortLinkCustomValue struct {
+	ID          uint64    `gorm:"primaryKey" json:"id"`
+	WorkspaceID uint64    `gorm:"not null;index" json:"workspace_id"`
+	ShortLinkID uint64    `gorm:"not null;uniqueIndex:idx_short_link_custom_values_link_field" json:"short_link_id"`
+	FieldID     uint64    `gorm:"not null;uniqueIndex:idx_short_link_custom_values_link_field;index" json:"field_id"`
+	FieldKey    string    `gorm:"size:50;not null;index" json:"key"`
+	Value       string    `gorm:"size:500;not null" json:"value"`
+	NumberValue *float64  `json:"number_value"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+func (ShortLinkCustomValue) TableName() string {
+	return "short_link_custom_values"
+}
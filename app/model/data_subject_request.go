@@ -0,0 +1,38 @@
+package model
+
+import "time"
+
+const (
+	DataSubjectActionDelete    = "delete"    // 删除记录
+	DataSubjectActionAnonymize = "anonymize" // 截断IP并清空 UA、访客标识等字段，记录保留用于统计
+
+	DataSubjectStatusPending   = "pending"
+	DataSubjectStatusRunning   = "running"
+	DataSubjectStatusCompleted = "completed"
+	DataSubjectStatusFailed    = "failed"
+)
+
+// DataSubjectRequest 按访客IP或访客标识删除、匿名化个人数据的任务
+// 任务结束后清空 IP 与 VisitorKey，只保留脱敏后的 Subject 用于审计
+type DataSubjectRequest struct {
+	ID           uint64     `gorm:"primaryKey" json:"id"`
+	WorkspaceID  uint64     `gorm:"not null;index" json:"workspace_id"`
+	Action       string     `gorm:"size:20;not null" json:"action"`
+	IP           string     `gorm:"size:45" json:"-"`
+	VisitorKey   string     `gorm:"size:64" json:"-"`
+	Subject      string     `gorm:"size:255" json:"subject"`
+	Status       string     `gorm:"size:20;not null;index" json:"status"`
+	Result       string     `gorm:"type:text" json:"-"` // JSON，各数据表处理的记录数
+	Error        string     `gorm:"size:500" json:"error"`
+	RequestedBy  *uint64    `gorm:"index" json:"requested_by"`
+	ClaimVersion int64      `gorm:"not null;default:0" json:"-"` // 每次被执行实例领取时加一
+	HeartbeatAt  *time.Time `json:"heartbeat_at"`
+	StartedAt    *time.Time `json:"started_at"`
+	FinishedAt   *time.Time `json:"finished_at"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+func (DataSubjectRequest) TableName() string {
+	return "data_subject_requests"
+}
@@ -0,0 +1,36 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// FolderMaxDepth 文件夹最大嵌套层级
+const FolderMaxDepth = 8
+
+// Folder 短网址文件夹，支持嵌套，并可为新建短网址提供默认设置
+type Folder struct {
+	ID          uint64         `gorm:"primaryKey" json:"id"`
+	WorkspaceID uint64         `gorm:"not null;index" json:"workspace_id"`
+	ParentID    *uint64        `gorm:"index" json:"parent_id"` // 上级文件夹，null表示根目录
+	Name        string         `gorm:"size:100;not null" json:"name"`
+	Description string         `gorm:"size:500" json:"description"`
+	CreatedBy   *uint64        `gorm:"index" json:"created_by"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
+
+	// 新建短网址时的默认设置，请求中未填写的字段使用这里的值
+	DefaultDomain      string `gorm:"size:100" json:"default_domain"`
+	DefaultUTMSource   string `gorm:"column:default_utm_source;size:255" json:"default_utm_source"`
+	DefaultUTMMedium   string `gorm:"column:default_utm_medium;size:255" json:"default_utm_medium"`
+	DefaultUTMCampaign string `gorm:"column:default_utm_campaign;size:255" json:"default_utm_campaign"`
+	DefaultUTMTerm     string `gorm:"column:default_utm_term;size:255" json:"default_utm_term"`
+	DefaultUTMContent  string `gorm:"column:default_utm_content;size:255" json:"default_utm_content"`
+	DefaultSecurity    string `gorm:"type:text" json:"default_security"` // 默认安全设置，JSON 格式的 LinkSecurityRequest
+}
+
+func (Folder) TableName() string {
+	return "folders"
+}
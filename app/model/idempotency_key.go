@@ -0,0 +1,20 @@
+package model
+
+import "time"
+
+// IdempotencyKey 幂等键记录，用于重放创建类请求的原始响应
+type IdempotencyKey struct {
+	ID             uint64     `gorm:"primaryKey" json:"id"`
+	WorkspaceID    uint64     `gorm:"not null;default:1;uniqueIndex:uk_idempotency_keys_scope_key" json:"workspace_id"`
+	Scope          string     `gorm:"size:100;not null;uniqueIndex:uk_idempotency_keys_scope_key" json:"scope"`
+	IdempotencyKey string     `gorm:"size:255;not null;uniqueIndex:uk_idempotency_keys_scope_key" json:"idempotency_key"`
+	RequestHash    string     `gorm:"size:64;not null" json:"request_hash"`
+	ResponseBody   string     `gorm:"type:text" json:"response_body"`
+	CompletedAt    *time.Time `json:"completed_at"` // 为空表示请求仍在处理中
+	ExpiresAt      time.Time  `gorm:"not null;index" json:"expires_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+func (IdempotencyKey) TableName() string {
+	return "idempotency_keys"
+}
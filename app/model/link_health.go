@@ -0,0 +1,41 @@
+package model
+
+import "time"
+
+const (
+	LinkHealthTargetOriginal  = "original"
+	LinkHealthTargetFallback  = "fallback"
+	LinkHealthTargetRoute     = "route"
+	LinkHealthTargetABVariant = "ab_variant"
+
+	LinkHealthStatusHealthy   = "healthy"
+	LinkHealthStatusBroken    = "broken"
+	LinkHealthStatusUnchecked = "unchecked" // 仅用于列表筛选，对应 health_status 为空
+
+	SecurityEventDestinationFailing   = "destination_failing"
+	SecurityEventDestinationRecovered = "destination_recovered"
+)
+
+// LinkHealthCheck 短网址目标地址最近一次健康检查结果，每个目标一行
+type LinkHealthCheck struct {
+	ID                  uint64     `gorm:"primaryKey" json:"id"`
+	WorkspaceID         uint64     `gorm:"not null;index" json:"workspace_id"`
+	ShortLinkID         uint64     `gorm:"not null;uniqueIndex:uk_link_health_checks_target" json:"short_link_id"`
+	TargetType          string     `gorm:"size:20;not null;uniqueIndex:uk_link_health_checks_target" json:"target_type"`
+	TargetID            uint64     `gorm:"not null;default:0;uniqueIndex:uk_link_health_checks_target" json:"target_id"` // 路由ID或A/B变体ID，原始地址和兜底地址为0
+	TargetURL           string     `gorm:"size:2000;not null" json:"target_url"`
+	Healthy             bool       `gorm:"not null;default:false" json:"healthy"`
+	StatusCode          int        `gorm:"not null;default:0" json:"status_code"`
+	Error               string     `gorm:"size:500" json:"error"`
+	RedirectChain       string     `gorm:"type:text" json:"redirect_chain"` // JSON 数组，依次记录跳转经过的地址
+	LatencyMs           int64      `gorm:"not null;default:0" json:"latency_ms"`
+	TLSExpiresAt        *time.Time `json:"tls_expires_at"`
+	ConsecutiveFailures int        `gorm:"not null;default:0" json:"consecutive_failures"`
+	CheckedAt           time.Time  `json:"checked_at"`
+	CreatedAt           time.Time  `json:"created_at"`
+	UpdatedAt           time.Time  `json:"updated_at"`
+}
+
+func (LinkHealthCheck) TableName() string {
+	return "link_health_checks"
+}
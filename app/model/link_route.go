@@ -7,15 +7,16 @@ import (
 )
 
 const (
-	RouteConditionCountry    = "country"
-	RouteConditionProvince   = "province"
-	RouteConditionCity       = "city"
-	RouteConditionDeviceType = "device_type"
-	RouteConditionBrowser    = "browser"
-	RouteConditionOS         = "os"
-	RouteConditionLanguage   = "language"
-	RouteConditionReferer    = "referer"
-	RouteConditionQueryParam = "query_param"
+	RouteConditionCountry     = "country"
+	RouteConditionProvince    = "province"
+	RouteConditionCity        = "city"
+	RouteConditionDeviceType  = "device_type"
+	RouteConditionBrowser     = "browser"
+	RouteConditionOS          = "os"
+	RouteConditionLanguage    = "language"
+	RouteConditionReferer     = "referer"
+	RouteConditionQueryParam  = "query_param"
+	RouteConditionCustomField = "custom_field" // 按短网址自身的自定义字段取值匹配，condition_key 为字段标识
 
 	RouteOperatorExists   = "exists"
 	RouteOperatorEq       = "eq"
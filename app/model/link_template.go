@@ -0,0 +1,26 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// LinkTemplate 工作区链接模板，创建短网址时预填请求中未填写的字段。每个工作区最多一个默认模板。
+type LinkTemplate struct {
+	ID          uint64         `gorm:"primaryKey" json:"id"`
+	WorkspaceID uint64         `gorm:"not null;index" json:"workspace_id"`
+	Name        string         `gorm:"size:100;not null" json:"name"`
+	Description string         `gorm:"size:500" json:"description"`
+	IsDefault   bool           `gorm:"not null;default:false" json:"is_default"` // 创建时未指定模板则使用默认模板
+	Settings    string         `gorm:"type:text" json:"settings"`                // JSON 格式的 LinkTemplateSettings
+	CreatedBy   *uint64        `gorm:"index" json:"created_by"`
+	UpdatedBy   *uint64        `json:"updated_by"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+func (LinkTemplate) TableName() string {
+	return "link_templates"
+}
@@ -0,0 +1,55 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	ReferrerChannelSearch    = "search"
+	ReferrerChannelSocial    = "social"
+	ReferrerChannelEmail     = "email"
+	ReferrerChannelMessaging = "messaging"
+	ReferrerChannelPaid      = "paid"
+	ReferrerChannelDirect    = "direct"   // 没有来源且没有投放参数
+	ReferrerChannelInternal  = "internal" // 来源为短网址域名或目标站点
+	ReferrerChannelReferral  = "referral" // 其他网站
+	ReferrerChannelUnknown   = "unknown"  // 仅用于渠道分析，表示尚未分类的历史点击
+
+	ReferrerMatchHost       = "host"        // 来源主机名等于规则或为其子域名，规则以 .* 结尾时匹配任意后缀，如 google.*
+	ReferrerMatchQueryParam = "query_param" // 访问短网址时带有该参数，如 gclid
+	ReferrerMatchUTMSource  = "utm_source"  // utm_source 等于规则（不区分大小写）
+	ReferrerMatchUTMMedium  = "utm_medium"  // utm_medium 等于规则（不区分大小写）
+)
+
+// ReferrerChannels 可分类的来源渠道
+var ReferrerChannels = []string{
+	ReferrerChannelSearch,
+	ReferrerChannelSocial,
+	ReferrerChannelEmail,
+	ReferrerChannelMessaging,
+	ReferrerChannelPaid,
+	ReferrerChannelDirect,
+	ReferrerChannelInternal,
+	ReferrerChannelReferral,
+}
+
+// ReferrerRule 工作区自定义的来源渠道规则，优先于内置规则
+type ReferrerRule struct {
+	ID          uint64         `gorm:"primaryKey" json:"id"`
+	WorkspaceID uint64         `gorm:"not null;index" json:"workspace_id"`
+	MatchType   string         `gorm:"size:20;not null" json:"match_type"`
+	Pattern     string         `gorm:"size:255;not null" json:"pattern"`
+	Channel     string         `gorm:"size:20;not null" json:"channel"`
+	Priority    int            `gorm:"not null;default:0" json:"priority"` // 数值大的先匹配
+	Enabled     bool           `gorm:"not null" json:"enabled"`
+	CreatedBy   *uint64        `gorm:"index" json:"created_by"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+func (ReferrerRule) TableName() string {
+	return "referrer_rules"
+}
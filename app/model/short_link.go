@@ -8,21 +8,30 @@ import (
 	"gorm.io/gorm"
 )
 
+const (
+	ShortLinkReviewPending  = "pending_review"
+	ShortLinkReviewApproved = "approved"
+	ShortLinkReviewRejected = "rejected"
+)
+
 // ShortLink 短网址模型
 type ShortLink struct {
 	ID           uint64         `gorm:"primaryKey" json:"id"`
 	WorkspaceID  uint64         `gorm:"not null;default:1;index" json:"workspace_id"`
 	CampaignID   *uint64        `gorm:"index" json:"campaign_id"`
+	FolderID     *uint64        `gorm:"index" json:"folder_id"`                           // 所属文件夹，null表示根目录
 	IssuerNumber *uint64        `gorm:"index" json:"issuer_number"`                       // 发号器分配的号码
 	DomainID     uint64         `gorm:"not null;index" json:"domain_id"`                  // 关联域名表ID
 	Protocol     string         `gorm:"size:10;default:'https';not null" json:"protocol"` // 协议头 http或https
 	Domain       string         `gorm:"size:100;not null;index;" json:"domain"`           // 域名
 	OriginalURL  string         `gorm:"size:2000;not null" json:"original_url"`           // 原始URL
+	URLHash      string         `gorm:"column:original_url_hash;size:64" json:"-"`        // 规范化目标URL指纹，用于复用匹配
 	FallbackURL  string         `gorm:"size:2000" json:"fallback_url"`                    // 高级路由未命中时的兜底URL
 	RedirectCode int            `gorm:"not null;default:302" json:"redirect_code"`        // 跳转状态码
 	Title        string         `gorm:"size:255" json:"title"`                            // 网页标题
 	IsCustomCode bool           `gorm:"default:false;" json:"is_custom_code"`             // 是否使用自定义短代码
 	ShortCode    string         `gorm:"size:20;index" json:"short_code"`                  // 短代码(可自定义)
+	ExternalID   *string        `gorm:"size:128" json:"external_id"`                      // 外部系统标识，工作区内唯一
 	ClickCount   int64          `gorm:"default:0" json:"click_count"`                     // 点击次数
 	CreatorIP    string         `gorm:"size:45" json:"creator_ip"`                        // 创建者IP
 	CreatedBy    *uint64        `gorm:"index" json:"created_by"`
@@ -40,6 +49,22 @@ type ShortLink struct {
 	UpdatedAt    time.Time      `json:"updated_at"`
 	DeletedAt    gorm.DeletedAt `gorm:"index" json:"-"`
 
+	// 目标地址健康检查，由后台任务维护
+	HealthStatus    string     `gorm:"size:20;index" json:"health_status"` // healthy 或 broken，空表示未检查
+	HealthCheckedAt *time.Time `gorm:"index" json:"health_checked_at"`
+
+	// 链接审核，工作区开启审核后成员创建或修改的短网址需管理员审核后才会生效
+	ReviewStatus  string     `gorm:"size:20;index" json:"review_status"` // pending_review、approved、rejected，空表示无需审核
+	ReviewComment string     `gorm:"size:500" json:"review_comment"`
+	ReviewedBy    *uint64    `json:"reviewed_by"`
+	ReviewedAt    *time.Time `json:"reviewed_at"`
+
+	// 转化追踪，开启后跳转时在目标地址追加点击ID，落地页据此回传转化
+	AppendClickID bool `gorm:"not null;default:false" json:"append_click_id"`
+
+	// 工作区去重窗口内同一访客的重复点击单独计数，不计入 ClickCount
+	DuplicateClickCount int64 `gorm:"not null;default:0" json:"duplicate_click_count"`
+
 	Campaign *Campaign `gorm:"foreignKey:CampaignID" json:"campaign,omitempty"`
 	Tags     []Tag     `gorm:"many2many:short_link_tags;" json:"tags,omitempty"`
 }
@@ -66,6 +91,11 @@ func (s *ShortLink) IsExpired() bool {
 	return time.Now().After(*s.ExpireAt)
 }
 
+// IsBlockedByReview 待审核或审核未通过的短网址视为未激活
+func (s *ShortLink) IsBlockedByReview() bool {
+	return s.ReviewStatus == ShortLinkReviewPending || s.ReviewStatus == ShortLinkReviewRejected
+}
+
 // GetFullURL 获取完整的短网址
 func (s *ShortLink) GetFullURL() string {
 	return fmt.Sprintf("%s://%s/%s", s.Protocol, s.Domain, s.GetShortCode())
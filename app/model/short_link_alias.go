@@ -0,0 +1,29 @@
+package model
+
+import "time"
+
+const (
+	ShortLinkAliasSourceRekey  = "rekey"  // 重新分配短码时保留的旧短码
+	ShortLinkAliasSourceManual = "manual" // 手动添加的别名
+
+	// ShortLinkAliasMaxPerLink 单条短网址最多可手动添加的别名数
+	ShortLinkAliasMaxPerLink = 50
+)
+
+// ShortLinkAlias 短网址别名，域名+短码全局唯一，访问时解析到同一条短网址
+type ShortLinkAlias struct {
+	ID          uint64    `gorm:"primaryKey" json:"id"`
+	WorkspaceID uint64    `gorm:"not null;index" json:"workspace_id"`
+	ShortLinkID uint64    `gorm:"not null;index" json:"short_link_id"`
+	DomainID    uint64    `gorm:"not null" json:"domain_id"`
+	Protocol    string    `gorm:"size:10;default:'https';not null" json:"protocol"`
+	Domain      string    `gorm:"size:100;not null;uniqueIndex:uk_short_link_aliases_domain_code" json:"domain"`
+	ShortCode   string    `gorm:"size:20;not null;uniqueIndex:uk_short_link_aliases_domain_code" json:"short_code"`
+	Source      string    `gorm:"size:20;not null;default:'rekey'" json:"source"`
+	CreatedBy   *uint64   `json:"created_by"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+func (ShortLinkAlias) TableName() string {
+	return "short_link_aliases"
+}
@@ -0,0 +1,29 @@
+package model
+
+import "time"
+
+const (
+	ShortLinkMetadataStatusFetched = "fetched"
+	ShortLinkMetadataStatusFailed  = "failed"
+)
+
+// ShortLinkMetadata 从目标页面抓取的建议元数据，每条短网址一行，不会覆盖用户填写的标题和描述
+type ShortLinkMetadata struct {
+	ID          uint64    `gorm:"primaryKey" json:"id"`
+	WorkspaceID uint64    `gorm:"not null;index" json:"workspace_id"`
+	ShortLinkID uint64    `gorm:"not null;uniqueIndex" json:"short_link_id"`
+	SourceURL   string    `gorm:"size:2000;not null" json:"source_url"` // 抓取时的目标地址
+	Title       string    `gorm:"size:255" json:"title"`
+	Description string    `gorm:"size:500" json:"description"`
+	ImageURL    string    `gorm:"size:2000" json:"image_url"`   // og:image
+	FaviconURL  string    `gorm:"size:2000" json:"favicon_url"` // 页面声明的图标，未声明时为 /favicon.ico
+	Status      string    `gorm:"size:20;not null" json:"status"`
+	Error       string    `gorm:"size:500" json:"error"`
+	FetchedAt   time.Time `json:"fetched_at"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+func (ShortLinkMetadata) TableName() string {
+	return "short_link_metadata"
+}
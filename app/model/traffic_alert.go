@@ -0,0 +1,108 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	TrafficAlertSpike        = "spike"         // 点击量激增
+	TrafficAlertDrop         = "drop"          // 点击量降为零
+	TrafficAlertBotRatio     = "bot_ratio"     // 机器人占比升高
+	TrafficAlertCountryShift = "country_shift" // 国家分布突变
+
+	AlertChannelTypeWebhook  = "webhook"  // 通用 JSON，附带签名
+	AlertChannelTypeSlack    = "slack"    // Slack Incoming Webhook
+	AlertChannelTypeDingTalk = "dingtalk" // 钉钉群机器人
+	AlertChannelTypeWeCom    = "wecom"    // 企业微信群机器人
+	AlertChannelTypeFeishu   = "feishu"   // 飞书群机器人
+
+	AlertDeliveryStatusPending   = "pending"
+	AlertDeliveryStatusDelivered = "delivered"
+	AlertDeliveryStatusFailed    = "failed"
+)
+
+var TrafficAlertTypes = []string{TrafficAlertSpike, TrafficAlertDrop, TrafficAlertBotRatio, TrafficAlertCountryShift}
+
+// TrafficAlertSetting 工作区流量异常检测阈值，没有记录时使用默认值
+type TrafficAlertSetting struct {
+	ID                    uint64    `gorm:"primaryKey" json:"id"`
+	WorkspaceID           uint64    `gorm:"not null;uniqueIndex" json:"workspace_id"`
+	Enabled               bool      `gorm:"not null" json:"enabled"`
+	WindowMinutes         int       `gorm:"not null;default:60" json:"window_minutes"`           // 与基线比较的最近时间窗口
+	BaselineDays          int       `gorm:"not null;default:7" json:"baseline_days"`             // 基线取窗口之前的天数
+	SpikeFactor           float64   `gorm:"not null;default:3" json:"spike_factor"`              // 窗口内每小时点击达到基线的倍数
+	SpikeMinClicks        int64     `gorm:"not null;default:100" json:"spike_min_clicks"`        // 窗口内点击低于该值不判定激增
+	DropMinHourlyClicks   float64   `gorm:"not null;default:10" json:"drop_min_hourly_clicks"`   // 基线每小时点击达到该值时，窗口内无点击判定为骤降
+	BotRatioDelta         float64   `gorm:"not null;default:0.3" json:"bot_ratio_delta"`         // 机器人占比比基线升高的幅度
+	CountryShiftThreshold float64   `gorm:"not null;default:0.5" json:"country_shift_threshold"` // 国家分布与基线的差异（0-1）
+	MixMinClicks          int64     `gorm:"not null;default:50" json:"mix_min_clicks"`           // 窗口内点击低于该值不判定占比和分布变化
+	CooldownMinutes       int       `gorm:"not null;default:360" json:"cooldown_minutes"`        // 同一对象同类告警的最短间隔
+	CreatedAt             time.Time `json:"created_at"`
+	UpdatedAt             time.Time `json:"updated_at"`
+}
+
+func (TrafficAlertSetting) TableName() string {
+	return "traffic_alert_settings"
+}
+
+// AlertChannel 告警投递渠道
+type AlertChannel struct {
+	ID          uint64         `gorm:"primaryKey" json:"id"`
+	WorkspaceID uint64         `gorm:"not null;index" json:"workspace_id"`
+	Name        string         `gorm:"size:100;not null" json:"name"`
+	Type        string         `gorm:"size:20;not null" json:"type"`
+	URL         string         `gorm:"size:1000;not null" json:"url"`
+	Secret      string         `gorm:"size:255" json:"-"` // webhook 类型用于签名
+	AlertTypes  string         `gorm:"size:255" json:"-"` // 逗号分隔的告警类型，空表示全部
+	Enabled     bool           `gorm:"not null" json:"enabled"`
+	CreatedBy   *uint64        `gorm:"index" json:"created_by"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+func (AlertChannel) TableName() string {
+	return "alert_channels"
+}
+
+// TrafficAlert 检测到的流量异常，short_link_id 为 0 表示整个工作区
+type TrafficAlert struct {
+	ID             uint64     `gorm:"primaryKey" json:"id"`
+	WorkspaceID    uint64     `gorm:"not null;uniqueIndex:uk_traffic_alerts_dedupe,priority:1;index" json:"workspace_id"`
+	ShortLinkID    uint64     `gorm:"not null;default:0;uniqueIndex:uk_traffic_alerts_dedupe,priority:2;index" json:"short_link_id"`
+	AlertType      string     `gorm:"size:20;not null;uniqueIndex:uk_traffic_alerts_dedupe,priority:3" json:"alert_type"`
+	DedupeBucket   int64      `gorm:"not null;default:0;uniqueIndex:uk_traffic_alerts_dedupe,priority:4" json:"-"` // 按冷却时间划分的时间段，避免多个实例重复告警
+	Message        string     `gorm:"size:500" json:"message"`
+	Observed       float64    `gorm:"not null;default:0" json:"observed"`
+	Baseline       float64    `gorm:"not null;default:0" json:"baseline"`
+	Details        string     `gorm:"type:text" json:"details"` // JSON，窗口与基线的点击、机器人和国家分布
+	WindowStart    time.Time  `json:"window_start"`
+	WindowEnd      time.Time  `json:"window_end"`
+	AcknowledgedBy *uint64    `json:"acknowledged_by"`
+	AcknowledgedAt *time.Time `json:"acknowledged_at"`
+	CreatedAt      time.Time  `gorm:"index" json:"created_at"`
+}
+
+func (TrafficAlert) TableName() string {
+	return "traffic_alerts"
+}
+
+// TrafficAlertDelivery 告警向单个渠道的投递状态
+type TrafficAlertDelivery struct {
+	ID            uint64     `gorm:"primaryKey" json:"id"`
+	AlertID       uint64     `gorm:"not null;index" json:"alert_id"`
+	ChannelID     uint64     `gorm:"not null;index" json:"channel_id"`
+	Status        string     `gorm:"size:20;not null;index" json:"status"`
+	Attempts      int        `gorm:"not null;default:0" json:"attempts"`
+	LastError     string     `gorm:"size:500" json:"last_error"`
+	NextAttemptAt time.Time  `gorm:"index" json:"next_attempt_at"`
+	DeliveredAt   *time.Time `json:"delivered_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+func (TrafficAlertDelivery) TableName() string {
+	return "traffic_alert_deliveries"
+}
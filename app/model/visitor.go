@@ -0,0 +1,28 @@
+package model
+
+import "time"
+
+// VisitorSalt 计算访客标识的每日盐值，过期后删除，使历史访客标识无法再与 IP 关联
+type VisitorSalt struct {
+	Day       string    `gorm:"primaryKey;size:10" json:"day"` // 服务器时区日期 YYYY-MM-DD
+	Salt      string    `gorm:"size:64;not null" json:"-"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (VisitorSalt) TableName() string {
+	return "visitor_salts"
+}
+
+// VisitorSketch 每个短网址每天的独立访客 HyperLogLog 草图，ShortLinkID 为 0 表示整个工作区
+type VisitorSketch struct {
+	ID          uint64    `gorm:"primaryKey" json:"id"`
+	WorkspaceID uint64    `gorm:"not null;uniqueIndex:uk_visitor_sketches_key,priority:1" json:"workspace_id"`
+	ShortLinkID uint64    `gorm:"not null;default:0;uniqueIndex:uk_visitor_sketches_key,priority:2" json:"short_link_id"`
+	Day         time.Time `gorm:"not null;uniqueIndex:uk_visitor_sketches_key,priority:3;index" json:"day"`
+	Registers   []byte    `gorm:"not null" json:"-"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+func (VisitorSketch) TableName() string {
+	return "visitor_sketches"
+}
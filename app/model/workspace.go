@@ -24,6 +24,23 @@ type Workspace struct {
 	UpdatedAt   time.Time      `json:"updated_at"`
 	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
 
+	// 工作区策略
+	ReuseExistingLinks  bool `gorm:"not null;default:false" json:"reuse_existing_links"`  // 创建时复用目标地址相同的已有短网址
+	RequireLinkApproval bool `gorm:"not null;default:false" json:"require_link_approval"` // 成员创建或修改的短网址需管理员审核
+
+	// 数据保留，天数为 0 时使用服务端默认值
+	AnonymizeIP                bool `gorm:"not null;default:false" json:"anonymize_ip"` // 写入点击、安全事件等数据前截断访客IP
+	ClickRetentionDays         int  `gorm:"not null;default:0" json:"click_retention_days"`
+	ABTestClickRetentionDays   int  `gorm:"not null;default:0" json:"ab_test_click_retention_days"`
+	SecurityEventRetentionDays int  `gorm:"not null;default:0" json:"security_event_retention_days"`
+	OperationLogRetentionDays  int  `gorm:"not null;default:0" json:"operation_log_retention_days"`
+
+	ClickDedupSeconds int    `gorm:"not null;default:0" json:"click_dedup_seconds"` // 同一访客在窗口内重复点击同一短网址时标记为重复点击，0 表示使用服务端默认值
+	Timezone          string `gorm:"size:64" json:"timezone"`                       // 统计报表按天、按小时划分使用的 IANA 时区，空表示使用服务端默认值
+
+	ConversionSecret     string `gorm:"size:64" json:"-"`                                     // 服务端转化回传的签名密钥，首次查看回传设置时生成
+	PixelConversionValue bool   `gorm:"not null;default:false" json:"pixel_conversion_value"` // 像素回传没有签名，开启后才记录其中的 value、currency
+
 	Members []WorkspaceMember `gorm:"foreignKey:WorkspaceID" json:"members,omitempty"`
 }
 
@@ -56,3 +73,8 @@ func (WorkspaceMember) TableName() string {
 func (m *WorkspaceMember) IsActive() bool {
 	return m.Status == 1
 }
+
+// CanManageBusinessResource 是否可以管理短网址等业务资源
+func (m *WorkspaceMember) CanManageBusinessResource() bool {
+	return m.Role == WorkspaceRoleOwner || m.Role == WorkspaceRoleAdmin || m.Role == WorkspaceRoleMember
+}
@@ -130,6 +130,48 @@ func (s *ABTestService) CreateABTestInWorkspace(req *dto.CreateABTestRequest, wo
 	return s.modelToResponse(abTest), nil
 }
 
+// cloneLatestAsDraft 在事务中把源短网址最近一次的AB测试及其变体复制为草稿
+func (s *ABTestService) cloneLatestAsDraft(tx *gorm.DB, sourceID uint64, target *model.ShortLink) error {
+	var abTest model.ABTest
+	err := tx.Preload("Variants", "deleted_at IS NULL").
+		Where("short_link_id = ? AND deleted_at IS NULL", sourceID).
+		Order("id DESC").
+		First(&abTest).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	cloned := model.ABTest{
+		WorkspaceID:  target.WorkspaceID,
+		ShortLinkID:  target.ID,
+		Name:         abTest.Name,
+		Description:  abTest.Description,
+		Status:       "draft",
+		TrafficSplit: abTest.TrafficSplit,
+		IsActive:     true,
+	}
+	if err := tx.Create(&cloned).Error; err != nil {
+		return err
+	}
+	for _, variant := range abTest.Variants {
+		if err := tx.Create(&model.ABTestVariant{
+			ABTestID:    cloned.ID,
+			Name:        variant.Name,
+			TargetURL:   variant.TargetURL,
+			Weight:      variant.Weight,
+			IsControl:   variant.IsControl,
+			Description: variant.Description,
+			IsActive:    true,
+		}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // GetABTest 获取AB测试详情
 func (s *ABTestService) GetABTest(id uint64) (*dto.ABTestResponse, error) {
 	return s.GetABTestInWorkspace(id, 1)
@@ -380,8 +422,8 @@ func (s *ABTestService) GetABTestRedirectInfo(shortLinkID uint64, userIP, userAg
 	}, nil
 }
 
-// RecordABTestClick 记录AB测试点击
-func (s *ABTestService) RecordABTestClick(redirectInfo *dto.ABTestRedirectInfo, clientIP, userAgent, referer, queryParams string) error {
+// RecordABTestClick 记录AB测试点击，bot 为本次访问的机器人识别结果
+func (s *ABTestService) RecordABTestClick(redirectInfo *dto.ABTestRedirectInfo, clientIP, userAgent, referer, queryParams string, bot BotVerdict) error {
 	// 检查会话是否已存在（防重复）
 	exists, err := s.abTestDao.CheckSessionExists(redirectInfo.ABTestID, redirectInfo.VariantID, redirectInfo.SessionID)
 	if err != nil {
@@ -410,7 +452,7 @@ func (s *ABTestService) RecordABTestClick(redirectInfo *dto.ABTestRedirectInfo,
 		ABTestID:    redirectInfo.ABTestID,
 		VariantID:   redirectInfo.VariantID,
 		ShortLinkID: abTest.ShortLinkID,
-		IP:          clientIP,
+		IP:          NewDataRetentionService(s.helper).StoredIP(shortLink.WorkspaceID, clientIP),
 		UserAgent:   userAgent,
 		Referer:     referer,
 		QueryParams: queryParams,
@@ -422,8 +464,8 @@ func (s *ABTestService) RecordABTestClick(redirectInfo *dto.ABTestRedirectInfo,
 		DeviceType:  metadata.DeviceType,
 		Browser:     metadata.Browser,
 		OS:          metadata.OS,
-		IsBot:       metadata.IsBot,
-		BotName:     metadata.BotName,
+		IsBot:       bot.IsBot,
+		BotName:     domain_validate.TruncateString(bot.BotName, 100),
 		Country:     region.Country,
 		Province:    region.Province,
 		City:        region.City,
@@ -499,7 +541,7 @@ func (s *ABTestService) RecordABTestFeedback(req *dto.ABTestFeedbackRequest, cli
 		Value:       req.Value,
 		Currency:    domain_validate.TruncateString(req.Currency, 16),
 		Metadata:    string(req.Metadata),
-		IP:          domain_validate.TruncateString(clientIP, 45),
+		IP:          domain_validate.TruncateString(NewDataRetentionService(s.helper).StoredIP(payload.WorkspaceID, clientIP), 45),
 		UserAgent:   domain_validate.TruncateString(userAgent, 1024),
 		Referer:     domain_validate.TruncateString(referer, 2048),
 		OccurredAt:  occurredAt,
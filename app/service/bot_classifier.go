@@ -0,0 +1,416 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"cnb.cool/mliev/dwz/dwz-server/v2/pkg/interfaces"
+)
+
+const (
+	defaultBotScoreThreshold = 60
+	maxBotScore              = 100
+	botVelocityRedisKey      = "dwz:bot_velocity:"
+	botVelocityLocalMaxKeys  = 100000 // 未连接 Redis 时本实例计数的 IP 数上限，超过时清理过期窗口
+)
+
+// BotSignals 一次访问中用于识别机器人的信息，Headers 为空时跳过请求头规则
+type BotSignals struct {
+	WorkspaceID uint64
+	ClientIP    string
+	UserAgent   string
+	Headers     http.Header
+	At          time.Time
+}
+
+// BotSignal 单条识别规则的判断，Reason 形如 "rule" 或 "rule:detail"
+type BotSignal struct {
+	Score  int
+	Reason string
+	Name   string // 可识别的机器人名称，写入点击的 bot_name
+}
+
+// BotVerdict 综合各规则后的结果，Score 为各规则分数之和（最高 100），达到阈值时 IsBot 为 true
+type BotVerdict struct {
+	IsBot   bool
+	Score   int
+	Reason  string
+	BotName string
+}
+
+// BotClassifier 机器人识别规则，未命中时返回 nil
+type BotClassifier interface {
+	Classify(signals *BotSignals) *BotSignal
+}
+
+var (
+	botClassifierMu        sync.RWMutex
+	botClassifierFactories []func(helper interfaces.HelperInterface) BotClassifier
+	botClassifierVersion   int
+)
+
+// botDetectorCache 最近一次构建的检测器，配置未变化时复用，避免每次跳转都重新解析 IP 段
+var botDetectorCache struct {
+	mu       sync.Mutex
+	key      string
+	detector *BotDetector
+}
+
+// RegisterBotClassifier 注册内置规则之外的识别规则，应在服务启动时调用
+func RegisterBotClassifier(factory func(helper interfaces.HelperInterface) BotClassifier) {
+	botClassifierMu.Lock()
+	defer botClassifierMu.Unlock()
+	botClassifierFactories = append(botClassifierFactories, factory)
+	botClassifierVersion++
+}
+
+// BotDetector 组合 User-Agent、请求头、访问频率、数据中心 IP 段和邮件安全网关等规则识别机器人
+type BotDetector struct {
+	classifiers []BotClassifier
+	threshold   int
+}
+
+// NewBotDetector 返回当前配置对应的检测器，配置与注册的规则不变时返回同一个实例
+func NewBotDetector(helper interfaces.HelperInterface) *BotDetector {
+	config := helper.GetConfig()
+	botClassifierMu.RLock()
+	version := botClassifierVersion
+	botClassifierMu.RUnlock()
+	key := fmt.Sprintf("%p|%d|%v|%v|%v|%d|%d|%t|%d", helper, version,
+		config.GetStringSlice("bot_detection.scanner_user_agents", nil),
+		config.GetStringSlice("bot_detection.scanner_cidrs", nil),
+		config.GetStringSlice("bot_detection.datacenter_cidrs", nil),
+		config.GetInt("bot_detection.velocity_window_seconds", 60),
+		config.GetInt("bot_detection.velocity_max_clicks", 30),
+		config.GetBool("bot_detection.header_checks", true),
+		config.GetInt("bot_detection.score_threshold", defaultBotScoreThreshold))
+
+	botDetectorCache.mu.Lock()
+	defer botDetectorCache.mu.Unlock()
+	if botDetectorCache.detector == nil || botDetectorCache.key != key {
+		botDetectorCache.detector = buildBotDetector(helper)
+		botDetectorCache.key = key
+	}
+	return botDetectorCache.detector
+}
+
+func buildBotDetector(helper interfaces.HelperInterface) *BotDetector {
+	config := helper.GetConfig()
+	classifiers := []BotClassifier{
+		userAgentBotClassifier{},
+		newScannerBotClassifier(config.GetStringSlice("bot_detection.scanner_user_agents", nil), config.GetStringSlice("bot_detection.scanner_cidrs", nil)),
+		newDatacenterBotClassifier(config.GetStringSlice("bot_detection.datacenter_cidrs", nil)),
+		&velocityBotClassifier{
+			helper:    helper,
+			window:    time.Duration(config.GetInt("bot_detection.velocity_window_seconds", 60)) * time.Second,
+			maxClicks: config.GetInt("bot_detection.velocity_max_clicks", 30),
+			local:     make(map[string]botVelocityRecord),
+		},
+	}
+	if config.GetBool("bot_detection.header_checks", true) {
+		classifiers = append(classifiers, headerBotClassifier{})
+	}
+	botClassifierMu.RLock()
+	for _, factory := range botClassifierFactories {
+		if classifier := factory(helper); classifier != nil {
+			classifiers = append(classifiers, classifier)
+		}
+	}
+	botClassifierMu.RUnlock()
+
+	threshold := config.GetInt("bot_detection.score_threshold", defaultBotScoreThreshold)
+	if threshold <= 0 {
+		threshold = defaultBotScoreThreshold
+	}
+	return &BotDetector{classifiers: classifiers, threshold: threshold}
+}
+
+// Classify 执行全部规则，原因按分数从高到低排列
+func (d *BotDetector) Classify(signals *BotSignals) BotVerdict {
+	if signals.At.IsZero() {
+		signals.At = time.Now()
+	}
+	var hits []BotSignal
+	for _, classifier := range d.classifiers {
+		if signal := classifier.Classify(signals); signal != nil && signal.Score > 0 {
+			hits = append(hits, *signal)
+		}
+	}
+	if len(hits) == 0 {
+		return BotVerdict{}
+	}
+	sort.SliceStable(hits, func(i, j int) bool { return hits[i].Score > hits[j].Score })
+
+	var verdict BotVerdict
+	reasons := make([]string, 0, len(hits))
+	for _, hit := range hits {
+		verdict.Score += hit.Score
+		reasons = append(reasons, hit.Reason)
+		if verdict.BotName == "" {
+			verdict.BotName = hit.Name
+		}
+	}
+	if verdict.Score > maxBotScore {
+		verdict.Score = maxBotScore
+	}
+	verdict.Reason = strings.Join(reasons, ",")
+	verdict.IsBot = verdict.Score >= d.threshold
+	if !verdict.IsBot {
+		verdict.BotName = ""
+	} else if verdict.BotName == "" {
+		verdict.BotName, _, _ = strings.Cut(hits[0].Reason, ":")
+	}
+	return verdict
+}
+
+// botUserAgentPattern User-Agent 关键字（小写）及对应的机器人名称
+type botUserAgentPattern struct {
+	keyword string
+	name    string
+}
+
+var (
+	// 链接预览抓取，聊天软件收到链接时会自动访问
+	linkPreviewUserAgents = []botUserAgentPattern{
+		{"whatsapp", "WhatsApp"}, {"telegrambot", "TelegramBot"}, {"discordbot", "Discordbot"},
+		{"skypeuripreview", "SkypeUriPreview"}, {"linkedinbot", "LinkedInBot"}, {"slack-imgproxy", "Slack"},
+		{"embedly", "Embedly"}, {"iframely", "Iframely"}, {"bitlypreview", "Bitly"},
+	}
+	// 无头浏览器和 HTTP 客户端库
+	automationUserAgents = []botUserAgentPattern{
+		{"headlesschrome", "HeadlessChrome"}, {"phantomjs", "PhantomJS"}, {"puppeteer", "Puppeteer"},
+		{"playwright", "Playwright"}, {"selenium", "Selenium"}, {"python-requests", "python-requests"},
+		{"python-urllib", "python-urllib"}, {"aiohttp", "aiohttp"}, {"curl/", "curl"}, {"wget/", "Wget"},
+		{"go-http-client", "Go-http-client"}, {"okhttp", "OkHttp"}, {"java/", "Java"}, {"libwww-perl", "libwww-perl"},
+		{"scrapy", "Scrapy"}, {"node-fetch", "node-fetch"}, {"axios/", "axios"}, {"apache-httpclient", "Apache-HttpClient"},
+	}
+	// 邮件安全网关，会访问邮件中的每一个链接
+	emailScannerUserAgents = []botUserAgentPattern{
+		{"barracuda", "Barracuda"}, {"mimecast", "Mimecast"}, {"proofpoint", "Proofpoint"},
+		{"safelinks", "Microsoft Safe Links"}, {"trendmicro", "Trend Micro"}, {"trend micro", "Trend Micro"},
+		{"symantec", "Symantec"}, {"fireeye", "FireEye"}, {"forcepoint", "Forcepoint"}, {"ironport", "Cisco IronPort"},
+		{"zscaler", "Zscaler"}, {"sophos", "Sophos"}, {"bitdefender", "Bitdefender"}, {"cloudmark", "Cloudmark"},
+	}
+)
+
+func matchBotUserAgent(userAgent string, patterns []botUserAgentPattern) (botUserAgentPattern, bool) {
+	lower := strings.ToLower(userAgent)
+	for _, pattern := range patterns {
+		if strings.Contains(lower, pattern.keyword) {
+			return pattern, true
+		}
+	}
+	return botUserAgentPattern{}, false
+}
+
+// userAgentBotClassifier 按 User-Agent 识别已知爬虫、链接预览和自动化工具
+type userAgentBotClassifier struct{}
+
+func (userAgentBotClassifier) Classify(signals *BotSignals) *BotSignal {
+	if strings.TrimSpace(signals.UserAgent) == "" {
+		return &BotSignal{Score: 60, Reason: "empty_user_agent"}
+	}
+	if metadata := parseTrafficMetadata(signals.UserAgent); metadata.IsBot {
+		return &BotSignal{Score: maxBotScore, Reason: "user_agent:" + metadata.BotName, Name: metadata.BotName}
+	}
+	if pattern, ok := matchBotUserAgent(signals.UserAgent, linkPreviewUserAgents); ok {
+		return &BotSignal{Score: maxBotScore, Reason: "link_preview:" + pattern.name, Name: pattern.name}
+	}
+	if pattern, ok := matchBotUserAgent(signals.UserAgent, automationUserAgents); ok {
+		return &BotSignal{Score: 90, Reason: "automation:" + pattern.name, Name: pattern.name}
+	}
+	return nil
+}
+
+// scannerBotClassifier 按 User-Agent 关键字或网关 IP 段识别邮件安全网关
+type scannerBotClassifier struct {
+	patterns []botUserAgentPattern
+	networks []*net.IPNet
+}
+
+func newScannerBotClassifier(userAgents, cidrs []string) scannerBotClassifier {
+	patterns := append([]botUserAgentPattern{}, emailScannerUserAgents...)
+	for _, keyword := range userAgents {
+		if keyword = strings.TrimSpace(keyword); keyword != "" {
+			patterns = append(patterns, botUserAgentPattern{keyword: strings.ToLower(keyword), name: keyword})
+		}
+	}
+	return scannerBotClassifier{patterns: patterns, networks: parseBotCIDRs(cidrs)}
+}
+
+func (c scannerBotClassifier) Classify(signals *BotSignals) *BotSignal {
+	if pattern, ok := matchBotUserAgent(signals.UserAgent, c.patterns); ok {
+		return &BotSignal{Score: maxBotScore, Reason: "email_scanner:" + pattern.name, Name: pattern.name}
+	}
+	if network := matchBotNetwork(signals.ClientIP, c.networks); network != nil {
+		return &BotSignal{Score: maxBotScore, Reason: "email_scanner:" + network.String()}
+	}
+	return nil
+}
+
+// datacenterBotClassifier 来自数据中心、云主机 IP 段的访问
+type datacenterBotClassifier struct {
+	networks []*net.IPNet
+}
+
+func newDatacenterBotClassifier(cidrs []string) datacenterBotClassifier {
+	return datacenterBotClassifier{networks: parseBotCIDRs(cidrs)}
+}
+
+func (c datacenterBotClassifier) Classify(signals *BotSignals) *BotSignal {
+	if network := matchBotNetwork(signals.ClientIP, c.networks); network != nil {
+		return &BotSignal{Score: 50, Reason: "datacenter:" + network.String()}
+	}
+	return nil
+}
+
+// parseBotCIDRs 解析 CIDR 列表，也接受单个 IP，忽略无法解析的项
+func parseBotCIDRs(cidrs []string) []*net.IPNet {
+	var networks []*net.IPNet
+	for _, value := range cidrs {
+		for _, cidr := range strings.Split(value, ",") {
+			cidr = strings.TrimSpace(cidr)
+			if cidr == "" {
+				continue
+			}
+			if !strings.Contains(cidr, "/") {
+				if strings.Contains(cidr, ":") {
+					cidr += "/128"
+				} else {
+					cidr += "/32"
+				}
+			}
+			if _, network, err := net.ParseCIDR(cidr); err == nil {
+				networks = append(networks, network)
+			}
+		}
+	}
+	return networks
+}
+
+func matchBotNetwork(ip string, networks []*net.IPNet) *net.IPNet {
+	if len(networks) == 0 {
+		return nil
+	}
+	parsed := net.ParseIP(strings.TrimSpace(ip))
+	if parsed == nil {
+		return nil
+	}
+	for _, network := range networks {
+		if network.Contains(parsed) {
+			return network
+		}
+	}
+	return nil
+}
+
+// headerBotClassifier 浏览器正常访问都会携带 Accept、Accept-Language，预取和预览请求带有用途标记
+type headerBotClassifier struct{}
+
+func (headerBotClassifier) Classify(signals *BotSignals) *BotSignal {
+	if signals.Headers == nil {
+		return nil
+	}
+	for _, name := range []string{"Sec-Purpose", "Purpose", "X-Purpose", "X-Moz"} {
+		value := strings.ToLower(signals.Headers.Get(name))
+		if strings.Contains(value, "prefetch") || strings.Contains(value, "preview") {
+			return &BotSignal{Score: 60, Reason: "prefetch"}
+		}
+	}
+	signal := &BotSignal{}
+	var reasons []string
+	if strings.TrimSpace(signals.Headers.Get("Accept-Language")) == "" {
+		signal.Score += 30
+		reasons = append(reasons, "missing_accept_language")
+	}
+	if strings.TrimSpace(signals.Headers.Get("Accept")) == "" {
+		signal.Score += 20
+		reasons = append(reasons, "missing_accept")
+	}
+	if signal.Score == 0 {
+		return nil
+	}
+	signal.Reason = strings.Join(reasons, ",")
+	return signal
+}
+
+// botVelocityRecord 一个 IP 在当前窗口内的点击数
+type botVelocityRecord struct {
+	Count       int
+	WindowStart int64
+}
+
+// velocityBotClassifier 同一 IP 短时间内大量点击，超过上限计 40 分，超过三倍计 70 分。
+// 按固定时间窗口计数：连接 Redis 时用 INCR 原子累加，多个实例合并计算；否则在本实例内计数。
+type velocityBotClassifier struct {
+	helper    interfaces.HelperInterface
+	window    time.Duration
+	maxClicks int
+
+	mu    sync.Mutex
+	local map[string]botVelocityRecord
+}
+
+func (c *velocityBotClassifier) Classify(signals *BotSignals) *BotSignal {
+	windowSeconds := int64(c.window.Seconds())
+	if c.maxClicks <= 0 || windowSeconds <= 0 || signals.ClientIP == "" {
+		return nil
+	}
+	windowStart := signals.At.Unix() - signals.At.Unix()%windowSeconds
+	count, err := c.incrRedis(signals.ClientIP, windowStart)
+	if err != nil {
+		count = c.incrLocal(signals.ClientIP, windowStart)
+	}
+
+	reason := fmt.Sprintf("velocity:%d/%ds", count, windowSeconds)
+	switch {
+	case count > c.maxClicks*3:
+		return &BotSignal{Score: 70, Reason: reason}
+	case count > c.maxClicks:
+		return &BotSignal{Score: 40, Reason: reason}
+	}
+	return nil
+}
+
+// incrRedis 在 Redis 中累加当前窗口的点击数，窗口结束后键自动过期
+func (c *velocityBotClassifier) incrRedis(ip string, windowStart int64) (int, error) {
+	client := c.helper.GetRedis()
+	if client == nil {
+		return 0, errors.New("redis unavailable")
+	}
+	ctx := context.Background()
+	key := fmt.Sprintf("%s%s:%d", botVelocityRedisKey, ip, windowStart)
+	pipe := client.TxPipeline()
+	incr := pipe.Incr(ctx, key)
+	pipe.Expire(ctx, key, c.window)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, err
+	}
+	return int(incr.Val()), nil
+}
+
+func (c *velocityBotClassifier) incrLocal(ip string, windowStart int64) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	record := c.local[ip]
+	if record.WindowStart != windowStart {
+		if len(c.local) >= botVelocityLocalMaxKeys {
+			for key, existing := range c.local {
+				if existing.WindowStart < windowStart {
+					delete(c.local, key)
+				}
+			}
+		}
+		record = botVelocityRecord{WindowStart: windowStart}
+	}
+	record.Count++
+	c.local[ip] = record
+	return record.Count
+}
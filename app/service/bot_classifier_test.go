@@ -0,0 +1,185 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/dto"
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/model"
+	"cnb.cool/mliev/dwz/dwz-server/v2/pkg/interfaces"
+)
+
+const testBrowserUserAgent = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36"
+
+func browserHeaders() http.Header {
+	return http.Header{
+		"User-Agent":      {testBrowserUserAgent},
+		"Accept":          {"text/html,application/xhtml+xml"},
+		"Accept-Language": {"zh-CN,zh;q=0.9"},
+	}
+}
+
+type fixedBotClassifier struct{}
+
+func (fixedBotClassifier) Classify(signals *BotSignals) *BotSignal {
+	if signals.ClientIP == "192.0.2.99" {
+		return &BotSignal{Score: 65, Reason: "custom", Name: "Custom"}
+	}
+	return nil
+}
+
+func TestBotDetectorClassify(t *testing.T) {
+	helper := newShortLinkRegressionHelper(t)
+	helper.settings["bot_detection.datacenter_cidrs"] = []string{"198.51.100.0/24"}
+	helper.settings["bot_detection.scanner_cidrs"] = []string{"203.0.113.5"}
+	helper.settings["bot_detection.velocity_max_clicks"] = 2
+
+	RegisterBotClassifier(func(interfaces.HelperInterface) BotClassifier { return fixedBotClassifier{} })
+	defer func() { botClassifierFactories = nil }()
+	detector := NewBotDetector(helper)
+	if NewBotDetector(helper) != detector {
+		t.Fatal("detector must be reused while the configuration is unchanged")
+	}
+	at := time.Date(2026, 10, 19, 10, 0, 30, 0, time.UTC)
+	classify := func(ip, userAgent string, headers http.Header) BotVerdict {
+		return detector.Classify(&BotSignals{ClientIP: ip, UserAgent: userAgent, Headers: headers, At: at})
+	}
+
+	if verdict := classify("10.0.0.1", testBrowserUserAgent, browserHeaders()); verdict.IsBot || verdict.Score != 0 || verdict.Reason != "" {
+		t.Fatalf("browser: %+v", verdict)
+	}
+	if verdict := classify("10.0.0.2", "Googlebot/2.1 (+http://www.google.com/bot.html)", nil); !verdict.IsBot || verdict.Score != 100 || !strings.HasPrefix(verdict.Reason, "user_agent:") {
+		t.Fatalf("crawler: %+v", verdict)
+	}
+	if verdict := classify("10.0.0.3", "Mozilla/5.0 HeadlessChrome/120.0.0.0", nil); !verdict.IsBot || verdict.Reason != "automation:HeadlessChrome" {
+		t.Fatalf("headless: %+v", verdict)
+	}
+	if verdict := classify("10.0.0.4", "Mozilla/5.0 (compatible; Mimecast URL Protect)", nil); !verdict.IsBot || verdict.BotName != "Mimecast" {
+		t.Fatalf("scanner user agent: %+v", verdict)
+	}
+	if verdict := classify("203.0.113.5", testBrowserUserAgent, browserHeaders()); !verdict.IsBot || verdict.Reason != "email_scanner:203.0.113.5/32" {
+		t.Fatalf("scanner network: %+v", verdict)
+	}
+
+	// 数据中心 IP 单独不足以判定，缺少常见请求头时达到阈值
+	if verdict := classify("198.51.100.7", testBrowserUserAgent, browserHeaders()); verdict.IsBot || verdict.Score != 50 {
+		t.Fatalf("datacenter with browser headers: %+v", verdict)
+	}
+	headers := browserHeaders()
+	headers.Del("Accept-Language")
+	verdict := classify("198.51.100.8", testBrowserUserAgent, headers)
+	if !verdict.IsBot || verdict.Score != 80 || verdict.Reason != "datacenter:198.51.100.0/24,missing_accept_language" || verdict.BotName != "datacenter" {
+		t.Fatalf("datacenter without accept-language: %+v", verdict)
+	}
+	headers = browserHeaders()
+	headers.Set("Sec-Purpose", "prefetch;prerender")
+	if verdict := classify("10.0.0.5", testBrowserUserAgent, headers); !verdict.IsBot || verdict.Reason != "prefetch" {
+		t.Fatalf("prefetch: %+v", verdict)
+	}
+
+	for i := 1; i <= 7; i++ {
+		verdict := classify("10.0.0.6", testBrowserUserAgent, browserHeaders())
+		switch {
+		case i <= 2 && verdict.Score != 0,
+			i == 3 && (verdict.Score != 40 || verdict.Reason != "velocity:3/60s"),
+			i == 7 && (verdict.Score != 70 || !verdict.IsBot):
+			t.Fatalf("velocity click %d: %+v", i, verdict)
+		}
+	}
+
+	// 下一个窗口重新计数
+	if verdict := detector.Classify(&BotSignals{ClientIP: "10.0.0.6", UserAgent: testBrowserUserAgent, Headers: browserHeaders(), At: at.Add(time.Minute)}); verdict.Score != 0 {
+		t.Fatalf("velocity must reset in the next window: %+v", verdict)
+	}
+	// 并发点击逐一计数，不会因读写交错而少计
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			classify("10.0.0.7", testBrowserUserAgent, browserHeaders())
+		}()
+	}
+	wg.Wait()
+	if verdict := classify("10.0.0.7", testBrowserUserAgent, browserHeaders()); verdict.Reason != "velocity:51/60s" {
+		t.Fatalf("concurrent velocity count: %+v", verdict)
+	}
+
+	if verdict := classify("192.0.2.99", testBrowserUserAgent, browserHeaders()); !verdict.IsBot || verdict.BotName != "Custom" {
+		t.Fatalf("registered classifier: %+v", verdict)
+	}
+
+	helper.settings["bot_detection.velocity_max_clicks"] = 5
+	if NewBotDetector(helper) == detector {
+		t.Fatal("detector must be rebuilt after the configuration changes")
+	}
+}
+
+func TestBotDetectionOnRedirect(t *testing.T) {
+	helper := newShortLinkRegressionHelper(t)
+	helper.settings["bot_detection.datacenter_cidrs"] = []string{"198.51.100.0/24"}
+	db := helper.GetDatabase()
+	seedBatchShortLinkDomain(t, db)
+	shortLinkSvc := NewShortLinkService(helper, context.Background())
+	create := func(code string, security *dto.LinkSecurityRequest) *dto.ShortLinkResponse {
+		link, err := shortLinkSvc.CreateShortLinkInWorkspace(&dto.CreateShortLinkRequest{
+			OriginalURL: "https://example.com/" + code,
+			Domain:      "batch.dwz.do",
+			CustomCode:  code,
+			Security:    security,
+		}, "", 1, 7)
+		if err != nil {
+			t.Fatalf("create %s: %v", code, err)
+		}
+		return link
+	}
+	guarded := create("guarded", &dto.LinkSecurityRequest{BotPolicy: model.LinkBotPolicyBlockKnownBots})
+	open := create("open", nil)
+
+	suspicious := browserHeaders()
+	suspicious.Del("Accept-Language")
+	if _, err := shortLinkSvc.ResolveRedirectWithHeaders("batch.dwz.do", "guarded", "198.51.100.8", "", "", "", suspicious); !errors.Is(err, ErrSecurityAccessDenied) {
+		t.Fatalf("behavioral bot must be blocked: %v", err)
+	}
+	var event model.LinkSecurityEvent
+	db.Where("short_link_id = ? AND event_type = ?", guarded.ID, model.SecurityEventBotBlocked).First(&event)
+	if !strings.Contains(event.Reason, "datacenter") {
+		t.Fatalf("blocked event must carry the reason: %+v", event)
+	}
+	if _, err := shortLinkSvc.ResolveRedirectWithHeaders("batch.dwz.do", "guarded", "10.0.0.1", "", "", "", browserHeaders()); err != nil {
+		t.Fatalf("browser must pass: %v", err)
+	}
+
+	if _, err := shortLinkSvc.ResolveRedirectWithHeaders("batch.dwz.do", "open", "198.51.100.8", "", "", "", suspicious); err != nil {
+		t.Fatalf("record-only link must redirect: %v", err)
+	}
+	if _, err := shortLinkSvc.ResolveRedirectWithHeaders("batch.dwz.do", "open", "10.0.0.1", "", "", "", browserHeaders()); err != nil {
+		t.Fatalf("redirect: %v", err)
+	}
+	waitForModelCount(t, db, &model.ClickStatistic{}, "short_link_id = ?", open.ID, 2)
+	var bot model.ClickStatistic
+	db.Where("short_link_id = ? AND ip = ?", open.ID, "198.51.100.8").First(&bot)
+	if !bot.IsBot || bot.BotScore != 80 || bot.BotName != "datacenter" || bot.BotReason != "datacenter:198.51.100.0/24,missing_accept_language" {
+		t.Fatalf("click must store bot score and reason: %+v", bot)
+	}
+
+	statisticSvc := NewClickStatisticService(helper)
+	minScore, maxScore := 50, 0
+	list, err := statisticSvc.GetClickStatisticListInWorkspace(&dto.ClickStatisticListRequest{Page: 1, PageSize: 10, ShortLinkID: open.ID, MinBotScore: &minScore}, 1)
+	if err != nil || list.Total != 1 || list.List[0].BotScore != 80 || list.List[0].BotReason == "" {
+		t.Fatalf("min_bot_score filter: %+v err=%v", list, err)
+	}
+	list, err = statisticSvc.GetClickStatisticListInWorkspace(&dto.ClickStatisticListRequest{Page: 1, PageSize: 10, ShortLinkID: open.ID, MaxBotScore: &maxScore}, 1)
+	if err != nil || list.Total != 1 || list.List[0].IP != "10.0.0.1" {
+		t.Fatalf("max_bot_score filter: %+v err=%v", list, err)
+	}
+	list, err = statisticSvc.GetClickStatisticListInWorkspace(&dto.ClickStatisticListRequest{Page: 1, PageSize: 10, BotReason: "missing_accept"}, 1)
+	if err != nil || list.Total != 1 {
+		t.Fatalf("bot_reason filter: %+v err=%v", list, err)
+	}
+}
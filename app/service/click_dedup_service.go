@@ -0,0 +1,113 @@
+package service
+
+import (
+	"context"
+	"hash/fnv"
+	"strconv"
+	"sync"
+	"time"
+
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/dao"
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/model"
+	"cnb.cool/mliev/dwz/dwz-server/v2/pkg/interfaces"
+)
+
+const (
+	clickDedupCacheKey = "workspace_click_dedup_seconds:"
+	clickDedupCacheTTL = 5 * time.Minute
+)
+
+// clickDedupLocks 按短网址和访客分段加锁，避免同一访客并发点击时都判定为首次点击。
+// 仅在进程内生效，多实例部署时同一瞬间的两次点击仍可能都被计入。
+var clickDedupLocks [64]sync.Mutex
+
+// ClickDedupService 识别去重窗口内同一访客对同一短网址的重复点击
+type ClickDedupService struct {
+	helper            interfaces.HelperInterface
+	workspaceDao      *dao.WorkspaceDao
+	clickStatisticDao *dao.ClickStatisticDao
+	shortLinkDao      *dao.ShortLinkDao
+}
+
+func NewClickDedupService(helper interfaces.HelperInterface) *ClickDedupService {
+	return &ClickDedupService{
+		helper:            helper,
+		workspaceDao:      dao.NewWorkspaceDao(helper),
+		clickStatisticDao: dao.NewClickStatisticDao(helper),
+		shortLinkDao:      dao.NewShortLinkDao(helper),
+	}
+}
+
+// Window 工作区的去重窗口，工作区未设置时使用服务端默认值，0 表示不去重
+func (s *ClickDedupService) Window(workspaceID uint64) time.Duration {
+	seconds := s.workspaceSeconds(workspaceID)
+	if seconds <= 0 {
+		seconds = s.helper.GetConfig().GetInt("analytics.click_dedup_seconds", 0)
+	}
+	if seconds <= 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// workspaceSeconds 工作区设置的去重秒数，结果写入缓存，避免每次点击都查询工作区
+func (s *ClickDedupService) workspaceSeconds(workspaceID uint64) int {
+	ctx := context.Background()
+	cache := s.helper.GetCache()
+	key := clickDedupCacheKey + strconv.FormatUint(workspaceID, 10)
+	var seconds int
+	if cache != nil && cache.Get(ctx, key, &seconds) == nil {
+		return seconds
+	}
+	workspace, err := s.workspaceDao.FindByID(workspaceID)
+	if err != nil {
+		return 0
+	}
+	if cache != nil {
+		_ = cache.Set(ctx, key, workspace.ClickDedupSeconds, clickDedupCacheTTL)
+	}
+	return workspace.ClickDedupSeconds
+}
+
+// ForgetWindow 工作区去重设置变更后清除缓存
+func (s *ClickDedupService) ForgetWindow(workspaceID uint64) {
+	if cache := s.helper.GetCache(); cache != nil {
+		_ = cache.Del(context.Background(), clickDedupCacheKey+strconv.FormatUint(workspaceID, 10))
+	}
+}
+
+// Record 标记是否为重复点击后写入点击记录，并累加短网址的点击次数或重复点击次数。
+// 访客标识为空时无法识别访客，按非重复点击处理。
+func (s *ClickDedupService) Record(statistic *model.ClickStatistic) error {
+	window := time.Duration(0)
+	if statistic.VisitorKey != "" {
+		window = s.Window(statistic.WorkspaceID)
+	}
+	var err error
+	if window <= 0 {
+		err = s.clickStatisticDao.Create(statistic)
+	} else {
+		err = s.recordWithinWindow(statistic, window)
+	}
+	if statistic.IsDuplicate {
+		s.shortLinkDao.IncrementDuplicateClickCount(statistic.ShortLinkID)
+	} else {
+		s.shortLinkDao.IncrementClickCount(statistic.ShortLinkID)
+	}
+	return err
+}
+
+func (s *ClickDedupService) recordWithinWindow(statistic *model.ClickStatistic, window time.Duration) error {
+	hash := fnv.New32a()
+	hash.Write([]byte(strconv.FormatUint(statistic.ShortLinkID, 10) + "|" + statistic.VisitorKey))
+	lock := &clickDedupLocks[hash.Sum32()%uint32(len(clickDedupLocks))]
+	lock.Lock()
+	defer lock.Unlock()
+
+	exists, err := s.clickStatisticDao.ExistsRecentVisitorClick(statistic.ShortLinkID, statistic.VisitorKey, statistic.ClickDate.Add(-window))
+	if err != nil {
+		s.helper.GetLogger().Error("查询重复点击失败: " + err.Error())
+	}
+	statistic.IsDuplicate = exists
+	return s.clickStatisticDao.Create(statistic)
+}
@@ -0,0 +1,85 @@
+package service
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/dto"
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/model"
+)
+
+func TestClickDedupWindow(t *testing.T) {
+	helper := newShortLinkRegressionHelper(t)
+	db := helper.GetDatabase()
+	seedBatchShortLinkDomain(t, db)
+	workspace := model.Workspace{ID: 1, Slug: "default", Name: "Default", Status: 1, ClickDedupSeconds: 30}
+	if err := db.Create(&workspace).Error; err != nil {
+		t.Fatalf("seed workspace: %v", err)
+	}
+	shortLinkSvc := NewShortLinkService(helper, context.Background())
+	link, err := shortLinkSvc.CreateShortLinkInWorkspace(&dto.CreateShortLinkRequest{
+		OriginalURL: "https://example.com/dedup",
+		Domain:      "batch.dwz.do",
+		CustomCode:  "dedup",
+	}, "", 1, 7)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	redirect := func(ip string, want int64) {
+		t.Helper()
+		if _, err := shortLinkSvc.ResolveRedirectWithHeaders("batch.dwz.do", "dedup", ip, "", "", "", browserHeaders()); err != nil {
+			t.Fatalf("redirect: %v", err)
+		}
+		waitForModelCount(t, db, &model.ShortLink{}, "id = ? AND click_count + duplicate_click_count >= "+strconv.FormatInt(want, 10), link.ID, 1)
+	}
+	redirect("10.0.0.1", 1)
+	redirect("10.0.0.1", 2)
+	redirect("10.0.0.2", 3)
+
+	var stored model.ShortLink
+	db.First(&stored, link.ID)
+	if stored.ClickCount != 2 || stored.DuplicateClickCount != 1 {
+		t.Fatalf("click counters: click=%d duplicate=%d", stored.ClickCount, stored.DuplicateClickCount)
+	}
+	var duplicates []model.ClickStatistic
+	db.Where("short_link_id = ? AND is_duplicate = ?", link.ID, true).Find(&duplicates)
+	if len(duplicates) != 1 || duplicates[0].IP != "10.0.0.1" {
+		t.Fatalf("only the repeated click must be a duplicate: %+v", duplicates)
+	}
+
+	statisticSvc := NewClickStatisticService(helper)
+	list, err := statisticSvc.GetClickStatisticListInWorkspace(&dto.ClickStatisticListRequest{Page: 1, PageSize: 10, ShortLinkID: link.ID}, 1)
+	if err != nil || list.Total != 2 {
+		t.Fatalf("duplicates must be excluded by default: %+v err=%v", list, err)
+	}
+	list, err = statisticSvc.GetClickStatisticListInWorkspace(&dto.ClickStatisticListRequest{Page: 1, PageSize: 10, ShortLinkID: link.ID, WithDuplicates: true}, 1)
+	if err != nil || list.Total != 3 {
+		t.Fatalf("include_duplicates: %+v err=%v", list, err)
+	}
+	stats, err := shortLinkSvc.GetShortLinkStatisticsInWorkspace(link.ID, 7, 1, time.Local, false)
+	if err != nil || stats.TotalClicks != 2 || stats.TodayClicks != 2 || stats.DuplicateClicks != 1 {
+		t.Fatalf("statistics: %+v err=%v", stats, err)
+	}
+	stats, err = shortLinkSvc.GetShortLinkStatisticsInWorkspace(link.ID, 7, 1, time.Local, true)
+	if err != nil || stats.TotalClicks != 3 || stats.TodayClicks != 3 {
+		t.Fatalf("statistics with duplicates: %+v err=%v", stats, err)
+	}
+
+	// 去重设置按工作区缓存，直接修改数据库不会生效
+	db.Model(&model.Workspace{}).Where("id = ?", 1).Update("click_dedup_seconds", 0)
+	if window := NewClickDedupService(helper).Window(1); window != 30*time.Second {
+		t.Fatalf("window must be served from cache: %s", window)
+	}
+	// 通过接口关闭去重后同一访客的点击不再标记
+	disabled := 0
+	if _, err := NewWorkspaceService(helper).UpdateWorkspace(1, &dto.UpdateWorkspaceRequest{Name: "Default", ClickDedupSeconds: &disabled}); err != nil {
+		t.Fatalf("disable dedup: %v", err)
+	}
+	redirect("10.0.0.1", 4)
+	db.First(&stored, link.ID)
+	if stored.ClickCount != 3 || stored.DuplicateClickCount != 1 {
+		t.Fatalf("dedup disabled: click=%d duplicate=%d", stored.ClickCount, stored.DuplicateClickCount)
+	}
+}
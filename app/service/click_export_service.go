@@ -0,0 +1,431 @@
+package service
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/rand"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/dao"
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/dto"
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/model"
+	"cnb.cool/mliev/dwz/dwz-server/v2/pkg/interfaces"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultClickExportChunkSize    = 5000
+	defaultClickExportLinkTTLHours = 24
+	defaultClickExportMaxActive    = 3
+	defaultClickExportStaleMinutes = 10
+	clickExportListLimit           = 50
+	clickExportDownloadPath        = "/api/v1/public/click_exports/"
+)
+
+var (
+	ErrClickExportNotFound       = errors.New("导出任务不存在")
+	ErrClickExportFormat         = errors.New("不支持的导出格式，可选 csv 或 ndjson")
+	ErrClickExportTooManyActive  = errors.New("进行中的导出任务过多，请稍后再试")
+	ErrClickExportNotCancellable = errors.New("导出任务已结束，无法取消")
+	ErrClickExportLinkExpired    = errors.New("下载链接不存在或已过期")
+)
+
+// ClickExportService 点击明细异步导出
+type ClickExportService struct {
+	helper            interfaces.HelperInterface
+	exportDao         *dao.ClickExportDao
+	clickStatisticDao *dao.ClickStatisticDao
+	clickStatisticSvc *ClickStatisticService
+}
+
+func NewClickExportService(helper interfaces.HelperInterface) *ClickExportService {
+	return &ClickExportService{
+		helper:            helper,
+		exportDao:         dao.NewClickExportDao(helper),
+		clickStatisticDao: dao.NewClickStatisticDao(helper),
+		clickStatisticSvc: NewClickStatisticService(helper),
+	}
+}
+
+// Create 创建导出任务，只导出创建时已存在的点击
+func (s *ClickExportService) Create(workspaceID, userID uint64, format string, req *dto.ClickStatisticListRequest) (*dto.ClickExportResponse, error) {
+	if format == "" {
+		format = model.ClickExportFormatCSV
+	}
+	if format != model.ClickExportFormatCSV && format != model.ClickExportFormatNDJSON {
+		return nil, ErrClickExportFormat
+	}
+	maxActive := s.helper.GetConfig().GetInt("analytics.export_max_active", defaultClickExportMaxActive)
+	if maxActive > 0 {
+		active, err := s.exportDao.CountActiveInWorkspace(workspaceID)
+		if err != nil {
+			return nil, err
+		}
+		if active >= int64(maxActive) {
+			return nil, ErrClickExportTooManyActive
+		}
+	}
+
+	// 文件夹在创建时展开，之后文件夹调整不影响导出范围
+	filters := *req
+	filters.Page, filters.PageSize = 0, 0
+	s.clickStatisticSvc.expandFolderFilter(workspaceID, &filters)
+	encoded, err := json.Marshal(filters)
+	if err != nil {
+		return nil, err
+	}
+	maxClickID, err := s.clickStatisticDao.GetMaxID()
+	if err != nil {
+		return nil, err
+	}
+	job := &model.ClickExportJob{
+		WorkspaceID: workspaceID,
+		UserID:      userID,
+		Format:      format,
+		Filters:     string(encoded),
+		Status:      model.ClickExportStatusPending,
+		MaxClickID:  maxClickID,
+	}
+	if err := s.exportDao.Create(job); err != nil {
+		return nil, err
+	}
+	return s.toResponse(job), nil
+}
+
+func (s *ClickExportService) Get(id, workspaceID uint64) (*dto.ClickExportResponse, error) {
+	job, err := s.exportDao.FindByID(id, workspaceID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrClickExportNotFound
+		}
+		return nil, err
+	}
+	return s.toResponse(job), nil
+}
+
+// List 最近的导出任务
+func (s *ClickExportService) List(workspaceID uint64) (*dto.ClickExportListResponse, error) {
+	jobs, err := s.exportDao.ListInWorkspace(workspaceID, clickExportListLimit)
+	if err != nil {
+		return nil, err
+	}
+	response := &dto.ClickExportListResponse{List: make([]dto.ClickExportResponse, 0, len(jobs))}
+	for i := range jobs {
+		response.List = append(response.List, *s.toResponse(&jobs[i]))
+	}
+	return response, nil
+}
+
+// Cancel 取消任务，执行中的任务在写完当前批次后停止并删除文件
+func (s *ClickExportService) Cancel(id, workspaceID uint64) (*dto.ClickExportResponse, error) {
+	if _, err := s.Get(id, workspaceID); err != nil {
+		return nil, err
+	}
+	cancelled, err := s.exportDao.Cancel(id, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	if !cancelled {
+		return nil, ErrClickExportNotCancellable
+	}
+	return s.Get(id, workspaceID)
+}
+
+// Download 按下载令牌返回可下载的导出任务，文件内容由 WriteChunk 逐段写出
+func (s *ClickExportService) Download(token string) (*model.ClickExportJob, error) {
+	if token == "" {
+		return nil, ErrClickExportLinkExpired
+	}
+	job, err := s.exportDao.FindByToken(token)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrClickExportLinkExpired
+		}
+		return nil, err
+	}
+	if job.FileName == "" || job.ExpiresAt == nil || !job.ExpiresAt.After(time.Now()) {
+		return nil, ErrClickExportLinkExpired
+	}
+	return job, nil
+}
+
+// WriteChunk 将序号大于 afterSeq 的下一个分段写入 w 并返回其序号，全部写完时返回 io.EOF。
+// 每个分段都是完整的 gzip 成员，按序号依次写出即为完整文件，无需整体读入内存
+func (s *ClickExportService) WriteChunk(w io.Writer, jobID uint64, afterSeq int) (int, error) {
+	chunk, err := s.exportDao.NextChunk(jobID, afterSeq)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return afterSeq, io.EOF
+		}
+		return afterSeq, err
+	}
+	if _, err := w.Write(chunk.Data); err != nil {
+		return afterSeq, err
+	}
+	return chunk.Seq, nil
+}
+
+// ProcessPending 依次执行待执行的任务和心跳超时（执行实例已退出）的任务，返回导出的行数。
+// ctx 取消时在当前批次写完后停止，任务交由下次领取继续导出
+func (s *ClickExportService) ProcessPending(ctx context.Context) (int64, error) {
+	staleMinutes := s.helper.GetConfig().GetInt("analytics.export_stale_minutes", defaultClickExportStaleMinutes)
+	var exported int64
+	for ctx.Err() == nil {
+		job, err := s.exportDao.Claim(time.Now().Add(-time.Duration(staleMinutes) * time.Minute))
+		if err != nil || job == nil {
+			return exported, err
+		}
+		rows, err := s.run(ctx, job)
+		exported += rows
+		if err != nil {
+			s.fail(job, err)
+		}
+	}
+	return exported, nil
+}
+
+// PurgeFiles 删除下载链接已过期、已取消或失败任务的文件
+func (s *ClickExportService) PurgeFiles() (int64, error) {
+	jobs, err := s.exportDao.ListFinishedWithFiles(time.Now(), 100)
+	if err != nil {
+		return 0, err
+	}
+	var purged int64
+	for i := range jobs {
+		if err := s.exportDao.DeleteChunks(jobs[i].ID); err != nil {
+			return purged, err
+		}
+		if err := s.exportDao.MarkFileRemoved(&jobs[i]); err != nil {
+			return purged, err
+		}
+		purged++
+	}
+	return purged, nil
+}
+
+// run 从上次记录的进度继续导出，每批写入一个 gzip 分段并在同一事务中记录进度
+func (s *ClickExportService) run(ctx context.Context, job *model.ClickExportJob) (int64, error) {
+	var req dto.ClickStatisticListRequest
+	if err := json.Unmarshal([]byte(job.Filters), &req); err != nil {
+		return 0, errors.New("导出筛选条件无效")
+	}
+	customFields, err := s.clickStatisticSvc.customFieldDao.ListInWorkspace(job.WorkspaceID)
+	if err != nil {
+		return 0, err
+	}
+	if job.FileName == "" {
+		if owned, err := s.writeHeader(job, customFields); err != nil || !owned {
+			return 0, s.abandon(job, err)
+		}
+	}
+	seq, err := s.exportDao.NextChunkSeq(job.ID)
+	if err != nil {
+		return 0, err
+	}
+
+	chunkSize := s.helper.GetConfig().GetInt("analytics.export_chunk_size", defaultClickExportChunkSize)
+	if chunkSize <= 0 {
+		chunkSize = defaultClickExportChunkSize
+	}
+	var exported int64
+	for {
+		if ctx.Err() != nil {
+			// 清空心跳，让任务可以立即被重新领取
+			_, err := s.exportDao.UpdateClaimed(job, map[string]any{"heartbeat_at": nil})
+			return exported, err
+		}
+		statistics, err := s.clickStatisticDao.ListExportChunkInWorkspace(job.WorkspaceID, &req, job.LastClickID, job.MaxClickID, chunkSize)
+		if err != nil {
+			return exported, err
+		}
+		if len(statistics) == 0 {
+			break
+		}
+		data, err := s.encode(job.Format, statistics, customFields)
+		if err != nil {
+			return exported, err
+		}
+		member, err := gzipMember(data)
+		if err != nil {
+			return exported, err
+		}
+		lastClickID := statistics[len(statistics)-1].ID
+		rowCount := job.RowCount + int64(len(statistics))
+		fileSize := job.FileSize + int64(len(member))
+		owned, err := s.exportDao.AppendChunk(job, &model.ClickExportChunk{JobID: job.ID, Seq: seq, Data: member}, map[string]any{
+			"last_click_id": lastClickID,
+			"row_count":     rowCount,
+			"file_size":     fileSize,
+			"heartbeat_at":  time.Now(),
+		})
+		if err != nil || !owned {
+			return exported, s.abandon(job, err)
+		}
+		job.LastClickID, job.RowCount, job.FileSize = lastClickID, rowCount, fileSize
+		seq++
+		exported += int64(len(statistics))
+		if len(statistics) < chunkSize {
+			break
+		}
+	}
+
+	token, err := newClickExportToken()
+	if err != nil {
+		return exported, err
+	}
+	ttlHours := s.helper.GetConfig().GetInt("analytics.export_link_ttl_hours", defaultClickExportLinkTTLHours)
+	now := time.Now()
+	owned, err := s.exportDao.UpdateClaimed(job, map[string]any{
+		"status":         model.ClickExportStatusCompleted,
+		"download_token": token,
+		"expires_at":     now.Add(time.Duration(ttlHours) * time.Hour),
+		"finished_at":    now,
+		"heartbeat_at":   now,
+	})
+	if err != nil || !owned {
+		return exported, s.abandon(job, err)
+	}
+	return exported, nil
+}
+
+// writeHeader 写入第一个分段：CSV 表头，NDJSON 为空分段
+func (s *ClickExportService) writeHeader(job *model.ClickExportJob, customFields []model.CustomField) (bool, error) {
+	var header []byte
+	if job.Format == model.ClickExportFormatCSV {
+		buffer := &bytes.Buffer{}
+		buffer.Write([]byte{0xEF, 0xBB, 0xBF})
+		writer := csv.NewWriter(buffer)
+		_ = writer.Write(clickStatisticCSVHeader(customFields))
+		writer.Flush()
+		header = buffer.Bytes()
+	}
+	member, err := gzipMember(header)
+	if err != nil {
+		return false, err
+	}
+	fileName := fmt.Sprintf("click-export-%d.%s.gz", job.ID, job.Format)
+	owned, err := s.exportDao.AppendChunk(job, &model.ClickExportChunk{JobID: job.ID, Seq: 0, Data: member}, map[string]any{
+		"file_name":     fileName,
+		"file_size":     len(member),
+		"last_click_id": 0,
+		"row_count":     0,
+	})
+	if err == nil && owned {
+		job.FileName, job.FileSize, job.LastClickID, job.RowCount = fileName, int64(len(member)), 0, 0
+	}
+	return owned, err
+}
+
+// encode 按导出格式编码一批点击
+func (s *ClickExportService) encode(format string, statistics []model.ClickStatistic, customFields []model.CustomField) ([]byte, error) {
+	var customValues map[uint64]map[uint64]string
+	if len(customFields) > 0 {
+		values, err := s.clickStatisticSvc.customValuesByShortLink(statistics)
+		if err != nil {
+			return nil, err
+		}
+		customValues = values
+	}
+
+	buffer := &bytes.Buffer{}
+	if format == model.ClickExportFormatNDJSON {
+		encoder := json.NewEncoder(buffer)
+		for i := range statistics {
+			record := dto.ClickStatisticExportRecord{ClickStatisticDetailResponse: *clickStatisticDetail(&statistics[i])}
+			if len(customFields) > 0 {
+				record.CustomFields = make(map[string]string, len(customFields))
+				for _, field := range customFields {
+					record.CustomFields[field.FieldKey] = customValues[statistics[i].ShortLinkID][field.ID]
+				}
+			}
+			if err := encoder.Encode(record); err != nil {
+				return nil, err
+			}
+		}
+		return buffer.Bytes(), nil
+	}
+
+	writer := csv.NewWriter(buffer)
+	for i := range statistics {
+		if err := writer.Write(clickStatisticCSVRecord(&statistics[i], customFields, customValues)); err != nil {
+			return nil, err
+		}
+	}
+	writer.Flush()
+	return buffer.Bytes(), writer.Error()
+}
+
+// abandon 任务不再归本实例所有时停止导出；任务已被取消则删除文件
+func (s *ClickExportService) abandon(job *model.ClickExportJob, err error) error {
+	if err != nil {
+		return err
+	}
+	current, findErr := s.exportDao.FindByID(job.ID, job.WorkspaceID)
+	if findErr == nil && current.Status == model.ClickExportStatusCancelled && current.FileName != "" {
+		if s.exportDao.DeleteChunks(job.ID) == nil {
+			_ = s.exportDao.MarkFileRemoved(current)
+		}
+	}
+	return nil
+}
+
+func (s *ClickExportService) fail(job *model.ClickExportJob, cause error) {
+	message := []rune(cause.Error())
+	if len(message) > 500 {
+		message = message[:500]
+	}
+	if _, err := s.exportDao.UpdateClaimed(job, map[string]any{
+		"status":      model.ClickExportStatusFailed,
+		"error":       string(message),
+		"finished_at": time.Now(),
+	}); err != nil {
+		s.helper.GetLogger().Error(fmt.Sprintf("更新导出任务 %d 状态失败: %s", job.ID, err.Error()))
+	}
+}
+
+func (s *ClickExportService) toResponse(job *model.ClickExportJob) *dto.ClickExportResponse {
+	response := &dto.ClickExportResponse{
+		ID:         job.ID,
+		Format:     job.Format,
+		Status:     job.Status,
+		RowCount:   job.RowCount,
+		FileSize:   job.FileSize,
+		Error:      job.Error,
+		ExpiresAt:  job.ExpiresAt,
+		StartedAt:  job.StartedAt,
+		FinishedAt: job.FinishedAt,
+		CreatedAt:  job.CreatedAt,
+	}
+	if job.Status == model.ClickExportStatusCompleted && job.DownloadToken != "" {
+		response.DownloadURL = clickExportDownloadPath + job.DownloadToken
+	}
+	return response
+}
+
+// gzipMember 将数据压缩为一个独立的 gzip 分段，多个分段拼接后仍是合法的 gzip 文件
+func gzipMember(data []byte) ([]byte, error) {
+	buffer := &bytes.Buffer{}
+	writer := gzip.NewWriter(buffer)
+	if _, err := writer.Write(data); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+func newClickExportToken() (string, error) {
+	random := make([]byte, 24)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(random), nil
+}
@@ -0,0 +1,187 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/dto"
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/model"
+)
+
+func TestClickExportJobsStreamResumeCancelAndExpire(t *testing.T) {
+	helper := newShortLinkRegressionHelper(t)
+	helper.settings["analytics.export_chunk_size"] = 2
+	db := helper.GetDatabase()
+	clickDate := time.Now().Add(-time.Hour)
+	for i := 0; i < 5; i++ {
+		seedClickStatistic(t, db, model.ClickStatistic{WorkspaceID: 1, ShortLinkID: 10, IP: "1.1.1.1", Country: "中国", ClickDate: clickDate})
+	}
+	seedClickStatistic(t, db, model.ClickStatistic{WorkspaceID: 1, ShortLinkID: 11, IP: "2.2.2.2", ClickDate: clickDate})
+	seedClickStatistic(t, db, model.ClickStatistic{WorkspaceID: 2, ShortLinkID: 10, IP: "3.3.3.3", ClickDate: clickDate})
+
+	exportSvc := NewClickExportService(helper)
+	if _, err := exportSvc.Create(1, 7, "xlsx", &dto.ClickStatisticListRequest{}); !errors.Is(err, ErrClickExportFormat) {
+		t.Fatalf("unsupported format must be rejected: %v", err)
+	}
+	csvJob, err := exportSvc.Create(1, 7, "", &dto.ClickStatisticListRequest{ShortLinkID: 10})
+	if err != nil || csvJob.Status != model.ClickExportStatusPending || csvJob.Format != model.ClickExportFormatCSV {
+		t.Fatalf("create csv job: %+v err=%v", csvJob, err)
+	}
+	// 创建任务后的点击不导出
+	seedClickStatistic(t, db, model.ClickStatistic{WorkspaceID: 1, ShortLinkID: 10, IP: "4.4.4.4", ClickDate: clickDate})
+
+	if exported, err := exportSvc.ProcessPending(context.Background()); err != nil || exported != 5 {
+		t.Fatalf("process csv job: exported=%d err=%v", exported, err)
+	}
+	completed, err := exportSvc.Get(csvJob.ID, 1)
+	if err != nil || completed.Status != model.ClickExportStatusCompleted || completed.RowCount != 5 || completed.DownloadURL == "" || completed.ExpiresAt == nil {
+		t.Fatalf("completed csv job: %+v err=%v", completed, err)
+	}
+	if _, err := exportSvc.Get(csvJob.ID, 2); !errors.Is(err, ErrClickExportNotFound) {
+		t.Fatalf("job must be scoped to its workspace: %v", err)
+	}
+	var stored model.ClickExportJob
+	db.First(&stored, csvJob.ID)
+	file := downloadClickExport(t, exportSvc, stored.DownloadToken)
+	if int64(len(file)) != stored.FileSize {
+		t.Fatalf("download size=%d want %d", len(file), stored.FileSize)
+	}
+	records := readGzipCSV(t, file)
+	if len(records) != 6 || records[0][0] != "\ufeffid" || records[1][24] != "中国" {
+		t.Fatalf("csv must contain header and the five matching clicks: %v", records)
+	}
+
+	// 停止时取消 ctx：执行中的任务写完当前批次后释放，下一次领取从记录的进度继续
+	// 此时链接共有 6 次点击
+	ndjsonJob, err := exportSvc.Create(1, 7, model.ClickExportFormatNDJSON, &dto.ClickStatisticListRequest{ShortLinkID: 10})
+	if err != nil {
+		t.Fatalf("create ndjson job: %v", err)
+	}
+	stopped, cancel := context.WithCancel(context.Background())
+	cancel()
+	if exported, err := exportSvc.ProcessPending(stopped); err != nil || exported != 0 {
+		t.Fatalf("stopped scheduler must not claim jobs: exported=%d err=%v", exported, err)
+	}
+	claimed, err := exportSvc.exportDao.Claim(time.Now().Add(-time.Hour))
+	if err != nil || claimed == nil || claimed.ID != ndjsonJob.ID {
+		t.Fatalf("claim ndjson job: %+v err=%v", claimed, err)
+	}
+	if exported, err := exportSvc.run(stopped, claimed); err != nil || exported != 0 {
+		t.Fatalf("cancelled run: exported=%d err=%v", exported, err)
+	}
+	var released model.ClickExportJob
+	db.First(&released, ndjsonJob.ID)
+	if released.Status != model.ClickExportStatusRunning || released.HeartbeatAt != nil || released.FileName == "" {
+		t.Fatalf("cancelled run must release the job: %+v", released)
+	}
+	if exported, err := exportSvc.ProcessPending(context.Background()); err != nil || exported != 6 {
+		t.Fatalf("resume ndjson job: exported=%d err=%v", exported, err)
+	}
+	var resumed model.ClickExportJob
+	db.First(&resumed, ndjsonJob.ID)
+	if resumed.Status != model.ClickExportStatusCompleted || resumed.RowCount != 6 {
+		t.Fatalf("resumed job: %+v", resumed)
+	}
+	file = downloadClickExport(t, exportSvc, resumed.DownloadToken)
+	lines := readGzipNDJSON(t, file)
+	if len(lines) != 6 {
+		t.Fatalf("resumed export must contain each click once, got %d lines", len(lines))
+	}
+	for i := 1; i < len(lines); i++ {
+		if lines[i].ID <= lines[i-1].ID {
+			t.Fatalf("clicks must be exported once in id order: %+v", lines)
+		}
+	}
+
+	cancelJob, err := exportSvc.Create(1, 7, "", &dto.ClickStatisticListRequest{})
+	if err != nil {
+		t.Fatalf("create job to cancel: %v", err)
+	}
+	if cancelled, err := exportSvc.Cancel(cancelJob.ID, 1); err != nil || cancelled.Status != model.ClickExportStatusCancelled {
+		t.Fatalf("cancel: %+v err=%v", cancelled, err)
+	}
+	if _, err := exportSvc.Cancel(cancelJob.ID, 1); !errors.Is(err, ErrClickExportNotCancellable) {
+		t.Fatalf("finished job cannot be cancelled again: %v", err)
+	}
+	if exported, err := exportSvc.ProcessPending(context.Background()); err != nil || exported != 0 {
+		t.Fatalf("cancelled job must not run: exported=%d err=%v", exported, err)
+	}
+
+	db.Model(&model.ClickExportJob{}).Where("id = ?", csvJob.ID).Update("expires_at", time.Now().Add(-time.Minute))
+	if _, err := exportSvc.Download(stored.DownloadToken); !errors.Is(err, ErrClickExportLinkExpired) {
+		t.Fatalf("expired link must be rejected: %v", err)
+	}
+	if purged, err := exportSvc.PurgeFiles(); err != nil || purged != 1 {
+		t.Fatalf("purge expired files: %d err=%v", purged, err)
+	}
+	var chunks int64
+	db.Model(&model.ClickExportChunk{}).Where("job_id = ?", csvJob.ID).Count(&chunks)
+	if chunks != 0 {
+		t.Fatalf("expired export chunks must be removed, got %d", chunks)
+	}
+	if expired, _ := exportSvc.Get(csvJob.ID, 1); expired.Status != model.ClickExportStatusExpired || expired.DownloadURL != "" {
+		t.Fatalf("expired job: %+v", expired)
+	}
+}
+
+// downloadClickExport 按分段逐个读出导出文件
+func downloadClickExport(t *testing.T, exportSvc *ClickExportService, token string) []byte {
+	t.Helper()
+	job, err := exportSvc.Download(token)
+	if err != nil {
+		t.Fatalf("download: %v", err)
+	}
+	var file bytes.Buffer
+	chunks := 0
+	for seq := -1; ; chunks++ {
+		if seq, err = exportSvc.WriteChunk(&file, job.ID, seq); err != nil {
+			break
+		}
+	}
+	if !errors.Is(err, io.EOF) || chunks < 2 {
+		t.Fatalf("write chunks: chunks=%d err=%v", chunks, err)
+	}
+	return file.Bytes()
+}
+
+func readGzipCSV(t *testing.T, data []byte) [][]string {
+	t.Helper()
+	reader, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("gzip reader: %v", err)
+	}
+	records, err := csv.NewReader(reader).ReadAll()
+	if err != nil {
+		t.Fatalf("read csv: %v", err)
+	}
+	return records
+}
+
+func readGzipNDJSON(t *testing.T, data []byte) []dto.ClickStatisticExportRecord {
+	t.Helper()
+	reader, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("gzip reader: %v", err)
+	}
+	var lines []dto.ClickStatisticExportRecord
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		var record dto.ClickStatisticExportRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatalf("decode ndjson line %q: %v", scanner.Text(), err)
+		}
+		lines = append(lines, record)
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatalf("read ndjson: %v", err)
+	}
+	return lines
+}
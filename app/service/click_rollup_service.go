@@ -0,0 +1,756 @@
+package service
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/dao"
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/dto"
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/model"
+	"cnb.cool/mliev/dwz/dwz-server/v2/pkg/interfaces"
+	"cnb.cool/mliev/dwz/dwz-server/v2/pkg/service/domain_validate"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultClickRollupBatchSize  = 5000
+	defaultClickRollupMaxBatches = 20
+	defaultClickRollupTailLimit  = 50000
+	clickRollupTopLimit          = 10
+
+	// 点击写入后等待该秒数再汇总，等并发写入中ID较小的点击提交
+	defaultClickRollupSettleSeconds = 30
+	clickRollupRebuildStaleAfter    = 10 * time.Minute
+)
+
+var (
+	ErrClickRollupRebuildActive   = errors.New("当前工作区已有进行中的汇总重建任务")
+	ErrClickRollupRebuildNotFound = errors.New("汇总重建任务不存在")
+
+	errClickRollupRebuildReclaimed = errors.New("重建任务已被其他实例接管")
+)
+
+// ClickRollupService 维护点击预聚合汇总，并基于汇总计算统计分析
+type ClickRollupService struct {
+	helper            interfaces.HelperInterface
+	clickRollupDao    *dao.ClickRollupDao
+	clickStatisticDao *dao.ClickStatisticDao
+	visitorSvc        *VisitorService
+	rebuildDao        *dao.ClickRollupRebuildDao
+}
+
+func NewClickRollupService(helper interfaces.HelperInterface) *ClickRollupService {
+	return &ClickRollupService{
+		helper:            helper,
+		clickRollupDao:    dao.NewClickRollupDao(helper),
+		clickStatisticDao: dao.NewClickStatisticDao(helper),
+		visitorSvc:        NewVisitorService(helper),
+		rebuildDao:        dao.NewClickRollupRebuildDao(helper),
+	}
+}
+
+// clickRollupEntry 一次点击在某个维度上的取值
+type clickRollupEntry struct {
+	Dimension string
+	Country   string
+	Province  string
+	Value     string
+	Label     string
+}
+
+type clickRollupKey struct {
+	ShortLinkID uint64
+	CampaignID  uint64
+	IsBot       bool
+	Granularity string
+	BucketStart int64
+	Dimension   string
+	Country     string
+	Province    string
+	Value       string
+}
+
+// ProcessPending 将游标之后已写入超过等待时间的新点击累加到汇总和独立访客草图，返回本轮处理的点击数。
+// 等待期内的点击由统计分析从明细补齐。
+func (s *ClickRollupService) ProcessPending() (int, error) {
+	batchSize := s.helper.GetConfig().GetInt("analytics.rollup_batch_size", defaultClickRollupBatchSize)
+	if batchSize <= 0 {
+		batchSize = defaultClickRollupBatchSize
+	}
+	maxBatches := s.helper.GetConfig().GetInt("analytics.rollup_max_batches", defaultClickRollupMaxBatches)
+	if maxBatches <= 0 {
+		maxBatches = defaultClickRollupMaxBatches
+	}
+
+	var settledBefore time.Time
+	if settle := s.helper.GetConfig().GetInt("analytics.rollup_settle_seconds", defaultClickRollupSettleSeconds); settle > 0 {
+		settledBefore = time.Now().Add(-time.Duration(settle) * time.Second)
+	}
+
+	processed := 0
+	for i := 0; i < maxBatches; i++ {
+		cursor, err := s.clickRollupDao.Cursor()
+		if err != nil {
+			return processed, err
+		}
+		statistics, err := s.clickRollupDao.ListClicksAfter(cursor.LastClickID, settledBefore, batchSize)
+		if err != nil || len(statistics) == 0 {
+			return processed, err
+		}
+		// Redis 草图先于推进游标写入：写入失败时游标不动，下一轮重试；PFADD 重复写入不影响结果
+		if err := s.visitorSvc.AddRedisSketches(statistics); err != nil {
+			return processed, err
+		}
+		applied, err := s.clickRollupDao.ApplyIncrement(cursor.LastClickID, statistics[len(statistics)-1].ID, buildClickRollups(statistics), s.visitorSvc.DatabaseSketches(statistics))
+		if err != nil || !applied {
+			// 其他实例已处理这一批
+			return processed, err
+		}
+		processed += len(statistics)
+		if len(statistics) < batchSize {
+			break
+		}
+	}
+	return processed, nil
+}
+
+// Rebuild 根据点击明细逐日重建工作区在时间范围内的汇总，时间范围为空表示全部，返回重新汇总的点击数
+func (s *ClickRollupService) Rebuild(workspaceID uint64, start, end time.Time) (int, error) {
+	start, end, err := s.rebuildRange(workspaceID, start, end)
+	if err != nil {
+		return 0, err
+	}
+	rebuilt := 0
+	for day := start; day.Before(end); day = day.AddDate(0, 0, 1) {
+		clicks, err := s.rebuildDay(workspaceID, day)
+		rebuilt += clicks
+		if err != nil {
+			return rebuilt, err
+		}
+	}
+	return rebuilt, nil
+}
+
+// rebuildRange 将重建范围对齐到整天，未指定的一端取点击明细与汇总覆盖的范围
+func (s *ClickRollupService) rebuildRange(workspaceID uint64, start, end time.Time) (time.Time, time.Time, error) {
+	if start.IsZero() || end.IsZero() {
+		first, last, err := s.clickRollupDao.Span(workspaceID)
+		if err != nil || first.IsZero() {
+			return time.Time{}, time.Time{}, err
+		}
+		if start.IsZero() {
+			start = first
+		}
+		if end.IsZero() {
+			end = model.ClickRollupBucketStart(last, model.ClickRollupGranularityDay).AddDate(0, 0, 1)
+		}
+	}
+	start = model.ClickRollupBucketStart(start, model.ClickRollupGranularityDay)
+	if !model.ClickRollupBucketStart(end, model.ClickRollupGranularityDay).Equal(end) {
+		end = model.ClickRollupBucketStart(end, model.ClickRollupGranularityDay).AddDate(0, 0, 1)
+	}
+	// 超过保留期的点击明细已删除，保留期截止当天及之前的汇总不重建
+	if cutoff := NewDataRetentionService(s.helper).ClickCutoff(workspaceID, time.Now()); !cutoff.IsZero() {
+		earliest := model.ClickRollupBucketStart(cutoff, model.ClickRollupGranularityDay).AddDate(0, 0, 1)
+		if start.Before(earliest) {
+			start = earliest
+		}
+	}
+	return start, end, nil
+}
+
+// rebuildDay 在内存中重算一天的汇总后整体替换，返回重新汇总的点击数。
+// 游标之后的点击由增量汇总负责，这里只重算游标之前的部分。
+func (s *ClickRollupService) rebuildDay(workspaceID uint64, day time.Time) (int, error) {
+	batchSize := s.helper.GetConfig().GetInt("analytics.rollup_batch_size", defaultClickRollupBatchSize)
+	if batchSize <= 0 {
+		batchSize = defaultClickRollupBatchSize
+	}
+	cursor, err := s.clickRollupDao.Cursor()
+	if err != nil {
+		return 0, err
+	}
+	end := day.AddDate(0, 0, 1)
+	builder := newClickRollupBuilder()
+	clicks := 0
+	var afterID uint64
+	for {
+		statistics, err := s.clickRollupDao.ListWorkspaceClicks(workspaceID, day, end, afterID, cursor.LastClickID, batchSize)
+		if err != nil {
+			return 0, err
+		}
+		if len(statistics) == 0 {
+			break
+		}
+		for i := range statistics {
+			builder.add(&statistics[i])
+		}
+		clicks += len(statistics)
+		afterID = statistics[len(statistics)-1].ID
+	}
+	err = s.clickRollupDao.ReplaceRange(workspaceID, day, end, cursor.LastClickID, func(tail []model.ClickStatistic) []model.ClickRollup {
+		for i := range tail {
+			builder.add(&tail[i])
+		}
+		clicks += len(tail)
+		return builder.rollups
+	})
+	if err != nil {
+		return 0, err
+	}
+	return clicks, nil
+}
+
+// CreateRebuildJob 提交后台重建任务，同一工作区同时只能有一个待执行或执行中的任务
+func (s *ClickRollupService) CreateRebuildJob(workspaceID, userID uint64, start, end time.Time) (*dto.ClickRollupRebuildJobResponse, error) {
+	active, err := s.rebuildDao.CountActiveInWorkspace(workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	if active > 0 {
+		return nil, ErrClickRollupRebuildActive
+	}
+	job := &model.ClickRollupRebuildJob{WorkspaceID: workspaceID, Status: model.ClickRollupRebuildStatusPending}
+	if !start.IsZero() {
+		job.StartDate = &start
+	}
+	if !end.IsZero() {
+		job.EndDate = &end
+	}
+	if userID > 0 {
+		job.RequestedBy = &userID
+	}
+	if err := s.rebuildDao.Create(job); err != nil {
+		return nil, err
+	}
+	return clickRollupRebuildJobToResponse(job), nil
+}
+
+func (s *ClickRollupService) GetRebuildJob(id, workspaceID uint64) (*dto.ClickRollupRebuildJobResponse, error) {
+	job, err := s.rebuildDao.FindByID(id, workspaceID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrClickRollupRebuildNotFound
+		}
+		return nil, err
+	}
+	return clickRollupRebuildJobToResponse(job), nil
+}
+
+// ProcessRebuildJobs 执行待处理的重建任务，返回重新汇总的点击数。
+// 每重建完一天记录进度，执行实例退出后由其他实例从下一天接续。
+func (s *ClickRollupService) ProcessRebuildJobs() (int, error) {
+	processed := 0
+	for {
+		job, err := s.rebuildDao.Claim(time.Now().Add(-clickRollupRebuildStaleAfter))
+		if err != nil || job == nil {
+			return processed, err
+		}
+		clicks, runErr := s.runRebuildJob(job)
+		processed += clicks
+		if errors.Is(runErr, errClickRollupRebuildReclaimed) {
+			continue
+		}
+		updates := map[string]any{"status": model.ClickRollupRebuildStatusCompleted, "finished_at": time.Now()}
+		if runErr != nil {
+			updates["status"] = model.ClickRollupRebuildStatusFailed
+			updates["error"] = domain_validate.TruncateString(runErr.Error(), 500)
+		}
+		if _, err := s.rebuildDao.UpdateClaimed(job, updates); err != nil {
+			return processed, err
+		}
+	}
+}
+
+func (s *ClickRollupService) runRebuildJob(job *model.ClickRollupRebuildJob) (int, error) {
+	var start, end time.Time
+	if job.StartDate != nil {
+		start = *job.StartDate
+	}
+	if job.EndDate != nil {
+		end = *job.EndDate
+	}
+	start, end, err := s.rebuildRange(job.WorkspaceID, start, end)
+	if err != nil {
+		return 0, err
+	}
+	if job.NextDate != nil && job.NextDate.After(start) {
+		start = *job.NextDate
+	}
+	rebuilt := 0
+	for day := start; day.Before(end); day = day.AddDate(0, 0, 1) {
+		clicks, err := s.rebuildDay(job.WorkspaceID, day)
+		if err != nil {
+			return rebuilt, err
+		}
+		rebuilt += clicks
+		ok, err := s.rebuildDao.UpdateClaimed(job, map[string]any{
+			"next_date":    day.AddDate(0, 0, 1),
+			"clicks":       gorm.Expr("clicks + ?", clicks),
+			"heartbeat_at": time.Now(),
+		})
+		if err == nil && !ok {
+			err = errClickRollupRebuildReclaimed
+		}
+		if err != nil {
+			return rebuilt, err
+		}
+	}
+	return rebuilt, nil
+}
+
+func clickRollupRebuildJobToResponse(job *model.ClickRollupRebuildJob) *dto.ClickRollupRebuildJobResponse {
+	return &dto.ClickRollupRebuildJobResponse{
+		ID:         job.ID,
+		Status:     job.Status,
+		StartDate:  job.StartDate,
+		EndDate:    job.EndDate,
+		NextDate:   job.NextDate,
+		Clicks:     job.Clicks,
+		Error:      job.Error,
+		StartedAt:  job.StartedAt,
+		FinishedAt: job.FinishedAt,
+		CreatedAt:  job.CreatedAt,
+	}
+}
+
+// Analysis 基于汇总计算统计分析；筛选条件无法由汇总满足时返回 false，由调用方扫描点击明细
+func (s *ClickRollupService) Analysis(workspaceID uint64, req *dto.ClickStatisticListRequest) (*dto.ClickStatisticAnalysisResponse, bool, error) {
+	if !s.supports(req, false) {
+		return nil, false, nil
+	}
+	rows, buckets, ok, err := s.collect(workspaceID, req, nil, true)
+	if err != nil || !ok {
+		return nil, ok, err
+	}
+
+	analysis := &dto.ClickStatisticAnalysisResponse{}
+	countries := map[string]int64{}
+	provinces := map[string]int64{}
+	cities := map[string]int64{}
+	values := map[string]map[string]int64{}
+	labels := map[string]map[string]string{}
+	for _, row := range rows {
+		switch row.Dimension {
+		case model.ClickRollupDimensionTotal:
+			analysis.TotalClicks += row.Clicks
+			if row.IsBot {
+				analysis.BotStats.BotClicks += row.Clicks
+			} else {
+				analysis.BotStats.HumanClicks += row.Clicks
+			}
+		case model.ClickRollupDimensionRegion:
+			if row.Country != "" {
+				countries[row.Country] += row.Clicks
+			}
+			if row.Province != "" {
+				provinces[row.Province] += row.Clicks
+			}
+			if row.DimensionValue != "" {
+				cities[row.DimensionValue] += row.Clicks
+			}
+		default:
+			if values[row.Dimension] == nil {
+				values[row.Dimension] = map[string]int64{}
+				labels[row.Dimension] = map[string]string{}
+			}
+			values[row.Dimension][row.DimensionValue] += row.Clicks
+			if row.Label != "" {
+				labels[row.Dimension][row.DimensionValue] = row.Label
+			}
+		}
+	}
+
+	for _, item := range topClickRollupValues(countries, clickRollupTopLimit) {
+		analysis.TopCountries = append(analysis.TopCountries, dto.CountryStatistic{Country: item.Value, Count: item.Count})
+	}
+	for _, item := range topClickRollupValues(provinces, clickRollupTopLimit) {
+		analysis.TopProvinces = append(analysis.TopProvinces, dto.ProvinceStatistic{Province: item.Value, Count: item.Count})
+	}
+	for _, item := range topClickRollupValues(cities, clickRollupTopLimit) {
+		analysis.TopCities = append(analysis.TopCities, dto.CityStatistic{City: item.Value, Count: item.Count})
+	}
+	for _, item := range topClickRollupValues(values[model.ClickRollupDimensionISP], clickRollupTopLimit) {
+		analysis.TopISPs = append(analysis.TopISPs, dto.ISPStatistic{ISP: item.Value, Count: item.Count})
+	}
+	for _, item := range topClickRollupValues(values[model.ClickRollupDimensionRefererHost], clickRollupTopLimit) {
+		analysis.TopRefererHosts = append(analysis.TopRefererHosts, dto.RefererStatistic{Referer: item.Value, Count: item.Count})
+	}
+	// 完整来源地址取值过多，汇总只保留来源域名
+	analysis.TopReferers = analysis.TopRefererHosts
+	for _, item := range topClickRollupValues(values[model.ClickRollupDimensionDevice], clickRollupTopLimit) {
+		analysis.TopDevices = append(analysis.TopDevices, dto.DeviceStatistic{DeviceType: item.Value, Count: item.Count})
+	}
+	for _, item := range topClickRollupValues(values[model.ClickRollupDimensionBrowser], clickRollupTopLimit) {
+		analysis.TopBrowsers = append(analysis.TopBrowsers, dto.BrowserStatistic{Browser: item.Value, Count: item.Count})
+	}
+	for _, item := range topClickRollupValues(values[model.ClickRollupDimensionOS], clickRollupTopLimit) {
+		analysis.TopOS = append(analysis.TopOS, dto.OSStatistic{OS: item.Value, Count: item.Count})
+	}
+	for _, item := range topClickRollupValues(values[model.ClickRollupDimensionUTMSource], clickRollupTopLimit) {
+		analysis.TopUTMSources = append(analysis.TopUTMSources, dto.UTMStatistic{Value: item.Value, Count: item.Count})
+	}
+	for _, item := range topClickRollupValues(values[model.ClickRollupDimensionUTMCampaign], clickRollupTopLimit) {
+		analysis.TopUTMCampaigns = append(analysis.TopUTMCampaigns, dto.UTMStatistic{Value: item.Value, Count: item.Count})
+	}
+	for _, item := range topClickRollupValues(values[model.ClickRollupDimensionRoute], clickRollupTopLimit) {
+		routeID, _ := strconv.ParseUint(item.Value, 10, 64)
+		analysis.TopRoutes = append(analysis.TopRoutes, dto.RouteStatistic{RouteID: routeID, RouteName: labels[model.ClickRollupDimensionRoute][item.Value], Count: item.Count})
+	}
+	for _, item := range topClickRollupValues(values[model.ClickRollupDimensionAlias], clickRollupTopLimit) {
+		aliasID, _ := strconv.ParseUint(item.Value, 10, 64)
+		analysis.TopAliases = append(analysis.TopAliases, dto.AliasStatistic{AliasID: aliasID, AliasCode: labels[model.ClickRollupDimensionAlias][item.Value], Count: item.Count})
+	}
+
+	location := dao.ReportLocation(req)
+	hours := map[int]int64{}
+	days := map[string]int64{}
+	for bucket, clicks := range buckets {
+		local := time.Unix(bucket, 0).In(location)
+		hours[local.Hour()] += clicks
+		days[local.Format("2006-01-02")] += clicks
+	}
+	for hour, count := range hours {
+		analysis.HourlyStats = append(analysis.HourlyStats, dto.HourlyStatistic{Hour: hour, Count: count})
+	}
+	sort.Slice(analysis.HourlyStats, func(i, j int) bool { return analysis.HourlyStats[i].Hour < analysis.HourlyStats[j].Hour })
+	for date, count := range days {
+		analysis.DailyStats = append(analysis.DailyStats, dto.DailyStatistic{Date: date, Count: count})
+	}
+	sort.Slice(analysis.DailyStats, func(i, j int) bool { return analysis.DailyStats[i].Date < analysis.DailyStats[j].Date })
+
+	if analysis.UniqueIPs, err = s.countUniqueIPs(workspaceID, req); err != nil {
+		return nil, false, err
+	}
+	return analysis, true, nil
+}
+
+// GeoAnalysis 基于汇总计算地理聚合，支持按国家、省份筛选
+func (s *ClickRollupService) GeoAnalysis(workspaceID uint64, req *dto.ClickStatisticListRequest, level string) (*dto.ClickStatisticGeoAnalysisResponse, bool, error) {
+	if !s.supports(req, true) {
+		return nil, false, nil
+	}
+	rows, _, ok, err := s.collect(workspaceID, req, []string{model.ClickRollupDimensionRegion}, false)
+	if err != nil || !ok {
+		return nil, ok, err
+	}
+	analysis := &dto.ClickStatisticGeoAnalysisResponse{
+		Level:    level,
+		Country:  req.Country,
+		Province: req.Province,
+		Regions:  []dto.GeoRegionStatistic{},
+	}
+	regions := map[string]int64{}
+	for _, row := range rows {
+		analysis.TotalClicks += row.Clicks
+		name := row.Country
+		switch level {
+		case "province":
+			name = row.Province
+		case "city":
+			name = row.DimensionValue
+		}
+		if name != "" {
+			regions[name] += row.Clicks
+		}
+	}
+	for _, item := range topClickRollupValues(regions, 0) {
+		analysis.Regions = append(analysis.Regions, dto.GeoRegionStatistic{Name: item.Value, Count: item.Count})
+	}
+	if analysis.UniqueIPs, err = s.countUniqueIPs(workspaceID, req); err != nil {
+		return nil, false, err
+	}
+	return analysis, true, nil
+}
+
+// countUniqueIPs 独立IP无法由汇总相加得到，只能按点击明细去重，耗时随时间范围内的点击数增长；
+// 关闭 analytics.rollup_unique_ips 后不再统计，返回 0，可改用基于草图的独立访客数
+func (s *ClickRollupService) countUniqueIPs(workspaceID uint64, req *dto.ClickStatisticListRequest) (int64, error) {
+	if !s.helper.GetConfig().GetBool("analytics.rollup_unique_ips", true) {
+		return 0, nil
+	}
+	return s.clickStatisticDao.CountUniqueIPsInWorkspace(workspaceID, req)
+}
+
+// ChannelBreakdown 基于汇总按来源渠道和主机名统计点击数，同时返回总点击数
+func (s *ClickRollupService) ChannelBreakdown(workspaceID uint64, req *dto.ClickStatisticListRequest) ([]dao.ReferrerChannelHostCount, int64, bool, error) {
+	if !s.supports(req, false) {
+		return nil, 0, false, nil
+	}
+	rows, _, ok, err := s.collect(workspaceID, req, []string{model.ClickRollupDimensionTotal, model.ClickRollupDimensionRefererChannel}, false)
+	if err != nil || !ok {
+		return nil, 0, ok, err
+	}
+	var total int64
+	counts := make([]dao.ReferrerChannelHostCount, 0, len(rows))
+	for _, row := range rows {
+		if row.Dimension == model.ClickRollupDimensionTotal {
+			total += row.Clicks
+			continue
+		}
+		channel, host, _ := strings.Cut(row.DimensionValue, "|")
+		counts = append(counts, dao.ReferrerChannelHostCount{Channel: channel, Host: host, Clicks: row.Clicks})
+	}
+	return counts, total, true, nil
+}
+
+// supports 汇总只保留短网址、活动和是否机器人维度的组合，其余筛选需要扫描点击明细；时间范围需按整点划分，
+// 报表时区与服务器时区相差整小时时才能由小时汇总换算出报表时区的日期和小时
+func (s *ClickRollupService) supports(req *dto.ClickStatisticListRequest, geo bool) bool {
+	if !s.helper.GetConfig().GetBool("analytics.rollup_enabled", true) {
+		return false
+	}
+	if req.RouteID > 0 || req.AliasID > 0 || req.DeviceType != "" || req.IP != "" || req.City != "" || req.ISP != "" || req.RefererChannel != "" {
+		return false
+	}
+	if req.MinBotScore != nil || req.MaxBotScore != nil || req.BotReason != "" || req.WithDuplicates {
+		return false
+	}
+	if !geo && (req.Country != "" || req.Province != "") {
+		return false
+	}
+	location := dao.ReportLocation(req)
+	for _, t := range []time.Time{req.StartDate, req.EndDate} {
+		if !t.IsZero() && !model.ClickRollupBucketStart(t, model.ClickRollupGranularityHour).Equal(t) {
+			return false
+		}
+	}
+	for _, t := range []time.Time{req.StartDate, req.EndDate, time.Now()} {
+		_, offset := t.In(location).Zone()
+		_, serverOffset := t.In(time.Local).Zone()
+		if (offset-serverOffset)%3600 != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// collect 读取汇总，并补上游标之后尚未汇总的点击；未汇总的点击过多时返回 false
+func (s *ClickRollupService) collect(workspaceID uint64, req *dto.ClickStatisticListRequest, dimensions []string, withTimeline bool) ([]dao.ClickRollupAggregate, map[int64]int64, bool, error) {
+	var rows []dao.ClickRollupAggregate
+	var timeline []dao.ClickRollupBucket
+	var lastClickID uint64
+	// 读取期间游标推进会导致重复或遗漏，游标前后一致时才使用本次结果
+	for attempt := 0; ; attempt++ {
+		before, err := s.clickRollupDao.Cursor()
+		if err != nil {
+			return nil, nil, false, err
+		}
+		if rows, err = s.clickRollupDao.Breakdown(workspaceID, req, dimensions); err != nil {
+			return nil, nil, false, err
+		}
+		if withTimeline {
+			if timeline, err = s.clickRollupDao.Timeline(workspaceID, req); err != nil {
+				return nil, nil, false, err
+			}
+		}
+		after, err := s.clickRollupDao.Cursor()
+		if err != nil {
+			return nil, nil, false, err
+		}
+		if before.LastClickID == after.LastClickID {
+			lastClickID = after.LastClickID
+			break
+		}
+		if attempt >= 2 {
+			return nil, nil, false, nil
+		}
+	}
+
+	tailLimit := s.helper.GetConfig().GetInt("analytics.rollup_tail_limit", defaultClickRollupTailLimit)
+	if tailLimit <= 0 {
+		tailLimit = defaultClickRollupTailLimit
+	}
+	tail, err := s.clickStatisticDao.ListAfterIDInWorkspace(workspaceID, req, lastClickID, tailLimit+1)
+	if err != nil {
+		return nil, nil, false, err
+	}
+	if len(tail) > tailLimit {
+		return nil, nil, false, nil
+	}
+
+	wanted := map[string]bool{}
+	for _, dimension := range dimensions {
+		wanted[dimension] = true
+	}
+	type aggregateKey struct {
+		Dimension string
+		Country   string
+		Province  string
+		Value     string
+		IsBot     bool
+	}
+	index := make(map[aggregateKey]int, len(rows))
+	for i, row := range rows {
+		index[aggregateKey{row.Dimension, row.Country, row.Province, row.DimensionValue, row.IsBot}] = i
+	}
+	buckets := make(map[int64]int64, len(timeline))
+	for _, bucket := range timeline {
+		buckets[bucket.BucketStart.Unix()] += bucket.Clicks
+	}
+	for i := range tail {
+		statistic := &tail[i]
+		for _, entry := range clickRollupEntries(statistic) {
+			if len(wanted) > 0 && !wanted[entry.Dimension] {
+				continue
+			}
+			key := aggregateKey{entry.Dimension, entry.Country, entry.Province, entry.Value, statistic.IsBot}
+			if position, ok := index[key]; ok {
+				rows[position].Clicks++
+				if entry.Label != "" {
+					rows[position].Label = entry.Label
+				}
+				continue
+			}
+			index[key] = len(rows)
+			rows = append(rows, dao.ClickRollupAggregate{
+				Dimension:      entry.Dimension,
+				Country:        entry.Country,
+				Province:       entry.Province,
+				DimensionValue: entry.Value,
+				Label:          entry.Label,
+				IsBot:          statistic.IsBot,
+				Clicks:         1,
+			})
+		}
+		if withTimeline {
+			buckets[model.ClickRollupBucketStart(statistic.ClickDate, model.ClickRollupGranularityHour).Unix()]++
+		}
+	}
+	return rows, buckets, true, nil
+}
+
+// buildClickRollups 将一批点击合并为小时和天两种粒度的汇总增量，重复点击不计入汇总
+func buildClickRollups(statistics []model.ClickStatistic) []model.ClickRollup {
+	builder := newClickRollupBuilder()
+	for i := range statistics {
+		builder.add(&statistics[i])
+	}
+	return builder.rollups
+}
+
+// clickRollupBuilder 逐条累加点击，合并为汇总行
+type clickRollupBuilder struct {
+	now     time.Time
+	index   map[clickRollupKey]int
+	rollups []model.ClickRollup
+}
+
+func newClickRollupBuilder() *clickRollupBuilder {
+	return &clickRollupBuilder{now: time.Now(), index: make(map[clickRollupKey]int)}
+}
+
+func (b *clickRollupBuilder) add(statistic *model.ClickStatistic) {
+	if statistic.IsDuplicate {
+		return
+	}
+	var campaignID uint64
+	if statistic.CampaignID != nil {
+		campaignID = *statistic.CampaignID
+	}
+	entries := clickRollupEntries(statistic)
+	for _, granularity := range []string{model.ClickRollupGranularityHour, model.ClickRollupGranularityDay} {
+		bucketStart := model.ClickRollupBucketStart(statistic.ClickDate, granularity)
+		for _, entry := range entries {
+			key := clickRollupKey{
+				ShortLinkID: statistic.ShortLinkID,
+				CampaignID:  campaignID,
+				IsBot:       statistic.IsBot,
+				Granularity: granularity,
+				BucketStart: bucketStart.Unix(),
+				Dimension:   entry.Dimension,
+				Country:     entry.Country,
+				Province:    entry.Province,
+				Value:       entry.Value,
+			}
+			if position, ok := b.index[key]; ok {
+				b.rollups[position].Clicks++
+				if entry.Label != "" {
+					b.rollups[position].Label = entry.Label
+				}
+				continue
+			}
+			b.index[key] = len(b.rollups)
+			b.rollups = append(b.rollups, model.ClickRollup{
+				WorkspaceID:    statistic.WorkspaceID,
+				ShortLinkID:    statistic.ShortLinkID,
+				CampaignID:     campaignID,
+				IsBot:          statistic.IsBot,
+				Granularity:    granularity,
+				BucketStart:    bucketStart,
+				Dimension:      entry.Dimension,
+				Country:        entry.Country,
+				Province:       entry.Province,
+				DimensionValue: entry.Value,
+				Label:          entry.Label,
+				Clicks:         1,
+				UpdatedAt:      b.now,
+			})
+		}
+	}
+}
+
+// clickRollupEntries 一次点击计入的维度取值，空值不计入（地区除外，用于按国家、省份筛选时的合计）
+func clickRollupEntries(statistic *model.ClickStatistic) []clickRollupEntry {
+	entries := []clickRollupEntry{
+		{Dimension: model.ClickRollupDimensionTotal},
+		{
+			Dimension: model.ClickRollupDimensionRegion,
+			Country:   domain_validate.TruncateString(statistic.Country, 100),
+			Province:  domain_validate.TruncateString(statistic.Province, 100),
+			Value:     domain_validate.TruncateString(statistic.City, 255),
+		},
+	}
+	add := func(dimension, value, label string) {
+		if value != "" {
+			entries = append(entries, clickRollupEntry{
+				Dimension: dimension,
+				Value:     domain_validate.TruncateString(value, 255),
+				Label:     domain_validate.TruncateString(label, 255),
+			})
+		}
+	}
+	add(model.ClickRollupDimensionISP, statistic.ISP, "")
+	add(model.ClickRollupDimensionRefererHost, statistic.RefererHost, "")
+	if statistic.RefererChannel != "" {
+		add(model.ClickRollupDimensionRefererChannel, statistic.RefererChannel+"|"+statistic.RefererHost, "")
+	}
+	add(model.ClickRollupDimensionDevice, statistic.DeviceType, "")
+	add(model.ClickRollupDimensionBrowser, statistic.Browser, "")
+	add(model.ClickRollupDimensionOS, statistic.OS, "")
+	add(model.ClickRollupDimensionUTMSource, statistic.UTMSource, "")
+	add(model.ClickRollupDimensionUTMCampaign, statistic.UTMCampaign, "")
+	if statistic.RouteID != nil {
+		add(model.ClickRollupDimensionRoute, strconv.FormatUint(*statistic.RouteID, 10), statistic.RouteName)
+	}
+	if statistic.AliasID != nil {
+		add(model.ClickRollupDimensionAlias, strconv.FormatUint(*statistic.AliasID, 10), statistic.AliasCode)
+	}
+	return entries
+}
+
+type clickRollupValueCount struct {
+	Value string
+	Count int64
+}
+
+// topClickRollupValues 按点击数降序排列，limit 为 0 时不截断
+func topClickRollupValues(values map[string]int64, limit int) []clickRollupValueCount {
+	items := make([]clickRollupValueCount, 0, len(values))
+	for value, count := range values {
+		items = append(items, clickRollupValueCount{Value: value, Count: count})
+	}
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].Count != items[j].Count {
+			return items[i].Count > items[j].Count
+		}
+		return items[i].Value < items[j].Value
+	})
+	if limit > 0 && len(items) > limit {
+		items = items[:limit]
+	}
+	return items
+}
@@ -0,0 +1,249 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/dao"
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/dto"
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/model"
+)
+
+func TestClickRollupAnalysisMatchesRawClicks(t *testing.T) {
+	helper := newShortLinkRegressionHelper(t)
+	db := helper.GetDatabase()
+	day := time.Date(2026, 5, 10, 0, 0, 0, 0, time.Local)
+	campaignID := uint64(3)
+	routeID := uint64(9)
+
+	seed := func(n int, at time.Time, stat model.ClickStatistic) {
+		for i := 0; i < n; i++ {
+			stat.ID = 0
+			stat.WorkspaceID = 1
+			stat.IP = fmt.Sprintf("10.0.%d.%d", at.Hour(), i)
+			stat.ClickDate = at
+			stat.RefererHost = model.RefererHost(stat.Referer)
+			seedClickStatistic(t, db, stat)
+		}
+	}
+	seed(5, day.Add(9*time.Hour), model.ClickStatistic{ShortLinkID: 1, Country: "中国", Province: "浙江", City: "杭州", Referer: "https://www.Google.com/search?q=a", DeviceType: "mobile", Browser: "Chrome", OS: "iOS", UTMSource: "newsletter", CampaignID: &campaignID})
+	seed(3, day.Add(15*time.Hour), model.ClickStatistic{ShortLinkID: 1, Country: "中国", Province: "广东", City: "深圳", Referer: "https://google.com/", DeviceType: "desktop", Browser: "Firefox", RouteID: &routeID, RouteName: "iOS 用户"})
+	seed(2, day.Add(33*time.Hour), model.ClickStatistic{ShortLinkID: 2, Country: "美国", ISP: "Comcast", Referer: "https://t.co/x", IsBot: true, UTMCampaign: "spring"})
+	seed(1, day.Add(-2*time.Hour), model.ClickStatistic{ShortLinkID: 1, Country: "日本"})
+
+	rollupSvc := NewClickRollupService(helper)
+	processed, err := rollupSvc.ProcessPending()
+	if err != nil || processed != 11 {
+		t.Fatalf("process pending: processed=%d err=%v", processed, err)
+	}
+	if again, err := rollupSvc.ProcessPending(); err != nil || again != 0 {
+		t.Fatalf("processed clicks must not be counted twice: processed=%d err=%v", again, err)
+	}
+	// 游标已被推进时，旧批次不能再次写入
+	if applied, err := dao.NewClickRollupDao(helper).ApplyIncrement(0, 11, nil, nil); err != nil || applied {
+		t.Fatalf("stale increment must be rejected: applied=%v err=%v", applied, err)
+	}
+
+	// 未汇总的新点击由明细补齐
+	seed(4, day.Add(34*time.Hour), model.ClickStatistic{ShortLinkID: 2, Country: "美国", ISP: "Comcast", Referer: "https://mail.example.com/inbox", UTMCampaign: "spring"})
+
+	rawDao := dao.NewClickStatisticDao(helper)
+	requests := []*dto.ClickStatisticListRequest{
+		{StartDate: day, EndDate: day.AddDate(0, 0, 2)},
+		{StartDate: day.Add(9 * time.Hour), EndDate: day.Add(34 * time.Hour)},
+		{StartDate: day.Add(-3 * time.Hour), EndDate: day.AddDate(0, 0, 2), ShortLinkID: 1},
+		{StartDate: day, EndDate: day.AddDate(0, 0, 2), CampaignID: campaignID},
+	}
+	compare := func(label string) {
+		t.Helper()
+		for i, req := range requests {
+			raw, err := rawDao.GetAnalysisInWorkspace(1, req)
+			if err != nil {
+				t.Fatalf("raw analysis %d: %v", i, err)
+			}
+			rolled, ok, err := rollupSvc.Analysis(1, req)
+			if err != nil || !ok {
+				t.Fatalf("%s rollup analysis %d: ok=%v err=%v", label, i, ok, err)
+			}
+			if !reflect.DeepEqual(normalizeAnalysisForCompare(raw), normalizeAnalysisForCompare(rolled)) {
+				t.Fatalf("%s rollup analysis %d differs from raw:\nraw=%+v\nrollup=%+v", label, i, raw, rolled)
+			}
+
+			for _, level := range []string{"country", "province", "city"} {
+				rawGeo, err := rawDao.GetGeoAnalysisInWorkspace(1, req, level)
+				if err != nil {
+					t.Fatalf("raw geo %d: %v", i, err)
+				}
+				rolledGeo, ok, err := rollupSvc.GeoAnalysis(1, req, level)
+				if err != nil || !ok || !reflect.DeepEqual(rawGeo, rolledGeo) {
+					t.Fatalf("%s rollup geo %d/%s differs: raw=%+v rollup=%+v ok=%v err=%v", label, i, level, rawGeo, rolledGeo, ok, err)
+				}
+			}
+		}
+	}
+	compare("incremental")
+
+	analysis, _, _ := rollupSvc.Analysis(1, requests[0])
+	if len(analysis.TopRefererHosts) != 3 || analysis.TopRefererHosts[0] != (dto.RefererStatistic{Referer: "google.com", Count: 8}) {
+		t.Fatalf("referer hosts must be normalized and merged: %+v", analysis.TopRefererHosts)
+	}
+	if !reflect.DeepEqual(analysis.TopReferers, analysis.TopRefererHosts) {
+		t.Fatalf("rollups only keep referer hosts: %+v", analysis.TopReferers)
+	}
+	var rawReferers int64
+	db.Model(&model.ClickRollup{}).Where("dimension = ?", "referer").Count(&rawReferers)
+	if rawReferers != 0 {
+		t.Fatalf("full referer URLs must not be rolled up: %d rows", rawReferers)
+	}
+	helper.settings["analytics.rollup_unique_ips"] = false
+	if skipped, _, _ := rollupSvc.Analysis(1, requests[0]); skipped.UniqueIPs != 0 {
+		t.Fatalf("unique IPs must not be counted when disabled: %d", skipped.UniqueIPs)
+	}
+	delete(helper.settings, "analytics.rollup_unique_ips")
+
+	// 重建后结果与明细一致，且不影响未汇总的点击
+	if err := db.Model(&model.ClickRollup{}).Where("dimension = ?", model.ClickRollupDimensionTotal).Update("clicks", 100).Error; err != nil {
+		t.Fatalf("corrupt rollups: %v", err)
+	}
+	rebuilt, err := rollupSvc.Rebuild(1, time.Time{}, time.Time{})
+	if err != nil || rebuilt != 11 {
+		t.Fatalf("rebuild: rebuilt=%d err=%v", rebuilt, err)
+	}
+	compare("rebuilt")
+	if processed, err := rollupSvc.ProcessPending(); err != nil || processed != 4 {
+		t.Fatalf("process after rebuild: processed=%d err=%v", processed, err)
+	}
+	compare("caught up")
+
+	// 明细才有的筛选条件交由调用方扫描明细
+	if _, ok, err := rollupSvc.Analysis(1, &dto.ClickStatisticListRequest{RouteID: routeID}); err != nil || ok {
+		t.Fatalf("route filter must fall back to raw clicks: ok=%v err=%v", ok, err)
+	}
+	if _, ok, err := rollupSvc.Analysis(1, &dto.ClickStatisticListRequest{StartDate: day.Add(90 * time.Minute)}); err != nil || ok {
+		t.Fatalf("partial hour must fall back to raw clicks: ok=%v err=%v", ok, err)
+	}
+}
+
+// normalizeAnalysisForCompare 明细扫描对并列项不保证顺序，只比较内容；汇总不保留完整来源地址，不比较 top_referers
+func normalizeAnalysisForCompare(analysis *dto.ClickStatisticAnalysisResponse) map[string]any {
+	counts := func(items any) map[string]int64 {
+		result := map[string]int64{}
+		value := reflect.ValueOf(items)
+		for i := 0; i < value.Len(); i++ {
+			item := value.Index(i)
+			key := ""
+			for j := 0; j < item.NumField()-1; j++ {
+				key += fmt.Sprint(item.Field(j).Interface()) + "|"
+			}
+			result[key] = item.Field(item.NumField() - 1).Int()
+		}
+		return result
+	}
+	return map[string]any{
+		"total":        analysis.TotalClicks,
+		"unique_ips":   analysis.UniqueIPs,
+		"bots":         analysis.BotStats,
+		"countries":    counts(analysis.TopCountries),
+		"provinces":    counts(analysis.TopProvinces),
+		"cities":       counts(analysis.TopCities),
+		"isps":         counts(analysis.TopISPs),
+		"hosts":        counts(analysis.TopRefererHosts),
+		"devices":      counts(analysis.TopDevices),
+		"browsers":     counts(analysis.TopBrowsers),
+		"os":           counts(analysis.TopOS),
+		"utm_sources":  counts(analysis.TopUTMSources),
+		"utm_campaign": counts(analysis.TopUTMCampaigns),
+		"routes":       counts(analysis.TopRoutes),
+		"aliases":      counts(analysis.TopAliases),
+		"hourly":       counts(analysis.HourlyStats),
+		"daily":        counts(analysis.DailyStats),
+	}
+}
+
+func TestClickRollupCursorWaitsForLateCommits(t *testing.T) {
+	helper := newShortLinkRegressionHelper(t)
+	helper.settings["analytics.rollup_settle_seconds"] = 60
+	db := helper.GetDatabase()
+	now := time.Now()
+	// 第 2 条点击仍在等待期内，模拟ID较小但提交较晚的点击；其后已到期的第 3 条也不能越过它汇总
+	for _, createdAt := range []time.Time{now.Add(-5 * time.Minute), now, now.Add(-5 * time.Minute)} {
+		seedClickStatistic(t, db, model.ClickStatistic{WorkspaceID: 1, ShortLinkID: 1, ClickDate: createdAt, CreatedAt: createdAt})
+	}
+
+	rollupSvc := NewClickRollupService(helper)
+	if processed, err := rollupSvc.ProcessPending(); err != nil || processed != 1 {
+		t.Fatalf("only clicks before the first unsettled one may be rolled up: processed=%d err=%v", processed, err)
+	}
+	if cursor, _ := dao.NewClickRollupDao(helper).Cursor(); cursor.LastClickID != 1 {
+		t.Fatalf("cursor must stop before the unsettled click: %d", cursor.LastClickID)
+	}
+	// 等待期内的点击由明细补齐
+	analysis, ok, err := rollupSvc.Analysis(1, &dto.ClickStatisticListRequest{})
+	if err != nil || !ok || analysis.TotalClicks != 3 {
+		t.Fatalf("analysis must include unsettled clicks: %+v ok=%v err=%v", analysis, ok, err)
+	}
+
+	helper.settings["analytics.rollup_settle_seconds"] = 0
+	if processed, err := rollupSvc.ProcessPending(); err != nil || processed != 2 {
+		t.Fatalf("remaining clicks: processed=%d err=%v", processed, err)
+	}
+}
+
+func TestClickRollupRebuildJob(t *testing.T) {
+	helper := newShortLinkRegressionHelper(t)
+	db := helper.GetDatabase()
+	day := time.Date(2026, 5, 10, 0, 0, 0, 0, time.Local)
+	for _, at := range []time.Time{day.Add(9 * time.Hour), day.Add(10 * time.Hour), day.Add(33 * time.Hour)} {
+		seedClickStatistic(t, db, model.ClickStatistic{WorkspaceID: 1, ShortLinkID: 1, Country: "中国", ClickDate: at})
+	}
+	rollupSvc := NewClickRollupService(helper)
+	if _, err := rollupSvc.ProcessPending(); err != nil {
+		t.Fatalf("process pending: %v", err)
+	}
+	dayTotal := func() int64 {
+		var total int64
+		db.Model(&model.ClickRollup{}).
+			Where("workspace_id = ? AND granularity = ? AND dimension = ?", 1, model.ClickRollupGranularityDay, model.ClickRollupDimensionTotal).
+			Select("COALESCE(SUM(clicks), 0)").Scan(&total)
+		return total
+	}
+
+	// 重建读取明细后游标推进的点击，由替换时补入
+	var tail int
+	if err := dao.NewClickRollupDao(helper).ReplaceRange(1, day, day.AddDate(0, 0, 1), 1, func(statistics []model.ClickStatistic) []model.ClickRollup {
+		tail = len(statistics)
+		return buildClickRollups(statistics)
+	}); err != nil || tail != 1 {
+		t.Fatalf("replace range must top up clicks rolled up after the build: tail=%d err=%v", tail, err)
+	}
+
+	db.Model(&model.ClickRollup{}).Where("dimension = ?", model.ClickRollupDimensionTotal).Update("clicks", 100)
+	job, err := rollupSvc.CreateRebuildJob(1, 7, time.Time{}, time.Time{})
+	if err != nil || job.Status != model.ClickRollupRebuildStatusPending {
+		t.Fatalf("create rebuild job: %+v err=%v", job, err)
+	}
+	if _, err := rollupSvc.CreateRebuildJob(1, 7, time.Time{}, time.Time{}); !errors.Is(err, ErrClickRollupRebuildActive) {
+		t.Fatalf("only one active rebuild per workspace: %v", err)
+	}
+	// 提交任务不改动现有汇总
+	if total := dayTotal(); total != 200 {
+		t.Fatalf("rollups must stay untouched until the job runs: %d", total)
+	}
+
+	if rebuilt, err := rollupSvc.ProcessRebuildJobs(); err != nil || rebuilt != 3 {
+		t.Fatalf("process rebuild jobs: rebuilt=%d err=%v", rebuilt, err)
+	}
+	job, err = rollupSvc.GetRebuildJob(job.ID, 1)
+	if err != nil || job.Status != model.ClickRollupRebuildStatusCompleted || job.Clicks != 3 || job.NextDate == nil || !job.NextDate.Equal(day.AddDate(0, 0, 2)) {
+		t.Fatalf("rebuild job: %+v err=%v", job, err)
+	}
+	if total := dayTotal(); total != 3 {
+		t.Fatalf("rebuilt rollups: %d", total)
+	}
+	if _, err := rollupSvc.GetRebuildJob(job.ID, 2); !errors.Is(err, ErrClickRollupRebuildNotFound) {
+		t.Fatalf("jobs are scoped to the workspace: %v", err)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"math"
 	"strconv"
 	"strings"
 	"time"
@@ -22,6 +23,9 @@ type ClickStatisticService struct {
 	helper            interfaces.HelperInterface
 	clickStatisticDao *dao.ClickStatisticDao
 	shortLinkDao      *dao.ShortLinkDao
+	customFieldDao    *dao.CustomFieldDao
+	clickRollupSvc    *ClickRollupService
+	visitorSvc        *VisitorService
 }
 
 func NewClickStatisticService(helper interfaces.HelperInterface) *ClickStatisticService {
@@ -29,12 +33,15 @@ func NewClickStatisticService(helper interfaces.HelperInterface) *ClickStatistic
 		helper:            helper,
 		clickStatisticDao: dao.NewClickStatisticDao(helper),
 		shortLinkDao:      dao.NewShortLinkDao(helper),
+		customFieldDao:    dao.NewCustomFieldDao(helper),
+		clickRollupSvc:    NewClickRollupService(helper),
+		visitorSvc:        NewVisitorService(helper),
 	}
 }
 
 const (
 	clickStatisticAnalysisCachePrefix  = "click_statistics:analysis"
-	clickStatisticAnalysisCacheVersion = "v1"
+	clickStatisticAnalysisCacheVersion = "v5"
 	clickStatisticAnalysisCacheTTL     = 5 * time.Minute
 )
 
@@ -52,6 +59,7 @@ func (s *ClickStatisticService) GetClickStatisticListInWorkspace(req *dto.ClickS
 		req.PageSize = 10
 	}
 
+	s.expandFolderFilter(workspaceID, req)
 	statistics, total, err := s.clickStatisticDao.ListInWorkspace(workspaceID, req)
 	if err != nil {
 		return nil, err
@@ -83,19 +91,29 @@ func (s *ClickStatisticService) GetClickStatisticAnalysis(shortLinkID uint64, da
 
 func (s *ClickStatisticService) GetClickStatisticAnalysisInWorkspace(workspaceID uint64, req *dto.ClickStatisticListRequest, days int) (*dto.ClickStatisticAnalysisResponse, error) {
 	if req.StartDate.IsZero() && req.EndDate.IsZero() {
-		req.StartDate, req.EndDate = defaultClickStatisticDateRange(days)
+		req.StartDate, req.EndDate = defaultClickStatisticDateRange(days, dao.ReportLocation(req))
 	}
 
+	s.expandFolderFilter(workspaceID, req)
 	var cached dto.ClickStatisticAnalysisResponse
 	cacheKey := s.analysisCacheKey("summary", workspaceID, req, "")
 	if s.getCache(cacheKey, &cached) == nil {
 		return &cached, nil
 	}
 
-	analysis, err := s.clickStatisticDao.GetAnalysisInWorkspace(workspaceID, req)
+	// 优先读取预聚合汇总，筛选条件不受支持时扫描点击明细
+	analysis, ok, err := s.clickRollupSvc.Analysis(workspaceID, req)
 	if err != nil {
 		return nil, err
 	}
+	if !ok {
+		if analysis, err = s.clickStatisticDao.GetAnalysisInWorkspace(workspaceID, req); err != nil {
+			return nil, err
+		}
+	}
+	if analysis.UniqueVisitors, err = s.visitorSvc.CountUniqueVisitors(workspaceID, req); err != nil {
+		return nil, err
+	}
 	s.setCache(cacheKey, analysis)
 	return analysis, nil
 }
@@ -106,29 +124,104 @@ func (s *ClickStatisticService) GetClickStatisticGeoAnalysisInWorkspace(workspac
 		return nil, err
 	}
 	if req.StartDate.IsZero() && req.EndDate.IsZero() {
-		req.StartDate, req.EndDate = defaultClickStatisticDateRange(days)
+		req.StartDate, req.EndDate = defaultClickStatisticDateRange(days, dao.ReportLocation(req))
 	}
 
+	s.expandFolderFilter(workspaceID, req)
 	var cached dto.ClickStatisticGeoAnalysisResponse
 	cacheKey := s.analysisCacheKey("geo", workspaceID, req, level)
 	if s.getCache(cacheKey, &cached) == nil {
 		return &cached, nil
 	}
 
-	analysis, err := s.clickStatisticDao.GetGeoAnalysisInWorkspace(workspaceID, req, level)
+	analysis, ok, err := s.clickRollupSvc.GeoAnalysis(workspaceID, req, level)
 	if err != nil {
 		return nil, err
 	}
+	if !ok {
+		if analysis, err = s.clickStatisticDao.GetGeoAnalysisInWorkspace(workspaceID, req, level); err != nil {
+			return nil, err
+		}
+	}
 	s.setCache(cacheKey, analysis)
 	return analysis, nil
 }
 
-func defaultClickStatisticDateRange(days int) (time.Time, time.Time) {
+// GetReferrerChannelBreakdownInWorkspace 按来源渠道统计点击，每个渠道附带点击最多的来源域名
+func (s *ClickStatisticService) GetReferrerChannelBreakdownInWorkspace(workspaceID uint64, req *dto.ClickStatisticListRequest, days int) (*dto.ReferrerChannelBreakdownResponse, error) {
+	if req.StartDate.IsZero() && req.EndDate.IsZero() {
+		req.StartDate, req.EndDate = defaultClickStatisticDateRange(days, dao.ReportLocation(req))
+	}
+
+	s.expandFolderFilter(workspaceID, req)
+	var cached dto.ReferrerChannelBreakdownResponse
+	cacheKey := s.analysisCacheKey("channels", workspaceID, req, "")
+	if s.getCache(cacheKey, &cached) == nil {
+		return &cached, nil
+	}
+
+	counts, total, ok, err := s.clickRollupSvc.ChannelBreakdown(workspaceID, req)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		if counts, err = s.clickStatisticDao.CountByReferrerChannelInWorkspace(workspaceID, req); err != nil {
+			return nil, err
+		}
+		total = 0
+		for _, count := range counts {
+			total += count.Clicks
+		}
+	}
+	breakdown := buildReferrerChannelBreakdown(counts, total)
+	s.setCache(cacheKey, breakdown)
+	return breakdown, nil
+}
+
+// buildReferrerChannelBreakdown 汇总各渠道点击，未分类的历史点击计入 unknown
+func buildReferrerChannelBreakdown(counts []dao.ReferrerChannelHostCount, total int64) *dto.ReferrerChannelBreakdownResponse {
+	channels := map[string]int64{}
+	hosts := map[string]map[string]int64{}
+	var classified int64
+	for _, count := range counts {
+		if count.Channel == "" {
+			continue
+		}
+		classified += count.Clicks
+		channels[count.Channel] += count.Clicks
+		if count.Host == "" {
+			continue
+		}
+		if hosts[count.Channel] == nil {
+			hosts[count.Channel] = map[string]int64{}
+		}
+		hosts[count.Channel][count.Host] += count.Clicks
+	}
+	if total > classified {
+		channels[model.ReferrerChannelUnknown] += total - classified
+	}
+
+	breakdown := &dto.ReferrerChannelBreakdownResponse{TotalClicks: total, Channels: []dto.ReferrerChannelStatistic{}}
+	for _, item := range topClickRollupValues(channels, 0) {
+		statistic := dto.ReferrerChannelStatistic{Channel: item.Value, Clicks: item.Count, TopHosts: []dto.RefererStatistic{}}
+		if total > 0 {
+			statistic.Percentage = math.Round(float64(item.Count)*10000/float64(total)) / 100
+		}
+		for _, host := range topClickRollupValues(hosts[item.Value], clickRollupTopLimit) {
+			statistic.TopHosts = append(statistic.TopHosts, dto.RefererStatistic{Referer: host.Value, Count: host.Count})
+		}
+		breakdown.Channels = append(breakdown.Channels, statistic)
+	}
+	return breakdown
+}
+
+// defaultClickStatisticDateRange 最近 days 天（含今天），按报表时区划分
+func defaultClickStatisticDateRange(days int, location *time.Location) (time.Time, time.Time) {
 	if days < 1 || days > 365 {
 		days = 7
 	}
-	now := time.Now()
-	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	now := time.Now().In(location)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, location)
 	return today.AddDate(0, 0, -(days - 1)), today.AddDate(0, 0, 1)
 }
 
@@ -150,6 +243,13 @@ func (s *ClickStatisticService) analysisCacheKey(kind string, workspaceID uint64
 	if req.IsBot != nil {
 		isBot = strconv.FormatBool(*req.IsBot)
 	}
+	minBotScore, maxBotScore := "", ""
+	if req.MinBotScore != nil {
+		minBotScore = strconv.Itoa(*req.MinBotScore)
+	}
+	if req.MaxBotScore != nil {
+		maxBotScore = strconv.Itoa(*req.MaxBotScore)
+	}
 	raw := strings.Join([]string{
 		clickStatisticAnalysisCacheVersion,
 		kind,
@@ -158,14 +258,22 @@ func (s *ClickStatisticService) analysisCacheKey(kind string, workspaceID uint64
 		"short_link_id=" + strconv.FormatUint(req.ShortLinkID, 10),
 		"campaign_id=" + strconv.FormatUint(req.CampaignID, 10),
 		"route_id=" + strconv.FormatUint(req.RouteID, 10),
+		"alias_id=" + strconv.FormatUint(req.AliasID, 10),
 		"tag_id=" + strconv.FormatUint(req.TagID, 10),
+		"folder_ids=" + fmt.Sprint(req.FolderIDs),
 		"device_type=" + req.DeviceType,
 		"is_bot=" + isBot,
+		"min_bot_score=" + minBotScore,
+		"max_bot_score=" + maxBotScore,
+		"bot_reason=" + req.BotReason,
+		"tz=" + dao.ReportLocation(req).String(),
+		"include_duplicates=" + strconv.FormatBool(req.WithDuplicates),
 		"ip=" + req.IP,
 		"country=" + req.Country,
 		"province=" + req.Province,
 		"city=" + req.City,
 		"isp=" + req.ISP,
+		"referer_channel=" + req.RefererChannel,
 		"start_date=" + req.StartDate.Format(time.RFC3339Nano),
 		"end_date=" + req.EndDate.Format(time.RFC3339Nano),
 	}, "|")
@@ -173,6 +281,16 @@ func (s *ClickStatisticService) analysisCacheKey(kind string, workspaceID uint64
 	return fmt.Sprintf("%s:%s:%s", clickStatisticAnalysisCachePrefix, kind, hex.EncodeToString(sum[:]))
 }
 
+// expandFolderFilter 按文件夹筛选时包含其全部子文件夹
+func (s *ClickStatisticService) expandFolderFilter(workspaceID uint64, req *dto.ClickStatisticListRequest) {
+	if req.FolderID == 0 || len(req.FolderIDs) > 0 {
+		return
+	}
+	if folderIDs, err := NewFolderService(s.helper).SubtreeIDs(req.FolderID, workspaceID); err == nil {
+		req.FolderIDs = folderIDs
+	}
+}
+
 func (s *ClickStatisticService) getCache(key string, dest any) error {
 	cache := s.helper.GetCache()
 	if cache == nil {
@@ -198,64 +316,27 @@ func (s *ClickStatisticService) GetClickStatisticAnalysisByDateRange(shortLinkID
 
 func (s *ClickStatisticService) ExportCSV(workspaceID uint64, req *dto.ClickStatisticListRequest) ([]byte, error) {
 	const maxRows = 50000
+	s.expandFolderFilter(workspaceID, req)
 	statistics, err := s.clickStatisticDao.ExportInWorkspace(workspaceID, req, maxRows+1)
 	if err != nil {
 		return nil, err
 	}
 	if len(statistics) > maxRows {
-		return nil, errors.New("导出数据超过 50000 行，请缩小筛选范围")
+		return nil, errors.New("导出数据超过 50000 行，请缩小筛选范围或使用异步导出")
+	}
+	customFields, customValues, err := s.exportCustomValues(workspaceID, statistics)
+	if err != nil {
+		return nil, err
 	}
 
 	buffer := &bytes.Buffer{}
 	buffer.Write([]byte{0xEF, 0xBB, 0xBF})
 	writer := csv.NewWriter(buffer)
-	header := []string{
-		"id", "workspace_id", "campaign_id", "route_id", "route_name", "short_link_id", "ip", "user_agent", "referer", "query_params",
-		"utm_source", "utm_medium", "utm_campaign", "utm_term", "utm_content",
-		"device_type", "browser", "os", "is_bot", "bot_name",
-		"country", "province", "city", "isp", "click_date", "created_at",
-	}
-	if err := writer.Write(header); err != nil {
+	if err := writer.Write(clickStatisticCSVHeader(customFields)); err != nil {
 		return nil, err
 	}
 	for _, stat := range statistics {
-		campaignID := ""
-		if stat.CampaignID != nil {
-			campaignID = strconv.FormatUint(*stat.CampaignID, 10)
-		}
-		routeID := ""
-		if stat.RouteID != nil {
-			routeID = strconv.FormatUint(*stat.RouteID, 10)
-		}
-		record := []string{
-			strconv.FormatUint(stat.ID, 10),
-			strconv.FormatUint(stat.WorkspaceID, 10),
-			campaignID,
-			routeID,
-			stat.RouteName,
-			strconv.FormatUint(stat.ShortLinkID, 10),
-			stat.IP,
-			stat.UserAgent,
-			stat.Referer,
-			stat.QueryParams,
-			stat.UTMSource,
-			stat.UTMMedium,
-			stat.UTMCampaign,
-			stat.UTMTerm,
-			stat.UTMContent,
-			stat.DeviceType,
-			stat.Browser,
-			stat.OS,
-			strconv.FormatBool(stat.IsBot),
-			stat.BotName,
-			stat.Country,
-			stat.Province,
-			stat.City,
-			stat.ISP,
-			stat.ClickDate.Format(time.RFC3339),
-			stat.CreatedAt.Format(time.RFC3339),
-		}
-		if err := writer.Write(record); err != nil {
+		if err := writer.Write(clickStatisticCSVRecord(&stat, customFields, customValues)); err != nil {
 			return nil, err
 		}
 	}
@@ -266,36 +347,116 @@ func (s *ClickStatisticService) ExportCSV(workspaceID uint64, req *dto.ClickStat
 	return buffer.Bytes(), nil
 }
 
+// clickStatisticCSVHeader 点击明细 CSV 表头，同步导出与异步导出共用
+func clickStatisticCSVHeader(customFields []model.CustomField) []string {
+	header := []string{
+		"id", "workspace_id", "campaign_id", "route_id", "route_name", "alias_id", "alias_code", "short_link_id", "ip", "user_agent", "referer", "referer_host", "referer_channel", "query_params",
+		"utm_source", "utm_medium", "utm_campaign", "utm_term", "utm_content",
+		"device_type", "browser", "os", "is_bot", "bot_name",
+		"country", "province", "city", "isp", "click_date", "created_at", "bot_score", "bot_reason", "is_duplicate",
+	}
+	for _, field := range customFields {
+		header = append(header, "cf_"+field.FieldKey)
+	}
+	return header
+}
+
+// clickStatisticCSVRecord 一条点击明细的 CSV 行，customValues 按短网址ID和字段ID索引
+func clickStatisticCSVRecord(stat *model.ClickStatistic, customFields []model.CustomField, customValues map[uint64]map[uint64]string) []string {
+	campaignID := ""
+	if stat.CampaignID != nil {
+		campaignID = strconv.FormatUint(*stat.CampaignID, 10)
+	}
+	routeID := ""
+	if stat.RouteID != nil {
+		routeID = strconv.FormatUint(*stat.RouteID, 10)
+	}
+	aliasID := ""
+	if stat.AliasID != nil {
+		aliasID = strconv.FormatUint(*stat.AliasID, 10)
+	}
+	record := []string{
+		strconv.FormatUint(stat.ID, 10),
+		strconv.FormatUint(stat.WorkspaceID, 10),
+		campaignID,
+		routeID,
+		stat.RouteName,
+		aliasID,
+		stat.AliasCode,
+		strconv.FormatUint(stat.ShortLinkID, 10),
+		stat.IP,
+		stat.UserAgent,
+		stat.Referer,
+		stat.RefererHost,
+		stat.RefererChannel,
+		stat.QueryParams,
+		stat.UTMSource,
+		stat.UTMMedium,
+		stat.UTMCampaign,
+		stat.UTMTerm,
+		stat.UTMContent,
+		stat.DeviceType,
+		stat.Browser,
+		stat.OS,
+		strconv.FormatBool(stat.IsBot),
+		stat.BotName,
+		stat.Country,
+		stat.Province,
+		stat.City,
+		stat.ISP,
+		stat.ClickDate.Format(time.RFC3339),
+		stat.CreatedAt.Format(time.RFC3339),
+		strconv.Itoa(stat.BotScore),
+		stat.BotReason,
+		strconv.FormatBool(stat.IsDuplicate),
+	}
+	for _, field := range customFields {
+		record = append(record, customValues[stat.ShortLinkID][field.ID])
+	}
+	return record
+}
+
+// exportCustomValues 导出时每个自定义字段一列（cf_<字段标识>），取点击所属短网址的当前取值
+func (s *ClickStatisticService) exportCustomValues(workspaceID uint64, statistics []model.ClickStatistic) ([]model.CustomField, map[uint64]map[uint64]string, error) {
+	fields, err := s.customFieldDao.ListInWorkspace(workspaceID)
+	if err != nil || len(fields) == 0 {
+		return nil, nil, err
+	}
+	byLink, err := s.customValuesByShortLink(statistics)
+	if err != nil {
+		return nil, nil, err
+	}
+	return fields, byLink, nil
+}
+
+// customValuesByShortLink 读取点击所属短网址的自定义字段取值，按短网址ID和字段ID索引
+func (s *ClickStatisticService) customValuesByShortLink(statistics []model.ClickStatistic) (map[uint64]map[uint64]string, error) {
+	seen := make(map[uint64]struct{})
+	shortLinkIDs := make([]uint64, 0)
+	for _, stat := range statistics {
+		if _, ok := seen[stat.ShortLinkID]; ok {
+			continue
+		}
+		seen[stat.ShortLinkID] = struct{}{}
+		shortLinkIDs = append(shortLinkIDs, stat.ShortLinkID)
+	}
+	values, err := s.customFieldDao.ValuesByShortLinkIDs(shortLinkIDs)
+	if err != nil {
+		return nil, err
+	}
+	byLink := make(map[uint64]map[uint64]string, len(shortLinkIDs))
+	for _, value := range values {
+		if byLink[value.ShortLinkID] == nil {
+			byLink[value.ShortLinkID] = make(map[uint64]string)
+		}
+		byLink[value.ShortLinkID][value.FieldID] = value.Value
+	}
+	return byLink, nil
+}
+
 // modelToResponse 将模型转换为响应格式
 func (s *ClickStatisticService) modelToResponse(statistic *model.ClickStatistic) *dto.ClickStatisticDetailResponse {
-	response := &dto.ClickStatisticDetailResponse{
-		ID:          statistic.ID,
-		WorkspaceID: statistic.WorkspaceID,
-		CampaignID:  statistic.CampaignID,
-		RouteID:     statistic.RouteID,
-		RouteName:   statistic.RouteName,
-		ShortLinkID: statistic.ShortLinkID,
-		IP:          statistic.IP,
-		UserAgent:   statistic.UserAgent,
-		Referer:     statistic.Referer,
-		QueryParams: statistic.QueryParams,
-		UTMSource:   statistic.UTMSource,
-		UTMMedium:   statistic.UTMMedium,
-		UTMCampaign: statistic.UTMCampaign,
-		UTMTerm:     statistic.UTMTerm,
-		UTMContent:  statistic.UTMContent,
-		DeviceType:  statistic.DeviceType,
-		Browser:     statistic.Browser,
-		OS:          statistic.OS,
-		IsBot:       statistic.IsBot,
-		BotName:     statistic.BotName,
-		Country:     statistic.Country,
-		Province:    statistic.Province,
-		City:        statistic.City,
-		ISP:         statistic.ISP,
-		ClickDate:   statistic.ClickDate,
-		CreatedAt:   statistic.CreatedAt,
-	}
+	response := clickStatisticDetail(statistic)
 
 	// 获取短链接信息
 	if shortLink, err := s.shortLinkDao.FindByID(statistic.ShortLinkID); err == nil {
@@ -306,3 +467,42 @@ func (s *ClickStatisticService) modelToResponse(statistic *model.ClickStatistic)
 
 	return response
 }
+
+// clickStatisticDetail 点击明细字段，不含短网址信息
+func clickStatisticDetail(statistic *model.ClickStatistic) *dto.ClickStatisticDetailResponse {
+	return &dto.ClickStatisticDetailResponse{
+		ID:             statistic.ID,
+		WorkspaceID:    statistic.WorkspaceID,
+		CampaignID:     statistic.CampaignID,
+		RouteID:        statistic.RouteID,
+		RouteName:      statistic.RouteName,
+		AliasID:        statistic.AliasID,
+		AliasCode:      statistic.AliasCode,
+		ShortLinkID:    statistic.ShortLinkID,
+		IP:             statistic.IP,
+		UserAgent:      statistic.UserAgent,
+		Referer:        statistic.Referer,
+		RefererHost:    statistic.RefererHost,
+		RefererChannel: statistic.RefererChannel,
+		QueryParams:    statistic.QueryParams,
+		UTMSource:      statistic.UTMSource,
+		UTMMedium:      statistic.UTMMedium,
+		UTMCampaign:    statistic.UTMCampaign,
+		UTMTerm:        statistic.UTMTerm,
+		UTMContent:     statistic.UTMContent,
+		DeviceType:     statistic.DeviceType,
+		Browser:        statistic.Browser,
+		OS:             statistic.OS,
+		IsBot:          statistic.IsBot,
+		BotName:        statistic.BotName,
+		BotScore:       statistic.BotScore,
+		BotReason:      statistic.BotReason,
+		IsDuplicate:    statistic.IsDuplicate,
+		Country:        statistic.Country,
+		Province:       statistic.Province,
+		City:           statistic.City,
+		ISP:            statistic.ISP,
+		ClickDate:      statistic.ClickDate,
+		CreatedAt:      statistic.CreatedAt,
+	}
+}
@@ -0,0 +1,285 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/dao"
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/dto"
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/model"
+	"cnb.cool/mliev/dwz/dwz-server/v2/pkg/interfaces"
+)
+
+const (
+	clickStreamRedisChannel          = "dwz:click_stream"
+	clickStreamActiveKeyPrefix       = "dwz:click_stream:active:"
+	clickStreamActiveTTL             = time.Minute     // 订阅连接每次刷新时续期，需长于连接的心跳间隔
+	clickStreamActiveCheckInterval   = 5 * time.Second // 发布前检查是否有订阅的结果缓存时间
+	clickStreamBufferSize            = 64
+	defaultClickStreamMaxSubscribers = 50
+)
+
+var ErrClickStreamTooManySubscribers = errors.New("当前工作区的实时点击流连接数已达上限")
+
+// clickStreamHub 进程内的点击事件广播器，所有实例的点击经 Redis 发布/订阅汇集到各实例的广播器
+var clickStreamHub = &clickStreamBroadcaster{
+	subscribers: make(map[*ClickStreamSubscription]struct{}),
+	instanceID:  newClickStreamInstanceID(),
+	active:      make(map[uint64]clickStreamActiveCheck),
+}
+
+type clickStreamBroadcaster struct {
+	mu          sync.RWMutex
+	subscribers map[*ClickStreamSubscription]struct{}
+	instanceID  string
+	relayOnce   sync.Once
+
+	activeMu sync.Mutex
+	active   map[uint64]clickStreamActiveCheck // 各工作区在所有实例上是否有订阅
+}
+
+type clickStreamActiveCheck struct {
+	active    bool
+	checkedAt time.Time
+}
+
+// clickStreamMessage 经 Redis 转发的点击事件，Origin 用于忽略本实例发出的消息
+type clickStreamMessage struct {
+	Origin      string               `json:"origin"`
+	WorkspaceID uint64               `json:"workspace_id"`
+	Event       dto.ClickStreamEvent `json:"event"`
+}
+
+// ClickStreamSubscription 一个实时点击流连接的订阅
+type ClickStreamSubscription struct {
+	workspaceID  uint64
+	req          dto.ClickStreamRequest
+	events       chan dto.ClickStreamEvent
+	shortLinkIDs atomic.Pointer[map[uint64]struct{}] // 按标签订阅时标签下的短网址
+	dropped      atomic.Int64
+	closeOnce    sync.Once
+	service      *ClickStreamService
+}
+
+// ClickStreamService 实时点击流
+type ClickStreamService struct {
+	helper            interfaces.HelperInterface
+	clickStatisticDao *dao.ClickStatisticDao
+}
+
+func NewClickStreamService(helper interfaces.HelperInterface) *ClickStreamService {
+	return &ClickStreamService{
+		helper:            helper,
+		clickStatisticDao: dao.NewClickStatisticDao(helper),
+	}
+}
+
+// Subscribe 订阅当前工作区的点击事件，使用完毕需调用 Close
+func (s *ClickStreamService) Subscribe(workspaceID uint64, req *dto.ClickStreamRequest) (*ClickStreamSubscription, error) {
+	subscription := &ClickStreamSubscription{
+		workspaceID: workspaceID,
+		req:         *req,
+		events:      make(chan dto.ClickStreamEvent, clickStreamBufferSize),
+		service:     s,
+	}
+	if err := subscription.Refresh(); err != nil {
+		return nil, err
+	}
+
+	maxSubscribers := s.helper.GetConfig().GetInt("analytics.click_stream_max_subscribers", defaultClickStreamMaxSubscribers)
+	clickStreamHub.mu.Lock()
+	count := 0
+	for existing := range clickStreamHub.subscribers {
+		if existing.workspaceID == workspaceID {
+			count++
+		}
+	}
+	if maxSubscribers > 0 && count >= maxSubscribers {
+		clickStreamHub.mu.Unlock()
+		return nil, ErrClickStreamTooManySubscribers
+	}
+	clickStreamHub.subscribers[subscription] = struct{}{}
+	clickStreamHub.mu.Unlock()
+
+	s.startRelay()
+	return subscription, nil
+}
+
+// Publish 广播一次点击，在跳转记录点击后调用
+func (s *ClickStreamService) Publish(shortLink *model.ShortLink, domain, shortCode string, statistic *model.ClickStatistic) {
+	if !s.helper.GetConfig().GetBool("analytics.click_stream_enabled", true) {
+		return
+	}
+	event := dto.ClickStreamEvent{
+		ID:             statistic.ID,
+		ShortLinkID:    statistic.ShortLinkID,
+		Domain:         domain,
+		ShortCode:      shortCode,
+		CampaignID:     statistic.CampaignID,
+		RouteID:        statistic.RouteID,
+		RouteName:      statistic.RouteName,
+		Country:        statistic.Country,
+		Province:       statistic.Province,
+		City:           statistic.City,
+		DeviceType:     statistic.DeviceType,
+		Browser:        statistic.Browser,
+		OS:             statistic.OS,
+		IsBot:          statistic.IsBot,
+		BotScore:       statistic.BotScore,
+		IsDuplicate:    statistic.IsDuplicate,
+		RefererHost:    statistic.RefererHost,
+		RefererChannel: statistic.RefererChannel,
+		UTMSource:      statistic.UTMSource,
+		UTMCampaign:    statistic.UTMCampaign,
+		ClickedAt:      statistic.ClickDate,
+	}
+	clickStreamHub.dispatch(shortLink.WorkspaceID, event)
+
+	// 没有任何实例订阅该工作区时不经 Redis 转发
+	if client := s.helper.GetRedis(); client != nil && s.hasSubscribers(shortLink.WorkspaceID) {
+		payload, err := json.Marshal(clickStreamMessage{Origin: clickStreamHub.instanceID, WorkspaceID: shortLink.WorkspaceID, Event: event})
+		if err != nil {
+			return
+		}
+		if err := client.Publish(context.Background(), clickStreamRedisChannel, payload).Err(); err != nil {
+			s.helper.GetLogger().Warn("发布实时点击事件失败: " + err.Error())
+		}
+	}
+}
+
+// markActive 标记工作区有订阅，供各实例发布前判断是否需要经 Redis 转发
+func (s *ClickStreamService) markActive(workspaceID uint64) {
+	client := s.helper.GetRedis()
+	if client == nil {
+		return
+	}
+	key := clickStreamActiveKeyPrefix + strconv.FormatUint(workspaceID, 10)
+	if err := client.Set(context.Background(), key, clickStreamHub.instanceID, clickStreamActiveTTL).Err(); err != nil {
+		s.helper.GetLogger().Warn("标记实时点击流订阅失败: " + err.Error())
+	}
+}
+
+// hasSubscribers 工作区在任一实例上是否有订阅，结果在本实例缓存几秒，避免每次点击都查询 Redis
+func (s *ClickStreamService) hasSubscribers(workspaceID uint64) bool {
+	clickStreamHub.activeMu.Lock()
+	check, ok := clickStreamHub.active[workspaceID]
+	clickStreamHub.activeMu.Unlock()
+	if ok && time.Since(check.checkedAt) < clickStreamActiveCheckInterval {
+		return check.active
+	}
+	key := clickStreamActiveKeyPrefix + strconv.FormatUint(workspaceID, 10)
+	exists, err := s.helper.GetRedis().Exists(context.Background(), key).Result()
+	// 查询失败时照常发布，避免漏掉其他实例的订阅
+	check = clickStreamActiveCheck{active: err != nil || exists > 0, checkedAt: time.Now()}
+	clickStreamHub.activeMu.Lock()
+	clickStreamHub.active[workspaceID] = check
+	clickStreamHub.activeMu.Unlock()
+	return check.active
+}
+
+// startRelay 首次订阅时开始接收其他实例经 Redis 发布的点击
+func (s *ClickStreamService) startRelay() {
+	client := s.helper.GetRedis()
+	if client == nil {
+		return
+	}
+	clickStreamHub.relayOnce.Do(func() {
+		go func() {
+			pubsub := client.Subscribe(context.Background(), clickStreamRedisChannel)
+			defer pubsub.Close()
+			for message := range pubsub.Channel() {
+				var decoded clickStreamMessage
+				if err := json.Unmarshal([]byte(message.Payload), &decoded); err != nil || decoded.Origin == clickStreamHub.instanceID {
+					continue
+				}
+				clickStreamHub.dispatch(decoded.WorkspaceID, decoded.Event)
+			}
+		}()
+	})
+}
+
+func (b *clickStreamBroadcaster) dispatch(workspaceID uint64, event dto.ClickStreamEvent) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for subscription := range b.subscribers {
+		if subscription.workspaceID != workspaceID || !subscription.matches(event) {
+			continue
+		}
+		// 连接消费过慢时丢弃事件，不阻塞跳转
+		select {
+		case subscription.events <- event:
+		default:
+			subscription.dropped.Add(1)
+		}
+	}
+}
+
+// Events 点击事件通道，订阅关闭后通道关闭
+func (sub *ClickStreamSubscription) Events() <-chan dto.ClickStreamEvent {
+	return sub.events
+}
+
+// Dropped 因连接消费过慢而丢弃的事件数
+func (sub *ClickStreamSubscription) Dropped() int64 {
+	return sub.dropped.Load()
+}
+
+// Refresh 续期订阅标记；按标签订阅时重新加载标签下的短网址，标签变更后由连接定期调用
+func (sub *ClickStreamSubscription) Refresh() error {
+	sub.service.markActive(sub.workspaceID)
+	if sub.req.TagID == 0 {
+		return nil
+	}
+	ids, err := sub.service.clickStatisticDao.FilterShortLinkIDsInWorkspace(sub.workspaceID, &dto.ClickStatisticListRequest{TagID: sub.req.TagID})
+	if err != nil {
+		return err
+	}
+	shortLinkIDs := make(map[uint64]struct{}, len(ids))
+	for _, id := range ids {
+		shortLinkIDs[id] = struct{}{}
+	}
+	sub.shortLinkIDs.Store(&shortLinkIDs)
+	return nil
+}
+
+// Close 取消订阅
+func (sub *ClickStreamSubscription) Close() {
+	sub.closeOnce.Do(func() {
+		clickStreamHub.mu.Lock()
+		delete(clickStreamHub.subscribers, sub)
+		clickStreamHub.mu.Unlock()
+		close(sub.events)
+	})
+}
+
+func (sub *ClickStreamSubscription) matches(event dto.ClickStreamEvent) bool {
+	if sub.req.ShortLinkID > 0 && event.ShortLinkID != sub.req.ShortLinkID {
+		return false
+	}
+	if sub.req.CampaignID > 0 && (event.CampaignID == nil || *event.CampaignID != sub.req.CampaignID) {
+		return false
+	}
+	if sub.req.TagID > 0 {
+		shortLinkIDs := sub.shortLinkIDs.Load()
+		if shortLinkIDs == nil {
+			return false
+		}
+		if _, ok := (*shortLinkIDs)[event.ShortLinkID]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func newClickStreamInstanceID() string {
+	random := make([]byte, 8)
+	_, _ = rand.Read(random)
+	return hex.EncodeToString(random)
+}
@@ -0,0 +1,96 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/dto"
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/model"
+)
+
+func TestClickStreamDeliversSanitizedScopedEvents(t *testing.T) {
+	helper := newShortLinkRegressionHelper(t)
+	db := helper.GetDatabase()
+	seedBatchShortLinkDomain(t, db)
+	tag := model.Tag{WorkspaceID: 1, Name: "live"}
+	if err := db.Create(&tag).Error; err != nil {
+		t.Fatalf("seed tag: %v", err)
+	}
+
+	shortLinkSvc := NewShortLinkService(helper, context.Background())
+	tagged, err := shortLinkSvc.CreateShortLinkInWorkspace(&dto.CreateShortLinkRequest{
+		OriginalURL: "https://example.com/tagged",
+		Domain:      "batch.dwz.do",
+		CustomCode:  "tagged",
+		TagIDs:      []uint64{tag.ID},
+	}, "", 1, 7)
+	if err != nil {
+		t.Fatalf("create tagged link: %v", err)
+	}
+	other, err := shortLinkSvc.CreateShortLinkInWorkspace(&dto.CreateShortLinkRequest{
+		OriginalURL: "https://example.com/other",
+		Domain:      "batch.dwz.do",
+		CustomCode:  "other",
+	}, "", 1, 7)
+	if err != nil {
+		t.Fatalf("create other link: %v", err)
+	}
+
+	streamSvc := NewClickStreamService(helper)
+	all, err := streamSvc.Subscribe(1, &dto.ClickStreamRequest{})
+	if err != nil {
+		t.Fatalf("subscribe workspace: %v", err)
+	}
+	defer all.Close()
+	byTag, err := streamSvc.Subscribe(1, &dto.ClickStreamRequest{TagID: tag.ID})
+	if err != nil {
+		t.Fatalf("subscribe tag: %v", err)
+	}
+	defer byTag.Close()
+	otherWorkspace, err := streamSvc.Subscribe(2, &dto.ClickStreamRequest{})
+	if err != nil {
+		t.Fatalf("subscribe other workspace: %v", err)
+	}
+	defer otherWorkspace.Close()
+
+	receive := func(subscription *ClickStreamSubscription) dto.ClickStreamEvent {
+		t.Helper()
+		select {
+		case event := <-subscription.Events():
+			return event
+		case <-time.After(2 * time.Second):
+			t.Fatal("timed out waiting for click event")
+		}
+		return dto.ClickStreamEvent{}
+	}
+	if _, err := shortLinkSvc.ResolveRedirectWithSecurity("batch.dwz.do", "tagged", "8.8.8.8", "Mozilla/5.0", "https://www.example.org/post?id=1", "", ""); err != nil {
+		t.Fatalf("redirect tagged: %v", err)
+	}
+	event := receive(all)
+	if event.ShortLinkID != tagged.ID || event.ShortCode != "tagged" || event.RefererHost != "example.org" || event.ID == 0 {
+		t.Fatalf("unexpected event: %+v", event)
+	}
+	if tagEvent := receive(byTag); tagEvent.ID != event.ID {
+		t.Fatalf("tag subscriber must receive tagged link click: %+v", tagEvent)
+	}
+
+	if _, err := shortLinkSvc.ResolveRedirectWithSecurity("batch.dwz.do", "other", "8.8.4.4", "Mozilla/5.0", "", "", ""); err != nil {
+		t.Fatalf("redirect other: %v", err)
+	}
+	if event := receive(all); event.ShortLinkID != other.ID {
+		t.Fatalf("workspace subscriber must receive every link: %+v", event)
+	}
+	select {
+	case event := <-byTag.Events():
+		t.Fatalf("tag subscriber must not receive untagged link: %+v", event)
+	case event := <-otherWorkspace.Events():
+		t.Fatalf("other workspace must not receive clicks: %+v", event)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	all.Close()
+	if _, ok := <-all.Events(); ok {
+		t.Fatal("closed subscription must close its channel")
+	}
+}
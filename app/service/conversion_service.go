@@ -0,0 +1,434 @@
+package service
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/dao"
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/dto"
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/model"
+	"cnb.cool/mliev/dwz/dwz-server/v2/pkg/interfaces"
+	"cnb.cool/mliev/dwz/dwz-server/v2/pkg/service/domain_validate"
+	"gorm.io/gorm"
+)
+
+const (
+	ConversionTimestampHeader = "X-DWZ-Timestamp"
+	ConversionSignatureHeader = "X-DWZ-Signature"
+
+	defaultConversionClickIDParam       = "dwz_click_id"
+	defaultConversionEvent              = "conversion"
+	defaultConversionSignatureTolerance = 300
+	defaultConversionAttributionDays    = 30
+	defaultConversionClickLookupWaitMs  = 2000
+	conversionClickLookupInterval       = 100 * time.Millisecond
+)
+
+var (
+	ErrConversionBadRequest       = errors.New("转化回传参数无效")
+	ErrConversionInvalidSignature = errors.New("转化回传签名无效")
+	ErrConversionExpiredSignature = errors.New("转化回传签名已过期")
+	ErrConversionClickNotFound    = errors.New("点击ID无效")
+	ErrConversionOutsideWindow    = errors.New("转化超出归因窗口")
+)
+
+type ConversionService struct {
+	helper        interfaces.HelperInterface
+	conversionDao *dao.ConversionDao
+	workspaceDao  *dao.WorkspaceDao
+}
+
+func NewConversionService(helper interfaces.HelperInterface) *ConversionService {
+	return &ConversionService{
+		helper:        helper,
+		conversionDao: dao.NewConversionDao(helper),
+		workspaceDao:  dao.NewWorkspaceDao(helper),
+	}
+}
+
+// RecordPostback 服务端回传，签名为 hex(HMAC-SHA256(工作区密钥, 时间戳 + "." + 请求体))
+func (s *ConversionService) RecordPostback(body []byte, timestamp, signature, clientIP, userAgent string) (*dto.ConversionResponse, error) {
+	var req dto.ConversionRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		return nil, ErrConversionBadRequest
+	}
+	if err := normalizeConversionRequest(&req); err != nil {
+		return nil, err
+	}
+	// 先按点击ID中的工作区校验签名，未通过签名的请求无法探测点击ID是否存在
+	workspaceID, ok := clickIDWorkspace(req.ClickID)
+	if !ok {
+		return nil, ErrConversionClickNotFound
+	}
+	workspace, err := s.workspaceDao.FindByID(workspaceID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrConversionInvalidSignature
+		}
+		return nil, err
+	}
+	if err := s.verifySignature(workspace.ConversionSecret, timestamp, signature, body, time.Now()); err != nil {
+		return nil, err
+	}
+	wait := s.helper.GetConfig().GetInt("conversion.click_lookup_wait_ms", defaultConversionClickLookupWaitMs)
+	click, err := s.findClick(req.ClickID, time.Duration(wait)*time.Millisecond)
+	if err != nil {
+		return nil, err
+	}
+	if click.WorkspaceID != workspace.ID {
+		return nil, ErrConversionClickNotFound
+	}
+	return s.record(click, &req, model.ConversionSourcePostback, clientIP, userAgent)
+}
+
+// RecordPixel 像素回传，依赖点击ID不可猜测，不校验签名。接口无需认证，格式不符的点击ID直接拒绝，
+// 只查找一次点击不等待异步写入；工作区未开启 pixel_conversion_value 时忽略 value、currency
+func (s *ConversionService) RecordPixel(req *dto.ConversionRequest, clientIP, userAgent string) (*dto.ConversionResponse, error) {
+	req.Metadata = nil
+	req.OccurredAt = nil
+	if err := normalizeConversionRequest(req); err != nil {
+		return nil, err
+	}
+	workspaceID, ok := clickIDWorkspace(req.ClickID)
+	if !ok {
+		return nil, ErrConversionClickNotFound
+	}
+	click, err := s.findClick(req.ClickID, 0)
+	if err != nil {
+		return nil, err
+	}
+	if click.WorkspaceID != workspaceID {
+		return nil, ErrConversionClickNotFound
+	}
+	workspace, err := s.workspaceDao.FindByID(workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	if !workspace.PixelConversionValue {
+		req.Value, req.Currency = nil, ""
+	}
+	return s.record(click, req, model.ConversionSourcePixel, clientIP, userAgent)
+}
+
+// GetPostbackSettings 获取回传设置，首次获取时生成签名密钥
+func (s *ConversionService) GetPostbackSettings(workspaceID uint64) (*dto.ConversionPostbackSettingsResponse, error) {
+	workspace, err := s.findWorkspace(workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	if workspace.ConversionSecret == "" {
+		if err := s.resetSecret(workspace); err != nil {
+			return nil, err
+		}
+	}
+	return s.settingsResponse(workspace), nil
+}
+
+// RotateSecret 重新生成签名密钥，旧密钥立即失效
+func (s *ConversionService) RotateSecret(workspaceID uint64) (*dto.ConversionPostbackSettingsResponse, error) {
+	workspace, err := s.findWorkspace(workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.resetSecret(workspace); err != nil {
+		return nil, err
+	}
+	return s.settingsResponse(workspace), nil
+}
+
+// Report 按短网址、活动、标签或路由汇总点击、转化和收入
+func (s *ConversionService) Report(workspaceID uint64, req *dto.ConversionReportRequest) (*dto.ConversionReportResponse, error) {
+	if req.GroupBy == "" {
+		req.GroupBy = "link"
+	}
+	clicks, err := s.conversionDao.GroupClicks(workspaceID, req)
+	if err != nil {
+		return nil, err
+	}
+	totals, err := s.conversionDao.GroupConversions(workspaceID, req)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make(map[uint64]*dto.ConversionReportItem)
+	item := func(id uint64) *dto.ConversionReportItem {
+		if existing, ok := items[id]; ok {
+			return existing
+		}
+		created := &dto.ConversionReportItem{ID: id, Revenue: map[string]float64{}}
+		items[id] = created
+		return created
+	}
+	response := &dto.ConversionReportResponse{GroupBy: req.GroupBy, TotalRevenue: map[string]float64{}}
+	for _, row := range clicks {
+		item(row.GroupID).Clicks += row.Clicks
+		response.TotalClicks += row.Clicks
+	}
+	for _, row := range totals {
+		current := item(row.GroupID)
+		current.Conversions += row.Conversions
+		current.Revenue[row.Currency] = roundRevenue(current.Revenue[row.Currency] + row.Revenue)
+		response.TotalConversions += row.Conversions
+		response.TotalRevenue[row.Currency] = roundRevenue(response.TotalRevenue[row.Currency] + row.Revenue)
+	}
+	if req.GroupBy == "tag" {
+		// 一条短网址可以有多个标签，合计按未分组的口径重新统计
+		overall := *req
+		overall.GroupBy = "link"
+		if response.TotalClicks, response.TotalConversions, response.TotalRevenue, err = s.reportTotals(workspaceID, &overall); err != nil {
+			return nil, err
+		}
+	}
+
+	ids := make([]uint64, 0, len(items))
+	for id := range items {
+		if id > 0 {
+			ids = append(ids, id)
+		}
+	}
+	names, err := s.conversionDao.GroupNames(req.GroupBy, ids)
+	if err != nil {
+		return nil, err
+	}
+	response.Items = make([]dto.ConversionReportItem, 0, len(items))
+	for id, current := range items {
+		current.Name = names[id]
+		if current.Clicks > 0 {
+			current.ConversionRate = math.Round(float64(current.Conversions)/float64(current.Clicks)*10000) / 10000
+		}
+		response.Items = append(response.Items, *current)
+	}
+	sort.Slice(response.Items, func(i, j int) bool {
+		if response.Items[i].Conversions != response.Items[j].Conversions {
+			return response.Items[i].Conversions > response.Items[j].Conversions
+		}
+		if response.Items[i].Clicks != response.Items[j].Clicks {
+			return response.Items[i].Clicks > response.Items[j].Clicks
+		}
+		return response.Items[i].ID < response.Items[j].ID
+	})
+	return response, nil
+}
+
+func (s *ConversionService) reportTotals(workspaceID uint64, req *dto.ConversionReportRequest) (int64, int64, map[string]float64, error) {
+	clicks, err := s.conversionDao.GroupClicks(workspaceID, req)
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	totals, err := s.conversionDao.GroupConversions(workspaceID, req)
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	var totalClicks, totalConversions int64
+	revenue := map[string]float64{}
+	for _, row := range clicks {
+		totalClicks += row.Clicks
+	}
+	for _, row := range totals {
+		totalConversions += row.Conversions
+		revenue[row.Currency] = roundRevenue(revenue[row.Currency] + row.Revenue)
+	}
+	return totalClicks, totalConversions, revenue, nil
+}
+
+func (s *ConversionService) record(click *model.ClickStatistic, req *dto.ConversionRequest, source, clientIP, userAgent string) (*dto.ConversionResponse, error) {
+	if existing, err := s.conversionDao.FindByEventID(click.WorkspaceID, req.EventID); err == nil {
+		return conversionToResponse(existing, true), nil
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	occurredAt := time.Now()
+	if req.OccurredAt != nil {
+		occurredAt = *req.OccurredAt
+	}
+	if occurredAt.Before(click.ClickDate) {
+		occurredAt = click.ClickDate
+	}
+	if days := s.helper.GetConfig().GetInt("conversion.attribution_window_days", defaultConversionAttributionDays); days > 0 &&
+		occurredAt.Sub(click.ClickDate) > time.Duration(days)*24*time.Hour {
+		return nil, ErrConversionOutsideWindow
+	}
+	metadata := ""
+	if len(req.Metadata) > 0 {
+		raw, err := json.Marshal(req.Metadata)
+		if err != nil || len(raw) > 4096 {
+			return nil, ErrConversionBadRequest
+		}
+		metadata = string(raw)
+	}
+	conversion := &model.Conversion{
+		WorkspaceID:      click.WorkspaceID,
+		ShortLinkID:      click.ShortLinkID,
+		CampaignID:       click.CampaignID,
+		RouteID:          click.RouteID,
+		ClickStatisticID: click.ID,
+		ClickID:          click.ClickID,
+		Event:            req.Event,
+		EventID:          req.EventID,
+		Value:            req.Value,
+		Currency:         req.Currency,
+		Source:           source,
+		Metadata:         metadata,
+		IP:               domain_validate.TruncateString(NewDataRetentionService(s.helper).StoredIP(click.WorkspaceID, clientIP), 45),
+		UserAgent:        domain_validate.TruncateString(userAgent, 1024),
+		ClickedAt:        click.ClickDate,
+		OccurredAt:       occurredAt,
+	}
+	if err := s.conversionDao.Create(conversion); err != nil {
+		// 并发回传同一事件时以先写入的为准
+		if existing, findErr := s.conversionDao.FindByEventID(click.WorkspaceID, req.EventID); findErr == nil {
+			return conversionToResponse(existing, true), nil
+		}
+		return nil, err
+	}
+	return conversionToResponse(conversion, false), nil
+}
+
+// findClick 点击明细在跳转后异步写入，落地页立即回传时点击可能尚未写入，在 wait 内重试后再判定无效
+func (s *ConversionService) findClick(clickID string, wait time.Duration) (*model.ClickStatistic, error) {
+	deadline := time.Now().Add(wait)
+	for {
+		click, err := s.conversionDao.FindClick(clickID)
+		if err == nil {
+			return click, nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+		if !time.Now().Before(deadline) {
+			return nil, ErrConversionClickNotFound
+		}
+		time.Sleep(conversionClickLookupInterval)
+	}
+}
+
+// clickIDWorkspace 解析点击ID中的工作区ID，格式不是 工作区ID-32位十六进制 时返回 false
+func clickIDWorkspace(clickID string) (uint64, bool) {
+	prefix, random, found := strings.Cut(clickID, "-")
+	if !found || len(random) != 32 {
+		return 0, false
+	}
+	if _, err := hex.DecodeString(random); err != nil {
+		return 0, false
+	}
+	workspaceID, err := strconv.ParseUint(prefix, 10, 64)
+	return workspaceID, err == nil && workspaceID > 0
+}
+
+func (s *ConversionService) findWorkspace(workspaceID uint64) (*model.Workspace, error) {
+	workspace, err := s.workspaceDao.FindByID(workspaceID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("工作区不存在")
+		}
+		return nil, err
+	}
+	return workspace, nil
+}
+
+func (s *ConversionService) resetSecret(workspace *model.Workspace) error {
+	bytes := make([]byte, 24)
+	if _, err := rand.Read(bytes); err != nil {
+		return err
+	}
+	workspace.ConversionSecret = "cvs_" + hex.EncodeToString(bytes)
+	return s.workspaceDao.Update(workspace)
+}
+
+func (s *ConversionService) settingsResponse(workspace *model.Workspace) *dto.ConversionPostbackSettingsResponse {
+	return &dto.ConversionPostbackSettingsResponse{
+		Secret:       workspace.ConversionSecret,
+		ClickIDParam: conversionClickIDParam(s.helper),
+		PostbackPath: "/api/v1/public/conversions",
+		PixelPath:    "/api/v1/public/conversions/pixel.gif",
+
+		PixelConversionValue: workspace.PixelConversionValue,
+	}
+}
+
+func (s *ConversionService) verifySignature(secret, timestamp, signature string, body []byte, now time.Time) error {
+	if secret == "" || timestamp == "" || signature == "" {
+		return ErrConversionInvalidSignature
+	}
+	unix, err := strconv.ParseInt(strings.TrimSpace(timestamp), 10, 64)
+	if err != nil {
+		return ErrConversionInvalidSignature
+	}
+	tolerance := s.helper.GetConfig().GetInt("conversion.signature_tolerance_seconds", defaultConversionSignatureTolerance)
+	if tolerance > 0 && math.Abs(now.Sub(time.Unix(unix, 0)).Seconds()) > float64(tolerance) {
+		return ErrConversionExpiredSignature
+	}
+	expected := ConversionSignature(secret, strings.TrimSpace(timestamp), body)
+	if !hmac.Equal([]byte(expected), []byte(strings.ToLower(strings.TrimSpace(signature)))) {
+		return ErrConversionInvalidSignature
+	}
+	return nil
+}
+
+// conversionClickIDParam 跳转时追加点击ID使用的查询参数名
+func conversionClickIDParam(helper interfaces.HelperInterface) string {
+	if param := strings.TrimSpace(helper.GetConfig().GetString("conversion.click_id_param", defaultConversionClickIDParam)); param != "" {
+		return param
+	}
+	return defaultConversionClickIDParam
+}
+
+// ConversionSignature 计算服务端回传签名
+func ConversionSignature(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func normalizeConversionRequest(req *dto.ConversionRequest) error {
+	req.ClickID = strings.TrimSpace(req.ClickID)
+	req.Event = strings.TrimSpace(req.Event)
+	req.EventID = strings.TrimSpace(req.EventID)
+	req.Currency = strings.ToUpper(strings.TrimSpace(req.Currency))
+	if req.Event == "" {
+		req.Event = defaultConversionEvent
+	}
+	if req.EventID == "" {
+		req.EventID = req.Event + ":" + req.ClickID
+	}
+	if req.ClickID == "" || len(req.ClickID) > 64 || len(req.Event) > 64 || len(req.EventID) > 128 || len(req.Currency) > 16 {
+		return ErrConversionBadRequest
+	}
+	if req.Value != nil && (*req.Value < 0 || math.IsNaN(*req.Value) || math.IsInf(*req.Value, 0)) {
+		return ErrConversionBadRequest
+	}
+	return nil
+}
+
+func roundRevenue(value float64) float64 {
+	return math.Round(value*10000) / 10000
+}
+
+func conversionToResponse(conversion *model.Conversion, duplicate bool) *dto.ConversionResponse {
+	return &dto.ConversionResponse{
+		ID:          conversion.ID,
+		ClickID:     conversion.ClickID,
+		ShortLinkID: conversion.ShortLinkID,
+		CampaignID:  conversion.CampaignID,
+		RouteID:     conversion.RouteID,
+		Event:       conversion.Event,
+		EventID:     conversion.EventID,
+		Value:       conversion.Value,
+		Currency:    conversion.Currency,
+		Source:      conversion.Source,
+		Duplicate:   duplicate,
+		OccurredAt:  conversion.OccurredAt,
+	}
+}
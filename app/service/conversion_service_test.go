@@ -0,0 +1,164 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/dto"
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/model"
+)
+
+func TestConversionClickIDPostbackPixelAndReport(t *testing.T) {
+	helper := newShortLinkRegressionHelper(t)
+	db := helper.GetDatabase()
+	seedBatchShortLinkDomain(t, db)
+	if err := db.Create(&model.Workspace{ID: 1, Slug: "default", Name: "Default", Status: 1}).Error; err != nil {
+		t.Fatalf("seed workspace: %v", err)
+	}
+	campaign := model.Campaign{WorkspaceID: 1, Name: "Spring"}
+	if err := db.Create(&campaign).Error; err != nil {
+		t.Fatalf("seed campaign: %v", err)
+	}
+
+	shortLinkSvc := NewShortLinkService(helper, context.Background())
+	tracked, err := shortLinkSvc.CreateShortLinkInWorkspace(&dto.CreateShortLinkRequest{
+		OriginalURL:   "https://example.com/landing?from=ad",
+		Domain:        "batch.dwz.do",
+		CustomCode:    "tracked",
+		CampaignID:    &campaign.ID,
+		AppendClickID: true,
+	}, "", 1, 7)
+	if err != nil || !tracked.AppendClickID {
+		t.Fatalf("create tracked link: %+v err=%v", tracked, err)
+	}
+	plain, err := shortLinkSvc.CreateShortLinkInWorkspace(&dto.CreateShortLinkRequest{
+		OriginalURL: "https://example.com/plain",
+		Domain:      "batch.dwz.do",
+		CustomCode:  "plain",
+	}, "", 1, 7)
+	if err != nil {
+		t.Fatalf("create plain link: %v", err)
+	}
+
+	redirect := func(code string) *RedirectDecision {
+		decision, err := shortLinkSvc.ResolveRedirectWithSecurity("batch.dwz.do", code, "8.8.8.8", "Mozilla/5.0", "", "", "")
+		if err != nil {
+			t.Fatalf("redirect %s: %v", code, err)
+		}
+		if !strings.HasPrefix(decision.ClickID, "1-") || len(decision.ClickID) != 34 {
+			t.Fatalf("every click must get a click id: %+v", decision)
+		}
+		return decision
+	}
+	first := redirect("tracked")
+	target, _ := url.Parse(first.TargetURL)
+	if target.Query().Get("dwz_click_id") != first.ClickID || target.Query().Get("from") != "ad" {
+		t.Fatalf("click id must be appended to target: %s", first.TargetURL)
+	}
+	second := redirect("tracked")
+	if second.ClickID == first.ClickID {
+		t.Fatal("click ids must be unique")
+	}
+	plainClick := redirect("plain")
+	if plainClick.TargetURL != "https://example.com/plain" {
+		t.Fatalf("click id must not be appended unless enabled: %s", plainClick.TargetURL)
+	}
+	waitForModelCount(t, db, &model.ClickStatistic{}, "short_link_id = ?", tracked.ID, 2)
+	waitForModelCount(t, db, &model.ClickStatistic{}, "short_link_id = ?", plain.ID, 1)
+
+	conversionSvc := NewConversionService(helper)
+	settings, err := conversionSvc.GetPostbackSettings(1)
+	if err != nil || settings.Secret == "" || settings.ClickIDParam != "dwz_click_id" {
+		t.Fatalf("postback settings: %+v err=%v", settings, err)
+	}
+	postback := func(secret string, at time.Time, body string) (*dto.ConversionResponse, error) {
+		timestamp := strconv.FormatInt(at.Unix(), 10)
+		return conversionSvc.RecordPostback([]byte(body), timestamp, ConversionSignature(secret, timestamp, []byte(body)), "1.1.1.1", "server")
+	}
+	body := fmt.Sprintf(`{"click_id":%q,"event":"purchase","event_id":"order-1","value":99.5,"currency":"usd"}`, first.ClickID)
+	if _, err := postback("wrong", time.Now(), body); !errors.Is(err, ErrConversionInvalidSignature) {
+		t.Fatalf("bad signature must be rejected, got %v", err)
+	}
+	if _, err := postback(settings.Secret, time.Now().Add(-time.Hour), body); !errors.Is(err, ErrConversionExpiredSignature) {
+		t.Fatalf("stale timestamp must be rejected, got %v", err)
+	}
+	const unknownClickID = "1-0123456789abcdef0123456789abcdef"
+	if _, err := postback(settings.Secret, time.Now(), `{"click_id":"`+unknownClickID+`"}`); !errors.Is(err, ErrConversionClickNotFound) {
+		t.Fatalf("unknown click id must be rejected, got %v", err)
+	}
+	// 签名错误时不透露点击ID是否存在
+	if _, err := postback("wrong", time.Now(), `{"click_id":"`+unknownClickID+`"}`); !errors.Is(err, ErrConversionInvalidSignature) {
+		t.Fatalf("signature must be verified before the click lookup, got %v", err)
+	}
+	purchase, err := postback(settings.Secret, time.Now(), body)
+	if err != nil || purchase.Duplicate || purchase.ShortLinkID != tracked.ID || purchase.CampaignID == nil || *purchase.CampaignID != campaign.ID || purchase.Currency != "USD" {
+		t.Fatalf("postback must be attributed to the click: %+v err=%v", purchase, err)
+	}
+	if again, err := postback(settings.Secret, time.Now(), body); err != nil || !again.Duplicate || again.ID != purchase.ID {
+		t.Fatalf("same event id must be deduplicated: %+v err=%v", again, err)
+	}
+
+	// 像素没有签名，未开启 pixel_conversion_value 时不记录金额
+	value := 10.0
+	lead, err := conversionSvc.RecordPixel(&dto.ConversionRequest{ClickID: second.ClickID, Event: "lead", Value: &value, Currency: "USD"}, "2.2.2.2", "browser")
+	if err != nil || lead.Source != model.ConversionSourcePixel || lead.EventID != "lead:"+second.ClickID || lead.Value != nil || lead.Currency != "" {
+		t.Fatalf("pixel conversion: %+v err=%v", lead, err)
+	}
+	enabled := true
+	if _, err := NewWorkspaceService(helper).UpdateWorkspace(1, &dto.UpdateWorkspaceRequest{Name: "Default", PixelConversionValue: &enabled}); err != nil {
+		t.Fatalf("enable pixel value: %v", err)
+	}
+	paid, err := conversionSvc.RecordPixel(&dto.ConversionRequest{ClickID: first.ClickID, Event: "lead", Value: &value, Currency: "usd"}, "2.2.2.2", "browser")
+	if err != nil || paid.Value == nil || *paid.Value != 10 || paid.Currency != "USD" {
+		t.Fatalf("pixel value after opt-in: %+v err=%v", paid, err)
+	}
+	if _, err := conversionSvc.RecordPixel(&dto.ConversionRequest{ClickID: "1-late' OR 1=1"}, "2.2.2.2", "browser"); !errors.Is(err, ErrConversionClickNotFound) {
+		t.Fatalf("malformed pixel click id must be rejected, got %v", err)
+	}
+
+	// 落地页在点击明细写入前回传，短暂等待后归因
+	helper.settings["conversion.click_lookup_wait_ms"] = 2000
+	// 像素只查找一次，不等待
+	started := time.Now()
+	if _, err := conversionSvc.RecordPixel(&dto.ConversionRequest{ClickID: unknownClickID}, "2.2.2.2", "browser"); !errors.Is(err, ErrConversionClickNotFound) || time.Since(started) > time.Second {
+		t.Fatalf("pixel must not wait for unknown clicks: err=%v elapsed=%s", err, time.Since(started))
+	}
+	const lateClickID = "1-fedcba9876543210fedcba9876543210"
+	late := model.ClickStatistic{WorkspaceID: 1, ShortLinkID: plain.ID, ClickID: lateClickID, IP: "8.8.8.8", ClickDate: time.Now()}
+	go func() {
+		time.Sleep(200 * time.Millisecond)
+		db.Create(&late)
+	}()
+	if early, err := postback(settings.Secret, time.Now(), `{"click_id":"`+lateClickID+`","event":"signup"}`); err != nil || early.ShortLinkID != plain.ID {
+		t.Fatalf("postback before the click is stored: %+v err=%v", early, err)
+	}
+	helper.settings["conversion.click_lookup_wait_ms"] = 0
+
+	// 重置密钥后旧密钥失效
+	rotated, err := conversionSvc.RotateSecret(1)
+	if err != nil || rotated.Secret == settings.Secret {
+		t.Fatalf("rotate secret: %+v err=%v", rotated, err)
+	}
+	if _, err := postback(settings.Secret, time.Now(), fmt.Sprintf(`{"click_id":%q}`, plainClick.ClickID)); !errors.Is(err, ErrConversionInvalidSignature) {
+		t.Fatalf("old secret must be rejected after rotation, got %v", err)
+	}
+
+	report, err := conversionSvc.Report(1, &dto.ConversionReportRequest{})
+	if err != nil || report.TotalClicks != 4 || report.TotalConversions != 4 || report.TotalRevenue["USD"] != 109.5 || len(report.Items) != 2 {
+		t.Fatalf("link report: %+v err=%v", report, err)
+	}
+	top := report.Items[0]
+	if top.ID != tracked.ID || top.Name != "batch.dwz.do/tracked" || top.Clicks != 2 || top.Conversions != 3 || top.ConversionRate != 1.5 {
+		t.Fatalf("link report item: %+v", top)
+	}
+	byCampaign, err := conversionSvc.Report(1, &dto.ConversionReportRequest{GroupBy: "campaign"})
+	if err != nil || len(byCampaign.Items) != 2 || byCampaign.Items[0].Name != "Spring" || byCampaign.Items[0].Revenue["USD"] != 109.5 {
+		t.Fatalf("campaign report: %+v err=%v", byCampaign, err)
+	}
+}
@@ -0,0 +1,334 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/dao"
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/dto"
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/model"
+	"cnb.cool/mliev/dwz/dwz-server/v2/pkg/interfaces"
+	"gorm.io/gorm"
+)
+
+const maxCustomValueLength = 500
+
+var customFieldKeyPattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,49}$`)
+
+type CustomFieldService struct {
+	helper         interfaces.HelperInterface
+	customFieldDao *dao.CustomFieldDao
+}
+
+func NewCustomFieldService(helper interfaces.HelperInterface) *CustomFieldService {
+	return &CustomFieldService{
+		helper:         helper,
+		customFieldDao: dao.NewCustomFieldDao(helper),
+	}
+}
+
+func (s *CustomFieldService) Create(workspaceID, userID uint64, req *dto.CustomFieldRequest) (*dto.CustomFieldResponse, error) {
+	field := &model.CustomField{
+		WorkspaceID: workspaceID,
+		FieldKey:    strings.TrimSpace(req.Key),
+		FieldType:   req.FieldType,
+		CreatedBy:   actorPtr(userID),
+	}
+	if !customFieldKeyPattern.MatchString(field.FieldKey) {
+		return nil, errors.New("字段标识无效，需以小写字母开头且仅包含小写字母、数字和下划线")
+	}
+	exists, err := s.customFieldDao.ExistsKey(workspaceID, field.FieldKey, 0)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, errors.New("字段标识已存在")
+	}
+	if err := s.save(field, userID, req); err != nil {
+		return nil, err
+	}
+	return customFieldToResponse(field), nil
+}
+
+func (s *CustomFieldService) Update(id, workspaceID, userID uint64, req *dto.CustomFieldRequest) (*dto.CustomFieldResponse, error) {
+	field, err := s.findField(id, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(req.Key) != field.FieldKey {
+		return nil, errors.New("字段标识不能修改")
+	}
+	if req.FieldType != field.FieldType {
+		return nil, errors.New("字段类型不能修改")
+	}
+	if err := s.save(field, userID, req); err != nil {
+		return nil, err
+	}
+	return customFieldToResponse(field), nil
+}
+
+func (s *CustomFieldService) Get(id, workspaceID uint64) (*dto.CustomFieldResponse, error) {
+	field, err := s.findField(id, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	return customFieldToResponse(field), nil
+}
+
+func (s *CustomFieldService) List(workspaceID uint64) (*dto.CustomFieldListResponse, error) {
+	fields, err := s.customFieldDao.ListInWorkspace(workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	list := make([]dto.CustomFieldResponse, 0, len(fields))
+	for _, field := range fields {
+		list = append(list, *customFieldToResponse(&field))
+	}
+	return &dto.CustomFieldListResponse{List: list}, nil
+}
+
+// Delete 删除字段定义，已有短网址上的取值一并清除
+func (s *CustomFieldService) Delete(id, workspaceID uint64) error {
+	if _, err := s.findField(id, workspaceID); err != nil {
+		return err
+	}
+	return s.customFieldDao.Delete(id, workspaceID)
+}
+
+// ResolveValues 按字段定义校验并规范化短网址的自定义字段取值。
+// 创建时校验必填字段；更新时只处理传入的字段，null 或空字符串表示清除，返回需清除的字段ID。
+func (s *CustomFieldService) ResolveValues(workspaceID uint64, input map[string]any, creating bool) ([]model.ShortLinkCustomValue, []uint64, error) {
+	if !creating && len(input) == 0 {
+		return nil, nil, nil
+	}
+	fields, err := s.customFieldDao.ListInWorkspace(workspaceID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(fields) == 0 && len(input) == 0 {
+		return nil, nil, nil
+	}
+	byKey := make(map[string]*model.CustomField, len(fields))
+	for i := range fields {
+		byKey[fields[i].FieldKey] = &fields[i]
+	}
+
+	var values []model.ShortLinkCustomValue
+	var removeFieldIDs []uint64
+	for key, raw := range input {
+		field, ok := byKey[key]
+		if !ok {
+			return nil, nil, fmt.Errorf("自定义字段 %s 不存在", key)
+		}
+		text, err := customValueText(field, raw)
+		if err != nil {
+			return nil, nil, err
+		}
+		if text == "" {
+			if field.Required {
+				return nil, nil, fmt.Errorf("自定义字段 %s 不能为空", key)
+			}
+			removeFieldIDs = append(removeFieldIDs, field.ID)
+			continue
+		}
+		value, number, err := normalizeCustomValue(field, text)
+		if err != nil {
+			return nil, nil, err
+		}
+		values = append(values, model.ShortLinkCustomValue{
+			WorkspaceID: workspaceID,
+			FieldID:     field.ID,
+			FieldKey:    field.FieldKey,
+			Value:       value,
+			NumberValue: number,
+		})
+	}
+	if creating {
+		for i := range fields {
+			field := &fields[i]
+			if !field.Required {
+				continue
+			}
+			if text, _ := customValueText(field, input[field.FieldKey]); text == "" {
+				return nil, nil, fmt.Errorf("自定义字段 %s 不能为空", field.FieldKey)
+			}
+		}
+	}
+	return values, removeFieldIDs, nil
+}
+
+// SaveValues 写入已校验的取值
+func (s *CustomFieldService) SaveValues(tx *gorm.DB, shortLinkID uint64, values []model.ShortLinkCustomValue, removeFieldIDs []uint64) error {
+	if len(values) == 0 && len(removeFieldIDs) == 0 {
+		return nil
+	}
+	return s.customFieldDao.ReplaceValues(tx, shortLinkID, values, removeFieldIDs)
+}
+
+// ValuesMap 短网址的取值，键为字段标识
+func (s *CustomFieldService) ValuesMap(shortLinkID uint64) map[string]string {
+	values, err := s.customFieldDao.ValuesByShortLinkID(shortLinkID)
+	if err != nil || len(values) == 0 {
+		return nil
+	}
+	result := make(map[string]string, len(values))
+	for _, value := range values {
+		result[value.FieldKey] = value.Value
+	}
+	return result
+}
+
+func (s *CustomFieldService) save(field *model.CustomField, userID uint64, req *dto.CustomFieldRequest) error {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return errors.New("字段名称不能为空")
+	}
+	var options []string
+	if field.FieldType == model.CustomFieldTypeEnum {
+		seen := make(map[string]struct{}, len(req.Options))
+		for _, option := range req.Options {
+			option = strings.TrimSpace(option)
+			if option == "" {
+				continue
+			}
+			if _, ok := seen[option]; ok {
+				continue
+			}
+			seen[option] = struct{}{}
+			options = append(options, option)
+		}
+		if len(options) == 0 {
+			return errors.New("枚举字段至少需要一个可选值")
+		}
+	}
+	if req.MinValue != nil && req.MaxValue != nil && *req.MinValue > *req.MaxValue {
+		return errors.New("最小值不能大于最大值")
+	}
+
+	field.Name = name
+	field.Description = req.Description
+	field.Options = ""
+	if len(options) > 0 {
+		raw, err := json.Marshal(options)
+		if err != nil {
+			return err
+		}
+		field.Options = string(raw)
+	}
+	field.Required = req.Required
+	field.MaxLength = 0
+	field.MinValue = nil
+	field.MaxValue = nil
+	switch field.FieldType {
+	case model.CustomFieldTypeString:
+		field.MaxLength = req.MaxLength
+	case model.CustomFieldTypeNumber:
+		field.MinValue = req.MinValue
+		field.MaxValue = req.MaxValue
+	}
+	field.Position = req.Position
+	field.UpdatedBy = actorPtr(userID)
+	return s.customFieldDao.Save(field)
+}
+
+func (s *CustomFieldService) findField(id, workspaceID uint64) (*model.CustomField, error) {
+	field, err := s.customFieldDao.FindByID(id, workspaceID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("自定义字段不存在")
+		}
+		return nil, err
+	}
+	return field, nil
+}
+
+// customValueText JSON 取值转为文本，数字字段允许直接传数字
+func customValueText(field *model.CustomField, raw any) (string, error) {
+	switch value := raw.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return strings.TrimSpace(value), nil
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64), nil
+	case int, int64, uint64, json.Number:
+		return fmt.Sprint(value), nil
+	default:
+		return "", fmt.Errorf("自定义字段 %s 取值无效", field.FieldKey)
+	}
+}
+
+func normalizeCustomValue(field *model.CustomField, text string) (string, *float64, error) {
+	switch field.FieldType {
+	case model.CustomFieldTypeNumber:
+		number, err := strconv.ParseFloat(text, 64)
+		if err != nil || math.IsNaN(number) || math.IsInf(number, 0) {
+			return "", nil, fmt.Errorf("自定义字段 %s 取值无效，需要数字", field.FieldKey)
+		}
+		if field.MinValue != nil && number < *field.MinValue {
+			return "", nil, fmt.Errorf("自定义字段 %s 不能小于 %s", field.FieldKey, strconv.FormatFloat(*field.MinValue, 'f', -1, 64))
+		}
+		if field.MaxValue != nil && number > *field.MaxValue {
+			return "", nil, fmt.Errorf("自定义字段 %s 不能大于 %s", field.FieldKey, strconv.FormatFloat(*field.MaxValue, 'f', -1, 64))
+		}
+		return strconv.FormatFloat(number, 'f', -1, 64), &number, nil
+	case model.CustomFieldTypeEnum:
+		for _, option := range customFieldOptions(field) {
+			if option == text {
+				return text, nil, nil
+			}
+		}
+		return "", nil, fmt.Errorf("自定义字段 %s 取值无效，可选值为 %s", field.FieldKey, strings.Join(customFieldOptions(field), "、"))
+	case model.CustomFieldTypeDate:
+		day, err := time.Parse("2006-01-02", text)
+		if err != nil {
+			return "", nil, fmt.Errorf("自定义字段 %s 取值无效，需要 YYYY-MM-DD 格式的日期", field.FieldKey)
+		}
+		return day.Format("2006-01-02"), nil, nil
+	default:
+		limit := maxCustomValueLength
+		if field.MaxLength > 0 {
+			limit = field.MaxLength
+		}
+		if utf8.RuneCountInString(text) > limit {
+			return "", nil, fmt.Errorf("自定义字段 %s 长度不能超过 %d 个字符", field.FieldKey, limit)
+		}
+		return text, nil, nil
+	}
+}
+
+func customFieldOptions(field *model.CustomField) []string {
+	var options []string
+	if field.Options != "" {
+		_ = json.Unmarshal([]byte(field.Options), &options)
+	}
+	return options
+}
+
+func customFieldToResponse(field *model.CustomField) *dto.CustomFieldResponse {
+	return &dto.CustomFieldResponse{
+		ID:          field.ID,
+		WorkspaceID: field.WorkspaceID,
+		Key:         field.FieldKey,
+		Name:        field.Name,
+		Description: field.Description,
+		FieldType:   field.FieldType,
+		Options:     customFieldOptions(field),
+		Required:    field.Required,
+		MaxLength:   field.MaxLength,
+		MinValue:    field.MinValue,
+		MaxValue:    field.MaxValue,
+		Position:    field.Position,
+		CreatedBy:   field.CreatedBy,
+		UpdatedBy:   field.UpdatedBy,
+		CreatedAt:   field.CreatedAt,
+		UpdatedAt:   field.UpdatedAt,
+	}
+}
@@ -0,0 +1,134 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/dto"
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/model"
+)
+
+func TestCustomFieldsValidateFilterExportAndRoute(t *testing.T) {
+	helper := newShortLinkRegressionHelper(t)
+	db := helper.GetDatabase()
+	seedBatchShortLinkDomain(t, db)
+
+	fieldSvc := NewCustomFieldService(helper)
+	minCost := 0.0
+	for _, req := range []dto.CustomFieldRequest{
+		{Key: "channel", Name: "渠道", FieldType: model.CustomFieldTypeEnum, Options: []string{"wechat", "email", "wechat"}, Required: true},
+		{Key: "cost", Name: "成本", FieldType: model.CustomFieldTypeNumber, MinValue: &minCost},
+		{Key: "launch", Name: "上线日期", FieldType: model.CustomFieldTypeDate},
+		{Key: "partner_id", Name: "合作方", FieldType: model.CustomFieldTypeString, MaxLength: 8},
+	} {
+		if _, err := fieldSvc.Create(1, 7, &req); err != nil {
+			t.Fatalf("create field %s: %v", req.Key, err)
+		}
+	}
+	if _, err := fieldSvc.Create(1, 7, &dto.CustomFieldRequest{Key: "Bad-Key", Name: "x", FieldType: model.CustomFieldTypeString}); err == nil {
+		t.Fatal("invalid field key must be rejected")
+	}
+	if _, err := fieldSvc.Create(1, 7, &dto.CustomFieldRequest{Key: "cost", Name: "x", FieldType: model.CustomFieldTypeString}); err == nil || !strings.Contains(err.Error(), "已存在") {
+		t.Fatalf("duplicate key must be rejected, got %v", err)
+	}
+	list, err := fieldSvc.List(1)
+	if err != nil || len(list.List) != 4 || len(list.List[0].Options) != 2 {
+		t.Fatalf("unexpected field list: %+v err=%v", list, err)
+	}
+
+	shortLinkSvc := NewShortLinkService(helper, context.Background())
+	create := func(code string, fields map[string]any) (*dto.ShortLinkResponse, error) {
+		return shortLinkSvc.CreateShortLinkInWorkspace(&dto.CreateShortLinkRequest{
+			OriginalURL:  "https://example.com/" + code,
+			Domain:       "batch.dwz.do",
+			CustomCode:   code,
+			CustomFields: fields,
+		}, "", 1, 7)
+	}
+	if _, err := create("missing", map[string]any{"cost": 10}); err == nil || err.Error() != "自定义字段 channel 不能为空" {
+		t.Fatalf("required field must be enforced, got %v", err)
+	}
+	for _, invalid := range []map[string]any{
+		{"channel": "sms"},
+		{"channel": "email", "cost": -1.0},
+		{"channel": "email", "launch": "2026/01/01"},
+		{"channel": "email", "partner_id": "toolongvalue"},
+		{"channel": "email", "unknown": "x"},
+	} {
+		if _, err := create("invalid", invalid); err == nil {
+			t.Fatalf("invalid values must be rejected: %+v", invalid)
+		}
+	}
+
+	wechat, err := create("wechat", map[string]any{"channel": "wechat", "cost": 120.5, "launch": "2026-03-01", "partner_id": "P01"})
+	if err != nil {
+		t.Fatalf("create wechat link: %v", err)
+	}
+	if wechat.CustomFields["channel"] != "wechat" || wechat.CustomFields["cost"] != "120.5" || wechat.CustomFields["launch"] != "2026-03-01" {
+		t.Fatalf("custom fields must be returned: %+v", wechat.CustomFields)
+	}
+	email, err := create("email", map[string]any{"channel": "email", "cost": "30"})
+	if err != nil {
+		t.Fatalf("create email link: %v", err)
+	}
+
+	// 更新只处理传入的字段，空值清除可选字段，必填字段不能清除
+	updated, err := shortLinkSvc.UpdateShortLinkInWorkspace(wechat.ID, &dto.UpdateShortLinkRequest{CustomFields: map[string]any{"partner_id": nil}}, 1, 7)
+	if err != nil || updated.CustomFields["partner_id"] != "" || updated.CustomFields["channel"] != "wechat" {
+		t.Fatalf("optional field must be cleared: %+v err=%v", updated, err)
+	}
+	if _, err := shortLinkSvc.UpdateShortLinkInWorkspace(wechat.ID, &dto.UpdateShortLinkRequest{CustomFields: map[string]any{"channel": ""}}, 1, 7); err == nil {
+		t.Fatal("required field must not be cleared")
+	}
+
+	for query, expected := range map[string]uint64{
+		"cf.channel:wechat":       wechat.ID,
+		"cf.cost>100":             wechat.ID,
+		"cf.cost<=30":             email.ID,
+		"cf.launch>=2026-01-01":   wechat.ID,
+		"-cf.channel:wechat":      email.ID,
+		"cf.channel:em* clicks<1": email.ID,
+	} {
+		result, err := shortLinkSvc.GetShortLinkListInWorkspace(&dto.ShortLinkListRequest{Page: 1, PageSize: 10, Q: query}, 1)
+		if err != nil || len(result.List) != 1 || result.List[0].ID != expected {
+			t.Fatalf("query %q: %+v err=%v", query, result, err)
+		}
+	}
+
+	routeSvc := NewLinkRouteService(helper)
+	if _, err := routeSvc.CreateRoute(wechat.ID, 1, 7, &dto.LinkRouteRequest{
+		Name:      "wechat landing",
+		TargetURL: "https://example.com/wechat-landing",
+		ConditionGroups: []dto.LinkRouteConditionGroupRequest{{
+			Conditions: []dto.LinkRouteConditionRequest{{ConditionType: model.RouteConditionCustomField, ConditionKey: "channel", Operator: model.RouteOperatorEq, ConditionValue: "wechat"}},
+		}},
+	}); err != nil {
+		t.Fatalf("create custom field route: %v", err)
+	}
+	tested, err := routeSvc.TestRoute(wechat.ID, 1, &dto.LinkRouteTestRequest{})
+	if err != nil || !tested.Matched || tested.TargetURL != "https://example.com/wechat-landing" {
+		t.Fatalf("custom field route must match: %+v err=%v", tested, err)
+	}
+
+	if err := db.Create(&model.ClickStatistic{WorkspaceID: 1, ShortLinkID: wechat.ID, IP: "10.0.0.1", ClickDate: time.Now()}).Error; err != nil {
+		t.Fatalf("seed click: %v", err)
+	}
+	csv, err := NewClickStatisticService(helper).ExportCSV(1, &dto.ClickStatisticListRequest{})
+	if err != nil {
+		t.Fatalf("export: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(csv)), "\n")
+	if len(lines) != 2 || !strings.HasSuffix(lines[0], ",cf_channel,cf_cost,cf_launch,cf_partner_id") || !strings.HasSuffix(lines[1], ",wechat,120.5,2026-03-01,") {
+		t.Fatalf("export must carry custom fields: %q", lines)
+	}
+
+	// 删除字段定义后取值一并清除
+	if err := fieldSvc.Delete(list.List[0].ID, 1); err != nil {
+		t.Fatalf("delete field: %v", err)
+	}
+	if values := fieldSvc.ValuesMap(wechat.ID); values["channel"] != "" || values["cost"] != "120.5" {
+		t.Fatalf("deleted field values must be removed: %+v", values)
+	}
+}
@@ -0,0 +1,192 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/dao"
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/model"
+	"cnb.cool/mliev/dwz/dwz-server/v2/pkg/interfaces"
+)
+
+const (
+	defaultRetentionPurgeBatchSize = 5000
+	anonymizeIPCacheKey            = "workspace_anonymize_ip:"
+	anonymizeIPCacheTTL            = 5 * time.Minute
+)
+
+// retentionTarget 按保留期清理的一类数据，defaultDays 为工作区和配置都未设置时的保留天数，0 表示永久保留
+type retentionTarget struct {
+	name        string
+	value       any
+	column      string
+	configKey   string
+	defaultDays int
+	days        func(workspace *model.Workspace) int
+}
+
+var retentionTargets = []retentionTarget{
+	{
+		name: "点击明细", value: &model.ClickStatistic{}, column: "click_date", configKey: "retention.click_days",
+		days: func(workspace *model.Workspace) int { return workspace.ClickRetentionDays },
+	},
+	{
+		name: "A/B 测试点击", value: &model.ABTestClickStatistic{}, column: "click_date", configKey: "retention.ab_test_click_days",
+		days: func(workspace *model.Workspace) int { return workspace.ABTestClickRetentionDays },
+	},
+	{
+		name: "安全事件", value: &model.LinkSecurityEvent{}, column: "created_at", configKey: "retention.security_event_days",
+		days: func(workspace *model.Workspace) int { return workspace.SecurityEventRetentionDays },
+	},
+	{
+		name: "操作日志", value: &model.OperationLog{}, column: "created_at", configKey: "retention.operation_log_days",
+		days: func(workspace *model.Workspace) int { return workspace.OperationLogRetentionDays },
+	},
+	{
+		// 与 Redis 草图的过期时间一致
+		name: "独立访客草图", value: &model.VisitorSketch{}, column: "day", configKey: "analytics.visitor_sketch_ttl_days", defaultDays: defaultVisitorSketchTTLDays,
+		days: func(*model.Workspace) int { return 0 },
+	},
+}
+
+// DataRetentionService 工作区数据保留期与访客IP匿名化
+type DataRetentionService struct {
+	helper         interfaces.HelperInterface
+	workspaceDao   *dao.WorkspaceDao
+	retentionDao   *dao.DataRetentionDao
+	clickRollupDao *dao.ClickRollupDao
+}
+
+func NewDataRetentionService(helper interfaces.HelperInterface) *DataRetentionService {
+	return &DataRetentionService{
+		helper:         helper,
+		workspaceDao:   dao.NewWorkspaceDao(helper),
+		retentionDao:   dao.NewDataRetentionDao(helper),
+		clickRollupDao: dao.NewClickRollupDao(helper),
+	}
+}
+
+// PurgeExpired 删除各工作区超过保留期的数据，返回删除的记录数。已删除的工作区同样按其保留期清理
+func (s *DataRetentionService) PurgeExpired(now time.Time) (int64, error) {
+	workspaces, err := s.workspaceDao.ListAllWithDeleted()
+	if err != nil {
+		return 0, err
+	}
+	batchSize := s.helper.GetConfig().GetInt("retention.purge_batch_size", defaultRetentionPurgeBatchSize)
+	if batchSize <= 0 {
+		batchSize = defaultRetentionPurgeBatchSize
+	}
+	var purged int64
+	for i := range workspaces {
+		workspace := &workspaces[i]
+		for _, target := range retentionTargets {
+			cutoff := s.cutoff(workspace, target, now)
+			if cutoff.IsZero() {
+				continue
+			}
+			var maxID *uint64
+			if _, ok := target.value.(*model.ClickStatistic); ok {
+				// 只删除已计入汇总的点击，避免汇总缺失
+				cursor, err := s.clickRollupDao.Cursor()
+				if err != nil {
+					return purged, err
+				}
+				maxID = &cursor.LastClickID
+			}
+			count, err := s.purge(workspace.ID, target, cutoff, maxID, batchSize)
+			purged += count
+			if err != nil {
+				return purged, err
+			}
+			if count > 0 {
+				s.helper.GetLogger().Info(fmt.Sprintf("[retention] 工作区 %d 已删除 %d 条超过保留期的%s", workspace.ID, count, target.name))
+			}
+		}
+	}
+	return purged, nil
+}
+
+func (s *DataRetentionService) purge(workspaceID uint64, target retentionTarget, cutoff time.Time, maxID *uint64, batchSize int) (int64, error) {
+	var purged int64
+	for {
+		count, err := s.retentionDao.PurgeBefore(target.value, workspaceID, target.column, cutoff, maxID, batchSize)
+		purged += count
+		if err != nil || count < int64(batchSize) {
+			return purged, err
+		}
+	}
+}
+
+// cutoff 数据类型的保留截止时间，早于它的记录会被删除；永久保留时返回零值
+func (s *DataRetentionService) cutoff(workspace *model.Workspace, target retentionTarget, now time.Time) time.Time {
+	days := target.days(workspace)
+	if days <= 0 {
+		days = s.helper.GetConfig().GetInt(target.configKey, 0)
+	}
+	if days <= 0 {
+		days = target.defaultDays
+	}
+	if days <= 0 {
+		return time.Time{}
+	}
+	return now.AddDate(0, 0, -days)
+}
+
+// ClickCutoff 工作区点击明细的保留截止时间，永久保留或工作区不存在时返回零值
+func (s *DataRetentionService) ClickCutoff(workspaceID uint64, now time.Time) time.Time {
+	workspace, err := s.workspaceDao.FindByID(workspaceID)
+	if err != nil {
+		workspace = &model.Workspace{ID: workspaceID}
+	}
+	return s.cutoff(workspace, retentionTargets[0], now)
+}
+
+// StoredIP 返回写入数据库的访客IP，工作区开启匿名化时截断。归属地查询应在此之前使用完整IP。
+func (s *DataRetentionService) StoredIP(workspaceID uint64, ip string) string {
+	if s.helper.GetConfig().GetBool("retention.anonymize_ip", false) || s.workspaceAnonymizesIP(workspaceID) {
+		return anonymizeIP(ip)
+	}
+	return ip
+}
+
+// workspaceAnonymizesIP 工作区是否开启IP匿名化，结果写入缓存，避免每次写入点击都查询工作区
+func (s *DataRetentionService) workspaceAnonymizesIP(workspaceID uint64) bool {
+	ctx := context.Background()
+	cache := s.helper.GetCache()
+	key := anonymizeIPCacheKey + strconv.FormatUint(workspaceID, 10)
+	var anonymize bool
+	if cache != nil && cache.Get(ctx, key, &anonymize) == nil {
+		return anonymize
+	}
+	workspace, err := s.workspaceDao.FindByID(workspaceID)
+	if err != nil {
+		return false
+	}
+	if cache != nil {
+		_ = cache.Set(ctx, key, workspace.AnonymizeIP, anonymizeIPCacheTTL)
+	}
+	return workspace.AnonymizeIP
+}
+
+// ForgetAnonymizeIP 工作区匿名化设置变更后清除缓存
+func (s *DataRetentionService) ForgetAnonymizeIP(workspaceID uint64) {
+	if cache := s.helper.GetCache(); cache != nil {
+		_ = cache.Del(context.Background(), anonymizeIPCacheKey+strconv.FormatUint(workspaceID, 10))
+	}
+}
+
+// anonymizeIP IPv4 保留前 24 位、IPv6 保留前 48 位，其余置零；无法解析时返回空字符串
+func anonymizeIP(ip string) string {
+	parsed := net.ParseIP(strings.TrimSpace(ip))
+	if parsed == nil {
+		return ""
+	}
+	if v4 := parsed.To4(); v4 != nil {
+		return v4.Mask(net.CIDRMask(24, 32)).String()
+	}
+	return parsed.Mask(net.CIDRMask(48, 128)).String()
+}
@@ -0,0 +1,179 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/dto"
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/model"
+)
+
+func TestAnonymizeIP(t *testing.T) {
+	cases := map[string]string{
+		"203.0.113.77":               "203.0.113.0",
+		" 10.1.2.3 ":                 "10.1.2.0",
+		"::ffff:192.0.2.9":           "192.0.2.0",
+		"2001:db8:85a3:8d3::8a2e:70": "2001:db8:85a3::",
+		"not-an-ip":                  "",
+	}
+	for input, want := range cases {
+		if got := anonymizeIP(input); got != want {
+			t.Errorf("anonymizeIP(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
+
+func TestDataRetentionPurgeAndIPAnonymization(t *testing.T) {
+	helper := newShortLinkRegressionHelper(t)
+	helper.settings["retention.security_event_days"] = 7
+	helper.settings["retention.purge_batch_size"] = 2
+	db := helper.GetDatabase()
+	seedBatchShortLinkDomain(t, db)
+	for _, workspace := range []model.Workspace{{ID: 1, Slug: "default", Name: "Default", Status: 1}, {ID: 2, Slug: "other", Name: "Other", Status: 0}, {ID: 3, Slug: "deleted", Name: "Deleted", Status: 1, OperationLogRetentionDays: 10}} {
+		if err := db.Create(&workspace).Error; err != nil {
+			t.Fatalf("seed workspace: %v", err)
+		}
+	}
+	enabled, clickDays, logDays := true, 30, 10
+	workspace, err := NewWorkspaceService(helper).UpdateWorkspace(1, &dto.UpdateWorkspaceRequest{
+		Name:                      "Default",
+		AnonymizeIP:               &enabled,
+		ClickRetentionDays:        &clickDays,
+		OperationLogRetentionDays: &logDays,
+	})
+	if err != nil || !workspace.AnonymizeIP || workspace.ClickRetentionDays != 30 || workspace.OperationLogRetentionDays != 10 {
+		t.Fatalf("update workspace: %+v err=%v", workspace, err)
+	}
+
+	shortLinkSvc := NewShortLinkService(helper, context.Background())
+	link, err := shortLinkSvc.CreateShortLinkInWorkspace(&dto.CreateShortLinkRequest{
+		OriginalURL: "https://example.com/private",
+		Domain:      "batch.dwz.do",
+		CustomCode:  "private",
+		Security:    &dto.LinkSecurityRequest{ReportEnabled: boolPtr(true)},
+	}, "", 1, 7)
+	if err != nil {
+		t.Fatalf("create link: %v", err)
+	}
+	if _, err := shortLinkSvc.ResolveRedirectWithSecurity("batch.dwz.do", "private", "203.0.113.77", "Mozilla/5.0", "", "", ""); err != nil {
+		t.Fatalf("redirect: %v", err)
+	}
+	waitForModelCount(t, db, &model.ClickStatistic{}, "short_link_id = ?", link.ID, 1)
+	var recorded model.ClickStatistic
+	db.Where("short_link_id = ?", link.ID).First(&recorded)
+	if recorded.IP != "203.0.113.0" || recorded.VisitorKey == "" {
+		t.Fatalf("click ip must be anonymized: %+v", recorded)
+	}
+	report, err := NewLinkSecurityService(helper).CreateAbuseReport(&dto.AbuseReportCreateRequest{ShortLinkID: link.ID, ReportType: "spam"}, "198.51.100.23", "Mozilla/5.0")
+	if err != nil {
+		t.Fatalf("create abuse report: %v", err)
+	}
+	var storedReport model.AbuseReport
+	db.First(&storedReport, report.ID)
+	if storedReport.ReporterIP != "198.51.100.0" {
+		t.Fatalf("reporter ip must be anonymized: %q", storedReport.ReporterIP)
+	}
+
+	now := time.Now()
+	old := now.AddDate(0, 0, -40)
+	for i := 0; i < 3; i++ {
+		seedClickStatistic(t, db, model.ClickStatistic{WorkspaceID: 1, ShortLinkID: link.ID, IP: "1.1.1.0", ClickDate: old})
+	}
+	seedClickStatistic(t, db, model.ClickStatistic{WorkspaceID: 2, ShortLinkID: 99, IP: "2.2.2.2", ClickDate: old})
+	for _, event := range []model.LinkSecurityEvent{
+		{WorkspaceID: 1, ShortLinkID: link.ID, EventType: "blocked", CreatedAt: now.AddDate(0, 0, -8)},
+		{WorkspaceID: 2, ShortLinkID: 99, EventType: "blocked", CreatedAt: now.AddDate(0, 0, -8)},
+		{WorkspaceID: 1, ShortLinkID: link.ID, EventType: "blocked", CreatedAt: now.AddDate(0, 0, -1)},
+	} {
+		if err := db.Create(&event).Error; err != nil {
+			t.Fatalf("seed event: %v", err)
+		}
+	}
+	for _, log := range []model.OperationLog{
+		{WorkspaceID: 1, Operation: "old", CreatedAt: now.AddDate(0, 0, -20)},
+		{WorkspaceID: 1, Operation: "new", CreatedAt: now.AddDate(0, 0, -2)},
+		{WorkspaceID: 2, Operation: "old", CreatedAt: now.AddDate(0, 0, -20)},
+		{WorkspaceID: 3, Operation: "old", CreatedAt: now.AddDate(0, 0, -20)},
+	} {
+		if err := db.Create(&log).Error; err != nil {
+			t.Fatalf("seed log: %v", err)
+		}
+	}
+
+	// 数据库中的独立访客草图与 Redis 草图一样按 analytics.visitor_sketch_ttl_days 过期
+	for _, sketch := range []model.VisitorSketch{
+		{WorkspaceID: 1, ShortLinkID: link.ID, Day: now.AddDate(0, 0, -401), Registers: []byte{1}},
+		{WorkspaceID: 1, ShortLinkID: link.ID, Day: now.AddDate(0, 0, -2), Registers: []byte{1}},
+	} {
+		if err := db.Create(&sketch).Error; err != nil {
+			t.Fatalf("seed sketch: %v", err)
+		}
+	}
+	if err := db.Delete(&model.Workspace{}, 3).Error; err != nil {
+		t.Fatalf("delete workspace: %v", err)
+	}
+
+	retentionSvc := NewDataRetentionService(helper)
+	count := func(value any, query string, args ...any) int64 {
+		var n int64
+		db.Model(value).Where(query, args...).Count(&n)
+		return n
+	}
+	// 尚未汇总的点击不删除
+	if _, err := retentionSvc.PurgeExpired(now); err != nil {
+		t.Fatalf("purge: %v", err)
+	}
+	if n := count(&model.ClickStatistic{}, "workspace_id = ?", 1); n != 4 {
+		t.Fatalf("clicks pending rollup must be kept, got %d", n)
+	}
+	if n := count(&model.LinkSecurityEvent{}, "event_type = ?", "blocked"); n != 1 {
+		t.Fatalf("events older than server default must be purged in every workspace, got %d", n)
+	}
+	if count(&model.OperationLog{}, "workspace_id = ?", 1) != 1 || count(&model.OperationLog{}, "workspace_id = ?", 2) != 1 {
+		t.Fatalf("operation logs must follow workspace retention")
+	}
+	if count(&model.OperationLog{}, "workspace_id = ?", 3) != 0 {
+		t.Fatalf("data of deleted workspaces must still be purged")
+	}
+	if count(&model.VisitorSketch{}, "day < ?", now.AddDate(0, 0, -400)) != 0 || count(&model.VisitorSketch{}, "1 = 1") != 1 {
+		t.Fatalf("visitor sketches must expire after the sketch ttl")
+	}
+
+	rollupSvc := NewClickRollupService(helper)
+	if _, err := rollupSvc.ProcessPending(); err != nil {
+		t.Fatalf("rollup: %v", err)
+	}
+	purged, err := retentionSvc.PurgeExpired(now)
+	if err != nil || purged != 3 {
+		t.Fatalf("purge rolled up clicks: purged=%d err=%v", purged, err)
+	}
+	if count(&model.ClickStatistic{}, "workspace_id = ?", 1) != 1 || count(&model.ClickStatistic{}, "workspace_id = ?", 2) != 1 {
+		t.Fatalf("clicks must follow workspace retention")
+	}
+
+	// 重建不清空保留期之前的汇总
+	if _, err := rollupSvc.Rebuild(1, time.Time{}, time.Time{}); err != nil {
+		t.Fatalf("rebuild: %v", err)
+	}
+	var total int64
+	db.Model(&model.ClickRollup{}).
+		Where("workspace_id = ? AND granularity = ? AND dimension = ?", 1, model.ClickRollupGranularityDay, model.ClickRollupDimensionTotal).
+		Select("COALESCE(SUM(clicks), 0)").Scan(&total)
+	if total != 4 {
+		t.Fatalf("rollups before retention cutoff must survive rebuild, got %d", total)
+	}
+
+	// 匿名化设置缓存，经工作区设置修改后立即失效
+	db.Model(&model.Workspace{}).Where("id = ?", 1).Update("anonymize_ip", false)
+	if ip := retentionSvc.StoredIP(1, "203.0.113.77"); ip != "203.0.113.0" {
+		t.Fatalf("anonymize setting must be cached, got %q", ip)
+	}
+	disabled := false
+	if _, err := NewWorkspaceService(helper).UpdateWorkspace(1, &dto.UpdateWorkspaceRequest{Name: "Default", AnonymizeIP: &disabled}); err != nil {
+		t.Fatalf("disable anonymize: %v", err)
+	}
+	if ip := retentionSvc.StoredIP(1, "203.0.113.77"); ip != "203.0.113.77" {
+		t.Fatalf("anonymize cache must be cleared on update, got %q", ip)
+	}
+}
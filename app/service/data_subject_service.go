@@ -0,0 +1,375 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/dao"
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/dto"
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/model"
+	"cnb.cool/mliev/dwz/dwz-server/v2/pkg/interfaces"
+	"cnb.cool/mliev/dwz/dwz-server/v2/pkg/service/domain_validate"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultDataSubjectExportLimit = 10000
+	dataSubjectBatchSize          = 500
+	dataSubjectStaleAfter         = 10 * time.Minute
+	dataSubjectListLimit          = 50
+)
+
+var (
+	ErrDataSubjectQueryEmpty      = errors.New("请提供访客IP或访客标识")
+	ErrDataSubjectRequestNotFound = errors.New("数据主体请求不存在")
+
+	errDataSubjectReclaimed = errors.New("任务已被其他实例重新领取")
+)
+
+// dataSubjectTable 含访客个人数据的数据表，clear 为匿名化时清空的字段
+type dataSubjectTable struct {
+	dao.DataSubjectTable
+	clear map[string]any
+}
+
+var dataSubjectClickTable = dao.DataSubjectTable{Name: "click_statistics", IPColumn: "ip", VisitorKeyColumn: "visitor_key"}
+
+// conversions 按点击关联匹配，需排在 click_statistics 之前处理，否则点击删除后无法再关联
+var dataSubjectTables = []dataSubjectTable{
+	{dao.DataSubjectTable{Name: "conversions", IPColumn: "ip", ClickColumn: "click_statistic_id", Clicks: &dataSubjectClickTable}, map[string]any{"user_agent": ""}},
+	{dataSubjectClickTable, map[string]any{"user_agent": "", "visitor_key": ""}},
+	{dao.DataSubjectTable{Name: "ab_test_click_statistics", IPColumn: "ip"}, map[string]any{"user_agent": "", "session_id": ""}},
+	{dao.DataSubjectTable{Name: "ab_test_feedbacks", IPColumn: "ip"}, map[string]any{"user_agent": "", "session_id": ""}},
+	{dao.DataSubjectTable{Name: "abuse_reports", IPColumn: "reporter_ip"}, map[string]any{"user_agent": "", "reporter_email": ""}},
+	{dao.DataSubjectTable{Name: "link_security_events", IPColumn: "client_ip"}, map[string]any{"user_agent": ""}},
+}
+
+// DataSubjectService 按访客IP或访客标识查找、导出、删除或匿名化个人数据
+type DataSubjectService struct {
+	helper         interfaces.HelperInterface
+	dataSubjectDao *dao.DataSubjectDao
+	logService     *OperationLogService
+}
+
+func NewDataSubjectService(helper interfaces.HelperInterface) *DataSubjectService {
+	return &DataSubjectService{
+		helper:         helper,
+		dataSubjectDao: dao.NewDataSubjectDao(helper),
+		logService:     NewOperationLogService(helper),
+	}
+}
+
+// dataSubjectIPs 按IP匹配时只匹配完整IP。开启IP匿名化的工作区保存的网段地址可能属于同一网段内的任何访客，
+// 不能作为该访客的数据导出或删除，只通过 networkCounts 单独报告。
+func dataSubjectIPs(ip string) []string {
+	if ip == "" {
+		return nil
+	}
+	return []string{ip}
+}
+
+// networkCounts 各数据表中IP为查询IP网段地址、无法确认归属的记录数
+func (s *DataSubjectService) networkCounts(workspaceID uint64, query *dto.DataSubjectQuery) (map[string]int64, int64, error) {
+	counts := map[string]int64{}
+	network := anonymizeIP(query.IP)
+	if network == "" || network == query.IP {
+		return counts, 0, nil
+	}
+	var total int64
+	for _, table := range dataSubjectTables {
+		count, err := s.dataSubjectDao.CountNetworkRecords(table.DataSubjectTable, workspaceID, network, dataSubjectIPs(query.IP), query.VisitorKey)
+		if err != nil {
+			return nil, 0, err
+		}
+		if count > 0 {
+			counts[table.Name] = count
+			total += count
+		}
+	}
+	return counts, total, nil
+}
+
+func normalizeDataSubjectQuery(query *dto.DataSubjectQuery) error {
+	query.IP = strings.TrimSpace(query.IP)
+	query.VisitorKey = strings.TrimSpace(query.VisitorKey)
+	if query.IP == "" && query.VisitorKey == "" {
+		return ErrDataSubjectQueryEmpty
+	}
+	return nil
+}
+
+// Search 统计各数据表中匹配的记录数
+func (s *DataSubjectService) Search(workspaceID uint64, query *dto.DataSubjectQuery) (*dto.DataSubjectSearchResponse, error) {
+	if err := normalizeDataSubjectQuery(query); err != nil {
+		return nil, err
+	}
+	response := &dto.DataSubjectSearchResponse{
+		Subject: dataSubjectLabel(query.IP, query.VisitorKey),
+		Counts:  make(map[string]int64, len(dataSubjectTables)),
+	}
+	for _, table := range dataSubjectTables {
+		count, err := s.dataSubjectDao.CountRecords(table.DataSubjectTable, workspaceID, dataSubjectIPs(query.IP), query.VisitorKey)
+		if err != nil {
+			return nil, err
+		}
+		response.Counts[table.Name] = count
+		response.Total += count
+	}
+	networkCounts, networkTotal, err := s.networkCounts(workspaceID, query)
+	if err != nil {
+		return nil, err
+	}
+	response.NetworkCounts, response.NetworkTotal = networkCounts, networkTotal
+	return response, nil
+}
+
+// Export 导出各数据表中匹配的完整记录，返回 JSON
+func (s *DataSubjectService) Export(workspaceID uint64, query *dto.DataSubjectQuery) ([]byte, error) {
+	if err := normalizeDataSubjectQuery(query); err != nil {
+		return nil, err
+	}
+	export := dto.DataSubjectExport{
+		IP:          query.IP,
+		VisitorKey:  query.VisitorKey,
+		WorkspaceID: workspaceID,
+		GeneratedAt: time.Now(),
+		Limit:       defaultDataSubjectExportLimit,
+		Truncated:   make(map[string]bool, len(dataSubjectTables)),
+		Records:     make(map[string][]map[string]any, len(dataSubjectTables)),
+	}
+	for _, table := range dataSubjectTables {
+		// 多取一条用于判断是否截断
+		records, err := s.dataSubjectDao.ListRecords(table.DataSubjectTable, workspaceID, dataSubjectIPs(query.IP), query.VisitorKey, defaultDataSubjectExportLimit+1)
+		if err != nil {
+			return nil, err
+		}
+		if len(records) > defaultDataSubjectExportLimit {
+			records = records[:defaultDataSubjectExportLimit]
+			export.Truncated[table.Name] = true
+		}
+		if records == nil {
+			records = []map[string]any{}
+		}
+		export.Records[table.Name] = records
+	}
+	networkCounts, _, err := s.networkCounts(workspaceID, query)
+	if err != nil {
+		return nil, err
+	}
+	export.NetworkCounts = networkCounts
+	return json.MarshalIndent(export, "", "  ")
+}
+
+// CreateErasure 创建删除或匿名化任务，由后台任务执行
+func (s *DataSubjectService) CreateErasure(workspaceID, userID uint64, req *dto.DataSubjectEraseRequest) (*dto.DataSubjectRequestResponse, error) {
+	if err := normalizeDataSubjectQuery(&req.DataSubjectQuery); err != nil {
+		return nil, err
+	}
+	request := &model.DataSubjectRequest{
+		WorkspaceID: workspaceID,
+		Action:      req.Action,
+		IP:          req.IP,
+		VisitorKey:  req.VisitorKey,
+		Subject:     dataSubjectLabel(req.IP, req.VisitorKey),
+		Status:      model.DataSubjectStatusPending,
+		RequestedBy: actorPtr(userID),
+	}
+	if err := s.dataSubjectDao.Create(request); err != nil {
+		return nil, err
+	}
+	return dataSubjectRequestToResponse(request), nil
+}
+
+func (s *DataSubjectService) List(workspaceID uint64) (*dto.DataSubjectRequestListResponse, error) {
+	requests, err := s.dataSubjectDao.ListInWorkspace(workspaceID, dataSubjectListLimit)
+	if err != nil {
+		return nil, err
+	}
+	list := make([]dto.DataSubjectRequestResponse, 0, len(requests))
+	for i := range requests {
+		list = append(list, *dataSubjectRequestToResponse(&requests[i]))
+	}
+	return &dto.DataSubjectRequestListResponse{List: list}, nil
+}
+
+func (s *DataSubjectService) Get(id, workspaceID uint64) (*dto.DataSubjectRequestResponse, error) {
+	request, err := s.dataSubjectDao.FindByID(id, workspaceID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrDataSubjectRequestNotFound
+		}
+		return nil, err
+	}
+	return dataSubjectRequestToResponse(request), nil
+}
+
+// ProcessPending 执行待处理的删除、匿名化任务，返回处理的记录数
+func (s *DataSubjectService) ProcessPending() (int64, error) {
+	var processed int64
+	for {
+		request, err := s.dataSubjectDao.Claim(time.Now().Add(-dataSubjectStaleAfter))
+		if err != nil || request == nil {
+			return processed, err
+		}
+		startedAt := time.Now()
+		counts, runErr := s.run(request)
+		if errors.Is(runErr, errDataSubjectReclaimed) {
+			continue
+		}
+		var total int64
+		for _, count := range counts {
+			total += count
+		}
+		processed += total
+		if err := s.finish(request, counts, total, runErr, time.Since(startedAt)); err != nil {
+			return processed, err
+		}
+	}
+}
+
+// run 逐表分批删除或匿名化匹配的记录，按ID游标推进
+func (s *DataSubjectService) run(request *model.DataSubjectRequest) (map[string]int64, error) {
+	counts := make(map[string]int64, len(dataSubjectTables))
+	ips := dataSubjectIPs(request.IP)
+	for _, table := range dataSubjectTables {
+		var afterID uint64
+		for {
+			rows, err := s.dataSubjectDao.ListRows(table.DataSubjectTable, request.WorkspaceID, ips, request.VisitorKey, afterID, dataSubjectBatchSize)
+			if err != nil {
+				return counts, err
+			}
+			if len(rows) == 0 {
+				break
+			}
+			if request.Action == model.DataSubjectActionDelete {
+				ids := make([]uint64, 0, len(rows))
+				for _, row := range rows {
+					ids = append(ids, row.ID)
+				}
+				if _, err := s.dataSubjectDao.DeleteRows(table.DataSubjectTable, ids); err != nil {
+					return counts, err
+				}
+			} else if err := s.anonymizeRows(table, rows); err != nil {
+				return counts, err
+			}
+			counts[table.Name] += int64(len(rows))
+			afterID = rows[len(rows)-1].ID
+			if ok, err := s.dataSubjectDao.UpdateClaimed(request, map[string]any{"heartbeat_at": time.Now()}); err != nil || !ok {
+				if err == nil {
+					err = errDataSubjectReclaimed
+				}
+				return counts, err
+			}
+		}
+	}
+	return counts, nil
+}
+
+// anonymizeRows 按截断后的IP分组更新，同时清空 UA 等字段
+func (s *DataSubjectService) anonymizeRows(table dataSubjectTable, rows []dao.DataSubjectRow) error {
+	groups := map[string][]uint64{}
+	for _, row := range rows {
+		anonymized := anonymizeIP(row.IP)
+		groups[anonymized] = append(groups[anonymized], row.ID)
+	}
+	for anonymized, ids := range groups {
+		updates := map[string]any{table.IPColumn: anonymized}
+		for column, value := range table.clear {
+			updates[column] = value
+		}
+		if err := s.dataSubjectDao.UpdateRows(table.DataSubjectTable, ids, updates); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// finish 保存结果并清空任务中的IP和访客标识，同时在操作日志中写入回执
+func (s *DataSubjectService) finish(request *model.DataSubjectRequest, counts map[string]int64, total int64, runErr error, elapsed time.Duration) error {
+	result, _ := json.Marshal(counts)
+	now := time.Now()
+	updates := map[string]any{
+		"status":      model.DataSubjectStatusCompleted,
+		"result":      string(result),
+		"ip":          "",
+		"visitor_key": "",
+		"finished_at": now,
+	}
+	errorMessage := ""
+	if runErr != nil {
+		errorMessage = domain_validate.TruncateString(runErr.Error(), 500)
+		updates["status"] = model.DataSubjectStatusFailed
+		updates["error"] = errorMessage
+	}
+	if _, err := s.dataSubjectDao.UpdateClaimed(request, updates); err != nil {
+		return err
+	}
+
+	receipt, _ := json.Marshal(map[string]any{
+		"request_id":   request.ID,
+		"action":       request.Action,
+		"subject":      request.Subject,
+		"status":       updates["status"],
+		"counts":       counts,
+		"total":        total,
+		"requested_by": request.RequestedBy,
+		"requested_at": request.CreatedAt,
+		"finished_at":  now,
+	})
+	operation := "个人数据删除回执"
+	if request.Action == model.DataSubjectActionAnonymize {
+		operation = "个人数据匿名化回执"
+	}
+	responseCode, status := 200, int8(1)
+	if runErr != nil {
+		responseCode, status = 500, 0
+	}
+	if err := s.logService.CreateLog(request.WorkspaceID, request.RequestedBy, "", operation, "数据主体请求", strconv.FormatUint(request.ID, 10),
+		"", "", string(receipt), "", "", "", responseCode, elapsed.Milliseconds(), status, errorMessage); err != nil {
+		s.helper.GetLogger().Error(fmt.Sprintf("[data-subject] 写入请求 %d 的回执失败: %s", request.ID, err.Error()))
+	}
+	return nil
+}
+
+// dataSubjectLabel 脱敏后的数据主体描述，用于任务记录和回执：IP 只保留网段，访客标识只保留前 8 位
+func dataSubjectLabel(ip, visitorKey string) string {
+	var parts []string
+	if ip != "" {
+		anonymized, prefix := anonymizeIP(ip), "/24"
+		if strings.Contains(anonymized, ":") {
+			prefix = "/48"
+		}
+		parts = append(parts, "ip="+anonymized+prefix)
+	}
+	if visitorKey != "" {
+		if len(visitorKey) > 8 {
+			visitorKey = visitorKey[:8] + "…"
+		}
+		parts = append(parts, "visitor_key="+visitorKey)
+	}
+	return strings.Join(parts, ", ")
+}
+
+func dataSubjectRequestToResponse(request *model.DataSubjectRequest) *dto.DataSubjectRequestResponse {
+	counts := map[string]int64{}
+	if request.Result != "" {
+		_ = json.Unmarshal([]byte(request.Result), &counts)
+	}
+	return &dto.DataSubjectRequestResponse{
+		ID:          request.ID,
+		WorkspaceID: request.WorkspaceID,
+		Action:      request.Action,
+		Subject:     request.Subject,
+		Status:      request.Status,
+		Counts:      counts,
+		Error:       request.Error,
+		RequestedBy: request.RequestedBy,
+		StartedAt:   request.StartedAt,
+		FinishedAt:  request.FinishedAt,
+		CreatedAt:   request.CreatedAt,
+	}
+}
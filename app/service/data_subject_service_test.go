@@ -0,0 +1,139 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/dto"
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/model"
+)
+
+func TestDataSubjectSearchExportAndErasure(t *testing.T) {
+	helper := newShortLinkRegressionHelper(t)
+	db := helper.GetDatabase()
+	const subjectIP, otherIP, visitorKey = "203.0.113.77", "198.51.100.9", "visitor-abcdef123456"
+	for _, workspaceID := range []uint64{1, 2} {
+		seedClickStatistic(t, db, model.ClickStatistic{WorkspaceID: workspaceID, ShortLinkID: 1, IP: subjectIP, UserAgent: "Mozilla/5.0", VisitorKey: "other-visitor"})
+	}
+	visitorClick := model.ClickStatistic{WorkspaceID: 1, ShortLinkID: 1, IP: otherIP, UserAgent: "Mozilla/5.0", VisitorKey: visitorKey}
+	unrelatedClick := model.ClickStatistic{WorkspaceID: 1, ShortLinkID: 1, IP: otherIP, UserAgent: "Mozilla/5.0", VisitorKey: "unrelated"}
+	for _, click := range []*model.ClickStatistic{&visitorClick, &unrelatedClick} {
+		if err := db.Create(click).Error; err != nil {
+			t.Fatalf("seed click: %v", err)
+		}
+	}
+	// 开启IP匿名化时写入的是网段地址，可能属于同一网段内的任何访客，不能当作该访客的数据
+	seedClickStatistic(t, db, model.ClickStatistic{WorkspaceID: 1, ShortLinkID: 1, IP: "203.0.113.0", UserAgent: "Mozilla/5.0"})
+	for _, record := range []any{
+		&model.Conversion{WorkspaceID: 1, ShortLinkID: 1, ClickStatisticID: visitorClick.ID, ClickID: "c1", Event: "purchase", EventID: "purchase:c1", Source: "postback", IP: otherIP, UserAgent: "Mozilla/5.0", OccurredAt: time.Now()},
+		&model.Conversion{WorkspaceID: 1, ShortLinkID: 1, ClickStatisticID: unrelatedClick.ID, ClickID: "c2", Event: "purchase", EventID: "purchase:c2", Source: "pixel", IP: subjectIP, UserAgent: "Mozilla/5.0", OccurredAt: time.Now()},
+		&model.ABTestClickStatistic{WorkspaceID: 1, ABTestID: 1, VariantID: 1, ShortLinkID: 1, IP: subjectIP, UserAgent: "Mozilla/5.0", SessionID: "s1"},
+		&model.ABTestFeedback{WorkspaceID: 1, ABTestID: 1, VariantID: 1, ShortLinkID: 1, SessionID: "s1", EventID: "e1", IP: subjectIP, UserAgent: "Mozilla/5.0"},
+		&model.AbuseReport{WorkspaceID: 1, ShortLinkID: 1, ReportType: "phishing", ReporterEmail: "reporter@example.com", ReporterIP: subjectIP, UserAgent: "Mozilla/5.0"},
+		&model.LinkSecurityEvent{WorkspaceID: 1, ShortLinkID: 1, EventType: "blocked", ClientIP: subjectIP, UserAgent: "Mozilla/5.0"},
+	} {
+		if err := db.Create(record).Error; err != nil {
+			t.Fatalf("seed %T: %v", record, err)
+		}
+	}
+
+	svc := NewDataSubjectService(helper)
+	if _, err := svc.Search(1, &dto.DataSubjectQuery{IP: " "}); !errors.Is(err, ErrDataSubjectQueryEmpty) {
+		t.Fatalf("empty query must be rejected: %v", err)
+	}
+	found, err := svc.Search(1, &dto.DataSubjectQuery{IP: subjectIP, VisitorKey: visitorKey})
+	if err != nil || found.Total != 8 || found.Counts["click_statistics"] != 2 || found.Counts["conversions"] != 2 || found.Counts["abuse_reports"] != 1 || found.Subject != "ip=203.0.113.0/24, visitor_key=visitor-…" ||
+		found.NetworkTotal != 1 || found.NetworkCounts["click_statistics"] != 1 {
+		t.Fatalf("search: %+v err=%v", found, err)
+	}
+
+	data, err := svc.Export(1, &dto.DataSubjectQuery{IP: subjectIP})
+	if err != nil {
+		t.Fatalf("export: %v", err)
+	}
+	var export dto.DataSubjectExport
+	if err := json.Unmarshal(data, &export); err != nil {
+		t.Fatalf("decode export: %v", err)
+	}
+	clicks := export.Records["click_statistics"]
+	if len(clicks) != 1 || clicks[0]["ip"] != subjectIP || export.NetworkCounts["click_statistics"] != 1 || len(export.Records["conversions"]) != 1 || len(export.Records["link_security_events"]) != 1 || export.Truncated["click_statistics"] {
+		t.Fatalf("export: %s", data)
+	}
+
+	anonymize, err := svc.CreateErasure(1, 7, &dto.DataSubjectEraseRequest{
+		DataSubjectQuery: dto.DataSubjectQuery{IP: subjectIP},
+		Action:           model.DataSubjectActionAnonymize,
+	})
+	if err != nil || anonymize.Status != model.DataSubjectStatusPending {
+		t.Fatalf("create anonymize request: %+v err=%v", anonymize, err)
+	}
+	processed, err := svc.ProcessPending()
+	if err != nil || processed != 6 {
+		t.Fatalf("process anonymize: processed=%d err=%v", processed, err)
+	}
+	var click model.ClickStatistic
+	db.Where("workspace_id = ? AND visitor_key = ?", 2, "other-visitor").First(&click)
+	if click.IP != subjectIP || click.UserAgent == "" {
+		t.Fatalf("other workspace must be untouched: %+v", click)
+	}
+	var report model.AbuseReport
+	db.First(&report)
+	if report.ReporterIP != "203.0.113.0" || report.UserAgent != "" || report.ReporterEmail != "" {
+		t.Fatalf("abuse report must be anonymized: %+v", report)
+	}
+	var anonymized, networkUntouched int64
+	db.Model(&model.ClickStatistic{}).Where("workspace_id = ? AND ip = ? AND user_agent = '' AND visitor_key = ''", 1, "203.0.113.0").Count(&anonymized)
+	db.Model(&model.ClickStatistic{}).Where("workspace_id = ? AND ip = ? AND user_agent <> ''", 1, "203.0.113.0").Count(&networkUntouched)
+	if anonymized != 1 || networkUntouched != 1 {
+		t.Fatalf("only the subject's click must be anonymized: anonymized=%d untouched=%d", anonymized, networkUntouched)
+	}
+	var conversion model.Conversion
+	db.Where("event_id = ?", "purchase:c2").First(&conversion)
+	if conversion.IP != "203.0.113.0" || conversion.UserAgent != "" {
+		t.Fatalf("conversion must be anonymized: %+v", conversion)
+	}
+
+	var stored model.DataSubjectRequest
+	db.First(&stored, anonymize.ID)
+	if stored.Status != model.DataSubjectStatusCompleted || stored.IP != "" || stored.FinishedAt == nil {
+		t.Fatalf("request must be completed without raw identifiers: %+v", stored)
+	}
+	var receipt model.OperationLog
+	if err := db.Where("resource = ? AND resource_id = ?", "数据主体请求", "1").First(&receipt).Error; err != nil {
+		t.Fatalf("receipt: %v", err)
+	}
+	var body map[string]any
+	if err := json.Unmarshal([]byte(receipt.RequestBody), &body); err != nil || receipt.Operation != "个人数据匿名化回执" ||
+		body["total"] != float64(6) || body["subject"] != "ip=203.0.113.0/24" || receipt.UserID == nil || *receipt.UserID != 7 {
+		t.Fatalf("receipt: %+v body=%v", receipt, body)
+	}
+
+	deletion, err := svc.CreateErasure(1, 7, &dto.DataSubjectEraseRequest{
+		DataSubjectQuery: dto.DataSubjectQuery{VisitorKey: visitorKey},
+		Action:           model.DataSubjectActionDelete,
+	})
+	if err != nil {
+		t.Fatalf("create delete request: %v", err)
+	}
+	// 访客的点击及其转化一并删除
+	if processed, err := svc.ProcessPending(); err != nil || processed != 2 {
+		t.Fatalf("process delete: processed=%d err=%v", processed, err)
+	}
+	var remaining int64
+	db.Model(&model.ClickStatistic{}).Where("workspace_id = ?", 1).Count(&remaining)
+	if remaining != 3 {
+		t.Fatalf("only the visitor's click must be deleted, %d left", remaining)
+	}
+	response, err := svc.Get(deletion.ID, 1)
+	if err != nil || response.Status != model.DataSubjectStatusCompleted || response.Counts["click_statistics"] != 1 || response.Counts["conversions"] != 1 {
+		t.Fatalf("get: %+v err=%v", response, err)
+	}
+	if _, err := svc.Get(deletion.ID, 2); !errors.Is(err, ErrDataSubjectRequestNotFound) {
+		t.Fatalf("request must be scoped to its workspace: %v", err)
+	}
+	if list, err := svc.List(1); err != nil || len(list.List) != 2 || list.List[0].ID != deletion.ID {
+		t.Fatalf("list: %+v err=%v", list, err)
+	}
+}
@@ -0,0 +1,467 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/dao"
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/dto"
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/model"
+	"cnb.cool/mliev/dwz/dwz-server/v2/pkg/interfaces"
+	"gorm.io/gorm"
+)
+
+type FolderService struct {
+	helper       interfaces.HelperInterface
+	folderDao    *dao.FolderDao
+	shortLinkDao *dao.ShortLinkDao
+	domainDao    *dao.DomainDao
+}
+
+func NewFolderService(helper interfaces.HelperInterface) *FolderService {
+	return &FolderService{
+		helper:       helper,
+		folderDao:    dao.NewFolderDao(helper),
+		shortLinkDao: dao.NewShortLinkDao(helper),
+		domainDao:    dao.NewDomainDao(helper),
+	}
+}
+
+func (s *FolderService) Create(workspaceID, userID uint64, req *dto.FolderRequest) (*dto.FolderResponse, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, errors.New("文件夹名称不能为空")
+	}
+	folders, err := s.folderDao.ListInWorkspace(workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	tree := newFolderTree(folders)
+	if req.ParentID != nil {
+		if _, ok := tree.byID[*req.ParentID]; !ok {
+			return nil, errors.New("上级文件夹不存在")
+		}
+		if tree.depth(*req.ParentID)+1 > model.FolderMaxDepth {
+			return nil, fmt.Errorf("文件夹层级不能超过 %d 层", model.FolderMaxDepth)
+		}
+	}
+	if err := s.ensureUniqueName(workspaceID, req.ParentID, name, 0); err != nil {
+		return nil, err
+	}
+
+	folder := &model.Folder{
+		WorkspaceID: workspaceID,
+		ParentID:    req.ParentID,
+		Name:        name,
+		Description: req.Description,
+		CreatedBy:   actorPtr(userID),
+	}
+	if err := s.applyDefaults(folder, req.Defaults); err != nil {
+		return nil, err
+	}
+	if err := s.folderDao.Create(folder); err != nil {
+		return nil, err
+	}
+	tree.add(*folder)
+	resp := s.modelToResponse(folder, tree, 0)
+	return &resp, nil
+}
+
+func (s *FolderService) Update(id, workspaceID uint64, req *dto.FolderRequest) (*dto.FolderResponse, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, errors.New("文件夹名称不能为空")
+	}
+	folders, err := s.folderDao.ListInWorkspace(workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	tree := newFolderTree(folders)
+	current, ok := tree.byID[id]
+	if !ok {
+		return nil, errors.New("文件夹不存在")
+	}
+	folder := current
+	if req.ParentID != nil {
+		if _, ok := tree.byID[*req.ParentID]; !ok {
+			return nil, errors.New("上级文件夹不存在")
+		}
+		if tree.isDescendant(*req.ParentID, id) {
+			return nil, errors.New("不能将文件夹移动到自身或其子文件夹下")
+		}
+		if tree.depth(*req.ParentID)+tree.height(id) > model.FolderMaxDepth {
+			return nil, fmt.Errorf("文件夹层级不能超过 %d 层", model.FolderMaxDepth)
+		}
+	}
+	if err := s.ensureUniqueName(workspaceID, req.ParentID, name, id); err != nil {
+		return nil, err
+	}
+
+	folder.ParentID = req.ParentID
+	folder.Name = name
+	folder.Description = req.Description
+	if req.Defaults != nil {
+		if err := s.applyDefaults(&folder, req.Defaults); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.folderDao.Update(&folder); err != nil {
+		return nil, err
+	}
+	tree.add(folder)
+	counts, err := s.folderDao.CountShortLinksByFolder(workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	resp := s.modelToResponse(&folder, tree, counts[folder.ID])
+	return &resp, nil
+}
+
+// Delete 删除空文件夹，文件夹中仍有短网址或子文件夹时拒绝删除
+func (s *FolderService) Delete(id, workspaceID uint64) error {
+	if _, err := s.findFolder(id, workspaceID); err != nil {
+		return err
+	}
+	children, err := s.folderDao.CountChildren(id, workspaceID)
+	if err != nil {
+		return err
+	}
+	links, err := s.shortLinkDao.CountInFolders(workspaceID, []uint64{id})
+	if err != nil {
+		return err
+	}
+	if children > 0 || links > 0 {
+		return errors.New("文件夹不为空，请先移出其中的短网址和子文件夹")
+	}
+	return s.folderDao.Delete(id, workspaceID)
+}
+
+func (s *FolderService) Get(id, workspaceID uint64) (*dto.FolderResponse, error) {
+	folders, err := s.folderDao.ListInWorkspace(workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	tree := newFolderTree(folders)
+	folder, ok := tree.byID[id]
+	if !ok {
+		return nil, errors.New("文件夹不存在")
+	}
+	counts, err := s.folderDao.CountShortLinksByFolder(workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	resp := s.modelToResponse(&folder, tree, counts[id])
+	return &resp, nil
+}
+
+func (s *FolderService) List(workspaceID uint64, req *dto.FolderListRequest) (*dto.FolderListResponse, error) {
+	folders, err := s.folderDao.ListInWorkspace(workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	counts, err := s.folderDao.CountShortLinksByFolder(workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	tree := newFolderTree(folders)
+	keyword := strings.ToLower(strings.TrimSpace(req.Keyword))
+	list := make([]dto.FolderResponse, 0, len(folders))
+	for _, folder := range folders {
+		if req.ParentID != nil {
+			if (*req.ParentID == 0 && folder.ParentID != nil) ||
+				(*req.ParentID > 0 && (folder.ParentID == nil || *folder.ParentID != *req.ParentID)) {
+				continue
+			}
+		}
+		if keyword != "" && !strings.Contains(strings.ToLower(folder.Name), keyword) {
+			continue
+		}
+		list = append(list, s.modelToResponse(&folder, tree, counts[folder.ID]))
+	}
+	return &dto.FolderListResponse{List: list}, nil
+}
+
+// Statistics 文件夹聚合统计，包含所有子文件夹中的短网址，筛选逻辑复用点击统计
+func (s *FolderService) Statistics(id, workspaceID uint64, req *dto.FolderStatisticsRequest) (*dto.FolderStatisticsResponse, error) {
+	folders, err := s.folderDao.ListInWorkspace(workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	tree := newFolderTree(folders)
+	folder, ok := tree.byID[id]
+	if !ok {
+		return nil, errors.New("文件夹不存在")
+	}
+	folderIDs := tree.subtree(id)
+	linkCount, err := s.shortLinkDao.CountInFolders(workspaceID, folderIDs)
+	if err != nil {
+		return nil, err
+	}
+	counts, err := s.folderDao.CountShortLinksByFolder(workspaceID)
+	if err != nil {
+		return nil, err
+	}
+
+	clickReq := &dto.ClickStatisticListRequest{
+		FolderID:  id,
+		FolderIDs: folderIDs,
+		IsBot:     req.IsBot,
+		StartDate: req.StartDate,
+		EndDate:   req.EndDate,
+		Location:  req.Location,
+	}
+	if req.Location != nil {
+		clickReq.Timezone = req.Location.String()
+	}
+	if !clickReq.EndDate.IsZero() {
+		clickReq.EndDate = clickReq.EndDate.AddDate(0, 0, 1)
+	}
+	analysis, err := NewClickStatisticService(s.helper).GetClickStatisticAnalysisInWorkspace(workspaceID, clickReq, req.Days)
+	if err != nil {
+		return nil, err
+	}
+	return &dto.FolderStatisticsResponse{
+		Folder:         s.modelToResponse(&folder, tree, counts[id]),
+		FolderCount:    len(folderIDs),
+		ShortLinkCount: linkCount,
+		Analysis:       analysis,
+	}, nil
+}
+
+// SubtreeIDs 返回文件夹及其全部子文件夹ID，文件夹不存在时返回错误
+func (s *FolderService) SubtreeIDs(id, workspaceID uint64) ([]uint64, error) {
+	folders, err := s.folderDao.ListInWorkspace(workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	tree := newFolderTree(folders)
+	if _, ok := tree.byID[id]; !ok {
+		return nil, errors.New("文件夹不存在")
+	}
+	return tree.subtree(id), nil
+}
+
+// ApplyCreateDefaults 用文件夹默认设置填充新建请求中未填写的域名、UTM 和安全设置
+func (s *FolderService) ApplyCreateDefaults(workspaceID uint64, req *dto.CreateShortLinkRequest) error {
+	if req.FolderID == nil {
+		return nil
+	}
+	folder, err := s.findFolder(*req.FolderID, workspaceID)
+	if err != nil {
+		return err
+	}
+	if req.Domain == "" {
+		req.Domain = folder.DefaultDomain
+	}
+	if req.UTMSource == "" {
+		req.UTMSource = folder.DefaultUTMSource
+	}
+	if req.UTMMedium == "" {
+		req.UTMMedium = folder.DefaultUTMMedium
+	}
+	if req.UTMCampaign == "" {
+		req.UTMCampaign = folder.DefaultUTMCampaign
+	}
+	if req.UTMTerm == "" {
+		req.UTMTerm = folder.DefaultUTMTerm
+	}
+	if req.UTMContent == "" {
+		req.UTMContent = folder.DefaultUTMContent
+	}
+	if req.Security == nil && folder.DefaultSecurity != "" {
+		var security dto.LinkSecurityRequest
+		if err := json.Unmarshal([]byte(folder.DefaultSecurity), &security); err != nil {
+			return errors.New("文件夹默认安全设置无效")
+		}
+		req.Security = &security
+	}
+	return nil
+}
+
+func (s *FolderService) findFolder(id, workspaceID uint64) (*model.Folder, error) {
+	folder, err := s.folderDao.FindByID(id, workspaceID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("文件夹不存在")
+		}
+		return nil, err
+	}
+	return folder, nil
+}
+
+func (s *FolderService) ensureUniqueName(workspaceID uint64, parentID *uint64, name string, excludeID uint64) error {
+	exists, err := s.folderDao.ExistsSiblingName(workspaceID, parentID, name, excludeID)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return errors.New("同级目录下已存在同名文件夹")
+	}
+	return nil
+}
+
+func (s *FolderService) applyDefaults(folder *model.Folder, defaults *dto.FolderDefaults) error {
+	if defaults == nil {
+		return nil
+	}
+	domain := strings.TrimSpace(defaults.Domain)
+	if domain != "" {
+		domainInfo, err := s.domainDao.FindByDomain(domain)
+		if err != nil || domainInfo.WorkspaceID != folder.WorkspaceID {
+			return errors.New("默认域名不存在")
+		}
+	}
+	security := ""
+	if defaults.Security != nil {
+		if defaults.Security.Password != nil && *defaults.Security.Password != "" {
+			return errors.New("文件夹默认安全设置不支持访问密码")
+		}
+		raw, err := json.Marshal(defaults.Security)
+		if err != nil {
+			return err
+		}
+		security = string(raw)
+	}
+	folder.DefaultDomain = domain
+	folder.DefaultUTMSource = defaults.UTMSource
+	folder.DefaultUTMMedium = defaults.UTMMedium
+	folder.DefaultUTMCampaign = defaults.UTMCampaign
+	folder.DefaultUTMTerm = defaults.UTMTerm
+	folder.DefaultUTMContent = defaults.UTMContent
+	folder.DefaultSecurity = security
+	return nil
+}
+
+func (s *FolderService) modelToResponse(folder *model.Folder, tree *folderTree, linkCount int64) dto.FolderResponse {
+	defaults := dto.FolderDefaults{
+		Domain:      folder.DefaultDomain,
+		UTMSource:   folder.DefaultUTMSource,
+		UTMMedium:   folder.DefaultUTMMedium,
+		UTMCampaign: folder.DefaultUTMCampaign,
+		UTMTerm:     folder.DefaultUTMTerm,
+		UTMContent:  folder.DefaultUTMContent,
+	}
+	if folder.DefaultSecurity != "" {
+		var security dto.LinkSecurityRequest
+		if json.Unmarshal([]byte(folder.DefaultSecurity), &security) == nil {
+			defaults.Security = &security
+		}
+	}
+	return dto.FolderResponse{
+		ID:             folder.ID,
+		WorkspaceID:    folder.WorkspaceID,
+		ParentID:       folder.ParentID,
+		Name:           folder.Name,
+		Description:    folder.Description,
+		Path:           tree.path(folder.ID),
+		Defaults:       defaults,
+		ShortLinkCount: linkCount,
+		CreatedBy:      folder.CreatedBy,
+		CreatedAt:      folder.CreatedAt,
+		UpdatedAt:      folder.UpdatedAt,
+	}
+}
+
+// folderTree 工作区文件夹目录树，用于计算路径、层级和子树
+type folderTree struct {
+	byID     map[uint64]model.Folder
+	children map[uint64][]uint64
+}
+
+func newFolderTree(folders []model.Folder) *folderTree {
+	tree := &folderTree{
+		byID:     make(map[uint64]model.Folder, len(folders)),
+		children: make(map[uint64][]uint64),
+	}
+	for _, folder := range folders {
+		tree.add(folder)
+	}
+	return tree
+}
+
+func (t *folderTree) add(folder model.Folder) {
+	if previous, ok := t.byID[folder.ID]; ok && previous.ParentID != nil {
+		siblings := t.children[*previous.ParentID]
+		for i, id := range siblings {
+			if id == folder.ID {
+				t.children[*previous.ParentID] = append(siblings[:i:i], siblings[i+1:]...)
+				break
+			}
+		}
+	}
+	t.byID[folder.ID] = folder
+	if folder.ParentID != nil {
+		t.children[*folder.ParentID] = append(t.children[*folder.ParentID], folder.ID)
+	}
+}
+
+// path 从根目录到文件夹的名称列表，遇到环或缺失的上级时截断
+func (t *folderTree) path(id uint64) []string {
+	var names []string
+	seen := map[uint64]bool{}
+	for current, ok := t.byID[id]; ok && !seen[current.ID]; current, ok = t.byID[derefUint64(current.ParentID)] {
+		seen[current.ID] = true
+		names = append([]string{current.Name}, names...)
+		if current.ParentID == nil {
+			break
+		}
+	}
+	return names
+}
+
+// depth 文件夹所在层级，根目录下的文件夹为 1
+func (t *folderTree) depth(id uint64) int {
+	return len(t.path(id))
+}
+
+// height 以该文件夹为根的子树层数
+func (t *folderTree) height(id uint64) int {
+	var walk func(uint64, int) int
+	walk = func(current uint64, level int) int {
+		if level > model.FolderMaxDepth*2 {
+			return level
+		}
+		best := 1
+		for _, child := range t.children[current] {
+			if h := walk(child, level+1) + 1; h > best {
+				best = h
+			}
+		}
+		return best
+	}
+	return walk(id, 0)
+}
+
+// isDescendant candidate 是否为 ancestor 本身或其子孙
+func (t *folderTree) isDescendant(candidate, ancestor uint64) bool {
+	for _, id := range t.subtree(ancestor) {
+		if id == candidate {
+			return true
+		}
+	}
+	return false
+}
+
+// subtree 返回文件夹及其全部子孙文件夹ID
+func (t *folderTree) subtree(id uint64) []uint64 {
+	ids := []uint64{id}
+	seen := map[uint64]bool{id: true}
+	for i := 0; i < len(ids); i++ {
+		for _, child := range t.children[ids[i]] {
+			if !seen[child] {
+				seen[child] = true
+				ids = append(ids, child)
+			}
+		}
+	}
+	return ids
+}
+
+func derefUint64(value *uint64) uint64 {
+	if value == nil {
+		return 0
+	}
+	return *value
+}
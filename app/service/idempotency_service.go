@@ -0,0 +1,149 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/dao"
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/model"
+	"cnb.cool/mliev/dwz/dwz-server/v2/pkg/interfaces"
+	"gorm.io/gorm"
+)
+
+// 幂等作用域，区分不同接口下相同的 Idempotency-Key
+const (
+	IdempotencyScopeCreateShortLink       = "short_links.create"
+	IdempotencyScopeBatchCreateShortLinks = "short_links.batch_create"
+)
+
+const (
+	defaultIdempotencyRetentionHours = 24
+	maxIdempotencyKeyLength          = 255
+	// 处理中的记录超过该时长视为进程中断遗留，允许重新执行
+	idempotencyProcessingTimeout = 5 * time.Minute
+)
+
+var (
+	ErrIdempotencyKeyTooLong    = errors.New("Idempotency-Key 长度不能超过255个字符")
+	ErrIdempotencyKeyMismatch   = errors.New("Idempotency-Key 已用于不同的请求参数")
+	ErrIdempotencyKeyInProgress = errors.New("相同 Idempotency-Key 的请求正在处理中")
+)
+
+type IdempotencyService struct {
+	helper            interfaces.HelperInterface
+	idempotencyKeyDao *dao.IdempotencyKeyDao
+}
+
+func NewIdempotencyService(helper interfaces.HelperInterface) *IdempotencyService {
+	return &IdempotencyService{
+		helper:            helper,
+		idempotencyKeyDao: dao.NewIdempotencyKeyDao(helper),
+	}
+}
+
+// Begin 登记一次幂等请求。key 为空时返回 nil 记录，表示不启用幂等；
+// replay 为 true 时记录中保存了首次请求的响应，调用方应直接返回该响应。
+func (s *IdempotencyService) Begin(workspaceID uint64, scope, key string, payload any) (record *model.IdempotencyKey, replay bool, err error) {
+	key = strings.TrimSpace(key)
+	if key == "" {
+		return nil, false, nil
+	}
+	if len(key) > maxIdempotencyKeyLength {
+		return nil, false, ErrIdempotencyKeyTooLong
+	}
+	requestHash, err := hashIdempotencyPayload(payload)
+	if err != nil {
+		return nil, false, err
+	}
+
+	// 插入可能与并发请求冲突，冲突后重新读取一次已有记录
+	for attempt := 0; attempt < 2; attempt++ {
+		now := time.Now()
+		existing, err := s.idempotencyKeyDao.Find(workspaceID, scope, key)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, false, err
+		}
+		if err == nil {
+			stale := existing.CompletedAt == nil && now.Sub(existing.CreatedAt) > idempotencyProcessingTimeout
+			if !existing.ExpiresAt.After(now) || stale {
+				if err := s.idempotencyKeyDao.Delete(existing.ID); err != nil {
+					return nil, false, err
+				}
+			} else {
+				if existing.RequestHash != requestHash {
+					return nil, false, ErrIdempotencyKeyMismatch
+				}
+				if existing.CompletedAt == nil {
+					return nil, false, ErrIdempotencyKeyInProgress
+				}
+				return existing, true, nil
+			}
+		}
+
+		record = &model.IdempotencyKey{
+			WorkspaceID:    workspaceID,
+			Scope:          scope,
+			IdempotencyKey: key,
+			RequestHash:    requestHash,
+			ExpiresAt:      now.Add(s.retention()),
+		}
+		createErr := s.idempotencyKeyDao.Create(record)
+		if createErr == nil {
+			return record, false, nil
+		}
+		// 只有并发请求抢先写入同一个键时才重新读取，其余写入错误直接返回
+		if _, err := s.idempotencyKeyDao.Find(workspaceID, scope, key); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, false, createErr
+			}
+			return nil, false, err
+		}
+	}
+	return nil, false, ErrIdempotencyKeyInProgress
+}
+
+// Complete 保存首次请求的响应，供窗口期内的重试重放
+func (s *IdempotencyService) Complete(record *model.IdempotencyKey, response any) error {
+	if record == nil {
+		return nil
+	}
+	body, err := json.Marshal(response)
+	if err != nil {
+		return err
+	}
+	return s.idempotencyKeyDao.Complete(record.ID, string(body), time.Now())
+}
+
+// Release 请求失败时释放幂等键，允许客户端使用相同的键重试
+func (s *IdempotencyService) Release(record *model.IdempotencyKey) error {
+	if record == nil {
+		return nil
+	}
+	return s.idempotencyKeyDao.Delete(record.ID)
+}
+
+// PurgeExpired 清理超过保留窗口的幂等键
+func (s *IdempotencyService) PurgeExpired() (int64, error) {
+	return s.idempotencyKeyDao.DeleteExpired(time.Now())
+}
+
+func (s *IdempotencyService) retention() time.Duration {
+	hours := s.helper.GetConfig().GetInt("idempotency.retention_hours", defaultIdempotencyRetentionHours)
+	if hours <= 0 {
+		hours = defaultIdempotencyRetentionHours
+	}
+	return time.Duration(hours) * time.Hour
+}
+
+func hashIdempotencyPayload(payload any) (string, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:]), nil
+}
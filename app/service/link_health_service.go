@@ -0,0 +1,453 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/dao"
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/dto"
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/model"
+	"cnb.cool/mliev/dwz/dwz-server/v2/pkg/interfaces"
+	"cnb.cool/mliev/dwz/dwz-server/v2/pkg/service/domain_validate"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultHealthCheckIntervalMinutes  = 360
+	defaultHealthCheckBatchSize        = 200
+	defaultHealthCheckConcurrency      = 4
+	defaultHealthCheckRatePerSecond    = 5
+	defaultHealthCheckTimeoutSeconds   = 10
+	defaultHealthCheckFailureThreshold = 2
+	defaultHealthCheckHostIntervalMs   = 1000
+	defaultHealthCheckManualCooldown   = 60
+	healthCheckHostThrottleMaxHosts    = 10000
+
+	healthCheckMaxRedirects = 10
+	// 响应体只读取少量内容，避免大文件拖慢检查
+	healthCheckMaxBodyBytes = 64 << 10
+	healthCheckUserAgent    = "Mozilla/5.0 (compatible; DWZHealthCheck/1.0)"
+)
+
+// LinkHealthService 定期请求短网址的各个目标地址，记录状态码、跳转链、耗时与证书到期时间
+type LinkHealthService struct {
+	helper              interfaces.HelperInterface
+	linkHealthDao       *dao.LinkHealthDao
+	shortLinkDao        *dao.ShortLinkDao
+	linkSecurityService *LinkSecurityService
+	client              *http.Client
+}
+
+func NewLinkHealthService(helper interfaces.HelperInterface) *LinkHealthService {
+	timeout := helper.GetConfig().GetInt("health_check.timeout_seconds", defaultHealthCheckTimeoutSeconds)
+	if timeout <= 0 {
+		timeout = defaultHealthCheckTimeoutSeconds
+	}
+	return &LinkHealthService{
+		helper:              helper,
+		linkHealthDao:       dao.NewLinkHealthDao(helper),
+		shortLinkDao:        dao.NewShortLinkDao(helper),
+		linkSecurityService: NewLinkSecurityService(helper),
+		client:              sharedMetadataHTTPClient(time.Duration(timeout) * time.Second),
+	}
+}
+
+// linkHealthTarget 待检查的目标地址
+type linkHealthTarget struct {
+	targetType string
+	targetID   uint64
+	url        string
+}
+
+// linkHealthProbe 单次请求的结果
+type linkHealthProbe struct {
+	healthy      bool
+	statusCode   int
+	err          string
+	chain        []string
+	latencyMs    int64
+	tlsExpiresAt *time.Time
+}
+
+var ErrLinkHealthCheckTooFrequent = errors.New("检查过于频繁，请稍后再试")
+
+// healthCheckHosts 本实例内同一主机两次请求的最小间隔，定时检查与手动检查共用
+var healthCheckHosts = &healthCheckHostThrottle{next: make(map[string]time.Time)}
+
+type healthCheckHostThrottle struct {
+	mu   sync.Mutex
+	next map[string]time.Time
+}
+
+// Wait 预约主机的下一个请求时间并等待到该时间
+func (t *healthCheckHostThrottle) Wait(host string, interval time.Duration) {
+	if host == "" || interval <= 0 {
+		return
+	}
+	t.mu.Lock()
+	now := time.Now()
+	if len(t.next) >= healthCheckHostThrottleMaxHosts {
+		for key, next := range t.next {
+			if next.Before(now) {
+				delete(t.next, key)
+			}
+		}
+	}
+	at := t.next[host]
+	if at.Before(now) {
+		at = now
+	}
+	t.next[host] = at.Add(interval)
+	t.mu.Unlock()
+	time.Sleep(at.Sub(now))
+}
+
+// healthCheckThrottle 限制所有并发检查合计的请求速率
+type healthCheckThrottle struct {
+	ticker *time.Ticker
+}
+
+func newHealthCheckThrottle(ratePerSecond int) *healthCheckThrottle {
+	if ratePerSecond <= 0 {
+		return &healthCheckThrottle{}
+	}
+	return &healthCheckThrottle{ticker: time.NewTicker(time.Second / time.Duration(ratePerSecond))}
+}
+
+func (t *healthCheckThrottle) Wait() {
+	if t.ticker != nil {
+		<-t.ticker.C
+	}
+}
+
+func (t *healthCheckThrottle) Stop() {
+	if t.ticker != nil {
+		t.ticker.Stop()
+	}
+}
+
+// CheckDueShortLinks 检查一批到期的短网址，返回本轮检查的短网址数量。未启用时直接返回。
+func (s *LinkHealthService) CheckDueShortLinks() (int, error) {
+	config := s.helper.GetConfig()
+	if !config.GetBool("health_check.enabled", false) {
+		return 0, nil
+	}
+	interval := config.GetInt("health_check.interval_minutes", defaultHealthCheckIntervalMinutes)
+	batchSize := config.GetInt("health_check.batch_size", defaultHealthCheckBatchSize)
+	if batchSize <= 0 {
+		batchSize = defaultHealthCheckBatchSize
+	}
+	concurrency := config.GetInt("health_check.concurrency", defaultHealthCheckConcurrency)
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+
+	checkedBefore := time.Now().Add(-time.Duration(interval) * time.Minute)
+	candidates, err := s.linkHealthDao.ListDueShortLinks(checkedBefore, batchSize)
+	if err != nil || len(candidates) == 0 {
+		return 0, err
+	}
+	// 每个实例都会执行该任务，先逐个领取，已被其他实例领取的跳过
+	shortLinks := candidates[:0]
+	for i := range candidates {
+		claimed, err := s.linkHealthDao.ClaimShortLink(candidates[i].ID, checkedBefore, time.Now())
+		if err != nil {
+			return 0, err
+		}
+		if claimed {
+			shortLinks = append(shortLinks, candidates[i])
+		}
+	}
+	if len(shortLinks) == 0 {
+		return 0, nil
+	}
+
+	throttle := newHealthCheckThrottle(config.GetInt("health_check.rate_per_second", defaultHealthCheckRatePerSecond))
+	defer throttle.Stop()
+
+	jobs := make(chan *model.ShortLink)
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for shortLink := range jobs {
+				if err := s.checkShortLink(shortLink, throttle); err != nil {
+					s.helper.GetLogger().Warn(fmt.Sprintf("[link-health] 检查短网址 %d 失败: %s", shortLink.ID, err.Error()))
+				}
+			}
+		}()
+	}
+	for i := range shortLinks {
+		jobs <- &shortLinks[i]
+	}
+	close(jobs)
+	wg.Wait()
+	return len(shortLinks), nil
+}
+
+// CheckShortLinkInWorkspace 立即检查指定短网址的全部目标地址。
+// 与定时检查一样限速，且同一短网址在 health_check.manual_cooldown_seconds 内只能检查一次
+func (s *LinkHealthService) CheckShortLinkInWorkspace(id, workspaceID uint64) (*dto.LinkHealthResponse, error) {
+	shortLink, err := s.findShortLink(id, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	cooldown := s.helper.GetConfig().GetInt("health_check.manual_cooldown_seconds", defaultHealthCheckManualCooldown)
+	checkedBefore := now.Add(-time.Duration(cooldown) * time.Second)
+	claimed, err := s.linkHealthDao.ClaimShortLink(shortLink.ID, checkedBefore, now)
+	if err != nil {
+		return nil, err
+	}
+	if !claimed {
+		return nil, ErrLinkHealthCheckTooFrequent
+	}
+	throttle := newHealthCheckThrottle(s.helper.GetConfig().GetInt("health_check.rate_per_second", defaultHealthCheckRatePerSecond))
+	defer throttle.Stop()
+	if err := s.checkShortLink(shortLink, throttle); err != nil {
+		return nil, err
+	}
+	return s.GetShortLinkHealthInWorkspace(id, workspaceID)
+}
+
+// GetShortLinkHealthInWorkspace 获取短网址最近一次检查结果
+func (s *LinkHealthService) GetShortLinkHealthInWorkspace(id, workspaceID uint64) (*dto.LinkHealthResponse, error) {
+	shortLink, err := s.findShortLink(id, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	checks, err := s.linkHealthDao.ListByShortLinkID(id, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	targets := make([]dto.LinkHealthTargetResponse, 0, len(checks))
+	for _, check := range checks {
+		chain := []string{}
+		if check.RedirectChain != "" {
+			_ = json.Unmarshal([]byte(check.RedirectChain), &chain)
+		}
+		targets = append(targets, dto.LinkHealthTargetResponse{
+			TargetType:          check.TargetType,
+			TargetID:            check.TargetID,
+			TargetURL:           check.TargetURL,
+			Healthy:             check.Healthy,
+			StatusCode:          check.StatusCode,
+			Error:               check.Error,
+			RedirectChain:       chain,
+			LatencyMs:           check.LatencyMs,
+			TLSExpiresAt:        check.TLSExpiresAt,
+			ConsecutiveFailures: check.ConsecutiveFailures,
+			CheckedAt:           check.CheckedAt,
+		})
+	}
+	return &dto.LinkHealthResponse{
+		ShortLinkID:     shortLink.ID,
+		HealthStatus:    shortLink.HealthStatus,
+		HealthCheckedAt: shortLink.HealthCheckedAt,
+		Targets:         targets,
+	}, nil
+}
+
+func (s *LinkHealthService) findShortLink(id, workspaceID uint64) (*model.ShortLink, error) {
+	shortLink, err := s.shortLinkDao.FindByIDInWorkspace(id, workspaceID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("短网址不存在")
+		}
+		return nil, err
+	}
+	return shortLink, nil
+}
+
+// checkShortLink 检查短网址的全部目标地址并保存结果。
+// 某个目标连续失败达到阈值时短网址标记为 broken 并记录 destination_failing 事件，恢复后记录 destination_recovered 事件。
+func (s *LinkHealthService) checkShortLink(shortLink *model.ShortLink, throttle *healthCheckThrottle) error {
+	targets, err := s.collectTargets(shortLink)
+	if err != nil {
+		return err
+	}
+	previousChecks, err := s.linkHealthDao.ListByShortLinkID(shortLink.ID, shortLink.WorkspaceID)
+	if err != nil {
+		return err
+	}
+	previous := make(map[string]model.LinkHealthCheck, len(previousChecks))
+	for _, check := range previousChecks {
+		previous[linkHealthTargetKey(check.TargetType, check.TargetID)] = check
+	}
+	threshold := s.helper.GetConfig().GetInt("health_check.failure_threshold", defaultHealthCheckFailureThreshold)
+	if threshold <= 0 {
+		threshold = 1
+	}
+
+	hostInterval := time.Duration(s.helper.GetConfig().GetInt("health_check.host_interval_ms", defaultHealthCheckHostIntervalMs)) * time.Millisecond
+
+	now := time.Now()
+	probes := make(map[string]linkHealthProbe, len(targets))
+	checks := make([]model.LinkHealthCheck, 0, len(targets))
+	var failing, recovered []model.LinkHealthCheck
+	anyBroken, allHealthy := false, true
+	for _, target := range targets {
+		// 同一地址在多个目标中出现时只请求一次
+		probe, ok := probes[target.url]
+		if !ok {
+			throttle.Wait()
+			healthCheckHosts.Wait(linkHealthHost(target.url), hostInterval)
+			probe = s.probe(target.url)
+			probes[target.url] = probe
+		}
+		chain, _ := json.Marshal(probe.chain)
+		check := model.LinkHealthCheck{
+			WorkspaceID:   shortLink.WorkspaceID,
+			ShortLinkID:   shortLink.ID,
+			TargetType:    target.targetType,
+			TargetID:      target.targetID,
+			TargetURL:     domain_validate.TruncateString(target.url, 2000),
+			Healthy:       probe.healthy,
+			StatusCode:    probe.statusCode,
+			Error:         domain_validate.TruncateString(probe.err, 500),
+			RedirectChain: string(chain),
+			LatencyMs:     probe.latencyMs,
+			TLSExpiresAt:  probe.tlsExpiresAt,
+			CheckedAt:     now,
+		}
+		last := previous[linkHealthTargetKey(target.targetType, target.targetID)]
+		if probe.healthy {
+			if last.ConsecutiveFailures >= threshold {
+				recovered = append(recovered, check)
+			}
+		} else {
+			allHealthy = false
+			check.ConsecutiveFailures = last.ConsecutiveFailures + 1
+			if check.ConsecutiveFailures >= threshold {
+				anyBroken = true
+				if last.ConsecutiveFailures < threshold {
+					failing = append(failing, check)
+				}
+			}
+		}
+		checks = append(checks, check)
+	}
+
+	status := shortLink.HealthStatus
+	switch {
+	case anyBroken:
+		status = model.LinkHealthStatusBroken
+	case allHealthy:
+		status = model.LinkHealthStatusHealthy
+	}
+	if err := s.linkHealthDao.Replace(shortLink.ID, checks, status, now); err != nil {
+		return err
+	}
+	shortLink.HealthStatus = status
+	shortLink.HealthCheckedAt = &now
+
+	for _, check := range failing {
+		reason := fmt.Sprintf("目标地址不可用 [%s] %s: %s", check.TargetType, check.TargetURL, describeLinkHealthFailure(check))
+		s.helper.GetLogger().Warn(fmt.Sprintf("[link-health] 短网址 %d %s", shortLink.ID, reason))
+		s.linkSecurityService.recordEvent(shortLink, model.SecurityEventDestinationFailing, reason, "", "", "")
+	}
+	for _, check := range recovered {
+		reason := fmt.Sprintf("目标地址已恢复 [%s] %s", check.TargetType, check.TargetURL)
+		s.linkSecurityService.recordEvent(shortLink, model.SecurityEventDestinationRecovered, reason, "", "", "")
+	}
+	return nil
+}
+
+// collectTargets 汇总原始地址、兜底地址、已启用路由和运行中 A/B 测试版本的目标地址
+func (s *LinkHealthService) collectTargets(shortLink *model.ShortLink) ([]linkHealthTarget, error) {
+	targets := []linkHealthTarget{{targetType: model.LinkHealthTargetOriginal, url: shortLink.OriginalURL}}
+	if shortLink.FallbackURL != "" {
+		targets = append(targets, linkHealthTarget{targetType: model.LinkHealthTargetFallback, url: shortLink.FallbackURL})
+	}
+	routes, err := s.linkHealthDao.ListActiveRoutes(shortLink.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, route := range routes {
+		targets = append(targets, linkHealthTarget{targetType: model.LinkHealthTargetRoute, targetID: route.ID, url: route.TargetURL})
+	}
+	variants, err := s.linkHealthDao.ListRunningVariants(shortLink.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, variant := range variants {
+		targets = append(targets, linkHealthTarget{targetType: model.LinkHealthTargetABVariant, targetID: variant.ID, url: variant.TargetURL})
+	}
+	return targets, nil
+}
+
+// probe 以 GET 请求目标地址并跟随跳转，最终状态码小于 400 视为可用
+func (s *LinkHealthService) probe(rawURL string) linkHealthProbe {
+	result := linkHealthProbe{chain: []string{rawURL}}
+	client := *s.client
+	guard := s.client.CheckRedirect
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if len(via) >= healthCheckMaxRedirects {
+			return errors.New("重定向次数过多")
+		}
+		// 每一跳都经过客户端自带的内网地址校验
+		if guard != nil {
+			if err := guard(req, via); err != nil {
+				return err
+			}
+		}
+		result.chain = append(result.chain, req.URL.String())
+		return nil
+	}
+
+	req, err := http.NewRequest(http.MethodGet, rawURL, nil)
+	if err != nil {
+		result.err = "目标地址无效: " + err.Error()
+		return result
+	}
+	req.Header.Set("User-Agent", healthCheckUserAgent)
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	result.latencyMs = time.Since(start).Milliseconds()
+	if err != nil {
+		result.err = err.Error()
+		return result
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, healthCheckMaxBodyBytes))
+
+	result.statusCode = resp.StatusCode
+	result.healthy = resp.StatusCode < http.StatusBadRequest
+	if !result.healthy {
+		result.err = resp.Status
+	}
+	if resp.TLS != nil && len(resp.TLS.PeerCertificates) > 0 {
+		expiresAt := resp.TLS.PeerCertificates[0].NotAfter
+		result.tlsExpiresAt = &expiresAt
+	}
+	return result
+}
+
+func linkHealthTargetKey(targetType string, targetID uint64) string {
+	return fmt.Sprintf("%s:%d", targetType, targetID)
+}
+
+func describeLinkHealthFailure(check model.LinkHealthCheck) string {
+	if check.Error != "" {
+		return check.Error
+	}
+	return fmt.Sprintf("HTTP %d", check.StatusCode)
+}
+
+// linkHealthHost 目标地址的主机名，用于按主机限速
+func linkHealthHost(rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(parsed.Hostname())
+}
@@ -0,0 +1,191 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/dto"
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/model"
+)
+
+func TestLinkHealthCheckFlagsBrokenDestinations(t *testing.T) {
+	var fallbackUp atomic.Bool
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ok", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.HandleFunc("/moved", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/ok", http.StatusFound)
+	})
+	mux.HandleFunc("/fallback", func(w http.ResponseWriter, r *http.Request) {
+		if fallbackUp.Load() {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		http.NotFound(w, r)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	tlsServer := httptest.NewTLSServer(mux)
+	defer tlsServer.Close()
+
+	helper := newShortLinkRegressionHelper(t)
+	helper.settings["health_check.enabled"] = true
+	helper.settings["health_check.interval_minutes"] = 0
+	helper.settings["health_check.rate_per_second"] = 0
+	helper.settings["health_check.host_interval_ms"] = 0
+	helper.settings["health_check.manual_cooldown_seconds"] = 0
+	db := helper.GetDatabase()
+	seedBatchShortLinkDomain(t, db)
+
+	created, err := NewShortLinkService(helper, context.Background()).CreateShortLinkInWorkspace(&dto.CreateShortLinkRequest{
+		OriginalURL: server.URL + "/moved",
+		FallbackURL: server.URL + "/fallback",
+		Domain:      "batch.dwz.do",
+		CustomCode:  "health",
+	}, "", 1, 7)
+	if err != nil {
+		t.Fatalf("create short link: %v", err)
+	}
+	route := model.LinkRoute{WorkspaceID: 1, ShortLinkID: created.ID, Name: "tls", TargetURL: tlsServer.URL + "/ok", IsActive: true}
+	if err := db.Create(&route).Error; err != nil {
+		t.Fatalf("seed route: %v", err)
+	}
+	abTest := model.ABTest{WorkspaceID: 1, ShortLinkID: created.ID, Name: "ab", Status: "running", IsActive: true}
+	if err := db.Create(&abTest).Error; err != nil {
+		t.Fatalf("seed ab test: %v", err)
+	}
+	if err := db.Create(&model.ABTestVariant{ABTestID: abTest.ID, Name: "B", TargetURL: server.URL + "/ok", IsActive: true}).Error; err != nil {
+		t.Fatalf("seed variant: %v", err)
+	}
+
+	healthSvc := NewLinkHealthService(helper)
+	healthSvc.client = tlsServer.Client()
+
+	// 默认连续失败 2 次才标记为失效
+	if checked, err := healthSvc.CheckDueShortLinks(); err != nil || checked != 1 {
+		t.Fatalf("first round: checked=%d err=%v", checked, err)
+	}
+	health, err := healthSvc.GetShortLinkHealthInWorkspace(created.ID, 1)
+	if err != nil {
+		t.Fatalf("get health: %v", err)
+	}
+	if health.HealthStatus != "" || len(health.Targets) != 4 {
+		t.Fatalf("unexpected first round health: %+v", health)
+	}
+	targets := make(map[string]dto.LinkHealthTargetResponse)
+	for _, target := range health.Targets {
+		targets[target.TargetType] = target
+	}
+	if original := targets[model.LinkHealthTargetOriginal]; !original.Healthy || original.StatusCode != http.StatusOK || len(original.RedirectChain) != 2 || !strings.HasSuffix(original.RedirectChain[1], "/ok") {
+		t.Fatalf("unexpected original target: %+v", original)
+	}
+	if fallback := targets[model.LinkHealthTargetFallback]; fallback.Healthy || fallback.StatusCode != http.StatusNotFound || fallback.ConsecutiveFailures != 1 {
+		t.Fatalf("unexpected fallback target: %+v", fallback)
+	}
+	if routeTarget := targets[model.LinkHealthTargetRoute]; !routeTarget.Healthy || routeTarget.TargetID != route.ID || routeTarget.TLSExpiresAt == nil {
+		t.Fatalf("route target must record tls expiry: %+v", routeTarget)
+	}
+	if variant := targets[model.LinkHealthTargetABVariant]; !variant.Healthy {
+		t.Fatalf("unexpected variant target: %+v", variant)
+	}
+
+	if _, err := healthSvc.CheckDueShortLinks(); err != nil {
+		t.Fatalf("second round: %v", err)
+	}
+	list, err := NewShortLinkService(helper, context.Background()).GetShortLinkListInWorkspace(&dto.ShortLinkListRequest{Page: 1, PageSize: 10, HealthStatus: model.LinkHealthStatusBroken}, 1)
+	if err != nil {
+		t.Fatalf("list broken links: %v", err)
+	}
+	if len(list.List) != 1 || list.List[0].ID != created.ID || list.List[0].HealthStatus != model.LinkHealthStatusBroken {
+		t.Fatalf("broken link must be flagged in list: %+v", list.List)
+	}
+	var failing []model.LinkSecurityEvent
+	db.Where("short_link_id = ? AND event_type = ?", created.ID, model.SecurityEventDestinationFailing).Find(&failing)
+	if len(failing) != 1 || !strings.Contains(failing[0].Reason, "/fallback") {
+		t.Fatalf("expected one failing event, got %+v", failing)
+	}
+
+	// 继续失败不重复记录事件，恢复后记录恢复事件
+	if _, err := healthSvc.CheckDueShortLinks(); err != nil {
+		t.Fatalf("third round: %v", err)
+	}
+	fallbackUp.Store(true)
+	recovered, err := healthSvc.CheckShortLinkInWorkspace(created.ID, 1)
+	if err != nil {
+		t.Fatalf("manual check: %v", err)
+	}
+	if recovered.HealthStatus != model.LinkHealthStatusHealthy {
+		t.Fatalf("expected healthy after recovery, got %+v", recovered)
+	}
+	helper.settings["health_check.manual_cooldown_seconds"] = 60
+	if _, err := healthSvc.CheckShortLinkInWorkspace(created.ID, 1); !errors.Is(err, ErrLinkHealthCheckTooFrequent) {
+		t.Fatalf("manual checks must be rate limited, got %v", err)
+	}
+	// 多个实例同时领取同一短网址时只有一个成功
+	due := time.Now().Add(time.Minute)
+	first, err := healthSvc.linkHealthDao.ClaimShortLink(created.ID, due, time.Now().Add(2*time.Minute))
+	if err != nil || !first {
+		t.Fatalf("first claim: %v err=%v", first, err)
+	}
+	if second, err := healthSvc.linkHealthDao.ClaimShortLink(created.ID, due, time.Now().Add(2*time.Minute)); err != nil || second {
+		t.Fatalf("second claim must fail: %v err=%v", second, err)
+	}
+	var counts []struct {
+		EventType string
+		Count     int64
+	}
+	db.Model(&model.LinkSecurityEvent{}).Select("event_type, COUNT(*) AS count").Where("short_link_id = ?", created.ID).Group("event_type").Find(&counts)
+	got, _ := json.Marshal(counts)
+	if len(counts) != 2 || counts[0].Count != 1 || counts[1].Count != 1 {
+		t.Fatalf("unexpected events: %s", got)
+	}
+}
+
+func TestHealthCheckHostThrottleSpacesRequestsToTheSameHost(t *testing.T) {
+	throttle := &healthCheckHostThrottle{next: make(map[string]time.Time)}
+	started := time.Now()
+	throttle.Wait("a.example.com", 50*time.Millisecond)
+	throttle.Wait("b.example.com", 50*time.Millisecond)
+	if elapsed := time.Since(started); elapsed >= 40*time.Millisecond {
+		t.Fatalf("different hosts must not wait, took %s", elapsed)
+	}
+	throttle.Wait("a.example.com", 50*time.Millisecond)
+	if elapsed := time.Since(started); elapsed < 50*time.Millisecond {
+		t.Fatalf("same host must wait for the interval, took %s", elapsed)
+	}
+	if host := linkHealthHost("https://A.Example.com:8443/path"); host != "a.example.com" {
+		t.Fatalf("unexpected host %q", host)
+	}
+}
+
+func TestLinkHealthProbeBlocksInternalAddresses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+	public := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "http://169.254.169.254/latest/meta-data/", http.StatusFound)
+	}))
+	defer public.Close()
+
+	healthSvc := NewLinkHealthService(newShortLinkRegressionHelper(t))
+	if probe := healthSvc.probe(server.URL); probe.healthy || probe.statusCode != 0 || !strings.Contains(probe.err, errMetadataBlockedAddress.Error()) {
+		t.Fatalf("internal destination must be blocked: %+v", probe)
+	}
+
+	// 测试服务器本身在回环地址，仅保留跳转校验以验证每一跳都被检查
+	guarded := newMetadataHTTPClient(time.Second)
+	healthSvc.client = public.Client()
+	healthSvc.client.CheckRedirect = guarded.CheckRedirect
+	if probe := healthSvc.probe(public.URL); probe.healthy || !strings.Contains(probe.err, errMetadataBlockedAddress.Error()) || len(probe.chain) != 1 {
+		t.Fatalf("redirect to internal address must be blocked: %+v", probe)
+	}
+}
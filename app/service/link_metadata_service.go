@@ -0,0 +1,425 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+	"unicode/utf8"
+
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/dao"
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/dto"
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/model"
+	"cnb.cool/mliev/dwz/dwz-server/v2/pkg/interfaces"
+	"golang.org/x/net/html"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultMetadataFetchTimeoutSeconds = 5
+	defaultMetadataFetchMaxBytes       = 512 << 10
+	defaultMetadataFetchConcurrency    = 4
+	metadataFetchQueueSize             = 1000
+	metadataFetchMaxRedirects          = 5
+	metadataFetchUserAgent             = "Mozilla/5.0 (compatible; DWZMetadataFetcher/1.0)"
+)
+
+var errMetadataBlockedAddress = errors.New("禁止访问内网或保留地址")
+
+// metadataHTTPClients 按超时时间复用的 HTTP 客户端，共享连接池
+var metadataHTTPClients sync.Map
+
+// metadataFetchQueue 后台抓取队列，由固定数量的协程消费，队列满时跳过
+var metadataFetchQueue struct {
+	once sync.Once
+	jobs chan *metadataFetchJob
+}
+
+type metadataFetchJob struct {
+	service   *LinkMetadataService
+	shortLink model.ShortLink
+}
+
+// metadataBlockedNetworks 标准库 IsPrivate 等方法未覆盖的保留网段
+var metadataBlockedNetworks = func() []*net.IPNet {
+	cidrs := []string{
+		"0.0.0.0/8",     // 本网络
+		"100.64.0.0/10", // 运营商级 NAT
+		"192.0.0.0/24",  // IETF 协议分配
+		"198.18.0.0/15", // 基准测试
+		"240.0.0.0/4",   // 保留
+		"64:ff9b::/96",  // NAT64，可映射到内网 IPv4
+	}
+	networks := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, network, _ := net.ParseCIDR(cidr)
+		networks = append(networks, network)
+	}
+	return networks
+}()
+
+// LinkMetadataService 抓取目标页面的标题、描述、OpenGraph 图片和图标，作为建议元数据保存
+type LinkMetadataService struct {
+	helper               interfaces.HelperInterface
+	shortLinkDao         *dao.ShortLinkDao
+	shortLinkMetadataDao *dao.ShortLinkMetadataDao
+	client               *http.Client
+}
+
+func NewLinkMetadataService(helper interfaces.HelperInterface) *LinkMetadataService {
+	timeout := helper.GetConfig().GetInt("metadata_fetch.timeout_seconds", defaultMetadataFetchTimeoutSeconds)
+	if timeout <= 0 {
+		timeout = defaultMetadataFetchTimeoutSeconds
+	}
+	return &LinkMetadataService{
+		helper:               helper,
+		shortLinkDao:         dao.NewShortLinkDao(helper),
+		shortLinkMetadataDao: dao.NewShortLinkMetadataDao(helper),
+		client:               sharedMetadataHTTPClient(time.Duration(timeout) * time.Second),
+	}
+}
+
+// sharedMetadataHTTPClient 返回进程内共享的公网 HTTP 客户端，相同超时时间复用同一个
+func sharedMetadataHTTPClient(timeout time.Duration) *http.Client {
+	if client, ok := metadataHTTPClients.Load(timeout); ok {
+		return client.(*http.Client)
+	}
+	client, _ := metadataHTTPClients.LoadOrStore(timeout, newMetadataHTTPClient(timeout))
+	return client.(*http.Client)
+}
+
+// newMetadataHTTPClient 只允许连接公网地址。校验放在建立连接时，针对解析后的实际 IP，
+// 跳转和 DNS 重绑定都无法绕过；每一跳跳转地址为 IP 时也先行校验。
+// 元数据抓取、目标地址健康检查和告警 Webhook 共用。
+func newMetadataHTTPClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || isMetadataBlockedIP(ip) {
+				return errMetadataBlockedAddress
+			}
+			return nil
+		},
+	}
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			Proxy:                 nil,
+			DialContext:           dialer.DialContext,
+			TLSHandshakeTimeout:   timeout,
+			ResponseHeaderTimeout: timeout,
+			MaxIdleConns:          10,
+			IdleConnTimeout:       30 * time.Second,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= metadataFetchMaxRedirects {
+				return errors.New("重定向次数过多")
+			}
+			if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+				return errors.New("仅支持 http 和 https 地址")
+			}
+			if ip := net.ParseIP(req.URL.Hostname()); ip != nil && isMetadataBlockedIP(ip) {
+				return errMetadataBlockedAddress
+			}
+			return nil
+		},
+	}
+}
+
+func isMetadataBlockedIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsMulticast() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() {
+		return true
+	}
+	for _, network := range metadataBlockedNetworks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// FetchInBackground 启用自动抓取时，在后台抓取短网址目标页面的元数据。
+// 同时抓取数不超过 metadata_fetch.concurrency，排队过多时跳过，可稍后手动重新抓取。
+func (s *LinkMetadataService) FetchInBackground(shortLink *model.ShortLink) {
+	if shortLink == nil || !s.helper.GetConfig().GetBool("metadata_fetch.enabled", false) {
+		return
+	}
+	metadataFetchQueue.once.Do(func() {
+		concurrency := s.helper.GetConfig().GetInt("metadata_fetch.concurrency", defaultMetadataFetchConcurrency)
+		if concurrency <= 0 {
+			concurrency = defaultMetadataFetchConcurrency
+		}
+		metadataFetchQueue.jobs = make(chan *metadataFetchJob, metadataFetchQueueSize)
+		for i := 0; i < concurrency; i++ {
+			go runMetadataFetchWorker(metadataFetchQueue.jobs)
+		}
+	})
+	select {
+	case metadataFetchQueue.jobs <- &metadataFetchJob{service: s, shortLink: *shortLink}:
+	default:
+		s.helper.GetLogger().Warn(fmt.Sprintf("[link-metadata] 抓取队列已满，跳过短网址 %d", shortLink.ID))
+	}
+}
+
+func runMetadataFetchWorker(jobs <-chan *metadataFetchJob) {
+	for job := range jobs {
+		if _, err := job.service.fetchAndStore(&job.shortLink); err != nil {
+			job.service.helper.GetLogger().Warn(fmt.Sprintf("[link-metadata] 抓取短网址 %d 元数据失败: %s", job.shortLink.ID, err.Error()))
+		}
+	}
+}
+
+// FetchShortLinkMetadataInWorkspace 立即重新抓取短网址目标页面的元数据
+func (s *LinkMetadataService) FetchShortLinkMetadataInWorkspace(id, workspaceID uint64) (*dto.ShortLinkMetadataResponse, error) {
+	shortLink, err := s.findShortLink(id, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	metadata, err := s.fetchAndStore(shortLink)
+	if err != nil {
+		return nil, err
+	}
+	return metadataToResponse(metadata), nil
+}
+
+// GetShortLinkMetadataInWorkspace 获取短网址的建议元数据
+func (s *LinkMetadataService) GetShortLinkMetadataInWorkspace(id, workspaceID uint64) (*dto.ShortLinkMetadataResponse, error) {
+	if _, err := s.findShortLink(id, workspaceID); err != nil {
+		return nil, err
+	}
+	metadata, err := s.shortLinkMetadataDao.FindByShortLinkID(id, workspaceID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("尚未抓取元数据")
+		}
+		return nil, err
+	}
+	return metadataToResponse(metadata), nil
+}
+
+func (s *LinkMetadataService) findShortLink(id, workspaceID uint64) (*model.ShortLink, error) {
+	shortLink, err := s.shortLinkDao.FindByIDInWorkspace(id, workspaceID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("短网址不存在")
+		}
+		return nil, err
+	}
+	return shortLink, nil
+}
+
+// fetchAndStore 抓取并保存元数据。抓取失败也会保存一条 failed 记录，便于排查。
+func (s *LinkMetadataService) fetchAndStore(shortLink *model.ShortLink) (*model.ShortLinkMetadata, error) {
+	metadata := &model.ShortLinkMetadata{
+		WorkspaceID: shortLink.WorkspaceID,
+		ShortLinkID: shortLink.ID,
+		SourceURL:   truncateUTF8(shortLink.OriginalURL, 2000),
+		Status:      model.ShortLinkMetadataStatusFetched,
+		FetchedAt:   time.Now(),
+	}
+	page, err := s.fetchPage(shortLink.OriginalURL)
+	if err != nil {
+		metadata.Status = model.ShortLinkMetadataStatusFailed
+		metadata.Error = truncateUTF8(err.Error(), 500)
+	} else {
+		metadata.Title = truncateUTF8(page.title, 255)
+		metadata.Description = truncateUTF8(page.description, 500)
+		metadata.ImageURL = truncateUTF8(page.imageURL, 2000)
+		metadata.FaviconURL = truncateUTF8(page.faviconURL, 2000)
+	}
+	if saveErr := s.shortLinkMetadataDao.Save(metadata); saveErr != nil {
+		return nil, saveErr
+	}
+	if err == nil && s.helper.GetConfig().GetBool("metadata_fetch.fill_empty", true) {
+		if err := s.shortLinkMetadataDao.FillEmptyTitleAndDescription(shortLink.ID, metadata.Title, metadata.Description); err != nil {
+			return nil, err
+		}
+	}
+	return metadata, nil
+}
+
+// pageMetadata 页面中解析出的元数据
+type pageMetadata struct {
+	title       string
+	description string
+	imageURL    string
+	faviconURL  string
+}
+
+// fetchPage 下载目标页面并解析元数据，只读取 metadata_fetch.max_bytes 以内的 HTML
+func (s *LinkMetadataService) fetchPage(rawURL string) (*pageMetadata, error) {
+	target, err := url.Parse(rawURL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return nil, errors.New("仅支持 http 和 https 地址")
+	}
+	req, err := http.NewRequest(http.MethodGet, target.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", metadataFetchUserAgent)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		if errors.Is(err, errMetadataBlockedAddress) {
+			return nil, errMetadataBlockedAddress
+		}
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= http.StatusBadRequest {
+		return nil, fmt.Errorf("目标页面返回 %s", resp.Status)
+	}
+	if mediaType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type")); err == nil &&
+		mediaType != "text/html" && mediaType != "application/xhtml+xml" {
+		return nil, fmt.Errorf("目标地址不是 HTML 页面: %s", mediaType)
+	}
+
+	maxBytes := s.helper.GetConfig().GetInt("metadata_fetch.max_bytes", defaultMetadataFetchMaxBytes)
+	if maxBytes <= 0 {
+		maxBytes = defaultMetadataFetchMaxBytes
+	}
+	page := parsePageMetadata(io.LimitReader(resp.Body, int64(maxBytes)), resp.Request.URL)
+	return page, nil
+}
+
+// parsePageMetadata 解析 <head> 中的标题、描述、OpenGraph 图片和图标，相对地址按 base 补全
+func parsePageMetadata(body io.Reader, base *url.URL) *pageMetadata {
+	page := &pageMetadata{}
+	var ogTitle, ogDescription string
+	tokenizer := html.NewTokenizer(body)
+	inTitle := false
+	for {
+		tokenType := tokenizer.Next()
+		if tokenType == html.ErrorToken {
+			break
+		}
+		token := tokenizer.Token()
+		switch tokenType {
+		case html.StartTagToken, html.SelfClosingTagToken:
+			switch token.Data {
+			case "title":
+				inTitle = page.title == ""
+			case "meta":
+				key := strings.ToLower(htmlAttr(token, "property"))
+				if key == "" {
+					key = strings.ToLower(htmlAttr(token, "name"))
+				}
+				content := strings.TrimSpace(htmlAttr(token, "content"))
+				switch key {
+				case "description":
+					if page.description == "" {
+						page.description = content
+					}
+				case "og:title":
+					ogTitle = content
+				case "og:description":
+					ogDescription = content
+				case "og:image", "og:image:url":
+					if page.imageURL == "" {
+						page.imageURL = resolveMetadataURL(base, content)
+					}
+				}
+			case "link":
+				rel := strings.Fields(strings.ToLower(htmlAttr(token, "rel")))
+				for _, value := range rel {
+					if value == "icon" && page.faviconURL == "" {
+						page.faviconURL = resolveMetadataURL(base, htmlAttr(token, "href"))
+					}
+				}
+			case "body":
+				// 元数据都在 <head> 中，进入正文后不再解析
+				return finishPageMetadata(page, ogTitle, ogDescription, base)
+			}
+		case html.TextToken:
+			if inTitle {
+				page.title = strings.Join(strings.Fields(token.Data), " ")
+				inTitle = false
+			}
+		case html.EndTagToken:
+			if token.Data == "title" {
+				inTitle = false
+			}
+		}
+	}
+	return finishPageMetadata(page, ogTitle, ogDescription, base)
+}
+
+func finishPageMetadata(page *pageMetadata, ogTitle, ogDescription string, base *url.URL) *pageMetadata {
+	if page.title == "" {
+		page.title = ogTitle
+	}
+	if page.description == "" {
+		page.description = ogDescription
+	}
+	if page.faviconURL == "" && base != nil {
+		page.faviconURL = resolveMetadataURL(base, "/favicon.ico")
+	}
+	return page
+}
+
+func htmlAttr(token html.Token, name string) string {
+	for _, attr := range token.Attr {
+		if strings.EqualFold(attr.Key, name) {
+			return attr.Val
+		}
+	}
+	return ""
+}
+
+// resolveMetadataURL 补全相对地址，只保留 http 和 https 地址
+func resolveMetadataURL(base *url.URL, raw string) string {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return ""
+	}
+	ref, err := url.Parse(raw)
+	if err != nil {
+		return ""
+	}
+	if base != nil {
+		ref = base.ResolveReference(ref)
+	}
+	if ref.Scheme != "http" && ref.Scheme != "https" {
+		return ""
+	}
+	return ref.String()
+}
+
+// truncateUTF8 按字节截断，不会截断多字节字符
+func truncateUTF8(value string, maxBytes int) string {
+	if len(value) <= maxBytes {
+		return value
+	}
+	value = value[:maxBytes]
+	for len(value) > 0 && !utf8.ValidString(value) {
+		value = value[:len(value)-1]
+	}
+	return value
+}
+
+func metadataToResponse(metadata *model.ShortLinkMetadata) *dto.ShortLinkMetadataResponse {
+	return &dto.ShortLinkMetadataResponse{
+		ShortLinkID: metadata.ShortLinkID,
+		SourceURL:   metadata.SourceURL,
+		Title:       metadata.Title,
+		Description: metadata.Description,
+		ImageURL:    metadata.ImageURL,
+		FaviconURL:  metadata.FaviconURL,
+		Status:      metadata.Status,
+		Error:       metadata.Error,
+		FetchedAt:   metadata.FetchedAt,
+	}
+}
@@ -0,0 +1,141 @@
+package service
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/dto"
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/model"
+)
+
+func TestLinkMetadataFetchFillsOnlyEmptyFields(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/page", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write([]byte(`<!doctype html><html><head>
+<title> 示例页面 </title>
+<meta name="description" content="页面描述">
+<meta property="og:image" content="/cover.png">
+<link rel="shortcut icon" href="/static/icon.png">
+</head><body><title>正文中的标题</title></body></html>`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	helper := newShortLinkRegressionHelper(t)
+	db := helper.GetDatabase()
+	seedBatchShortLinkDomain(t, db)
+	shortLinkSvc := NewShortLinkService(helper, context.Background())
+
+	empty, err := shortLinkSvc.CreateShortLinkInWorkspace(&dto.CreateShortLinkRequest{
+		OriginalURL: server.URL + "/page",
+		Domain:      "batch.dwz.do",
+		CustomCode:  "meta-empty",
+	}, "", 1, 7)
+	if err != nil {
+		t.Fatalf("create short link: %v", err)
+	}
+	titled, err := shortLinkSvc.CreateShortLinkInWorkspace(&dto.CreateShortLinkRequest{
+		OriginalURL: server.URL + "/page",
+		Domain:      "batch.dwz.do",
+		CustomCode:  "meta-titled",
+		Title:       "用户标题",
+	}, "", 1, 7)
+	if err != nil {
+		t.Fatalf("create titled short link: %v", err)
+	}
+
+	metadataSvc := NewLinkMetadataService(helper)
+	metadataSvc.client = server.Client()
+	if _, err := metadataSvc.GetShortLinkMetadataInWorkspace(empty.ID, 1); err == nil || err.Error() != "尚未抓取元数据" {
+		t.Fatalf("expected not fetched error, got %v", err)
+	}
+
+	metadata, err := metadataSvc.FetchShortLinkMetadataInWorkspace(empty.ID, 1)
+	if err != nil {
+		t.Fatalf("fetch metadata: %v", err)
+	}
+	if metadata.Status != model.ShortLinkMetadataStatusFetched || metadata.Title != "示例页面" || metadata.Description != "页面描述" ||
+		metadata.ImageURL != server.URL+"/cover.png" || metadata.FaviconURL != server.URL+"/static/icon.png" {
+		t.Fatalf("unexpected metadata: %+v", metadata)
+	}
+	if _, err := metadataSvc.FetchShortLinkMetadataInWorkspace(titled.ID, 1); err != nil {
+		t.Fatalf("fetch titled metadata: %v", err)
+	}
+
+	var filled, kept model.ShortLink
+	db.First(&filled, empty.ID)
+	db.First(&kept, titled.ID)
+	if filled.Title != "示例页面" || filled.Description != "页面描述" {
+		t.Fatalf("empty fields must be filled: %+v", filled)
+	}
+	if kept.Title != "用户标题" || kept.Description != "页面描述" {
+		t.Fatalf("user title must not be overwritten: %+v", kept)
+	}
+	var count int64
+	db.Model(&model.ShortLinkMetadata{}).Where("short_link_id = ?", empty.ID).Count(&count)
+	if _, err := metadataSvc.FetchShortLinkMetadataInWorkspace(empty.ID, 1); err != nil || count != 1 {
+		t.Fatalf("refetch must keep one metadata row: count=%d err=%v", count, err)
+	}
+}
+
+func TestLinkMetadataFetchBlocksPrivateAddresses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = w.Write([]byte("<title>internal</title>"))
+	}))
+	defer server.Close()
+
+	helper := newShortLinkRegressionHelper(t)
+	db := helper.GetDatabase()
+	seedBatchShortLinkDomain(t, db)
+	created, err := NewShortLinkService(helper, context.Background()).CreateShortLinkInWorkspace(&dto.CreateShortLinkRequest{
+		OriginalURL: server.URL + "/admin",
+		Domain:      "batch.dwz.do",
+		CustomCode:  "meta-internal",
+	}, "", 1, 7)
+	if err != nil {
+		t.Fatalf("create short link: %v", err)
+	}
+
+	metadata, err := NewLinkMetadataService(helper).FetchShortLinkMetadataInWorkspace(created.ID, 1)
+	if err != nil {
+		t.Fatalf("fetch metadata: %v", err)
+	}
+	if metadata.Status != model.ShortLinkMetadataStatusFailed || metadata.Error != errMetadataBlockedAddress.Error() || metadata.Title != "" {
+		t.Fatalf("loopback target must be blocked: %+v", metadata)
+	}
+
+	for _, raw := range []string{"10.1.2.3", "169.254.169.254", "100.64.0.1", "::1", "fd00::1", "::ffff:127.0.0.1"} {
+		if !isMetadataBlockedIP(net.ParseIP(raw)) {
+			t.Fatalf("%s must be blocked", raw)
+		}
+	}
+	if isMetadataBlockedIP(net.ParseIP("93.184.216.34")) {
+		t.Fatalf("public address must be allowed")
+	}
+}
+
+func TestLinkMetadataBackgroundFetchUsesSharedClientAndWorkers(t *testing.T) {
+	helper := newShortLinkRegressionHelper(t)
+	helper.settings["metadata_fetch.enabled"] = true
+	db := helper.GetDatabase()
+	seedBatchShortLinkDomain(t, db)
+
+	if NewLinkMetadataService(helper).client != NewLinkMetadataService(helper).client {
+		t.Fatal("services must share the HTTP client")
+	}
+	// 创建短网址时由后台协程抓取，内网地址记为失败
+	created, err := NewShortLinkService(helper, context.Background()).CreateShortLinkInWorkspace(&dto.CreateShortLinkRequest{
+		OriginalURL: "http://127.0.0.1:1/page",
+		Domain:      "batch.dwz.do",
+		CustomCode:  "meta-background",
+	}, "", 1, 7)
+	if err != nil {
+		t.Fatalf("create short link: %v", err)
+	}
+	waitForModelCount(t, db, &model.ShortLinkMetadata{}, "short_link_id = ?", created.ID, 1)
+}
@@ -1,7 +1,9 @@
 package service
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"net/url"
 	"sort"
 	"strings"
@@ -63,6 +65,27 @@ func (s *LinkRouteService) CreateRoute(shortLinkID, workspaceID, userID uint64,
 	if err := s.validateRouteRequest(workspaceID, req); err != nil {
 		return nil, err
 	}
+	var route *model.LinkRoute
+	if err := s.helper.GetDatabase().Transaction(func(tx *gorm.DB) error {
+		var err error
+		route, err = s.createRoute(tx, shortLinkID, workspaceID, userID, req)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	if err := NewShortLinkService(s.helper, context.Background()).RequireReviewAfterChange(shortLinkID, workspaceID, userID); err != nil {
+		return nil, err
+	}
+	created, err := s.findRoute(route.ID, shortLinkID, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	resp := linkRouteToResponse(created)
+	return &resp, nil
+}
+
+// createRoute 在事务中写入已校验的路由规则及其条件
+func (s *LinkRouteService) createRoute(tx *gorm.DB, shortLinkID, workspaceID, userID uint64, req *dto.LinkRouteRequest) (*model.LinkRoute, error) {
 	isActive := true
 	if req.IsActive != nil {
 		isActive = *req.IsActive
@@ -81,20 +104,10 @@ func (s *LinkRouteService) CreateRoute(shortLinkID, workspaceID, userID uint64,
 	if route.Priority == 0 {
 		route.Priority = 100
 	}
-	if err := s.helper.GetDatabase().Transaction(func(tx *gorm.DB) error {
-		if err := tx.Create(route).Error; err != nil {
-			return err
-		}
-		return s.replaceConditionGroups(tx, route.ID, req.ConditionGroups)
-	}); err != nil {
-		return nil, err
-	}
-	created, err := s.findRoute(route.ID, shortLinkID, workspaceID)
-	if err != nil {
+	if err := tx.Create(route).Error; err != nil {
 		return nil, err
 	}
-	resp := linkRouteToResponse(created)
-	return &resp, nil
+	return route, s.replaceConditionGroups(tx, route.ID, req.ConditionGroups)
 }
 
 func (s *LinkRouteService) UpdateRoute(routeID, shortLinkID, workspaceID, userID uint64, req *dto.LinkRouteRequest) (*dto.LinkRouteResponse, error) {
@@ -126,6 +139,9 @@ func (s *LinkRouteService) UpdateRoute(routeID, shortLinkID, workspaceID, userID
 	}); err != nil {
 		return nil, err
 	}
+	if err := NewShortLinkService(s.helper, context.Background()).RequireReviewAfterChange(shortLinkID, workspaceID, userID); err != nil {
+		return nil, err
+	}
 	updated, err := s.findRoute(routeID, shortLinkID, workspaceID)
 	if err != nil {
 		return nil, err
@@ -140,20 +156,61 @@ func (s *LinkRouteService) DeleteRoute(routeID, shortLinkID, workspaceID uint64)
 		return err
 	}
 	return s.helper.GetDatabase().Transaction(func(tx *gorm.DB) error {
-		var groups []model.LinkRouteConditionGroup
-		if err := tx.Where("route_id = ?", route.ID).Find(&groups).Error; err != nil {
+		return deleteRoute(tx, route)
+	})
+}
+
+// ReplaceRoutes 用请求中的路由整体替换短网址现有的路由规则
+func (s *LinkRouteService) ReplaceRoutes(shortLinkID, workspaceID, userID uint64, reqs []dto.LinkRouteRequest) error {
+	if _, err := s.ensureShortLink(shortLinkID, workspaceID); err != nil {
+		return err
+	}
+	for i := range reqs {
+		if err := s.validateRouteRequest(workspaceID, &reqs[i]); err != nil {
 			return err
 		}
-		for _, group := range groups {
-			if err := tx.Where("group_id = ?", group.ID).Delete(&model.LinkRouteCondition{}).Error; err != nil {
-				return err
-			}
+	}
+	if err := s.helper.GetDatabase().Transaction(func(tx *gorm.DB) error {
+		return s.replaceRoutes(tx, shortLinkID, workspaceID, userID, reqs)
+	}); err != nil {
+		return err
+	}
+	return NewShortLinkService(s.helper, context.Background()).RequireReviewAfterChange(shortLinkID, workspaceID, userID)
+}
+
+// replaceRoutes 在事务内删除短网址现有路由并写入新路由，调用方需事先校验路由
+func (s *LinkRouteService) replaceRoutes(tx *gorm.DB, shortLinkID, workspaceID, userID uint64, reqs []dto.LinkRouteRequest) error {
+	var routes []model.LinkRoute
+	if err := tx.Where("short_link_id = ? AND workspace_id = ?", shortLinkID, workspaceID).Find(&routes).Error; err != nil {
+		return err
+	}
+	for i := range routes {
+		if err := deleteRoute(tx, &routes[i]); err != nil {
+			return err
 		}
-		if err := tx.Where("route_id = ?", route.ID).Delete(&model.LinkRouteConditionGroup{}).Error; err != nil {
+	}
+	for i := range reqs {
+		if _, err := s.createRoute(tx, shortLinkID, workspaceID, userID, &reqs[i]); err != nil {
 			return err
 		}
-		return tx.Delete(route).Error
-	})
+	}
+	return nil
+}
+
+func deleteRoute(tx *gorm.DB, route *model.LinkRoute) error {
+	var groups []model.LinkRouteConditionGroup
+	if err := tx.Where("route_id = ?", route.ID).Find(&groups).Error; err != nil {
+		return err
+	}
+	for _, group := range groups {
+		if err := tx.Where("group_id = ?", group.ID).Delete(&model.LinkRouteCondition{}).Error; err != nil {
+			return err
+		}
+	}
+	if err := tx.Where("route_id = ?", route.ID).Delete(&model.LinkRouteConditionGroup{}).Error; err != nil {
+		return err
+	}
+	return tx.Delete(route).Error
 }
 
 func (s *LinkRouteService) ReorderRoutes(shortLinkID, workspaceID, userID uint64, req *dto.LinkRouteReorderRequest) error {
@@ -221,6 +278,9 @@ func (s *LinkRouteService) Resolve(shortLink *model.ShortLink, input RouteResolv
 	}
 
 	context := s.buildMatchContext(input)
+	if routesUseCustomFields(routes) {
+		context.CustomFields = NewCustomFieldService(s.helper).ValuesMap(shortLink.ID)
+	}
 	for i := range routes {
 		route := &routes[i]
 		if s.matchRoute(route, context) {
@@ -276,6 +336,91 @@ func (s *LinkRouteService) RoutingSummary(shortLinkID, workspaceID uint64, fallb
 	return active > 0, strings.Join(parts, " / ")
 }
 
+// checkRoutesForClone 用目标工作区的 URL 安全规则检查源短网址的全部路由目标；
+// 复制到其他工作区时，自定义字段属于源工作区，引用自定义字段的路由无法复制，列出这些路由并拒绝
+func (s *LinkRouteService) checkRoutesForClone(shortLinkID, workspaceID, targetWorkspaceID uint64) error {
+	routes, err := s.loadRoutes(shortLinkID, workspaceID, false)
+	if err != nil {
+		return err
+	}
+	for _, route := range routes {
+		if result := s.securityService.ScanURL(targetWorkspaceID, route.TargetURL); !result.Safe {
+			return errors.New("路由目标 URL 命中安全规则: " + result.Reason)
+		}
+	}
+	if targetWorkspaceID == workspaceID {
+		return nil
+	}
+	var names []string
+	for _, route := range routes {
+		if routesUseCustomFields([]model.LinkRoute{route}) {
+			names = append(names, route.Name)
+		}
+	}
+	if len(names) > 0 {
+		return fmt.Errorf("路由 %s 使用了自定义字段条件，无法复制到其他工作区，请修改路由或不复制路由", strings.Join(names, "、"))
+	}
+	return nil
+}
+
+// cloneRoutes 在事务中把源短网址的路由及条件组复制到目标短网址
+func (s *LinkRouteService) cloneRoutes(tx *gorm.DB, sourceID uint64, target *model.ShortLink, userID uint64) error {
+	var routes []model.LinkRoute
+	if err := tx.
+		Preload("ConditionGroups", func(db *gorm.DB) *gorm.DB { return db.Order("position ASC, id ASC") }).
+		Preload("ConditionGroups.Conditions", func(db *gorm.DB) *gorm.DB { return db.Order("position ASC, id ASC") }).
+		Where("short_link_id = ? AND deleted_at IS NULL", sourceID).
+		Order("priority ASC, id ASC").
+		Find(&routes).Error; err != nil {
+		return err
+	}
+	for _, route := range routes {
+		cloned := model.LinkRoute{
+			WorkspaceID: target.WorkspaceID,
+			ShortLinkID: target.ID,
+			Name:        route.Name,
+			Description: route.Description,
+			Priority:    route.Priority,
+			TargetURL:   route.TargetURL,
+			IsActive:    route.IsActive,
+			CreatedBy:   actorPtr(userID),
+			UpdatedBy:   actorPtr(userID),
+		}
+		if err := tx.Create(&cloned).Error; err != nil {
+			return err
+		}
+		if !route.IsActive {
+			// is_active 默认 true，零值不会被 Create 写入
+			if err := tx.Model(&cloned).Update("is_active", false).Error; err != nil {
+				return err
+			}
+		}
+		for _, group := range route.ConditionGroups {
+			clonedGroup := model.LinkRouteConditionGroup{RouteID: cloned.ID, Position: group.Position}
+			if err := tx.Create(&clonedGroup).Error; err != nil {
+				return err
+			}
+			conditions := make([]model.LinkRouteCondition, 0, len(group.Conditions))
+			for _, condition := range group.Conditions {
+				conditions = append(conditions, model.LinkRouteCondition{
+					GroupID:        clonedGroup.ID,
+					ConditionType:  condition.ConditionType,
+					Operator:       condition.Operator,
+					ConditionKey:   condition.ConditionKey,
+					ConditionValue: condition.ConditionValue,
+					Position:       condition.Position,
+				})
+			}
+			if len(conditions) > 0 {
+				if err := tx.Create(&conditions).Error; err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
 func (s *LinkRouteService) validateRouteRequest(workspaceID uint64, req *dto.LinkRouteRequest) error {
 	if strings.TrimSpace(req.Name) == "" {
 		return errors.New("路由名称不能为空")
@@ -383,15 +528,16 @@ func (s *LinkRouteService) loadRoutes(shortLinkID, workspaceID uint64, activeOnl
 }
 
 type routeMatchContext struct {
-	Country     string
-	Province    string
-	City        string
-	DeviceType  string
-	Browser     string
-	OS          string
-	Language    string
-	Referer     string
-	QueryValues url.Values
+	Country      string
+	Province     string
+	City         string
+	DeviceType   string
+	Browser      string
+	OS           string
+	Language     string
+	Referer      string
+	QueryValues  url.Values
+	CustomFields map[string]string
 }
 
 func (s *LinkRouteService) buildMatchContext(input RouteResolveInput) routeMatchContext {
@@ -458,6 +604,14 @@ func validateRouteCondition(condition dto.LinkRouteConditionRequest) error {
 			operator != model.RouteOperatorContains && operator != model.RouteOperatorPrefix && operator != model.RouteOperatorSuffix {
 			return errors.New("Query 参数条件操作符不支持")
 		}
+	case model.RouteConditionCustomField:
+		if strings.TrimSpace(condition.ConditionKey) == "" {
+			return errors.New("自定义字段条件必须填写字段标识")
+		}
+		if operator != model.RouteOperatorExists && operator != model.RouteOperatorEq && operator != model.RouteOperatorIn &&
+			operator != model.RouteOperatorContains && operator != model.RouteOperatorPrefix && operator != model.RouteOperatorSuffix {
+			return errors.New("自定义字段条件操作符不支持")
+		}
 	default:
 		return errors.New("不支持的路由条件类型")
 	}
@@ -500,12 +654,32 @@ func matchCondition(condition model.LinkRouteCondition, context routeMatchContex
 			}
 		}
 		return false
+	case model.RouteConditionCustomField:
+		value, exists := context.CustomFields[condition.ConditionKey]
+		if condition.Operator == model.RouteOperatorExists {
+			return exists
+		}
+		actual = value
 	default:
 		return false
 	}
 	return matchScalar(actual, condition.Operator, condition.ConditionValue)
 }
 
+// routesUseCustomFields 仅在路由引用自定义字段时才读取短网址的取值
+func routesUseCustomFields(routes []model.LinkRoute) bool {
+	for _, route := range routes {
+		for _, group := range route.ConditionGroups {
+			for _, condition := range group.Conditions {
+				if condition.ConditionType == model.RouteConditionCustomField {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
 func matchScalar(actual, operator, expected string) bool {
 	actual = strings.ToLower(strings.TrimSpace(actual))
 	expected = strings.ToLower(strings.TrimSpace(expected))
@@ -35,6 +35,7 @@ type RedirectDecision struct {
 	ShortLink     *model.ShortLink
 	Security      *model.LinkSecuritySetting
 	Route         *model.LinkRoute
+	ClickID       string // 本次点击的点击ID，预览请求为空
 	Reason        string
 	PasswordURL   string
 	ReportEnabled bool
@@ -90,11 +91,77 @@ func (s *LinkSecurityService) UpsertSecurity(shortLinkID, workspaceID, userID ui
 		}
 	}
 
+	if err := applySecurityRequest(setting, req, userID); err != nil {
+		return nil, err
+	}
+	var rules []model.LinkSecurityIPRule
+	if req.IPRules != nil {
+		if rules, err = buildIPRules(workspaceID, shortLinkID, req.IPRules); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.helper.GetDatabase().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(setting).Error; err != nil {
+			return err
+		}
+		if req.IPRules == nil {
+			return nil
+		}
+		return replaceIPRules(tx, workspaceID, shortLinkID, rules)
+	}); err != nil {
+		return nil, err
+	}
+	if err := NewShortLinkService(s.helper, context.Background()).RequireReviewAfterChange(shortLinkID, workspaceID, userID); err != nil {
+		return nil, err
+	}
+	return s.settingToResponse(setting), nil
+}
+
+// prepareCreateSecurity 校验创建短网址时附带的安全设置，返回待写入的设置和 IP 规则
+func (s *LinkSecurityService) prepareCreateSecurity(workspaceID, userID uint64, req *dto.LinkSecurityRequest) (*model.LinkSecuritySetting, []model.LinkSecurityIPRule, error) {
+	if req == nil {
+		return nil, nil, nil
+	}
+	setting := &model.LinkSecuritySetting{
+		WorkspaceID: workspaceID,
+		IPPolicy:    model.LinkIPPolicyOff,
+		BotPolicy:   model.LinkBotPolicyRecordOnly,
+		CreatedBy:   actorPtr(userID),
+		UpdatedBy:   actorPtr(userID),
+	}
+	if err := applySecurityRequest(setting, req, userID); err != nil {
+		return nil, nil, err
+	}
+	rules, err := buildIPRules(workspaceID, 0, req.IPRules)
+	if err != nil {
+		return nil, nil, err
+	}
+	return setting, rules, nil
+}
+
+// createSecurity 在创建短网址的事务中写入安全设置和 IP 规则
+func (s *LinkSecurityService) createSecurity(tx *gorm.DB, shortLinkID uint64, setting *model.LinkSecuritySetting, rules []model.LinkSecurityIPRule) error {
+	if setting == nil {
+		return nil
+	}
+	setting.ShortLinkID = shortLinkID
+	if err := tx.Create(setting).Error; err != nil {
+		return err
+	}
+	for i := range rules {
+		rules[i].ShortLinkID = shortLinkID
+	}
+	return replaceIPRules(tx, setting.WorkspaceID, shortLinkID, rules)
+}
+
+// applySecurityRequest 校验请求并写入设置字段，设置访问密码时生成哈希
+func applySecurityRequest(setting *model.LinkSecuritySetting, req *dto.LinkSecurityRequest, userID uint64) error {
 	if req.AccessWindowStart != nil && req.AccessWindowEnd != nil && req.AccessWindowEnd.Before(*req.AccessWindowStart) {
-		return nil, errors.New("有效访问结束时间不能早于开始时间")
+		return errors.New("有效访问结束时间不能早于开始时间")
 	}
 	if req.MaxClicks != nil && *req.MaxClicks < 1 {
-		return nil, errors.New("最大访问次数必须大于 0")
+		return errors.New("最大访问次数必须大于 0")
 	}
 
 	if req.Password != nil {
@@ -105,7 +172,7 @@ func (s *LinkSecurityService) UpsertSecurity(shortLinkID, workspaceID, userID ui
 		} else {
 			hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 			if err != nil {
-				return nil, err
+				return err
 			}
 			setting.PasswordHash = string(hash)
 			setting.PasswordEnabled = true
@@ -115,7 +182,7 @@ func (s *LinkSecurityService) UpsertSecurity(shortLinkID, workspaceID, userID ui
 		setting.PasswordEnabled = *req.PasswordEnabled
 	}
 	if setting.PasswordEnabled && setting.PasswordHash == "" {
-		return nil, errors.New("启用访问密码时必须设置密码")
+		return errors.New("启用访问密码时必须设置密码")
 	}
 
 	setting.AccessWindowStart = req.AccessWindowStart
@@ -139,29 +206,55 @@ func (s *LinkSecurityService) UpsertSecurity(shortLinkID, workspaceID, userID ui
 	if userID > 0 {
 		setting.UpdatedBy = &userID
 	}
+	return nil
+}
 
-	db := s.helper.GetDatabase()
-	if err := db.Save(setting).Error; err != nil {
-		return nil, err
+// cloneSecurity 在事务中复制安全设置与 IP 规则；访问密码和 URL 拦截状态不复制
+func (s *LinkSecurityService) cloneSecurity(tx *gorm.DB, sourceID uint64, target *model.ShortLink, userID uint64) error {
+	var setting model.LinkSecuritySetting
+	err := tx.Where("short_link_id = ? AND deleted_at IS NULL", sourceID).First(&setting).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
 	}
-
-	if req.IPRules != nil {
-		if err := s.replaceIPRules(workspaceID, shortLinkID, req.IPRules); err != nil {
-			return nil, err
+	if err == nil {
+		cloned := model.LinkSecuritySetting{
+			WorkspaceID:       target.WorkspaceID,
+			ShortLinkID:       target.ID,
+			AccessWindowStart: setting.AccessWindowStart,
+			AccessWindowEnd:   setting.AccessWindowEnd,
+			MaxClicks:         setting.MaxClicks,
+			IPPolicy:          setting.IPPolicy,
+			BotPolicy:         setting.BotPolicy,
+			ReportEnabled:     setting.ReportEnabled,
+			CreatedBy:         actorPtr(userID),
+			UpdatedBy:         actorPtr(userID),
+		}
+		if err := tx.Create(&cloned).Error; err != nil {
+			return err
 		}
 	}
-	return s.settingToResponse(setting), nil
-}
 
-func (s *LinkSecurityService) ApplyCreateSecurity(shortLink *model.ShortLink, userID uint64, req *dto.LinkSecurityRequest) error {
-	if req == nil {
+	var rules []model.LinkSecurityIPRule
+	if err := tx.Where("short_link_id = ? AND deleted_at IS NULL", sourceID).Order("id ASC").Find(&rules).Error; err != nil {
+		return err
+	}
+	if len(rules) == 0 {
 		return nil
 	}
-	_, err := s.UpsertSecurity(shortLink.ID, shortLink.WorkspaceID, userID, req)
-	return err
+	entities := make([]model.LinkSecurityIPRule, 0, len(rules))
+	for _, rule := range rules {
+		entities = append(entities, model.LinkSecurityIPRule{
+			WorkspaceID: target.WorkspaceID,
+			ShortLinkID: target.ID,
+			CIDR:        rule.CIDR,
+			Description: rule.Description,
+		})
+	}
+	return tx.Create(&entities).Error
 }
 
-func (s *LinkSecurityService) EvaluateRedirect(shortLink *model.ShortLink, domain, shortCode, clientIP, userAgent, referer, accessToken string) (*model.LinkSecuritySetting, error) {
+// EvaluateRedirect 检查访问是否符合安全策略，bot 为本次访问的机器人识别结果
+func (s *LinkSecurityService) EvaluateRedirect(shortLink *model.ShortLink, domain, shortCode, clientIP, userAgent, referer, accessToken string, bot BotVerdict) (*model.LinkSecuritySetting, error) {
 	setting, err := s.findSetting(shortLink.ID, shortLink.WorkspaceID)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -187,8 +280,8 @@ func (s *LinkSecurityService) EvaluateRedirect(shortLink *model.ShortLink, domai
 		s.recordEvent(shortLink, model.SecurityEventAccessDenied, reason, clientIP, userAgent, referer)
 		return setting, ErrSecurityAccessDenied
 	}
-	if setting.BotPolicy == model.LinkBotPolicyBlockKnownBots && parseTrafficMetadata(userAgent).IsBot {
-		s.recordEvent(shortLink, model.SecurityEventBotBlocked, "已识别 Bot 访问", clientIP, userAgent, referer)
+	if setting.BotPolicy == model.LinkBotPolicyBlockKnownBots && bot.IsBot {
+		s.recordEvent(shortLink, model.SecurityEventBotBlocked, fmt.Sprintf("已识别 Bot 访问（评分 %d：%s）", bot.Score, bot.Reason), clientIP, userAgent, referer)
 		return setting, ErrSecurityAccessDenied
 	}
 	if setting.URLBlocked {
@@ -230,8 +323,12 @@ func (s *LinkSecurityService) ScanURL(workspaceID uint64, rawURL string) urlSafe
 	if err != nil {
 		return urlSafetyResult{Safe: false, Reason: "URL 格式无效"}
 	}
-	host := strings.ToLower(parsed.Hostname())
-	rawLower := strings.ToLower(rawURL)
+	host := strings.TrimSuffix(strings.ToLower(parsed.Hostname()), ".")
+	// 同时匹配原文与规范化后的 URL，避免通过大小写、默认端口或参数顺序绕过规则
+	candidates := []string{strings.ToLower(rawURL)}
+	if normalized, err := NormalizeTargetURL(rawURL); err == nil {
+		candidates = append(candidates, strings.ToLower(normalized))
+	}
 
 	var rules []model.SecurityURLRule
 	if err := s.helper.GetDatabase().
@@ -245,12 +342,12 @@ func (s *LinkSecurityService) ScanURL(workspaceID uint64, rawURL string) urlSafe
 	}
 
 	for _, rule := range rules {
-		if rule.Action == model.SecurityRuleActionAllow && s.matchURLRule(rule, host, rawLower) {
+		if rule.Action == model.SecurityRuleActionAllow && s.matchURLRule(rule, host, candidates) {
 			return urlSafetyResult{Safe: true}
 		}
 	}
 	for _, rule := range rules {
-		if rule.Action == model.SecurityRuleActionBlock && s.matchURLRule(rule, host, rawLower) {
+		if rule.Action == model.SecurityRuleActionBlock && s.matchURLRule(rule, host, candidates) {
 			return urlSafetyResult{Safe: false, Reason: fmt.Sprintf("命中%s安全规则: %s", securityRuleTypeLabel(rule.RuleType), rule.Pattern)}
 		}
 	}
@@ -452,10 +549,12 @@ func (s *LinkSecurityService) CreateAbuseReport(req *dto.AbuseReportCreateReques
 	if err != nil || !setting.ReportEnabled {
 		return nil, errors.New("该短网址未开启举报入口")
 	}
+	// 举报人IP与点击一样按工作区设置匿名化，重复举报按匿名化后的IP判断
+	reporterIP := domain_validate.TruncateString(NewDataRetentionService(s.helper).StoredIP(shortLink.WorkspaceID, clientIP), 45)
 	var duplicates int64
 	if err := s.helper.GetDatabase().Model(&model.AbuseReport{}).
 		Where("short_link_id = ? AND reporter_ip = ? AND status IN ? AND created_at >= ?",
-			shortLink.ID, clientIP, []string{model.AbuseReportStatusPending, model.AbuseReportStatusReviewing}, time.Now().Add(-time.Hour)).
+			shortLink.ID, reporterIP, []string{model.AbuseReportStatusPending, model.AbuseReportStatusReviewing}, time.Now().Add(-time.Hour)).
 		Count(&duplicates).Error; err != nil {
 		return nil, err
 	}
@@ -468,7 +567,7 @@ func (s *LinkSecurityService) CreateAbuseReport(req *dto.AbuseReportCreateReques
 		ReportType:    req.ReportType,
 		Description:   req.Description,
 		ReporterEmail: req.ReporterEmail,
-		ReporterIP:    domain_validate.TruncateString(clientIP, 45),
+		ReporterIP:    reporterIP,
 		UserAgent:     domain_validate.TruncateString(userAgent, 1024),
 		Status:        model.AbuseReportStatusPending,
 	}
@@ -565,20 +664,13 @@ func (s *LinkSecurityService) ensureShortLinkInWorkspace(shortLinkID, workspaceI
 	return nil
 }
 
-func (s *LinkSecurityService) replaceIPRules(workspaceID, shortLinkID uint64, rules []dto.LinkSecurityIPRuleRequest) error {
-	db := s.helper.GetDatabase()
-	if err := db.Where("short_link_id = ? AND workspace_id = ?", shortLinkID, workspaceID).
-		Delete(&model.LinkSecurityIPRule{}).Error; err != nil {
-		return err
-	}
-	if len(rules) == 0 {
-		return nil
-	}
+// buildIPRules 校验并规范化 IP 规则
+func buildIPRules(workspaceID, shortLinkID uint64, rules []dto.LinkSecurityIPRuleRequest) ([]model.LinkSecurityIPRule, error) {
 	entities := make([]model.LinkSecurityIPRule, 0, len(rules))
 	for _, item := range rules {
 		normalized, err := normalizeCIDR(item.CIDR)
 		if err != nil {
-			return fmt.Errorf("无效的 IP 规则 %s", item.CIDR)
+			return nil, fmt.Errorf("无效的 IP 规则 %s", item.CIDR)
 		}
 		entities = append(entities, model.LinkSecurityIPRule{
 			WorkspaceID: workspaceID,
@@ -587,7 +679,18 @@ func (s *LinkSecurityService) replaceIPRules(workspaceID, shortLinkID uint64, ru
 			Description: item.Description,
 		})
 	}
-	return db.Create(&entities).Error
+	return entities, nil
+}
+
+func replaceIPRules(tx *gorm.DB, workspaceID, shortLinkID uint64, rules []model.LinkSecurityIPRule) error {
+	if err := tx.Where("short_link_id = ? AND workspace_id = ?", shortLinkID, workspaceID).
+		Delete(&model.LinkSecurityIPRule{}).Error; err != nil {
+		return err
+	}
+	if len(rules) == 0 {
+		return nil
+	}
+	return tx.Create(&rules).Error
 }
 
 func (s *LinkSecurityService) evaluateIPPolicy(setting *model.LinkSecuritySetting, clientIP string) (bool, string) {
@@ -637,7 +740,7 @@ func (s *LinkSecurityService) recordEvent(shortLink *model.ShortLink, eventType,
 		ShortLinkID: shortLink.ID,
 		EventType:   eventType,
 		Reason:      domain_validate.TruncateString(reason, 500),
-		ClientIP:    domain_validate.TruncateString(clientIP, 45),
+		ClientIP:    domain_validate.TruncateString(NewDataRetentionService(s.helper).StoredIP(shortLink.WorkspaceID, clientIP), 45),
 		UserAgent:   domain_validate.TruncateString(userAgent, 1024),
 		Referer:     domain_validate.TruncateString(referer, 2048),
 	}
@@ -739,13 +842,18 @@ func (s *LinkSecurityService) cookieSecret() string {
 	return secret
 }
 
-func (s *LinkSecurityService) matchURLRule(rule model.SecurityURLRule, host, rawLower string) bool {
+func (s *LinkSecurityService) matchURLRule(rule model.SecurityURLRule, host string, urlCandidates []string) bool {
 	pattern := normalizeSecurityRulePattern(rule.RuleType, rule.Pattern)
 	switch rule.RuleType {
 	case model.SecurityRuleTypeDomain:
 		return host == pattern || strings.HasSuffix(host, "."+pattern)
 	case model.SecurityRuleTypeKeyword:
-		return strings.Contains(rawLower, strings.ToLower(pattern))
+		for _, candidate := range urlCandidates {
+			if strings.Contains(candidate, pattern) {
+				return true
+			}
+		}
+		return false
 	default:
 		return false
 	}
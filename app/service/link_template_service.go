@@ -0,0 +1,268 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/dao"
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/dto"
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/model"
+	"cnb.cool/mliev/dwz/dwz-server/v2/pkg/interfaces"
+	"gorm.io/gorm"
+)
+
+type LinkTemplateService struct {
+	helper           interfaces.HelperInterface
+	linkTemplateDao  *dao.LinkTemplateDao
+	domainDao        *dao.DomainDao
+	campaignDao      *dao.CampaignDao
+	tagDao           *dao.TagDao
+	folderDao        *dao.FolderDao
+	linkRouteService *LinkRouteService
+}
+
+func NewLinkTemplateService(helper interfaces.HelperInterface) *LinkTemplateService {
+	return &LinkTemplateService{
+		helper:           helper,
+		linkTemplateDao:  dao.NewLinkTemplateDao(helper),
+		domainDao:        dao.NewDomainDao(helper),
+		campaignDao:      dao.NewCampaignDao(helper),
+		tagDao:           dao.NewTagDao(helper),
+		folderDao:        dao.NewFolderDao(helper),
+		linkRouteService: NewLinkRouteService(helper),
+	}
+}
+
+func (s *LinkTemplateService) Create(workspaceID, userID uint64, req *dto.LinkTemplateRequest) (*dto.LinkTemplateResponse, error) {
+	template := &model.LinkTemplate{
+		WorkspaceID: workspaceID,
+		CreatedBy:   actorPtr(userID),
+	}
+	if err := s.save(template, userID, req); err != nil {
+		return nil, err
+	}
+	return templateToResponse(template), nil
+}
+
+func (s *LinkTemplateService) Update(id, workspaceID, userID uint64, req *dto.LinkTemplateRequest) (*dto.LinkTemplateResponse, error) {
+	template, err := s.findTemplate(id, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.save(template, userID, req); err != nil {
+		return nil, err
+	}
+	return templateToResponse(template), nil
+}
+
+func (s *LinkTemplateService) Get(id, workspaceID uint64) (*dto.LinkTemplateResponse, error) {
+	template, err := s.findTemplate(id, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	return templateToResponse(template), nil
+}
+
+func (s *LinkTemplateService) List(workspaceID uint64) (*dto.LinkTemplateListResponse, error) {
+	templates, err := s.linkTemplateDao.ListInWorkspace(workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	list := make([]dto.LinkTemplateResponse, 0, len(templates))
+	for _, template := range templates {
+		list = append(list, *templateToResponse(&template))
+	}
+	return &dto.LinkTemplateListResponse{List: list}, nil
+}
+
+func (s *LinkTemplateService) Delete(id, workspaceID uint64) error {
+	if _, err := s.findTemplate(id, workspaceID); err != nil {
+		return err
+	}
+	return s.linkTemplateDao.Delete(id, workspaceID)
+}
+
+// ApplyCreateTemplate 用请求指定的模板（未指定时为工作区默认模板）填充请求中未填写的字段。
+// template_id 为 0 表示不使用模板；套用后 template_id 置为 0，避免重复套用。
+func (s *LinkTemplateService) ApplyCreateTemplate(workspaceID uint64, req *dto.CreateShortLinkRequest) error {
+	var template *model.LinkTemplate
+	var err error
+	switch {
+	case req.TemplateID == nil:
+		template, err = s.linkTemplateDao.FindDefault(workspaceID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+	case *req.TemplateID == 0:
+		return nil
+	default:
+		template, err = s.findTemplate(*req.TemplateID, workspaceID)
+	}
+	if err != nil {
+		return err
+	}
+	var settings dto.LinkTemplateSettings
+	if template.Settings != "" {
+		if err := json.Unmarshal([]byte(template.Settings), &settings); err != nil {
+			return errors.New("链接模板设置无效")
+		}
+	}
+
+	if req.Domain == "" {
+		req.Domain = settings.Domain
+	}
+	if req.RedirectCode == 0 {
+		req.RedirectCode = settings.RedirectCode
+	}
+	if req.FallbackURL == "" {
+		req.FallbackURL = settings.FallbackURL
+	}
+	if req.ExpireAt == nil && settings.ExpireInDays > 0 {
+		expireAt := time.Now().AddDate(0, 0, settings.ExpireInDays)
+		req.ExpireAt = &expireAt
+	}
+	if req.CampaignID == nil {
+		req.CampaignID = settings.CampaignID
+	}
+	if req.FolderID == nil {
+		req.FolderID = settings.FolderID
+	}
+	if len(req.TagIDs) == 0 {
+		req.TagIDs = settings.TagIDs
+	}
+	if req.UTMSource == "" {
+		req.UTMSource = settings.UTMSource
+	}
+	if req.UTMMedium == "" {
+		req.UTMMedium = settings.UTMMedium
+	}
+	if req.UTMCampaign == "" {
+		req.UTMCampaign = settings.UTMCampaign
+	}
+	if req.UTMTerm == "" {
+		req.UTMTerm = settings.UTMTerm
+	}
+	if req.UTMContent == "" {
+		req.UTMContent = settings.UTMContent
+	}
+	if req.Security == nil {
+		req.Security = settings.Security
+	}
+	if req.Routes == nil {
+		req.Routes = settings.Routes
+	}
+	applied := uint64(0)
+	req.TemplateID = &applied
+	return nil
+}
+
+func (s *LinkTemplateService) save(template *model.LinkTemplate, userID uint64, req *dto.LinkTemplateRequest) error {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return errors.New("模板名称不能为空")
+	}
+	exists, err := s.linkTemplateDao.ExistsName(template.WorkspaceID, name, template.ID)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return errors.New("同名链接模板已存在")
+	}
+	settings := req.Settings
+	settings.Domain = strings.TrimSpace(settings.Domain)
+	if err := s.validateSettings(template.WorkspaceID, &settings); err != nil {
+		return err
+	}
+	raw, err := json.Marshal(settings)
+	if err != nil {
+		return err
+	}
+
+	template.Name = name
+	template.Description = req.Description
+	template.IsDefault = req.IsDefault
+	template.Settings = string(raw)
+	template.UpdatedBy = actorPtr(userID)
+	return s.linkTemplateDao.Save(template)
+}
+
+// validateSettings 保存时校验模板引用的域名、活动、标签、文件夹和路由，避免创建时才发现模板不可用
+func (s *LinkTemplateService) validateSettings(workspaceID uint64, settings *dto.LinkTemplateSettings) error {
+	if settings.Domain != "" {
+		domainInfo, err := s.domainDao.FindByDomain(settings.Domain)
+		if err != nil || domainInfo.WorkspaceID != workspaceID {
+			return errors.New("模板域名不存在")
+		}
+	}
+	if settings.FallbackURL != "" {
+		if _, err := parseTargetURL(settings.FallbackURL); err != nil {
+			return errors.New("兜底地址 URL 格式无效")
+		}
+	}
+	if settings.CampaignID != nil && *settings.CampaignID > 0 {
+		if _, err := s.campaignDao.FindByID(*settings.CampaignID, workspaceID); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("活动不存在")
+			}
+			return err
+		}
+	}
+	if len(settings.TagIDs) > 0 {
+		tags, err := s.tagDao.FindMany(settings.TagIDs, workspaceID)
+		if err != nil {
+			return err
+		}
+		if len(tags) != len(settings.TagIDs) {
+			return errors.New("标签不存在")
+		}
+	}
+	if settings.FolderID != nil {
+		if _, err := s.folderDao.FindByID(*settings.FolderID, workspaceID); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("文件夹不存在")
+			}
+			return err
+		}
+	}
+	if settings.Security != nil && settings.Security.Password != nil && *settings.Security.Password != "" {
+		return errors.New("链接模板安全设置不支持访问密码")
+	}
+	for i := range settings.Routes {
+		if err := s.linkRouteService.validateRouteRequest(workspaceID, &settings.Routes[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *LinkTemplateService) findTemplate(id, workspaceID uint64) (*model.LinkTemplate, error) {
+	template, err := s.linkTemplateDao.FindByID(id, workspaceID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("链接模板不存在")
+		}
+		return nil, err
+	}
+	return template, nil
+}
+
+func templateToResponse(template *model.LinkTemplate) *dto.LinkTemplateResponse {
+	var settings dto.LinkTemplateSettings
+	if template.Settings != "" {
+		_ = json.Unmarshal([]byte(template.Settings), &settings)
+	}
+	return &dto.LinkTemplateResponse{
+		ID:          template.ID,
+		WorkspaceID: template.WorkspaceID,
+		Name:        template.Name,
+		Description: template.Description,
+		IsDefault:   template.IsDefault,
+		Settings:    settings,
+		CreatedBy:   template.CreatedBy,
+		UpdatedBy:   template.UpdatedBy,
+		CreatedAt:   template.CreatedAt,
+		UpdatedAt:   template.UpdatedAt,
+	}
+}
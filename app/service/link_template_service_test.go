@@ -0,0 +1,136 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/dto"
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/model"
+)
+
+func TestLinkTemplatePrefillsCreateRequest(t *testing.T) {
+	helper := newShortLinkRegressionHelper(t)
+	db := helper.GetDatabase()
+	seedBatchShortLinkDomain(t, db)
+	tag := model.Tag{WorkspaceID: 1, Name: "preset"}
+	if err := db.Create(&tag).Error; err != nil {
+		t.Fatalf("seed tag: %v", err)
+	}
+
+	templateSvc := NewLinkTemplateService(helper)
+	template, err := templateSvc.Create(1, 7, &dto.LinkTemplateRequest{
+		Name:      "活动推广",
+		IsDefault: true,
+		Settings: dto.LinkTemplateSettings{
+			Domain:       "batch.dwz.do",
+			RedirectCode: 301,
+			ExpireInDays: 30,
+			TagIDs:       []uint64{tag.ID},
+			UTMSource:    "newsletter",
+			Security:     &dto.LinkSecurityRequest{ReportEnabled: boolPtr(true)},
+			Routes: []dto.LinkRouteRequest{{
+				Name:      "mobile",
+				TargetURL: "https://m.example.com",
+				ConditionGroups: []dto.LinkRouteConditionGroupRequest{{
+					Conditions: []dto.LinkRouteConditionRequest{{ConditionType: model.RouteConditionDeviceType, Operator: model.RouteOperatorEq, ConditionValue: "mobile"}},
+				}},
+			}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("create template: %v", err)
+	}
+	if _, err := templateSvc.Create(1, 7, &dto.LinkTemplateRequest{Name: "活动推广"}); err == nil || !strings.Contains(err.Error(), "已存在") {
+		t.Fatalf("duplicate template name must be rejected, got %v", err)
+	}
+	if _, err := templateSvc.Create(1, 7, &dto.LinkTemplateRequest{Name: "bad", Settings: dto.LinkTemplateSettings{Domain: "unknown.dwz.do"}}); err == nil {
+		t.Fatal("template with unknown domain must be rejected")
+	}
+
+	shortLinkSvc := NewShortLinkService(helper, context.Background())
+	created, err := shortLinkSvc.CreateShortLinkInWorkspace(&dto.CreateShortLinkRequest{
+		OriginalURL:  "https://example.com/landing",
+		CustomCode:   "preset",
+		RedirectCode: 307,
+	}, "", 1, 7)
+	if err != nil {
+		t.Fatalf("create with default template: %v", err)
+	}
+	if created.Domain != "batch.dwz.do" || created.RedirectCode != 307 || !strings.Contains(created.OriginalURL, "utm_source=newsletter") {
+		t.Fatalf("default template must fill empty fields only: %+v", created)
+	}
+	if created.ExpireAt == nil || created.ExpireAt.Before(time.Now().AddDate(0, 0, 29)) {
+		t.Fatalf("expiry rule not applied: %+v", created.ExpireAt)
+	}
+	if len(created.Tags) != 1 || !created.ReportEnabled || !created.RoutingEnabled {
+		t.Fatalf("tags, security and routes must be applied: %+v", created)
+	}
+
+	noTemplate := uint64(0)
+	if _, err := shortLinkSvc.CreateShortLinkInWorkspace(&dto.CreateShortLinkRequest{
+		OriginalURL: "https://example.com/plain",
+		CustomCode:  "plain",
+		TemplateID:  &noTemplate,
+	}, "", 1, 7); err == nil || err.Error() != "域名不能为空" {
+		t.Fatalf("template_id=0 must skip default template, got %v", err)
+	}
+
+	// 新的默认模板会取消原默认模板
+	second, err := templateSvc.Create(1, 7, &dto.LinkTemplateRequest{
+		Name:      "短期活动",
+		IsDefault: true,
+		Settings:  dto.LinkTemplateSettings{Domain: "batch.dwz.do", UTMSource: "sms"},
+	})
+	if err != nil {
+		t.Fatalf("create second template: %v", err)
+	}
+	list, err := templateSvc.List(1)
+	if err != nil || len(list.List) != 2 || list.List[0].ID != second.ID || !list.List[0].IsDefault || list.List[1].IsDefault {
+		t.Fatalf("only one default template expected: %+v err=%v", list, err)
+	}
+
+	// 显式引用的模板优先于默认模板
+	referenced := dto.CreateShortLinkRequest{TemplateID: &template.ID}
+	if err := templateSvc.ApplyCreateTemplate(1, &referenced); err != nil {
+		t.Fatalf("apply referenced template: %v", err)
+	}
+	if referenced.RedirectCode != 301 || referenced.UTMSource != "newsletter" || len(referenced.Routes) != 1 || *referenced.TemplateID != 0 {
+		t.Fatalf("referenced template not applied: %+v", referenced)
+	}
+	// 批量创建时每条短网址使用预设的深拷贝
+	cloned := cloneCreateShortLinkRequest(&referenced)
+	cloned.TagIDs[0] = 0
+	cloned.Security.ReportEnabled = boolPtr(false)
+	cloned.Routes[0].ConditionGroups[0].Conditions[0].ConditionValue = "desktop"
+	if referenced.TagIDs[0] != tag.ID || !*referenced.Security.ReportEnabled || referenced.Routes[0].ConditionGroups[0].Conditions[0].ConditionValue != "mobile" {
+		t.Fatalf("clone must not share presets: %+v", referenced)
+	}
+	missing := uint64(999)
+	if _, err := shortLinkSvc.BatchCreateShortLinksInWorkspace(&dto.BatchCreateShortLinkRequest{URLs: []string{"https://example.com/c"}, TemplateID: &missing}, "", helper, 1, 7); err == nil || err.Error() != "链接模板不存在" {
+		t.Fatalf("unknown template must fail, got %v", err)
+	}
+
+	// 路由写入失败时短网址、标签和安全设置一并回滚
+	if err := db.Migrator().DropTable(&model.LinkRouteCondition{}); err != nil {
+		t.Fatalf("drop conditions: %v", err)
+	}
+	if _, err := shortLinkSvc.CreateShortLinkInWorkspace(&dto.CreateShortLinkRequest{
+		OriginalURL: "https://example.com/rollback",
+		CustomCode:  "rollback",
+		TemplateID:  &template.ID,
+	}, "", 1, 7); err == nil {
+		t.Fatal("route write failure must fail the create")
+	}
+	var leftovers int64
+	db.Model(&model.ShortLink{}).Where("short_code = ?", "rollback").Count(&leftovers)
+	if leftovers != 0 {
+		t.Fatal("short link must be rolled back")
+	}
+	var settings int64
+	db.Model(&model.LinkSecuritySetting{}).Count(&settings)
+	if settings != 1 {
+		t.Fatalf("security setting must be rolled back, %d left", settings)
+	}
+}
@@ -108,11 +108,6 @@ func (s *OperationLogService) GetLogListByWorkspace(workspaceID uint64, req *dto
 	}, nil
 }
 
-// CleanOldLogs 清理过期日志
-func (s *OperationLogService) CleanOldLogs(days int) error {
-	return s.logDAO.DeleteOldLogs(days)
-}
-
 // convertToLogInfo 转换为LogInfo
 func (s *OperationLogService) convertToLogInfo(log *model.OperationLog) dto.OperationLogInfo {
 	return dto.OperationLogInfo{
@@ -0,0 +1,123 @@
+package service
+
+import "cnb.cool/mliev/dwz/dwz-server/v2/app/model"
+
+// defaultReferrerRules 内置来源渠道规则，按顺序匹配，命中第一条即停止。
+// 投放参数和 utm_medium 先于来源主机名匹配；网页邮箱、即时通讯的主机名须排在同一公司的搜索主机名之前。
+// 来源为短网址域名或目标站点时归为 internal，没有命中任何规则时有来源为 referral、无来源为 direct。
+var defaultReferrerRules = []model.ReferrerRule{
+	// 广告点击ID
+	{MatchType: model.ReferrerMatchQueryParam, Pattern: "gclid", Channel: model.ReferrerChannelPaid},
+	{MatchType: model.ReferrerMatchQueryParam, Pattern: "gbraid", Channel: model.ReferrerChannelPaid},
+	{MatchType: model.ReferrerMatchQueryParam, Pattern: "wbraid", Channel: model.ReferrerChannelPaid},
+	{MatchType: model.ReferrerMatchQueryParam, Pattern: "dclid", Channel: model.ReferrerChannelPaid},
+	{MatchType: model.ReferrerMatchQueryParam, Pattern: "msclkid", Channel: model.ReferrerChannelPaid},
+	{MatchType: model.ReferrerMatchQueryParam, Pattern: "ttclid", Channel: model.ReferrerChannelPaid},
+	{MatchType: model.ReferrerMatchQueryParam, Pattern: "twclid", Channel: model.ReferrerChannelPaid},
+	{MatchType: model.ReferrerMatchQueryParam, Pattern: "li_fat_id", Channel: model.ReferrerChannelPaid},
+	{MatchType: model.ReferrerMatchQueryParam, Pattern: "yclid", Channel: model.ReferrerChannelPaid},
+	{MatchType: model.ReferrerMatchQueryParam, Pattern: "bd_vid", Channel: model.ReferrerChannelPaid},
+	{MatchType: model.ReferrerMatchQueryParam, Pattern: "qz_gdt", Channel: model.ReferrerChannelPaid},
+	{MatchType: model.ReferrerMatchQueryParam, Pattern: "gdt_vid", Channel: model.ReferrerChannelPaid},
+
+	// utm_medium
+	{MatchType: model.ReferrerMatchUTMMedium, Pattern: "cpc", Channel: model.ReferrerChannelPaid},
+	{MatchType: model.ReferrerMatchUTMMedium, Pattern: "ppc", Channel: model.ReferrerChannelPaid},
+	{MatchType: model.ReferrerMatchUTMMedium, Pattern: "cpm", Channel: model.ReferrerChannelPaid},
+	{MatchType: model.ReferrerMatchUTMMedium, Pattern: "cpv", Channel: model.ReferrerChannelPaid},
+	{MatchType: model.ReferrerMatchUTMMedium, Pattern: "paid", Channel: model.ReferrerChannelPaid},
+	{MatchType: model.ReferrerMatchUTMMedium, Pattern: "paidsearch", Channel: model.ReferrerChannelPaid},
+	{MatchType: model.ReferrerMatchUTMMedium, Pattern: "paid_search", Channel: model.ReferrerChannelPaid},
+	{MatchType: model.ReferrerMatchUTMMedium, Pattern: "paid_social", Channel: model.ReferrerChannelPaid},
+	{MatchType: model.ReferrerMatchUTMMedium, Pattern: "paidsocial", Channel: model.ReferrerChannelPaid},
+	{MatchType: model.ReferrerMatchUTMMedium, Pattern: "display", Channel: model.ReferrerChannelPaid},
+	{MatchType: model.ReferrerMatchUTMMedium, Pattern: "banner", Channel: model.ReferrerChannelPaid},
+	{MatchType: model.ReferrerMatchUTMMedium, Pattern: "ads", Channel: model.ReferrerChannelPaid},
+	{MatchType: model.ReferrerMatchUTMMedium, Pattern: "email", Channel: model.ReferrerChannelEmail},
+	{MatchType: model.ReferrerMatchUTMMedium, Pattern: "e-mail", Channel: model.ReferrerChannelEmail},
+	{MatchType: model.ReferrerMatchUTMMedium, Pattern: "newsletter", Channel: model.ReferrerChannelEmail},
+	{MatchType: model.ReferrerMatchUTMMedium, Pattern: "edm", Channel: model.ReferrerChannelEmail},
+	{MatchType: model.ReferrerMatchUTMMedium, Pattern: "sms", Channel: model.ReferrerChannelMessaging},
+	{MatchType: model.ReferrerMatchUTMMedium, Pattern: "im", Channel: model.ReferrerChannelMessaging},
+	{MatchType: model.ReferrerMatchUTMMedium, Pattern: "social", Channel: model.ReferrerChannelSocial},
+	{MatchType: model.ReferrerMatchUTMMedium, Pattern: "social-media", Channel: model.ReferrerChannelSocial},
+	{MatchType: model.ReferrerMatchUTMMedium, Pattern: "organic", Channel: model.ReferrerChannelSearch},
+
+	// 网页邮箱
+	{MatchType: model.ReferrerMatchHost, Pattern: "mail.google.com", Channel: model.ReferrerChannelEmail},
+	{MatchType: model.ReferrerMatchHost, Pattern: "outlook.live.com", Channel: model.ReferrerChannelEmail},
+	{MatchType: model.ReferrerMatchHost, Pattern: "outlook.office.com", Channel: model.ReferrerChannelEmail},
+	{MatchType: model.ReferrerMatchHost, Pattern: "outlook.office365.com", Channel: model.ReferrerChannelEmail},
+	{MatchType: model.ReferrerMatchHost, Pattern: "mail.yahoo.com", Channel: model.ReferrerChannelEmail},
+	{MatchType: model.ReferrerMatchHost, Pattern: "mail.qq.com", Channel: model.ReferrerChannelEmail},
+	{MatchType: model.ReferrerMatchHost, Pattern: "exmail.qq.com", Channel: model.ReferrerChannelEmail},
+	{MatchType: model.ReferrerMatchHost, Pattern: "mail.163.com", Channel: model.ReferrerChannelEmail},
+	{MatchType: model.ReferrerMatchHost, Pattern: "mail.126.com", Channel: model.ReferrerChannelEmail},
+	{MatchType: model.ReferrerMatchHost, Pattern: "mail.aliyun.com", Channel: model.ReferrerChannelEmail},
+	{MatchType: model.ReferrerMatchHost, Pattern: "mail.proton.me", Channel: model.ReferrerChannelEmail},
+
+	// 即时通讯
+	{MatchType: model.ReferrerMatchHost, Pattern: "weixin.qq.com", Channel: model.ReferrerChannelMessaging},
+	{MatchType: model.ReferrerMatchHost, Pattern: "wx.qq.com", Channel: model.ReferrerChannelMessaging},
+	{MatchType: model.ReferrerMatchHost, Pattern: "im.qq.com", Channel: model.ReferrerChannelMessaging},
+	{MatchType: model.ReferrerMatchHost, Pattern: "whatsapp.com", Channel: model.ReferrerChannelMessaging},
+	{MatchType: model.ReferrerMatchHost, Pattern: "wa.me", Channel: model.ReferrerChannelMessaging},
+	{MatchType: model.ReferrerMatchHost, Pattern: "t.me", Channel: model.ReferrerChannelMessaging},
+	{MatchType: model.ReferrerMatchHost, Pattern: "telegram.org", Channel: model.ReferrerChannelMessaging},
+	{MatchType: model.ReferrerMatchHost, Pattern: "messenger.com", Channel: model.ReferrerChannelMessaging},
+	{MatchType: model.ReferrerMatchHost, Pattern: "m.me", Channel: model.ReferrerChannelMessaging},
+	{MatchType: model.ReferrerMatchHost, Pattern: "discord.com", Channel: model.ReferrerChannelMessaging},
+	{MatchType: model.ReferrerMatchHost, Pattern: "discordapp.com", Channel: model.ReferrerChannelMessaging},
+	{MatchType: model.ReferrerMatchHost, Pattern: "slack.com", Channel: model.ReferrerChannelMessaging},
+	{MatchType: model.ReferrerMatchHost, Pattern: "line.me", Channel: model.ReferrerChannelMessaging},
+	{MatchType: model.ReferrerMatchHost, Pattern: "teams.microsoft.com", Channel: model.ReferrerChannelMessaging},
+	{MatchType: model.ReferrerMatchHost, Pattern: "dingtalk.com", Channel: model.ReferrerChannelMessaging},
+	{MatchType: model.ReferrerMatchHost, Pattern: "feishu.cn", Channel: model.ReferrerChannelMessaging},
+	{MatchType: model.ReferrerMatchHost, Pattern: "larksuite.com", Channel: model.ReferrerChannelMessaging},
+
+	// 社交媒体
+	{MatchType: model.ReferrerMatchHost, Pattern: "facebook.com", Channel: model.ReferrerChannelSocial},
+	{MatchType: model.ReferrerMatchHost, Pattern: "fb.com", Channel: model.ReferrerChannelSocial},
+	{MatchType: model.ReferrerMatchHost, Pattern: "instagram.com", Channel: model.ReferrerChannelSocial},
+	{MatchType: model.ReferrerMatchHost, Pattern: "threads.net", Channel: model.ReferrerChannelSocial},
+	{MatchType: model.ReferrerMatchHost, Pattern: "twitter.com", Channel: model.ReferrerChannelSocial},
+	{MatchType: model.ReferrerMatchHost, Pattern: "x.com", Channel: model.ReferrerChannelSocial},
+	{MatchType: model.ReferrerMatchHost, Pattern: "t.co", Channel: model.ReferrerChannelSocial},
+	{MatchType: model.ReferrerMatchHost, Pattern: "linkedin.com", Channel: model.ReferrerChannelSocial},
+	{MatchType: model.ReferrerMatchHost, Pattern: "lnkd.in", Channel: model.ReferrerChannelSocial},
+	{MatchType: model.ReferrerMatchHost, Pattern: "reddit.com", Channel: model.ReferrerChannelSocial},
+	{MatchType: model.ReferrerMatchHost, Pattern: "pinterest.com", Channel: model.ReferrerChannelSocial},
+	{MatchType: model.ReferrerMatchHost, Pattern: "tiktok.com", Channel: model.ReferrerChannelSocial},
+	{MatchType: model.ReferrerMatchHost, Pattern: "youtube.com", Channel: model.ReferrerChannelSocial},
+	{MatchType: model.ReferrerMatchHost, Pattern: "youtu.be", Channel: model.ReferrerChannelSocial},
+	{MatchType: model.ReferrerMatchHost, Pattern: "quora.com", Channel: model.ReferrerChannelSocial},
+	{MatchType: model.ReferrerMatchHost, Pattern: "tumblr.com", Channel: model.ReferrerChannelSocial},
+	{MatchType: model.ReferrerMatchHost, Pattern: "vk.com", Channel: model.ReferrerChannelSocial},
+	{MatchType: model.ReferrerMatchHost, Pattern: "weibo.com", Channel: model.ReferrerChannelSocial},
+	{MatchType: model.ReferrerMatchHost, Pattern: "weibo.cn", Channel: model.ReferrerChannelSocial},
+	{MatchType: model.ReferrerMatchHost, Pattern: "t.cn", Channel: model.ReferrerChannelSocial},
+	{MatchType: model.ReferrerMatchHost, Pattern: "zhihu.com", Channel: model.ReferrerChannelSocial},
+	{MatchType: model.ReferrerMatchHost, Pattern: "xiaohongshu.com", Channel: model.ReferrerChannelSocial},
+	{MatchType: model.ReferrerMatchHost, Pattern: "xhslink.com", Channel: model.ReferrerChannelSocial},
+	{MatchType: model.ReferrerMatchHost, Pattern: "douyin.com", Channel: model.ReferrerChannelSocial},
+	{MatchType: model.ReferrerMatchHost, Pattern: "bilibili.com", Channel: model.ReferrerChannelSocial},
+	{MatchType: model.ReferrerMatchHost, Pattern: "b23.tv", Channel: model.ReferrerChannelSocial},
+	{MatchType: model.ReferrerMatchHost, Pattern: "douban.com", Channel: model.ReferrerChannelSocial},
+	{MatchType: model.ReferrerMatchHost, Pattern: "kuaishou.com", Channel: model.ReferrerChannelSocial},
+
+	// 搜索引擎
+	{MatchType: model.ReferrerMatchHost, Pattern: "google.*", Channel: model.ReferrerChannelSearch},
+	{MatchType: model.ReferrerMatchHost, Pattern: "bing.com", Channel: model.ReferrerChannelSearch},
+	{MatchType: model.ReferrerMatchHost, Pattern: "baidu.com", Channel: model.ReferrerChannelSearch},
+	{MatchType: model.ReferrerMatchHost, Pattern: "so.com", Channel: model.ReferrerChannelSearch},
+	{MatchType: model.ReferrerMatchHost, Pattern: "sogou.com", Channel: model.ReferrerChannelSearch},
+	{MatchType: model.ReferrerMatchHost, Pattern: "sm.cn", Channel: model.ReferrerChannelSearch},
+	{MatchType: model.ReferrerMatchHost, Pattern: "search.yahoo.com", Channel: model.ReferrerChannelSearch},
+	{MatchType: model.ReferrerMatchHost, Pattern: "yahoo.co.jp", Channel: model.ReferrerChannelSearch},
+	{MatchType: model.ReferrerMatchHost, Pattern: "duckduckgo.com", Channel: model.ReferrerChannelSearch},
+	{MatchType: model.ReferrerMatchHost, Pattern: "yandex.*", Channel: model.ReferrerChannelSearch},
+	{MatchType: model.ReferrerMatchHost, Pattern: "naver.com", Channel: model.ReferrerChannelSearch},
+	{MatchType: model.ReferrerMatchHost, Pattern: "ecosia.org", Channel: model.ReferrerChannelSearch},
+	{MatchType: model.ReferrerMatchHost, Pattern: "search.brave.com", Channel: model.ReferrerChannelSearch},
+	{MatchType: model.ReferrerMatchHost, Pattern: "startpage.com", Channel: model.ReferrerChannelSearch},
+}
@@ -0,0 +1,299 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/dao"
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/dto"
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/model"
+	"cnb.cool/mliev/dwz/dwz-server/v2/pkg/interfaces"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultReferrerBackfillBatchSize = 1000
+	referrerRulesCacheKey            = "workspace_referrer_rules:"
+	referrerRulesCacheTTL            = 5 * time.Minute
+)
+
+var (
+	ErrReferrerRuleNotFound = errors.New("来源渠道规则不存在")
+	ErrReferrerRuleInvalid  = errors.New("来源渠道规则无效")
+)
+
+// ReferrerInput 分类一次点击来源所需的信息
+type ReferrerInput struct {
+	Referer       string
+	QueryParams   string   // 访问短网址时的原始查询字符串
+	UTMSource     string   // 短网址配置的 utm_source，查询字符串中没有时使用
+	UTMMedium     string   // 短网址配置的 utm_medium，查询字符串中没有时使用
+	InternalHosts []string // 短网址域名和目标站点主机名
+}
+
+// ReferrerService 点击来源渠道分类与规则维护
+type ReferrerService struct {
+	helper            interfaces.HelperInterface
+	ruleDao           *dao.ReferrerRuleDao
+	clickStatisticDao *dao.ClickStatisticDao
+	shortLinkDao      *dao.ShortLinkDao
+}
+
+func NewReferrerService(helper interfaces.HelperInterface) *ReferrerService {
+	return &ReferrerService{
+		helper:            helper,
+		ruleDao:           dao.NewReferrerRuleDao(helper),
+		clickStatisticDao: dao.NewClickStatisticDao(helper),
+		shortLinkDao:      dao.NewShortLinkDao(helper),
+	}
+}
+
+// Classify 返回来源主机名和渠道，工作区规则优先于内置规则
+func (s *ReferrerService) Classify(workspaceID uint64, input ReferrerInput) (string, string) {
+	return classifyReferrer(s.enabledRules(workspaceID), input)
+}
+
+// enabledRules 工作区启用的规则，结果写入缓存，避免每次点击都查询规则
+func (s *ReferrerService) enabledRules(workspaceID uint64) []model.ReferrerRule {
+	ctx := context.Background()
+	cache := s.helper.GetCache()
+	key := referrerRulesCacheKey + strconv.FormatUint(workspaceID, 10)
+	var rules []model.ReferrerRule
+	if cache != nil && cache.Get(ctx, key, &rules) == nil {
+		return rules
+	}
+	rules, err := s.ruleDao.ListInWorkspace(workspaceID, true)
+	if err != nil {
+		return nil
+	}
+	if cache != nil {
+		_ = cache.Set(ctx, key, rules, referrerRulesCacheTTL)
+	}
+	return rules
+}
+
+// forgetRules 规则变更后清除缓存
+func (s *ReferrerService) forgetRules(workspaceID uint64) {
+	if cache := s.helper.GetCache(); cache != nil {
+		_ = cache.Del(context.Background(), referrerRulesCacheKey+strconv.FormatUint(workspaceID, 10))
+	}
+}
+
+// ShortLinkReferrerInput 点击所属短网址提供的分类信息
+func ShortLinkReferrerInput(shortLink *model.ShortLink, domain, referer, queryParams string) ReferrerInput {
+	internalHosts := []string{domain, shortLink.Domain}
+	if parsed, err := url.Parse(shortLink.OriginalURL); err == nil {
+		internalHosts = append(internalHosts, parsed.Hostname())
+	}
+	return ReferrerInput{
+		Referer:       referer,
+		QueryParams:   queryParams,
+		UTMSource:     shortLink.UTMSource,
+		UTMMedium:     shortLink.UTMMedium,
+		InternalHosts: internalHosts,
+	}
+}
+
+func classifyReferrer(workspaceRules []model.ReferrerRule, input ReferrerInput) (string, string) {
+	host := model.RefererHost(input.Referer)
+	query, _ := url.ParseQuery(input.QueryParams)
+	utmSource := strings.ToLower(strings.TrimSpace(firstNonEmpty(query.Get("utm_source"), input.UTMSource)))
+	utmMedium := strings.ToLower(strings.TrimSpace(firstNonEmpty(query.Get("utm_medium"), input.UTMMedium)))
+	matches := func(rule model.ReferrerRule) bool {
+		return referrerRuleMatches(rule, host, query, utmSource, utmMedium)
+	}
+
+	for _, rule := range workspaceRules {
+		if matches(rule) {
+			return host, rule.Channel
+		}
+	}
+	if host != "" {
+		for _, internal := range input.InternalHosts {
+			if internal = strings.TrimPrefix(strings.ToLower(internal), "www."); internal != "" && host == internal {
+				return host, model.ReferrerChannelInternal
+			}
+		}
+	}
+	for _, rule := range defaultReferrerRules {
+		if matches(rule) {
+			return host, rule.Channel
+		}
+	}
+	if host == "" {
+		return host, model.ReferrerChannelDirect
+	}
+	return host, model.ReferrerChannelReferral
+}
+
+func referrerRuleMatches(rule model.ReferrerRule, host string, query url.Values, utmSource, utmMedium string) bool {
+	pattern := strings.ToLower(rule.Pattern)
+	switch rule.MatchType {
+	case model.ReferrerMatchHost:
+		if host == "" {
+			return false
+		}
+		if prefix, ok := strings.CutSuffix(pattern, ".*"); ok {
+			// google.* 匹配 google.com、google.co.uk 及其子域名
+			return strings.HasPrefix(host, prefix+".") || strings.Contains(host, "."+prefix+".")
+		}
+		return host == pattern || strings.HasSuffix(host, "."+pattern)
+	case model.ReferrerMatchQueryParam:
+		_, ok := query[rule.Pattern]
+		return ok
+	case model.ReferrerMatchUTMSource:
+		return utmSource != "" && utmSource == pattern
+	case model.ReferrerMatchUTMMedium:
+		return utmMedium != "" && utmMedium == pattern
+	}
+	return false
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// BackfillPending 为新增渠道字段之前的点击补充来源分类，每次处理一批
+func (s *ReferrerService) BackfillPending() (int, error) {
+	statistics, err := s.clickStatisticDao.ListUnclassifiedReferrers(defaultReferrerBackfillBatchSize)
+	if err != nil || len(statistics) == 0 {
+		return 0, err
+	}
+	rules := make(map[uint64][]model.ReferrerRule)
+	shortLinks := make(map[uint64]*model.ShortLink)
+	for _, statistic := range statistics {
+		if _, ok := rules[statistic.WorkspaceID]; !ok {
+			workspaceRules, err := s.ruleDao.ListInWorkspace(statistic.WorkspaceID, true)
+			if err != nil {
+				return 0, err
+			}
+			rules[statistic.WorkspaceID] = workspaceRules
+		}
+		shortLink, ok := shortLinks[statistic.ShortLinkID]
+		if !ok {
+			if shortLink, err = s.shortLinkDao.FindByID(statistic.ShortLinkID); err != nil {
+				shortLink = &model.ShortLink{}
+			}
+			shortLinks[statistic.ShortLinkID] = shortLink
+		}
+		input := ShortLinkReferrerInput(shortLink, shortLink.Domain, statistic.Referer, statistic.QueryParams)
+		input.UTMSource, input.UTMMedium = statistic.UTMSource, statistic.UTMMedium
+		host, channel := classifyReferrer(rules[statistic.WorkspaceID], input)
+		if err := s.clickStatisticDao.UpdateReferrer(statistic.ID, host, channel); err != nil {
+			return 0, err
+		}
+	}
+	return len(statistics), nil
+}
+
+// ListRules 工作区规则和内置规则
+func (s *ReferrerService) ListRules(workspaceID uint64) (*dto.ReferrerRuleListResponse, error) {
+	rules, err := s.ruleDao.ListInWorkspace(workspaceID, false)
+	if err != nil {
+		return nil, err
+	}
+	response := &dto.ReferrerRuleListResponse{
+		List:    make([]dto.ReferrerRuleResponse, 0, len(rules)),
+		Builtin: make([]dto.ReferrerRuleResponse, 0, len(defaultReferrerRules)),
+	}
+	for i := range rules {
+		response.List = append(response.List, referrerRuleToResponse(&rules[i], false))
+	}
+	for i := range defaultReferrerRules {
+		rule := defaultReferrerRules[i]
+		rule.Enabled = true
+		response.Builtin = append(response.Builtin, referrerRuleToResponse(&rule, true))
+	}
+	return response, nil
+}
+
+func (s *ReferrerService) CreateRule(workspaceID, userID uint64, req *dto.ReferrerRuleRequest) (*dto.ReferrerRuleResponse, error) {
+	rule := &model.ReferrerRule{WorkspaceID: workspaceID, Enabled: true, CreatedBy: actorPtr(userID)}
+	if err := s.applyRule(rule, req); err != nil {
+		return nil, err
+	}
+	if err := s.ruleDao.Save(rule); err != nil {
+		return nil, err
+	}
+	s.forgetRules(workspaceID)
+	response := referrerRuleToResponse(rule, false)
+	return &response, nil
+}
+
+func (s *ReferrerService) UpdateRule(id, workspaceID uint64, req *dto.ReferrerRuleRequest) (*dto.ReferrerRuleResponse, error) {
+	rule, err := s.ruleDao.FindByID(id, workspaceID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrReferrerRuleNotFound
+		}
+		return nil, err
+	}
+	if err := s.applyRule(rule, req); err != nil {
+		return nil, err
+	}
+	if err := s.ruleDao.Save(rule); err != nil {
+		return nil, err
+	}
+	s.forgetRules(workspaceID)
+	response := referrerRuleToResponse(rule, false)
+	return &response, nil
+}
+
+func (s *ReferrerService) DeleteRule(id, workspaceID uint64) error {
+	deleted, err := s.ruleDao.Delete(id, workspaceID)
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return ErrReferrerRuleNotFound
+	}
+	s.forgetRules(workspaceID)
+	return nil
+}
+
+func (s *ReferrerService) applyRule(rule *model.ReferrerRule, req *dto.ReferrerRuleRequest) error {
+	pattern := strings.TrimSpace(req.Pattern)
+	if req.MatchType == model.ReferrerMatchHost {
+		pattern = strings.TrimPrefix(strings.ToLower(pattern), "www.")
+		if strings.Contains(pattern, "/") {
+			return fmt.Errorf("%w: 主机名规则只能填写域名，如 example.com 或 google.*", ErrReferrerRuleInvalid)
+		}
+	}
+	if pattern == "" {
+		return fmt.Errorf("%w: 规则内容不能为空", ErrReferrerRuleInvalid)
+	}
+	rule.MatchType = req.MatchType
+	rule.Pattern = pattern
+	rule.Channel = req.Channel
+	rule.Priority = req.Priority
+	if req.Enabled != nil {
+		rule.Enabled = *req.Enabled
+	}
+	return nil
+}
+
+func referrerRuleToResponse(rule *model.ReferrerRule, builtin bool) dto.ReferrerRuleResponse {
+	return dto.ReferrerRuleResponse{
+		ID:          rule.ID,
+		WorkspaceID: rule.WorkspaceID,
+		MatchType:   rule.MatchType,
+		Pattern:     rule.Pattern,
+		Channel:     rule.Channel,
+		Priority:    rule.Priority,
+		Enabled:     rule.Enabled,
+		Builtin:     builtin,
+		CreatedBy:   rule.CreatedBy,
+		CreatedAt:   rule.CreatedAt,
+		UpdatedAt:   rule.UpdatedAt,
+	}
+}
@@ -0,0 +1,184 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/dto"
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/model"
+)
+
+func TestClassifyReferrerBuiltinRules(t *testing.T) {
+	internal := []string{"batch.dwz.do", "example.com"}
+	cases := []struct {
+		name    string
+		input   ReferrerInput
+		host    string
+		channel string
+	}{
+		{"search", ReferrerInput{Referer: "https://www.google.co.uk/search?q=a"}, "google.co.uk", model.ReferrerChannelSearch},
+		{"baidu", ReferrerInput{Referer: "https://m.baidu.com/s?wd=a"}, "m.baidu.com", model.ReferrerChannelSearch},
+		{"webmail before search", ReferrerInput{Referer: "https://mail.google.com/mail/u/0/"}, "mail.google.com", model.ReferrerChannelEmail},
+		{"social", ReferrerInput{Referer: "https://t.co/abc"}, "t.co", model.ReferrerChannelSocial},
+		{"email via utm", ReferrerInput{QueryParams: "utm_medium=Email"}, "", model.ReferrerChannelEmail},
+		{"link utm fallback", ReferrerInput{UTMMedium: "newsletter"}, "", model.ReferrerChannelEmail},
+		{"paid via click id", ReferrerInput{Referer: "https://www.google.com/", QueryParams: "gclid=abc"}, "google.com", model.ReferrerChannelPaid},
+		{"internal", ReferrerInput{Referer: "https://www.example.com/page", InternalHosts: internal}, "example.com", model.ReferrerChannelInternal},
+		{"direct", ReferrerInput{}, "", model.ReferrerChannelDirect},
+		{"referral", ReferrerInput{Referer: "https://blog.someone.net/post"}, "blog.someone.net", model.ReferrerChannelReferral},
+	}
+	for _, tc := range cases {
+		if tc.input.InternalHosts == nil {
+			tc.input.InternalHosts = internal
+		}
+		host, channel := classifyReferrer(nil, tc.input)
+		if host != tc.host || channel != tc.channel {
+			t.Errorf("%s: got host=%q channel=%q, want host=%q channel=%q", tc.name, host, channel, tc.host, tc.channel)
+		}
+	}
+}
+
+func TestReferrerRulesRedirectBreakdownAndBackfill(t *testing.T) {
+	helper := newShortLinkRegressionHelper(t)
+	db := helper.GetDatabase()
+	seedBatchShortLinkDomain(t, db)
+
+	referrerSvc := NewReferrerService(helper)
+	if _, err := referrerSvc.CreateRule(1, 7, &dto.ReferrerRuleRequest{MatchType: model.ReferrerMatchHost, Pattern: "https://x.com/a", Channel: model.ReferrerChannelPaid}); !errors.Is(err, ErrReferrerRuleInvalid) {
+		t.Fatalf("host rule with path must be rejected: %v", err)
+	}
+	// 工作区规则优先于内置规则
+	rule, err := referrerSvc.CreateRule(1, 7, &dto.ReferrerRuleRequest{MatchType: model.ReferrerMatchHost, Pattern: "www.T.co", Channel: model.ReferrerChannelPaid})
+	if err != nil || rule.Pattern != "t.co" || !rule.Enabled {
+		t.Fatalf("create rule: %+v err=%v", rule, err)
+	}
+	disabled := false
+	facebook, err := referrerSvc.CreateRule(1, 7, &dto.ReferrerRuleRequest{MatchType: model.ReferrerMatchHost, Pattern: "facebook.com", Channel: model.ReferrerChannelPaid, Enabled: &disabled})
+	if err != nil || facebook.Enabled {
+		t.Fatalf("disabled rule: %+v err=%v", facebook, err)
+	}
+	if err := referrerSvc.DeleteRule(rule.ID, 2); !errors.Is(err, ErrReferrerRuleNotFound) {
+		t.Fatalf("rule must be scoped to its workspace: %v", err)
+	}
+	rules, err := referrerSvc.ListRules(1)
+	if err != nil || len(rules.List) != 2 || len(rules.Builtin) != len(defaultReferrerRules) || !rules.Builtin[0].Builtin {
+		t.Fatalf("list rules: %+v err=%v", rules, err)
+	}
+
+	// 规则按工作区缓存，通过接口修改规则后立即生效
+	fromFacebook := ReferrerInput{Referer: "https://www.facebook.com/"}
+	if _, channel := referrerSvc.Classify(1, fromFacebook); channel != model.ReferrerChannelSocial {
+		t.Fatalf("disabled rule must not apply: %q", channel)
+	}
+	enabled := true
+	if _, err := referrerSvc.UpdateRule(facebook.ID, 1, &dto.ReferrerRuleRequest{MatchType: model.ReferrerMatchHost, Pattern: "facebook.com", Channel: model.ReferrerChannelPaid, Enabled: &enabled}); err != nil {
+		t.Fatalf("enable rule: %v", err)
+	}
+	if _, channel := referrerSvc.Classify(1, fromFacebook); channel != model.ReferrerChannelPaid {
+		t.Fatalf("updated rule must apply immediately: %q", channel)
+	}
+	db.Model(&model.ReferrerRule{}).Where("id = ?", facebook.ID).Update("enabled", false)
+	if _, channel := referrerSvc.Classify(1, fromFacebook); channel != model.ReferrerChannelPaid {
+		t.Fatalf("rules must be served from cache: %q", channel)
+	}
+	if err := referrerSvc.DeleteRule(facebook.ID, 1); err != nil {
+		t.Fatalf("delete rule: %v", err)
+	}
+	if _, channel := referrerSvc.Classify(1, fromFacebook); channel != model.ReferrerChannelSocial {
+		t.Fatalf("deleted rule must stop applying: %q", channel)
+	}
+
+	shortLinkSvc := NewShortLinkService(helper, context.Background())
+	link, err := shortLinkSvc.CreateShortLinkInWorkspace(&dto.CreateShortLinkRequest{
+		OriginalURL: "https://example.com/landing",
+		Domain:      "batch.dwz.do",
+		CustomCode:  "channels",
+	}, "", 1, 7)
+	if err != nil {
+		t.Fatalf("create link: %v", err)
+	}
+	visit := func(referer, query string) {
+		if _, err := shortLinkSvc.ResolveRedirectWithSecurity("batch.dwz.do", "channels", "1.1.1.1", "Mozilla/5.0", referer, query, ""); err != nil {
+			t.Fatalf("redirect: %v", err)
+		}
+	}
+	visit("https://t.co/abc", "")
+	visit("https://www.google.com/search?q=a", "")
+	visit("https://www.google.com/search?q=b", "")
+	visit("https://example.com/other", "")
+	visit("", "utm_medium=email")
+	waitForModelCount(t, db, &model.ClickStatistic{}, "short_link_id = ?", link.ID, 5)
+
+	var clicks []model.ClickStatistic
+	db.Where("short_link_id = ?", link.ID).Order("id").Find(&clicks)
+	// 点击异步写入，按来源比对而不是按写入顺序
+	want := map[string]string{
+		"https://t.co/abc":                  model.ReferrerChannelPaid,
+		"https://www.google.com/search?q=a": model.ReferrerChannelSearch,
+		"https://www.google.com/search?q=b": model.ReferrerChannelSearch,
+		"https://example.com/other":         model.ReferrerChannelInternal,
+		"":                                  model.ReferrerChannelEmail,
+	}
+	for _, click := range clicks {
+		if click.RefererChannel != want[click.Referer] {
+			t.Fatalf("click %q channel=%q want %q", click.Referer, click.RefererChannel, want[click.Referer])
+		}
+		if click.Referer == "https://www.google.com/search?q=a" && click.RefererHost != "google.com" {
+			t.Fatalf("referer host must be normalized: %q", click.RefererHost)
+		}
+	}
+
+	// 字段上线前的历史点击由回填任务分类
+	clickDate := clicks[0].ClickDate
+	seedClickStatistic(t, db, model.ClickStatistic{WorkspaceID: 1, ShortLinkID: link.ID, IP: "2.2.2.2", Referer: "https://www.facebook.com/", ClickDate: clickDate})
+	seedClickStatistic(t, db, model.ClickStatistic{WorkspaceID: 1, ShortLinkID: link.ID, IP: "3.3.3.3", ClickDate: clickDate})
+
+	statisticSvc := NewClickStatisticService(helper)
+	breakdown := func(label string) map[string]dto.ReferrerChannelStatistic {
+		t.Helper()
+		helper.cache = newShortLinkRegressionCache()
+		response, err := statisticSvc.GetReferrerChannelBreakdownInWorkspace(1, &dto.ClickStatisticListRequest{}, 7)
+		if err != nil || response.TotalClicks != 7 {
+			t.Fatalf("%s breakdown: %+v err=%v", label, response, err)
+		}
+		channels := map[string]dto.ReferrerChannelStatistic{}
+		for _, channel := range response.Channels {
+			channels[channel.Channel] = channel
+		}
+		return channels
+	}
+	before := breakdown("raw")
+	if before[model.ReferrerChannelUnknown].Clicks != 2 || before[model.ReferrerChannelSearch].Clicks != 2 || before[model.ReferrerChannelSearch].Percentage != 28.57 {
+		t.Fatalf("unclassified clicks must be reported as unknown: %+v", before)
+	}
+	if hosts := before[model.ReferrerChannelSearch].TopHosts; len(hosts) != 1 || hosts[0].Referer != "google.com" || hosts[0].Count != 2 {
+		t.Fatalf("top hosts: %+v", hosts)
+	}
+
+	if classified, err := referrerSvc.BackfillPending(); err != nil || classified != 2 {
+		t.Fatalf("backfill: classified=%d err=%v", classified, err)
+	}
+	if again, err := referrerSvc.BackfillPending(); err != nil || again != 0 {
+		t.Fatalf("classified clicks must not be backfilled again: %d err=%v", again, err)
+	}
+	after := breakdown("backfilled")
+	if _, ok := after[model.ReferrerChannelUnknown]; ok || after[model.ReferrerChannelSocial].Clicks != 1 || after[model.ReferrerChannelDirect].Clicks != 1 {
+		t.Fatalf("backfilled breakdown: %+v", after)
+	}
+
+	if _, err := NewClickRollupService(helper).ProcessPending(); err != nil {
+		t.Fatalf("process rollups: %v", err)
+	}
+	rolled := breakdown("rollup")
+	for channel, statistic := range after {
+		if rolled[channel].Clicks != statistic.Clicks || len(rolled[channel].TopHosts) != len(statistic.TopHosts) {
+			t.Fatalf("rollup breakdown differs for %s: raw=%+v rollup=%+v", channel, statistic, rolled[channel])
+		}
+	}
+
+	filtered, err := statisticSvc.GetClickStatisticListInWorkspace(&dto.ClickStatisticListRequest{RefererChannel: model.ReferrerChannelSearch, Page: 1, PageSize: 10}, 1)
+	if err != nil || filtered.Total != 2 {
+		t.Fatalf("filter by channel: %+v err=%v", filtered, err)
+	}
+}
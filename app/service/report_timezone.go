@@ -0,0 +1,43 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/dao"
+	"cnb.cool/mliev/dwz/dwz-server/v2/pkg/interfaces"
+)
+
+var ErrInvalidTimezone = errors.New("无效的时区，请使用 IANA 时区名称，如 Asia/Shanghai")
+
+// LoadTimezone 解析 IANA 时区名称，名称为空时返回 nil
+func LoadTimezone(name string) (*time.Location, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, nil
+	}
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, ErrInvalidTimezone
+	}
+	return location, nil
+}
+
+// ReportLocation 报表按天、按小时划分使用的时区：请求参数 tz 优先，其次为工作区时区、服务端配置 analytics.timezone，
+// 均未设置时使用服务器时区。只有请求参数无效时返回错误，已保存的无效设置按未设置处理。
+func ReportLocation(helper interfaces.HelperInterface, workspaceID uint64, override string) (*time.Location, error) {
+	location, err := LoadTimezone(override)
+	if err != nil || location != nil {
+		return location, err
+	}
+	if workspace, err := dao.NewWorkspaceDao(helper).FindByID(workspaceID); err == nil {
+		if location, _ := LoadTimezone(workspace.Timezone); location != nil {
+			return location, nil
+		}
+	}
+	if location, _ := LoadTimezone(helper.GetConfig().GetString("analytics.timezone", "")); location != nil {
+		return location, nil
+	}
+	return time.Local, nil
+}
@@ -0,0 +1,106 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/dto"
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/model"
+)
+
+func TestReportTimezoneBuckets(t *testing.T) {
+	helper := newShortLinkRegressionHelper(t)
+	db := helper.GetDatabase()
+	if err := db.Create(&model.Workspace{ID: 1, Slug: "default", Name: "Default", Status: 1}).Error; err != nil {
+		t.Fatalf("seed workspace: %v", err)
+	}
+
+	timezone := "Asia/Tokyo"
+	if _, err := NewWorkspaceService(helper).UpdateWorkspace(1, &dto.UpdateWorkspaceRequest{Timezone: &timezone}); err != nil {
+		t.Fatalf("update timezone: %v", err)
+	}
+	invalid := "Mars/Olympus"
+	if _, err := NewWorkspaceService(helper).UpdateWorkspace(1, &dto.UpdateWorkspaceRequest{Timezone: &invalid}); !errors.Is(err, ErrInvalidTimezone) {
+		t.Fatalf("invalid timezone must be rejected: %v", err)
+	}
+
+	if location, err := ReportLocation(helper, 1, ""); err != nil || location.String() != "Asia/Tokyo" {
+		t.Fatalf("workspace timezone: %v err=%v", location, err)
+	}
+	if location, err := ReportLocation(helper, 1, "America/New_York"); err != nil || location.String() != "America/New_York" {
+		t.Fatalf("tz override: %v err=%v", location, err)
+	}
+	if _, err := ReportLocation(helper, 1, invalid); !errors.Is(err, ErrInvalidTimezone) {
+		t.Fatalf("invalid override: %v", err)
+	}
+	helper.settings["analytics.timezone"] = "Europe/Berlin"
+	if location, err := ReportLocation(helper, 2, ""); err != nil || location.String() != "Europe/Berlin" {
+		t.Fatalf("config timezone: %v err=%v", location, err)
+	}
+
+	// UTC 下分属两天的点击，在东京时间同属 10 月 19 日
+	for _, at := range []time.Time{
+		time.Date(2026, 10, 18, 23, 30, 0, 0, time.UTC),
+		time.Date(2026, 10, 19, 1, 0, 0, 0, time.UTC),
+	} {
+		seedClickStatistic(t, db, model.ClickStatistic{WorkspaceID: 1, ShortLinkID: 1, IP: "10.0.0.1", ClickDate: at})
+	}
+	// 纽约 11 月 1 日结束夏令时，这次点击在当地是 10 月 31 日 00:30（UTC-4），不能按结束日的 UTC-5 换算
+	seedClickStatistic(t, db, model.ClickStatistic{WorkspaceID: 1, ShortLinkID: 1, IP: "10.0.0.1", ClickDate: time.Date(2026, 10, 31, 4, 30, 0, 0, time.UTC)})
+	analysisBetween := func(name string, start, end time.Time) *dto.ClickStatisticAnalysisResponse {
+		t.Helper()
+		location, _ := LoadTimezone(name)
+		req := &dto.ClickStatisticListRequest{
+			StartDate: time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, location),
+			EndDate:   time.Date(end.Year(), end.Month(), end.Day(), 0, 0, 0, 0, location),
+			Timezone:  name,
+			Location:  location,
+		}
+		response, err := NewClickStatisticService(helper).GetClickStatisticAnalysisInWorkspace(1, req, 7)
+		if err != nil {
+			t.Fatalf("analysis %s: %v", name, err)
+		}
+		return response
+	}
+	analysis := func(name string) *dto.ClickStatisticAnalysisResponse {
+		t.Helper()
+		return analysisBetween(name, time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC), time.Date(2026, 10, 21, 0, 0, 0, 0, time.UTC))
+	}
+	check := func(stage string) {
+		t.Helper()
+		tokyo := analysis("Asia/Tokyo")
+		if len(tokyo.DailyStats) != 1 || tokyo.DailyStats[0].Date != "2026-10-19" || tokyo.DailyStats[0].Count != 2 {
+			t.Fatalf("%s tokyo daily: %+v", stage, tokyo.DailyStats)
+		}
+		hours := map[int]int64{}
+		for _, stat := range tokyo.HourlyStats {
+			hours[stat.Hour] = stat.Count
+		}
+		if hours[8] != 1 || hours[10] != 1 {
+			t.Fatalf("%s tokyo hourly: %+v", stage, tokyo.HourlyStats)
+		}
+		if utc := analysis("UTC"); len(utc.DailyStats) != 2 {
+			t.Fatalf("%s utc daily: %+v", stage, utc.DailyStats)
+		}
+		if newYork := analysis("America/New_York"); len(newYork.DailyStats) != 1 || newYork.DailyStats[0].Date != "2026-10-18" {
+			t.Fatalf("%s new york daily: %+v", stage, newYork.DailyStats)
+		}
+		dst := analysisBetween("America/New_York", time.Date(2026, 10, 30, 0, 0, 0, 0, time.UTC), time.Date(2026, 11, 3, 0, 0, 0, 0, time.UTC))
+		if len(dst.DailyStats) != 1 || dst.DailyStats[0].Date != "2026-10-31" || len(dst.HourlyStats) != 1 || dst.HourlyStats[0].Hour != 0 {
+			t.Fatalf("%s dst: %+v %+v", stage, dst.DailyStats, dst.HourlyStats)
+		}
+		if kolkata := analysis("Asia/Kolkata"); len(kolkata.DailyStats) != 1 || kolkata.DailyStats[0].Date != "2026-10-19" {
+			t.Fatalf("%s kolkata daily: %+v", stage, kolkata.DailyStats)
+		}
+	}
+	helper.settings["analytics.rollup_enabled"] = false
+	check("raw")
+
+	// 汇总表按小时存储，整小时偏移的时区在汇总后结果不变
+	helper.settings["analytics.rollup_enabled"] = true
+	if _, err := NewClickRollupService(helper).ProcessPending(); err != nil {
+		t.Fatalf("process pending: %v", err)
+	}
+	check("rollup")
+}
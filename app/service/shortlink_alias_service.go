@@ -0,0 +1,222 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/dto"
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/model"
+	"gorm.io/gorm"
+)
+
+// shortLinkCodePattern 与短码路由分发规则一致
+var shortLinkCodePattern = regexp.MustCompile(`^[a-zA-Z0-9._-]+$`)
+
+// RekeyShortLinkInWorkspace 为短网址分配新的域名或短码，旧的域名+短码保留为永久别名。
+// 点击统计仍归属同一条短网址；新短码若是该短网址已有的别名，则将其提升为主短码。
+func (s *ShortLinkService) RekeyShortLinkInWorkspace(id uint64, req *dto.RekeyShortLinkRequest, workspaceID, userID uint64) (*dto.ShortLinkResponse, error) {
+	shortLink, err := s.shortLinkDao.FindByIDInWorkspace(id, workspaceID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("短网址不存在")
+		}
+		return nil, err
+	}
+
+	domain := strings.TrimSpace(req.Domain)
+	if domain == "" {
+		domain = shortLink.Domain
+	}
+	domainInfo, err := s.findActiveDomainInWorkspace(domain, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	shortCode := strings.TrimSpace(req.ShortCode)
+	keepCode := shortCode == "" && domainInfo.Domain != shortLink.Domain
+	if keepCode {
+		// 仅更换域名时沿用当前短码
+		shortCode = shortLink.GetShortCode()
+	}
+	if shortCode != "" && !shortLinkCodePattern.MatchString(shortCode) {
+		return nil, errors.New("短代码格式无效，仅支持字母、数字、点、下划线和短横线")
+	}
+	if domainInfo.Domain == shortLink.Domain && shortCode == shortLink.GetShortCode() {
+		return nil, errors.New("新短代码不能与当前短代码相同")
+	}
+
+	previous := &model.ShortLinkAlias{
+		WorkspaceID: shortLink.WorkspaceID,
+		ShortLinkID: shortLink.ID,
+		DomainID:    shortLink.DomainID,
+		Protocol:    shortLink.Protocol,
+		Domain:      shortLink.Domain,
+		ShortCode:   shortLink.GetShortCode(),
+		Source:      model.ShortLinkAliasSourceRekey,
+		CreatedBy:   actorPtr(userID),
+	}
+
+	rekeyed := *shortLink
+	rekeyed.Domain = domainInfo.Domain
+	rekeyed.DomainID = domainInfo.ID
+	rekeyed.Protocol = domainInfo.Protocol
+	rekeyed.UpdatedBy = actorPtr(userID)
+	reviewStatus, err := s.reviewStatusForActor(workspaceID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var promotedAliasID uint64
+	if shortCode != "" {
+		alias, err := s.shortLinkAliasDao.FindByDomainAndCode(domainInfo.Domain, shortCode)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+		if err == nil {
+			if alias.ShortLinkID != shortLink.ID {
+				return nil, errors.New("自定义短代码已存在")
+			}
+			promotedAliasID = alias.ID
+			rekeyed.ShortCode = shortCode
+			rekeyed.IsCustomCode = true
+		}
+	}
+	if promotedAliasID == 0 {
+		if err := s.assignShortCode(&rekeyed, domainInfo, shortCode); err != nil {
+			return nil, err
+		}
+		if keepCode {
+			rekeyed.IsCustomCode = shortLink.IsCustomCode
+		}
+	}
+
+	if err := s.shortLinkDao.Rekey(&rekeyed, previous, promotedAliasID); err != nil {
+		return nil, err
+	}
+	if reviewStatus == model.ShortLinkReviewPending && rekeyed.ReviewStatus != model.ShortLinkReviewPending {
+		// 新短码需重新审核，审核通过前新旧短码均不可访问
+		markPendingReview(&rekeyed)
+		if err := s.shortLinkDao.Update(&rekeyed); err != nil {
+			return nil, err
+		}
+	}
+
+	s.removeCacheShortLink(shortLink.Domain, shortLink.GetShortCode())
+	s.removeAliasCache(&rekeyed)
+	s.cacheShortLink(&rekeyed)
+
+	return s.modelToResponse(&rekeyed), nil
+}
+
+// CreateShortLinkAliasInWorkspace 为短网址手动添加别名，别名访问计入同一条短网址的统计
+func (s *ShortLinkService) CreateShortLinkAliasInWorkspace(id uint64, req *dto.CreateShortLinkAliasRequest, workspaceID, userID uint64) (*dto.ShortLinkAliasResponse, error) {
+	shortLink, err := s.shortLinkDao.FindByIDInWorkspace(id, workspaceID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("短网址不存在")
+		}
+		return nil, err
+	}
+
+	domain := strings.TrimSpace(req.Domain)
+	if domain == "" {
+		domain = shortLink.Domain
+	}
+	domainInfo, err := s.findActiveDomainInWorkspace(domain, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	shortCode := strings.TrimSpace(req.ShortCode)
+	if !shortLinkCodePattern.MatchString(shortCode) {
+		return nil, errors.New("短代码格式无效，仅支持字母、数字、点、下划线和短横线")
+	}
+	taken, err := s.isShortCodeTaken(domainInfo.Domain, shortCode)
+	if err != nil {
+		return nil, err
+	}
+	if taken {
+		return nil, errors.New("自定义短代码已存在")
+	}
+	count, err := s.shortLinkAliasDao.CountByShortLinkID(shortLink.ID, model.ShortLinkAliasSourceManual)
+	if err != nil {
+		return nil, err
+	}
+	if count >= model.ShortLinkAliasMaxPerLink {
+		return nil, fmt.Errorf("别名数量不能超过 %d 个", model.ShortLinkAliasMaxPerLink)
+	}
+
+	alias := &model.ShortLinkAlias{
+		WorkspaceID: shortLink.WorkspaceID,
+		ShortLinkID: shortLink.ID,
+		DomainID:    domainInfo.ID,
+		Protocol:    domainInfo.Protocol,
+		Domain:      domainInfo.Domain,
+		ShortCode:   shortCode,
+		Source:      model.ShortLinkAliasSourceManual,
+		CreatedBy:   actorPtr(userID),
+	}
+	if err := s.shortLinkAliasDao.Create(alias); err != nil {
+		return nil, err
+	}
+	if err := s.RequireReviewAfterChange(shortLink.ID, workspaceID, userID); err != nil {
+		return nil, err
+	}
+
+	response := aliasToResponse(alias)
+	return &response, nil
+}
+
+// ListShortLinkAliasesInWorkspace 获取短网址的别名列表
+func (s *ShortLinkService) ListShortLinkAliasesInWorkspace(id, workspaceID uint64) (*dto.ShortLinkAliasListResponse, error) {
+	if _, err := s.shortLinkDao.FindByIDInWorkspace(id, workspaceID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("短网址不存在")
+		}
+		return nil, err
+	}
+	aliases, err := s.shortLinkAliasDao.ListByShortLinkID(id, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	list := make([]dto.ShortLinkAliasResponse, 0, len(aliases))
+	for _, alias := range aliases {
+		list = append(list, aliasToResponse(&alias))
+	}
+	return &dto.ShortLinkAliasListResponse{List: list}, nil
+}
+
+// DeleteShortLinkAliasInWorkspace 删除短网址别名，删除后旧短码不再跳转
+func (s *ShortLinkService) DeleteShortLinkAliasInWorkspace(id, aliasID, workspaceID uint64) error {
+	if _, err := s.shortLinkDao.FindByIDInWorkspace(id, workspaceID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("短网址不存在")
+		}
+		return err
+	}
+	alias, err := s.shortLinkAliasDao.FindByIDForShortLink(aliasID, id, workspaceID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("别名不存在")
+		}
+		return err
+	}
+	if err := s.shortLinkAliasDao.Delete(alias.ID); err != nil {
+		return err
+	}
+	s.removeCacheShortLink(alias.Domain, alias.ShortCode)
+	return nil
+}
+
+func aliasToResponse(alias *model.ShortLinkAlias) dto.ShortLinkAliasResponse {
+	return dto.ShortLinkAliasResponse{
+		ID:          alias.ID,
+		ShortLinkID: alias.ShortLinkID,
+		Domain:      alias.Domain,
+		ShortCode:   alias.ShortCode,
+		ShortURL:    fmt.Sprintf("%s://%s/%s", alias.Protocol, alias.Domain, alias.ShortCode),
+		Source:      alias.Source,
+		CreatedBy:   alias.CreatedBy,
+		CreatedAt:   alias.CreatedAt,
+	}
+}
@@ -0,0 +1,218 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/dto"
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/model"
+	"gorm.io/gorm"
+)
+
+// 克隆时可选择复制的关联内容
+const (
+	CloneIncludeTags     = "tags"
+	CloneIncludeCampaign = "campaign"
+	CloneIncludeUTM      = "utm"
+	CloneIncludeRoutes   = "routes"
+	CloneIncludeSecurity = "security"
+	CloneIncludeABTest   = "ab_test"
+)
+
+var defaultCloneIncludes = []string{
+	CloneIncludeTags,
+	CloneIncludeCampaign,
+	CloneIncludeUTM,
+	CloneIncludeRoutes,
+	CloneIncludeSecurity,
+	CloneIncludeABTest,
+}
+
+// CloneShortLinkInWorkspace 将短网址复制为新的短代码/域名，可选复制到用户可管理的其他工作区。
+// 标签、活动仅在同一工作区内复制；安全设置不复制访问密码；AB测试复制为草稿。
+func (s *ShortLinkService) CloneShortLinkInWorkspace(id uint64, req *dto.CloneShortLinkRequest, creatorIP string, workspaceID, userID uint64) (*dto.ShortLinkResponse, error) {
+	source, err := s.shortLinkDao.FindByIDInWorkspace(id, workspaceID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("短网址不存在")
+		}
+		return nil, err
+	}
+
+	targetWorkspaceID := workspaceID
+	if req.TargetWorkspaceID > 0 && req.TargetWorkspaceID != workspaceID {
+		member, err := s.workspaceDao.GetMember(req.TargetWorkspaceID, userID)
+		if err != nil || !member.CanManageBusinessResource() {
+			return nil, errors.New("无权限在目标工作区创建短网址")
+		}
+		targetWorkspaceID = req.TargetWorkspaceID
+	}
+	sameWorkspace := targetWorkspaceID == source.WorkspaceID
+
+	includes := req.Include
+	if len(includes) == 0 {
+		includes = defaultCloneIncludes
+	}
+	include := make(map[string]bool, len(includes))
+	for _, item := range includes {
+		if !slices.Contains(defaultCloneIncludes, item) {
+			return nil, fmt.Errorf("无效的复制内容: %s", item)
+		}
+		include[item] = true
+	}
+
+	domain := req.Domain
+	if domain == "" {
+		domain = source.Domain
+	}
+	domainInfo, err := s.findActiveDomainInWorkspace(domain, targetWorkspaceID)
+	if err != nil {
+		return nil, err
+	}
+
+	originalURL := source.OriginalURL
+	if !include[CloneIncludeUTM] {
+		if originalURL, err = stripUTMFromURL(source); err != nil {
+			return nil, errors.New("无效的URL格式")
+		}
+	}
+	if result := s.linkSecurityService.ScanURL(targetWorkspaceID, originalURL); !result.Safe {
+		return nil, errors.New("目标 URL 命中安全规则: " + result.Reason)
+	}
+	if source.FallbackURL != "" {
+		if result := s.linkSecurityService.ScanURL(targetWorkspaceID, source.FallbackURL); !result.Safe {
+			return nil, errors.New("兜底地址命中安全规则: " + result.Reason)
+		}
+	}
+
+	if include[CloneIncludeRoutes] {
+		if err := s.linkRouteService.checkRoutesForClone(source.ID, source.WorkspaceID, targetWorkspaceID); err != nil {
+			return nil, err
+		}
+	}
+
+	title := req.Title
+	if title == "" {
+		title = source.Title
+	}
+	reviewStatus, err := s.reviewStatusForActor(targetWorkspaceID, userID)
+	if err != nil {
+		return nil, err
+	}
+	actor := actorPtr(userID)
+	clone := &model.ShortLink{
+		WorkspaceID:   targetWorkspaceID,
+		Domain:        domainInfo.Domain,
+		DomainID:      domainInfo.ID,
+		Protocol:      domainInfo.Protocol,
+		OriginalURL:   originalURL,
+		URLHash:       targetURLFingerprint(originalURL),
+		FallbackURL:   source.FallbackURL,
+		RedirectCode:  source.RedirectCode,
+		Title:         title,
+		Description:   source.Description,
+		Notes:         source.Notes,
+		ExpireAt:      source.ExpireAt,
+		IsActive:      true,
+		ReviewStatus:  reviewStatus,
+		AppendClickID: source.AppendClickID,
+		CreatorIP:     creatorIP,
+		CreatedBy:     actor,
+		UpdatedBy:     actor,
+	}
+	if include[CloneIncludeUTM] {
+		clone.UTMSource = source.UTMSource
+		clone.UTMMedium = source.UTMMedium
+		clone.UTMCampaign = source.UTMCampaign
+		clone.UTMTerm = source.UTMTerm
+		clone.UTMContent = source.UTMContent
+	}
+	if include[CloneIncludeCampaign] && sameWorkspace {
+		clone.CampaignID = source.CampaignID
+	}
+	if sameWorkspace {
+		clone.FolderID = source.FolderID
+	}
+
+	var tagIDs []uint64
+	if include[CloneIncludeTags] && sameWorkspace {
+		tags, err := s.tagDao.GetTagsByShortLinkID(source.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, tag := range tags {
+			tagIDs = append(tagIDs, tag.ID)
+		}
+	}
+
+	if err := s.assignShortCode(clone, domainInfo, req.CustomCode); err != nil {
+		return nil, err
+	}
+
+	// 事务内只使用 tx，安全扫描等查询需在事务外完成
+	err = s.helper.GetDatabase().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(clone).Error; err != nil {
+			return err
+		}
+		for _, tagID := range tagIDs {
+			if err := tx.Create(&model.ShortLinkTag{ShortLinkID: clone.ID, TagID: tagID}).Error; err != nil {
+				return err
+			}
+		}
+		if sameWorkspace {
+			// 自定义字段定义属于工作区，仅在同一工作区内复制取值
+			if err := s.customFieldService.customFieldDao.CopyValues(tx, source.ID, clone.ID); err != nil {
+				return err
+			}
+		}
+		if include[CloneIncludeRoutes] {
+			if err := s.linkRouteService.cloneRoutes(tx, source.ID, clone, userID); err != nil {
+				return err
+			}
+		}
+		if include[CloneIncludeSecurity] {
+			if err := s.linkSecurityService.cloneSecurity(tx, source.ID, clone, userID); err != nil {
+				return err
+			}
+		}
+		if include[CloneIncludeABTest] {
+			if err := s.abTestService.cloneLatestAsDraft(tx, source.ID, clone); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	created, err := s.shortLinkDao.FindByIDInWorkspace(clone.ID, targetWorkspaceID)
+	if err != nil {
+		return nil, err
+	}
+	s.cacheShortLink(created)
+	return s.modelToResponse(created), nil
+}
+
+// stripUTMFromURL 移除由短网址 UTM 字段合并进目标地址的参数
+func stripUTMFromURL(shortLink *model.ShortLink) (string, error) {
+	parsedURL, err := parseTargetURL(shortLink.OriginalURL)
+	if err != nil {
+		return "", err
+	}
+	query := parsedURL.Query()
+	for key, value := range map[string]string{
+		"utm_source":   shortLink.UTMSource,
+		"utm_medium":   shortLink.UTMMedium,
+		"utm_campaign": shortLink.UTMCampaign,
+		"utm_term":     shortLink.UTMTerm,
+		"utm_content":  shortLink.UTMContent,
+	} {
+		if value != "" {
+			query.Del(key)
+		}
+	}
+	parsedURL.RawQuery = query.Encode()
+	return parsedURL.String(), nil
+}
@@ -0,0 +1,86 @@
+package service
+
+import (
+	"errors"
+	"strings"
+
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/dto"
+	"gorm.io/gorm"
+)
+
+// GetShortLinkByExternalIDInWorkspace 根据外部系统标识查询短网址
+func (s *ShortLinkService) GetShortLinkByExternalIDInWorkspace(externalID string, workspaceID uint64) (*dto.ShortLinkResponse, error) {
+	externalID = strings.TrimSpace(externalID)
+	if externalID == "" {
+		return nil, errors.New("外部标识不能为空")
+	}
+	shortLink, err := s.shortLinkDao.FindByExternalIDInWorkspace(externalID, workspaceID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("短网址不存在")
+		}
+		return nil, err
+	}
+	return s.modelToResponse(shortLink), nil
+}
+
+// UpsertShortLinkByExternalIDInWorkspace 按外部标识创建或更新短网址，返回值 created 表示是否新建。
+// 已存在时按请求内容覆盖填写了的可编辑字段，传入路由时整体替换原有路由，域名与短代码保持不变。
+func (s *ShortLinkService) UpsertShortLinkByExternalIDInWorkspace(externalID string, req *dto.CreateShortLinkRequest, creatorIP string, workspaceID, userID uint64) (*dto.ShortLinkResponse, bool, error) {
+	externalID = strings.TrimSpace(externalID)
+	if externalID == "" {
+		return nil, false, errors.New("外部标识不能为空")
+	}
+	req.ExternalID = externalID
+
+	existing, err := s.shortLinkDao.FindByExternalIDInWorkspace(externalID, workspaceID)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, false, err
+		}
+		response, err := s.CreateShortLinkInWorkspace(req, creatorIP, workspaceID, userID)
+		if err != nil {
+			return nil, false, err
+		}
+		return response, true, nil
+	}
+
+	// 请求中未填写的字段保持原值
+	updateReq := &dto.UpdateShortLinkRequest{
+		OriginalURL:  req.OriginalURL,
+		Title:        req.Title,
+		Description:  req.Description,
+		ExpireAt:     existing.ExpireAt,
+		CampaignID:   req.CampaignID,
+		TagIDs:       req.TagIDs,
+		UTMSource:    req.UTMSource,
+		UTMMedium:    req.UTMMedium,
+		UTMCampaign:  req.UTMCampaign,
+		UTMTerm:      req.UTMTerm,
+		UTMContent:   req.UTMContent,
+		Notes:        req.Notes,
+		Security:     req.Security,
+		CustomFields: req.CustomFields,
+	}
+	if req.FallbackURL != "" {
+		updateReq.FallbackURL = &req.FallbackURL
+	}
+	if req.ExpireAt != nil {
+		updateReq.ExpireAt = req.ExpireAt
+	}
+	if req.RedirectCode != 0 {
+		updateReq.RedirectCode = &req.RedirectCode
+	}
+	if req.AppendClickID {
+		updateReq.AppendClickID = &req.AppendClickID
+	}
+	// 短网址与路由在同一事务内更新，路由写入失败时短网址保持原样
+	if _, err := s.updateShortLinkInWorkspace(existing.ID, updateReq, workspaceID, userID, req.Routes); err != nil {
+		return nil, false, err
+	}
+	response, err := s.GetShortLinkInWorkspace(existing.ID, workspaceID)
+	if err != nil {
+		return nil, false, err
+	}
+	return response, false, nil
+}
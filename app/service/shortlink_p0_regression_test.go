@@ -4,14 +4,17 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/url"
 	"strings"
 	"sync"
 	"testing"
 	"time"
 
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/dao"
 	"cnb.cool/mliev/dwz/dwz-server/v2/app/dto"
 	"cnb.cool/mliev/dwz/dwz-server/v2/app/model"
+	"cnb.cool/mliev/dwz/dwz-server/v2/migrations"
 	"cnb.cool/mliev/dwz/dwz-server/v2/pkg/interfaces"
 	ipRegionImpl "cnb.cool/mliev/dwz/dwz-server/v2/pkg/service/ip_region/impl"
 	"github.com/glebarez/sqlite"
@@ -605,7 +608,7 @@ func TestBatchDeleteShortLinksInWorkspace(t *testing.T) {
 		t.Fatalf("count inactive short link: %v", err)
 	}
 	if inactiveCount != 0 {
-		t.Fatalf("inactive short link should be hard deleted, got count %d", inactiveCount)
+		t.Fatalf("inactive short link should be moved to trash, got count %d", inactiveCount)
 	}
 	if _, err := shortLinkSvc.getShortLinkFromCache(inactive.Domain, inactive.ShortCode); err == nil {
 		t.Fatal("deleted short link should be removed from cache")
@@ -615,6 +618,1132 @@ func TestBatchDeleteShortLinksInWorkspace(t *testing.T) {
 	}
 }
 
+func TestShortLinkTrashRestoreAndPurge(t *testing.T) {
+	helper := newShortLinkRegressionHelper(t)
+	db := helper.GetDatabase()
+	shortLinkSvc := NewShortLinkService(helper, context.Background())
+
+	domain := seedBatchShortLinkDomain(t, db)
+	link := seedBatchShortLink(t, db, domain.ID, 1, "trash-restore", false)
+	route := model.LinkRoute{WorkspaceID: 1, ShortLinkID: link.ID, Name: "cn", TargetURL: "https://example.com/cn", IsActive: true}
+	removedRoute := model.LinkRoute{WorkspaceID: 1, ShortLinkID: link.ID, Name: "old", TargetURL: "https://example.com/old", IsActive: true}
+	for _, value := range []any{
+		&route,
+		&removedRoute,
+		&model.LinkSecuritySetting{WorkspaceID: 1, ShortLinkID: link.ID, IPPolicy: model.LinkIPPolicyOff, BotPolicy: model.LinkBotPolicyRecordOnly},
+		&model.ABTest{WorkspaceID: 1, ShortLinkID: link.ID, Name: "draft", Status: "draft"},
+	} {
+		if err := db.Create(value).Error; err != nil {
+			t.Fatalf("seed relation: %v", err)
+		}
+	}
+	// 早于短网址单独删除的路由，恢复时不应被一并还原
+	if err := db.Model(&model.LinkRoute{}).Where("id = ?", removedRoute.ID).
+		Update("deleted_at", time.Now().Add(-time.Hour)).Error; err != nil {
+		t.Fatalf("delete old route: %v", err)
+	}
+
+	if err := shortLinkSvc.DeleteShortLinkInWorkspace(link.ID, 1); err != nil {
+		t.Fatalf("delete short link: %v", err)
+	}
+	trash, err := shortLinkSvc.GetTrashListInWorkspace(&dto.ShortLinkTrashListRequest{}, 1)
+	if err != nil {
+		t.Fatalf("list trash: %v", err)
+	}
+	if trash.Total != 1 || trash.List[0].ID != link.ID || trash.List[0].PurgeAt == nil {
+		t.Fatalf("deleted short link should be listed in trash: %+v", trash)
+	}
+	if other, err := shortLinkSvc.GetTrashListInWorkspace(&dto.ShortLinkTrashListRequest{}, 2); err != nil || other.Total != 0 {
+		t.Fatalf("trash should be scoped to workspace, got %+v err=%v", other, err)
+	}
+	var liveRoutes int64
+	db.Model(&model.LinkRoute{}).Where("short_link_id = ?", link.ID).Count(&liveRoutes)
+	if liveRoutes != 0 {
+		t.Fatalf("routes should be trashed with the short link, got %d", liveRoutes)
+	}
+
+	restored, err := shortLinkSvc.RestoreShortLinkInWorkspace(link.ID, 1)
+	if err != nil {
+		t.Fatalf("restore short link: %v", err)
+	}
+	if restored.ID != link.ID || restored.IsActive {
+		t.Fatalf("restored short link should stay inactive: %+v", restored)
+	}
+	var routes []model.LinkRoute
+	if err := db.Where("short_link_id = ?", link.ID).Find(&routes).Error; err != nil {
+		t.Fatalf("load routes: %v", err)
+	}
+	if len(routes) != 1 || routes[0].ID != route.ID {
+		t.Fatalf("only routes trashed with the link should be restored, got %+v", routes)
+	}
+	var settings, abTests int64
+	db.Model(&model.LinkSecuritySetting{}).Where("short_link_id = ?", link.ID).Count(&settings)
+	db.Model(&model.ABTest{}).Where("short_link_id = ?", link.ID).Count(&abTests)
+	if settings != 1 || abTests != 1 {
+		t.Fatalf("security settings and ab tests should be restored, got %d/%d", settings, abTests)
+	}
+
+	if err := shortLinkSvc.DeleteShortLinkInWorkspace(link.ID, 1); err != nil {
+		t.Fatalf("delete short link again: %v", err)
+	}
+	seedBatchShortLink(t, db, domain.ID, 1, "trash-restore", true)
+	if _, err := shortLinkSvc.RestoreShortLinkInWorkspace(link.ID, 1); err == nil || !strings.Contains(err.Error(), "已被其他短网址占用") {
+		t.Fatalf("expected short code conflict on restore, got %v", err)
+	}
+
+	alert := model.TrafficAlert{WorkspaceID: 1, ShortLinkID: link.ID, AlertType: model.TrafficAlertSpike}
+	if err := db.Create(&alert).Error; err != nil {
+		t.Fatalf("seed traffic alert: %v", err)
+	}
+	for _, value := range []any{
+		&model.Conversion{WorkspaceID: 1, ShortLinkID: link.ID, ClickID: "c1", Event: "signup", EventID: "signup:c1", Source: model.ConversionSourcePostback, OccurredAt: time.Now()},
+		&model.ClickRollup{WorkspaceID: 1, ShortLinkID: link.ID, Granularity: model.ClickRollupGranularityDay, BucketStart: time.Now().Truncate(24 * time.Hour), Dimension: model.ClickRollupDimensionTotal, Clicks: 1},
+		&model.LinkSecurityEvent{WorkspaceID: 1, ShortLinkID: link.ID, EventType: "ip_blocked"},
+		&model.TrafficAlertDelivery{AlertID: alert.ID, ChannelID: 1, Status: "pending"},
+	} {
+		if err := db.Create(value).Error; err != nil {
+			t.Fatalf("seed purge relation: %v", err)
+		}
+	}
+
+	if err := shortLinkSvc.PurgeShortLinkInWorkspace(link.ID, 1); err != nil {
+		t.Fatalf("purge short link: %v", err)
+	}
+	for _, relation := range []any{&model.Conversion{}, &model.ClickRollup{}, &model.LinkSecurityEvent{}, &model.TrafficAlert{}} {
+		var count int64
+		db.Model(relation).Where("short_link_id = ?", link.ID).Count(&count)
+		if count != 0 {
+			t.Fatalf("purged short link should remove %T rows, got %d", relation, count)
+		}
+	}
+	var deliveries int64
+	db.Model(&model.TrafficAlertDelivery{}).Where("alert_id = ?", alert.ID).Count(&deliveries)
+	if deliveries != 0 {
+		t.Fatalf("purged short link should remove alert deliveries, got %d", deliveries)
+	}
+	var remaining int64
+	db.Unscoped().Model(&model.ShortLink{}).Where("id = ?", link.ID).Count(&remaining)
+	if remaining != 0 {
+		t.Fatalf("purged short link should be removed permanently, got %d", remaining)
+	}
+	db.Unscoped().Model(&model.LinkRoute{}).Where("short_link_id = ?", link.ID).Count(&remaining)
+	if remaining != 0 {
+		t.Fatalf("purged short link routes should be removed permanently, got %d", remaining)
+	}
+	if err := shortLinkSvc.PurgeShortLinkInWorkspace(link.ID, 1); err == nil || !strings.Contains(err.Error(), "不存在") {
+		t.Fatalf("expected missing trash entry error, got %v", err)
+	}
+
+	expired := seedBatchShortLink(t, db, domain.ID, 1, "trash-expired", false)
+	recent := seedBatchShortLink(t, db, domain.ID, 1, "trash-recent", false)
+	for _, id := range []uint64{expired.ID, recent.ID} {
+		if err := shortLinkSvc.DeleteShortLinkInWorkspace(id, 1); err != nil {
+			t.Fatalf("delete short link %d: %v", id, err)
+		}
+	}
+	if err := db.Unscoped().Model(&model.ShortLink{}).Where("id = ?", expired.ID).
+		Update("deleted_at", time.Now().AddDate(0, 0, -defaultTrashRetentionDays-1)).Error; err != nil {
+		t.Fatalf("age trashed short link: %v", err)
+	}
+	purged, err := shortLinkSvc.PurgeExpiredTrash()
+	if err != nil || purged != 1 {
+		t.Fatalf("expected one expired trash entry to be purged, got %d err=%v", purged, err)
+	}
+	if _, err := shortLinkSvc.shortLinkDao.FindTrashedByIDInWorkspace(recent.ID, 1); err != nil {
+		t.Fatalf("recent trash entry should be kept: %v", err)
+	}
+}
+
+func TestCloneShortLinkInWorkspace(t *testing.T) {
+	helper := newShortLinkRegressionHelper(t)
+	db := helper.GetDatabase()
+	shortLinkSvc := NewShortLinkService(helper, context.Background())
+
+	seedBatchShortLinkDomain(t, db)
+	campaign := model.Campaign{WorkspaceID: 1, Name: "Clone", Status: model.CampaignStatusActive}
+	tag := model.Tag{WorkspaceID: 1, Name: "clone", Color: "#2563eb"}
+	for _, value := range []any{&campaign, &tag} {
+		if err := db.Create(value).Error; err != nil {
+			t.Fatalf("seed: %v", err)
+		}
+	}
+	password := "secret"
+	source, err := shortLinkSvc.CreateShortLinkInWorkspace(&dto.CreateShortLinkRequest{
+		OriginalURL: "https://example.com/landing?keep=1",
+		Domain:      "batch.dwz.do",
+		CustomCode:  "clone-src",
+		CampaignID:  &campaign.ID,
+		TagIDs:      []uint64{tag.ID},
+		UTMSource:   "newsletter",
+		Security: &dto.LinkSecurityRequest{
+			Password:        &password,
+			PasswordEnabled: boolPtr(true),
+			IPPolicy:        model.LinkIPPolicyBlocklist,
+			IPRules:         []dto.LinkSecurityIPRuleRequest{{CIDR: "203.0.113.0/24"}},
+		},
+	}, "203.0.113.10", 1, 7)
+	if err != nil {
+		t.Fatalf("create source: %v", err)
+	}
+	if _, err := NewLinkRouteService(helper).CreateRoute(source.ID, 1, 7, &dto.LinkRouteRequest{
+		Name:      "cn",
+		TargetURL: "https://example.com/cn",
+		ConditionGroups: []dto.LinkRouteConditionGroupRequest{{Conditions: []dto.LinkRouteConditionRequest{
+			{ConditionType: model.RouteConditionCountry, Operator: model.RouteOperatorEq, ConditionValue: "中国"},
+		}}},
+	}); err != nil {
+		t.Fatalf("create route: %v", err)
+	}
+	abSvc := NewABTestService(helper)
+	abResp, err := abSvc.CreateABTestInWorkspace(&dto.CreateABTestRequest{
+		ShortLinkID:  source.ID,
+		Name:         "split",
+		TrafficSplit: "weighted",
+		Variants: []dto.CreateABTestVariantRequest{
+			{Name: "A", TargetURL: "https://example.com/a", Weight: 60, IsControl: true},
+			{Name: "B", TargetURL: "https://example.com/b", Weight: 40},
+		},
+	}, 1)
+	if err != nil {
+		t.Fatalf("create ab test: %v", err)
+	}
+	if _, err := abSvc.StartABTestInWorkspace(abResp.ID, &dto.StartABTestRequest{}, 1); err != nil {
+		t.Fatalf("start ab test: %v", err)
+	}
+
+	if _, err := shortLinkSvc.CloneShortLinkInWorkspace(source.ID, &dto.CloneShortLinkRequest{CustomCode: "clone-bad", Include: []string{"routes", "notes"}}, "203.0.113.11", 1, 7); err == nil || !strings.Contains(err.Error(), "无效的复制内容") {
+		t.Fatalf("unknown include must be rejected, got %v", err)
+	}
+	full, err := shortLinkSvc.CloneShortLinkInWorkspace(source.ID, &dto.CloneShortLinkRequest{CustomCode: "clone-full"}, "203.0.113.11", 1, 7)
+	if err != nil {
+		t.Fatalf("clone short link: %v", err)
+	}
+	if full.ID == source.ID || full.ShortCode != "clone-full" || full.Domain != "batch.dwz.do" {
+		t.Fatalf("clone should get a new code on the source domain: %+v", full)
+	}
+	if full.CampaignID == nil || *full.CampaignID != campaign.ID || len(full.Tags) != 1 || full.UTMSource != "newsletter" ||
+		!strings.Contains(full.OriginalURL, "utm_source=newsletter") {
+		t.Fatalf("clone should copy campaign, tags and UTM: %+v", full)
+	}
+	var setting model.LinkSecuritySetting
+	if err := db.Where("short_link_id = ?", full.ID).First(&setting).Error; err != nil {
+		t.Fatalf("load cloned security: %v", err)
+	}
+	if setting.PasswordEnabled || setting.PasswordHash != "" || setting.IPPolicy != model.LinkIPPolicyBlocklist {
+		t.Fatalf("clone should copy security without password: %+v", setting)
+	}
+	var ipRules, conditions int64
+	db.Model(&model.LinkSecurityIPRule{}).Where("short_link_id = ?", full.ID).Count(&ipRules)
+	db.Model(&model.LinkRouteCondition{}).
+		Joins("JOIN link_route_condition_groups g ON g.id = link_route_conditions.group_id").
+		Joins("JOIN link_routes r ON r.id = g.route_id").
+		Where("r.short_link_id = ?", full.ID).Count(&conditions)
+	if ipRules != 1 || conditions != 1 {
+		t.Fatalf("clone should copy ip rules and route conditions, got %d/%d", ipRules, conditions)
+	}
+	var clonedTest model.ABTest
+	if err := db.Preload("Variants").Where("short_link_id = ?", full.ID).First(&clonedTest).Error; err != nil {
+		t.Fatalf("load cloned ab test: %v", err)
+	}
+	if clonedTest.Status != "draft" || len(clonedTest.Variants) != 2 {
+		t.Fatalf("clone should copy ab test as draft with variants: %+v", clonedTest)
+	}
+
+	partial, err := shortLinkSvc.CloneShortLinkInWorkspace(source.ID, &dto.CloneShortLinkRequest{
+		CustomCode: "clone-partial",
+		Include:    []string{CloneIncludeRoutes},
+	}, "203.0.113.11", 1, 7)
+	if err != nil {
+		t.Fatalf("clone selected resources: %v", err)
+	}
+	if partial.CampaignID != nil || len(partial.Tags) != 0 || partial.UTMSource != "" ||
+		strings.Contains(partial.OriginalURL, "utm_source") || !strings.Contains(partial.OriginalURL, "keep=1") {
+		t.Fatalf("partial clone should skip campaign, tags and UTM: %+v", partial)
+	}
+	if !partial.RoutingEnabled || partial.SecurityEnabled {
+		t.Fatalf("partial clone should only copy routes: %+v", partial)
+	}
+
+	if _, err := shortLinkSvc.CloneShortLinkInWorkspace(source.ID, &dto.CloneShortLinkRequest{CustomCode: "clone-full"}, "", 1, 7); err == nil ||
+		!strings.Contains(err.Error(), "已存在") {
+		t.Fatalf("expected custom code conflict, got %v", err)
+	}
+
+	crossReq := &dto.CloneShortLinkRequest{Domain: "other.dwz.do", CustomCode: "clone-cross", TargetWorkspaceID: 2}
+	if _, err := shortLinkSvc.CloneShortLinkInWorkspace(source.ID, crossReq, "", 1, 7); err == nil || !strings.Contains(err.Error(), "无权限") {
+		t.Fatalf("expected target workspace permission error, got %v", err)
+	}
+	for _, value := range []any{
+		&model.WorkspaceMember{WorkspaceID: 2, UserID: 7, Role: model.WorkspaceRoleMember, Status: 1},
+		&model.Domain{WorkspaceID: 2, Protocol: "https", Domain: "other.dwz.do", IsActive: true},
+	} {
+		if err := db.Create(value).Error; err != nil {
+			t.Fatalf("seed target workspace: %v", err)
+		}
+	}
+	cross, err := shortLinkSvc.CloneShortLinkInWorkspace(source.ID, crossReq, "", 1, 7)
+	if err != nil {
+		t.Fatalf("clone into other workspace: %v", err)
+	}
+	if cross.WorkspaceID != 2 || cross.CampaignID != nil || len(cross.Tags) != 0 || !cross.RoutingEnabled {
+		t.Fatalf("cross-workspace clone should drop campaign and tags: %+v", cross)
+	}
+
+	// 自定义字段属于源工作区，引用它的路由不能复制到其他工作区
+	var group model.LinkRouteConditionGroup
+	if err := db.Joins("JOIN link_routes r ON r.id = link_route_condition_groups.route_id").
+		Where("r.short_link_id = ?", source.ID).First(&group).Error; err != nil {
+		t.Fatalf("load source route group: %v", err)
+	}
+	if err := db.Create(&model.LinkRouteCondition{GroupID: group.ID, ConditionType: model.RouteConditionCustomField, Operator: model.RouteOperatorEq, ConditionKey: "region", ConditionValue: "east", Position: 1}).Error; err != nil {
+		t.Fatalf("seed custom field condition: %v", err)
+	}
+	crossReq.CustomCode = "clone-cross-fields"
+	if _, err := shortLinkSvc.CloneShortLinkInWorkspace(source.ID, crossReq, "", 1, 7); err == nil || !strings.Contains(err.Error(), "路由 cn 使用了自定义字段条件") {
+		t.Fatalf("expected custom field routes to be rejected across workspaces, got %v", err)
+	}
+	crossReq.Include = []string{CloneIncludeTags}
+	if _, err := shortLinkSvc.CloneShortLinkInWorkspace(source.ID, crossReq, "", 1, 7); err != nil {
+		t.Fatalf("cross-workspace clone without routes: %v", err)
+	}
+	if _, err := shortLinkSvc.CloneShortLinkInWorkspace(source.ID, &dto.CloneShortLinkRequest{CustomCode: "clone-fields", Include: []string{CloneIncludeRoutes}}, "", 1, 7); err != nil {
+		t.Fatalf("same-workspace clone keeps custom field routes: %v", err)
+	}
+}
+
+func TestIdempotencyKeyReplay(t *testing.T) {
+	helper := newShortLinkRegressionHelper(t)
+	db := helper.GetDatabase()
+	idempotencySvc := NewIdempotencyService(helper)
+
+	if record, replay, err := idempotencySvc.Begin(1, IdempotencyScopeCreateShortLink, "", nil); err != nil || record != nil || replay {
+		t.Fatalf("empty key should disable idempotency, got %+v %v %v", record, replay, err)
+	}
+
+	req := &dto.CreateShortLinkRequest{OriginalURL: "https://example.com/a", Domain: "batch.dwz.do"}
+	record, replay, err := idempotencySvc.Begin(1, IdempotencyScopeCreateShortLink, "retry-1", req)
+	if err != nil || record == nil || replay {
+		t.Fatalf("begin: %+v %v %v", record, replay, err)
+	}
+	if _, _, err := idempotencySvc.Begin(1, IdempotencyScopeCreateShortLink, "retry-1", req); err == nil || !strings.Contains(err.Error(), "正在处理中") {
+		t.Fatalf("expected in-progress error, got %v", err)
+	}
+	if err := idempotencySvc.Complete(record, dto.ShortLinkResponse{ID: 42, ShortCode: "abc"}); err != nil {
+		t.Fatalf("complete: %v", err)
+	}
+
+	replayed, replay, err := idempotencySvc.Begin(1, IdempotencyScopeCreateShortLink, "retry-1", req)
+	if err != nil || !replay {
+		t.Fatalf("expected replay, got %v %v", replay, err)
+	}
+	var response dto.ShortLinkResponse
+	if err := json.Unmarshal([]byte(replayed.ResponseBody), &response); err != nil || response.ID != 42 {
+		t.Fatalf("unexpected replayed response %q: %v", replayed.ResponseBody, err)
+	}
+
+	other := &dto.CreateShortLinkRequest{OriginalURL: "https://example.com/b", Domain: "batch.dwz.do"}
+	if _, _, err := idempotencySvc.Begin(1, IdempotencyScopeCreateShortLink, "retry-1", other); err == nil || !strings.Contains(err.Error(), "不同的请求参数") {
+		t.Fatalf("expected payload mismatch error, got %v", err)
+	}
+	// 作用域与工作区互相隔离
+	if _, replay, err := idempotencySvc.Begin(2, IdempotencyScopeCreateShortLink, "retry-1", req); err != nil || replay {
+		t.Fatalf("other workspace should start fresh, got %v %v", replay, err)
+	}
+	if _, replay, err := idempotencySvc.Begin(1, IdempotencyScopeBatchCreateShortLinks, "retry-1", req); err != nil || replay {
+		t.Fatalf("other scope should start fresh, got %v %v", replay, err)
+	}
+
+	failed, _, err := idempotencySvc.Begin(1, IdempotencyScopeCreateShortLink, "retry-2", req)
+	if err != nil {
+		t.Fatalf("begin retry-2: %v", err)
+	}
+	if err := idempotencySvc.Release(failed); err != nil {
+		t.Fatalf("release: %v", err)
+	}
+	if _, replay, err := idempotencySvc.Begin(1, IdempotencyScopeCreateShortLink, "retry-2", req); err != nil || replay {
+		t.Fatalf("released key should be reusable, got %v %v", replay, err)
+	}
+
+	if err := db.Model(&model.IdempotencyKey{}).Where("idempotency_key = ?", "retry-1").
+		Update("expires_at", time.Now().Add(-time.Minute)).Error; err != nil {
+		t.Fatalf("expire key: %v", err)
+	}
+	if purged, err := idempotencySvc.PurgeExpired(); err != nil || purged != 3 {
+		t.Fatalf("expected 3 expired keys purged, got %d %v", purged, err)
+	}
+
+	// 写入失败但并非键冲突时返回原始错误，不能当作请求处理中
+	writeErr := errors.New("disk full")
+	if err := db.Callback().Create().Before("gorm:create").Register("test:fail_idempotency", func(tx *gorm.DB) {
+		if tx.Statement.Table == "idempotency_keys" {
+			_ = tx.AddError(writeErr)
+		}
+	}); err != nil {
+		t.Fatalf("register callback: %v", err)
+	}
+	defer db.Callback().Create().Remove("test:fail_idempotency")
+	if _, _, err := idempotencySvc.Begin(1, IdempotencyScopeCreateShortLink, "retry-3", req); !errors.Is(err, writeErr) {
+		t.Fatalf("expected write error, got %v", err)
+	}
+}
+
+func TestShortLinkExternalIDUpsertAndLookup(t *testing.T) {
+	helper := newShortLinkRegressionHelper(t)
+	db := helper.GetDatabase()
+	shortLinkSvc := NewShortLinkService(helper, context.Background())
+	seedBatchShortLinkDomain(t, db)
+	expireAt := time.Now().Add(48 * time.Hour).Truncate(time.Second)
+
+	created, isNew, err := shortLinkSvc.UpsertShortLinkByExternalIDInWorkspace("crm-1001", &dto.CreateShortLinkRequest{
+		OriginalURL: "https://example.com/v1",
+		Domain:      "batch.dwz.do",
+		CustomCode:  "ext-v1",
+		Title:       "v1",
+		FallbackURL: "https://example.com/fallback",
+		ExpireAt:    &expireAt,
+		Routes: []dto.LinkRouteRequest{{
+			Name:      "old",
+			TargetURL: "https://example.com/old",
+			ConditionGroups: []dto.LinkRouteConditionGroupRequest{{
+				Conditions: []dto.LinkRouteConditionRequest{{ConditionType: model.RouteConditionDeviceType, Operator: model.RouteOperatorEq, ConditionValue: "mobile"}},
+			}},
+		}},
+	}, "203.0.113.10", 1, 7)
+	if err != nil || !isNew {
+		t.Fatalf("upsert create: %v %v", isNew, err)
+	}
+	if created.ExternalID == nil || *created.ExternalID != "crm-1001" {
+		t.Fatalf("external id not stored: %+v", created)
+	}
+
+	updated, isNew, err := shortLinkSvc.UpsertShortLinkByExternalIDInWorkspace("crm-1001", &dto.CreateShortLinkRequest{
+		OriginalURL: "https://example.com/v2",
+		Domain:      "batch.dwz.do",
+		CustomCode:  "ext-v2",
+		Title:       "v2",
+		Routes: []dto.LinkRouteRequest{{
+			Name:      "new",
+			TargetURL: "https://example.com/new",
+			ConditionGroups: []dto.LinkRouteConditionGroupRequest{{
+				Conditions: []dto.LinkRouteConditionRequest{{ConditionType: model.RouteConditionDeviceType, Operator: model.RouteOperatorEq, ConditionValue: "desktop"}},
+			}},
+		}},
+	}, "203.0.113.10", 1, 7)
+	if err != nil || isNew {
+		t.Fatalf("upsert update: %v %v", isNew, err)
+	}
+	if updated.ID != created.ID || updated.OriginalURL != "https://example.com/v2" || updated.Title != "v2" || updated.ShortCode != created.ShortCode {
+		t.Fatalf("upsert should update the existing link in place: %+v", updated)
+	}
+	// 未填写的回退地址与过期时间保持原值，路由整体替换
+	var stored model.ShortLink
+	if err := db.First(&stored, created.ID).Error; err != nil {
+		t.Fatalf("load upserted link: %v", err)
+	}
+	if stored.FallbackURL != "https://example.com/fallback" || stored.ExpireAt == nil || !stored.ExpireAt.Equal(expireAt) {
+		t.Fatalf("omitted fields must be preserved: fallback=%v expire=%v", stored.FallbackURL, stored.ExpireAt)
+	}
+	var routes []model.LinkRoute
+	if err := db.Where("short_link_id = ?", created.ID).Find(&routes).Error; err != nil || len(routes) != 1 || routes[0].Name != "new" {
+		t.Fatalf("routes must be replaced: %+v %v", routes, err)
+	}
+	// 路由写入失败时短网址的修改一并回滚
+	if err := db.Migrator().DropTable("link_route_conditions"); err != nil {
+		t.Fatalf("drop conditions table: %v", err)
+	}
+	if _, _, err := shortLinkSvc.UpsertShortLinkByExternalIDInWorkspace("crm-1001", &dto.CreateShortLinkRequest{
+		OriginalURL: "https://example.com/broken",
+		Title:       "broken",
+		Routes: []dto.LinkRouteRequest{{
+			Name:      "broken",
+			TargetURL: "https://example.com/broken",
+			ConditionGroups: []dto.LinkRouteConditionGroupRequest{{
+				Conditions: []dto.LinkRouteConditionRequest{{ConditionType: model.RouteConditionDeviceType, Operator: model.RouteOperatorEq, ConditionValue: "tablet"}},
+			}},
+		}},
+	}, "203.0.113.10", 1, 7); err == nil {
+		t.Fatal("expected route write failure")
+	}
+	if err := db.AutoMigrate(&model.LinkRouteCondition{}); err != nil {
+		t.Fatalf("restore conditions table: %v", err)
+	}
+	if err := db.First(&stored, created.ID).Error; err != nil || stored.OriginalURL != "https://example.com/v2" || stored.Title != "v2" {
+		t.Fatalf("link update must roll back with routes: %+v %v", stored, err)
+	}
+	if err := db.Where("short_link_id = ?", created.ID).Find(&routes).Error; err != nil || len(routes) != 1 || routes[0].Name != "new" {
+		t.Fatalf("routes must be kept on failure: %+v %v", routes, err)
+	}
+
+	found, err := shortLinkSvc.GetShortLinkByExternalIDInWorkspace("crm-1001", 1)
+	if err != nil || found.ID != created.ID {
+		t.Fatalf("lookup by external id: %+v %v", found, err)
+	}
+	if _, err := shortLinkSvc.GetShortLinkByExternalIDInWorkspace("crm-1001", 2); err == nil || !strings.Contains(err.Error(), "短网址不存在") {
+		t.Fatalf("external id must be scoped to workspace, got %v", err)
+	}
+
+	if _, err := shortLinkSvc.CreateShortLinkInWorkspace(&dto.CreateShortLinkRequest{
+		OriginalURL: "https://example.com/dup",
+		Domain:      "batch.dwz.do",
+		CustomCode:  "ext-dup",
+		ExternalID:  "crm-1001",
+	}, "", 1, 7); err == nil || !strings.Contains(err.Error(), "外部标识已存在") {
+		t.Fatalf("expected duplicate external id error, got %v", err)
+	}
+	other, err := shortLinkSvc.CreateShortLinkInWorkspace(&dto.CreateShortLinkRequest{
+		OriginalURL: "https://example.com/other",
+		Domain:      "batch.dwz.do",
+		CustomCode:  "ext-other",
+		ExternalID:  "crm-1002",
+	}, "", 1, 7)
+	if err != nil {
+		t.Fatalf("create with external id: %v", err)
+	}
+	taken := "crm-1001"
+	if _, err := shortLinkSvc.UpdateShortLinkInWorkspace(other.ID, &dto.UpdateShortLinkRequest{ExternalID: &taken}, 1, 7); err == nil || !strings.Contains(err.Error(), "外部标识已存在") {
+		t.Fatalf("expected duplicate external id on update, got %v", err)
+	}
+	cleared := ""
+	clearedResp, err := shortLinkSvc.UpdateShortLinkInWorkspace(other.ID, &dto.UpdateShortLinkRequest{ExternalID: &cleared}, 1, 7)
+	if err != nil || clearedResp.ExternalID != nil {
+		t.Fatalf("empty external id should clear it: %+v %v", clearedResp, err)
+	}
+
+	// 删除后外部标识可被复用，原短网址恢复时冲突
+	if _, err := shortLinkSvc.UpdateShortLinkStatusInWorkspace(created.ID, false, 1, 7); err != nil {
+		t.Fatalf("disable: %v", err)
+	}
+	if err := shortLinkSvc.DeleteShortLinkInWorkspace(created.ID, 1); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	reused, isNew, err := shortLinkSvc.UpsertShortLinkByExternalIDInWorkspace("crm-1001", &dto.CreateShortLinkRequest{
+		OriginalURL: "https://example.com/v3",
+		Domain:      "batch.dwz.do",
+		CustomCode:  "ext-v3",
+	}, "", 1, 7)
+	if err != nil || !isNew || reused.ID == created.ID {
+		t.Fatalf("deleted external id should be reusable: %+v %v %v", reused, isNew, err)
+	}
+	if _, err := shortLinkSvc.RestoreShortLinkInWorkspace(created.ID, 1); err == nil || !strings.Contains(err.Error(), "外部标识已被其他短网址占用") {
+		t.Fatalf("expected external id conflict on restore, got %v", err)
+	}
+}
+
+func TestNormalizeTargetURL(t *testing.T) {
+	cases := map[string]string{
+		"HTTPS://Example.COM:443?b=2&a=1":         "https://example.com/?a=1&b=2",
+		"http://example.com.:80/Path?x=1#frag":    "http://example.com/Path?x=1#frag",
+		"https://example.com:8443/a":              "https://example.com:8443/a",
+		"http://[2001:DB8::1]:80/":                "http://[2001:db8::1]/",
+		"https://example.com/search?q=a+b&q=c%2F": "https://example.com/search?q=a+b&q=c%2F",
+	}
+	for raw, want := range cases {
+		got, err := NormalizeTargetURL(raw)
+		if err != nil || got != want {
+			t.Fatalf("NormalizeTargetURL(%q) = %q, %v; want %q", raw, got, err, want)
+		}
+	}
+	if _, err := NormalizeTargetURL("example.com/no-scheme"); err == nil {
+		t.Fatal("expected error for URL without scheme")
+	}
+}
+
+func TestCreateShortLinkReusesExistingLink(t *testing.T) {
+	helper := newShortLinkRegressionHelper(t)
+	db := helper.GetDatabase()
+	shortLinkSvc := NewShortLinkService(helper, context.Background())
+	domain := seedBatchShortLinkDomain(t, db)
+	workspace := model.Workspace{ID: 1, Slug: "default", Name: "Default", Status: 1}
+	if err := db.Create(&workspace).Error; err != nil {
+		t.Fatalf("seed workspace: %v", err)
+	}
+
+	original, err := shortLinkSvc.CreateShortLinkInWorkspace(&dto.CreateShortLinkRequest{
+		OriginalURL: "https://Example.com/landing?b=2&a=1",
+		Domain:      "batch.dwz.do",
+		CustomCode:  "reuse-src",
+		UTMSource:   "newsletter",
+	}, "", 1, 7)
+	if err != nil {
+		t.Fatalf("create original: %v", err)
+	}
+	if original.Reused {
+		t.Fatal("custom code must never reuse an existing link")
+	}
+
+	reused, err := shortLinkSvc.CreateShortLinkInWorkspace(&dto.CreateShortLinkRequest{
+		OriginalURL: "HTTPS://example.com:443/landing?a=1&b=2",
+		Domain:      "batch.dwz.do",
+		UTMSource:   "newsletter",
+		Reuse:       boolPtr(true),
+	}, "", 1, 7)
+	if err != nil {
+		t.Fatalf("create with reuse flag: %v", err)
+	}
+	if !reused.Reused || reused.ID != original.ID {
+		t.Fatalf("expected existing link %d to be reused, got %+v", original.ID, reused)
+	}
+	if len(reused.IgnoredOptions) != 0 {
+		t.Fatalf("plain reuse should not report ignored options: %v", reused.IgnoredOptions)
+	}
+	maxClicks := int64(5)
+	withOptions, err := shortLinkSvc.CreateShortLinkInWorkspace(&dto.CreateShortLinkRequest{
+		OriginalURL:  "https://example.com/landing?b=2&a=1",
+		Domain:       "batch.dwz.do",
+		UTMSource:    "newsletter",
+		RedirectCode: 301,
+		Security:     &dto.LinkSecurityRequest{MaxClicks: &maxClicks},
+		Reuse:        boolPtr(true),
+	}, "", 1, 7)
+	if err != nil || withOptions.ID != original.ID || strings.Join(withOptions.IgnoredOptions, ",") != "redirect_code,security" {
+		t.Fatalf("reuse must report ignored options: %+v %v", withOptions, err)
+	}
+	if _, err := NewCustomFieldService(helper).Create(1, 7, &dto.CustomFieldRequest{Key: "owner", Name: "负责人", FieldType: model.CustomFieldTypeString}); err != nil {
+		t.Fatalf("create custom field: %v", err)
+	}
+	if _, err := shortLinkSvc.CreateShortLinkInWorkspace(&dto.CreateShortLinkRequest{
+		OriginalURL:  "https://example.com/landing?a=1&b=2",
+		Domain:       "batch.dwz.do",
+		UTMSource:    "newsletter",
+		Reuse:        boolPtr(true),
+		CustomFields: map[string]any{"owner": "alice"},
+	}, "", 1, 7); err == nil || !strings.Contains(err.Error(), "自定义字段") {
+		t.Fatalf("custom fields must not be dropped on reuse, got %v", err)
+	}
+	if values := shortLinkSvc.customFieldService.ValuesMap(original.ID); values != nil {
+		t.Fatalf("reused link must keep its custom fields: %v", values)
+	}
+
+	// 工作区策略默认关闭，开启后无需请求参数
+	if shortLinkSvc.shouldReuseLink(&dto.CreateShortLinkRequest{}, 1) {
+		t.Fatal("reuse should be opt-in per workspace")
+	}
+	if _, err := NewWorkspaceService(helper).UpdateWorkspace(1, &dto.UpdateWorkspaceRequest{Name: "Default", ReuseExistingLinks: boolPtr(true)}); err != nil {
+		t.Fatalf("enable workspace policy: %v", err)
+	}
+	if !shortLinkSvc.shouldReuseLink(&dto.CreateShortLinkRequest{}, 1) {
+		t.Fatal("workspace policy should enable reuse")
+	}
+	if shortLinkSvc.shouldReuseLink(&dto.CreateShortLinkRequest{Reuse: boolPtr(false)}, 1) {
+		t.Fatal("request flag should override workspace policy")
+	}
+	if shortLinkSvc.shouldReuseLink(&dto.CreateShortLinkRequest{ExternalID: "crm-1"}, 1) {
+		t.Fatal("external id should disable reuse")
+	}
+	byPolicy, err := shortLinkSvc.CreateShortLinkInWorkspace(&dto.CreateShortLinkRequest{
+		OriginalURL: "https://example.com/landing?a=1&b=2",
+		Domain:      "batch.dwz.do",
+		UTMSource:   "newsletter",
+	}, "", 1, 7)
+	if err != nil || !byPolicy.Reused || byPolicy.ID != original.ID {
+		t.Fatalf("expected reuse via workspace policy, got %+v %v", byPolicy, err)
+	}
+
+	// UTM、域名不同或原短网址不可访问时不复用
+	candidate := &model.ShortLink{
+		WorkspaceID: 1,
+		Domain:      "batch.dwz.do",
+		URLHash:     targetURLFingerprint("https://example.com/landing?a=1&b=2&utm_source=newsletter"),
+		UTMSource:   "newsletter",
+	}
+	if _, err := shortLinkSvc.shortLinkDao.FindReusableInWorkspace(candidate, time.Now()); err != nil {
+		t.Fatalf("expected candidate to match: %v", err)
+	}
+	for name, mutate := range map[string]func(*model.ShortLink){
+		"utm":    func(link *model.ShortLink) { link.UTMSource = "ads" },
+		"domain": func(link *model.ShortLink) { link.Domain = "other.dwz.do" },
+		"url":    func(link *model.ShortLink) { link.URLHash = targetURLFingerprint("https://example.com/other") },
+	} {
+		changed := *candidate
+		mutate(&changed)
+		if _, err := shortLinkSvc.shortLinkDao.FindReusableInWorkspace(&changed, time.Now()); !errors.Is(err, gorm.ErrRecordNotFound) {
+			t.Fatalf("%s mismatch should not reuse, got %v", name, err)
+		}
+	}
+	if _, err := shortLinkSvc.UpdateShortLinkStatusInWorkspace(original.ID, false, 1, 7); err != nil {
+		t.Fatalf("disable original: %v", err)
+	}
+	if _, err := shortLinkSvc.shortLinkDao.FindReusableInWorkspace(candidate, time.Now()); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("inactive link should not be reused, got %v", err)
+	}
+
+	// 历史短网址由迁移一次性补算指纹
+	legacy := seedBatchShortLink(t, db, domain.ID, 1, "legacy", true)
+	if updated, err := migrations.BackfillShortLinkURLHashes(db); err != nil || updated != 1 {
+		t.Fatalf("expected one legacy link backfilled, got %d %v", updated, err)
+	}
+	var backfilled model.ShortLink
+	if err := db.First(&backfilled, legacy.ID).Error; err != nil || backfilled.URLHash != targetURLFingerprint(legacy.OriginalURL) {
+		t.Fatalf("legacy link hash not backfilled: %q %v", backfilled.URLHash, err)
+	}
+}
+
+func TestSecurityScanMatchesNormalizedURL(t *testing.T) {
+	helper := newShortLinkRegressionHelper(t)
+	securitySvc := NewLinkSecurityService(helper)
+	for _, req := range []*dto.SecurityURLRuleRequest{
+		{RuleType: model.SecurityRuleTypeDomain, Action: model.SecurityRuleActionBlock, Pattern: "evil.example"},
+		{RuleType: model.SecurityRuleTypeKeyword, Action: model.SecurityRuleActionBlock, Pattern: "a=1&b=2"},
+	} {
+		if _, err := securitySvc.CreateURLRule(1, 1, req); err != nil {
+			t.Fatalf("create rule: %v", err)
+		}
+	}
+	for _, rawURL := range []string{
+		"https://EVIL.example.:443/path",
+		"https://safe.example/path?b=2&a=1",
+	} {
+		if result := securitySvc.ScanURL(1, rawURL); result.Safe {
+			t.Fatalf("expected %q to be blocked after normalization", rawURL)
+		}
+	}
+	if result := securitySvc.ScanURL(1, "https://safe.example/path?a=2"); !result.Safe {
+		t.Fatalf("unexpected block: %s", result.Reason)
+	}
+}
+
+func TestParseShortLinkQuery(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.Local)
+	valid := []string{
+		`tag:promo AND clicks>100 AND created:>2026-01-01 AND target:*example.com*`,
+		`(tag:a OR tag:b) -is:inactive`,
+		`title:"spring sale" NOT security:password`,
+		`utm_source:newsletter expires:<=2026-12-31T00:00:00Z`,
+		`"AND"`,
+	}
+	for _, input := range valid {
+		query, err := dao.ParseShortLinkQuery(input)
+		if err != nil {
+			t.Fatalf("parse %q: %v", input, err)
+		}
+		if _, _, err := query.Build(now); err != nil {
+			t.Fatalf("build %q: %v", input, err)
+		}
+	}
+
+	// 仅日期的取值按 now 所在时区划分整天
+	tokyo := time.FixedZone("UTC+9", 9*3600)
+	dayQuery, _ := dao.ParseShortLinkQuery(`created:2026-03-01`)
+	if _, args, err := dayQuery.Build(now.In(tokyo)); err != nil || len(args) != 2 ||
+		!args[0].(time.Time).Equal(time.Date(2026, 3, 1, 0, 0, 0, 0, tokyo)) || !args[1].(time.Time).Equal(time.Date(2026, 3, 2, 0, 0, 0, 0, tokyo)) {
+		t.Fatalf("date-only values must use the query location: %v %v", args, err)
+	}
+
+	invalid := []string{
+		`password_hash:x`,
+		`clicks>abc`,
+		`created:>yesterday`,
+		`(tag:a`,
+		`tag:a OR`,
+		`is:unknown`,
+		`title:"unterminated`,
+	}
+	for _, input := range invalid {
+		query, err := dao.ParseShortLinkQuery(input)
+		if err == nil {
+			_, _, err = query.Build(now)
+		}
+		if err == nil || !strings.HasPrefix(err.Error(), "无效的") {
+			t.Fatalf("expected invalid query error for %q, got %v", input, err)
+		}
+	}
+
+	if query, err := dao.ParseShortLinkQuery("   "); err != nil || query != nil {
+		t.Fatalf("blank query should be ignored: %+v %v", query, err)
+	}
+	if _, err := dao.ParseShortLinkSort("-password_hash"); err == nil {
+		t.Fatal("sort on unknown column must be rejected")
+	}
+}
+
+func TestShortLinkListQuerySortAndCursor(t *testing.T) {
+	helper := newShortLinkRegressionHelper(t)
+	db := helper.GetDatabase()
+	shortLinkSvc := NewShortLinkService(helper, context.Background())
+	domain := seedBatchShortLinkDomain(t, db)
+
+	promo := model.Tag{WorkspaceID: 1, Name: "promo"}
+	if err := db.Create(&promo).Error; err != nil {
+		t.Fatalf("seed tag: %v", err)
+	}
+	created := time.Date(2026, 2, 1, 10, 0, 0, 0, time.Local)
+	links := make([]model.ShortLink, 0, 5)
+	for i, clicks := range []int64{50, 150, 300, 120, 5} {
+		link := seedBatchShortLink(t, db, domain.ID, 1, fmt.Sprintf("q%d", i), true)
+		createdAt := created
+		if i == 4 {
+			createdAt = time.Date(2025, 12, 1, 10, 0, 0, 0, time.Local)
+		}
+		target := "https://shop.example.com/item"
+		if i == 3 {
+			target = "https://other.test/item"
+		}
+		if err := db.Model(&link).Updates(map[string]any{"click_count": clicks, "created_at": createdAt, "original_url": target}).Error; err != nil {
+			t.Fatalf("update seed link: %v", err)
+		}
+		if i != 0 {
+			if err := db.Create(&model.ShortLinkTag{ShortLinkID: link.ID, TagID: promo.ID}).Error; err != nil {
+				t.Fatalf("tag seed link: %v", err)
+			}
+		}
+		links = append(links, link)
+	}
+	seedBatchShortLink(t, db, domain.ID, 2, "q-other-ws", true)
+	if err := db.Create(&model.LinkSecuritySetting{WorkspaceID: 1, ShortLinkID: links[2].ID, PasswordEnabled: true}).Error; err != nil {
+		t.Fatalf("seed security setting: %v", err)
+	}
+
+	list := func(req dto.ShortLinkListRequest) *dto.ShortLinkListResponse {
+		t.Helper()
+		if req.Page == 0 {
+			req.Page = 1
+		}
+		if req.PageSize == 0 {
+			req.PageSize = 10
+		}
+		resp, err := shortLinkSvc.GetShortLinkListInWorkspace(&req, 1)
+		if err != nil {
+			t.Fatalf("list %+v: %v", req, err)
+		}
+		return resp
+	}
+	codes := func(resp *dto.ShortLinkListResponse) string {
+		result := make([]string, 0, len(resp.List))
+		for _, item := range resp.List {
+			result = append(result, item.ShortCode)
+		}
+		return strings.Join(result, ",")
+	}
+
+	resp := list(dto.ShortLinkListRequest{Q: "tag:promo AND clicks>100 AND created:>2026-01-01 AND target:*example.com*", Sort: "-clicks"})
+	if got := codes(resp); got != "q2,q1" || resp.Total != 2 {
+		t.Fatalf("unexpected query result: %s total=%d", got, resp.Total)
+	}
+	resp = list(dto.ShortLinkListRequest{Q: "(clicks<10 OR target:*other.test*) -code:q4", Sort: "id"})
+	if got := codes(resp); got != "q3" {
+		t.Fatalf("unexpected OR/NOT result: %s", got)
+	}
+	resp = list(dto.ShortLinkListRequest{Q: "security:password", Sort: "id"})
+	if got := codes(resp); got != "q2" {
+		t.Fatalf("unexpected security result: %s", got)
+	}
+	// 未指定时区时按工作区时区解析日期
+	if err := db.Save(&model.Workspace{ID: 1, Slug: "default", Name: "Default", Status: 1, Timezone: "Asia/Tokyo"}).Error; err != nil {
+		t.Fatalf("seed workspace: %v", err)
+	}
+	zoned := &dto.ShortLinkListRequest{Page: 1, PageSize: 10, Q: "created:2026-02-01"}
+	if _, err := shortLinkSvc.GetShortLinkListInWorkspace(zoned, 1); err != nil || zoned.Location == nil || zoned.Location.String() != "Asia/Tokyo" {
+		t.Fatalf("query must use the workspace timezone: %v %v", zoned.Location, err)
+	}
+	db.Model(&model.Workspace{}).Where("id = ?", 1).Update("timezone", "")
+	if _, err := shortLinkSvc.GetShortLinkListInWorkspace(&dto.ShortLinkListRequest{Page: 1, PageSize: 10, Q: "clicks>>1"}, 1); err == nil || !strings.Contains(err.Error(), "无效的") {
+		t.Fatalf("expected invalid query error, got %v", err)
+	}
+
+	var pages []string
+	cursor := ""
+	for i := 0; i < 5; i++ {
+		resp = list(dto.ShortLinkListRequest{Sort: "-clicks", PageSize: 2, Cursor: cursor})
+		pages = append(pages, codes(resp))
+		if resp.Total != 5 {
+			t.Fatalf("cursor pagination should keep total, got %d", resp.Total)
+		}
+		if resp.NextCursor == "" {
+			break
+		}
+		cursor = resp.NextCursor
+	}
+	if got := strings.Join(pages, "|"); got != "q2,q1|q3,q0|q4" {
+		t.Fatalf("unexpected cursor pages: %s", got)
+	}
+	firstPage := list(dto.ShortLinkListRequest{Sort: "-clicks", PageSize: 2})
+	for _, sort := range []string{"id", "clicks"} {
+		if _, err := shortLinkSvc.GetShortLinkListInWorkspace(&dto.ShortLinkListRequest{Page: 1, PageSize: 2, Sort: sort, Cursor: firstPage.NextCursor}, 1); err == nil || !strings.Contains(err.Error(), "无效的分页游标") {
+			t.Fatalf("cursor must be rejected for sort %s, got %v", sort, err)
+		}
+	}
+
+	// 按时间排序的游标在创建时间相同时按 ID 继续翻页
+	pages = pages[:0]
+	cursor = ""
+	for i := 0; i < 5; i++ {
+		resp = list(dto.ShortLinkListRequest{Sort: "-created", PageSize: 2, Cursor: cursor})
+		pages = append(pages, codes(resp))
+		if resp.NextCursor == "" {
+			break
+		}
+		cursor = resp.NextCursor
+	}
+	if got := strings.Join(pages, "|"); got != "q3,q2|q1,q0|q4" {
+		t.Fatalf("unexpected created cursor pages: %s", got)
+	}
+}
+
+func TestShortLinkFoldersMoveDefaultsAndStatistics(t *testing.T) {
+	helper := newShortLinkRegressionHelper(t)
+	db := helper.GetDatabase()
+	shortLinkSvc := NewShortLinkService(helper, context.Background())
+	folderSvc := NewFolderService(helper)
+	seedBatchShortLinkDomain(t, db)
+
+	product, err := folderSvc.Create(1, 7, &dto.FolderRequest{
+		Name: "Product",
+		Defaults: &dto.FolderDefaults{
+			Domain:    "batch.dwz.do",
+			UTMSource: "folder",
+			Security:  &dto.LinkSecurityRequest{BotPolicy: model.LinkBotPolicyBlockKnownBots},
+		},
+	})
+	if err != nil {
+		t.Fatalf("create folder: %v", err)
+	}
+	team, err := folderSvc.Create(1, 7, &dto.FolderRequest{Name: "Team", ParentID: &product.ID})
+	if err != nil {
+		t.Fatalf("create subfolder: %v", err)
+	}
+	if strings.Join(team.Path, "/") != "Product/Team" {
+		t.Fatalf("unexpected folder path: %v", team.Path)
+	}
+	if _, err := folderSvc.Create(1, 7, &dto.FolderRequest{Name: "Team", ParentID: &product.ID}); err == nil || !strings.Contains(err.Error(), "同名") {
+		t.Fatalf("expected duplicate sibling name error, got %v", err)
+	}
+	if _, err := folderSvc.Update(product.ID, 1, &dto.FolderRequest{Name: "Product", ParentID: &team.ID}); err == nil || !strings.Contains(err.Error(), "子文件夹") {
+		t.Fatalf("expected cycle error, got %v", err)
+	}
+	if _, err := folderSvc.Create(1, 7, &dto.FolderRequest{
+		Name:     "Secret",
+		Defaults: &dto.FolderDefaults{Security: &dto.LinkSecurityRequest{Password: stringPtr("123456")}},
+	}); err == nil {
+		t.Fatal("folder defaults must not store passwords")
+	}
+
+	created, err := shortLinkSvc.CreateShortLinkInWorkspace(&dto.CreateShortLinkRequest{
+		OriginalURL: "https://example.com/product",
+		CustomCode:  "folder-1",
+		FolderID:    &product.ID,
+		UTMMedium:   "email",
+	}, "", 1, 7)
+	if err != nil {
+		t.Fatalf("create in folder: %v", err)
+	}
+	if created.Domain != "batch.dwz.do" || created.FolderID == nil || *created.FolderID != product.ID {
+		t.Fatalf("folder defaults not applied: %+v", created)
+	}
+	if created.UTMSource != "folder" || created.UTMMedium != "email" {
+		t.Fatalf("folder UTM defaults should only fill empty fields: %+v", created)
+	}
+	security, err := shortLinkSvc.linkSecurityService.GetSecurity(created.ID, 1)
+	if err != nil || security == nil || security.BotPolicy != model.LinkBotPolicyBlockKnownBots {
+		t.Fatalf("folder security defaults not applied: %+v %v", security, err)
+	}
+	if _, err := shortLinkSvc.CreateShortLinkInWorkspace(&dto.CreateShortLinkRequest{
+		OriginalURL: "https://example.com/missing",
+		CustomCode:  "folder-missing",
+		FolderID:    uint64Ptr(9999),
+	}, "", 1, 7); err == nil || !strings.Contains(err.Error(), "文件夹不存在") {
+		t.Fatalf("expected missing folder error, got %v", err)
+	}
+
+	var rootIDs []uint64
+	for _, code := range []string{"folder-2", "folder-3"} {
+		resp, err := shortLinkSvc.CreateShortLinkInWorkspace(&dto.CreateShortLinkRequest{
+			OriginalURL: "https://example.com/" + code,
+			Domain:      "batch.dwz.do",
+			CustomCode:  code,
+		}, "", 1, 7)
+		if err != nil {
+			t.Fatalf("create root link: %v", err)
+		}
+		rootIDs = append(rootIDs, resp.ID)
+	}
+	moved, err := shortLinkSvc.MoveShortLinkInWorkspace(rootIDs[0], &team.ID, 1, 7)
+	if err != nil || moved.FolderID == nil || *moved.FolderID != team.ID {
+		t.Fatalf("move link: %+v %v", moved, err)
+	}
+	batch, err := shortLinkSvc.BatchMoveShortLinksInWorkspace([]uint64{rootIDs[1], 9999}, &team.ID, 1, 7)
+	if err != nil || len(batch.Success) != 1 || len(batch.Failed) != 1 {
+		t.Fatalf("batch move: %+v %v", batch, err)
+	}
+
+	list := func(folderID uint64, subfolders bool) int64 {
+		t.Helper()
+		resp, err := shortLinkSvc.GetShortLinkListInWorkspace(&dto.ShortLinkListRequest{Page: 1, PageSize: 10, FolderID: &folderID, Subfolders: subfolders}, 1)
+		if err != nil {
+			t.Fatalf("list folder %d: %v", folderID, err)
+		}
+		return resp.Total
+	}
+	if got := list(product.ID, false); got != 1 {
+		t.Fatalf("expected 1 direct link in folder, got %d", got)
+	}
+	if got := list(product.ID, true); got != 3 {
+		t.Fatalf("expected 3 links including subfolders, got %d", got)
+	}
+	if got := list(0, false); got != 0 {
+		t.Fatalf("expected no unfiled links, got %d", got)
+	}
+
+	now := time.Now()
+	for _, id := range []uint64{created.ID, rootIDs[0], rootIDs[0]} {
+		if err := db.Create(&model.ClickStatistic{WorkspaceID: 1, ShortLinkID: id, IP: "203.0.113.1", ClickDate: now}).Error; err != nil {
+			t.Fatalf("seed click: %v", err)
+		}
+	}
+	stats, err := folderSvc.Statistics(product.ID, 1, &dto.FolderStatisticsRequest{Days: 7})
+	if err != nil {
+		t.Fatalf("folder statistics: %v", err)
+	}
+	if stats.FolderCount != 2 || stats.ShortLinkCount != 3 || stats.Analysis.TotalClicks != 3 {
+		t.Fatalf("unexpected folder statistics: %+v %+v", stats, stats.Analysis)
+	}
+	teamStats, err := folderSvc.Statistics(team.ID, 1, &dto.FolderStatisticsRequest{Days: 7})
+	if err != nil || teamStats.Analysis.TotalClicks != 2 {
+		t.Fatalf("unexpected subfolder statistics: %+v %v", teamStats, err)
+	}
+
+	if err := folderSvc.Delete(team.ID, 1); err == nil || !strings.Contains(err.Error(), "不为空") {
+		t.Fatalf("expected non-empty folder delete error, got %v", err)
+	}
+	if _, err := shortLinkSvc.BatchMoveShortLinksInWorkspace(rootIDs, nil, 1, 7); err != nil {
+		t.Fatalf("move back to root: %v", err)
+	}
+	if err := folderSvc.Delete(team.ID, 1); err != nil {
+		t.Fatalf("delete empty folder: %v", err)
+	}
+}
+
+func TestRekeyShortLinkKeepsAliases(t *testing.T) {
+	helper := newShortLinkRegressionHelper(t)
+	db := helper.GetDatabase()
+	shortLinkSvc := NewShortLinkService(helper, context.Background())
+	seedBatchShortLinkDomain(t, db)
+	if err := db.Create(&model.Domain{WorkspaceID: 1, Protocol: "https", Domain: "alias.dwz.do", IsActive: true}).Error; err != nil {
+		t.Fatalf("seed second domain: %v", err)
+	}
+
+	created, err := shortLinkSvc.CreateShortLinkInWorkspace(&dto.CreateShortLinkRequest{
+		OriginalURL: "https://example.com/printed",
+		Domain:      "batch.dwz.do",
+		CustomCode:  "rk-old",
+	}, "", 1, 7)
+	if err != nil {
+		t.Fatalf("create short link: %v", err)
+	}
+
+	rekeyed, err := shortLinkSvc.RekeyShortLinkInWorkspace(created.ID, &dto.RekeyShortLinkRequest{ShortCode: "rk-new"}, 1, 7)
+	if err != nil {
+		t.Fatalf("rekey short code: %v", err)
+	}
+	if rekeyed.ID != created.ID || rekeyed.ShortCode != "rk-new" || rekeyed.ShortURL != "https://batch.dwz.do/rk-new" {
+		t.Fatalf("unexpected rekey result: %+v", rekeyed)
+	}
+	if _, err := shortLinkSvc.RekeyShortLinkInWorkspace(created.ID, &dto.RekeyShortLinkRequest{ShortCode: "rk-new"}, 1, 7); err == nil {
+		t.Fatal("rekey to the current code must fail")
+	}
+
+	for _, code := range []string{"rk-old", "rk-new"} {
+		if _, err := shortLinkSvc.RedirectShortLinkWithQuery("batch.dwz.do", code, "8.8.8.8", "Mozilla/5.0", "", ""); err != nil {
+			t.Fatalf("redirect %s: %v", code, err)
+		}
+	}
+	waitForModelCount(t, db, &model.ClickStatistic{}, "short_link_id = ?", created.ID, 2)
+
+	if _, err := shortLinkSvc.CreateShortLinkInWorkspace(&dto.CreateShortLinkRequest{
+		OriginalURL: "https://example.com/other",
+		Domain:      "batch.dwz.do",
+		CustomCode:  "rk-old",
+	}, "", 1, 7); err == nil || !strings.Contains(err.Error(), "已存在") {
+		t.Fatalf("alias code must stay reserved, got %v", err)
+	}
+
+	moved, err := shortLinkSvc.RekeyShortLinkInWorkspace(created.ID, &dto.RekeyShortLinkRequest{Domain: "alias.dwz.do"}, 1, 7)
+	if err != nil || moved.ShortURL != "https://alias.dwz.do/rk-new" {
+		t.Fatalf("rekey domain: %+v %v", moved, err)
+	}
+	promoted, err := shortLinkSvc.RekeyShortLinkInWorkspace(created.ID, &dto.RekeyShortLinkRequest{Domain: "batch.dwz.do", ShortCode: "rk-old"}, 1, 7)
+	if err != nil || promoted.ShortURL != "https://batch.dwz.do/rk-old" {
+		t.Fatalf("promote alias back to primary: %+v %v", promoted, err)
+	}
+
+	aliases, err := shortLinkSvc.ListShortLinkAliasesInWorkspace(created.ID, 1)
+	if err != nil {
+		t.Fatalf("list aliases: %v", err)
+	}
+	urls := make([]string, 0, len(aliases.List))
+	for _, alias := range aliases.List {
+		urls = append(urls, alias.ShortURL)
+	}
+	if got := strings.Join(urls, ","); len(aliases.List) != 2 || !strings.Contains(got, "https://batch.dwz.do/rk-new") || !strings.Contains(got, "https://alias.dwz.do/rk-new") {
+		t.Fatalf("unexpected aliases: %s", got)
+	}
+
+	var aliasID uint64
+	for _, alias := range aliases.List {
+		if alias.Domain == "alias.dwz.do" {
+			aliasID = alias.ID
+		}
+	}
+	if _, err := shortLinkSvc.RedirectShortLinkWithQuery("alias.dwz.do", "rk-new", "", "", "", ""); err != nil {
+		t.Fatalf("redirect alias before delete: %v", err)
+	}
+	if err := shortLinkSvc.DeleteShortLinkAliasInWorkspace(created.ID, aliasID, 1); err != nil {
+		t.Fatalf("delete alias: %v", err)
+	}
+	if _, err := shortLinkSvc.RedirectShortLinkWithQuery("alias.dwz.do", "rk-new", "", "", "", ""); err == nil || !strings.Contains(err.Error(), "短网址不存在") {
+		t.Fatalf("deleted alias must stop resolving, got %v", err)
+	}
+	if err := shortLinkSvc.DeleteShortLinkAliasInWorkspace(created.ID, aliasID, 1); err == nil || err.Error() != "别名不存在" {
+		t.Fatalf("expected missing alias error, got %v", err)
+	}
+}
+
+func TestShortLinkManualAliasesAttributeClicks(t *testing.T) {
+	helper := newShortLinkRegressionHelper(t)
+	db := helper.GetDatabase()
+	shortLinkSvc := NewShortLinkService(helper, context.Background())
+	seedBatchShortLinkDomain(t, db)
+
+	created, err := shortLinkSvc.CreateShortLinkInWorkspace(&dto.CreateShortLinkRequest{
+		OriginalURL: "https://example.com/spring",
+		Domain:      "batch.dwz.do",
+		CustomCode:  "spring",
+	}, "", 1, 7)
+	if err != nil {
+		t.Fatalf("create short link: %v", err)
+	}
+	for _, code := range []string{"spring26", "s26"} {
+		if _, err := shortLinkSvc.CreateShortLinkAliasInWorkspace(created.ID, &dto.CreateShortLinkAliasRequest{ShortCode: code}, 1, 7); err != nil {
+			t.Fatalf("create alias %s: %v", code, err)
+		}
+	}
+	if _, err := shortLinkSvc.CreateShortLinkAliasInWorkspace(created.ID, &dto.CreateShortLinkAliasRequest{ShortCode: "spring"}, 1, 7); err == nil || !strings.Contains(err.Error(), "已存在") {
+		t.Fatalf("alias must not reuse a primary code, got %v", err)
+	}
+	if _, err := shortLinkSvc.CreateShortLinkAliasInWorkspace(created.ID, &dto.CreateShortLinkAliasRequest{ShortCode: "bad/code"}, 1, 7); err == nil {
+		t.Fatal("invalid alias code must fail")
+	}
+
+	for _, code := range []string{"spring", "spring26", "spring26", "s26"} {
+		if _, err := shortLinkSvc.RedirectShortLinkWithQuery("batch.dwz.do", code, "8.8.8.8", "Mozilla/5.0", "", ""); err != nil {
+			t.Fatalf("redirect %s: %v", code, err)
+		}
+	}
+	waitForModelCount(t, db, &model.ClickStatistic{}, "short_link_id = ?", created.ID, 4)
+
+	analysis, err := NewClickStatisticService(helper).GetClickStatisticAnalysisInWorkspace(1, &dto.ClickStatisticListRequest{ShortLinkID: created.ID}, 0)
+	if err != nil {
+		t.Fatalf("analysis: %v", err)
+	}
+	if analysis.TotalClicks != 4 || len(analysis.TopAliases) != 2 || analysis.TopAliases[0].AliasCode != "spring26" || analysis.TopAliases[0].Count != 2 {
+		t.Fatalf("unexpected alias breakdown: total=%d aliases=%+v", analysis.TotalClicks, analysis.TopAliases)
+	}
+
+	filtered, err := NewClickStatisticService(helper).GetClickStatisticAnalysisInWorkspace(1, &dto.ClickStatisticListRequest{ShortLinkID: created.ID, AliasID: analysis.TopAliases[1].AliasID}, 0)
+	if err != nil || filtered.TotalClicks != 1 {
+		t.Fatalf("alias filter: %+v %v", filtered, err)
+	}
+}
+
 func seedBatchShortLinkDomain(t *testing.T, db *gorm.DB) model.Domain {
 	t.Helper()
 	domain := model.Domain{
@@ -673,6 +1802,14 @@ func boolPtr(value bool) *bool {
 	return &value
 }
 
+func stringPtr(value string) *string {
+	return &value
+}
+
+func uint64Ptr(value uint64) *uint64 {
+	return &value
+}
+
 func newShortLinkRegressionHelper(t *testing.T) *shortLinkRegressionHelper {
 	t.Helper()
 	dbName := strings.NewReplacer("/", "_", " ", "_").Replace(t.Name())
@@ -686,6 +1823,7 @@ func newShortLinkRegressionHelper(t *testing.T) *shortLinkRegressionHelper {
 	}
 	sqlDB.SetMaxOpenConns(1)
 	if err := db.AutoMigrate(
+		&model.User{},
 		&model.Workspace{},
 		&model.WorkspaceMember{},
 		&model.Domain{},
@@ -706,6 +1844,29 @@ func newShortLinkRegressionHelper(t *testing.T) *shortLinkRegressionHelper {
 		&model.ABTestVariant{},
 		&model.ABTestClickStatistic{},
 		&model.ABTestFeedback{},
+		&model.IdempotencyKey{},
+		&model.Folder{},
+		&model.ShortLinkAlias{},
+		&model.LinkHealthCheck{},
+		&model.ShortLinkMetadata{},
+		&model.LinkTemplate{},
+		&model.CustomField{},
+		&model.ShortLinkCustomValue{},
+		&model.Conversion{},
+		&model.ClickRollup{},
+		&model.ClickRollupCursor{},
+		&model.VisitorSalt{},
+		&model.VisitorSketch{},
+		&model.ClickExportJob{},
+		&model.ClickExportChunk{},
+		&model.ReferrerRule{},
+		&model.TrafficAlertSetting{},
+		&model.AlertChannel{},
+		&model.TrafficAlert{},
+		&model.TrafficAlertDelivery{},
+		&model.OperationLog{},
+		&model.DataSubjectRequest{},
+		&model.ClickRollupRebuildJob{},
 	); err != nil {
 		t.Fatalf("auto migrate: %v", err)
 	}
@@ -716,7 +1877,7 @@ func newShortLinkRegressionHelper(t *testing.T) *shortLinkRegressionHelper {
 	}
 	return &shortLinkRegressionHelper{
 		db:       db,
-		settings: shortLinkRegressionSettings{"database.driver": "sqlite", "jwt.secret": "test-secret-for-ab-feedback"},
+		settings: shortLinkRegressionSettings{"database.driver": "sqlite", "jwt.secret": "test-secret-for-ab-feedback", "analytics.rollup_settle_seconds": 0, "conversion.click_lookup_wait_ms": 0},
 		cache:    newShortLinkRegressionCache(),
 	}
 }
@@ -0,0 +1,112 @@
+package service
+
+import (
+	"errors"
+	"time"
+
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/dto"
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/model"
+	"gorm.io/gorm"
+)
+
+// reviewStatusForActor 工作区开启链接审核且操作者为 member 时，创建或修改的短网址进入待审核状态
+func (s *ShortLinkService) reviewStatusForActor(workspaceID, userID uint64) (string, error) {
+	if userID == 0 {
+		return "", nil
+	}
+	workspace, err := s.workspaceDao.FindByID(workspaceID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", nil
+		}
+		return "", err
+	}
+	if !workspace.RequireLinkApproval {
+		return "", nil
+	}
+	member, err := s.workspaceDao.GetMember(workspaceID, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", nil
+		}
+		return "", err
+	}
+	if member.Role == model.WorkspaceRoleMember {
+		return model.ShortLinkReviewPending, nil
+	}
+	return "", nil
+}
+
+// markPendingReview 将短网址置为待审核，并清空上一次的审核结论
+func markPendingReview(shortLink *model.ShortLink) {
+	shortLink.ReviewStatus = model.ShortLinkReviewPending
+	shortLink.ReviewComment = ""
+	shortLink.ReviewedBy = nil
+	shortLink.ReviewedAt = nil
+}
+
+// RequireReviewAfterChange 成员通过路由、别名、更换短码或安全设置改动短网址后，按工作区审核策略重新进入待审核
+func (s *ShortLinkService) RequireReviewAfterChange(shortLinkID, workspaceID, userID uint64) error {
+	reviewStatus, err := s.reviewStatusForActor(workspaceID, userID)
+	if err != nil || reviewStatus != model.ShortLinkReviewPending {
+		return err
+	}
+	shortLink, err := s.shortLinkDao.FindByIDInWorkspace(shortLinkID, workspaceID)
+	if err != nil {
+		return err
+	}
+	if shortLink.ReviewStatus == model.ShortLinkReviewPending {
+		return nil
+	}
+	markPendingReview(shortLink)
+	if err := s.shortLinkDao.Update(shortLink); err != nil {
+		return err
+	}
+	s.cacheShortLink(shortLink)
+	s.removeAliasCache(shortLink)
+	return nil
+}
+
+// GetReviewQueueInWorkspace 获取工作区待审核的短网址
+func (s *ShortLinkService) GetReviewQueueInWorkspace(req *dto.ShortLinkListRequest, workspaceID uint64) (*dto.ShortLinkListResponse, error) {
+	queueReq := *req
+	queueReq.ReviewStatus = model.ShortLinkReviewPending
+	return s.GetShortLinkListInWorkspace(&queueReq, workspaceID)
+}
+
+// ApproveShortLinkInWorkspace 审核通过，短网址随即按自身的启用状态生效
+func (s *ShortLinkService) ApproveShortLinkInWorkspace(id uint64, req *dto.ReviewShortLinkRequest, workspaceID, userID uint64) (*dto.ShortLinkResponse, error) {
+	return s.reviewShortLink(id, model.ShortLinkReviewApproved, req.Comment, workspaceID, userID)
+}
+
+// RejectShortLinkInWorkspace 驳回短网址，驳回后仍不可访问，成员修改后重新进入待审核
+func (s *ShortLinkService) RejectShortLinkInWorkspace(id uint64, req *dto.ReviewShortLinkRequest, workspaceID, userID uint64) (*dto.ShortLinkResponse, error) {
+	return s.reviewShortLink(id, model.ShortLinkReviewRejected, req.Comment, workspaceID, userID)
+}
+
+func (s *ShortLinkService) reviewShortLink(id uint64, decision, comment string, workspaceID, userID uint64) (*dto.ShortLinkResponse, error) {
+	shortLink, err := s.shortLinkDao.FindByIDInWorkspace(id, workspaceID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("短网址不存在")
+		}
+		return nil, err
+	}
+	if shortLink.ReviewStatus != model.ShortLinkReviewPending {
+		return nil, errors.New("短网址不是待审核状态，不能审核")
+	}
+
+	now := time.Now()
+	shortLink.ReviewStatus = decision
+	shortLink.ReviewComment = comment
+	shortLink.ReviewedBy = actorPtr(userID)
+	shortLink.ReviewedAt = &now
+	if err := s.shortLinkDao.Update(shortLink); err != nil {
+		return nil, err
+	}
+
+	s.cacheShortLink(shortLink)
+	s.removeAliasCache(shortLink)
+
+	return s.modelToResponse(shortLink), nil
+}
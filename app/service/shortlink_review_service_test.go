@@ -0,0 +1,222 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/dao"
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/dto"
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/model"
+	"gorm.io/gorm"
+)
+
+func TestLinkApprovalWorkflowForMembers(t *testing.T) {
+	helper := newShortLinkRegressionHelper(t)
+	db := helper.GetDatabase()
+	seedBatchShortLinkDomain(t, db)
+	if err := db.Create(&model.Workspace{ID: 1, Slug: "default", Name: "Default", Status: 1}).Error; err != nil {
+		t.Fatalf("seed workspace: %v", err)
+	}
+	for _, member := range []model.WorkspaceMember{
+		{WorkspaceID: 1, UserID: 7, Role: model.WorkspaceRoleMember, Status: 1},
+		{WorkspaceID: 1, UserID: 8, Role: model.WorkspaceRoleAdmin, Status: 1},
+	} {
+		if err := db.Create(&member).Error; err != nil {
+			t.Fatalf("seed member: %v", err)
+		}
+	}
+	shortLinkSvc := NewShortLinkService(helper, context.Background())
+
+	// 未开启审核时成员创建的短网址直接生效
+	direct, err := shortLinkSvc.CreateShortLinkInWorkspace(&dto.CreateShortLinkRequest{
+		OriginalURL: "https://example.com/direct",
+		Domain:      "batch.dwz.do",
+		CustomCode:  "direct",
+	}, "", 1, 7)
+	if err != nil || direct.ReviewStatus != "" {
+		t.Fatalf("approval should be opt-in: %+v err=%v", direct, err)
+	}
+
+	if _, err := NewWorkspaceService(helper).UpdateWorkspace(1, &dto.UpdateWorkspaceRequest{Name: "Default", RequireLinkApproval: boolPtr(true)}); err != nil {
+		t.Fatalf("enable approval: %v", err)
+	}
+	pending, err := shortLinkSvc.CreateShortLinkInWorkspace(&dto.CreateShortLinkRequest{
+		OriginalURL: "https://example.com/pending",
+		Domain:      "batch.dwz.do",
+		CustomCode:  "pending",
+	}, "", 1, 7)
+	if err != nil || pending.ReviewStatus != model.ShortLinkReviewPending {
+		t.Fatalf("member link must wait for review: %+v err=%v", pending, err)
+	}
+	if _, err := shortLinkSvc.ResolveRedirectWithSecurity("batch.dwz.do", "pending", "", "", "", "", ""); err == nil || err.Error() != "短网址已被禁用" {
+		t.Fatalf("pending link must be treated as inactive, got %v", err)
+	}
+	adminLink, err := shortLinkSvc.CreateShortLinkInWorkspace(&dto.CreateShortLinkRequest{
+		OriginalURL: "https://example.com/admin",
+		Domain:      "batch.dwz.do",
+		CustomCode:  "admin",
+	}, "", 1, 8)
+	if err != nil || adminLink.ReviewStatus != "" {
+		t.Fatalf("admin link must skip review: %+v err=%v", adminLink, err)
+	}
+
+	queue, err := shortLinkSvc.GetReviewQueueInWorkspace(&dto.ShortLinkListRequest{Page: 1, PageSize: 10}, 1)
+	if err != nil || len(queue.List) != 1 || queue.List[0].ID != pending.ID {
+		t.Fatalf("unexpected review queue: %+v err=%v", queue, err)
+	}
+
+	approved, err := shortLinkSvc.ApproveShortLinkInWorkspace(pending.ID, &dto.ReviewShortLinkRequest{Comment: "已确认"}, 1, 8)
+	if err != nil {
+		t.Fatalf("approve: %v", err)
+	}
+	if approved.ReviewStatus != model.ShortLinkReviewApproved || approved.ReviewComment != "已确认" || approved.ReviewedBy == nil || *approved.ReviewedBy != 8 {
+		t.Fatalf("unexpected approved link: %+v", approved)
+	}
+	if decision, err := shortLinkSvc.ResolveRedirectWithSecurity("batch.dwz.do", "pending", "", "", "", "", ""); err != nil || decision.TargetURL != "https://example.com/pending" {
+		t.Fatalf("approved link must redirect: %+v err=%v", decision, err)
+	}
+	if _, err := shortLinkSvc.ApproveShortLinkInWorkspace(pending.ID, &dto.ReviewShortLinkRequest{}, 1, 8); err == nil {
+		t.Fatal("approved link must not be reviewed again")
+	}
+
+	// 成员修改后重新进入待审核，驳回后仍不可访问
+	edited, err := shortLinkSvc.UpdateShortLinkInWorkspace(pending.ID, &dto.UpdateShortLinkRequest{OriginalURL: "https://example.com/edited"}, 1, 7)
+	if err != nil || edited.ReviewStatus != model.ShortLinkReviewPending || edited.ReviewComment != "" || edited.ReviewedBy != nil {
+		t.Fatalf("member edit must require review again: %+v err=%v", edited, err)
+	}
+	if _, err := shortLinkSvc.RejectShortLinkInWorkspace(pending.ID, &dto.ReviewShortLinkRequest{Comment: "落地页不合规"}, 1, 8); err != nil {
+		t.Fatalf("reject: %v", err)
+	}
+	if _, err := shortLinkSvc.ResolveRedirectWithSecurity("batch.dwz.do", "pending", "", "", "", "", ""); err == nil {
+		t.Fatal("rejected link must stay inactive")
+	}
+	rejected, err := shortLinkSvc.GetShortLinkListInWorkspace(&dto.ShortLinkListRequest{Page: 1, PageSize: 10, Q: "review:rejected"}, 1)
+	if err != nil || len(rejected.List) != 1 || rejected.List[0].ReviewComment != "落地页不合规" {
+		t.Fatalf("rejected link must be searchable: %+v err=%v", rejected, err)
+	}
+}
+
+func TestMemberDestinationChangesRequireReview(t *testing.T) {
+	helper := newShortLinkRegressionHelper(t)
+	db := helper.GetDatabase()
+	seedBatchShortLinkDomain(t, db)
+	if err := db.Create(&model.Workspace{ID: 1, Slug: "default", Name: "Default", Status: 1, RequireLinkApproval: true}).Error; err != nil {
+		t.Fatalf("seed workspace: %v", err)
+	}
+	for _, member := range []model.WorkspaceMember{
+		{WorkspaceID: 1, UserID: 7, Role: model.WorkspaceRoleMember, Status: 1},
+		{WorkspaceID: 1, UserID: 8, Role: model.WorkspaceRoleAdmin, Status: 1},
+	} {
+		if err := db.Create(&member).Error; err != nil {
+			t.Fatalf("seed member: %v", err)
+		}
+	}
+	shortLinkSvc := NewShortLinkService(helper, context.Background())
+	link, err := shortLinkSvc.CreateShortLinkInWorkspace(&dto.CreateShortLinkRequest{
+		OriginalURL: "https://example.com/reviewed",
+		Domain:      "batch.dwz.do",
+		CustomCode:  "reviewed",
+	}, "", 1, 7)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	approve := func() {
+		t.Helper()
+		if _, err := shortLinkSvc.ApproveShortLinkInWorkspace(link.ID, &dto.ReviewShortLinkRequest{}, 1, 8); err != nil {
+			t.Fatalf("approve: %v", err)
+		}
+	}
+	expectPending := func(change string) {
+		t.Helper()
+		var stored model.ShortLink
+		db.First(&stored, link.ID)
+		if stored.ReviewStatus != model.ShortLinkReviewPending {
+			t.Fatalf("%s by a member must require review again, got %q", change, stored.ReviewStatus)
+		}
+		if _, err := shortLinkSvc.ResolveRedirectWithSecurity("batch.dwz.do", stored.GetShortCode(), "", "", "", "", ""); err == nil {
+			t.Fatalf("%s must not go live before approval", change)
+		}
+	}
+
+	// 待审核和已驳回的短网址不被复用
+	reusable := func() bool {
+		t.Helper()
+		var candidate model.ShortLink
+		db.First(&candidate, link.ID)
+		found, err := dao.NewShortLinkDao(helper).FindReusableInWorkspace(&candidate, time.Now())
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			t.Fatalf("find reusable: %v", err)
+		}
+		return found != nil
+	}
+	if reusable() {
+		t.Fatal("pending link must not be reused")
+	}
+	approve()
+	if !reusable() {
+		t.Fatal("approved link must be reusable")
+	}
+
+	routes := NewLinkRouteService(helper)
+	route, err := routes.CreateRoute(link.ID, 1, 7, &dto.LinkRouteRequest{
+		Name:      "mobile",
+		TargetURL: "https://evil.example.com/",
+		ConditionGroups: []dto.LinkRouteConditionGroupRequest{{
+			Conditions: []dto.LinkRouteConditionRequest{{ConditionType: model.RouteConditionDeviceType, Operator: model.RouteOperatorEq, ConditionValue: "mobile"}},
+		}},
+	})
+	if err != nil {
+		t.Fatalf("create route: %v", err)
+	}
+	expectPending("adding a route")
+
+	approve()
+	if _, err := routes.UpdateRoute(route.ID, link.ID, 1, 7, &dto.LinkRouteRequest{
+		Name:      "mobile",
+		TargetURL: "https://evil.example.com/other",
+		ConditionGroups: []dto.LinkRouteConditionGroupRequest{{
+			Conditions: []dto.LinkRouteConditionRequest{{ConditionType: model.RouteConditionDeviceType, Operator: model.RouteOperatorEq, ConditionValue: "mobile"}},
+		}},
+	}); err != nil {
+		t.Fatalf("update route: %v", err)
+	}
+	expectPending("updating a route")
+
+	approve()
+	if _, err := shortLinkSvc.CreateShortLinkAliasInWorkspace(link.ID, &dto.CreateShortLinkAliasRequest{ShortCode: "reviewed-alias"}, 1, 7); err != nil {
+		t.Fatalf("create alias: %v", err)
+	}
+	expectPending("adding an alias")
+
+	approve()
+	if _, err := shortLinkSvc.RekeyShortLinkInWorkspace(link.ID, &dto.RekeyShortLinkRequest{ShortCode: "rekeyed"}, 1, 7); err != nil {
+		t.Fatalf("rekey: %v", err)
+	}
+	expectPending("changing the short code")
+
+	approve()
+	maxClicks := int64(10)
+	if _, err := NewLinkSecurityService(helper).UpsertSecurity(link.ID, 1, 7, &dto.LinkSecurityRequest{MaxClicks: &maxClicks}); err != nil {
+		t.Fatalf("update security: %v", err)
+	}
+	expectPending("changing security settings")
+
+	// 管理员的改动不触发审核
+	approve()
+	if _, err := routes.CreateRoute(link.ID, 1, 8, &dto.LinkRouteRequest{
+		Name:      "desktop",
+		TargetURL: "https://example.com/desktop",
+		ConditionGroups: []dto.LinkRouteConditionGroupRequest{{
+			Conditions: []dto.LinkRouteConditionRequest{{ConditionType: model.RouteConditionDeviceType, Operator: model.RouteOperatorEq, ConditionValue: "desktop"}},
+		}},
+	}); err != nil {
+		t.Fatalf("admin route: %v", err)
+	}
+	var stored model.ShortLink
+	db.First(&stored, link.ID)
+	if stored.ReviewStatus != model.ShortLinkReviewApproved {
+		t.Fatalf("admin changes must keep the approval, got %q", stored.ReviewStatus)
+	}
+}
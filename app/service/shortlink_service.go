@@ -2,9 +2,15 @@ package service
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"maps"
+	"net/http"
 	"net/url"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 
@@ -21,14 +27,17 @@ type ShortLinkService struct {
 	helper              interfaces.HelperInterface
 	context             context.Context
 	shortLinkDao        *dao.ShortLinkDao
+	shortLinkAliasDao   *dao.ShortLinkAliasDao
 	clickStatisticDao   *dao.ClickStatisticDao
 	domainDao           *dao.DomainDao
 	campaignDao         *dao.CampaignDao
 	tagDao              *dao.TagDao
+	workspaceDao        *dao.WorkspaceDao
 	idGenerator         interfaces.IDGenerator // 新的分布式发号器
 	abTestService       *ABTestService         // AB测试服务
 	linkSecurityService *LinkSecurityService
 	linkRouteService    *LinkRouteService
+	customFieldService  *CustomFieldService
 }
 
 const httpStatusFound = 302
@@ -41,14 +50,17 @@ func NewShortLinkService(helper interfaces.HelperInterface, context context.Cont
 		helper:              helper,
 		context:             context,
 		shortLinkDao:        dao.NewShortLinkDao(helper),
+		shortLinkAliasDao:   dao.NewShortLinkAliasDao(helper),
 		clickStatisticDao:   dao.NewClickStatisticDao(helper),
 		domainDao:           dao.NewDomainDao(helper),
 		campaignDao:         dao.NewCampaignDao(helper),
 		tagDao:              dao.NewTagDao(helper),
+		workspaceDao:        dao.NewWorkspaceDao(helper),
 		idGenerator:         helper2.GetIdGenerator(),
 		abTestService:       NewABTestService(helper),
 		linkSecurityService: NewLinkSecurityService(helper),
 		linkRouteService:    NewLinkRouteService(helper),
+		customFieldService:  NewCustomFieldService(helper),
 	}
 }
 
@@ -58,6 +70,21 @@ func (s *ShortLinkService) CreateShortLink(req *dto.CreateShortLinkRequest, crea
 }
 
 func (s *ShortLinkService) CreateShortLinkInWorkspace(req *dto.CreateShortLinkRequest, creatorIP string, workspaceID, userID uint64) (*dto.ShortLinkResponse, error) {
+	// 先套用链接模板，模板指定的文件夹默认值随后再填充
+	withTemplate := *req
+	if err := NewLinkTemplateService(s.helper).ApplyCreateTemplate(workspaceID, &withTemplate); err != nil {
+		return nil, err
+	}
+	req = &withTemplate
+	if req.FolderID != nil {
+		// 填充文件夹默认值，不修改调用方的请求
+		withDefaults := *req
+		if err := NewFolderService(s.helper).ApplyCreateDefaults(workspaceID, &withDefaults); err != nil {
+			return nil, err
+		}
+		req = &withDefaults
+	}
+
 	// 验证原始URL
 	if _, err := parseTargetURL(req.OriginalURL); err != nil {
 		return nil, errors.New("无效的URL格式")
@@ -73,29 +100,18 @@ func (s *ShortLinkService) CreateShortLinkInWorkspace(req *dto.CreateShortLinkRe
 		return nil, errors.New("域名不能为空")
 	}
 
-	// 验证域名格式,域名不能带有协议头
-	if err := s.validateDomain(domain); err != nil {
-		return nil, err
-	}
-
-	// 验证域名是否存在且活跃并获取域名信息
-	domainInfo, err := s.domainDao.FindByDomain(domain)
+	domainInfo, err := s.findActiveDomainInWorkspace(domain, workspaceID)
 	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("域名不存在")
-		}
 		return nil, err
 	}
-	if !domainInfo.IsActive {
-		return nil, errors.New("域名未激活")
-	}
-	if domainInfo.WorkspaceID != workspaceID {
-		return nil, errors.New("域名不存在")
-	}
 
 	if err := s.validateCampaignAndTags(workspaceID, req.CampaignID, req.TagIDs); err != nil {
 		return nil, err
 	}
+	externalID, err := s.resolveExternalID(req.ExternalID, workspaceID, 0)
+	if err != nil {
+		return nil, err
+	}
 	if result := s.linkSecurityService.ScanURL(workspaceID, finalURL); !result.Safe {
 		return nil, errors.New("目标 URL 命中安全规则: " + result.Reason)
 	}
@@ -114,6 +130,24 @@ func (s *ShortLinkService) CreateShortLinkInWorkspace(req *dto.CreateShortLinkRe
 	if !isAllowedRedirectCode(redirectCode) {
 		return nil, errors.New("跳转状态码仅支持 301、302、307、308")
 	}
+	for i := range req.Routes {
+		if err := s.linkRouteService.validateRouteRequest(workspaceID, &req.Routes[i]); err != nil {
+			return nil, err
+		}
+	}
+	customValues, _, err := s.customFieldService.ResolveValues(workspaceID, req.CustomFields, true)
+	if err != nil {
+		return nil, err
+	}
+	securitySetting, securityRules, err := s.linkSecurityService.prepareCreateSecurity(workspaceID, userID, req.Security)
+	if err != nil {
+		return nil, err
+	}
+
+	reviewStatus, err := s.reviewStatusForActor(workspaceID, userID)
+	if err != nil {
+		return nil, err
+	}
 
 	var actor *uint64
 	if userID > 0 {
@@ -122,102 +156,85 @@ func (s *ShortLinkService) CreateShortLinkInWorkspace(req *dto.CreateShortLinkRe
 
 	// 创建短网址记录
 	shortLink := &model.ShortLink{
-		WorkspaceID:  workspaceID,
-		CampaignID:   req.CampaignID,
-		Domain:       domain,
-		DomainID:     domainInfo.ID,
-		Protocol:     domainInfo.Protocol,
-		OriginalURL:  finalURL,
-		FallbackURL:  req.FallbackURL,
-		RedirectCode: redirectCode,
-		Title:        req.Title,
-		Description:  req.Description,
-		UTMSource:    req.UTMSource,
-		UTMMedium:    req.UTMMedium,
-		UTMCampaign:  req.UTMCampaign,
-		UTMTerm:      req.UTMTerm,
-		UTMContent:   req.UTMContent,
-		Notes:        req.Notes,
-		ExpireAt:     req.ExpireAt,
-		IsActive:     true,
-		CreatorIP:    creatorIP,
-		CreatedBy:    actor,
-		UpdatedBy:    actor,
-	}
-
-	// 处理自定义短代码
-	if req.CustomCode != "" {
-
-		// 检查自定义短代码是否已存在
-		exists, err := s.shortLinkDao.ExistsByDomainAndCode(domain, req.CustomCode)
-		if err != nil {
+		WorkspaceID:   workspaceID,
+		CampaignID:    req.CampaignID,
+		FolderID:      req.FolderID,
+		Domain:        domain,
+		DomainID:      domainInfo.ID,
+		Protocol:      domainInfo.Protocol,
+		OriginalURL:   finalURL,
+		FallbackURL:   req.FallbackURL,
+		RedirectCode:  redirectCode,
+		Title:         req.Title,
+		Description:   req.Description,
+		UTMSource:     req.UTMSource,
+		UTMMedium:     req.UTMMedium,
+		UTMCampaign:   req.UTMCampaign,
+		UTMTerm:       req.UTMTerm,
+		UTMContent:    req.UTMContent,
+		Notes:         req.Notes,
+		URLHash:       targetURLFingerprint(finalURL),
+		ExternalID:    externalID,
+		ExpireAt:      req.ExpireAt,
+		IsActive:      true,
+		ReviewStatus:  reviewStatus,
+		AppendClickID: req.AppendClickID,
+		CreatorIP:     creatorIP,
+		CreatedBy:     actor,
+		UpdatedBy:     actor,
+	}
+
+	if s.shouldReuseLink(req, workspaceID) {
+		existing, err := s.shortLinkDao.FindReusableInWorkspace(shortLink, time.Now())
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, err
 		}
-		if exists {
-			return nil, errors.New("自定义短代码已存在")
+		if existing != nil {
+			if len(req.CustomFields) > 0 {
+				// 自定义字段属于单条短网址，复用时写入会改动他人创建的链接
+				return nil, errors.New("复用已有短网址时无法设置自定义字段，请将 reuse 设为 false")
+			}
+			response := s.modelToResponse(existing)
+			response.Reused = true
+			response.IgnoredOptions = reuseIgnoredOptions(req, shortLink, existing)
+			return response, nil
 		}
+	}
 
-		shortLink.ShortCode = req.CustomCode
-		shortLink.IsCustomCode = true
-	} else {
-		// 使用分布式发号器生成短代码，使用域名配置
-		// 处理指针类型，提供默认值
-		randomSuffixLength := 2
-		if domainInfo.RandomSuffixLength != nil {
-			randomSuffixLength = *domainInfo.RandomSuffixLength
-		}
-		enableChecksum := true
-		if domainInfo.EnableChecksum != nil {
-			enableChecksum = *domainInfo.EnableChecksum
-		}
-		enableXorObfuscation := false
-		if domainInfo.EnableXorObfuscation != nil {
-			enableXorObfuscation = *domainInfo.EnableXorObfuscation
-		}
-		xorSecret := uint64(0)
-		if domainInfo.XorSecret != nil {
-			xorSecret = *domainInfo.XorSecret
-		}
-		xorRot := 0
-		if domainInfo.XorRot != nil {
-			xorRot = *domainInfo.XorRot
+	if err := s.assignShortCode(shortLink, domainInfo, req.CustomCode); err != nil {
+		return nil, err
+	}
+
+	// 短网址与标签、自定义字段、安全设置、路由一起写入，任一失败时整体回滚。
+	// 事务内只使用 tx，校验和安全扫描等查询需在事务外完成
+	if err := s.helper.GetDatabase().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(shortLink).Error; err != nil {
+			return err
 		}
-		defaultStartNumber := uint64(0)
-		if domainInfo.DefaultStartNumber != nil {
-			defaultStartNumber = *domainInfo.DefaultStartNumber
+		if len(req.TagIDs) > 0 {
+			if err := s.tagDao.ReplaceShortLinkTags(tx, shortLink.ID, req.TagIDs); err != nil {
+				return err
+			}
 		}
-		config := interfaces.ShortCodeConfig{
-			RandomSuffixLength:   randomSuffixLength,
-			EnableChecksum:       enableChecksum,
-			EnableXorObfuscation: enableXorObfuscation,
-			XorSecret:            xorSecret,
-			XorRot:               xorRot,
-			DefaultStartNumber:   defaultStartNumber,
+		if err := s.customFieldService.SaveValues(tx, shortLink.ID, customValues, nil); err != nil {
+			return err
 		}
-		generatedCode, issuerNumber, err := s.idGenerator.GenerateShortCodeWithConfig(domainInfo.ID, s.context, config)
-		if err != nil {
-			return nil, fmt.Errorf("生成短代码失败: %v", err)
+		if err := s.linkSecurityService.createSecurity(tx, shortLink.ID, securitySetting, securityRules); err != nil {
+			return err
 		}
-		shortLink.ShortCode = generatedCode
-		shortLink.IsCustomCode = false
-		shortLink.IssuerNumber = issuerNumber
-	}
-
-	// 保存到数据库（使用自定义ID避免GORM自动生成）
-	if err := s.shortLinkDao.Create(shortLink); err != nil {
-		return nil, err
-	}
-	if len(req.TagIDs) > 0 {
-		if err := s.tagDao.ReplaceShortLinkTags(shortLink.ID, req.TagIDs); err != nil {
-			return nil, err
+		for i := range req.Routes {
+			if _, err := s.linkRouteService.createRoute(tx, shortLink.ID, workspaceID, userID, &req.Routes[i]); err != nil {
+				return err
+			}
 		}
-	}
-	if err := s.linkSecurityService.ApplyCreateSecurity(shortLink, userID, req.Security); err != nil {
+		return nil
+	}); err != nil {
 		return nil, err
 	}
 
 	// 缓存到Redis
 	s.cacheShortLink(shortLink)
+	NewLinkMetadataService(s.helper).FetchInBackground(shortLink)
 
 	return s.modelToResponse(shortLink), nil
 }
@@ -248,6 +265,11 @@ func (s *ShortLinkService) UpdateShortLink(id uint64, req *dto.UpdateShortLinkRe
 }
 
 func (s *ShortLinkService) UpdateShortLinkInWorkspace(id uint64, req *dto.UpdateShortLinkRequest, workspaceID, userID uint64) (*dto.ShortLinkResponse, error) {
+	return s.updateShortLinkInWorkspace(id, req, workspaceID, userID, nil)
+}
+
+// updateShortLinkInWorkspace 更新短网址，routes 不为空时在同一事务内整体替换路由
+func (s *ShortLinkService) updateShortLinkInWorkspace(id uint64, req *dto.UpdateShortLinkRequest, workspaceID, userID uint64, routes []dto.LinkRouteRequest) (*dto.ShortLinkResponse, error) {
 	shortLink, err := s.shortLinkDao.FindByID(id)
 	if workspaceID > 0 {
 		shortLink, err = s.shortLinkDao.FindByIDInWorkspace(id, workspaceID)
@@ -258,6 +280,8 @@ func (s *ShortLinkService) UpdateShortLinkInWorkspace(id uint64, req *dto.Update
 		}
 		return nil, err
 	}
+	previousOriginalURL := shortLink.OriginalURL
+	previousTargets := shortLink.OriginalURL + "\n" + shortLink.FallbackURL
 
 	// 更新字段
 	if req.OriginalURL != "" {
@@ -290,6 +314,17 @@ func (s *ShortLinkService) UpdateShortLinkInWorkspace(id uint64, req *dto.Update
 	if req.CampaignID != nil {
 		shortLink.CampaignID = req.CampaignID
 	}
+	customValues, removeFieldIDs, err := s.customFieldService.ResolveValues(shortLink.WorkspaceID, req.CustomFields, false)
+	if err != nil {
+		return nil, err
+	}
+	if req.ExternalID != nil {
+		externalID, err := s.resolveExternalID(*req.ExternalID, shortLink.WorkspaceID, shortLink.ID)
+		if err != nil {
+			return nil, err
+		}
+		shortLink.ExternalID = externalID
+	}
 
 	if req.Title != "" {
 		shortLink.Title = req.Title
@@ -316,6 +351,9 @@ func (s *ShortLinkService) UpdateShortLinkInWorkspace(id uint64, req *dto.Update
 	if req.Notes != "" {
 		shortLink.Notes = req.Notes
 	}
+	if req.AppendClickID != nil {
+		shortLink.AppendClickID = *req.AppendClickID
+	}
 	finalURL, err := mergeUTMToURL(shortLink.OriginalURL, shortLink.UTMSource, shortLink.UTMMedium, shortLink.UTMCampaign, shortLink.UTMTerm, shortLink.UTMContent)
 	if err != nil {
 		return nil, errors.New("无效的URL格式")
@@ -324,6 +362,12 @@ func (s *ShortLinkService) UpdateShortLinkInWorkspace(id uint64, req *dto.Update
 		return nil, errors.New("目标 URL 命中安全规则: " + result.Reason)
 	}
 	shortLink.OriginalURL = finalURL
+	shortLink.URLHash = targetURLFingerprint(finalURL)
+	if shortLink.OriginalURL+"\n"+shortLink.FallbackURL != previousTargets {
+		// 目标地址变化后清空健康状态，等待下一轮检查
+		shortLink.HealthStatus = ""
+		shortLink.HealthCheckedAt = nil
+	}
 
 	shortLink.ExpireAt = req.ExpireAt
 
@@ -333,15 +377,39 @@ func (s *ShortLinkService) UpdateShortLinkInWorkspace(id uint64, req *dto.Update
 	if userID > 0 {
 		shortLink.UpdatedBy = &userID
 	}
-
-	if err := s.shortLinkDao.Update(shortLink); err != nil {
+	reviewStatus, err := s.reviewStatusForActor(workspaceID, userID)
+	if err != nil {
 		return nil, err
 	}
-	if req.TagIDs != nil {
-		if err := s.tagDao.ReplaceShortLinkTags(shortLink.ID, req.TagIDs); err != nil {
+	if reviewStatus == model.ShortLinkReviewPending {
+		// 成员修改后需重新审核，审核通过前不可访问
+		markPendingReview(shortLink)
+	}
+	for i := range routes {
+		if err := s.linkRouteService.validateRouteRequest(workspaceID, &routes[i]); err != nil {
 			return nil, err
 		}
 	}
+
+	if err := s.helper.GetDatabase().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(shortLink).Error; err != nil {
+			return err
+		}
+		if req.TagIDs != nil {
+			if err := s.tagDao.ReplaceShortLinkTags(tx, shortLink.ID, req.TagIDs); err != nil {
+				return err
+			}
+		}
+		if err := s.customFieldService.SaveValues(tx, shortLink.ID, customValues, removeFieldIDs); err != nil {
+			return err
+		}
+		if len(routes) > 0 {
+			return s.linkRouteService.replaceRoutes(tx, shortLink.ID, workspaceID, userID, routes)
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
 	if req.Security != nil {
 		if _, err := s.linkSecurityService.UpsertSecurity(shortLink.ID, shortLink.WorkspaceID, userID, req.Security); err != nil {
 			return nil, err
@@ -350,6 +418,10 @@ func (s *ShortLinkService) UpdateShortLinkInWorkspace(id uint64, req *dto.Update
 
 	// 更新缓存
 	s.cacheShortLink(shortLink)
+	s.removeAliasCache(shortLink)
+	if shortLink.OriginalURL != previousOriginalURL {
+		NewLinkMetadataService(s.helper).FetchInBackground(shortLink)
+	}
 
 	return s.modelToResponse(shortLink), nil
 }
@@ -383,6 +455,7 @@ func (s *ShortLinkService) UpdateShortLinkStatusInWorkspace(id uint64, isActive
 
 	// 更新缓存
 	s.cacheShortLink(shortLink)
+	s.removeAliasCache(shortLink)
 
 	return s.modelToResponse(shortLink), nil
 }
@@ -410,6 +483,59 @@ func (s *ShortLinkService) BatchUpdateShortLinkStatusInWorkspace(ids []uint64, i
 	}, nil
 }
 
+// MoveShortLinkInWorkspace 移动短网址到文件夹，folderID 为空表示移回根目录
+func (s *ShortLinkService) MoveShortLinkInWorkspace(id uint64, folderID *uint64, workspaceID, userID uint64) (*dto.ShortLinkResponse, error) {
+	if folderID != nil {
+		if _, err := NewFolderService(s.helper).findFolder(*folderID, workspaceID); err != nil {
+			return nil, err
+		}
+	}
+	return s.moveShortLink(id, folderID, workspaceID, userID)
+}
+
+// BatchMoveShortLinksInWorkspace 批量移动短网址到文件夹
+func (s *ShortLinkService) BatchMoveShortLinksInWorkspace(ids []uint64, folderID *uint64, workspaceID, userID uint64) (*dto.BatchMoveShortLinkResponse, error) {
+	if folderID != nil {
+		if _, err := NewFolderService(s.helper).findFolder(*folderID, workspaceID); err != nil {
+			return nil, err
+		}
+	}
+	success := make([]uint64, 0, len(ids))
+	failed := make([]dto.BatchShortLinkOperationFailedItem, 0)
+
+	for _, id := range uniqueShortLinkIDs(ids) {
+		if _, err := s.moveShortLink(id, folderID, workspaceID, userID); err != nil {
+			failed = append(failed, dto.BatchShortLinkOperationFailedItem{
+				ID:    id,
+				Error: err.Error(),
+			})
+			continue
+		}
+		success = append(success, id)
+	}
+
+	return &dto.BatchMoveShortLinkResponse{
+		Success: success,
+		Failed:  failed,
+	}, nil
+}
+
+func (s *ShortLinkService) moveShortLink(id uint64, folderID *uint64, workspaceID, userID uint64) (*dto.ShortLinkResponse, error) {
+	shortLink, err := s.shortLinkDao.FindByIDInWorkspace(id, workspaceID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("短网址不存在")
+		}
+		return nil, err
+	}
+	if err := s.shortLinkDao.UpdateFolder(shortLink.ID, folderID, actorPtr(userID)); err != nil {
+		return nil, err
+	}
+	shortLink.FolderID = folderID
+	shortLink.UpdatedBy = actorPtr(userID)
+	return s.modelToResponse(shortLink), nil
+}
+
 // DeleteShortLink 删除短网址
 func (s *ShortLinkService) DeleteShortLink(id uint64) error {
 	return s.DeleteShortLinkInWorkspace(id, 1)
@@ -438,6 +564,7 @@ func (s *ShortLinkService) DeleteShortLinkInWorkspace(id, workspaceID uint64) er
 
 	// 从缓存中删除
 	s.removeCacheShortLink(shortLink.Domain, shortLink.GetShortCode())
+	s.removeAliasCache(shortLink)
 
 	return nil
 }
@@ -464,6 +591,143 @@ func (s *ShortLinkService) BatchDeleteShortLinksInWorkspace(ids []uint64, worksp
 	}, nil
 }
 
+// defaultTrashRetentionDays 回收站默认保留天数
+const defaultTrashRetentionDays = 30
+
+// trashPurgeBatchSize 自动清理时每批处理的短网址数量
+const trashPurgeBatchSize = 100
+
+// trashRetentionDays 回收站保留天数，<=0 表示不自动清理
+func (s *ShortLinkService) trashRetentionDays() int {
+	return s.helper.GetConfig().GetInt("trash.retention_days", defaultTrashRetentionDays)
+}
+
+// GetTrashListInWorkspace 获取回收站列表
+func (s *ShortLinkService) GetTrashListInWorkspace(req *dto.ShortLinkTrashListRequest, workspaceID uint64) (*dto.ShortLinkTrashListResponse, error) {
+	if req.Page < 1 {
+		req.Page = 1
+	}
+	if req.PageSize < 1 || req.PageSize > 100 {
+		req.PageSize = 10
+	}
+
+	offset := (req.Page - 1) * req.PageSize
+	shortLinks, total, err := s.shortLinkDao.ListTrashInWorkspace(workspaceID, offset, req.PageSize, req)
+	if err != nil {
+		return nil, err
+	}
+
+	retentionDays := s.trashRetentionDays()
+	responses := make([]dto.ShortLinkTrashResponse, 0, len(shortLinks))
+	for _, shortLink := range shortLinks {
+		item := dto.ShortLinkTrashResponse{
+			ID:          shortLink.ID,
+			WorkspaceID: shortLink.WorkspaceID,
+			ShortCode:   shortLink.GetShortCode(),
+			Domain:      shortLink.Domain,
+			ShortURL:    shortLink.GetFullURL(),
+			OriginalURL: shortLink.OriginalURL,
+			Title:       shortLink.Title,
+			ClickCount:  shortLink.ClickCount,
+			CreatedBy:   shortLink.CreatedBy,
+			CreatedAt:   shortLink.CreatedAt,
+			DeletedAt:   shortLink.DeletedAt.Time,
+		}
+		if retentionDays > 0 {
+			purgeAt := shortLink.DeletedAt.Time.AddDate(0, 0, retentionDays)
+			item.PurgeAt = &purgeAt
+		}
+		responses = append(responses, item)
+	}
+
+	return &dto.ShortLinkTrashListResponse{
+		List:          responses,
+		Total:         total,
+		Page:          req.Page,
+		Size:          req.PageSize,
+		RetentionDays: retentionDays,
+	}, nil
+}
+
+// RestoreShortLinkInWorkspace 从回收站恢复短网址。只有禁用的短网址才能删除，
+// 恢复不修改启用状态，因此恢复后仍为禁用，需要手动启用
+func (s *ShortLinkService) RestoreShortLinkInWorkspace(id, workspaceID uint64) (*dto.ShortLinkResponse, error) {
+	shortLink, err := s.shortLinkDao.FindTrashedByIDInWorkspace(id, workspaceID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("回收站中不存在该短网址")
+		}
+		return nil, err
+	}
+
+	// 删除后短代码可能已被重新使用，恢复会违反 domain + short_code 唯一约束
+	if shortLink.ShortCode != "" {
+		exists, err := s.isShortCodeTaken(shortLink.Domain, shortLink.ShortCode)
+		if err != nil {
+			return nil, err
+		}
+		if exists {
+			return nil, errors.New("短代码已被其他短网址占用，无法恢复")
+		}
+	}
+	if shortLink.ExternalID != nil && *shortLink.ExternalID != "" {
+		exists, err := s.shortLinkDao.ExistsByExternalID(*shortLink.ExternalID, shortLink.WorkspaceID)
+		if err != nil {
+			return nil, err
+		}
+		if exists {
+			return nil, errors.New("外部标识已被其他短网址占用，无法恢复")
+		}
+	}
+
+	if err := s.shortLinkDao.Restore(id); err != nil {
+		return nil, err
+	}
+
+	restored, err := s.shortLinkDao.FindByIDInWorkspace(id, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	return s.modelToResponse(restored), nil
+}
+
+// PurgeShortLinkInWorkspace 彻底删除回收站中的短网址
+func (s *ShortLinkService) PurgeShortLinkInWorkspace(id, workspaceID uint64) error {
+	if _, err := s.shortLinkDao.FindTrashedByIDInWorkspace(id, workspaceID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("回收站中不存在该短网址")
+		}
+		return err
+	}
+	return s.shortLinkDao.Purge(id)
+}
+
+// PurgeExpiredTrash 清理超过保留期的回收站短网址，返回清理数量
+func (s *ShortLinkService) PurgeExpiredTrash() (int, error) {
+	retentionDays := s.trashRetentionDays()
+	if retentionDays <= 0 {
+		return 0, nil
+	}
+
+	cutoff := time.Now().AddDate(0, 0, -retentionDays)
+	purged := 0
+	for {
+		shortLinks, err := s.shortLinkDao.FindTrashedBefore(cutoff, trashPurgeBatchSize)
+		if err != nil {
+			return purged, err
+		}
+		for _, shortLink := range shortLinks {
+			if err := s.shortLinkDao.Purge(shortLink.ID); err != nil {
+				return purged, err
+			}
+			purged++
+		}
+		if len(shortLinks) < trashPurgeBatchSize {
+			return purged, nil
+		}
+	}
+}
+
 // GetShortLinkList 获取短网址列表
 func (s *ShortLinkService) GetShortLinkList(req *dto.ShortLinkListRequest) (*dto.ShortLinkListResponse, error) {
 	return s.GetShortLinkListInWorkspace(req, 1)
@@ -478,6 +742,22 @@ func (s *ShortLinkService) GetShortLinkListInWorkspace(req *dto.ShortLinkListReq
 		req.PageSize = 10
 	}
 
+	if req.Q != "" && req.Location == nil {
+		location, err := ReportLocation(s.helper, workspaceID, "")
+		if err != nil {
+			return nil, err
+		}
+		req.Location = location
+	}
+
+	if req.Subfolders && req.FolderID != nil && *req.FolderID > 0 {
+		folderIDs, err := NewFolderService(s.helper).SubtreeIDs(*req.FolderID, workspaceID)
+		if err != nil {
+			return nil, err
+		}
+		req.FolderIDs = folderIDs
+	}
+
 	offset := (req.Page - 1) * req.PageSize
 	shortLinks, total, err := s.shortLinkDao.ListInWorkspace(workspaceID, offset, req.PageSize, req)
 	if err != nil {
@@ -490,11 +770,18 @@ func (s *ShortLinkService) GetShortLinkListInWorkspace(req *dto.ShortLinkListReq
 		responses = append(responses, *s.modelToResponse(&shortLink))
 	}
 
+	nextCursor := ""
+	if len(shortLinks) == req.PageSize {
+		sort, _ := dao.ParseShortLinkSort(req.Sort)
+		nextCursor = dao.EncodeShortLinkCursor(sort, &shortLinks[len(shortLinks)-1])
+	}
+
 	return &dto.ShortLinkListResponse{
-		List:  responses,
-		Total: total,
-		Page:  req.Page,
-		Size:  req.PageSize,
+		List:       responses,
+		Total:      total,
+		Page:       req.Page,
+		Size:       req.PageSize,
+		NextCursor: nextCursor,
 	}, nil
 }
 
@@ -513,12 +800,12 @@ func (s *ShortLinkService) RedirectShortLink(domain, shortCode, clientIP, userAg
 			return "", err
 		}
 
-		// 缓存到Redis
-		s.cacheShortLink(shortLink)
+		// 缓存到Redis，通过别名访问时以别名为键
+		s.cacheShortLinkAs(domain, shortCode, shortLink)
 	}
 
-	// 检查是否激活
-	if !shortLink.IsActive {
+	// 检查是否激活，待审核或审核未通过视为未激活
+	if !shortLink.IsActive || shortLink.IsBlockedByReview() {
 		return "", errors.New("短网址已被禁用")
 	}
 
@@ -529,8 +816,8 @@ func (s *ShortLinkService) RedirectShortLink(domain, shortCode, clientIP, userAg
 
 	// 异步记录点击统计
 	if clientIP != "" { // 只有非预览请求才记录统计
-		go s.recordClickStatistic(shortLink, clientIP, userAgent, referer, queryParams)
-		go s.incrementClickCount(shortLink.ID)
+		bot := NewBotDetector(s.helper).Classify(&BotSignals{WorkspaceID: shortLink.WorkspaceID, ClientIP: clientIP, UserAgent: userAgent})
+		go s.recordClickStatistic(shortLink, domain, shortCode, clientIP, userAgent, referer, queryParams, newClickID(shortLink.WorkspaceID), bot)
 	}
 
 	return shortLink.OriginalURL, nil
@@ -550,6 +837,15 @@ func (s *ShortLinkService) ResolveRedirectWithSecurity(domain, shortCode, client
 }
 
 func (s *ShortLinkService) ResolveRedirectWithSecurityAndLanguage(domain, shortCode, clientIP, userAgent, referer, queryString, accessToken, acceptLanguage string) (*RedirectDecision, error) {
+	return s.resolveRedirect(domain, shortCode, clientIP, userAgent, referer, queryString, accessToken, acceptLanguage, nil)
+}
+
+// ResolveRedirectWithHeaders 使用完整请求头解析跳转，请求头同时用于机器人识别
+func (s *ShortLinkService) ResolveRedirectWithHeaders(domain, shortCode, clientIP, referer, queryString, accessToken string, headers http.Header) (*RedirectDecision, error) {
+	return s.resolveRedirect(domain, shortCode, clientIP, headers.Get("User-Agent"), referer, queryString, accessToken, headers.Get("Accept-Language"), headers)
+}
+
+func (s *ShortLinkService) resolveRedirect(domain, shortCode, clientIP, userAgent, referer, queryString, accessToken, acceptLanguage string, headers http.Header) (*RedirectDecision, error) {
 
 	// 先从缓存查找
 	shortLink, err := s.getShortLinkFromCache(domain, shortCode)
@@ -564,12 +860,12 @@ func (s *ShortLinkService) ResolveRedirectWithSecurityAndLanguage(domain, shortC
 			return nil, err
 		}
 
-		// 缓存到Redis
-		s.cacheShortLink(shortLink)
+		// 缓存到Redis，通过别名访问时以别名为键
+		s.cacheShortLinkAs(domain, shortCode, shortLink)
 	}
 
-	// 检查是否激活
-	if !shortLink.IsActive {
+	// 检查是否激活，待审核或审核未通过视为未激活
+	if !shortLink.IsActive || shortLink.IsBlockedByReview() {
 		return nil, errors.New("短网址已被禁用")
 	}
 
@@ -578,7 +874,8 @@ func (s *ShortLinkService) ResolveRedirectWithSecurityAndLanguage(domain, shortC
 		return nil, errors.New("短网址已过期")
 	}
 
-	setting, err := s.linkSecurityService.EvaluateRedirect(shortLink, domain, shortCode, clientIP, userAgent, referer, accessToken)
+	bot := NewBotDetector(s.helper).Classify(&BotSignals{WorkspaceID: shortLink.WorkspaceID, ClientIP: clientIP, UserAgent: userAgent, Headers: headers})
+	setting, err := s.linkSecurityService.EvaluateRedirect(shortLink, domain, shortCode, clientIP, userAgent, referer, accessToken, bot)
 	if err != nil {
 		return &RedirectDecision{
 			ShortLink:     shortLink,
@@ -609,24 +906,23 @@ func (s *ShortLinkService) ResolveRedirectWithSecurityAndLanguage(domain, shortC
 	var targetURL string
 	var matchedRoute *model.LinkRoute
 	var abTestInfo *dto.ABTestRedirectInfo
+	clickID := ""
 	if clientIP != "" { // 只有非预览请求才记录统计和检查AB测试
+		clickID = newClickID(shortLink.WorkspaceID)
 		if routeResult.RoutingEnabled {
 			targetURL = routeResult.TargetURL
 			matchedRoute = routeResult.Route
-			go s.recordClickStatisticWithRoute(shortLink, matchedRoute, clientIP, userAgent, referer, queryString)
-			go s.incrementClickCount(shortLink.ID)
+			go s.recordClickStatisticWithRoute(shortLink, matchedRoute, domain, shortCode, clientIP, userAgent, referer, queryString, clickID, bot)
 		} else if info, err := s.abTestService.GetABTestRedirectInfo(shortLink.ID, clientIP, userAgent); err == nil && info != nil {
 			// 有AB测试，使用AB测试的目标URL
 			abTestInfo = info
 			targetURL = abTestInfo.TargetURL
-			go s.recordClickStatistic(shortLink, clientIP, userAgent, referer, queryString)
-			go s.incrementClickCount(shortLink.ID)
-			go s.abTestService.RecordABTestClick(abTestInfo, clientIP, userAgent, referer, queryString)
+			go s.recordClickStatistic(shortLink, domain, shortCode, clientIP, userAgent, referer, queryString, clickID, bot)
+			go s.abTestService.RecordABTestClick(abTestInfo, clientIP, userAgent, referer, queryString, bot)
 		} else {
 			// 没有AB测试，使用原始URL
 			targetURL = shortLink.OriginalURL
-			go s.recordClickStatistic(shortLink, clientIP, userAgent, referer, queryString)
-			go s.incrementClickCount(shortLink.ID)
+			go s.recordClickStatistic(shortLink, domain, shortCode, clientIP, userAgent, referer, queryString, clickID, bot)
 		}
 	} else {
 		if routeResult.RoutingEnabled {
@@ -642,7 +938,7 @@ func (s *ShortLinkService) ResolveRedirectWithSecurityAndLanguage(domain, shortC
 	domainInfo, err := s.domainDao.FindByDomain(domain)
 	if err != nil {
 		// 如果查找域名配置失败，默认不透传参数，直接返回目标URL
-		return &RedirectDecision{TargetURL: s.withABTestFeedbackToken(targetURL, abTestInfo), StatusCode: redirectCode, ShortLink: shortLink, Security: setting, Route: matchedRoute, ReportEnabled: setting != nil && setting.ReportEnabled}, nil
+		return &RedirectDecision{TargetURL: s.withTrackingParams(targetURL, shortLink, abTestInfo, clickID), StatusCode: redirectCode, ShortLink: shortLink, ClickID: clickID, Security: setting, Route: matchedRoute, ReportEnabled: setting != nil && setting.ReportEnabled}, nil
 	}
 
 	// 构建最终的跳转URL
@@ -654,7 +950,7 @@ func (s *ShortLinkService) ResolveRedirectWithSecurityAndLanguage(domain, shortC
 		origURL, err := url.Parse(targetURL)
 		if err != nil {
 			// 如果解析失败，返回目标URL
-			return &RedirectDecision{TargetURL: s.withABTestFeedbackToken(targetURL, abTestInfo), StatusCode: redirectCode, ShortLink: shortLink, Security: setting, Route: matchedRoute, ReportEnabled: setting != nil && setting.ReportEnabled}, nil
+			return &RedirectDecision{TargetURL: s.withTrackingParams(targetURL, shortLink, abTestInfo, clickID), StatusCode: redirectCode, ShortLink: shortLink, ClickID: clickID, Security: setting, Route: matchedRoute, ReportEnabled: setting != nil && setting.ReportEnabled}, nil
 		}
 
 		// 解析查询参数
@@ -675,8 +971,37 @@ func (s *ShortLinkService) ResolveRedirectWithSecurityAndLanguage(domain, shortC
 		finalURL = origURL.String()
 	}
 
-	finalURL = s.withABTestFeedbackToken(finalURL, abTestInfo)
-	return &RedirectDecision{TargetURL: finalURL, StatusCode: redirectCode, ShortLink: shortLink, Security: setting, Route: matchedRoute, ReportEnabled: setting != nil && setting.ReportEnabled}, nil
+	finalURL = s.withTrackingParams(finalURL, shortLink, abTestInfo, clickID)
+	return &RedirectDecision{TargetURL: finalURL, StatusCode: redirectCode, ShortLink: shortLink, ClickID: clickID, Security: setting, Route: matchedRoute, ReportEnabled: setting != nil && setting.ReportEnabled}, nil
+}
+
+// withTrackingParams 追加 A/B 测试反馈令牌，开启追加点击ID的短网址同时追加点击ID
+func (s *ShortLinkService) withTrackingParams(targetURL string, shortLink *model.ShortLink, abTestInfo *dto.ABTestRedirectInfo, clickID string) string {
+	targetURL = s.withABTestFeedbackToken(targetURL, abTestInfo)
+	if clickID == "" || !shortLink.AppendClickID {
+		return targetURL
+	}
+	parsedURL, err := url.Parse(targetURL)
+	if err != nil {
+		return targetURL
+	}
+	query := parsedURL.Query()
+	query.Set(conversionClickIDParam(s.helper), clickID)
+	parsedURL.RawQuery = query.Encode()
+	return parsedURL.String()
+}
+
+// newClickID 生成点击ID，用于转化归因，格式为 工作区ID-32位十六进制随机数，随机部分不可猜测。
+// 带上工作区ID，回传时可以先校验签名再查找点击。
+func newClickID(workspaceID uint64) string {
+	bytes := make([]byte, 16)
+	random := ""
+	if _, err := rand.Read(bytes); err != nil {
+		random = fmt.Sprintf("%032x", time.Now().UnixNano())
+	} else {
+		random = hex.EncodeToString(bytes)
+	}
+	return strconv.FormatUint(workspaceID, 10) + "-" + random
 }
 
 func (s *ShortLinkService) withABTestFeedbackToken(targetURL string, abTestInfo *dto.ABTestRedirectInfo) string {
@@ -699,19 +1024,39 @@ func (s *ShortLinkService) findShortLinkByCode(domain, shortCode string) (*model
 	var shortLink model.ShortLink
 	link := model.ShortLink{}
 	err := s.helper.GetDatabase().Table(link.TableName()).Where("domain = ? AND short_code = ? AND deleted_at IS NULL", domain, shortCode).First(&shortLink).Error
+	if err == nil {
+		return &shortLink, nil
+	}
+
+	// 策略2：通过别名查找（重新分配短码后保留的旧短码）
+	alias, err := s.shortLinkAliasDao.FindByDomainAndCode(domain, shortCode)
+	if err != nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+	aliased, err := s.shortLinkDao.FindByIDInWorkspace(alias.ShortLinkID, alias.WorkspaceID)
 	if err != nil {
 		return nil, gorm.ErrRecordNotFound
 	}
+	return aliased, nil
+}
 
-	return &shortLink, nil
+// isShortCodeTaken 域名+短码是否已被短网址或别名占用
+func (s *ShortLinkService) isShortCodeTaken(domain, shortCode string) (bool, error) {
+	exists, err := s.shortLinkDao.ExistsByDomainAndCode(domain, shortCode)
+	if err != nil || exists {
+		return exists, err
+	}
+	return s.shortLinkAliasDao.ExistsByDomainAndCode(domain, shortCode)
 }
 
 // GetShortLinkStatistics 获取短网址统计信息
 func (s *ShortLinkService) GetShortLinkStatistics(id uint64, days int) (*dto.ShortLinkStatisticResponse, error) {
-	return s.GetShortLinkStatisticsInWorkspace(id, days, 1)
+	return s.GetShortLinkStatisticsInWorkspace(id, days, 1, time.Local, false)
 }
 
-func (s *ShortLinkService) GetShortLinkStatisticsInWorkspace(id uint64, days int, workspaceID uint64) (*dto.ShortLinkStatisticResponse, error) {
+// GetShortLinkStatisticsInWorkspace 按 location 划分今天、近一周、近一月和每日统计，
+// includeDuplicates 为 true 时各项点击数包含去重窗口内的重复点击
+func (s *ShortLinkService) GetShortLinkStatisticsInWorkspace(id uint64, days int, workspaceID uint64, location *time.Location, includeDuplicates bool) (*dto.ShortLinkStatisticResponse, error) {
 	shortLink, err := s.shortLinkDao.FindByID(id)
 	if workspaceID > 0 {
 		shortLink, err = s.shortLinkDao.FindByIDInWorkspace(id, workspaceID)
@@ -723,35 +1068,53 @@ func (s *ShortLinkService) GetShortLinkStatisticsInWorkspace(id uint64, days int
 		return nil, err
 	}
 
-	now := time.Now()
-	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	now := time.Now().In(location)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, location)
 	weekAgo := today.AddDate(0, 0, -7)
 	monthAgo := today.AddDate(0, -1, 0)
 
 	// 获取各时间段的点击数
-	todayClicks, _ := s.shortLinkDao.GetClickCountByDateRange(id, today, today.AddDate(0, 0, 1))
-	weekClicks, _ := s.shortLinkDao.GetClickCountByDateRange(id, weekAgo, now)
-	monthClicks, _ := s.shortLinkDao.GetClickCountByDateRange(id, monthAgo, now)
+	todayClicks, _ := s.shortLinkDao.GetClickCountByDateRange(id, today, today.AddDate(0, 0, 1), includeDuplicates)
+	weekClicks, _ := s.shortLinkDao.GetClickCountByDateRange(id, weekAgo, now, includeDuplicates)
+	monthClicks, _ := s.shortLinkDao.GetClickCountByDateRange(id, monthAgo, now, includeDuplicates)
+
+	// 合并每日草图得到各时间段的独立访客数
+	visitorSvc := NewVisitorService(s.helper)
+	countVisitors := func(start, end time.Time) int64 {
+		count, _ := visitorSvc.CountUniqueVisitors(shortLink.WorkspaceID, &dto.ClickStatisticListRequest{ShortLinkID: id, StartDate: start, EndDate: end})
+		return count
+	}
+	tomorrow := today.AddDate(0, 0, 1)
 
 	// 获取每日统计
-	dailyStats, _ := s.shortLinkDao.GetDailyClickCount(id, days)
+	dailyStats, _ := s.shortLinkDao.GetDailyClickCount(id, days, location, includeDuplicates)
 	dailyStatistics := make([]dto.ClickStatisticResponse, 0)
 
 	// 填充每日统计数据
 	for i := days - 1; i >= 0; i-- {
-		date := now.AddDate(0, 0, -i).Format("2006-01-02")
+		day := today.AddDate(0, 0, -i)
+		date := day.Format("2006-01-02")
 		count := dailyStats[date]
 		dailyStatistics = append(dailyStatistics, dto.ClickStatisticResponse{
-			Date:       date,
-			ClickCount: count,
+			Date:           date,
+			ClickCount:     count,
+			UniqueVisitors: countVisitors(day, day.AddDate(0, 0, 1)),
 		})
 	}
 
+	totalClicks := shortLink.ClickCount
+	if includeDuplicates {
+		totalClicks += shortLink.DuplicateClickCount
+	}
 	return &dto.ShortLinkStatisticResponse{
-		TotalClicks:     shortLink.ClickCount,
+		TotalClicks:     totalClicks,
+		DuplicateClicks: shortLink.DuplicateClickCount,
 		TodayClicks:     todayClicks,
 		WeekClicks:      weekClicks,
 		MonthClicks:     monthClicks,
+		TodayVisitors:   countVisitors(today, tomorrow),
+		WeekVisitors:    countVisitors(weekAgo, tomorrow),
+		MonthVisitors:   countVisitors(monthAgo, tomorrow),
 		DailyStatistics: dailyStatistics,
 	}, nil
 }
@@ -765,18 +1128,25 @@ func (s *ShortLinkService) BatchCreateShortLinksInWorkspace(req *dto.BatchCreate
 	success := make([]dto.ShortLinkResponse, 0)
 	failed := make([]dto.BatchFailedItem, 0)
 
-	domain := req.Domain
-	if domain == "" {
-		domain = s.helper.GetEnv().GetString("shortlink_domain", "http://localhost:8080")
+	// 模板只解析一次，每条短网址复制同一份预设
+	base := dto.CreateShortLinkRequest{
+		Domain:        req.Domain,
+		TemplateID:    req.TemplateID,
+		CustomFields:  req.CustomFields,
+		AppendClickID: req.AppendClickID,
+	}
+	if err := NewLinkTemplateService(s.helper).ApplyCreateTemplate(workspaceID, &base); err != nil {
+		return nil, err
+	}
+	if base.Domain == "" {
+		base.Domain = s.helper.GetEnv().GetString("shortlink_domain", "http://localhost:8080")
 	}
 
 	for _, originalURL := range req.URLs {
-		createReq := &dto.CreateShortLinkRequest{
-			OriginalURL: originalURL,
-			Domain:      domain,
-		}
+		createReq := cloneCreateShortLinkRequest(&base)
+		createReq.OriginalURL = originalURL
 
-		response, err := s.CreateShortLinkInWorkspace(createReq, creatorIP, workspaceID, userID)
+		response, err := s.CreateShortLinkInWorkspace(&createReq, creatorIP, workspaceID, userID)
 		if err != nil {
 			failed = append(failed, dto.BatchFailedItem{
 				URL:   originalURL,
@@ -793,6 +1163,50 @@ func (s *ShortLinkService) BatchCreateShortLinksInWorkspace(req *dto.BatchCreate
 	}, nil
 }
 
+// cloneCreateShortLinkRequest 深拷贝创建请求，批量创建时每条短网址各自修改预设互不影响
+func cloneCreateShortLinkRequest(req *dto.CreateShortLinkRequest) dto.CreateShortLinkRequest {
+	cloned := *req
+	cloned.ExpireAt = clonePtr(req.ExpireAt)
+	cloned.CampaignID = clonePtr(req.CampaignID)
+	cloned.FolderID = clonePtr(req.FolderID)
+	cloned.TemplateID = clonePtr(req.TemplateID)
+	cloned.Reuse = clonePtr(req.Reuse)
+	cloned.TagIDs = slices.Clone(req.TagIDs)
+	cloned.CustomFields = maps.Clone(req.CustomFields)
+	if req.Security != nil {
+		security := *req.Security
+		security.Password = clonePtr(req.Security.Password)
+		security.PasswordEnabled = clonePtr(req.Security.PasswordEnabled)
+		security.AccessWindowStart = clonePtr(req.Security.AccessWindowStart)
+		security.AccessWindowEnd = clonePtr(req.Security.AccessWindowEnd)
+		security.MaxClicks = clonePtr(req.Security.MaxClicks)
+		security.ReportEnabled = clonePtr(req.Security.ReportEnabled)
+		security.IPRules = slices.Clone(req.Security.IPRules)
+		cloned.Security = &security
+	}
+	if req.Routes != nil {
+		cloned.Routes = make([]dto.LinkRouteRequest, len(req.Routes))
+		for i, route := range req.Routes {
+			route.IsActive = clonePtr(route.IsActive)
+			groups := make([]dto.LinkRouteConditionGroupRequest, len(route.ConditionGroups))
+			for j, group := range route.ConditionGroups {
+				groups[j].Conditions = slices.Clone(group.Conditions)
+			}
+			route.ConditionGroups = groups
+			cloned.Routes[i] = route
+		}
+	}
+	return cloned
+}
+
+func clonePtr[T any](value *T) *T {
+	if value == nil {
+		return nil
+	}
+	copied := *value
+	return &copied
+}
+
 func uniqueShortLinkIDs(ids []uint64) []uint64 {
 	seen := make(map[uint64]struct{}, len(ids))
 	uniqueIDs := make([]uint64, 0, len(ids))
@@ -808,14 +1222,104 @@ func uniqueShortLinkIDs(ids []uint64) []uint64 {
 
 // 私有方法
 
-// validateDomain 验证域名
+// findActiveDomainInWorkspace 校验域名格式，并返回当前工作区下已激活的域名
+func (s *ShortLinkService) findActiveDomainInWorkspace(domain string, workspaceID uint64) (*model.Domain, error) {
+	// 验证域名格式,域名不能带有协议头
+	if err := s.validateDomain(domain); err != nil {
+		return nil, err
+	}
+
+	// 验证域名是否存在且活跃并获取域名信息
+	domainInfo, err := s.domainDao.FindByDomain(domain)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("域名不存在")
+		}
+		return nil, err
+	}
+	if !domainInfo.IsActive {
+		return nil, errors.New("域名未激活")
+	}
+	if domainInfo.WorkspaceID != workspaceID {
+		return nil, errors.New("域名不存在")
+	}
+	return domainInfo, nil
+}
+
+// assignShortCode 为短网址设置自定义短代码，或按域名配置通过发号器生成
+func (s *ShortLinkService) assignShortCode(shortLink *model.ShortLink, domainInfo *model.Domain, customCode string) error {
+	// 处理自定义短代码
+	if customCode != "" {
+
+		// 检查自定义短代码是否已存在
+		exists, err := s.isShortCodeTaken(shortLink.Domain, customCode)
+		if err != nil {
+			return err
+		}
+		if exists {
+			return errors.New("自定义短代码已存在")
+		}
+
+		shortLink.ShortCode = customCode
+		shortLink.IsCustomCode = true
+	} else {
+		// 使用分布式发号器生成短代码，使用域名配置
+		// 处理指针类型，提供默认值
+		randomSuffixLength := 2
+		if domainInfo.RandomSuffixLength != nil {
+			randomSuffixLength = *domainInfo.RandomSuffixLength
+		}
+		enableChecksum := true
+		if domainInfo.EnableChecksum != nil {
+			enableChecksum = *domainInfo.EnableChecksum
+		}
+		enableXorObfuscation := false
+		if domainInfo.EnableXorObfuscation != nil {
+			enableXorObfuscation = *domainInfo.EnableXorObfuscation
+		}
+		xorSecret := uint64(0)
+		if domainInfo.XorSecret != nil {
+			xorSecret = *domainInfo.XorSecret
+		}
+		xorRot := 0
+		if domainInfo.XorRot != nil {
+			xorRot = *domainInfo.XorRot
+		}
+		defaultStartNumber := uint64(0)
+		if domainInfo.DefaultStartNumber != nil {
+			defaultStartNumber = *domainInfo.DefaultStartNumber
+		}
+		config := interfaces.ShortCodeConfig{
+			RandomSuffixLength:   randomSuffixLength,
+			EnableChecksum:       enableChecksum,
+			EnableXorObfuscation: enableXorObfuscation,
+			XorSecret:            xorSecret,
+			XorRot:               xorRot,
+			DefaultStartNumber:   defaultStartNumber,
+		}
+		generatedCode, issuerNumber, err := s.idGenerator.GenerateShortCodeWithConfig(domainInfo.ID, s.context, config)
+		if err != nil {
+			return fmt.Errorf("生成短代码失败: %v", err)
+		}
+		shortLink.ShortCode = generatedCode
+		shortLink.IsCustomCode = false
+		shortLink.IssuerNumber = issuerNumber
+	}
+	return nil
+}
+
 func (s *ShortLinkService) validateDomain(domain string) error {
 	return domain_validate.ValidateDomain(domain)
 }
 
 // cacheShortLink 缓存短网址到Redis
 func (s *ShortLinkService) cacheShortLink(shortLink *model.ShortLink) {
-	key := fmt.Sprintf("shortlink:%s:%s", shortLink.Domain, shortLink.GetShortCode())
+	s.cacheShortLinkAs(shortLink.Domain, shortLink.GetShortCode(), shortLink)
+}
+
+// cacheShortLinkAs 以指定的域名+短码为键缓存短网址
+func (s *ShortLinkService) cacheShortLinkAs(domain, shortCode string, shortLink *model.ShortLink) {
+	key := fmt.Sprintf("shortlink:%s:%s", domain, shortCode)
 
 	err := s.helper.GetCache().Set(s.context, key, &shortLink, 24*time.Hour)
 	if err != nil {
@@ -843,12 +1347,24 @@ func (s *ShortLinkService) removeCacheShortLink(domain, shortCode string) {
 	}
 }
 
+// removeAliasCache 删除短网址所有别名的缓存
+func (s *ShortLinkService) removeAliasCache(shortLink *model.ShortLink) {
+	aliases, err := s.shortLinkAliasDao.ListByShortLinkID(shortLink.ID, shortLink.WorkspaceID)
+	if err != nil {
+		return
+	}
+	for _, alias := range aliases {
+		s.removeCacheShortLink(alias.Domain, alias.ShortCode)
+	}
+}
+
 // recordClickStatistic 记录点击统计
-func (s *ShortLinkService) recordClickStatistic(shortLink *model.ShortLink, clientIP, userAgent, referer string, queryParams string) {
-	s.recordClickStatisticWithRoute(shortLink, nil, clientIP, userAgent, referer, queryParams)
+func (s *ShortLinkService) recordClickStatistic(shortLink *model.ShortLink, domain, shortCode, clientIP, userAgent, referer string, queryParams string, clickID string, bot BotVerdict) {
+	s.recordClickStatisticWithRoute(shortLink, nil, domain, shortCode, clientIP, userAgent, referer, queryParams, clickID, bot)
 }
 
-func (s *ShortLinkService) recordClickStatisticWithRoute(shortLink *model.ShortLink, route *model.LinkRoute, clientIP, userAgent, referer string, queryParams string) {
+// recordClickStatisticWithRoute 记录点击统计，domain/shortCode 为访问时使用的域名和短码，用于识别别名访问
+func (s *ShortLinkService) recordClickStatisticWithRoute(shortLink *model.ShortLink, route *model.LinkRoute, domain, shortCode, clientIP, userAgent, referer string, queryParams string, clickID string, bot BotVerdict) {
 	region := s.helper.GetIPRegion().Lookup(clientIP)
 	metadata := parseTrafficMetadata(userAgent)
 	var routeID *uint64
@@ -857,39 +1373,56 @@ func (s *ShortLinkService) recordClickStatisticWithRoute(shortLink *model.ShortL
 		routeID = &route.ID
 		routeName = route.Name
 	}
+	var aliasID *uint64
+	aliasCode := ""
+	if domain != shortLink.Domain || shortCode != shortLink.GetShortCode() {
+		if alias, err := s.shortLinkAliasDao.FindByDomainAndCode(domain, shortCode); err == nil && alias.ShortLinkID == shortLink.ID {
+			aliasID = &alias.ID
+			aliasCode = alias.ShortCode
+		}
+	}
+	refererHost, refererChannel := NewReferrerService(s.helper).Classify(shortLink.WorkspaceID, ShortLinkReferrerInput(shortLink, domain, referer, queryParams))
+	clickDate := time.Now()
 	statistic := &model.ClickStatistic{
-		WorkspaceID: shortLink.WorkspaceID,
-		CampaignID:  shortLink.CampaignID,
-		RouteID:     routeID,
-		RouteName:   domain_validate.TruncateString(routeName, 100),
-		ShortLinkID: shortLink.ID,
-		IP:          domain_validate.TruncateString(clientIP, 45),
-		UserAgent:   domain_validate.TruncateString(userAgent, 1024),
-		Referer:     domain_validate.TruncateString(referer, 2048),
-		QueryParams: domain_validate.TruncateString(queryParams, 2048), // 截断过长的参数
-		UTMSource:   domain_validate.TruncateString(shortLink.UTMSource, 255),
-		UTMMedium:   domain_validate.TruncateString(shortLink.UTMMedium, 255),
-		UTMCampaign: domain_validate.TruncateString(shortLink.UTMCampaign, 255),
-		UTMTerm:     domain_validate.TruncateString(shortLink.UTMTerm, 255),
-		UTMContent:  domain_validate.TruncateString(shortLink.UTMContent, 255),
-		DeviceType:  domain_validate.TruncateString(metadata.DeviceType, 50),
-		Browser:     domain_validate.TruncateString(metadata.Browser, 100),
-		OS:          domain_validate.TruncateString(metadata.OS, 100),
-		IsBot:       metadata.IsBot,
-		BotName:     domain_validate.TruncateString(metadata.BotName, 100),
-		Country:     domain_validate.TruncateString(region.Country, 100),
-		Province:    domain_validate.TruncateString(region.Province, 100),
-		City:        domain_validate.TruncateString(region.City, 100),
-		ISP:         domain_validate.TruncateString(region.ISP, 100),
-		ClickDate:   time.Now(),
-	}
-
-	s.clickStatisticDao.Create(statistic)
-}
-
-// incrementClickCount 增加点击次数
-func (s *ShortLinkService) incrementClickCount(shortLinkID uint64) {
-	s.shortLinkDao.IncrementClickCount(shortLinkID)
+		WorkspaceID:    shortLink.WorkspaceID,
+		CampaignID:     shortLink.CampaignID,
+		RouteID:        routeID,
+		RouteName:      domain_validate.TruncateString(routeName, 100),
+		AliasID:        aliasID,
+		AliasCode:      aliasCode,
+		ClickID:        clickID,
+		VisitorKey:     NewVisitorService(s.helper).VisitorKey(clientIP, userAgent, clickDate),
+		ShortLinkID:    shortLink.ID,
+		IP:             domain_validate.TruncateString(NewDataRetentionService(s.helper).StoredIP(shortLink.WorkspaceID, clientIP), 45),
+		UserAgent:      domain_validate.TruncateString(userAgent, 1024),
+		Referer:        domain_validate.TruncateString(referer, 2048),
+		RefererHost:    domain_validate.TruncateString(refererHost, 255),
+		RefererChannel: refererChannel,
+		QueryParams:    domain_validate.TruncateString(queryParams, 2048), // 截断过长的参数
+		UTMSource:      domain_validate.TruncateString(shortLink.UTMSource, 255),
+		UTMMedium:      domain_validate.TruncateString(shortLink.UTMMedium, 255),
+		UTMCampaign:    domain_validate.TruncateString(shortLink.UTMCampaign, 255),
+		UTMTerm:        domain_validate.TruncateString(shortLink.UTMTerm, 255),
+		UTMContent:     domain_validate.TruncateString(shortLink.UTMContent, 255),
+		DeviceType:     domain_validate.TruncateString(metadata.DeviceType, 50),
+		Browser:        domain_validate.TruncateString(metadata.Browser, 100),
+		OS:             domain_validate.TruncateString(metadata.OS, 100),
+		IsBot:          bot.IsBot,
+		BotName:        domain_validate.TruncateString(bot.BotName, 100),
+		BotScore:       bot.Score,
+		BotReason:      domain_validate.TruncateString(bot.Reason, 255),
+		Country:        domain_validate.TruncateString(region.Country, 100),
+		Province:       domain_validate.TruncateString(region.Province, 100),
+		City:           domain_validate.TruncateString(region.City, 100),
+		ISP:            domain_validate.TruncateString(region.ISP, 100),
+		ClickDate:      clickDate,
+	}
+
+	// 写入时识别去重窗口内的重复点击，并累加点击次数或重复点击次数
+	if err := NewClickDedupService(s.helper).Record(statistic); err != nil {
+		return
+	}
+	NewClickStreamService(s.helper).Publish(shortLink, domain, shortCode, statistic)
 }
 
 // modelToResponse 将模型转换为响应格式
@@ -928,6 +1461,7 @@ func (s *ShortLinkService) modelToResponse(shortLink *model.ShortLink) *dto.Shor
 		WorkspaceID:     shortLink.WorkspaceID,
 		CampaignID:      shortLink.CampaignID,
 		CampaignName:    campaignName,
+		FolderID:        shortLink.FolderID,
 		Tags:            tagResponses,
 		ShortCode:       shortLink.GetShortCode(),
 		Domain:          shortLink.Domain,
@@ -943,9 +1477,11 @@ func (s *ShortLinkService) modelToResponse(shortLink *model.ShortLink) *dto.Shor
 		UTMTerm:         shortLink.UTMTerm,
 		UTMContent:      shortLink.UTMContent,
 		Notes:           shortLink.Notes,
+		ExternalID:      shortLink.ExternalID,
 		ExpireAt:        shortLink.ExpireAt,
 		IsActive:        shortLink.IsActive,
 		ClickCount:      shortLink.ClickCount,
+		DuplicateClicks: shortLink.DuplicateClickCount,
 		CreatedBy:       shortLink.CreatedBy,
 		UpdatedBy:       shortLink.UpdatedBy,
 		SecurityEnabled: securityEnabled,
@@ -953,11 +1489,70 @@ func (s *ShortLinkService) modelToResponse(shortLink *model.ShortLink) *dto.Shor
 		ReportEnabled:   reportEnabled,
 		RoutingEnabled:  routingEnabled,
 		RoutingSummary:  routingSummary,
+		HealthStatus:    shortLink.HealthStatus,
+		HealthCheckedAt: shortLink.HealthCheckedAt,
+		ReviewStatus:    shortLink.ReviewStatus,
+		ReviewComment:   shortLink.ReviewComment,
+		ReviewedBy:      shortLink.ReviewedBy,
+		ReviewedAt:      shortLink.ReviewedAt,
+		CustomFields:    s.customFieldService.ValuesMap(shortLink.ID),
+		AppendClickID:   shortLink.AppendClickID,
 		CreatedAt:       shortLink.CreatedAt,
 		UpdatedAt:       shortLink.UpdatedAt,
 	}
 }
 
+// shouldReuseLink 请求未指定短代码和外部标识时，按请求参数或工作区策略决定是否复用已有短网址
+func (s *ShortLinkService) shouldReuseLink(req *dto.CreateShortLinkRequest, workspaceID uint64) bool {
+	if req.CustomCode != "" || strings.TrimSpace(req.ExternalID) != "" {
+		return false
+	}
+	if req.Reuse != nil {
+		return *req.Reuse
+	}
+	workspace, err := s.workspaceDao.FindByID(workspaceID)
+	return err == nil && workspace.ReuseExistingLinks
+}
+
+// reuseIgnoredOptions 复用已有短网址时请求中未生效的选项，包括模板和文件夹带入的默认值
+func reuseIgnoredOptions(req *dto.CreateShortLinkRequest, candidate, existing *model.ShortLink) []string {
+	var ignored []string
+	add := func(name string, differs bool) {
+		if differs {
+			ignored = append(ignored, name)
+		}
+	}
+	add("title", candidate.Title != "" && candidate.Title != existing.Title)
+	add("description", candidate.Description != "" && candidate.Description != existing.Description)
+	add("fallback_url", candidate.FallbackURL != "" && candidate.FallbackURL != existing.FallbackURL)
+	add("redirect_code", candidate.RedirectCode != existing.RedirectCode)
+	add("expire_at", candidate.ExpireAt != nil && (existing.ExpireAt == nil || !candidate.ExpireAt.Equal(*existing.ExpireAt)))
+	add("campaign_id", candidate.CampaignID != nil && (existing.CampaignID == nil || *candidate.CampaignID != *existing.CampaignID))
+	add("folder_id", candidate.FolderID != nil && (existing.FolderID == nil || *candidate.FolderID != *existing.FolderID))
+	add("notes", candidate.Notes != "" && candidate.Notes != existing.Notes)
+	add("append_click_id", candidate.AppendClickID && !existing.AppendClickID)
+	add("tag_ids", len(req.TagIDs) > 0)
+	add("security", req.Security != nil)
+	add("routes", len(req.Routes) > 0)
+	return ignored
+}
+
+// resolveExternalID 校验外部标识未被工作区内其他短网址占用，空值表示不设置
+func (s *ShortLinkService) resolveExternalID(externalID string, workspaceID, excludeID uint64) (*string, error) {
+	externalID = strings.TrimSpace(externalID)
+	if externalID == "" {
+		return nil, nil
+	}
+	existing, err := s.shortLinkDao.FindByExternalIDInWorkspace(externalID, workspaceID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	if err == nil && existing.ID != excludeID {
+		return nil, errors.New("外部标识已存在")
+	}
+	return &externalID, nil
+}
+
 func (s *ShortLinkService) validateCampaignAndTags(workspaceID uint64, campaignID *uint64, tagIDs []uint64) error {
 	if campaignID != nil && *campaignID > 0 {
 		if _, err := s.campaignDao.FindByID(*campaignID, workspaceID); err != nil {
@@ -0,0 +1,647 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/dao"
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/dto"
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/model"
+	"cnb.cool/mliev/dwz/dwz-server/v2/pkg/interfaces"
+	"cnb.cool/mliev/dwz/dwz-server/v2/pkg/service/domain_validate"
+	"gorm.io/gorm"
+)
+
+const (
+	TrafficAlertEventHeader     = "X-DWZ-Event"
+	TrafficAlertTimestampHeader = "X-DWZ-Alert-Timestamp"
+	TrafficAlertSignatureHeader = "X-DWZ-Alert-Signature"
+
+	defaultTrafficAlertMinBaselineHours  = 24
+	defaultTrafficAlertDeliveryBatchSize = 100
+	defaultTrafficAlertMaxAttempts       = 5
+	defaultTrafficAlertTimeoutSeconds    = 10
+	trafficAlertMaxRetryDelay            = time.Hour
+)
+
+var (
+	ErrTrafficAlertNotFound = errors.New("告警不存在")
+	ErrAlertChannelNotFound = errors.New("告警渠道不存在")
+)
+
+// TrafficAlertService 流量异常检测、告警记录与投递
+type TrafficAlertService struct {
+	helper            interfaces.HelperInterface
+	alertDao          *dao.TrafficAlertDao
+	clickStatisticDao *dao.ClickStatisticDao
+	clickRollupDao    *dao.ClickRollupDao
+	shortLinkDao      *dao.ShortLinkDao
+	workspaceDao      *dao.WorkspaceDao
+	client            *http.Client
+}
+
+func NewTrafficAlertService(helper interfaces.HelperInterface) *TrafficAlertService {
+	timeout := helper.GetConfig().GetInt("analytics.alert_delivery_timeout_seconds", defaultTrafficAlertTimeoutSeconds)
+	if timeout <= 0 {
+		timeout = defaultTrafficAlertTimeoutSeconds
+	}
+	return &TrafficAlertService{
+		helper:            helper,
+		alertDao:          dao.NewTrafficAlertDao(helper),
+		clickStatisticDao: dao.NewClickStatisticDao(helper),
+		clickRollupDao:    dao.NewClickRollupDao(helper),
+		shortLinkDao:      dao.NewShortLinkDao(helper),
+		workspaceDao:      dao.NewWorkspaceDao(helper),
+		client:            sharedMetadataHTTPClient(time.Duration(timeout) * time.Second),
+	}
+}
+
+// defaultTrafficAlertSetting 工作区未保存设置时使用的阈值
+func defaultTrafficAlertSetting(workspaceID uint64) *model.TrafficAlertSetting {
+	return &model.TrafficAlertSetting{
+		WorkspaceID:           workspaceID,
+		Enabled:               true,
+		WindowMinutes:         60,
+		BaselineDays:          7,
+		SpikeFactor:           3,
+		SpikeMinClicks:        100,
+		DropMinHourlyClicks:   10,
+		BotRatioDelta:         0.3,
+		CountryShiftThreshold: 0.5,
+		MixMinClicks:          50,
+		CooldownMinutes:       360,
+	}
+}
+
+func (s *TrafficAlertService) setting(workspaceID uint64) (*model.TrafficAlertSetting, error) {
+	setting, err := s.alertDao.FindSetting(workspaceID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return defaultTrafficAlertSetting(workspaceID), nil
+	}
+	return setting, err
+}
+
+// DetectAnomalies 检测所有工作区最近窗口的流量异常，返回新增的告警数
+func (s *TrafficAlertService) DetectAnomalies(now time.Time) (int, error) {
+	if !s.helper.GetConfig().GetBool("analytics.anomaly_detection_enabled", true) {
+		return 0, nil
+	}
+	workspaces, err := s.workspaceDao.ListActive()
+	if err != nil {
+		return 0, err
+	}
+	created := 0
+	for i := range workspaces {
+		count, err := s.detectWorkspace(&workspaces[i], now)
+		if err != nil {
+			s.helper.GetLogger().Warn(fmt.Sprintf("[traffic-alert] 检测工作区 %d 失败: %s", workspaces[i].ID, err.Error()))
+			continue
+		}
+		created += count
+	}
+	return created, nil
+}
+
+// detectWorkspace 以窗口内的点击明细对比窗口之前若干天的小时汇总，逐个短网址和整个工作区判断异常
+func (s *TrafficAlertService) detectWorkspace(workspace *model.Workspace, now time.Time) (int, error) {
+	setting, err := s.setting(workspace.ID)
+	if err != nil || !setting.Enabled {
+		return 0, err
+	}
+	windowStart := now.Add(-time.Duration(setting.WindowMinutes) * time.Minute)
+	baselineEnd := model.ClickRollupBucketStart(windowStart, model.ClickRollupGranularityHour)
+	baselineStart := baselineEnd.AddDate(0, 0, -setting.BaselineDays)
+
+	windowCounts, err := s.clickStatisticDao.CountTrafficMixInWorkspace(workspace.ID, windowStart, now)
+	if err != nil {
+		return 0, err
+	}
+	baselineCounts, err := s.clickRollupDao.TrafficMixInWorkspace(workspace.ID, baselineStart, baselineEnd)
+	if err != nil {
+		return 0, err
+	}
+	windows := groupTrafficMix(windowCounts)
+	baselines := groupTrafficMix(baselineCounts)
+
+	var linkIDs []uint64
+	for id := range windows {
+		if id != 0 {
+			linkIDs = append(linkIDs, id)
+		}
+	}
+	for id := range baselines {
+		if _, ok := windows[id]; !ok && id != 0 {
+			linkIDs = append(linkIDs, id)
+		}
+	}
+	shortLinks, err := s.shortLinkDao.ListByIDsInWorkspace(workspace.ID, linkIDs)
+	if err != nil {
+		return 0, err
+	}
+
+	cooldown := time.Duration(setting.CooldownMinutes) * time.Minute
+	recent, err := s.alertDao.ListRecentAlerts(workspace.ID, now.Add(-cooldown))
+	if err != nil {
+		return 0, err
+	}
+	cooling := make(map[string]bool, len(recent))
+	for _, alert := range recent {
+		cooling[trafficAlertKey(alert.ShortLinkID, alert.AlertType)] = true
+	}
+	channels, err := s.alertDao.ListChannels(workspace.ID, true)
+	if err != nil {
+		return 0, err
+	}
+
+	subjects := []trafficAlertSubject{{label: "工作区 " + workspace.Name, createdAt: workspace.CreatedAt}}
+	for i := range shortLinks {
+		shortLink := &shortLinks[i]
+		if !shortLink.IsActive || (shortLink.ExpireAt != nil && shortLink.ExpireAt.Before(now)) {
+			continue
+		}
+		subjects = append(subjects, trafficAlertSubject{
+			shortLinkID: shortLink.ID,
+			label:       "短网址 " + shortLink.Domain + "/" + shortLink.ShortCode,
+			createdAt:   shortLink.CreatedAt,
+		})
+	}
+
+	created := 0
+	windowHours := now.Sub(windowStart).Hours()
+	for _, subject := range subjects {
+		// 创建时间晚于基线起点时只统计创建之后的时长，历史不足一天时不检测
+		from := baselineStart
+		if subject.createdAt.After(from) {
+			from = subject.createdAt
+		}
+		baselineHours := baselineEnd.Sub(from).Hours()
+		if baselineHours < defaultTrafficAlertMinBaselineHours {
+			continue
+		}
+		window, baseline := windows[subject.shortLinkID], baselines[subject.shortLinkID]
+		if window == nil {
+			window = &trafficSample{}
+		}
+		if baseline == nil {
+			baseline = &trafficSample{}
+		}
+		for _, anomaly := range detectTrafficAnomalies(setting, window, baseline, windowHours, baselineHours) {
+			if cooling[trafficAlertKey(subject.shortLinkID, anomaly.alertType)] {
+				continue
+			}
+			ok, err := s.createAlert(workspace.ID, subject, anomaly, window, baseline, windowStart, now, cooldown, channels)
+			if err != nil {
+				return created, err
+			}
+			if ok {
+				created++
+			}
+		}
+	}
+	return created, nil
+}
+
+// trafficAlertSubject 检测对象，short_link_id 为 0 表示整个工作区
+type trafficAlertSubject struct {
+	shortLinkID uint64
+	label       string
+	createdAt   time.Time
+}
+
+func (s *TrafficAlertService) createAlert(workspaceID uint64, subject trafficAlertSubject, anomaly trafficAnomaly, window, baseline *trafficSample, windowStart, now time.Time, cooldown time.Duration, channels []model.AlertChannel) (bool, error) {
+	details, _ := json.Marshal(map[string]any{
+		"window":   window,
+		"baseline": baseline,
+		"distance": anomaly.distance,
+	})
+	alert := &model.TrafficAlert{
+		WorkspaceID:  workspaceID,
+		ShortLinkID:  subject.shortLinkID,
+		AlertType:    anomaly.alertType,
+		DedupeBucket: now.Unix() / int64(cooldown.Seconds()),
+		Message:      domain_validate.TruncateString(subject.label+" "+anomaly.message, 500),
+		Observed:     anomaly.observed,
+		Baseline:     anomaly.baseline,
+		Details:      string(details),
+		WindowStart:  windowStart,
+		WindowEnd:    now,
+		CreatedAt:    now,
+	}
+	var deliveries []model.TrafficAlertDelivery
+	for _, channel := range channels {
+		if alertChannelAccepts(&channel, anomaly.alertType) {
+			deliveries = append(deliveries, model.TrafficAlertDelivery{
+				ChannelID:     channel.ID,
+				Status:        model.AlertDeliveryStatusPending,
+				NextAttemptAt: now,
+			})
+		}
+	}
+	created, err := s.alertDao.CreateAlert(alert, deliveries)
+	if created {
+		s.helper.GetLogger().Warn(fmt.Sprintf("[traffic-alert] 工作区 %d %s", workspaceID, alert.Message))
+	}
+	return created, err
+}
+
+func groupTrafficMix(counts []dao.TrafficMixCount) map[uint64]*trafficSample {
+	samples := map[uint64]*trafficSample{0: {}}
+	for _, count := range counts {
+		sample := samples[count.ShortLinkID]
+		if sample == nil {
+			sample = &trafficSample{}
+			samples[count.ShortLinkID] = sample
+		}
+		sample.add(count.IsBot, count.Country, count.Clicks)
+		samples[0].add(count.IsBot, count.Country, count.Clicks)
+	}
+	return samples
+}
+
+func trafficAlertKey(shortLinkID uint64, alertType string) string {
+	return strconv.FormatUint(shortLinkID, 10) + ":" + alertType
+}
+
+func alertChannelAccepts(channel *model.AlertChannel, alertType string) bool {
+	if channel.AlertTypes == "" {
+		return true
+	}
+	for _, accepted := range strings.Split(channel.AlertTypes, ",") {
+		if accepted == alertType {
+			return true
+		}
+	}
+	return false
+}
+
+// DeliverPending 投递到期的告警，失败时按指数退避重试，达到次数上限后标记为失败
+func (s *TrafficAlertService) DeliverPending(now time.Time) (int, error) {
+	deliveries, err := s.alertDao.ListDueDeliveries(now, defaultTrafficAlertDeliveryBatchSize)
+	if err != nil || len(deliveries) == 0 {
+		return 0, err
+	}
+	maxAttempts := s.helper.GetConfig().GetInt("analytics.alert_delivery_max_attempts", defaultTrafficAlertMaxAttempts)
+	if maxAttempts <= 0 {
+		maxAttempts = defaultTrafficAlertMaxAttempts
+	}
+	delivered := 0
+	for i := range deliveries {
+		delivery := &deliveries[i]
+		ok, err := s.alertDao.ClaimDelivery(delivery, now.Add(trafficAlertRetryDelay(delivery.Attempts)))
+		if err != nil {
+			return delivered, err
+		}
+		if !ok {
+			continue
+		}
+		alert, channel, err := s.alertDao.FindAlertAndChannel(delivery.AlertID, delivery.ChannelID)
+		if err != nil {
+			return delivered, err
+		}
+		updates := map[string]any{}
+		switch {
+		case channel == nil || !channel.Enabled:
+			updates["status"] = model.AlertDeliveryStatusFailed
+			updates["last_error"] = "告警渠道已删除或停用"
+		default:
+			if sendErr := s.send(channel, alert); sendErr != nil {
+				updates["last_error"] = domain_validate.TruncateString(sendErr.Error(), 500)
+				if delivery.Attempts >= maxAttempts {
+					updates["status"] = model.AlertDeliveryStatusFailed
+				}
+			} else {
+				updates["status"] = model.AlertDeliveryStatusDelivered
+				updates["last_error"] = ""
+				updates["delivered_at"] = time.Now()
+				delivered++
+			}
+		}
+		if err := s.alertDao.UpdateDelivery(delivery.ID, updates); err != nil {
+			return delivered, err
+		}
+	}
+	return delivered, nil
+}
+
+// trafficAlertRetryDelay 第 attempts+1 次投递失败后的等待时间：1、2、4 分钟……最长 1 小时
+func trafficAlertRetryDelay(attempts int) time.Duration {
+	if attempts > 6 {
+		return trafficAlertMaxRetryDelay
+	}
+	delay := time.Minute << attempts
+	if delay > trafficAlertMaxRetryDelay {
+		return trafficAlertMaxRetryDelay
+	}
+	return delay
+}
+
+// send 按渠道类型组装消息并推送，响应状态码不是 2xx 时视为失败
+func (s *TrafficAlertService) send(channel *model.AlertChannel, alert *model.TrafficAlert) error {
+	text := "[短网址流量告警] " + alert.Message
+	var payload any
+	switch channel.Type {
+	case model.AlertChannelTypeSlack:
+		payload = map[string]any{"text": text}
+	case model.AlertChannelTypeDingTalk, model.AlertChannelTypeWeCom:
+		payload = map[string]any{"msgtype": "text", "text": map[string]any{"content": text}}
+	case model.AlertChannelTypeFeishu:
+		payload = map[string]any{"msg_type": "text", "content": map[string]any{"text": text}}
+	default:
+		payload = s.toResponse(alert, nil)
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, channel.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if channel.Type == model.AlertChannelTypeWebhook {
+		req.Header.Set(TrafficAlertEventHeader, "traffic_alert")
+		if channel.Secret != "" {
+			timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+			req.Header.Set(TrafficAlertTimestampHeader, timestamp)
+			req.Header.Set(TrafficAlertSignatureHeader, TrafficAlertSignature(channel.Secret, timestamp, body))
+		}
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return errors.New("渠道返回 " + resp.Status)
+	}
+	return nil
+}
+
+// TrafficAlertSignature 计算告警 webhook 签名，算法与转化回传签名相同
+func TrafficAlertSignature(secret, timestamp string, body []byte) string {
+	return ConversionSignature(secret, timestamp, body)
+}
+
+// GetSetting 工作区流量异常检测阈值
+func (s *TrafficAlertService) GetSetting(workspaceID uint64) (*dto.TrafficAlertSettingResponse, error) {
+	setting, err := s.setting(workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	return trafficAlertSettingToResponse(setting), nil
+}
+
+func (s *TrafficAlertService) UpdateSetting(workspaceID uint64, req *dto.TrafficAlertSettingRequest) (*dto.TrafficAlertSettingResponse, error) {
+	setting, err := s.setting(workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	if req.Enabled != nil {
+		setting.Enabled = *req.Enabled
+	}
+	if req.WindowMinutes != nil {
+		setting.WindowMinutes = *req.WindowMinutes
+	}
+	if req.BaselineDays != nil {
+		setting.BaselineDays = *req.BaselineDays
+	}
+	if req.SpikeFactor != nil {
+		setting.SpikeFactor = *req.SpikeFactor
+	}
+	if req.SpikeMinClicks != nil {
+		setting.SpikeMinClicks = *req.SpikeMinClicks
+	}
+	if req.DropMinHourlyClicks != nil {
+		setting.DropMinHourlyClicks = *req.DropMinHourlyClicks
+	}
+	if req.BotRatioDelta != nil {
+		setting.BotRatioDelta = *req.BotRatioDelta
+	}
+	if req.CountryShiftThreshold != nil {
+		setting.CountryShiftThreshold = *req.CountryShiftThreshold
+	}
+	if req.MixMinClicks != nil {
+		setting.MixMinClicks = *req.MixMinClicks
+	}
+	if req.CooldownMinutes != nil {
+		setting.CooldownMinutes = *req.CooldownMinutes
+	}
+	if err := s.alertDao.SaveSetting(setting); err != nil {
+		return nil, err
+	}
+	return trafficAlertSettingToResponse(setting), nil
+}
+
+func trafficAlertSettingToResponse(setting *model.TrafficAlertSetting) *dto.TrafficAlertSettingResponse {
+	return &dto.TrafficAlertSettingResponse{
+		WorkspaceID:           setting.WorkspaceID,
+		Enabled:               setting.Enabled,
+		WindowMinutes:         setting.WindowMinutes,
+		BaselineDays:          setting.BaselineDays,
+		SpikeFactor:           setting.SpikeFactor,
+		SpikeMinClicks:        setting.SpikeMinClicks,
+		DropMinHourlyClicks:   setting.DropMinHourlyClicks,
+		BotRatioDelta:         setting.BotRatioDelta,
+		CountryShiftThreshold: setting.CountryShiftThreshold,
+		MixMinClicks:          setting.MixMinClicks,
+		CooldownMinutes:       setting.CooldownMinutes,
+	}
+}
+
+// ListAlerts 按时间倒序列出告警及各渠道投递状态
+func (s *TrafficAlertService) ListAlerts(workspaceID uint64, req *dto.TrafficAlertListRequest) (*dto.TrafficAlertListResponse, error) {
+	if req.Page < 1 {
+		req.Page = 1
+	}
+	if req.PageSize < 1 || req.PageSize > 100 {
+		req.PageSize = 10
+	}
+	alerts, total, err := s.alertDao.ListAlerts(workspaceID, req)
+	if err != nil {
+		return nil, err
+	}
+	alertIDs := make([]uint64, 0, len(alerts))
+	for _, alert := range alerts {
+		alertIDs = append(alertIDs, alert.ID)
+	}
+	deliveries, err := s.alertDao.ListDeliveries(alertIDs)
+	if err != nil {
+		return nil, err
+	}
+	byAlert := make(map[uint64][]model.TrafficAlertDelivery)
+	for _, delivery := range deliveries {
+		byAlert[delivery.AlertID] = append(byAlert[delivery.AlertID], delivery)
+	}
+	response := &dto.TrafficAlertListResponse{List: make([]dto.TrafficAlertResponse, 0, len(alerts)), Total: total, Page: req.Page, Size: req.PageSize}
+	for i := range alerts {
+		response.List = append(response.List, *s.toResponse(&alerts[i], byAlert[alerts[i].ID]))
+	}
+	return response, nil
+}
+
+// Acknowledge 标记告警已处理
+func (s *TrafficAlertService) Acknowledge(id, workspaceID, userID uint64) (*dto.TrafficAlertResponse, error) {
+	if err := s.alertDao.Acknowledge(id, workspaceID, userID, time.Now()); err != nil {
+		return nil, err
+	}
+	alert, err := s.alertDao.FindAlert(id, workspaceID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTrafficAlertNotFound
+		}
+		return nil, err
+	}
+	deliveries, err := s.alertDao.ListDeliveries([]uint64{alert.ID})
+	if err != nil {
+		return nil, err
+	}
+	return s.toResponse(alert, deliveries), nil
+}
+
+func (s *TrafficAlertService) toResponse(alert *model.TrafficAlert, deliveries []model.TrafficAlertDelivery) *dto.TrafficAlertResponse {
+	response := &dto.TrafficAlertResponse{
+		ID:             alert.ID,
+		WorkspaceID:    alert.WorkspaceID,
+		ShortLinkID:    alert.ShortLinkID,
+		AlertType:      alert.AlertType,
+		Message:        alert.Message,
+		Observed:       alert.Observed,
+		Baseline:       alert.Baseline,
+		Details:        json.RawMessage("{}"),
+		WindowStart:    alert.WindowStart,
+		WindowEnd:      alert.WindowEnd,
+		AcknowledgedBy: alert.AcknowledgedBy,
+		AcknowledgedAt: alert.AcknowledgedAt,
+		Deliveries:     make([]dto.TrafficAlertDeliveryResponse, 0, len(deliveries)),
+		CreatedAt:      alert.CreatedAt,
+	}
+	if json.Valid([]byte(alert.Details)) {
+		response.Details = json.RawMessage(alert.Details)
+	}
+	for _, delivery := range deliveries {
+		response.Deliveries = append(response.Deliveries, dto.TrafficAlertDeliveryResponse{
+			ChannelID:   delivery.ChannelID,
+			Status:      delivery.Status,
+			Attempts:    delivery.Attempts,
+			LastError:   delivery.LastError,
+			DeliveredAt: delivery.DeliveredAt,
+		})
+	}
+	return response
+}
+
+func (s *TrafficAlertService) ListChannels(workspaceID uint64) (*dto.AlertChannelListResponse, error) {
+	channels, err := s.alertDao.ListChannels(workspaceID, false)
+	if err != nil {
+		return nil, err
+	}
+	response := &dto.AlertChannelListResponse{List: make([]dto.AlertChannelResponse, 0, len(channels))}
+	for i := range channels {
+		response.List = append(response.List, alertChannelToResponse(&channels[i]))
+	}
+	return response, nil
+}
+
+func (s *TrafficAlertService) CreateChannel(workspaceID, userID uint64, req *dto.AlertChannelRequest) (*dto.AlertChannelResponse, error) {
+	channel := &model.AlertChannel{WorkspaceID: workspaceID, Enabled: true, CreatedBy: actorPtr(userID)}
+	applyAlertChannel(channel, req)
+	if err := s.alertDao.SaveChannel(channel); err != nil {
+		return nil, err
+	}
+	response := alertChannelToResponse(channel)
+	return &response, nil
+}
+
+func (s *TrafficAlertService) UpdateChannel(id, workspaceID uint64, req *dto.AlertChannelRequest) (*dto.AlertChannelResponse, error) {
+	channel, err := s.findChannel(id, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	applyAlertChannel(channel, req)
+	if err := s.alertDao.SaveChannel(channel); err != nil {
+		return nil, err
+	}
+	response := alertChannelToResponse(channel)
+	return &response, nil
+}
+
+func (s *TrafficAlertService) DeleteChannel(id, workspaceID uint64) error {
+	deleted, err := s.alertDao.DeleteChannel(id, workspaceID)
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return ErrAlertChannelNotFound
+	}
+	return nil
+}
+
+// TestChannel 向渠道发送一条测试告警，不写入告警记录
+func (s *TrafficAlertService) TestChannel(id, workspaceID uint64) error {
+	channel, err := s.findChannel(id, workspaceID)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	return s.send(channel, &model.TrafficAlert{
+		WorkspaceID: workspaceID,
+		AlertType:   model.TrafficAlertSpike,
+		Message:     "测试消息：告警渠道配置成功",
+		Details:     "{}",
+		WindowStart: now,
+		WindowEnd:   now,
+		CreatedAt:   now,
+	})
+}
+
+func (s *TrafficAlertService) findChannel(id, workspaceID uint64) (*model.AlertChannel, error) {
+	channel, err := s.alertDao.FindChannel(id, workspaceID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrAlertChannelNotFound
+		}
+		return nil, err
+	}
+	return channel, nil
+}
+
+func applyAlertChannel(channel *model.AlertChannel, req *dto.AlertChannelRequest) {
+	channel.Name = strings.TrimSpace(req.Name)
+	channel.Type = req.Type
+	channel.URL = strings.TrimSpace(req.URL)
+	if req.Secret != nil {
+		channel.Secret = *req.Secret
+	}
+	channel.AlertTypes = strings.Join(req.AlertTypes, ",")
+	if req.Enabled != nil {
+		channel.Enabled = *req.Enabled
+	}
+}
+
+func alertChannelToResponse(channel *model.AlertChannel) dto.AlertChannelResponse {
+	alertTypes := []string{}
+	if channel.AlertTypes != "" {
+		alertTypes = strings.Split(channel.AlertTypes, ",")
+	}
+	return dto.AlertChannelResponse{
+		ID:          channel.ID,
+		WorkspaceID: channel.WorkspaceID,
+		Name:        channel.Name,
+		Type:        channel.Type,
+		URL:         channel.URL,
+		HasSecret:   channel.Secret != "",
+		AlertTypes:  alertTypes,
+		Enabled:     channel.Enabled,
+		CreatedBy:   channel.CreatedBy,
+		CreatedAt:   channel.CreatedAt,
+		UpdatedAt:   channel.UpdatedAt,
+	}
+}
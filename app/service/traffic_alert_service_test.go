@@ -0,0 +1,230 @@
+package service
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/dto"
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/model"
+)
+
+func TestDetectTrafficAnomalies(t *testing.T) {
+	setting := defaultTrafficAlertSetting(1)
+	baseline := &trafficSample{}
+	baseline.add(false, "中国", 160)
+	baseline.add(true, "中国", 8)
+
+	spike := &trafficSample{}
+	spike.add(false, "中国", 120)
+	anomalies := detectTrafficAnomalies(setting, spike, baseline, 1, 168)
+	if len(anomalies) != 1 || anomalies[0].alertType != model.TrafficAlertSpike || anomalies[0].observed != 120 || anomalies[0].baseline != 1 {
+		t.Fatalf("spike: %+v", anomalies)
+	}
+
+	// 窗口点击未达到最小样本时不判定激增
+	small := &trafficSample{}
+	small.add(false, "中国", 20)
+	if anomalies := detectTrafficAnomalies(setting, small, baseline, 1, 168); len(anomalies) != 0 {
+		t.Fatalf("small window must not alert: %+v", anomalies)
+	}
+
+	busy := &trafficSample{}
+	busy.add(false, "中国", 168*20)
+	if anomalies := detectTrafficAnomalies(setting, &trafficSample{}, busy, 1, 168); len(anomalies) != 1 || anomalies[0].alertType != model.TrafficAlertDrop {
+		t.Fatalf("drop: %+v", anomalies)
+	}
+	if anomalies := detectTrafficAnomalies(setting, &trafficSample{}, baseline, 1, 168); len(anomalies) != 0 {
+		t.Fatalf("quiet baseline must not alert on zero clicks: %+v", anomalies)
+	}
+
+	bots := &trafficSample{}
+	bots.add(true, "中国", 30)
+	bots.add(false, "中国", 30)
+	if anomalies := detectTrafficAnomalies(setting, bots, baseline, 1, 168); len(anomalies) != 1 || anomalies[0].alertType != model.TrafficAlertBotRatio || anomalies[0].observed != 0.5 {
+		t.Fatalf("bot ratio: %+v", anomalies)
+	}
+
+	shifted := &trafficSample{}
+	shifted.add(false, "美国", 50)
+	shifted.add(false, "中国", 10)
+	anomalies = detectTrafficAnomalies(setting, shifted, baseline, 1, 168)
+	if len(anomalies) != 1 || anomalies[0].alertType != model.TrafficAlertCountryShift || anomalies[0].distance < 0.83 || anomalies[0].observed < 0.83 || anomalies[0].baseline != 0 {
+		t.Fatalf("country shift: %+v", anomalies)
+	}
+}
+
+func TestTrafficAlertDetectionAndDelivery(t *testing.T) {
+	helper := newShortLinkRegressionHelper(t)
+	helper.settings["analytics.alert_delivery_max_attempts"] = 2
+	db := helper.GetDatabase()
+	domain := seedBatchShortLinkDomain(t, db)
+	longAgo := time.Now().AddDate(0, 0, -30)
+	if err := db.Create(&model.Workspace{ID: 1, Slug: "default", Name: "Default", Status: 1, CreatedAt: longAgo}).Error; err != nil {
+		t.Fatalf("seed workspace: %v", err)
+	}
+	seedLink := func(code string) model.ShortLink {
+		link := model.ShortLink{WorkspaceID: 1, DomainID: domain.ID, Domain: domain.Domain, ShortCode: code, OriginalURL: "https://example.com/" + code, IsActive: true, CreatedAt: longAgo}
+		if err := db.Create(&link).Error; err != nil {
+			t.Fatalf("seed link: %v", err)
+		}
+		return link
+	}
+	attacked, quiet, fresh := seedLink("attacked"), seedLink("quiet"), seedLink("fresh")
+	if err := db.Model(&fresh).Update("created_at", time.Now().Add(-2*time.Hour)).Error; err != nil {
+		t.Fatalf("update link: %v", err)
+	}
+
+	now := time.Now()
+	baselineHour := model.ClickRollupBucketStart(now.AddDate(0, 0, -3), model.ClickRollupGranularityHour)
+	for _, rollup := range []model.ClickRollup{
+		{WorkspaceID: 1, ShortLinkID: attacked.ID, Granularity: model.ClickRollupGranularityHour, BucketStart: baselineHour, Dimension: model.ClickRollupDimensionRegion, Country: "中国", DimensionValue: "北京", Clicks: 336},
+		{WorkspaceID: 1, ShortLinkID: quiet.ID, Granularity: model.ClickRollupGranularityHour, BucketStart: baselineHour, Dimension: model.ClickRollupDimensionRegion, Country: "中国", DimensionValue: "上海", Clicks: 168 * 20},
+		// 其他维度不参与统计
+		{WorkspaceID: 1, ShortLinkID: quiet.ID, Granularity: model.ClickRollupGranularityHour, BucketStart: baselineHour, Dimension: model.ClickRollupDimensionTotal, Clicks: 168 * 20},
+	} {
+		if err := db.Create(&rollup).Error; err != nil {
+			t.Fatalf("seed rollup: %v", err)
+		}
+	}
+	var clicks []model.ClickStatistic
+	for i := 0; i < 150; i++ {
+		clicks = append(clicks, model.ClickStatistic{WorkspaceID: 1, ShortLinkID: attacked.ID, IP: "8.8.8.8", IsBot: true, Country: "美国", ClickDate: now.Add(-10 * time.Minute)})
+	}
+	for i := 0; i < 150; i++ {
+		// 新建短网址历史不足一天，不检测
+		clicks = append(clicks, model.ClickStatistic{WorkspaceID: 1, ShortLinkID: fresh.ID, IP: "8.8.4.4", Country: "中国", ClickDate: now.Add(-10 * time.Minute)})
+	}
+	if err := db.CreateInBatches(&clicks, 100).Error; err != nil {
+		t.Fatalf("seed clicks: %v", err)
+	}
+
+	var mu sync.Mutex
+	var received []*http.Request
+	var bodies [][]byte
+	okServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		received = append(received, r)
+		bodies = append(bodies, body)
+		mu.Unlock()
+	}))
+	defer okServer.Close()
+	failServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer failServer.Close()
+
+	alertSvc := NewTrafficAlertService(helper)
+	secret := "alert-secret"
+	internal, err := alertSvc.CreateChannel(1, 7, &dto.AlertChannelRequest{Name: "internal", Type: model.AlertChannelTypeWebhook, URL: okServer.URL})
+	if err != nil {
+		t.Fatalf("create internal channel: %v", err)
+	}
+	if err := alertSvc.TestChannel(internal.ID, 1); err == nil || len(received) != 0 {
+		t.Fatalf("default client must block internal addresses: %v", err)
+	}
+	if err := alertSvc.DeleteChannel(internal.ID, 1); err != nil {
+		t.Fatalf("delete internal channel: %v", err)
+	}
+	// 测试服务监听在回环地址，改用不做地址校验的客户端
+	alertSvc.client = okServer.Client()
+	webhook, err := alertSvc.CreateChannel(1, 7, &dto.AlertChannelRequest{Name: "ops", Type: model.AlertChannelTypeWebhook, URL: okServer.URL, Secret: &secret})
+	if err != nil || !webhook.HasSecret || !webhook.Enabled {
+		t.Fatalf("create webhook: %+v err=%v", webhook, err)
+	}
+	failing, err := alertSvc.CreateChannel(1, 7, &dto.AlertChannelRequest{Name: "im", Type: model.AlertChannelTypeDingTalk, URL: failServer.URL, AlertTypes: []string{model.TrafficAlertDrop}})
+	if err != nil {
+		t.Fatalf("create failing channel: %v", err)
+	}
+	disabled := false
+	muted, err := alertSvc.CreateChannel(1, 7, &dto.AlertChannelRequest{Name: "muted", Type: model.AlertChannelTypeSlack, URL: okServer.URL, Enabled: &disabled})
+	if err != nil || muted.Enabled {
+		t.Fatalf("disabled channel: %+v err=%v", muted, err)
+	}
+
+	created, err := alertSvc.DetectAnomalies(now)
+	if err != nil {
+		t.Fatalf("detect: %v", err)
+	}
+	// attacked：激增、机器人占比、国家分布；quiet：骤降；工作区：激增、机器人占比、国家分布
+	if created != 7 {
+		t.Fatalf("expected 7 alerts, got %d", created)
+	}
+	var alerts []model.TrafficAlert
+	db.Order("id ASC").Find(&alerts)
+	types := map[uint64][]string{}
+	for _, alert := range alerts {
+		types[alert.ShortLinkID] = append(types[alert.ShortLinkID], alert.AlertType)
+	}
+	if len(types[attacked.ID]) != 3 || len(types[0]) != 3 || len(types[quiet.ID]) != 1 || types[quiet.ID][0] != model.TrafficAlertDrop || len(types[fresh.ID]) != 0 {
+		t.Fatalf("unexpected alerts: %+v", types)
+	}
+	if again, err := alertSvc.DetectAnomalies(now.Add(5 * time.Minute)); err != nil || again != 0 {
+		t.Fatalf("cooldown must suppress repeated alerts: %d err=%v", again, err)
+	}
+
+	delivered, err := alertSvc.DeliverPending(now)
+	if err != nil || delivered != 7 {
+		t.Fatalf("deliver: %d err=%v", delivered, err)
+	}
+	mu.Lock()
+	if len(received) != 7 {
+		t.Fatalf("webhook must receive 7 alerts, got %d", len(received))
+	}
+	first := received[0]
+	if first.Header.Get(TrafficAlertEventHeader) != "traffic_alert" ||
+		first.Header.Get(TrafficAlertSignatureHeader) != TrafficAlertSignature(secret, first.Header.Get(TrafficAlertTimestampHeader), bodies[0]) {
+		t.Fatalf("webhook headers: %+v", first.Header)
+	}
+	mu.Unlock()
+
+	var failed model.TrafficAlertDelivery
+	db.Where("channel_id = ?", failing.ID).First(&failed)
+	if failed.Status != model.AlertDeliveryStatusPending || failed.Attempts != 1 || failed.LastError == "" || !failed.NextAttemptAt.After(now) {
+		t.Fatalf("failed delivery must be retried later: %+v", failed)
+	}
+	if delivered, _ := alertSvc.DeliverPending(now); delivered != 0 {
+		t.Fatalf("retry must wait for backoff")
+	}
+	if _, err := alertSvc.DeliverPending(now.Add(2 * time.Minute)); err != nil {
+		t.Fatalf("retry: %v", err)
+	}
+	db.First(&failed, failed.ID)
+	if failed.Status != model.AlertDeliveryStatusFailed || failed.Attempts != 2 {
+		t.Fatalf("delivery must fail after max attempts: %+v", failed)
+	}
+	var mutedCount int64
+	db.Model(&model.TrafficAlertDelivery{}).Where("channel_id = ?", muted.ID).Count(&mutedCount)
+	if mutedCount != 0 {
+		t.Fatalf("disabled channel must not receive alerts")
+	}
+
+	acked, err := alertSvc.Acknowledge(alerts[0].ID, 1, 9)
+	if err != nil || acked.AcknowledgedBy == nil || *acked.AcknowledgedBy != 9 || len(acked.Deliveries) != 1 {
+		t.Fatalf("acknowledge: %+v err=%v", acked, err)
+	}
+	if _, err := alertSvc.Acknowledge(alerts[0].ID, 2, 9); !errors.Is(err, ErrTrafficAlertNotFound) {
+		t.Fatalf("alert must be scoped to its workspace: %v", err)
+	}
+	pending := false
+	list, err := alertSvc.ListAlerts(1, &dto.TrafficAlertListRequest{Acknowledged: &pending})
+	if err != nil || list.Total != 6 || list.Page != 1 {
+		t.Fatalf("list unacknowledged: %+v err=%v", list, err)
+	}
+
+	setting, err := alertSvc.UpdateSetting(1, &dto.TrafficAlertSettingRequest{Enabled: &disabled})
+	if err != nil || setting.Enabled || setting.WindowMinutes != 60 {
+		t.Fatalf("update setting: %+v err=%v", setting, err)
+	}
+	if stored, _ := alertSvc.GetSetting(1); stored.Enabled {
+		t.Fatalf("disabled setting must persist")
+	}
+	if created, _ := alertSvc.DetectAnomalies(now.AddDate(0, 0, 1)); created != 0 {
+		t.Fatalf("disabled workspace must not alert: %d", created)
+	}
+}
@@ -0,0 +1,137 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/model"
+)
+
+// trafficSample 一段时间内的点击构成
+type trafficSample struct {
+	Clicks    int64            `json:"clicks"`
+	Bots      int64            `json:"bots"`
+	Countries map[string]int64 `json:"countries"`
+}
+
+func (t *trafficSample) add(isBot bool, country string, clicks int64) {
+	t.Clicks += clicks
+	if isBot {
+		t.Bots += clicks
+	}
+	if t.Countries == nil {
+		t.Countries = map[string]int64{}
+	}
+	t.Countries[country] += clicks
+}
+
+func (t *trafficSample) botRatio() float64 {
+	if t.Clicks == 0 {
+		return 0
+	}
+	return float64(t.Bots) / float64(t.Clicks)
+}
+
+func (t *trafficSample) countryShare(country string) float64 {
+	if t.Clicks == 0 {
+		return 0
+	}
+	return float64(t.Countries[country]) / float64(t.Clicks)
+}
+
+// trafficAnomaly 一次检测发现的异常
+type trafficAnomaly struct {
+	alertType string
+	message   string
+	observed  float64
+	baseline  float64
+	distance  float64 // 国家分布差异，仅 country_shift
+}
+
+// detectTrafficAnomalies 比较最近窗口与基线的点击构成，windowHours、baselineHours 为两段时间的小时数。
+// 点击量按每小时点击数比较；国家分布差异为两组占比差值绝对值之和的一半，取值 0-1。
+func detectTrafficAnomalies(setting *model.TrafficAlertSetting, window, baseline *trafficSample, windowHours, baselineHours float64) []trafficAnomaly {
+	var anomalies []trafficAnomaly
+	if windowHours <= 0 || baselineHours <= 0 {
+		return anomalies
+	}
+	windowRate := float64(window.Clicks) / windowHours
+	baselineRate := float64(baseline.Clicks) / baselineHours
+
+	if window.Clicks >= setting.SpikeMinClicks && windowRate >= setting.SpikeFactor*baselineRate {
+		anomalies = append(anomalies, trafficAnomaly{
+			alertType: model.TrafficAlertSpike,
+			message:   fmt.Sprintf("点击量激增：最近每小时 %.1f 次，基线每小时 %.1f 次", windowRate, baselineRate),
+			observed:  roundTrafficValue(windowRate),
+			baseline:  roundTrafficValue(baselineRate),
+		})
+	}
+	if window.Clicks == 0 && baselineRate >= setting.DropMinHourlyClicks {
+		anomalies = append(anomalies, trafficAnomaly{
+			alertType: model.TrafficAlertDrop,
+			message:   fmt.Sprintf("点击量降为零：最近窗口内无点击，基线每小时 %.1f 次", baselineRate),
+			observed:  0,
+			baseline:  roundTrafficValue(baselineRate),
+		})
+	}
+	if window.Clicks < setting.MixMinClicks || baseline.Clicks == 0 {
+		return anomalies
+	}
+
+	if windowRatio, baselineRatio := window.botRatio(), baseline.botRatio(); windowRatio-baselineRatio >= setting.BotRatioDelta {
+		anomalies = append(anomalies, trafficAnomaly{
+			alertType: model.TrafficAlertBotRatio,
+			message:   fmt.Sprintf("机器人占比由 %.1f%% 升至 %.1f%%", baselineRatio*100, windowRatio*100),
+			observed:  roundTrafficValue(windowRatio),
+			baseline:  roundTrafficValue(baselineRatio),
+		})
+	}
+
+	if baseline.Clicks < setting.MixMinClicks {
+		return anomalies
+	}
+	seen := map[string]bool{}
+	var countries []string
+	for _, sample := range []*trafficSample{window, baseline} {
+		for country := range sample.Countries {
+			if !seen[country] {
+				seen[country] = true
+				countries = append(countries, country)
+			}
+		}
+	}
+	sort.Strings(countries)
+	var distance, topIncrease float64
+	topCountry := ""
+	for _, country := range countries {
+		diff := window.countryShare(country) - baseline.countryShare(country)
+		distance += math.Abs(diff)
+		if diff > topIncrease {
+			topIncrease, topCountry = diff, country
+		}
+	}
+	distance /= 2
+	if distance >= setting.CountryShiftThreshold {
+		anomalies = append(anomalies, trafficAnomaly{
+			alertType: model.TrafficAlertCountryShift,
+			message: fmt.Sprintf("国家分布变化 %.0f%%，%s 占比由 %.1f%% 升至 %.1f%%",
+				distance*100, trafficCountryLabel(topCountry), baseline.countryShare(topCountry)*100, window.countryShare(topCountry)*100),
+			observed: roundTrafficValue(window.countryShare(topCountry)),
+			baseline: roundTrafficValue(baseline.countryShare(topCountry)),
+			distance: roundTrafficValue(distance),
+		})
+	}
+	return anomalies
+}
+
+func trafficCountryLabel(country string) string {
+	if country == "" {
+		return "未知地区"
+	}
+	return country
+}
+
+func roundTrafficValue(value float64) float64 {
+	return math.Round(value*10000) / 10000
+}
@@ -0,0 +1,13 @@
+package service
+
+import "cnb.cool/mliev/dwz/dwz-server/v2/pkg/service/target_url"
+
+// NormalizeTargetURL 规范化目标 URL，短网址复用匹配与安全扫描共用该规则，规则见 target_url.Normalize
+func NormalizeTargetURL(rawURL string) (string, error) {
+	return target_url.Normalize(rawURL)
+}
+
+// targetURLFingerprint 规范化 URL 的 sha256，用于按目标地址检索短网址；无法解析时按原文计算
+func targetURLFingerprint(rawURL string) string {
+	return target_url.Fingerprint(rawURL)
+}
@@ -0,0 +1,301 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/dao"
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/dto"
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/model"
+	"cnb.cool/mliev/dwz/dwz-server/v2/pkg/interfaces"
+	"cnb.cool/mliev/dwz/dwz-server/v2/pkg/service/hyperloglog"
+)
+
+const (
+	visitorSketchStoreDatabase     = "database"
+	visitorSketchStoreRedis        = "redis"
+	defaultVisitorSketchTTLDays    = 400
+	visitorSketchRedisKeyPrefix    = "dwz:visitors"
+	visitorSketchRedisTempKeyLabel = "tmp"
+)
+
+// visitorSaltCache 进程内缓存当天盐值，避免每次点击查询数据库
+var visitorSaltCache struct {
+	sync.Mutex
+	day  string
+	salt string
+}
+
+// VisitorService 计算访客标识并维护独立访客草图。
+// 访客标识为 IP 与 UA 加当日盐值的哈希，盐值每天轮换并在过期后删除，
+// 因此同一访客在不同日期的标识不同，跨天范围内会分别计数。
+type VisitorService struct {
+	helper            interfaces.HelperInterface
+	visitorDao        *dao.VisitorDao
+	clickStatisticDao *dao.ClickStatisticDao
+	clickRollupDao    *dao.ClickRollupDao
+}
+
+func NewVisitorService(helper interfaces.HelperInterface) *VisitorService {
+	return &VisitorService{
+		helper:            helper,
+		visitorDao:        dao.NewVisitorDao(helper),
+		clickStatisticDao: dao.NewClickStatisticDao(helper),
+		clickRollupDao:    dao.NewClickRollupDao(helper),
+	}
+}
+
+// VisitorKey 计算访客标识，盐值不可用时返回空，该点击不计入独立访客
+func (s *VisitorService) VisitorKey(ip, userAgent string, at time.Time) string {
+	if ip == "" {
+		return ""
+	}
+	salt, err := s.salt(at.In(time.Local).Format("2006-01-02"))
+	if err != nil {
+		s.helper.GetLogger().Error("获取访客盐值失败: " + err.Error())
+		return ""
+	}
+	sum := sha256.Sum256([]byte(salt + "|" + ip + "|" + userAgent))
+	return hex.EncodeToString(sum[:])
+}
+
+func (s *VisitorService) salt(day string) (string, error) {
+	visitorSaltCache.Lock()
+	defer visitorSaltCache.Unlock()
+	if visitorSaltCache.day == day {
+		return visitorSaltCache.salt, nil
+	}
+	random := make([]byte, 32)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+	salt, err := s.visitorDao.FindOrCreateSalt(day, hex.EncodeToString(random))
+	if err != nil {
+		return "", err
+	}
+	visitorSaltCache.day, visitorSaltCache.salt = day, salt
+	return salt, nil
+}
+
+// PurgeExpiredSalts 删除前一天之前的盐值，之后无法再由 IP 推算出历史访客标识
+func (s *VisitorService) PurgeExpiredSalts() (int64, error) {
+	yesterday := time.Now().AddDate(0, 0, -1).Format("2006-01-02")
+	return s.visitorDao.PurgeSaltsBefore(yesterday)
+}
+
+// CountUniqueVisitors 独立访客数，不含机器人。
+// 仅按短网址、标签、文件夹筛选且时间范围按天划分时合并每日草图，其余情况按访客标识精确去重。
+func (s *VisitorService) CountUniqueVisitors(workspaceID uint64, req *dto.ClickStatisticListRequest) (int64, error) {
+	if req.IsBot != nil && *req.IsBot {
+		return 0, nil
+	}
+	if !s.sketchSupports(req) {
+		return s.clickStatisticDao.CountUniqueVisitorsInWorkspace(workspaceID, req)
+	}
+
+	var shortLinkIDs []uint64
+	if req.ShortLinkID > 0 && req.TagID == 0 && len(req.FolderIDs) == 0 && req.FolderID == 0 {
+		shortLinkIDs = []uint64{req.ShortLinkID}
+	} else if req.ShortLinkID > 0 || req.TagID > 0 || len(req.FolderIDs) > 0 || req.FolderID > 0 {
+		ids, err := s.clickStatisticDao.FilterShortLinkIDsInWorkspace(workspaceID, req)
+		if err != nil {
+			return 0, err
+		}
+		if len(ids) == 0 {
+			return 0, nil
+		}
+		shortLinkIDs = ids
+	}
+
+	// 先读游标再读草图：游标之后的点击从明细补齐，草图与明细重叠的部分去重后不影响结果
+	cursor, err := s.clickRollupDao.Cursor()
+	if err != nil {
+		return 0, err
+	}
+	tailLimit := s.helper.GetConfig().GetInt("analytics.rollup_tail_limit", defaultClickRollupTailLimit)
+	if tailLimit <= 0 {
+		tailLimit = defaultClickRollupTailLimit
+	}
+	tail, err := s.clickStatisticDao.ListVisitorKeysAfterInWorkspace(workspaceID, req, cursor.LastClickID, tailLimit+1)
+	if err != nil {
+		return 0, err
+	}
+	if len(tail) > tailLimit {
+		return s.clickStatisticDao.CountUniqueVisitorsInWorkspace(workspaceID, req)
+	}
+
+	start := model.ClickRollupBucketStart(req.StartDate, model.ClickRollupGranularityDay)
+	end := ceilVisitorDay(req.EndDate)
+	if s.sketchStore() == visitorSketchStoreRedis {
+		return s.countRedis(workspaceID, shortLinkIDs, start, end, tail)
+	}
+	sketches, err := s.visitorDao.ListSketches(workspaceID, shortLinkIDs, start, end)
+	if err != nil {
+		return 0, err
+	}
+	merged := hyperloglog.New()
+	for _, sketch := range sketches {
+		if registers, err := hyperloglog.FromBytes(sketch.Registers); err == nil {
+			merged.Merge(registers)
+		}
+	}
+	for _, key := range tail {
+		merged.Add(key)
+	}
+	return merged.Count(), nil
+}
+
+// sketchSupports 草图按短网址和天划分，只能满足短网址范围的筛选
+func (s *VisitorService) sketchSupports(req *dto.ClickStatisticListRequest) bool {
+	if req.CampaignID > 0 || req.RouteID > 0 || req.AliasID > 0 || req.DeviceType != "" || req.IP != "" ||
+		req.Country != "" || req.Province != "" || req.City != "" || req.ISP != "" ||
+		req.MinBotScore != nil || req.MaxBotScore != nil || req.BotReason != "" {
+		return false
+	}
+	if req.StartDate.IsZero() || req.EndDate.IsZero() {
+		return false
+	}
+	if !model.ClickRollupBucketStart(req.StartDate, model.ClickRollupGranularityDay).Equal(req.StartDate) {
+		return false
+	}
+	// 结束时间晚于当前时间时，最后一天的草图即为截至当前的访客
+	return req.EndDate.After(time.Now()) || ceilVisitorDay(req.EndDate).Equal(req.EndDate)
+}
+
+// DatabaseSketches 将一批点击合并为每日草图，使用 Redis 存储时返回空
+func (s *VisitorService) DatabaseSketches(statistics []model.ClickStatistic) []model.VisitorSketch {
+	if s.sketchStore() != visitorSketchStoreDatabase {
+		return nil
+	}
+	groups := groupVisitorKeys(statistics)
+	now := time.Now()
+	sketches := make([]model.VisitorSketch, 0, len(groups))
+	for key, visitorKeys := range groups {
+		sketch := hyperloglog.New()
+		for _, visitorKey := range visitorKeys {
+			sketch.Add(visitorKey)
+		}
+		sketches = append(sketches, model.VisitorSketch{
+			WorkspaceID: key.WorkspaceID,
+			ShortLinkID: key.ShortLinkID,
+			Day:         time.Unix(key.Day, 0).In(time.Local),
+			Registers:   sketch.Bytes(),
+			UpdatedAt:   now,
+		})
+	}
+	return sketches
+}
+
+// AddRedisSketches 使用 Redis 存储时写入草图；重复写入不影响结果，需在汇总游标推进前执行
+func (s *VisitorService) AddRedisSketches(statistics []model.ClickStatistic) error {
+	if s.sketchStore() != visitorSketchStoreRedis {
+		return nil
+	}
+	ttlDays := s.helper.GetConfig().GetInt("analytics.visitor_sketch_ttl_days", defaultVisitorSketchTTLDays)
+	if ttlDays <= 0 {
+		ttlDays = defaultVisitorSketchTTLDays
+	}
+	ctx := context.Background()
+	pipe := s.helper.GetRedis().Pipeline()
+	for key, visitorKeys := range groupVisitorKeys(statistics) {
+		redisKey := visitorSketchRedisKey(key.WorkspaceID, key.ShortLinkID, time.Unix(key.Day, 0))
+		members := make([]any, len(visitorKeys))
+		for i, visitorKey := range visitorKeys {
+			members[i] = visitorKey
+		}
+		pipe.PFAdd(ctx, redisKey, members...)
+		pipe.Expire(ctx, redisKey, time.Duration(ttlDays)*24*time.Hour)
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+func (s *VisitorService) countRedis(workspaceID uint64, shortLinkIDs []uint64, start, end time.Time, tail []string) (int64, error) {
+	if len(shortLinkIDs) == 0 {
+		shortLinkIDs = []uint64{0}
+	}
+	var keys []string
+	for day := start; day.Before(end); day = day.AddDate(0, 0, 1) {
+		for _, shortLinkID := range shortLinkIDs {
+			keys = append(keys, visitorSketchRedisKey(workspaceID, shortLinkID, day))
+		}
+	}
+	ctx := context.Background()
+	client := s.helper.GetRedis()
+	if len(tail) == 0 {
+		if len(keys) == 0 {
+			return 0, nil
+		}
+		return client.PFCount(ctx, keys...).Result()
+	}
+
+	// 尚未写入草图的访客先与已有草图合并到临时键
+	random := make([]byte, 8)
+	if _, err := rand.Read(random); err != nil {
+		return 0, err
+	}
+	tempKey := fmt.Sprintf("%s:%s:%s", visitorSketchRedisKeyPrefix, visitorSketchRedisTempKeyLabel, hex.EncodeToString(random))
+	defer client.Del(ctx, tempKey)
+	members := make([]any, len(tail))
+	for i, key := range tail {
+		members[i] = key
+	}
+	pipe := client.Pipeline()
+	if len(keys) > 0 {
+		pipe.PFMerge(ctx, tempKey, keys...)
+	}
+	pipe.PFAdd(ctx, tempKey, members...)
+	pipe.Expire(ctx, tempKey, time.Minute)
+	count := pipe.PFCount(ctx, tempKey)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, err
+	}
+	return count.Val(), nil
+}
+
+// sketchStore 草图存储位置，配置为 redis 但未连接 Redis 时使用数据库
+func (s *VisitorService) sketchStore() string {
+	if s.helper.GetConfig().GetString("analytics.visitor_sketch_store", visitorSketchStoreDatabase) == visitorSketchStoreRedis && s.helper.GetRedis() != nil {
+		return visitorSketchStoreRedis
+	}
+	return visitorSketchStoreDatabase
+}
+
+type visitorSketchKey struct {
+	WorkspaceID uint64
+	ShortLinkID uint64
+	Day         int64
+}
+
+// groupVisitorKeys 按短网址和天归集访客标识，同时归集到工作区合计（短网址ID为 0）
+func groupVisitorKeys(statistics []model.ClickStatistic) map[visitorSketchKey][]string {
+	groups := make(map[visitorSketchKey][]string)
+	for _, statistic := range statistics {
+		if statistic.IsBot || statistic.VisitorKey == "" {
+			continue
+		}
+		day := model.ClickRollupBucketStart(statistic.ClickDate, model.ClickRollupGranularityDay).Unix()
+		for _, shortLinkID := range []uint64{statistic.ShortLinkID, 0} {
+			key := visitorSketchKey{WorkspaceID: statistic.WorkspaceID, ShortLinkID: shortLinkID, Day: day}
+			groups[key] = append(groups[key], statistic.VisitorKey)
+		}
+	}
+	return groups
+}
+
+func visitorSketchRedisKey(workspaceID, shortLinkID uint64, day time.Time) string {
+	return fmt.Sprintf("%s:%d:%d:%s", visitorSketchRedisKeyPrefix, workspaceID, shortLinkID, day.In(time.Local).Format("20060102"))
+}
+
+func ceilVisitorDay(t time.Time) time.Time {
+	day := model.ClickRollupBucketStart(t, model.ClickRollupGranularityDay)
+	if day.Equal(t) {
+		return day
+	}
+	return day.AddDate(0, 0, 1)
+}
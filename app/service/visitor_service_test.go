@@ -0,0 +1,117 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/dto"
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/model"
+)
+
+func TestVisitorKeysAndUniqueVisitorSketches(t *testing.T) {
+	helper := newShortLinkRegressionHelper(t)
+	db := helper.GetDatabase()
+	seedBatchShortLinkDomain(t, db)
+
+	shortLinkSvc := NewShortLinkService(helper, context.Background())
+	link, err := shortLinkSvc.CreateShortLinkInWorkspace(&dto.CreateShortLinkRequest{
+		OriginalURL: "https://example.com/visitors",
+		Domain:      "batch.dwz.do",
+		CustomCode:  "visitors",
+	}, "", 1, 7)
+	if err != nil {
+		t.Fatalf("create link: %v", err)
+	}
+	visit := func(ip, userAgent string) {
+		if _, err := shortLinkSvc.ResolveRedirectWithSecurity("batch.dwz.do", "visitors", ip, userAgent, "", "", ""); err != nil {
+			t.Fatalf("redirect: %v", err)
+		}
+	}
+	for _, ip := range []string{"1.1.1.1", "2.2.2.2", "3.3.3.3"} {
+		visit(ip, "Mozilla/5.0")
+		visit(ip, "Mozilla/5.0")
+	}
+	visit("4.4.4.4", "Googlebot/2.1 (+http://www.google.com/bot.html)")
+	waitForModelCount(t, db, &model.ClickStatistic{}, "short_link_id = ?", link.ID, 7)
+
+	var clicks []model.ClickStatistic
+	db.Where("short_link_id = ?", link.ID).Order("id").Find(&clicks)
+	// 点击异步写入，按IP比较而不依赖写入顺序
+	keysByIP := make(map[string]map[string]bool)
+	allKeys := make(map[string]bool)
+	for _, click := range clicks {
+		if keysByIP[click.IP] == nil {
+			keysByIP[click.IP] = make(map[string]bool)
+		}
+		keysByIP[click.IP][click.VisitorKey] = true
+		allKeys[click.VisitorKey] = true
+	}
+	if len(keysByIP["1.1.1.1"]) != 1 || keysByIP["1.1.1.1"][""] || len(allKeys) != 4 {
+		t.Fatalf("visitor key must be stable per IP and UA within a day: %v", keysByIP)
+	}
+	if len(clicks[0].VisitorKey) != 64 || clicks[0].VisitorKey == clicks[0].IP {
+		t.Fatalf("visitor key must be a salted hash: %q", clicks[0].VisitorKey)
+	}
+
+	statistics := func() *dto.ShortLinkStatisticResponse {
+		t.Helper()
+		response, err := shortLinkSvc.GetShortLinkStatisticsInWorkspace(link.ID, 7, 1, time.Local, false)
+		if err != nil {
+			t.Fatalf("link statistics: %v", err)
+		}
+		return response
+	}
+	// 尚未写入草图时从明细补齐，机器人不计入
+	if stats := statistics(); stats.TodayVisitors != 3 || stats.WeekVisitors != 3 || stats.DailyStatistics[6].UniqueVisitors != 3 {
+		t.Fatalf("unique visitors before rollup: %+v", stats)
+	}
+
+	if _, err := NewClickRollupService(helper).ProcessPending(); err != nil {
+		t.Fatalf("process pending: %v", err)
+	}
+	var sketches int64
+	db.Model(&model.VisitorSketch{}).Where("workspace_id = ?", 1).Count(&sketches)
+	if sketches != 2 {
+		t.Fatalf("expected link and workspace sketches, got %d", sketches)
+	}
+
+	visit("5.5.5.5", "Mozilla/5.0")
+	visit("1.1.1.1", "Mozilla/5.0")
+	waitForModelCount(t, db, &model.ClickStatistic{}, "short_link_id = ?", link.ID, 9)
+	if stats := statistics(); stats.TodayVisitors != 4 || stats.MonthVisitors != 4 {
+		t.Fatalf("sketch and pending clicks must merge: %+v", stats)
+	}
+
+	visitorSvc := NewVisitorService(helper)
+	today := model.ClickRollupBucketStart(time.Now(), model.ClickRollupGranularityDay)
+	req := &dto.ClickStatisticListRequest{StartDate: today, EndDate: today.AddDate(0, 0, 1)}
+	if count, err := visitorSvc.CountUniqueVisitors(1, req); err != nil || count != 4 {
+		t.Fatalf("workspace unique visitors: %d err=%v", count, err)
+	}
+	// 草图无法满足的筛选条件按访客标识精确去重
+	if count, err := visitorSvc.CountUniqueVisitors(1, &dto.ClickStatisticListRequest{StartDate: today, EndDate: today.AddDate(0, 0, 1), IP: "1.1.1.1"}); err != nil || count != 1 {
+		t.Fatalf("raw unique visitors: %d err=%v", count, err)
+	}
+	bots := true
+	if count, err := visitorSvc.CountUniqueVisitors(1, &dto.ClickStatisticListRequest{StartDate: today, EndDate: today.AddDate(0, 0, 1), IsBot: &bots}); err != nil || count != 0 {
+		t.Fatalf("bots are not visitors: %d err=%v", count, err)
+	}
+	analysis, err := NewClickStatisticService(helper).GetClickStatisticAnalysisInWorkspace(1, &dto.ClickStatisticListRequest{}, 7)
+	if err != nil || analysis.UniqueVisitors != 4 {
+		t.Fatalf("analysis unique visitors: %+v err=%v", analysis, err)
+	}
+
+	yesterday := time.Now().AddDate(0, 0, -1).Format("2006-01-02")
+	if err := db.Create(&[]model.VisitorSalt{{Day: "2000-01-01", Salt: "old"}, {Day: yesterday, Salt: "recent"}}).Error; err != nil {
+		t.Fatalf("seed salts: %v", err)
+	}
+	if purged, err := visitorSvc.PurgeExpiredSalts(); err != nil || purged != 1 {
+		t.Fatalf("purge salts: %d err=%v", purged, err)
+	}
+	var remaining int64
+	db.Model(&model.VisitorSalt{}).Where("day = ?", yesterday).Count(&remaining)
+	if remaining != 1 {
+		t.Fatalf("yesterday's salt must be kept, remaining=%d", remaining)
+	}
+}
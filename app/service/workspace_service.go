@@ -2,6 +2,7 @@ package service
 
 import (
 	"errors"
+	"strings"
 
 	"cnb.cool/mliev/dwz/dwz-server/v2/app/dao"
 	"cnb.cool/mliev/dwz/dwz-server/v2/app/dto"
@@ -11,12 +12,14 @@ import (
 )
 
 type WorkspaceService struct {
+	helper       interfaces.HelperInterface
 	workspaceDao *dao.WorkspaceDao
 	userDao      *dao.UserDAO
 }
 
 func NewWorkspaceService(helper interfaces.HelperInterface) *WorkspaceService {
 	return &WorkspaceService{
+		helper:       helper,
 		workspaceDao: dao.NewWorkspaceDao(helper),
 		userDao:      dao.NewUserDAO(helper),
 	}
@@ -93,9 +96,48 @@ func (s *WorkspaceService) UpdateWorkspace(workspaceID uint64, req *dto.UpdateWo
 	}
 	workspace.Name = req.Name
 	workspace.Description = req.Description
+	if req.ReuseExistingLinks != nil {
+		workspace.ReuseExistingLinks = *req.ReuseExistingLinks
+	}
+	if req.RequireLinkApproval != nil {
+		workspace.RequireLinkApproval = *req.RequireLinkApproval
+	}
+	if req.AnonymizeIP != nil {
+		workspace.AnonymizeIP = *req.AnonymizeIP
+	}
+	if req.ClickRetentionDays != nil {
+		workspace.ClickRetentionDays = *req.ClickRetentionDays
+	}
+	if req.ABTestClickRetentionDays != nil {
+		workspace.ABTestClickRetentionDays = *req.ABTestClickRetentionDays
+	}
+	if req.SecurityEventRetentionDays != nil {
+		workspace.SecurityEventRetentionDays = *req.SecurityEventRetentionDays
+	}
+	if req.OperationLogRetentionDays != nil {
+		workspace.OperationLogRetentionDays = *req.OperationLogRetentionDays
+	}
+	if req.ClickDedupSeconds != nil {
+		workspace.ClickDedupSeconds = *req.ClickDedupSeconds
+	}
+	if req.Timezone != nil {
+		if _, err := LoadTimezone(*req.Timezone); err != nil {
+			return nil, err
+		}
+		workspace.Timezone = strings.TrimSpace(*req.Timezone)
+	}
+	if req.PixelConversionValue != nil {
+		workspace.PixelConversionValue = *req.PixelConversionValue
+	}
 	if err := s.workspaceDao.Update(workspace); err != nil {
 		return nil, err
 	}
+	if req.AnonymizeIP != nil {
+		NewDataRetentionService(s.helper).ForgetAnonymizeIP(workspaceID)
+	}
+	if req.ClickDedupSeconds != nil {
+		NewClickDedupService(s.helper).ForgetWindow(workspaceID)
+	}
 	resp := s.workspaceToResponse(workspace)
 	return &resp, nil
 }
@@ -184,6 +226,20 @@ func (s *WorkspaceService) workspaceToResponse(workspace *model.Workspace) dto.W
 		Status:      workspace.Status,
 		CreatedAt:   workspace.CreatedAt,
 		UpdatedAt:   workspace.UpdatedAt,
+
+		ReuseExistingLinks:  workspace.ReuseExistingLinks,
+		RequireLinkApproval: workspace.RequireLinkApproval,
+
+		AnonymizeIP:                workspace.AnonymizeIP,
+		ClickRetentionDays:         workspace.ClickRetentionDays,
+		ABTestClickRetentionDays:   workspace.ABTestClickRetentionDays,
+		SecurityEventRetentionDays: workspace.SecurityEventRetentionDays,
+		OperationLogRetentionDays:  workspace.OperationLogRetentionDays,
+
+		ClickDedupSeconds: workspace.ClickDedupSeconds,
+		Timezone:          workspace.Timezone,
+
+		PixelConversionValue: workspace.PixelConversionValue,
 	}
 }
 
@@ -10,6 +10,7 @@ import (
 	ipRegionAssembly "cnb.cool/mliev/dwz/dwz-server/v2/pkg/service/ip_region/assembly"
 	"cnb.cool/mliev/dwz/dwz-server/v2/pkg/service/migration"
 	redisAssembly "cnb.cool/mliev/dwz/dwz-server/v2/pkg/service/redis/assembly"
+	"cnb.cool/mliev/dwz/dwz-server/v2/pkg/service/scheduler"
 	versionAssembly "cnb.cool/mliev/dwz/dwz-server/v2/pkg/service/version/assembly"
 	"cnb.cool/mliev/open/go-web/pkg/interfaces"
 	configAssembly "cnb.cool/mliev/open/go-web/pkg/server/config/assembly"
@@ -67,12 +68,13 @@ func DefaultAssembliesWithVersion(version, gitCommit, buildTime string) []interf
 }
 
 // DefaultServers returns the CE server chain (migration → id_generator →
-// http_server). EE consumers can prepend / append their own servers around
+// scheduler → http_server). EE consumers can prepend / append their own servers around
 // this slice. migrationsFS is the embedded SQL tree forwarded from main.go.
 func DefaultServers(migrationsFS embed.FS) []interfaces.ServerInterface {
 	return []interfaces.ServerInterface{
 		&migration.Migration{BaseFS: migrationsFS},
 		&idGenerator.IDGenerator{},
+		&scheduler.Scheduler{Jobs: scheduler.DefaultJobs()},
 		&httpServer.HttpServer{},
 	}
 }
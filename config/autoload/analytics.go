@@ -0,0 +1,50 @@
+package autoload
+
+import (
+	"cnb.cool/mliev/open/go-web/pkg/helper"
+)
+
+type Analytics struct{}
+
+func (Analytics) InitConfig() map[string]any {
+	return map[string]any{
+		// 统计分析是否读取预聚合汇总，关闭后直接扫描点击明细
+		"analytics.rollup_enabled": helper.GetEnv().GetBool("analytics.rollup_enabled", true),
+		// 增量汇总每批读取的点击记录数
+		"analytics.rollup_batch_size": helper.GetEnv().GetInt("analytics.rollup_batch_size", 5000),
+		// 增量汇总每轮最多处理的批数
+		"analytics.rollup_max_batches": helper.GetEnv().GetInt("analytics.rollup_max_batches", 20),
+		// 点击写入后等待该秒数再汇总，避免并发写入时游标越过晚提交的点击，0 表示不等待
+		"analytics.rollup_settle_seconds": helper.GetEnv().GetInt("analytics.rollup_settle_seconds", 30),
+		// 尚未汇总的点击超过该数量时，统计分析改为直接扫描点击明细
+		"analytics.rollup_tail_limit": helper.GetEnv().GetInt("analytics.rollup_tail_limit", 50000),
+		// 读取汇总时是否仍按点击明细去重统计独立IP，数据量大时可关闭，unique_ips 返回 0
+		"analytics.rollup_unique_ips": helper.GetEnv().GetBool("analytics.rollup_unique_ips", true),
+		// 独立访客草图存储：database 或 redis，未连接 Redis 时使用数据库
+		"analytics.visitor_sketch_store": helper.GetEnv().GetString("analytics.visitor_sketch_store", "database"),
+		// 使用 Redis 存储时草图的保留天数
+		"analytics.visitor_sketch_ttl_days": helper.GetEnv().GetInt("analytics.visitor_sketch_ttl_days", 400),
+		// 是否推送实时点击流，连接 Redis 时经发布/订阅转发到所有实例
+		"analytics.click_stream_enabled": helper.GetEnv().GetBool("analytics.click_stream_enabled", true),
+		// 每个工作区同时打开的实时点击流连接上限，0 表示不限制
+		"analytics.click_stream_max_subscribers": helper.GetEnv().GetInt("analytics.click_stream_max_subscribers", 50),
+		// 同一访客在该秒数内重复点击同一短网址时标记为重复点击，工作区未设置时使用，0 表示不去重
+		"analytics.click_dedup_seconds": helper.GetEnv().GetInt("analytics.click_dedup_seconds", 0),
+		// 统计报表按天、按小时划分使用的 IANA 时区，工作区未设置时使用，为空时使用服务器时区
+		"analytics.timezone": helper.GetEnv().GetString("analytics.timezone", ""),
+		// 异步导出每批读取并写入一个分段的点击数
+		"analytics.export_chunk_size": helper.GetEnv().GetInt("analytics.export_chunk_size", 5000),
+		// 导出文件下载链接的有效小时数，过期后删除文件
+		"analytics.export_link_ttl_hours": helper.GetEnv().GetInt("analytics.export_link_ttl_hours", 24),
+		// 每个工作区同时待执行和执行中的导出任务上限，0 表示不限制
+		"analytics.export_max_active": helper.GetEnv().GetInt("analytics.export_max_active", 3),
+		// 执行中的任务超过该分钟数没有心跳时视为执行实例已退出，由其他实例接续导出
+		"analytics.export_stale_minutes": helper.GetEnv().GetInt("analytics.export_stale_minutes", 10),
+		// 是否检测流量异常，阈值按工作区在告警设置中调整
+		"analytics.anomaly_detection_enabled": helper.GetEnv().GetBool("analytics.anomaly_detection_enabled", true),
+		// 告警推送请求的超时秒数
+		"analytics.alert_delivery_timeout_seconds": helper.GetEnv().GetInt("analytics.alert_delivery_timeout_seconds", 10),
+		// 告警推送失败后的最多尝试次数
+		"analytics.alert_delivery_max_attempts": helper.GetEnv().GetInt("analytics.alert_delivery_max_attempts", 5),
+	}
+}
@@ -0,0 +1,24 @@
+package autoload
+
+import (
+	"cnb.cool/mliev/open/go-web/pkg/helper"
+)
+
+type BotDetection struct{}
+
+func (BotDetection) InitConfig() map[string]any {
+	return map[string]any{
+		// 机器人评分达到该值时视为机器人，评分为各识别规则分数之和，最高 100
+		"bot_detection.score_threshold": helper.GetEnv().GetInt("bot_detection.score_threshold", 60),
+		// 是否按请求头（Accept、Accept-Language、预览用途等）识别，关闭后只使用其他规则
+		"bot_detection.header_checks": helper.GetEnv().GetBool("bot_detection.header_checks", true),
+		// 同一 IP 在窗口秒数内的点击超过上限时计入机器人评分，上限 <=0 表示不检查
+		"bot_detection.velocity_window_seconds": helper.GetEnv().GetInt("bot_detection.velocity_window_seconds", 60),
+		"bot_detection.velocity_max_clicks":     helper.GetEnv().GetInt("bot_detection.velocity_max_clicks", 30),
+		// 数据中心、云主机 IP 段（CIDR），来自这些网段的访问计入机器人评分
+		"bot_detection.datacenter_cidrs": helper.GetEnv().GetStringSlice("bot_detection.datacenter_cidrs", []string{}),
+		// 邮件安全网关 IP 段（CIDR），以及内置规则之外的网关 User-Agent 关键字
+		"bot_detection.scanner_cidrs":       helper.GetEnv().GetStringSlice("bot_detection.scanner_cidrs", []string{}),
+		"bot_detection.scanner_user_agents": helper.GetEnv().GetStringSlice("bot_detection.scanner_user_agents", []string{}),
+	}
+}
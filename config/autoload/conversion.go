@@ -0,0 +1,20 @@
+package autoload
+
+import (
+	"cnb.cool/mliev/open/go-web/pkg/helper"
+)
+
+type Conversion struct{}
+
+func (Conversion) InitConfig() map[string]any {
+	return map[string]any{
+		// 开启追加点击ID的短网址，跳转时使用的查询参数名
+		"conversion.click_id_param": helper.GetEnv().GetString("conversion.click_id_param", "dwz_click_id"),
+		// 服务端回传签名时间戳允许的偏差（秒）
+		"conversion.signature_tolerance_seconds": helper.GetEnv().GetInt("conversion.signature_tolerance_seconds", 300),
+		// 点击后多少天内的转化可以归因，0 表示不限制
+		"conversion.attribution_window_days": helper.GetEnv().GetInt("conversion.attribution_window_days", 30),
+		// 点击明细异步写入，回传的点击ID暂未写入时最多等待的毫秒数
+		"conversion.click_lookup_wait_ms": helper.GetEnv().GetInt("conversion.click_lookup_wait_ms", 2000),
+	}
+}
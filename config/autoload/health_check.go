@@ -0,0 +1,30 @@
+package autoload
+
+import (
+	"cnb.cool/mliev/open/go-web/pkg/helper"
+)
+
+type HealthCheck struct{}
+
+func (HealthCheck) InitConfig() map[string]any {
+	return map[string]any{
+		// 是否启用目标地址健康检查后台任务
+		"health_check.enabled": helper.GetEnv().GetBool("health_check.enabled", false),
+		// 同一短网址两次检查的最小间隔（分钟）
+		"health_check.interval_minutes": helper.GetEnv().GetInt("health_check.interval_minutes", 360),
+		// 每轮最多检查的短网址数量
+		"health_check.batch_size": helper.GetEnv().GetInt("health_check.batch_size", 200),
+		// 并发检查的短网址数量
+		"health_check.concurrency": helper.GetEnv().GetInt("health_check.concurrency", 4),
+		// 所有并发检查合计每秒最多发出的请求数，<=0 表示不限制，手动检查同样适用
+		"health_check.rate_per_second": helper.GetEnv().GetInt("health_check.rate_per_second", 5),
+		// 本实例内对同一主机两次请求的最小间隔（毫秒），<=0 表示不限制
+		"health_check.host_interval_ms": helper.GetEnv().GetInt("health_check.host_interval_ms", 1000),
+		// 同一短网址两次手动检查的最小间隔（秒）
+		"health_check.manual_cooldown_seconds": helper.GetEnv().GetInt("health_check.manual_cooldown_seconds", 60),
+		// 单次请求超时（秒）
+		"health_check.timeout_seconds": helper.GetEnv().GetInt("health_check.timeout_seconds", 10),
+		// 连续失败达到该次数后标记为失效并记录事件
+		"health_check.failure_threshold": helper.GetEnv().GetInt("health_check.failure_threshold", 2),
+	}
+}
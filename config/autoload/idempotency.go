@@ -0,0 +1,14 @@
+package autoload
+
+import (
+	"cnb.cool/mliev/open/go-web/pkg/helper"
+)
+
+type Idempotency struct{}
+
+func (Idempotency) InitConfig() map[string]any {
+	return map[string]any{
+		// 幂等键保留小时数，窗口内相同 Idempotency-Key 的请求直接重放原始响应
+		"idempotency.retention_hours": helper.GetEnv().GetInt("idempotency.retention_hours", 24),
+	}
+}
@@ -0,0 +1,22 @@
+package autoload
+
+import (
+	"cnb.cool/mliev/open/go-web/pkg/helper"
+)
+
+type MetadataFetch struct{}
+
+func (MetadataFetch) InitConfig() map[string]any {
+	return map[string]any{
+		// 是否在创建或修改目标地址后自动后台抓取目标页面元数据
+		"metadata_fetch.enabled": helper.GetEnv().GetBool("metadata_fetch.enabled", false),
+		// 单次抓取超时（秒）
+		"metadata_fetch.timeout_seconds": helper.GetEnv().GetInt("metadata_fetch.timeout_seconds", 5),
+		// 后台同时抓取的数量
+		"metadata_fetch.concurrency": helper.GetEnv().GetInt("metadata_fetch.concurrency", 4),
+		// 最多读取的页面字节数
+		"metadata_fetch.max_bytes": helper.GetEnv().GetInt("metadata_fetch.max_bytes", 524288),
+		// 标题、描述为空时是否用抓取结果填充
+		"metadata_fetch.fill_empty": helper.GetEnv().GetBool("metadata_fetch.fill_empty", true),
+	}
+}
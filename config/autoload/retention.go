@@ -0,0 +1,21 @@
+package autoload
+
+import (
+	"cnb.cool/mliev/open/go-web/pkg/helper"
+)
+
+type Retention struct{}
+
+func (Retention) InitConfig() map[string]any {
+	return map[string]any{
+		// 工作区未设置保留天数时使用的默认值，<=0 表示永久保留
+		"retention.click_days":          helper.GetEnv().GetInt("retention.click_days", 0),
+		"retention.ab_test_click_days":  helper.GetEnv().GetInt("retention.ab_test_click_days", 0),
+		"retention.security_event_days": helper.GetEnv().GetInt("retention.security_event_days", 0),
+		"retention.operation_log_days":  helper.GetEnv().GetInt("retention.operation_log_days", 0),
+		// 为所有工作区开启访客IP匿名化，不论工作区设置
+		"retention.anonymize_ip": helper.GetEnv().GetBool("retention.anonymize_ip", false),
+		// 清理任务每批删除的记录数
+		"retention.purge_batch_size": helper.GetEnv().GetInt("retention.purge_batch_size", 5000),
+	}
+}
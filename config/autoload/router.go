@@ -60,6 +60,9 @@ func (Router) InitConfig() map[string]any {
 				public.POST("/link_access/password", controller.LinkSecurityController{}.SubmitPassword)
 				public.POST("/abuse_reports", controller.LinkSecurityController{}.CreatePublicAbuseReport)
 				public.POST("/ab_test_feedback", controller.ABTestController{}.CreateABTestFeedback)
+				public.POST("/conversions", controller.ConversionController{}.Postback)
+				public.GET("/conversions/pixel.gif", controller.ConversionController{}.Pixel)
+				public.GET("/click_exports/:token", controller.ClickExportController{}.Download)
 			}
 
 			// 受保护的 API：操作日志 + 鉴权
@@ -98,6 +101,18 @@ func (Router) InitConfig() map[string]any {
 					short.PUT("/:id", controller.ShortLinkController{}.UpdateShortLink)
 					short.PUT("/:id/status", controller.ShortLinkController{}.UpdateShortLinkStatus)
 					short.DELETE("/:id", controller.ShortLinkController{}.DeleteShortLink)
+					short.POST("/:id/clone", controller.ShortLinkController{}.CloneShortLink)
+					short.POST("/:id/move", controller.ShortLinkController{}.MoveShortLink)
+					short.POST("/:id/rekey", controller.ShortLinkController{}.RekeyShortLink)
+					short.POST("/:id/review/approve", controller.ShortLinkController{}.ApproveShortLink)
+					short.POST("/:id/review/reject", controller.ShortLinkController{}.RejectShortLink)
+					short.GET("/:id/aliases", controller.ShortLinkController{}.GetShortLinkAliases)
+					short.POST("/:id/aliases", controller.ShortLinkController{}.CreateShortLinkAlias)
+					short.GET("/:id/health", controller.LinkHealthController{}.Get)
+					short.POST("/:id/health/check", controller.LinkHealthController{}.Check)
+					short.GET("/:id/metadata", controller.LinkMetadataController{}.Get)
+					short.POST("/:id/metadata/fetch", controller.LinkMetadataController{}.Fetch)
+					short.DELETE("/:id/aliases/:alias_id", controller.ShortLinkController{}.DeleteShortLinkAlias)
 					short.GET("/:id/statistics", controller.ShortLinkController{}.GetShortLinkStatistics)
 					short.GET("/:id/security", controller.LinkSecurityController{}.GetShortLinkSecurity)
 					short.PUT("/:id/security", controller.LinkSecurityController{}.UpdateShortLinkSecurity)
@@ -111,6 +126,13 @@ func (Router) InitConfig() map[string]any {
 					short.POST("/batch", controller.ShortLinkController{}.BatchCreateShortLinks)
 					short.POST("/batch/status", controller.ShortLinkController{}.BatchUpdateShortLinkStatus)
 					short.POST("/batch/delete", controller.ShortLinkController{}.BatchDeleteShortLinks)
+					short.POST("/batch/move", controller.ShortLinkController{}.BatchMoveShortLinks)
+					short.GET("/reviews", controller.ShortLinkController{}.GetShortLinkReviewQueue)
+					short.GET("/trash", controller.ShortLinkController{}.GetShortLinkTrash)
+					short.POST("/trash/:id/restore", controller.ShortLinkController{}.RestoreShortLink)
+					short.DELETE("/trash/:id", controller.ShortLinkController{}.PurgeShortLink)
+					short.GET("/external/:external_id", controller.ShortLinkController{}.GetShortLinkByExternalID)
+					short.PUT("/external/:external_id", controller.ShortLinkController{}.UpsertShortLinkByExternalID)
 				}
 
 				workspaces := v1.Group("/workspaces")
@@ -133,6 +155,41 @@ func (Router) InitConfig() map[string]any {
 					campaigns.DELETE("/:id", controller.CampaignController{}.Delete)
 				}
 
+				folders := v1.Group("/folders")
+				{
+					folders.POST("", controller.FolderController{}.Create)
+					folders.GET("", controller.FolderController{}.List)
+					folders.GET("/:id", controller.FolderController{}.Get)
+					folders.PUT("/:id", controller.FolderController{}.Update)
+					folders.DELETE("/:id", controller.FolderController{}.Delete)
+					folders.GET("/:id/statistics", controller.FolderController{}.Statistics)
+				}
+
+				linkTemplates := v1.Group("/link_templates")
+				{
+					linkTemplates.POST("", controller.LinkTemplateController{}.Create)
+					linkTemplates.GET("", controller.LinkTemplateController{}.List)
+					linkTemplates.GET("/:id", controller.LinkTemplateController{}.Get)
+					linkTemplates.PUT("/:id", controller.LinkTemplateController{}.Update)
+					linkTemplates.DELETE("/:id", controller.LinkTemplateController{}.Delete)
+				}
+
+				customFields := v1.Group("/custom_fields")
+				{
+					customFields.POST("", controller.CustomFieldController{}.Create)
+					customFields.GET("", controller.CustomFieldController{}.List)
+					customFields.GET("/:id", controller.CustomFieldController{}.Get)
+					customFields.PUT("/:id", controller.CustomFieldController{}.Update)
+					customFields.DELETE("/:id", controller.CustomFieldController{}.Delete)
+				}
+
+				conversions := v1.Group("/conversions")
+				{
+					conversions.GET("/report", controller.ConversionController{}.Report)
+					conversions.GET("/postback", controller.ConversionController{}.GetPostbackSettings)
+					conversions.POST("/postback/rotate", controller.ConversionController{}.RotatePostbackSecret)
+				}
+
 				tags := v1.Group("/tags")
 				{
 					tags.POST("", controller.TagController{}.Create)
@@ -217,7 +274,45 @@ func (Router) InitConfig() map[string]any {
 					clickStats.GET("", controller.ClickStatisticController{}.GetClickStatisticList)
 					clickStats.GET("/analysis", controller.ClickStatisticController{}.GetClickStatisticAnalysis)
 					clickStats.GET("/geo-analysis", controller.ClickStatisticController{}.GetClickStatisticGeoAnalysis)
+					clickStats.GET("/channels", controller.ClickStatisticController{}.GetReferrerChannels)
 					clickStats.GET("/export", controller.ClickStatisticController{}.ExportCSV)
+					clickStats.POST("/rollups/rebuild", controller.ClickStatisticController{}.RebuildRollups)
+					clickStats.GET("/rollups/rebuild/:id", controller.ClickStatisticController{}.GetRollupRebuild)
+					clickStats.GET("/stream", controller.ClickStreamController{}.Stream)
+					clickStats.POST("/exports", controller.ClickExportController{}.Create)
+					clickStats.GET("/exports", controller.ClickExportController{}.List)
+					clickStats.GET("/exports/:id", controller.ClickExportController{}.Get)
+					clickStats.POST("/exports/:id/cancel", controller.ClickExportController{}.Cancel)
+				}
+
+				referrerRules := v1.Group("/referrer_rules")
+				{
+					referrerRules.GET("", controller.ReferrerRuleController{}.List)
+					referrerRules.POST("", controller.ReferrerRuleController{}.Create)
+					referrerRules.PUT("/:id", controller.ReferrerRuleController{}.Update)
+					referrerRules.DELETE("/:id", controller.ReferrerRuleController{}.Delete)
+				}
+
+				trafficAlerts := v1.Group("/traffic_alerts")
+				{
+					trafficAlerts.GET("", controller.TrafficAlertController{}.List)
+					trafficAlerts.POST("/:id/acknowledge", controller.TrafficAlertController{}.Acknowledge)
+					trafficAlerts.GET("/settings", controller.TrafficAlertController{}.GetSettings)
+					trafficAlerts.PUT("/settings", controller.TrafficAlertController{}.UpdateSettings)
+					trafficAlerts.GET("/channels", controller.TrafficAlertController{}.ListChannels)
+					trafficAlerts.POST("/channels", controller.TrafficAlertController{}.CreateChannel)
+					trafficAlerts.PUT("/channels/:id", controller.TrafficAlertController{}.UpdateChannel)
+					trafficAlerts.DELETE("/channels/:id", controller.TrafficAlertController{}.DeleteChannel)
+					trafficAlerts.POST("/channels/:id/test", controller.TrafficAlertController{}.TestChannel)
+				}
+
+				dataSubjects := v1.Group("/data_subject_requests")
+				{
+					dataSubjects.GET("", controller.DataSubjectController{}.List)
+					dataSubjects.POST("", controller.DataSubjectController{}.Create)
+					dataSubjects.POST("/search", controller.DataSubjectController{}.Search)
+					dataSubjects.POST("/export", controller.DataSubjectController{}.Export)
+					dataSubjects.GET("/:id", controller.DataSubjectController{}.Get)
 				}
 
 				stats := v1.Group("/statistics")
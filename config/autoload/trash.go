@@ -0,0 +1,14 @@
+package autoload
+
+import (
+	"cnb.cool/mliev/open/go-web/pkg/helper"
+)
+
+type Trash struct{}
+
+func (Trash) InitConfig() map[string]any {
+	return map[string]any{
+		// 回收站保留天数，超过后自动彻底删除；<=0 表示不自动清理
+		"trash.retention_days": helper.GetEnv().GetInt("trash.retention_days", 30),
+	}
+}
@@ -20,5 +20,13 @@ func (Config) Get() []interfaces.InitConfig {
 		autoload.IdGenerator{},
 		autoload.Jwt{},
 		autoload.IPRegion{},
+		autoload.Trash{},
+		autoload.Idempotency{},
+		autoload.HealthCheck{},
+		autoload.MetadataFetch{},
+		autoload.Conversion{},
+		autoload.Analytics{},
+		autoload.Retention{},
+		autoload.BotDetection{},
 	}
 }
@@ -0,0 +1,111 @@
+// Package migrations 2026101923500000_4052_backfill_short_link_url_hash 为
+// 复用策略上线前创建的短网址补算 original_url_hash，使其参与复用匹配。
+//
+// 与 0015 相同，up 做完前置检查后交给后台协程回填并立即返回，goose 记账后
+// 不再运行，不阻塞启动；新建与更新短网址时都会同步写入指纹。回填按主键分批读取
+// 缺少指纹的短网址，每批一条 UPDATE ... CASE 写回。指纹算法位于
+// pkg/service/target_url，与业务代码共用，任何引入本包的程序都能执行回填。
+// 协程中途失败时已回填的部分保留，手工删除 goose_db_version 中的 4052 记录即可重跑，
+// 只会处理仍缺少指纹的短网址。
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"cnb.cool/mliev/dwz/dwz-server/v2/pkg/helper"
+	"cnb.cool/mliev/dwz/dwz-server/v2/pkg/service/target_url"
+	"github.com/pressly/goose/v3"
+	"gorm.io/gorm"
+)
+
+const urlHashBackfillBatchSize = 500
+
+func init() {
+	goose.AddNamedMigrationNoTxContext(
+		"2026101923500000_4052_backfill_short_link_url_hash.go",
+		upBackfillShortLinkURLHash,
+		downBackfillShortLinkURLHash,
+	)
+}
+
+func upBackfillShortLinkURLHash(_ context.Context, _ *sql.DB) error {
+	h := helper.GetHelper()
+	// 安装阶段容器 DB 尚未注入，short_links 必然是空表
+	if h.GetDatabase() == nil {
+		return nil
+	}
+	h.GetLogger().Info("[migration 4052] 已受理，后台协程补算目标地址指纹（不阻塞启动）")
+	go runURLHashBackfillAsync()
+	return nil
+}
+
+func runURLHashBackfillAsync() {
+	h := helper.GetHelper()
+	db := h.GetDatabase()
+	if db == nil {
+		h.GetLogger().Error("[migration 4052] 协程启动时 DB 不可用，放弃")
+		return
+	}
+	updated, err := BackfillShortLinkURLHashes(db)
+	if err != nil {
+		h.GetLogger().Error(fmt.Sprintf("[migration 4052] 目标地址指纹回填失败（已回填 %d 条）: %s", updated, err.Error()))
+		return
+	}
+	h.GetLogger().Info(fmt.Sprintf("[migration 4052] 已为 %d 条短网址补算目标地址指纹", updated))
+}
+
+// BackfillShortLinkURLHashes 为缺少目标地址指纹的短网址（含回收站）补算指纹，返回更新的条数；
+// 只更新指纹列，不修改 updated_at
+func BackfillShortLinkURLHashes(db *gorm.DB) (int64, error) {
+	type row struct {
+		ID          uint64 `gorm:"column:id"`
+		OriginalURL string `gorm:"column:original_url"`
+	}
+	var updated int64
+	var lastID uint64
+	for {
+		var rows []row
+		if err := db.Table("short_links").
+			Select("id, original_url").
+			Where("id > ?", lastID).
+			Where("original_url_hash IS NULL OR original_url_hash = ''").
+			Order("id ASC").
+			Limit(urlHashBackfillBatchSize).
+			Find(&rows).Error; err != nil {
+			return updated, fmt.Errorf("select id,original_url: %w", err)
+		}
+		if len(rows) == 0 {
+			return updated, nil
+		}
+
+		var cases strings.Builder
+		args := make([]any, 0, len(rows)*2)
+		ids := make([]uint64, 0, len(rows))
+		cases.WriteString("CASE id")
+		for _, r := range rows {
+			cases.WriteString(" WHEN ? THEN ?")
+			args = append(args, r.ID, target_url.Fingerprint(r.OriginalURL))
+			ids = append(ids, r.ID)
+		}
+		cases.WriteString(" END")
+		res := db.Table("short_links").
+			Where("id IN ?", ids).
+			UpdateColumn("original_url_hash", gorm.Expr(cases.String(), args...))
+		if res.Error != nil {
+			return updated, fmt.Errorf("update short_links after id=%d: %w", lastID, res.Error)
+		}
+		updated += res.RowsAffected
+		lastID = rows[len(rows)-1].ID
+		if len(rows) < urlHashBackfillBatchSize {
+			return updated, nil
+		}
+	}
+}
+
+// downBackfillShortLinkURLHash 指纹可随时重新计算，回滚无需处理
+func downBackfillShortLinkURLHash(_ context.Context, _ *sql.DB) error {
+	return nil
+}
@@ -0,0 +1,35 @@
+-- +goose Up
+ALTER TABLE `short_links`
+  ADD COLUMN `external_id` VARCHAR(128) NULL,
+  ADD COLUMN `external_id_active_key` VARCHAR(128)
+    GENERATED ALWAYS AS (
+      CASE
+        WHEN `deleted_at` IS NULL AND `external_id` IS NOT NULL AND `external_id` <> ''
+        THEN `external_id`
+        ELSE NULL
+      END
+    ) STORED,
+  ADD UNIQUE KEY `uk_short_links_workspace_external_id` (`workspace_id`, `external_id_active_key`);
+
+CREATE TABLE `idempotency_keys` (
+  `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  `workspace_id` BIGINT UNSIGNED NOT NULL DEFAULT 1,
+  `scope` VARCHAR(100) NOT NULL,
+  `idempotency_key` VARCHAR(255) NOT NULL,
+  `request_hash` CHAR(64) NOT NULL,
+  `response_body` LONGTEXT NULL,
+  `completed_at` DATETIME(3) NULL,
+  `expires_at` DATETIME(3) NOT NULL,
+  `created_at` DATETIME(3) NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_idempotency_keys_scope_key` (`workspace_id`, `scope`, `idempotency_key`),
+  KEY `idx_idempotency_keys_expires_at` (`expires_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- +goose Down
+DROP TABLE IF EXISTS `idempotency_keys`;
+
+ALTER TABLE `short_links`
+  DROP INDEX `uk_short_links_workspace_external_id`,
+  DROP COLUMN `external_id_active_key`,
+  DROP COLUMN `external_id`;
@@ -0,0 +1,15 @@
+-- +goose Up
+ALTER TABLE `short_links`
+  ADD COLUMN `original_url_hash` CHAR(64) NULL,
+  ADD KEY `idx_short_links_reuse_lookup` (`workspace_id`, `domain`, `original_url_hash`);
+
+ALTER TABLE `workspaces`
+  ADD COLUMN `reuse_existing_links` BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- +goose Down
+ALTER TABLE `workspaces`
+  DROP COLUMN `reuse_existing_links`;
+
+ALTER TABLE `short_links`
+  DROP INDEX `idx_short_links_reuse_lookup`,
+  DROP COLUMN `original_url_hash`;
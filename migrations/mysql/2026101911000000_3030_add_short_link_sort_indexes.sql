@@ -0,0 +1,11 @@
+-- +goose Up
+ALTER TABLE `short_links`
+  ADD KEY `idx_short_links_ws_created` (`workspace_id`, `created_at`, `id`),
+  ADD KEY `idx_short_links_ws_updated` (`workspace_id`, `updated_at`, `id`),
+  ADD KEY `idx_short_links_ws_clicks` (`workspace_id`, `click_count`, `id`);
+
+-- +goose Down
+ALTER TABLE `short_links`
+  DROP INDEX `idx_short_links_ws_clicks`,
+  DROP INDEX `idx_short_links_ws_updated`,
+  DROP INDEX `idx_short_links_ws_created`;
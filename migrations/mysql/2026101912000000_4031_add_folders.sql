@@ -0,0 +1,35 @@
+-- +goose Up
+CREATE TABLE `folders` (
+  `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  `workspace_id` BIGINT UNSIGNED NOT NULL DEFAULT 1,
+  `parent_id` BIGINT UNSIGNED NULL,
+  `name` VARCHAR(100) NOT NULL,
+  `description` VARCHAR(500) NULL,
+  `created_by` BIGINT UNSIGNED NULL,
+  `created_at` DATETIME(3) NULL,
+  `updated_at` DATETIME(3) NULL,
+  `deleted_at` DATETIME(3) NULL,
+  `default_domain` VARCHAR(100) NULL,
+  `default_utm_source` VARCHAR(255) NULL,
+  `default_utm_medium` VARCHAR(255) NULL,
+  `default_utm_campaign` VARCHAR(255) NULL,
+  `default_utm_term` VARCHAR(255) NULL,
+  `default_utm_content` VARCHAR(255) NULL,
+  `default_security` TEXT NULL,
+  PRIMARY KEY (`id`),
+  KEY `idx_folders_workspace_parent` (`workspace_id`, `parent_id`),
+  KEY `idx_folders_parent_id` (`parent_id`),
+  KEY `idx_folders_created_by` (`created_by`),
+  KEY `idx_folders_deleted_at` (`deleted_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+ALTER TABLE `short_links`
+  ADD COLUMN `folder_id` BIGINT UNSIGNED NULL,
+  ADD KEY `idx_short_links_folder_id` (`folder_id`);
+
+-- +goose Down
+ALTER TABLE `short_links`
+  DROP INDEX `idx_short_links_folder_id`,
+  DROP COLUMN `folder_id`;
+
+DROP TABLE IF EXISTS `folders`;
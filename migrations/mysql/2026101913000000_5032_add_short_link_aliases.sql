@@ -0,0 +1,20 @@
+-- +goose Up
+CREATE TABLE `short_link_aliases` (
+  `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  `workspace_id` BIGINT UNSIGNED NOT NULL DEFAULT 1,
+  `short_link_id` BIGINT UNSIGNED NOT NULL,
+  `domain_id` BIGINT UNSIGNED NOT NULL,
+  `protocol` VARCHAR(10) NOT NULL DEFAULT 'https',
+  `domain` VARCHAR(100) NOT NULL,
+  `short_code` VARCHAR(20) NOT NULL,
+  `source` VARCHAR(20) NOT NULL DEFAULT 'rekey',
+  `created_by` BIGINT UNSIGNED NULL,
+  `created_at` DATETIME(3) NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_short_link_aliases_domain_code` (`domain`, `short_code`),
+  KEY `idx_short_link_aliases_workspace_id` (`workspace_id`),
+  KEY `idx_short_link_aliases_short_link_id` (`short_link_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- +goose Down
+DROP TABLE IF EXISTS `short_link_aliases`;
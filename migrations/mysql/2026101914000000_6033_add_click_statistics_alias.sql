@@ -0,0 +1,11 @@
+-- +goose Up
+ALTER TABLE `click_statistics`
+  ADD COLUMN `alias_id` BIGINT UNSIGNED NULL,
+  ADD COLUMN `alias_code` VARCHAR(20) NULL,
+  ADD KEY `idx_click_statistics_alias_id` (`alias_id`);
+
+-- +goose Down
+ALTER TABLE `click_statistics`
+  DROP INDEX `idx_click_statistics_alias_id`,
+  DROP COLUMN `alias_code`,
+  DROP COLUMN `alias_id`;
@@ -0,0 +1,37 @@
+-- +goose Up
+CREATE TABLE `link_health_checks` (
+  `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  `workspace_id` BIGINT UNSIGNED NOT NULL,
+  `short_link_id` BIGINT UNSIGNED NOT NULL,
+  `target_type` VARCHAR(20) NOT NULL,
+  `target_id` BIGINT UNSIGNED NOT NULL DEFAULT 0,
+  `target_url` VARCHAR(2000) NOT NULL,
+  `healthy` TINYINT(1) NOT NULL DEFAULT 0,
+  `status_code` INT NOT NULL DEFAULT 0,
+  `error` VARCHAR(500) NULL,
+  `redirect_chain` TEXT NULL,
+  `latency_ms` BIGINT NOT NULL DEFAULT 0,
+  `tls_expires_at` DATETIME(3) NULL,
+  `consecutive_failures` INT NOT NULL DEFAULT 0,
+  `checked_at` DATETIME(3) NULL,
+  `created_at` DATETIME(3) NULL,
+  `updated_at` DATETIME(3) NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_link_health_checks_target` (`short_link_id`, `target_type`, `target_id`),
+  KEY `idx_link_health_checks_workspace_id` (`workspace_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+ALTER TABLE `short_links`
+  ADD COLUMN `health_status` VARCHAR(20) NULL,
+  ADD COLUMN `health_checked_at` DATETIME(3) NULL,
+  ADD KEY `idx_short_links_health_status` (`health_status`),
+  ADD KEY `idx_short_links_health_checked_at` (`health_checked_at`);
+
+-- +goose Down
+ALTER TABLE `short_links`
+  DROP INDEX `idx_short_links_health_checked_at`,
+  DROP INDEX `idx_short_links_health_status`,
+  DROP COLUMN `health_checked_at`,
+  DROP COLUMN `health_status`;
+
+DROP TABLE IF EXISTS `link_health_checks`;
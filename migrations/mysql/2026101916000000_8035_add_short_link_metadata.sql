@@ -0,0 +1,22 @@
+-- +goose Up
+CREATE TABLE `short_link_metadata` (
+  `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  `workspace_id` BIGINT UNSIGNED NOT NULL,
+  `short_link_id` BIGINT UNSIGNED NOT NULL,
+  `source_url` VARCHAR(2000) NOT NULL,
+  `title` VARCHAR(255) NULL,
+  `description` VARCHAR(500) NULL,
+  `image_url` VARCHAR(2000) NULL,
+  `favicon_url` VARCHAR(2000) NULL,
+  `status` VARCHAR(20) NOT NULL,
+  `error` VARCHAR(500) NULL,
+  `fetched_at` DATETIME(3) NULL,
+  `created_at` DATETIME(3) NULL,
+  `updated_at` DATETIME(3) NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_short_link_metadata_short_link_id` (`short_link_id`),
+  KEY `idx_short_link_metadata_workspace_id` (`workspace_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- +goose Down
+DROP TABLE IF EXISTS `short_link_metadata`;
@@ -0,0 +1,21 @@
+-- +goose Up
+ALTER TABLE `workspaces`
+  ADD COLUMN `require_link_approval` TINYINT(1) NOT NULL DEFAULT 0;
+
+ALTER TABLE `short_links`
+  ADD COLUMN `review_status` VARCHAR(20) NULL,
+  ADD COLUMN `review_comment` VARCHAR(500) NULL,
+  ADD COLUMN `reviewed_by` BIGINT UNSIGNED NULL,
+  ADD COLUMN `reviewed_at` DATETIME(3) NULL,
+  ADD KEY `idx_short_links_review_status` (`review_status`);
+
+-- +goose Down
+ALTER TABLE `short_links`
+  DROP INDEX `idx_short_links_review_status`,
+  DROP COLUMN `reviewed_at`,
+  DROP COLUMN `reviewed_by`,
+  DROP COLUMN `review_comment`,
+  DROP COLUMN `review_status`;
+
+ALTER TABLE `workspaces`
+  DROP COLUMN `require_link_approval`;
@@ -0,0 +1,46 @@
+-- +goose Up
+CREATE TABLE `custom_fields` (
+  `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  `workspace_id` BIGINT UNSIGNED NOT NULL,
+  `field_key` VARCHAR(50) NOT NULL,
+  `name` VARCHAR(100) NOT NULL,
+  `description` VARCHAR(500) NULL,
+  `field_type` VARCHAR(20) NOT NULL,
+  `options` TEXT NULL,
+  `required` TINYINT(1) NOT NULL DEFAULT 0,
+  `max_length` INT NOT NULL DEFAULT 0,
+  `min_value` DOUBLE NULL,
+  `max_value` DOUBLE NULL,
+  `position` INT NOT NULL DEFAULT 0,
+  `created_by` BIGINT UNSIGNED NULL,
+  `updated_by` BIGINT UNSIGNED NULL,
+  `created_at` DATETIME(3) NULL,
+  `updated_at` DATETIME(3) NULL,
+  `deleted_at` DATETIME(3) NULL,
+  PRIMARY KEY (`id`),
+  KEY `idx_custom_fields_workspace_id` (`workspace_id`),
+  KEY `idx_custom_fields_field_key` (`field_key`),
+  KEY `idx_custom_fields_created_by` (`created_by`),
+  KEY `idx_custom_fields_deleted_at` (`deleted_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE `short_link_custom_values` (
+  `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  `workspace_id` BIGINT UNSIGNED NOT NULL,
+  `short_link_id` BIGINT UNSIGNED NOT NULL,
+  `field_id` BIGINT UNSIGNED NOT NULL,
+  `field_key` VARCHAR(50) NOT NULL,
+  `value` VARCHAR(500) NOT NULL,
+  `number_value` DOUBLE NULL,
+  `created_at` DATETIME(3) NULL,
+  `updated_at` DATETIME(3) NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_short_link_custom_values_link_field` (`short_link_id`, `field_id`),
+  KEY `idx_short_link_custom_values_workspace_id` (`workspace_id`),
+  KEY `idx_short_link_custom_values_field_id` (`field_id`),
+  KEY `idx_short_link_custom_values_field_key` (`field_key`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- +goose Down
+DROP TABLE IF EXISTS `short_link_custom_values`;
+DROP TABLE IF EXISTS `custom_fields`;
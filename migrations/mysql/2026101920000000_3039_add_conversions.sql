@@ -0,0 +1,52 @@
+-- +goose Up
+ALTER TABLE `workspaces`
+  ADD COLUMN `conversion_secret` VARCHAR(64) NULL;
+
+ALTER TABLE `short_links`
+  ADD COLUMN `append_click_id` TINYINT(1) NOT NULL DEFAULT 0;
+
+ALTER TABLE `click_statistics`
+  ADD COLUMN `click_id` VARCHAR(64) NULL,
+  ADD KEY `idx_click_statistics_click_id` (`click_id`);
+
+CREATE TABLE `conversions` (
+  `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  `workspace_id` BIGINT UNSIGNED NOT NULL,
+  `short_link_id` BIGINT UNSIGNED NOT NULL,
+  `campaign_id` BIGINT UNSIGNED NULL,
+  `route_id` BIGINT UNSIGNED NULL,
+  `click_statistic_id` BIGINT UNSIGNED NOT NULL,
+  `click_id` VARCHAR(64) NOT NULL,
+  `event` VARCHAR(64) NOT NULL,
+  `event_id` VARCHAR(128) NOT NULL,
+  `value` DECIMAL(18,4) NULL,
+  `currency` VARCHAR(16) NULL,
+  `source` VARCHAR(20) NOT NULL,
+  `metadata` TEXT NULL,
+  `ip` VARCHAR(45) NULL,
+  `user_agent` VARCHAR(1024) NULL,
+  `clicked_at` DATETIME(3) NULL,
+  `occurred_at` DATETIME(3) NOT NULL,
+  `created_at` DATETIME(3) NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_conversions_event` (`workspace_id`, `event_id`),
+  KEY `idx_conversions_short_link_id` (`short_link_id`),
+  KEY `idx_conversions_campaign_id` (`campaign_id`),
+  KEY `idx_conversions_route_id` (`route_id`),
+  KEY `idx_conversions_click_statistic_id` (`click_statistic_id`),
+  KEY `idx_conversions_click_id` (`click_id`),
+  KEY `idx_conversions_occurred_at` (`occurred_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- +goose Down
+DROP TABLE IF EXISTS `conversions`;
+
+ALTER TABLE `click_statistics`
+  DROP INDEX `idx_click_statistics_click_id`,
+  DROP COLUMN `click_id`;
+
+ALTER TABLE `short_links`
+  DROP COLUMN `append_click_id`;
+
+ALTER TABLE `workspaces`
+  DROP COLUMN `conversion_secret`;
@@ -0,0 +1,32 @@
+-- +goose Up
+CREATE TABLE `click_rollups` (
+  `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  `workspace_id` BIGINT UNSIGNED NOT NULL,
+  `short_link_id` BIGINT UNSIGNED NOT NULL,
+  `campaign_id` BIGINT UNSIGNED NOT NULL DEFAULT 0,
+  `is_bot` TINYINT(1) NOT NULL DEFAULT 0,
+  `granularity` VARCHAR(8) NOT NULL,
+  `bucket_start` DATETIME(3) NOT NULL,
+  `dimension` VARCHAR(32) NOT NULL,
+  `country` VARCHAR(100) NOT NULL DEFAULT '',
+  `province` VARCHAR(100) NOT NULL DEFAULT '',
+  `dimension_value` VARCHAR(255) NOT NULL DEFAULT '',
+  `label` VARCHAR(255) NULL,
+  `clicks` BIGINT NOT NULL DEFAULT 0,
+  `updated_at` DATETIME(3) NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_click_rollups_key` (`workspace_id`, `short_link_id`, `campaign_id`, `is_bot`, `granularity`, `bucket_start`, `dimension`, `country`, `province`, `dimension_value`),
+  KEY `idx_click_rollups_short_link_id` (`short_link_id`),
+  KEY `idx_click_rollups_bucket_start` (`bucket_start`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE `click_rollup_cursors` (
+  `name` VARCHAR(50) NOT NULL,
+  `last_click_id` BIGINT UNSIGNED NOT NULL DEFAULT 0,
+  `updated_at` DATETIME(3) NULL,
+  PRIMARY KEY (`name`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- +goose Down
+DROP TABLE IF EXISTS `click_rollup_cursors`;
+DROP TABLE IF EXISTS `click_rollups`;
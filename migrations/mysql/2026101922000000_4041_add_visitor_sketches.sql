@@ -0,0 +1,30 @@
+-- +goose Up
+ALTER TABLE `click_statistics`
+  ADD COLUMN `visitor_key` VARCHAR(64) NULL,
+  ADD KEY `idx_click_statistics_visitor_key` (`visitor_key`);
+
+CREATE TABLE `visitor_salts` (
+  `day` VARCHAR(10) NOT NULL,
+  `salt` VARCHAR(64) NOT NULL,
+  `created_at` DATETIME(3) NULL,
+  PRIMARY KEY (`day`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE `visitor_sketches` (
+  `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  `workspace_id` BIGINT UNSIGNED NOT NULL,
+  `short_link_id` BIGINT UNSIGNED NOT NULL DEFAULT 0,
+  `day` DATETIME(3) NOT NULL,
+  `registers` BLOB NOT NULL,
+  `updated_at` DATETIME(3) NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_visitor_sketches_key` (`workspace_id`, `short_link_id`, `day`),
+  KEY `idx_visitor_sketches_day` (`day`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- +goose Down
+DROP TABLE IF EXISTS `visitor_sketches`;
+DROP TABLE IF EXISTS `visitor_salts`;
+ALTER TABLE `click_statistics`
+  DROP INDEX `idx_click_statistics_visitor_key`,
+  DROP COLUMN `visitor_key`;
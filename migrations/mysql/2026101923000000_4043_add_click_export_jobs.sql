@@ -0,0 +1,30 @@
+-- +goose Up
+CREATE TABLE `click_export_jobs` (
+  `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  `workspace_id` BIGINT UNSIGNED NOT NULL,
+  `user_id` BIGINT UNSIGNED NOT NULL DEFAULT 0,
+  `format` VARCHAR(20) NOT NULL,
+  `filters` TEXT NULL,
+  `status` VARCHAR(20) NOT NULL,
+  `max_click_id` BIGINT UNSIGNED NOT NULL DEFAULT 0,
+  `last_click_id` BIGINT UNSIGNED NOT NULL DEFAULT 0,
+  `row_count` BIGINT NOT NULL DEFAULT 0,
+  `file_name` VARCHAR(255) NULL,
+  `file_size` BIGINT NOT NULL DEFAULT 0,
+  `error` VARCHAR(500) NULL,
+  `download_token` VARCHAR(64) NULL,
+  `claim_version` BIGINT NOT NULL DEFAULT 0,
+  `heartbeat_at` DATETIME(3) NULL,
+  `expires_at` DATETIME(3) NULL,
+  `started_at` DATETIME(3) NULL,
+  `finished_at` DATETIME(3) NULL,
+  `created_at` DATETIME(3) NULL,
+  `updated_at` DATETIME(3) NULL,
+  PRIMARY KEY (`id`),
+  KEY `idx_click_export_jobs_workspace_id` (`workspace_id`),
+  KEY `idx_click_export_jobs_status` (`status`),
+  KEY `idx_click_export_jobs_download_token` (`download_token`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- +goose Down
+DROP TABLE IF EXISTS `click_export_jobs`;
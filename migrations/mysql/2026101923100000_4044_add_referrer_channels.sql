@@ -0,0 +1,32 @@
+-- +goose Up
+ALTER TABLE `click_statistics`
+  ADD COLUMN `referer_host` VARCHAR(255) NULL,
+  ADD COLUMN `referer_channel` VARCHAR(20) NULL,
+  ADD KEY `idx_click_statistics_referer_host` (`referer_host`),
+  ADD KEY `idx_click_statistics_referer_channel` (`referer_channel`);
+
+CREATE TABLE `referrer_rules` (
+  `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  `workspace_id` BIGINT UNSIGNED NOT NULL,
+  `match_type` VARCHAR(20) NOT NULL,
+  `pattern` VARCHAR(255) NOT NULL,
+  `channel` VARCHAR(20) NOT NULL,
+  `priority` INT NOT NULL DEFAULT 0,
+  `enabled` BOOLEAN NOT NULL DEFAULT TRUE,
+  `created_by` BIGINT UNSIGNED NULL,
+  `created_at` DATETIME(3) NULL,
+  `updated_at` DATETIME(3) NULL,
+  `deleted_at` DATETIME(3) NULL,
+  PRIMARY KEY (`id`),
+  KEY `idx_referrer_rules_workspace_id` (`workspace_id`),
+  KEY `idx_referrer_rules_created_by` (`created_by`),
+  KEY `idx_referrer_rules_deleted_at` (`deleted_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- +goose Down
+DROP TABLE IF EXISTS `referrer_rules`;
+ALTER TABLE `click_statistics`
+  DROP INDEX `idx_click_statistics_referer_channel`,
+  DROP INDEX `idx_click_statistics_referer_host`,
+  DROP COLUMN `referer_channel`,
+  DROP COLUMN `referer_host`;
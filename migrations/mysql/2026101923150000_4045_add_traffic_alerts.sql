@@ -0,0 +1,84 @@
+-- +goose Up
+CREATE TABLE `traffic_alert_settings` (
+  `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  `workspace_id` BIGINT UNSIGNED NOT NULL,
+  `enabled` BOOLEAN NOT NULL DEFAULT TRUE,
+  `window_minutes` INT NOT NULL DEFAULT 60,
+  `baseline_days` INT NOT NULL DEFAULT 7,
+  `spike_factor` DOUBLE NOT NULL DEFAULT 3,
+  `spike_min_clicks` BIGINT NOT NULL DEFAULT 100,
+  `drop_min_hourly_clicks` DOUBLE NOT NULL DEFAULT 10,
+  `bot_ratio_delta` DOUBLE NOT NULL DEFAULT 0.3,
+  `country_shift_threshold` DOUBLE NOT NULL DEFAULT 0.5,
+  `mix_min_clicks` BIGINT NOT NULL DEFAULT 50,
+  `cooldown_minutes` INT NOT NULL DEFAULT 360,
+  `created_at` DATETIME(3) NULL,
+  `updated_at` DATETIME(3) NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_traffic_alert_settings_workspace_id` (`workspace_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE `alert_channels` (
+  `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  `workspace_id` BIGINT UNSIGNED NOT NULL,
+  `name` VARCHAR(100) NOT NULL,
+  `type` VARCHAR(20) NOT NULL,
+  `url` VARCHAR(1000) NOT NULL,
+  `secret` VARCHAR(255) NULL,
+  `alert_types` VARCHAR(255) NULL,
+  `enabled` BOOLEAN NOT NULL DEFAULT TRUE,
+  `created_by` BIGINT UNSIGNED NULL,
+  `created_at` DATETIME(3) NULL,
+  `updated_at` DATETIME(3) NULL,
+  `deleted_at` DATETIME(3) NULL,
+  PRIMARY KEY (`id`),
+  KEY `idx_alert_channels_workspace_id` (`workspace_id`),
+  KEY `idx_alert_channels_created_by` (`created_by`),
+  KEY `idx_alert_channels_deleted_at` (`deleted_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE `traffic_alerts` (
+  `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  `workspace_id` BIGINT UNSIGNED NOT NULL,
+  `short_link_id` BIGINT UNSIGNED NOT NULL DEFAULT 0,
+  `alert_type` VARCHAR(20) NOT NULL,
+  `dedupe_bucket` BIGINT NOT NULL DEFAULT 0,
+  `message` VARCHAR(500) NULL,
+  `observed` DOUBLE NOT NULL DEFAULT 0,
+  `baseline` DOUBLE NOT NULL DEFAULT 0,
+  `details` TEXT NULL,
+  `window_start` DATETIME(3) NULL,
+  `window_end` DATETIME(3) NULL,
+  `acknowledged_by` BIGINT UNSIGNED NULL,
+  `acknowledged_at` DATETIME(3) NULL,
+  `created_at` DATETIME(3) NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_traffic_alerts_dedupe` (`workspace_id`, `short_link_id`, `alert_type`, `dedupe_bucket`),
+  KEY `idx_traffic_alerts_workspace_id` (`workspace_id`),
+  KEY `idx_traffic_alerts_short_link_id` (`short_link_id`),
+  KEY `idx_traffic_alerts_created_at` (`created_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE `traffic_alert_deliveries` (
+  `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  `alert_id` BIGINT UNSIGNED NOT NULL,
+  `channel_id` BIGINT UNSIGNED NOT NULL,
+  `status` VARCHAR(20) NOT NULL,
+  `attempts` INT NOT NULL DEFAULT 0,
+  `last_error` VARCHAR(500) NULL,
+  `next_attempt_at` DATETIME(3) NULL,
+  `delivered_at` DATETIME(3) NULL,
+  `created_at` DATETIME(3) NULL,
+  `updated_at` DATETIME(3) NULL,
+  PRIMARY KEY (`id`),
+  KEY `idx_traffic_alert_deliveries_alert_id` (`alert_id`),
+  KEY `idx_traffic_alert_deliveries_channel_id` (`channel_id`),
+  KEY `idx_traffic_alert_deliveries_status` (`status`),
+  KEY `idx_traffic_alert_deliveries_next_attempt_at` (`next_attempt_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- +goose Down
+DROP TABLE IF EXISTS `traffic_alert_deliveries`;
+DROP TABLE IF EXISTS `traffic_alerts`;
+DROP TABLE IF EXISTS `alert_channels`;
+DROP TABLE IF EXISTS `traffic_alert_settings`;
@@ -0,0 +1,21 @@
+-- +goose Up
+ALTER TABLE `workspaces`
+  ADD COLUMN `anonymize_ip` TINYINT(1) NOT NULL DEFAULT 0,
+  ADD COLUMN `click_retention_days` INT NOT NULL DEFAULT 0,
+  ADD COLUMN `ab_test_click_retention_days` INT NOT NULL DEFAULT 0,
+  ADD COLUMN `security_event_retention_days` INT NOT NULL DEFAULT 0,
+  ADD COLUMN `operation_log_retention_days` INT NOT NULL DEFAULT 0;
+
+ALTER TABLE `click_statistics`
+  ADD KEY `idx_click_statistics_workspace_date` (`workspace_id`, `click_date`);
+
+-- +goose Down
+ALTER TABLE `click_statistics`
+  DROP INDEX `idx_click_statistics_workspace_date`;
+
+ALTER TABLE `workspaces`
+  DROP COLUMN `operation_log_retention_days`,
+  DROP COLUMN `security_event_retention_days`,
+  DROP COLUMN `ab_test_click_retention_days`,
+  DROP COLUMN `click_retention_days`,
+  DROP COLUMN `anonymize_ip`;
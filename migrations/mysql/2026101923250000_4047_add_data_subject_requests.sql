@@ -0,0 +1,26 @@
+-- +goose Up
+CREATE TABLE `data_subject_requests` (
+  `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  `workspace_id` BIGINT UNSIGNED NOT NULL,
+  `action` VARCHAR(20) NOT NULL,
+  `ip` VARCHAR(45) NULL,
+  `visitor_key` VARCHAR(64) NULL,
+  `subject` VARCHAR(255) NULL,
+  `status` VARCHAR(20) NOT NULL,
+  `result` TEXT NULL,
+  `error` VARCHAR(500) NULL,
+  `requested_by` BIGINT UNSIGNED NULL,
+  `claim_version` BIGINT NOT NULL DEFAULT 0,
+  `heartbeat_at` DATETIME(3) NULL,
+  `started_at` DATETIME(3) NULL,
+  `finished_at` DATETIME(3) NULL,
+  `created_at` DATETIME(3) NULL,
+  `updated_at` DATETIME(3) NULL,
+  PRIMARY KEY (`id`),
+  KEY `idx_data_subject_requests_workspace_id` (`workspace_id`),
+  KEY `idx_data_subject_requests_status` (`status`),
+  KEY `idx_data_subject_requests_requested_by` (`requested_by`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- +goose Down
+DROP TABLE IF EXISTS `data_subject_requests`;
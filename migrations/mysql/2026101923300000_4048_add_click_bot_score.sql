@@ -0,0 +1,9 @@
+-- +goose Up
+ALTER TABLE `click_statistics`
+  ADD COLUMN `bot_score` INT NOT NULL DEFAULT 0,
+  ADD COLUMN `bot_reason` VARCHAR(255) NULL;
+
+-- +goose Down
+ALTER TABLE `click_statistics`
+  DROP COLUMN `bot_reason`,
+  DROP COLUMN `bot_score`;
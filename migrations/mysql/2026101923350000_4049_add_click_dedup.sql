@@ -0,0 +1,17 @@
+-- +goose Up
+ALTER TABLE `click_statistics`
+  ADD COLUMN `is_duplicate` TINYINT(1) NOT NULL DEFAULT 0;
+CREATE INDEX `idx_click_statistics_is_duplicate` ON `click_statistics` (`is_duplicate`);
+ALTER TABLE `short_links`
+  ADD COLUMN `duplicate_click_count` BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE `workspaces`
+  ADD COLUMN `click_dedup_seconds` INT NOT NULL DEFAULT 0;
+
+-- +goose Down
+ALTER TABLE `workspaces`
+  DROP COLUMN `click_dedup_seconds`;
+ALTER TABLE `short_links`
+  DROP COLUMN `duplicate_click_count`;
+DROP INDEX `idx_click_statistics_is_duplicate` ON `click_statistics`;
+ALTER TABLE `click_statistics`
+  DROP COLUMN `is_duplicate`;
@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE `workspaces`
+  ADD COLUMN `timezone` VARCHAR(64) NULL;
+
+-- +goose Down
+ALTER TABLE `workspaces`
+  DROP COLUMN `timezone`;
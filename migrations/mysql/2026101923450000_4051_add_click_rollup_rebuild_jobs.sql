@@ -0,0 +1,25 @@
+-- +goose Up
+CREATE TABLE `click_rollup_rebuild_jobs` (
+  `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  `workspace_id` BIGINT UNSIGNED NOT NULL,
+  `start_date` DATETIME(3) NULL,
+  `end_date` DATETIME(3) NULL,
+  `next_date` DATETIME(3) NULL,
+  `status` VARCHAR(20) NOT NULL,
+  `clicks` BIGINT NOT NULL DEFAULT 0,
+  `error` VARCHAR(500) NULL,
+  `requested_by` BIGINT UNSIGNED NULL,
+  `claim_version` BIGINT NOT NULL DEFAULT 0,
+  `heartbeat_at` DATETIME(3) NULL,
+  `started_at` DATETIME(3) NULL,
+  `finished_at` DATETIME(3) NULL,
+  `created_at` DATETIME(3) NULL,
+  `updated_at` DATETIME(3) NULL,
+  PRIMARY KEY (`id`),
+  KEY `idx_click_rollup_rebuild_jobs_workspace_id` (`workspace_id`),
+  KEY `idx_click_rollup_rebuild_jobs_status` (`status`),
+  KEY `idx_click_rollup_rebuild_jobs_requested_by` (`requested_by`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- +goose Down
+DROP TABLE IF EXISTS `click_rollup_rebuild_jobs`;
@@ -0,0 +1,13 @@
+-- +goose Up
+CREATE TABLE `click_export_chunks` (
+  `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  `job_id` BIGINT UNSIGNED NOT NULL,
+  `seq` INT NOT NULL,
+  `data` LONGBLOB NOT NULL,
+  `created_at` DATETIME(3) NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_click_export_chunks_job_seq` (`job_id`, `seq`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- +goose Down
+DROP TABLE IF EXISTS `click_export_chunks`;
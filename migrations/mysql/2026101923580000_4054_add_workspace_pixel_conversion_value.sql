@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE `workspaces`
+  ADD COLUMN `pixel_conversion_value` TINYINT(1) NOT NULL DEFAULT 0;
+
+-- +goose Down
+ALTER TABLE `workspaces`
+  DROP COLUMN `pixel_conversion_value`;
@@ -0,0 +1,5 @@
+-- +goose Up
+DELETE FROM `click_rollups` WHERE `dimension` = 'referer';
+
+-- +goose Down
+-- 已删除的完整来源地址汇总无法恢复
@@ -0,0 +1,26 @@
+-- +goose Up
+ALTER TABLE short_links ADD COLUMN external_id VARCHAR(128);
+
+CREATE UNIQUE INDEX uk_short_links_workspace_external_id
+  ON short_links(workspace_id, external_id)
+  WHERE deleted_at IS NULL AND external_id IS NOT NULL AND external_id <> '';
+
+CREATE TABLE idempotency_keys (
+  id BIGSERIAL PRIMARY KEY,
+  workspace_id BIGINT NOT NULL DEFAULT 1,
+  scope VARCHAR(100) NOT NULL,
+  idempotency_key VARCHAR(255) NOT NULL,
+  request_hash CHAR(64) NOT NULL,
+  response_body TEXT,
+  completed_at TIMESTAMP,
+  expires_at TIMESTAMP NOT NULL,
+  created_at TIMESTAMP
+);
+
+CREATE UNIQUE INDEX uk_idempotency_keys_scope_key ON idempotency_keys(workspace_id, scope, idempotency_key);
+CREATE INDEX idx_idempotency_keys_expires_at ON idempotency_keys(expires_at);
+
+-- +goose Down
+DROP TABLE IF EXISTS idempotency_keys;
+DROP INDEX IF EXISTS uk_short_links_workspace_external_id;
+ALTER TABLE short_links DROP COLUMN external_id;
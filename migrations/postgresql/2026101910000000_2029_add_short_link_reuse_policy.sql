@@ -0,0 +1,10 @@
+-- +goose Up
+ALTER TABLE short_links ADD COLUMN original_url_hash CHAR(64);
+CREATE INDEX idx_short_links_reuse_lookup ON short_links(workspace_id, domain, original_url_hash);
+
+ALTER TABLE workspaces ADD COLUMN reuse_existing_links BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- +goose Down
+ALTER TABLE workspaces DROP COLUMN reuse_existing_links;
+DROP INDEX IF EXISTS idx_short_links_reuse_lookup;
+ALTER TABLE short_links DROP COLUMN original_url_hash;
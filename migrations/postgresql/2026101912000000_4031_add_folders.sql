@@ -0,0 +1,32 @@
+-- +goose Up
+CREATE TABLE folders (
+  id BIGSERIAL PRIMARY KEY,
+  workspace_id BIGINT NOT NULL DEFAULT 1,
+  parent_id BIGINT,
+  name VARCHAR(100) NOT NULL,
+  description VARCHAR(500),
+  created_by BIGINT,
+  created_at TIMESTAMP,
+  updated_at TIMESTAMP,
+  deleted_at TIMESTAMP,
+  default_domain VARCHAR(100),
+  default_utm_source VARCHAR(255),
+  default_utm_medium VARCHAR(255),
+  default_utm_campaign VARCHAR(255),
+  default_utm_term VARCHAR(255),
+  default_utm_content VARCHAR(255),
+  default_security TEXT
+);
+
+CREATE INDEX idx_folders_workspace_parent ON folders(workspace_id, parent_id);
+CREATE INDEX idx_folders_parent_id ON folders(parent_id);
+CREATE INDEX idx_folders_created_by ON folders(created_by);
+CREATE INDEX idx_folders_deleted_at ON folders(deleted_at);
+
+ALTER TABLE short_links ADD COLUMN folder_id BIGINT;
+CREATE INDEX idx_short_links_folder_id ON short_links(folder_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_short_links_folder_id;
+ALTER TABLE short_links DROP COLUMN folder_id;
+DROP TABLE IF EXISTS folders;
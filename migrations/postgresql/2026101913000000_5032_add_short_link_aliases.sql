@@ -0,0 +1,20 @@
+-- +goose Up
+CREATE TABLE short_link_aliases (
+  id BIGSERIAL PRIMARY KEY,
+  workspace_id BIGINT NOT NULL DEFAULT 1,
+  short_link_id BIGINT NOT NULL,
+  domain_id BIGINT NOT NULL,
+  protocol VARCHAR(10) NOT NULL DEFAULT 'https',
+  domain VARCHAR(100) NOT NULL,
+  short_code VARCHAR(20) NOT NULL,
+  source VARCHAR(20) NOT NULL DEFAULT 'rekey',
+  created_by BIGINT,
+  created_at TIMESTAMP
+);
+
+CREATE UNIQUE INDEX uk_short_link_aliases_domain_code ON short_link_aliases(domain, short_code);
+CREATE INDEX idx_short_link_aliases_workspace_id ON short_link_aliases(workspace_id);
+CREATE INDEX idx_short_link_aliases_short_link_id ON short_link_aliases(short_link_id);
+
+-- +goose Down
+DROP TABLE IF EXISTS short_link_aliases;
@@ -0,0 +1,9 @@
+-- +goose Up
+ALTER TABLE click_statistics ADD COLUMN alias_id BIGINT;
+ALTER TABLE click_statistics ADD COLUMN alias_code VARCHAR(20);
+CREATE INDEX idx_click_statistics_alias_id ON click_statistics(alias_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_click_statistics_alias_id;
+ALTER TABLE click_statistics DROP COLUMN alias_code;
+ALTER TABLE click_statistics DROP COLUMN alias_id;
@@ -0,0 +1,34 @@
+-- +goose Up
+CREATE TABLE link_health_checks (
+  id BIGSERIAL PRIMARY KEY,
+  workspace_id BIGINT NOT NULL,
+  short_link_id BIGINT NOT NULL,
+  target_type VARCHAR(20) NOT NULL,
+  target_id BIGINT NOT NULL DEFAULT 0,
+  target_url VARCHAR(2000) NOT NULL,
+  healthy BOOLEAN NOT NULL DEFAULT FALSE,
+  status_code INTEGER NOT NULL DEFAULT 0,
+  error VARCHAR(500),
+  redirect_chain TEXT,
+  latency_ms BIGINT NOT NULL DEFAULT 0,
+  tls_expires_at TIMESTAMP,
+  consecutive_failures INTEGER NOT NULL DEFAULT 0,
+  checked_at TIMESTAMP,
+  created_at TIMESTAMP,
+  updated_at TIMESTAMP
+);
+
+CREATE UNIQUE INDEX uk_link_health_checks_target ON link_health_checks(short_link_id, target_type, target_id);
+CREATE INDEX idx_link_health_checks_workspace_id ON link_health_checks(workspace_id);
+
+ALTER TABLE short_links ADD COLUMN health_status VARCHAR(20);
+ALTER TABLE short_links ADD COLUMN health_checked_at TIMESTAMP;
+CREATE INDEX idx_short_links_health_status ON short_links(health_status);
+CREATE INDEX idx_short_links_health_checked_at ON short_links(health_checked_at);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_short_links_health_checked_at;
+DROP INDEX IF EXISTS idx_short_links_health_status;
+ALTER TABLE short_links DROP COLUMN health_checked_at;
+ALTER TABLE short_links DROP COLUMN health_status;
+DROP TABLE IF EXISTS link_health_checks;
@@ -0,0 +1,22 @@
+-- +goose Up
+CREATE TABLE short_link_metadata (
+  id BIGSERIAL PRIMARY KEY,
+  workspace_id BIGINT NOT NULL,
+  short_link_id BIGINT NOT NULL,
+  source_url VARCHAR(2000) NOT NULL,
+  title VARCHAR(255),
+  description VARCHAR(500),
+  image_url VARCHAR(2000),
+  favicon_url VARCHAR(2000),
+  status VARCHAR(20) NOT NULL,
+  error VARCHAR(500),
+  fetched_at TIMESTAMP,
+  created_at TIMESTAMP,
+  updated_at TIMESTAMP
+);
+
+CREATE UNIQUE INDEX uk_short_link_metadata_short_link_id ON short_link_metadata(short_link_id);
+CREATE INDEX idx_short_link_metadata_workspace_id ON short_link_metadata(workspace_id);
+
+-- +goose Down
+DROP TABLE IF EXISTS short_link_metadata;
@@ -0,0 +1,21 @@
+-- +goose Up
+CREATE TABLE link_templates (
+  id BIGSERIAL PRIMARY KEY,
+  workspace_id BIGINT NOT NULL,
+  name VARCHAR(100) NOT NULL,
+  description VARCHAR(500),
+  is_default BOOLEAN NOT NULL DEFAULT FALSE,
+  settings TEXT,
+  created_by BIGINT,
+  updated_by BIGINT,
+  created_at TIMESTAMP,
+  updated_at TIMESTAMP,
+  deleted_at TIMESTAMP
+);
+
+CREATE INDEX idx_link_templates_workspace_id ON link_templates(workspace_id);
+CREATE INDEX idx_link_templates_created_by ON link_templates(created_by);
+CREATE INDEX idx_link_templates_deleted_at ON link_templates(deleted_at);
+
+-- +goose Down
+DROP TABLE IF EXISTS link_templates;
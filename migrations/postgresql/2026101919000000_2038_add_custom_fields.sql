@@ -0,0 +1,46 @@
+-- +goose Up
+CREATE TABLE custom_fields (
+  id BIGSERIAL PRIMARY KEY,
+  workspace_id BIGINT NOT NULL,
+  field_key VARCHAR(50) NOT NULL,
+  name VARCHAR(100) NOT NULL,
+  description VARCHAR(500),
+  field_type VARCHAR(20) NOT NULL,
+  options TEXT,
+  required BOOLEAN NOT NULL DEFAULT FALSE,
+  max_length INTEGER NOT NULL DEFAULT 0,
+  min_value DOUBLE PRECISION,
+  max_value DOUBLE PRECISION,
+  position INTEGER NOT NULL DEFAULT 0,
+  created_by BIGINT,
+  updated_by BIGINT,
+  created_at TIMESTAMP,
+  updated_at TIMESTAMP,
+  deleted_at TIMESTAMP
+);
+
+CREATE INDEX idx_custom_fields_workspace_id ON custom_fields(workspace_id);
+CREATE INDEX idx_custom_fields_field_key ON custom_fields(field_key);
+CREATE INDEX idx_custom_fields_created_by ON custom_fields(created_by);
+CREATE INDEX idx_custom_fields_deleted_at ON custom_fields(deleted_at);
+
+CREATE TABLE short_link_custom_values (
+  id BIGSERIAL PRIMARY KEY,
+  workspace_id BIGINT NOT NULL,
+  short_link_id BIGINT NOT NULL,
+  field_id BIGINT NOT NULL,
+  field_key VARCHAR(50) NOT NULL,
+  value VARCHAR(500) NOT NULL,
+  number_value DOUBLE PRECISION,
+  created_at TIMESTAMP,
+  updated_at TIMESTAMP
+);
+
+CREATE UNIQUE INDEX idx_short_link_custom_values_link_field ON short_link_custom_values(short_link_id, field_id);
+CREATE INDEX idx_short_link_custom_values_workspace_id ON short_link_custom_values(workspace_id);
+CREATE INDEX idx_short_link_custom_values_field_id ON short_link_custom_values(field_id);
+CREATE INDEX idx_short_link_custom_values_field_key ON short_link_custom_values(field_key);
+
+-- +goose Down
+DROP TABLE IF EXISTS short_link_custom_values;
+DROP TABLE IF EXISTS custom_fields;
@@ -0,0 +1,43 @@
+-- +goose Up
+ALTER TABLE workspaces ADD COLUMN conversion_secret VARCHAR(64);
+
+ALTER TABLE short_links ADD COLUMN append_click_id BOOLEAN NOT NULL DEFAULT FALSE;
+
+ALTER TABLE click_statistics ADD COLUMN click_id VARCHAR(64);
+CREATE INDEX idx_click_statistics_click_id ON click_statistics(click_id);
+
+CREATE TABLE conversions (
+  id BIGSERIAL PRIMARY KEY,
+  workspace_id BIGINT NOT NULL,
+  short_link_id BIGINT NOT NULL,
+  campaign_id BIGINT,
+  route_id BIGINT,
+  click_statistic_id BIGINT NOT NULL,
+  click_id VARCHAR(64) NOT NULL,
+  event VARCHAR(64) NOT NULL,
+  event_id VARCHAR(128) NOT NULL,
+  value DECIMAL(18,4),
+  currency VARCHAR(16),
+  source VARCHAR(20) NOT NULL,
+  metadata TEXT,
+  ip VARCHAR(45),
+  user_agent VARCHAR(1024),
+  clicked_at TIMESTAMP,
+  occurred_at TIMESTAMP NOT NULL,
+  created_at TIMESTAMP
+);
+
+CREATE UNIQUE INDEX uk_conversions_event ON conversions(workspace_id, event_id);
+CREATE INDEX idx_conversions_short_link_id ON conversions(short_link_id);
+CREATE INDEX idx_conversions_campaign_id ON conversions(campaign_id);
+CREATE INDEX idx_conversions_route_id ON conversions(route_id);
+CREATE INDEX idx_conversions_click_statistic_id ON conversions(click_statistic_id);
+CREATE INDEX idx_conversions_click_id ON conversions(click_id);
+CREATE INDEX idx_conversions_occurred_at ON conversions(occurred_at);
+
+-- +goose Down
+DROP TABLE IF EXISTS conversions;
+DROP INDEX IF EXISTS idx_click_statistics_click_id;
+ALTER TABLE click_statistics DROP COLUMN click_id;
+ALTER TABLE short_links DROP COLUMN append_click_id;
+ALTER TABLE workspaces DROP COLUMN conversion_secret;
@@ -0,0 +1,31 @@
+-- +goose Up
+CREATE TABLE click_rollups (
+  id BIGSERIAL PRIMARY KEY,
+  workspace_id BIGINT NOT NULL,
+  short_link_id BIGINT NOT NULL,
+  campaign_id BIGINT NOT NULL DEFAULT 0,
+  is_bot BOOLEAN NOT NULL DEFAULT FALSE,
+  granularity VARCHAR(8) NOT NULL,
+  bucket_start TIMESTAMP NOT NULL,
+  dimension VARCHAR(32) NOT NULL,
+  country VARCHAR(100) NOT NULL DEFAULT '',
+  province VARCHAR(100) NOT NULL DEFAULT '',
+  dimension_value VARCHAR(255) NOT NULL DEFAULT '',
+  label VARCHAR(255),
+  clicks BIGINT NOT NULL DEFAULT 0,
+  updated_at TIMESTAMP
+);
+
+CREATE UNIQUE INDEX uk_click_rollups_key ON click_rollups(workspace_id, short_link_id, campaign_id, is_bot, granularity, bucket_start, dimension, country, province, dimension_value);
+CREATE INDEX idx_click_rollups_short_link_id ON click_rollups(short_link_id);
+CREATE INDEX idx_click_rollups_bucket_start ON click_rollups(bucket_start);
+
+CREATE TABLE click_rollup_cursors (
+  name VARCHAR(50) PRIMARY KEY,
+  last_click_id BIGINT NOT NULL DEFAULT 0,
+  updated_at TIMESTAMP
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS click_rollup_cursors;
+DROP TABLE IF EXISTS click_rollups;
@@ -0,0 +1,27 @@
+-- +goose Up
+ALTER TABLE click_statistics ADD COLUMN visitor_key VARCHAR(64);
+CREATE INDEX idx_click_statistics_visitor_key ON click_statistics(visitor_key);
+
+CREATE TABLE visitor_salts (
+  day VARCHAR(10) PRIMARY KEY,
+  salt VARCHAR(64) NOT NULL,
+  created_at TIMESTAMP
+);
+
+CREATE TABLE visitor_sketches (
+  id BIGSERIAL PRIMARY KEY,
+  workspace_id BIGINT NOT NULL,
+  short_link_id BIGINT NOT NULL DEFAULT 0,
+  day TIMESTAMP NOT NULL,
+  registers BYTEA NOT NULL,
+  updated_at TIMESTAMP
+);
+
+CREATE UNIQUE INDEX uk_visitor_sketches_key ON visitor_sketches(workspace_id, short_link_id, day);
+CREATE INDEX idx_visitor_sketches_day ON visitor_sketches(day);
+
+-- +goose Down
+DROP TABLE IF EXISTS visitor_sketches;
+DROP TABLE IF EXISTS visitor_salts;
+DROP INDEX IF EXISTS idx_click_statistics_visitor_key;
+ALTER TABLE click_statistics DROP COLUMN visitor_key;
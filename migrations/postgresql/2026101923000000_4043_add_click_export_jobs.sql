@@ -0,0 +1,30 @@
+-- +goose Up
+CREATE TABLE click_export_jobs (
+  id BIGSERIAL PRIMARY KEY,
+  workspace_id BIGINT NOT NULL,
+  user_id BIGINT NOT NULL DEFAULT 0,
+  format VARCHAR(20) NOT NULL,
+  filters TEXT,
+  status VARCHAR(20) NOT NULL,
+  max_click_id BIGINT NOT NULL DEFAULT 0,
+  last_click_id BIGINT NOT NULL DEFAULT 0,
+  row_count BIGINT NOT NULL DEFAULT 0,
+  file_name VARCHAR(255),
+  file_size BIGINT NOT NULL DEFAULT 0,
+  error VARCHAR(500),
+  download_token VARCHAR(64),
+  claim_version BIGINT NOT NULL DEFAULT 0,
+  heartbeat_at TIMESTAMP,
+  expires_at TIMESTAMP,
+  started_at TIMESTAMP,
+  finished_at TIMESTAMP,
+  created_at TIMESTAMP,
+  updated_at TIMESTAMP
+);
+
+CREATE INDEX idx_click_export_jobs_workspace_id ON click_export_jobs(workspace_id);
+CREATE INDEX idx_click_export_jobs_status ON click_export_jobs(status);
+CREATE INDEX idx_click_export_jobs_download_token ON click_export_jobs(download_token);
+
+-- +goose Down
+DROP TABLE IF EXISTS click_export_jobs;
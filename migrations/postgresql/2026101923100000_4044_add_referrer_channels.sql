@@ -0,0 +1,30 @@
+-- +goose Up
+ALTER TABLE click_statistics ADD COLUMN referer_host VARCHAR(255);
+ALTER TABLE click_statistics ADD COLUMN referer_channel VARCHAR(20);
+CREATE INDEX idx_click_statistics_referer_host ON click_statistics(referer_host);
+CREATE INDEX idx_click_statistics_referer_channel ON click_statistics(referer_channel);
+
+CREATE TABLE referrer_rules (
+  id BIGSERIAL PRIMARY KEY,
+  workspace_id BIGINT NOT NULL,
+  match_type VARCHAR(20) NOT NULL,
+  pattern VARCHAR(255) NOT NULL,
+  channel VARCHAR(20) NOT NULL,
+  priority INTEGER NOT NULL DEFAULT 0,
+  enabled BOOLEAN NOT NULL DEFAULT TRUE,
+  created_by BIGINT,
+  created_at TIMESTAMP,
+  updated_at TIMESTAMP,
+  deleted_at TIMESTAMP
+);
+
+CREATE INDEX idx_referrer_rules_workspace_id ON referrer_rules(workspace_id);
+CREATE INDEX idx_referrer_rules_created_by ON referrer_rules(created_by);
+CREATE INDEX idx_referrer_rules_deleted_at ON referrer_rules(deleted_at);
+
+-- +goose Down
+DROP TABLE IF EXISTS referrer_rules;
+DROP INDEX IF EXISTS idx_click_statistics_referer_channel;
+DROP INDEX IF EXISTS idx_click_statistics_referer_host;
+ALTER TABLE click_statistics DROP COLUMN referer_channel;
+ALTER TABLE click_statistics DROP COLUMN referer_host;
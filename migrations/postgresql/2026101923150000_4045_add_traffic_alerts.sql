@@ -0,0 +1,84 @@
+-- +goose Up
+CREATE TABLE traffic_alert_settings (
+  id BIGSERIAL PRIMARY KEY,
+  workspace_id BIGINT NOT NULL,
+  enabled BOOLEAN NOT NULL DEFAULT TRUE,
+  window_minutes INTEGER NOT NULL DEFAULT 60,
+  baseline_days INTEGER NOT NULL DEFAULT 7,
+  spike_factor DOUBLE PRECISION NOT NULL DEFAULT 3,
+  spike_min_clicks BIGINT NOT NULL DEFAULT 100,
+  drop_min_hourly_clicks DOUBLE PRECISION NOT NULL DEFAULT 10,
+  bot_ratio_delta DOUBLE PRECISION NOT NULL DEFAULT 0.3,
+  country_shift_threshold DOUBLE PRECISION NOT NULL DEFAULT 0.5,
+  mix_min_clicks BIGINT NOT NULL DEFAULT 50,
+  cooldown_minutes INTEGER NOT NULL DEFAULT 360,
+  created_at TIMESTAMP,
+  updated_at TIMESTAMP
+);
+
+CREATE UNIQUE INDEX idx_traffic_alert_settings_workspace_id ON traffic_alert_settings(workspace_id);
+
+CREATE TABLE alert_channels (
+  id BIGSERIAL PRIMARY KEY,
+  workspace_id BIGINT NOT NULL,
+  name VARCHAR(100) NOT NULL,
+  type VARCHAR(20) NOT NULL,
+  url VARCHAR(1000) NOT NULL,
+  secret VARCHAR(255),
+  alert_types VARCHAR(255),
+  enabled BOOLEAN NOT NULL DEFAULT TRUE,
+  created_by BIGINT,
+  created_at TIMESTAMP,
+  updated_at TIMESTAMP,
+  deleted_at TIMESTAMP
+);
+
+CREATE INDEX idx_alert_channels_workspace_id ON alert_channels(workspace_id);
+CREATE INDEX idx_alert_channels_created_by ON alert_channels(created_by);
+CREATE INDEX idx_alert_channels_deleted_at ON alert_channels(deleted_at);
+
+CREATE TABLE traffic_alerts (
+  id BIGSERIAL PRIMARY KEY,
+  workspace_id BIGINT NOT NULL,
+  short_link_id BIGINT NOT NULL DEFAULT 0,
+  alert_type VARCHAR(20) NOT NULL,
+  dedupe_bucket BIGINT NOT NULL DEFAULT 0,
+  message VARCHAR(500),
+  observed DOUBLE PRECISION NOT NULL DEFAULT 0,
+  baseline DOUBLE PRECISION NOT NULL DEFAULT 0,
+  details TEXT,
+  window_start TIMESTAMP,
+  window_end TIMESTAMP,
+  acknowledged_by BIGINT,
+  acknowledged_at TIMESTAMP,
+  created_at TIMESTAMP
+);
+
+CREATE UNIQUE INDEX uk_traffic_alerts_dedupe ON traffic_alerts(workspace_id, short_link_id, alert_type, dedupe_bucket);
+CREATE INDEX idx_traffic_alerts_workspace_id ON traffic_alerts(workspace_id);
+CREATE INDEX idx_traffic_alerts_short_link_id ON traffic_alerts(short_link_id);
+CREATE INDEX idx_traffic_alerts_created_at ON traffic_alerts(created_at);
+
+CREATE TABLE traffic_alert_deliveries (
+  id BIGSERIAL PRIMARY KEY,
+  alert_id BIGINT NOT NULL,
+  channel_id BIGINT NOT NULL,
+  status VARCHAR(20) NOT NULL,
+  attempts INTEGER NOT NULL DEFAULT 0,
+  last_error VARCHAR(500),
+  next_attempt_at TIMESTAMP,
+  delivered_at TIMESTAMP,
+  created_at TIMESTAMP,
+  updated_at TIMESTAMP
+);
+
+CREATE INDEX idx_traffic_alert_deliveries_alert_id ON traffic_alert_deliveries(alert_id);
+CREATE INDEX idx_traffic_alert_deliveries_channel_id ON traffic_alert_deliveries(channel_id);
+CREATE INDEX idx_traffic_alert_deliveries_status ON traffic_alert_deliveries(status);
+CREATE INDEX idx_traffic_alert_deliveries_next_attempt_at ON traffic_alert_deliveries(next_attempt_at);
+
+-- +goose Down
+DROP TABLE IF EXISTS traffic_alert_deliveries;
+DROP TABLE IF EXISTS traffic_alerts;
+DROP TABLE IF EXISTS alert_channels;
+DROP TABLE IF EXISTS traffic_alert_settings;
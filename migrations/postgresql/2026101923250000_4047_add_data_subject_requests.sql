@@ -0,0 +1,26 @@
+-- +goose Up
+CREATE TABLE data_subject_requests (
+  id BIGSERIAL PRIMARY KEY,
+  workspace_id BIGINT NOT NULL,
+  action VARCHAR(20) NOT NULL,
+  ip VARCHAR(45),
+  visitor_key VARCHAR(64),
+  subject VARCHAR(255),
+  status VARCHAR(20) NOT NULL,
+  result TEXT,
+  error VARCHAR(500),
+  requested_by BIGINT,
+  claim_version BIGINT NOT NULL DEFAULT 0,
+  heartbeat_at TIMESTAMP,
+  started_at TIMESTAMP,
+  finished_at TIMESTAMP,
+  created_at TIMESTAMP,
+  updated_at TIMESTAMP
+);
+
+CREATE INDEX idx_data_subject_requests_workspace_id ON data_subject_requests(workspace_id);
+CREATE INDEX idx_data_subject_requests_status ON data_subject_requests(status);
+CREATE INDEX idx_data_subject_requests_requested_by ON data_subject_requests(requested_by);
+
+-- +goose Down
+DROP TABLE IF EXISTS data_subject_requests;
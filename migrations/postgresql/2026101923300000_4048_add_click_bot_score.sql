@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE click_statistics ADD COLUMN bot_score INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE click_statistics ADD COLUMN bot_reason VARCHAR(255);
+
+-- +goose Down
+ALTER TABLE click_statistics DROP COLUMN bot_reason;
+ALTER TABLE click_statistics DROP COLUMN bot_score;
@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE workspaces ADD COLUMN timezone VARCHAR(64);
+
+-- +goose Down
+ALTER TABLE workspaces DROP COLUMN timezone;
@@ -0,0 +1,25 @@
+-- +goose Up
+CREATE TABLE click_rollup_rebuild_jobs (
+  id BIGSERIAL PRIMARY KEY,
+  workspace_id BIGINT NOT NULL,
+  start_date TIMESTAMP,
+  end_date TIMESTAMP,
+  next_date TIMESTAMP,
+  status VARCHAR(20) NOT NULL,
+  clicks BIGINT NOT NULL DEFAULT 0,
+  error VARCHAR(500),
+  requested_by BIGINT,
+  claim_version BIGINT NOT NULL DEFAULT 0,
+  heartbeat_at TIMESTAMP,
+  started_at TIMESTAMP,
+  finished_at TIMESTAMP,
+  created_at TIMESTAMP,
+  updated_at TIMESTAMP
+);
+
+CREATE INDEX idx_click_rollup_rebuild_jobs_workspace_id ON click_rollup_rebuild_jobs(workspace_id);
+CREATE INDEX idx_click_rollup_rebuild_jobs_status ON click_rollup_rebuild_jobs(status);
+CREATE INDEX idx_click_rollup_rebuild_jobs_requested_by ON click_rollup_rebuild_jobs(requested_by);
+
+-- +goose Down
+DROP TABLE IF EXISTS click_rollup_rebuild_jobs;
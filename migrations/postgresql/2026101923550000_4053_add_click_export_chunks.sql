@@ -0,0 +1,13 @@
+-- +goose Up
+CREATE TABLE click_export_chunks (
+  id BIGSERIAL PRIMARY KEY,
+  job_id BIGINT NOT NULL,
+  seq INTEGER NOT NULL,
+  data BYTEA NOT NULL,
+  created_at TIMESTAMP
+);
+
+CREATE UNIQUE INDEX idx_click_export_chunks_job_seq ON click_export_chunks(job_id, seq);
+
+-- +goose Down
+DROP TABLE IF EXISTS click_export_chunks;
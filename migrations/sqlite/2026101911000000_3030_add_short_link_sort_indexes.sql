@@ -0,0 +1,9 @@
+-- +goose Up
+CREATE INDEX idx_short_links_ws_created ON short_links(workspace_id, created_at, id);
+CREATE INDEX idx_short_links_ws_updated ON short_links(workspace_id, updated_at, id);
+CREATE INDEX idx_short_links_ws_clicks ON short_links(workspace_id, click_count, id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_short_links_ws_clicks;
+DROP INDEX IF EXISTS idx_short_links_ws_updated;
+DROP INDEX IF EXISTS idx_short_links_ws_created;
@@ -0,0 +1,32 @@
+-- +goose Up
+CREATE TABLE folders (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  workspace_id INTEGER NOT NULL DEFAULT 1,
+  parent_id INTEGER,
+  name TEXT NOT NULL,
+  description TEXT,
+  created_by INTEGER,
+  created_at DATETIME,
+  updated_at DATETIME,
+  deleted_at DATETIME,
+  default_domain TEXT,
+  default_utm_source TEXT,
+  default_utm_medium TEXT,
+  default_utm_campaign TEXT,
+  default_utm_term TEXT,
+  default_utm_content TEXT,
+  default_security TEXT
+);
+
+CREATE INDEX idx_folders_workspace_parent ON folders(workspace_id, parent_id);
+CREATE INDEX idx_folders_parent_id ON folders(parent_id);
+CREATE INDEX idx_folders_created_by ON folders(created_by);
+CREATE INDEX idx_folders_deleted_at ON folders(deleted_at);
+
+ALTER TABLE short_links ADD COLUMN folder_id INTEGER;
+CREATE INDEX idx_short_links_folder_id ON short_links(folder_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_short_links_folder_id;
+ALTER TABLE short_links DROP COLUMN folder_id;
+DROP TABLE IF EXISTS folders;
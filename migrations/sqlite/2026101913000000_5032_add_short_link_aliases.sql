@@ -0,0 +1,20 @@
+-- +goose Up
+CREATE TABLE short_link_aliases (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  workspace_id INTEGER NOT NULL DEFAULT 1,
+  short_link_id INTEGER NOT NULL,
+  domain_id INTEGER NOT NULL,
+  protocol TEXT NOT NULL DEFAULT 'https',
+  domain TEXT NOT NULL,
+  short_code TEXT NOT NULL,
+  source TEXT NOT NULL DEFAULT 'rekey',
+  created_by INTEGER,
+  created_at DATETIME
+);
+
+CREATE UNIQUE INDEX uk_short_link_aliases_domain_code ON short_link_aliases(domain, short_code);
+CREATE INDEX idx_short_link_aliases_workspace_id ON short_link_aliases(workspace_id);
+CREATE INDEX idx_short_link_aliases_short_link_id ON short_link_aliases(short_link_id);
+
+-- +goose Down
+DROP TABLE IF EXISTS short_link_aliases;
@@ -0,0 +1,9 @@
+-- +goose Up
+ALTER TABLE click_statistics ADD COLUMN alias_id INTEGER;
+ALTER TABLE click_statistics ADD COLUMN alias_code TEXT;
+CREATE INDEX idx_click_statistics_alias_id ON click_statistics(alias_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_click_statistics_alias_id;
+ALTER TABLE click_statistics DROP COLUMN alias_code;
+ALTER TABLE click_statistics DROP COLUMN alias_id;
@@ -0,0 +1,34 @@
+-- +goose Up
+CREATE TABLE link_health_checks (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  workspace_id INTEGER NOT NULL,
+  short_link_id INTEGER NOT NULL,
+  target_type TEXT NOT NULL,
+  target_id INTEGER NOT NULL DEFAULT 0,
+  target_url TEXT NOT NULL,
+  healthy BOOLEAN NOT NULL DEFAULT FALSE,
+  status_code INTEGER NOT NULL DEFAULT 0,
+  error TEXT,
+  redirect_chain TEXT,
+  latency_ms INTEGER NOT NULL DEFAULT 0,
+  tls_expires_at DATETIME,
+  consecutive_failures INTEGER NOT NULL DEFAULT 0,
+  checked_at DATETIME,
+  created_at DATETIME,
+  updated_at DATETIME
+);
+
+CREATE UNIQUE INDEX uk_link_health_checks_target ON link_health_checks(short_link_id, target_type, target_id);
+CREATE INDEX idx_link_health_checks_workspace_id ON link_health_checks(workspace_id);
+
+ALTER TABLE short_links ADD COLUMN health_status TEXT;
+ALTER TABLE short_links ADD COLUMN health_checked_at DATETIME;
+CREATE INDEX idx_short_links_health_status ON short_links(health_status);
+CREATE INDEX idx_short_links_health_checked_at ON short_links(health_checked_at);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_short_links_health_checked_at;
+DROP INDEX IF EXISTS idx_short_links_health_status;
+ALTER TABLE short_links DROP COLUMN health_checked_at;
+ALTER TABLE short_links DROP COLUMN health_status;
+DROP TABLE IF EXISTS link_health_checks;
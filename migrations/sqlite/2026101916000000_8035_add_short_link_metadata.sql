@@ -0,0 +1,22 @@
+-- +goose Up
+CREATE TABLE short_link_metadata (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  workspace_id INTEGER NOT NULL,
+  short_link_id INTEGER NOT NULL,
+  source_url TEXT NOT NULL,
+  title TEXT,
+  description TEXT,
+  image_url TEXT,
+  favicon_url TEXT,
+  status TEXT NOT NULL,
+  error TEXT,
+  fetched_at DATETIME,
+  created_at DATETIME,
+  updated_at DATETIME
+);
+
+CREATE UNIQUE INDEX uk_short_link_metadata_short_link_id ON short_link_metadata(short_link_id);
+CREATE INDEX idx_short_link_metadata_workspace_id ON short_link_metadata(workspace_id);
+
+-- +goose Down
+DROP TABLE IF EXISTS short_link_metadata;
@@ -0,0 +1,16 @@
+-- +goose Up
+ALTER TABLE workspaces ADD COLUMN require_link_approval BOOLEAN NOT NULL DEFAULT FALSE;
+
+ALTER TABLE short_links ADD COLUMN review_status TEXT;
+ALTER TABLE short_links ADD COLUMN review_comment TEXT;
+ALTER TABLE short_links ADD COLUMN reviewed_by INTEGER;
+ALTER TABLE short_links ADD COLUMN reviewed_at DATETIME;
+CREATE INDEX idx_short_links_review_status ON short_links(review_status);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_short_links_review_status;
+ALTER TABLE short_links DROP COLUMN reviewed_at;
+ALTER TABLE short_links DROP COLUMN reviewed_by;
+ALTER TABLE short_links DROP COLUMN review_comment;
+ALTER TABLE short_links DROP COLUMN review_status;
+ALTER TABLE workspaces DROP COLUMN require_link_approval;
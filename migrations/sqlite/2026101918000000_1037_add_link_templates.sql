@@ -0,0 +1,21 @@
+-- +goose Up
+CREATE TABLE link_templates (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  workspace_id INTEGER NOT NULL,
+  name TEXT NOT NULL,
+  description TEXT,
+  is_default BOOLEAN NOT NULL DEFAULT FALSE,
+  settings TEXT,
+  created_by INTEGER,
+  updated_by INTEGER,
+  created_at DATETIME,
+  updated_at DATETIME,
+  deleted_at DATETIME
+);
+
+CREATE INDEX idx_link_templates_workspace_id ON link_templates(workspace_id);
+CREATE INDEX idx_link_templates_created_by ON link_templates(created_by);
+CREATE INDEX idx_link_templates_deleted_at ON link_templates(deleted_at);
+
+-- +goose Down
+DROP TABLE IF EXISTS link_templates;
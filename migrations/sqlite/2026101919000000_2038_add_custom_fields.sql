@@ -0,0 +1,46 @@
+-- +goose Up
+CREATE TABLE custom_fields (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  workspace_id INTEGER NOT NULL,
+  field_key TEXT NOT NULL,
+  name TEXT NOT NULL,
+  description TEXT,
+  field_type TEXT NOT NULL,
+  options TEXT,
+  required BOOLEAN NOT NULL DEFAULT FALSE,
+  max_length INTEGER NOT NULL DEFAULT 0,
+  min_value REAL,
+  max_value REAL,
+  position INTEGER NOT NULL DEFAULT 0,
+  created_by INTEGER,
+  updated_by INTEGER,
+  created_at DATETIME,
+  updated_at DATETIME,
+  deleted_at DATETIME
+);
+
+CREATE INDEX idx_custom_fields_workspace_id ON custom_fields(workspace_id);
+CREATE INDEX idx_custom_fields_field_key ON custom_fields(field_key);
+CREATE INDEX idx_custom_fields_created_by ON custom_fields(created_by);
+CREATE INDEX idx_custom_fields_deleted_at ON custom_fields(deleted_at);
+
+CREATE TABLE short_link_custom_values (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  workspace_id INTEGER NOT NULL,
+  short_link_id INTEGER NOT NULL,
+  field_id INTEGER NOT NULL,
+  field_key TEXT NOT NULL,
+  value TEXT NOT NULL,
+  number_value REAL,
+  created_at DATETIME,
+  updated_at DATETIME
+);
+
+CREATE UNIQUE INDEX idx_short_link_custom_values_link_field ON short_link_custom_values(short_link_id, field_id);
+CREATE INDEX idx_short_link_custom_values_workspace_id ON short_link_custom_values(workspace_id);
+CREATE INDEX idx_short_link_custom_values_field_id ON short_link_custom_values(field_id);
+CREATE INDEX idx_short_link_custom_values_field_key ON short_link_custom_values(field_key);
+
+-- +goose Down
+DROP TABLE IF EXISTS short_link_custom_values;
+DROP TABLE IF EXISTS custom_fields;
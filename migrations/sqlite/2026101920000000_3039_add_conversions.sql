@@ -0,0 +1,43 @@
+-- +goose Up
+ALTER TABLE workspaces ADD COLUMN conversion_secret TEXT;
+
+ALTER TABLE short_links ADD COLUMN append_click_id BOOLEAN NOT NULL DEFAULT FALSE;
+
+ALTER TABLE click_statistics ADD COLUMN click_id TEXT;
+CREATE INDEX idx_click_statistics_click_id ON click_statistics(click_id);
+
+CREATE TABLE conversions (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  workspace_id INTEGER NOT NULL,
+  short_link_id INTEGER NOT NULL,
+  campaign_id INTEGER,
+  route_id INTEGER,
+  click_statistic_id INTEGER NOT NULL,
+  click_id TEXT NOT NULL,
+  event TEXT NOT NULL,
+  event_id TEXT NOT NULL,
+  value REAL,
+  currency TEXT,
+  source TEXT NOT NULL,
+  metadata TEXT,
+  ip TEXT,
+  user_agent TEXT,
+  clicked_at DATETIME,
+  occurred_at DATETIME NOT NULL,
+  created_at DATETIME
+);
+
+CREATE UNIQUE INDEX uk_conversions_event ON conversions(workspace_id, event_id);
+CREATE INDEX idx_conversions_short_link_id ON conversions(short_link_id);
+CREATE INDEX idx_conversions_campaign_id ON conversions(campaign_id);
+CREATE INDEX idx_conversions_route_id ON conversions(route_id);
+CREATE INDEX idx_conversions_click_statistic_id ON conversions(click_statistic_id);
+CREATE INDEX idx_conversions_click_id ON conversions(click_id);
+CREATE INDEX idx_conversions_occurred_at ON conversions(occurred_at);
+
+-- +goose Down
+DROP TABLE IF EXISTS conversions;
+DROP INDEX IF EXISTS idx_click_statistics_click_id;
+ALTER TABLE click_statistics DROP COLUMN click_id;
+ALTER TABLE short_links DROP COLUMN append_click_id;
+ALTER TABLE workspaces DROP COLUMN conversion_secret;
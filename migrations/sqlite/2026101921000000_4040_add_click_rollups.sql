@@ -0,0 +1,31 @@
+-- +goose Up
+CREATE TABLE click_rollups (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  workspace_id INTEGER NOT NULL,
+  short_link_id INTEGER NOT NULL,
+  campaign_id INTEGER NOT NULL DEFAULT 0,
+  is_bot BOOLEAN NOT NULL DEFAULT FALSE,
+  granularity TEXT NOT NULL,
+  bucket_start DATETIME NOT NULL,
+  dimension TEXT NOT NULL,
+  country TEXT NOT NULL DEFAULT '',
+  province TEXT NOT NULL DEFAULT '',
+  dimension_value TEXT NOT NULL DEFAULT '',
+  label TEXT,
+  clicks INTEGER NOT NULL DEFAULT 0,
+  updated_at DATETIME
+);
+
+CREATE UNIQUE INDEX uk_click_rollups_key ON click_rollups(workspace_id, short_link_id, campaign_id, is_bot, granularity, bucket_start, dimension, country, province, dimension_value);
+CREATE INDEX idx_click_rollups_short_link_id ON click_rollups(short_link_id);
+CREATE INDEX idx_click_rollups_bucket_start ON click_rollups(bucket_start);
+
+CREATE TABLE click_rollup_cursors (
+  name TEXT PRIMARY KEY,
+  last_click_id INTEGER NOT NULL DEFAULT 0,
+  updated_at DATETIME
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS click_rollup_cursors;
+DROP TABLE IF EXISTS click_rollups;
@@ -0,0 +1,27 @@
+-- +goose Up
+ALTER TABLE click_statistics ADD COLUMN visitor_key TEXT;
+CREATE INDEX idx_click_statistics_visitor_key ON click_statistics(visitor_key);
+
+CREATE TABLE visitor_salts (
+  day TEXT PRIMARY KEY,
+  salt TEXT NOT NULL,
+  created_at DATETIME
+);
+
+CREATE TABLE visitor_sketches (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  workspace_id INTEGER NOT NULL,
+  short_link_id INTEGER NOT NULL DEFAULT 0,
+  day DATETIME NOT NULL,
+  registers BLOB NOT NULL,
+  updated_at DATETIME
+);
+
+CREATE UNIQUE INDEX uk_visitor_sketches_key ON visitor_sketches(workspace_id, short_link_id, day);
+CREATE INDEX idx_visitor_sketches_day ON visitor_sketches(day);
+
+-- +goose Down
+DROP TABLE IF EXISTS visitor_sketches;
+DROP TABLE IF EXISTS visitor_salts;
+DROP INDEX IF EXISTS idx_click_statistics_visitor_key;
+ALTER TABLE click_statistics DROP COLUMN visitor_key;
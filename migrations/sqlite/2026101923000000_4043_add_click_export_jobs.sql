@@ -0,0 +1,30 @@
+-- +goose Up
+CREATE TABLE click_export_jobs (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  workspace_id INTEGER NOT NULL,
+  user_id INTEGER NOT NULL DEFAULT 0,
+  format TEXT NOT NULL,
+  filters TEXT,
+  status TEXT NOT NULL,
+  max_click_id INTEGER NOT NULL DEFAULT 0,
+  last_click_id INTEGER NOT NULL DEFAULT 0,
+  row_count INTEGER NOT NULL DEFAULT 0,
+  file_name TEXT,
+  file_size INTEGER NOT NULL DEFAULT 0,
+  error TEXT,
+  download_token TEXT,
+  claim_version INTEGER NOT NULL DEFAULT 0,
+  heartbeat_at DATETIME,
+  expires_at DATETIME,
+  started_at DATETIME,
+  finished_at DATETIME,
+  created_at DATETIME,
+  updated_at DATETIME
+);
+
+CREATE INDEX idx_click_export_jobs_workspace_id ON click_export_jobs(workspace_id);
+CREATE INDEX idx_click_export_jobs_status ON click_export_jobs(status);
+CREATE INDEX idx_click_export_jobs_download_token ON click_export_jobs(download_token);
+
+-- +goose Down
+DROP TABLE IF EXISTS click_export_jobs;
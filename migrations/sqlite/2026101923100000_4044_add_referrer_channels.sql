@@ -0,0 +1,30 @@
+-- +goose Up
+ALTER TABLE click_statistics ADD COLUMN referer_host TEXT;
+ALTER TABLE click_statistics ADD COLUMN referer_channel TEXT;
+CREATE INDEX idx_click_statistics_referer_host ON click_statistics(referer_host);
+CREATE INDEX idx_click_statistics_referer_channel ON click_statistics(referer_channel);
+
+CREATE TABLE referrer_rules (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  workspace_id INTEGER NOT NULL,
+  match_type TEXT NOT NULL,
+  pattern TEXT NOT NULL,
+  channel TEXT NOT NULL,
+  priority INTEGER NOT NULL DEFAULT 0,
+  enabled BOOLEAN NOT NULL DEFAULT TRUE,
+  created_by INTEGER,
+  created_at DATETIME,
+  updated_at DATETIME,
+  deleted_at DATETIME
+);
+
+CREATE INDEX idx_referrer_rules_workspace_id ON referrer_rules(workspace_id);
+CREATE INDEX idx_referrer_rules_created_by ON referrer_rules(created_by);
+CREATE INDEX idx_referrer_rules_deleted_at ON referrer_rules(deleted_at);
+
+-- +goose Down
+DROP TABLE IF EXISTS referrer_rules;
+DROP INDEX IF EXISTS idx_click_statistics_referer_channel;
+DROP INDEX IF EXISTS idx_click_statistics_referer_host;
+ALTER TABLE click_statistics DROP COLUMN referer_channel;
+ALTER TABLE click_statistics DROP COLUMN referer_host;
@@ -0,0 +1,84 @@
+-- +goose Up
+CREATE TABLE traffic_alert_settings (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  workspace_id INTEGER NOT NULL,
+  enabled BOOLEAN NOT NULL DEFAULT TRUE,
+  window_minutes INTEGER NOT NULL DEFAULT 60,
+  baseline_days INTEGER NOT NULL DEFAULT 7,
+  spike_factor REAL NOT NULL DEFAULT 3,
+  spike_min_clicks INTEGER NOT NULL DEFAULT 100,
+  drop_min_hourly_clicks REAL NOT NULL DEFAULT 10,
+  bot_ratio_delta REAL NOT NULL DEFAULT 0.3,
+  country_shift_threshold REAL NOT NULL DEFAULT 0.5,
+  mix_min_clicks INTEGER NOT NULL DEFAULT 50,
+  cooldown_minutes INTEGER NOT NULL DEFAULT 360,
+  created_at DATETIME,
+  updated_at DATETIME
+);
+
+CREATE UNIQUE INDEX idx_traffic_alert_settings_workspace_id ON traffic_alert_settings(workspace_id);
+
+CREATE TABLE alert_channels (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  workspace_id INTEGER NOT NULL,
+  name TEXT NOT NULL,
+  type TEXT NOT NULL,
+  url TEXT NOT NULL,
+  secret TEXT,
+  alert_types TEXT,
+  enabled BOOLEAN NOT NULL DEFAULT TRUE,
+  created_by INTEGER,
+  created_at DATETIME,
+  updated_at DATETIME,
+  deleted_at DATETIME
+);
+
+CREATE INDEX idx_alert_channels_workspace_id ON alert_channels(workspace_id);
+CREATE INDEX idx_alert_channels_created_by ON alert_channels(created_by);
+CREATE INDEX idx_alert_channels_deleted_at ON alert_channels(deleted_at);
+
+CREATE TABLE traffic_alerts (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  workspace_id INTEGER NOT NULL,
+  short_link_id INTEGER NOT NULL DEFAULT 0,
+  alert_type TEXT NOT NULL,
+  dedupe_bucket INTEGER NOT NULL DEFAULT 0,
+  message TEXT,
+  observed REAL NOT NULL DEFAULT 0,
+  baseline REAL NOT NULL DEFAULT 0,
+  details TEXT,
+  window_start DATETIME,
+  window_end DATETIME,
+  acknowledged_by INTEGER,
+  acknowledged_at DATETIME,
+  created_at DATETIME
+);
+
+CREATE UNIQUE INDEX uk_traffic_alerts_dedupe ON traffic_alerts(workspace_id, short_link_id, alert_type, dedupe_bucket);
+CREATE INDEX idx_traffic_alerts_workspace_id ON traffic_alerts(workspace_id);
+CREATE INDEX idx_traffic_alerts_short_link_id ON traffic_alerts(short_link_id);
+CREATE INDEX idx_traffic_alerts_created_at ON traffic_alerts(created_at);
+
+CREATE TABLE traffic_alert_deliveries (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  alert_id INTEGER NOT NULL,
+  channel_id INTEGER NOT NULL,
+  status TEXT NOT NULL,
+  attempts INTEGER NOT NULL DEFAULT 0,
+  last_error TEXT,
+  next_attempt_at DATETIME,
+  delivered_at DATETIME,
+  created_at DATETIME,
+  updated_at DATETIME
+);
+
+CREATE INDEX idx_traffic_alert_deliveries_alert_id ON traffic_alert_deliveries(alert_id);
+CREATE INDEX idx_traffic_alert_deliveries_channel_id ON traffic_alert_deliveries(channel_id);
+CREATE INDEX idx_traffic_alert_deliveries_status ON traffic_alert_deliveries(status);
+CREATE INDEX idx_traffic_alert_deliveries_next_attempt_at ON traffic_alert_deliveries(next_attempt_at);
+
+-- +goose Down
+DROP TABLE IF EXISTS traffic_alert_deliveries;
+DROP TABLE IF EXISTS traffic_alerts;
+DROP TABLE IF EXISTS alert_channels;
+DROP TABLE IF EXISTS traffic_alert_settings;
@@ -0,0 +1,16 @@
+-- +goose Up
+ALTER TABLE workspaces ADD COLUMN anonymize_ip BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE workspaces ADD COLUMN click_retention_days INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE workspaces ADD COLUMN ab_test_click_retention_days INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE workspaces ADD COLUMN security_event_retention_days INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE workspaces ADD COLUMN operation_log_retention_days INTEGER NOT NULL DEFAULT 0;
+
+CREATE INDEX idx_click_statistics_workspace_date ON click_statistics(workspace_id, click_date);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_click_statistics_workspace_date;
+ALTER TABLE workspaces DROP COLUMN operation_log_retention_days;
+ALTER TABLE workspaces DROP COLUMN security_event_retention_days;
+ALTER TABLE workspaces DROP COLUMN ab_test_click_retention_days;
+ALTER TABLE workspaces DROP COLUMN click_retention_days;
+ALTER TABLE workspaces DROP COLUMN anonymize_ip;
@@ -0,0 +1,26 @@
+-- +goose Up
+CREATE TABLE data_subject_requests (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  workspace_id INTEGER NOT NULL,
+  action TEXT NOT NULL,
+  ip TEXT,
+  visitor_key TEXT,
+  subject TEXT,
+  status TEXT NOT NULL,
+  result TEXT,
+  error TEXT,
+  requested_by INTEGER,
+  claim_version INTEGER NOT NULL DEFAULT 0,
+  heartbeat_at DATETIME,
+  started_at DATETIME,
+  finished_at DATETIME,
+  created_at DATETIME,
+  updated_at DATETIME
+);
+
+CREATE INDEX idx_data_subject_requests_workspace_id ON data_subject_requests(workspace_id);
+CREATE INDEX idx_data_subject_requests_status ON data_subject_requests(status);
+CREATE INDEX idx_data_subject_requests_requested_by ON data_subject_requests(requested_by);
+
+-- +goose Down
+DROP TABLE IF EXISTS data_subject_requests;
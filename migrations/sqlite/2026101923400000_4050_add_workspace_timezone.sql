@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE workspaces ADD COLUMN timezone TEXT;
+
+-- +goose Down
+ALTER TABLE workspaces DROP COLUMN timezone;
@@ -0,0 +1,25 @@
+-- +goose Up
+CREATE TABLE click_rollup_rebuild_jobs (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  workspace_id INTEGER NOT NULL,
+  start_date DATETIME,
+  end_date DATETIME,
+  next_date DATETIME,
+  status TEXT NOT NULL,
+  clicks INTEGER NOT NULL DEFAULT 0,
+  error TEXT,
+  requested_by INTEGER,
+  claim_version INTEGER NOT NULL DEFAULT 0,
+  heartbeat_at DATETIME,
+  started_at DATETIME,
+  finished_at DATETIME,
+  created_at DATETIME,
+  updated_at DATETIME
+);
+
+CREATE INDEX idx_click_rollup_rebuild_jobs_workspace_id ON click_rollup_rebuild_jobs(workspace_id);
+CREATE INDEX idx_click_rollup_rebuild_jobs_status ON click_rollup_rebuild_jobs(status);
+CREATE INDEX idx_click_rollup_rebuild_jobs_requested_by ON click_rollup_rebuild_jobs(requested_by);
+
+-- +goose Down
+DROP TABLE IF EXISTS click_rollup_rebuild_jobs;
@@ -0,0 +1,13 @@
+-- +goose Up
+CREATE TABLE click_export_chunks (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  job_id INTEGER NOT NULL,
+  seq INTEGER NOT NULL,
+  data BLOB NOT NULL,
+  created_at DATETIME
+);
+
+CREATE UNIQUE INDEX idx_click_export_chunks_job_seq ON click_export_chunks(job_id, seq);
+
+-- +goose Down
+DROP TABLE IF EXISTS click_export_chunks;
@@ -0,0 +1,83 @@
+// Package hyperloglog 提供可合并的基数估计，用于统计独立访客
+package hyperloglog
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"math"
+	"math/bits"
+)
+
+const (
+	// Precision 寄存器位数，4096 个寄存器，标准误差约 1.6%
+	Precision = 12
+	// Size 序列化后的字节数
+	Size = 1 << Precision
+)
+
+// Sketch HyperLogLog 草图，每个寄存器占一个字节
+type Sketch struct {
+	registers []byte
+}
+
+// New 创建空草图
+func New() *Sketch {
+	return &Sketch{registers: make([]byte, Size)}
+}
+
+// FromBytes 从序列化数据恢复草图
+func FromBytes(data []byte) (*Sketch, error) {
+	if len(data) != Size {
+		return nil, errors.New("hyperloglog: 草图数据长度错误")
+	}
+	registers := make([]byte, Size)
+	copy(registers, data)
+	return &Sketch{registers: registers}, nil
+}
+
+// Add 加入一个元素，重复加入不影响结果
+func (s *Sketch) Add(value string) {
+	sum := sha256.Sum256([]byte(value))
+	hash := binary.BigEndian.Uint64(sum[:8])
+	index := hash >> (64 - Precision)
+	rank := byte(bits.LeadingZeros64(hash<<Precision|1<<(Precision-1)) + 1)
+	if rank > s.registers[index] {
+		s.registers[index] = rank
+	}
+}
+
+// Merge 合并另一个草图，结果为两者并集的估计
+func (s *Sketch) Merge(other *Sketch) {
+	for i, rank := range other.registers {
+		if rank > s.registers[i] {
+			s.registers[i] = rank
+		}
+	}
+}
+
+// Count 估计元素个数
+func (s *Sketch) Count() int64 {
+	m := float64(Size)
+	sum := 0.0
+	zeros := 0
+	for _, rank := range s.registers {
+		sum += math.Ldexp(1, -int(rank))
+		if rank == 0 {
+			zeros++
+		}
+	}
+	estimate := 0.7213 / (1 + 1.079/m) * m * m / sum
+	if estimate <= 2.5*m && zeros > 0 {
+		// 基数较小时使用线性计数
+		estimate = m * math.Log(m/float64(zeros))
+	}
+	return int64(math.Round(estimate))
+}
+
+// Bytes 序列化草图
+func (s *Sketch) Bytes() []byte {
+	data := make([]byte, Size)
+	copy(data, s.registers)
+	return data
+}
@@ -0,0 +1,37 @@
+package hyperloglog
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestSketchEstimatesAndMerges(t *testing.T) {
+	for _, n := range []int{0, 1, 100, 5000, 100000} {
+		sketch := New()
+		for i := 0; i < n; i++ {
+			sketch.Add(fmt.Sprintf("visitor-%d", i))
+			sketch.Add(fmt.Sprintf("visitor-%d", i))
+		}
+		count := sketch.Count()
+		if diff := float64(count - int64(n)); diff > float64(n)*0.05+1 || diff < -float64(n)*0.05-1 {
+			t.Fatalf("estimate for %d off: %d", n, count)
+		}
+	}
+
+	a, b := New(), New()
+	for i := 0; i < 3000; i++ {
+		a.Add(fmt.Sprintf("v-%d", i))
+		b.Add(fmt.Sprintf("v-%d", i+2000))
+	}
+	restored, err := FromBytes(a.Bytes())
+	if err != nil {
+		t.Fatalf("restore: %v", err)
+	}
+	restored.Merge(b)
+	if count := restored.Count(); count < 4750 || count > 5250 {
+		t.Fatalf("union estimate off: %d", count)
+	}
+	if _, err := FromBytes([]byte{1, 2}); err == nil {
+		t.Fatal("invalid data must be rejected")
+	}
+}
@@ -0,0 +1,133 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"cnb.cool/mliev/dwz/dwz-server/v2/app/service"
+	"cnb.cool/mliev/dwz/dwz-server/v2/pkg/interfaces"
+)
+
+// DefaultJobs 返回 CE 内置的后台任务。
+func DefaultJobs() []Job {
+	return []Job{
+		{Name: "回收站清理", Interval: time.Hour, Run: purgeExpiredTrash},
+		{Name: "幂等键清理", Interval: time.Hour, Run: purgeExpiredIdempotencyKeys},
+		{Name: "目标地址健康检查", Interval: 5 * time.Minute, Run: checkLinkHealth},
+		{Name: "点击统计汇总", Interval: time.Minute, Run: rollupClickStatistics},
+		{Name: "点击汇总重建", Interval: 30 * time.Second, Run: rebuildClickRollups},
+		{Name: "访客盐值清理", Interval: time.Hour, Run: purgeExpiredVisitorSalts},
+		{Name: "点击明细导出", Interval: 30 * time.Second, Run: runClickExports},
+		{Name: "导出文件清理", Interval: time.Hour, Run: purgeClickExportFiles},
+		{Name: "点击来源分类回填", Interval: 5 * time.Minute, Run: backfillReferrerChannels},
+		{Name: "流量异常检测", Interval: 5 * time.Minute, Run: detectTrafficAnomalies},
+		{Name: "流量告警投递", Interval: 30 * time.Second, Run: deliverTrafficAlerts},
+		{Name: "数据保留清理", Interval: time.Hour, Run: purgeExpiredData},
+		{Name: "个人数据删除任务", Interval: 30 * time.Second, Run: processDataSubjectRequests},
+	}
+}
+
+func purgeExpiredTrash(_ context.Context, h interfaces.HelperInterface) error {
+	purged, err := service.NewShortLinkService(h, context.Background()).PurgeExpiredTrash()
+	if purged > 0 {
+		h.GetLogger().Info(fmt.Sprintf("[scheduler] 回收站已自动清理 %d 条短网址", purged))
+	}
+	return err
+}
+
+func purgeExpiredIdempotencyKeys(_ context.Context, h interfaces.HelperInterface) error {
+	purged, err := service.NewIdempotencyService(h).PurgeExpired()
+	if purged > 0 {
+		h.GetLogger().Info(fmt.Sprintf("[scheduler] 已清理 %d 条过期幂等键", purged))
+	}
+	return err
+}
+
+func checkLinkHealth(_ context.Context, h interfaces.HelperInterface) error {
+	checked, err := service.NewLinkHealthService(h).CheckDueShortLinks()
+	if checked > 0 {
+		h.GetLogger().Info(fmt.Sprintf("[scheduler] 已检查 %d 条短网址的目标地址", checked))
+	}
+	return err
+}
+
+func rollupClickStatistics(_ context.Context, h interfaces.HelperInterface) error {
+	processed, err := service.NewClickRollupService(h).ProcessPending()
+	if processed > 0 {
+		h.GetLogger().Info(fmt.Sprintf("[scheduler] 已汇总 %d 条点击记录", processed))
+	}
+	return err
+}
+
+func rebuildClickRollups(_ context.Context, h interfaces.HelperInterface) error {
+	rebuilt, err := service.NewClickRollupService(h).ProcessRebuildJobs()
+	if rebuilt > 0 {
+		h.GetLogger().Info(fmt.Sprintf("[scheduler] 已重建 %d 条点击记录的汇总", rebuilt))
+	}
+	return err
+}
+
+func purgeExpiredVisitorSalts(_ context.Context, h interfaces.HelperInterface) error {
+	purged, err := service.NewVisitorService(h).PurgeExpiredSalts()
+	if purged > 0 {
+		h.GetLogger().Info(fmt.Sprintf("[scheduler] 已删除 %d 个过期访客盐值", purged))
+	}
+	return err
+}
+
+func runClickExports(ctx context.Context, h interfaces.HelperInterface) error {
+	exported, err := service.NewClickExportService(h).ProcessPending(ctx)
+	if exported > 0 {
+		h.GetLogger().Info(fmt.Sprintf("[scheduler] 已导出 %d 条点击明细", exported))
+	}
+	return err
+}
+
+func purgeClickExportFiles(_ context.Context, h interfaces.HelperInterface) error {
+	purged, err := service.NewClickExportService(h).PurgeFiles()
+	if purged > 0 {
+		h.GetLogger().Info(fmt.Sprintf("[scheduler] 已删除 %d 个过期导出文件", purged))
+	}
+	return err
+}
+
+func backfillReferrerChannels(_ context.Context, h interfaces.HelperInterface) error {
+	classified, err := service.NewReferrerService(h).BackfillPending()
+	if classified > 0 {
+		h.GetLogger().Info(fmt.Sprintf("[scheduler] 已为 %d 条历史点击补充来源渠道", classified))
+	}
+	return err
+}
+
+func detectTrafficAnomalies(_ context.Context, h interfaces.HelperInterface) error {
+	created, err := service.NewTrafficAlertService(h).DetectAnomalies(time.Now())
+	if created > 0 {
+		h.GetLogger().Info(fmt.Sprintf("[scheduler] 发现 %d 个流量异常", created))
+	}
+	return err
+}
+
+func deliverTrafficAlerts(_ context.Context, h interfaces.HelperInterface) error {
+	delivered, err := service.NewTrafficAlertService(h).DeliverPending(time.Now())
+	if delivered > 0 {
+		h.GetLogger().Info(fmt.Sprintf("[scheduler] 已投递 %d 条流量告警", delivered))
+	}
+	return err
+}
+
+func purgeExpiredData(_ context.Context, h interfaces.HelperInterface) error {
+	purged, err := service.NewDataRetentionService(h).PurgeExpired(time.Now())
+	if purged > 0 {
+		h.GetLogger().Info(fmt.Sprintf("[scheduler] 已删除 %d 条超过保留期的数据", purged))
+	}
+	return err
+}
+
+func processDataSubjectRequests(_ context.Context, h interfaces.HelperInterface) error {
+	processed, err := service.NewDataSubjectService(h).ProcessPending()
+	if processed > 0 {
+		h.GetLogger().Info(fmt.Sprintf("[scheduler] 已删除或匿名化 %d 条个人数据", processed))
+	}
+	return err
+}
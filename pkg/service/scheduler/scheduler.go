@@ -0,0 +1,89 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"cnb.cool/mliev/dwz/dwz-server/v2/pkg/helper"
+	"cnb.cool/mliev/dwz/dwz-server/v2/pkg/interfaces"
+)
+
+// Job 是一个周期执行的后台任务。ctx 在 Stop() 时取消，耗时较长的任务应据此尽快返回。
+type Job struct {
+	Name     string
+	Interval time.Duration
+	Run      func(ctx context.Context, h interfaces.HelperInterface) error
+}
+
+// Scheduler implements go-web's ServerInterface. Run() starts one goroutine
+// per job once the app is installed and returns immediately; Stop() cancels
+// the jobs' context and waits for any in-flight round to return.
+type Scheduler struct {
+	Jobs []Job
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func (s *Scheduler) Run() error {
+	h := helper.GetHelper()
+	logger := h.GetLogger()
+
+	if installed := h.GetInstalled(); installed == nil || !installed.IsInstalled() {
+		logger.Info("[scheduler] 应用未安装，跳过后台任务")
+		return nil
+	}
+
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+	for _, job := range s.Jobs {
+		if job.Interval <= 0 || job.Run == nil {
+			continue
+		}
+		s.wg.Add(1)
+		go s.loop(h, job)
+		logger.Info(fmt.Sprintf("[scheduler] 已启动后台任务: %s (间隔 %s)", job.Name, job.Interval))
+	}
+	return nil
+}
+
+func (s *Scheduler) Stop() error {
+	if s.cancel == nil {
+		return nil
+	}
+	s.cancel()
+	s.wg.Wait()
+	s.cancel = nil
+	return nil
+}
+
+func (s *Scheduler) loop(h interfaces.HelperInterface, job Job) {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(job.Interval)
+	defer ticker.Stop()
+
+	s.runOnce(h, job)
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+			s.runOnce(h, job)
+		}
+	}
+}
+
+// runOnce 执行一次任务，panic 不应拖垮整个进程。
+func (s *Scheduler) runOnce(h interfaces.HelperInterface, job Job) {
+	defer func() {
+		if r := recover(); r != nil {
+			h.GetLogger().Error(fmt.Sprintf("[scheduler] 任务 %s panic: %v", job.Name, r))
+		}
+	}()
+	if err := job.Run(s.ctx, h); err != nil {
+		h.GetLogger().Error(fmt.Sprintf("[scheduler] 任务 %s 执行失败: %s", job.Name, err.Error()))
+	}
+}
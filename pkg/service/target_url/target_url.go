@@ -0,0 +1,56 @@
+// Package target_url 规范化短网址目标地址并计算指纹，供业务代码与数据迁移共用
+package target_url
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net"
+	"net/url"
+	"strings"
+)
+
+// Normalize 规范化目标 URL：scheme 与 host 转小写、去掉默认端口和 host 末尾的点、
+// 空路径补为 "/"、query 按参数名排序。缺少 scheme 或 host 时返回错误。
+func Normalize(rawURL string) (string, error) {
+	parsedURL, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return "", err
+	}
+	if parsedURL.Scheme == "" || parsedURL.Host == "" {
+		return "", errors.New("missing URL scheme or host")
+	}
+	scheme := strings.ToLower(parsedURL.Scheme)
+	host := strings.TrimSuffix(strings.ToLower(parsedURL.Hostname()), ".")
+	port := parsedURL.Port()
+	if (scheme == "http" && port == "80") || (scheme == "https" && port == "443") {
+		port = ""
+	}
+	switch {
+	case port != "":
+		host = net.JoinHostPort(host, port)
+	case strings.Contains(host, ":"):
+		host = "[" + host + "]"
+	}
+
+	parsedURL.Scheme = scheme
+	parsedURL.Host = host
+	if parsedURL.Path == "" {
+		parsedURL.Path = "/"
+		parsedURL.RawPath = ""
+	}
+	if parsedURL.RawQuery != "" {
+		parsedURL.RawQuery = parsedURL.Query().Encode()
+	}
+	return parsedURL.String(), nil
+}
+
+// Fingerprint 规范化 URL 的 sha256，用于按目标地址检索短网址；无法解析时按原文计算
+func Fingerprint(rawURL string) string {
+	normalized, err := Normalize(rawURL)
+	if err != nil {
+		normalized = strings.TrimSpace(rawURL)
+	}
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}